@@ -3,33 +3,84 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"time"
 
 	"github.com/alecthomas/kong"
 	buildkitelogs "github.com/buildkite/buildkite-logs"
 	"github.com/buildkite/buildkite-mcp-server/internal/commands"
+	"github.com/buildkite/buildkite-mcp-server/pkg/audit"
+	"github.com/buildkite/buildkite-mcp-server/pkg/breaker"
+	"github.com/buildkite/buildkite-mcp-server/pkg/cache"
+	"github.com/buildkite/buildkite-mcp-server/pkg/logcache"
+	"github.com/buildkite/buildkite-mcp-server/pkg/logsink"
+	"github.com/buildkite/buildkite-mcp-server/pkg/policy"
+	"github.com/buildkite/buildkite-mcp-server/pkg/ratelimit"
+	"github.com/buildkite/buildkite-mcp-server/pkg/redact"
+	"github.com/buildkite/buildkite-mcp-server/pkg/retry"
+	"github.com/buildkite/buildkite-mcp-server/pkg/scrub"
+	"github.com/buildkite/buildkite-mcp-server/pkg/server"
+	"github.com/buildkite/buildkite-mcp-server/pkg/tokens"
+	"github.com/buildkite/buildkite-mcp-server/pkg/toolsets"
 	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
-	gobuildkite "github.com/buildkite/go-buildkite/v4"
 	"github.com/mattn/go-isatty"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 )
 
 var (
 	version = "dev"
 
 	cli struct {
-		Stdio                 commands.StdioCmd `cmd:"" help:"stdio mcp server."`
-		HTTP                  commands.HTTPCmd  `cmd:"" help:"http mcp server. (pass --use-sse to use SSE transport"`
-		Tools                 commands.ToolsCmd `cmd:"" help:"list available tools." hidden:""`
-		APIToken              string            `help:"The Buildkite API token to use." env:"BUILDKITE_API_TOKEN"`
-		APITokenFrom1Password string            `help:"The 1Password item to read the Buildkite API token from. Format: 'op://vault/item/field'" env:"BUILDKITE_API_TOKEN_FROM_1PASSWORD"`
-		BaseURL               string            `help:"The base URL of the Buildkite API to use." env:"BUILDKITE_BASE_URL" default:"https://api.buildkite.com/"`
-		CacheURL              string            `help:"The blob storage URL for job logs cache." env:"BKLOG_CACHE_URL"`
-		Debug                 bool              `help:"Enable debug mode." env:"DEBUG"`
-		OTELExporter          string            `help:"OpenTelemetry exporter to enable. Options are 'http/protobuf', 'grpc', or 'noop'." enum:"http/protobuf, grpc, noop" env:"OTEL_EXPORTER_OTLP_PROTOCOL" default:"noop"`
-		HTTPHeaders           []string          `help:"Additional HTTP headers to send with every request. Format: 'Key: Value'" name:"http-header" env:"BUILDKITE_HTTP_HEADERS"`
+		Stdio                 commands.StdioCmd        `cmd:"" help:"stdio mcp server."`
+		HTTP                  commands.HTTPCmd         `cmd:"" help:"http mcp server. (pass --use-sse to use SSE transport, send SIGHUP to reload toolsets, policies, redaction and scrubbing rules)"`
+		Tools                 commands.ToolsCmd        `cmd:"" help:"list available tools." hidden:""`
+		ExportAuditLog        commands.AuditCmd        `cmd:"" help:"export a verifiable bundle of audit records for a time range."`
+		APIToken              string                   `help:"The Buildkite API token to use." env:"BUILDKITE_API_TOKEN"`
+		APITokenFrom1Password string                   `help:"The 1Password item to read the Buildkite API token from. Format: 'op://vault/item/field'" env:"BUILDKITE_API_TOKEN_FROM_1PASSWORD"`
+		BaseURL               string                   `help:"The base URL of the Buildkite API to use." env:"BUILDKITE_BASE_URL" default:"https://api.buildkite.com/"`
+		GraphQLURL            string                   `help:"The URL of the Buildkite GraphQL API used by the graphql toolset." name:"graphql-url" env:"BUILDKITE_GRAPHQL_URL" default:"https://graphql.buildkite.com/v1"`
+		GraphQLToken          string                   `help:"The Buildkite API token used for the GraphQL API, which needs GraphQL access enabled. Defaults to the API token." name:"graphql-token" env:"BUILDKITE_GRAPHQL_TOKEN"`
+		CacheURL              string                   `help:"The blob storage URL for job logs cache." env:"BKLOG_CACHE_URL"`
+		CacheBackend          string                   `help:"Cache backend shared by every replica of the server, e.g. 'redis://localhost:6379/0'. Job logs are cached there for 24h instead of in the blob storage URL, unless a ttl is given, e.g. 'redis://localhost:6379/0?ttl=12h'." env:"BUILDKITE_CACHE_BACKEND"`
+		CacheTTL              time.Duration            `help:"How long responses of read-only Buildkite API calls are cached for, after which they're revalidated with their ETag or Last-Modified header. Writes made through the server revalidate every cached response. 0 disables the cache." name:"cache-ttl" env:"BUILDKITE_CACHE_TTL" default:"0s"`
+		ResponseCacheDir      string                   `help:"Directory to keep cached API responses in, so they outlive the server. Defaults to keeping them in memory." env:"BUILDKITE_RESPONSE_CACHE_DIR"`
+		ResponseCacheMaxBytes int64                    `help:"Size in bytes the API responses cached in memory are kept within, evicting others to make room. 0 disables the limit." env:"BUILDKITE_RESPONSE_CACHE_MAX_BYTES" default:"67108864"`
+		LogCacheMaxBytes      int64                    `help:"Size in bytes the job logs cache is kept within by the http command, deleting the logs cached longest ago first. 0 disables the limit. Caches which can't be listed, such as Redis, expire logs themselves." env:"BUILDKITE_LOG_CACHE_MAX_BYTES" default:"0"`
+		LogCacheMaxAge        time.Duration            `help:"How long the http command keeps job logs in the cache before deleting them. 0 keeps them until the cache is over its size." env:"BUILDKITE_LOG_CACHE_MAX_AGE" default:"0s"`
+		SharedLogCacheSocket  string                   `help:"Unix socket through which servers on this machine share one job logs cache. The http command serves its cache on the socket, and stdio servers download logs through it while it's being served." env:"BUILDKITE_SHARED_LOG_CACHE_SOCKET"`
+		Debug                 bool                     `help:"Enable debug mode." env:"DEBUG"`
+		OTELExporter          string                   `help:"OpenTelemetry exporter to enable. Options are 'http/protobuf', 'grpc', or 'noop'." enum:"http/protobuf, grpc, noop" env:"OTEL_EXPORTER_OTLP_PROTOCOL" default:"noop"`
+		HTTPHeaders           []string                 `help:"Additional HTTP headers to send with every request. Format: 'Key: Value'" name:"http-header" env:"BUILDKITE_HTTP_HEADERS"`
+		AllowedOrgs           []string                 `help:"Comma-separated list of organization slugs tools are permitted to access. Defaults to all organizations." env:"BUILDKITE_ALLOWED_ORGS"`
+		AllowedPipelines      []string                 `help:"Comma-separated list of pipeline slug patterns tools are permitted to access (e.g. 'frontend-*' or 'my-org/deploy'). Defaults to all pipelines." env:"BUILDKITE_ALLOWED_PIPELINES"`
+		Policy                string                   `help:"CEL expression evaluated before each tool call, which must return true for the call to proceed. It is given tool, args, principal and read_only, e.g. 'read_only || args.pipeline_slug.startsWith(\"sandbox-\")'." env:"BUILDKITE_POLICY"`
+		RedactPatterns        []string                 `help:"Additional regular expressions matching secrets to redact from logs and build environments, applied alongside the built-in patterns." name:"redact-pattern" env:"BUILDKITE_REDACT_PATTERNS"`
+		AllowUnsafeEnvValues  bool                     `help:"Allow tool calls to ask for the values of build environment variables with secret-like names, such as *_TOKEN, *_KEY and *PASSWORD*, which are otherwise redacted." env:"BUILDKITE_ALLOW_UNSAFE_ENV_VALUES"`
+		ScrubRules            []scrub.Rule             `help:"Scrubbing rule applied to all tool output. Format: 'name=pattern', or an object with name, pattern and replacement keys in the config file." name:"scrub-rule" sep:"none"`
+		AuditLog              string                   `help:"Path to a JSONL file recording every invocation of a write tool." env:"BUILDKITE_AUDIT_LOG"`
+		AuditWebhook          string                   `help:"URL each audit record is also posted to as JSON, such as a SIEM's collector, so a copy is kept outside the server." env:"BUILDKITE_AUDIT_WEBHOOK"`
+		AuditSigningKey       string                   `help:"Key used to sign each audit record and exported bundle with HMAC-SHA256." env:"BUILDKITE_AUDIT_SIGNING_KEY"`
+		LogSink               string                   `help:"Where to write server logs. Options are 'stderr', 'syslog', or 'otlp'." enum:"stderr, syslog, otlp" env:"BUILDKITE_LOG_SINK" default:"stderr"`
+		SyslogAddress         string                   `help:"Syslog server address used by the syslog log sink, e.g. 'udp://localhost:514'. Defaults to the local syslog daemon." env:"BUILDKITE_SYSLOG_ADDRESS"`
+		OTLPLogsEndpoint      string                   `help:"OTLP/HTTP logs endpoint used by the otlp log sink, e.g. 'http://localhost:4318/v1/logs'." name:"otlp-logs-endpoint" env:"OTEL_EXPORTER_OTLP_LOGS_ENDPOINT"`
+		BreakerThreshold      int                      `help:"Consecutive failures of a part of the Buildkite API, such as artifacts, after which its calls fail fast until the cooldown has passed. 0 disables the circuit breaker." name:"circuit-breaker-threshold" default:"5" env:"BUILDKITE_CIRCUIT_BREAKER_THRESHOLD"`
+		BreakerCooldown       time.Duration            `help:"How long calls to a failing part of the Buildkite API fail fast before one is let through to check it has recovered." name:"circuit-breaker-cooldown" default:"30s" env:"BUILDKITE_CIRCUIT_BREAKER_COOLDOWN"`
+		RateLimitReserve      int                      `help:"Requests of an organization's Buildkite API rate limit to keep spare. Once no more remain, requests wait for the limit to reset." default:"5" env:"BUILDKITE_RATE_LIMIT_RESERVE"`
+		RateLimitRetries      int                      `help:"Times a request rejected for exceeding the Buildkite API rate limit is retried, waiting for the limit to reset with jitter. 0 disables retries." default:"3" env:"BUILDKITE_RATE_LIMIT_RETRIES"`
+		APIRetries            int                      `help:"Times a read-only Buildkite API request which failed transiently, with a 5xx response or a connection reset, is retried, backing off exponentially. 0 disables retries." name:"api-retries" default:"3" env:"BUILDKITE_API_RETRIES"`
+		APIRetryDelay         time.Duration            `help:"How long to wait before the first retry of a transiently failed Buildkite API request, doubling with jitter for each retry after." name:"api-retry-delay" default:"500ms" env:"BUILDKITE_API_RETRY_DELAY"`
+		APIRetryMaxDelay      time.Duration            `help:"Longest wait between retries of a transiently failed Buildkite API request." name:"api-retry-max-delay" default:"5s" env:"BUILDKITE_API_RETRY_MAX_DELAY"`
+		ArtifactRetention     time.Duration            `help:"How long your organization retains artifacts, used to estimate when listed artifacts expire. The Buildkite API doesn't expose retention settings, so set this if yours differs from the default 6 months, or 0 to disable." default:"4320h" env:"BUILDKITE_ARTIFACT_RETENTION"`
+		MaxResultTokens       int                      `help:"Estimated number of tokens of the largest tool result returned. The longest lists of larger results are cut short and the result marked truncated, with a hint to paginate. 0 disables the limit." default:"25000" env:"BUILDKITE_MAX_RESULT_TOKENS"`
+		ToolTimeout           time.Duration            `help:"How long a tool call may run for before it fails with an error saying it timed out. Tools which wait for a build or follow a log are given their wait_timeout on top. 0 disables the timeout." default:"2m" env:"BUILDKITE_TOOL_TIMEOUT"`
+		ToolTimeouts          map[string]time.Duration `help:"Timeout of a tool overriding --tool-timeout, including any wait_timeout, e.g. 'read_logs=5m'. 0 leaves the tool without a timeout." name:"tool-timeout-override" env:"BUILDKITE_TOOL_TIMEOUT_OVERRIDES"`
+		Tokenizer             string                   `help:"Tokenizer used to estimate the tokens of tool results for clients which don't declare their model. Options are 'heuristic', 'cl100k', 'o200k', or 'claude'." enum:"heuristic, cl100k, o200k, claude" default:"heuristic" env:"BUILDKITE_TOKENIZER"`
+		DisplayTimezone       string                   `help:"IANA timezone, e.g. 'Europe/London', to display timestamps of log entries and build summaries in, noting how long ago each was. Tool calls can ask for another with their timezone parameter." env:"BUILDKITE_DISPLAY_TIMEZONE"`
+		Config                kong.ConfigFlag          `help:"Load flag values from a JSON config file, keyed by flag name (e.g. 'allowed_orgs')."`
 		Version               kong.VersionFlag
 	}
 )
@@ -37,19 +88,24 @@ var (
 func main() {
 	ctx := context.Background()
 
-	cmd := kong.Parse(&cli,
-		kong.Name("buildkite-mcp-server"),
-		kong.Description("A server that proxies requests to the Buildkite API."),
+	cmd := kong.Parse(&cli, append(kongOptions(),
 		kong.UsageOnError(),
-		kong.Vars{
-			"version": version,
-		},
 		kong.BindTo(ctx, (*context.Context)(nil)),
-	)
+	)...)
 
-	log.Logger = setupLogger(cli.Debug)
+	logWriter, err := logsink.New(logsink.Config{
+		Sink:           cli.LogSink,
+		SyslogAddress:  cli.SyslogAddress,
+		OTLPEndpoint:   cli.OTLPLogsEndpoint,
+		ServiceName:    "buildkite-mcp-server",
+		ServiceVersion: version,
+	})
+	cmd.FatalIfErrorf(err)
 
-	err := run(ctx, cmd)
+	log.Logger = setupLogger(cli.Debug, cli.LogSink, logWriter)
+
+	err = run(ctx, cmd)
+	_ = logWriter.Close()
 	cmd.FatalIfErrorf(err)
 }
 
@@ -62,36 +118,148 @@ func run(ctx context.Context, cmd *kong.Context) error {
 		_ = tp.Shutdown(ctx)
 	}()
 
+	// the http server can serve the metrics for Prometheus to scrape as well as exporting them
+	var metricReaders []sdkmetric.Reader
+	var metricsHandler http.Handler
+	if cmd.Command() == "http" && cli.HTTP.Metrics {
+		reader, handler, err := trace.NewPrometheusReader()
+		if err != nil {
+			return err
+		}
+		metricReaders = append(metricReaders, reader)
+		metricsHandler = handler
+	}
+
+	mp, err := trace.NewMeterProvider(ctx, cli.OTELExporter, "buildkite-mcp-server", version, metricReaders...)
+	if err != nil {
+		return fmt.Errorf("failed to create meter provider: %w", err)
+	}
+	defer func() {
+		_ = mp.Shutdown(ctx)
+	}()
+
+	auditSigner := audit.NewSigner(cli.AuditSigningKey)
+
+	// exporting the audit log is an offline operation which doesn't need API access
+	if cmd.Command() == "export-audit-log" {
+		return cmd.Run(&commands.Globals{Version: version, AuditLogPath: cli.AuditLog, AuditSigner: auditSigner})
+	}
+
 	// Parse additional headers into a map
 	headers := commands.ParseHeaders(cli.HTTPHeaders)
 
-	// resolve the api token from either the token or 1password flag
-	apiToken, err := commands.ResolveAPIToken(cli.APIToken, cli.APITokenFrom1Password)
+	// a multi-tenant http server calls the API with each caller's token, so doesn't need its own
+	var apiToken string
+	if !isMultiTenantWithoutToken(cmd) {
+		apiToken, err = commands.ResolveAPIToken(cli.APIToken, cli.APITokenFrom1Password)
+		if err != nil {
+			return fmt.Errorf("failed to resolve Buildkite API token: %w", err)
+		}
+	} else if len(cli.HTTP.PrewarmPipelines) > 0 {
+		return fmt.Errorf("prewarming job logs requires an API token, which a multi-tenant server is not given")
+	}
+
+	globals, err := newPolicies(cli.AllowedOrgs, cli.AllowedPipelines, cli.Policy, cli.RedactPatterns, cli.AllowUnsafeEnvValues, cli.ScrubRules)
+	if err != nil {
+		return err
+	}
+
+	displayTimezone, err := loadDisplayTimezone(cli.DisplayTimezone)
 	if err != nil {
-		return fmt.Errorf("failed to resolve Buildkite API token: %w", err)
+		return err
 	}
 
-	client, err := gobuildkite.NewOpts(
-		gobuildkite.WithTokenAuth(apiToken),
-		gobuildkite.WithUserAgent(commands.UserAgent(version)),
-		gobuildkite.WithHTTPClient(trace.NewHTTPClientWithHeaders(headers)),
-		gobuildkite.WithBaseURL(cli.BaseURL),
-	)
+	tokenizer, err := tokens.Get(cli.Tokenizer)
+	if err != nil {
+		return err
+	}
+	tokens.SetDefault(tokenizer)
+
+	// records are written to every sink configured, so the webhook can keep a copy out of the server's reach
+	var auditSinks []io.Writer
+	var lastAuditRecord audit.Record
+	if cli.AuditLog != "" {
+		lastAuditRecord, err = audit.LastRecord(cli.AuditLog)
+		if err != nil {
+			return err
+		}
+
+		f, err := audit.AppendFile(cli.AuditLog)
+		if err != nil {
+			return err
+		}
+		auditSinks = append(auditSinks, f)
+	}
+	if cli.AuditWebhook != "" {
+		webhook, err := audit.NewWebhook(cli.AuditWebhook)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			// give records still queued a chance to be sent once the server has stopped
+			closeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+			defer cancel()
+			if err := webhook.Close(closeCtx); err != nil {
+				log.Warn().Err(err).Msg("Failed to send queued audit records")
+			}
+		}()
+		auditSinks = append(auditSinks, webhook)
+	}
+
+	var auditLogger *audit.Logger
+	if len(auditSinks) > 0 {
+		auditLogger = audit.NewLogger(audit.Sinks(auditSinks...), auditSigner)
+		auditLogger.ContinueFrom(lastAuditRecord)
+	}
+
+	circuitBreaker := breaker.New(cli.BreakerThreshold, cli.BreakerCooldown)
+
+	responseCache, err := newResponseCache(cli.CacheTTL, cli.ResponseCacheDir, cli.ResponseCacheMaxBytes)
+	if err != nil {
+		return err
+	}
+
+	rateLimiter := ratelimit.New(cli.RateLimitReserve, cli.RateLimitRetries)
+
+	retries := retry.New(cli.APIRetries, cli.APIRetryDelay, cli.APIRetryMaxDelay)
+
+	client, err := commands.NewClient(apiToken, version, cli.BaseURL, headers, circuitBreaker, rateLimiter, retries, responseCache)
 	if err != nil {
 		return fmt.Errorf("failed to create buildkite client: %w", err)
 	}
 
+	graphQLToken := cli.GraphQLToken
+	if graphQLToken == "" {
+		graphQLToken = apiToken
+	}
+
+	// a shared cache backend is opened through the blob URL mux, which the cache package registers it with
+	logsCacheURL := cli.CacheURL
+	if cli.CacheBackend != "" {
+		if cli.CacheURL != "" {
+			return fmt.Errorf("cannot specify both --cache-url and --cache-backend")
+		}
+		logsCacheURL = cli.CacheBackend
+	}
+
+	logCache, err := logcache.NewCollector(logsCacheURL, cli.LogCacheMaxBytes, cli.LogCacheMaxAge)
+	if err != nil {
+		return fmt.Errorf("failed to set up job logs cache: %w", err)
+	}
+
 	// Create ParquetClient with cache URL from flag/env (uses upstream library's high-level client)
-	buildkiteLogsClient, err := buildkitelogs.NewClient(ctx, client, cli.CacheURL)
+	buildkiteLogsClient, err := buildkitelogs.NewClient(ctx, client, logsCacheURL)
 	if err != nil {
 		return fmt.Errorf("failed to create buildkite logs client: %w", err)
 	}
 
 	buildkiteLogsClient.Hooks().AddAfterCacheCheck(func(ctx context.Context, result *buildkitelogs.CacheCheckResult) {
+		trace.RecordLogCacheCheck(ctx, result.Exists)
 		log.Ctx(ctx).Debug().Str("org", result.Org).Str("pipeline", result.Pipeline).Str("build", result.Build).Str("job", result.Job).Dur("time_taken", result.Duration).Msg("Checked job logs cache")
 	})
 
 	buildkiteLogsClient.Hooks().AddAfterLogDownload(func(ctx context.Context, result *buildkitelogs.LogDownloadResult) {
+		trace.RecordLogDownload(ctx, result.Duration, result.LogSize)
 		log.Ctx(ctx).Debug().Str("org", result.Org).Str("pipeline", result.Pipeline).Str("build", result.Build).Str("job", result.Job).Dur("time_taken", result.Duration).Msg("Downloaded and cached job logs")
 	})
 
@@ -103,20 +271,153 @@ func run(ctx context.Context, cmd *kong.Context) error {
 		log.Ctx(ctx).Debug().Str("org", result.Org).Str("pipeline", result.Pipeline).Str("build", result.Build).Str("job", result.Job).Dur("time_taken", result.Duration).Msg("Stored logs to blob storage")
 	})
 
-	return cmd.Run(&commands.Globals{Version: version, Client: client, BuildkiteLogsClient: buildkiteLogsClient})
+	globals.Version = version
+	globals.Client = client
+	globals.BuildkiteLogsClient = buildkiteLogsClient
+	globals.LogsCacheURL = logsCacheURL
+	globals.LogCache = logCache
+	globals.GraphQLClient = commands.NewGraphQLClient(graphQLToken, version, cli.GraphQLURL, headers, circuitBreaker, retries)
+	globals.AuditLogger = auditLogger
+	globals.AuditLogPath = cli.AuditLog
+	globals.AuditSigner = auditSigner
+	globals.ArtifactRetention = cli.ArtifactRetention
+	globals.MaxResultTokens = cli.MaxResultTokens
+	globals.ToolTimeout = cli.ToolTimeout
+	globals.ToolTimeouts = cli.ToolTimeouts
+	globals.Breaker = circuitBreaker
+	globals.RateLimiter = rateLimiter
+	globals.SharedLogCacheSocket = cli.SharedLogCacheSocket
+	globals.DisplayTimezone = displayTimezone
+	globals.Reload = reloadConfig
+	globals.MetricsHandler = metricsHandler
+
+	return cmd.Run(globals)
+}
+
+// isMultiTenantWithoutToken reports whether the http server is running in multi-tenant mode without
+// an API token of its own
+func isMultiTenantWithoutToken(cmd *kong.Context) bool {
+	return cmd.Command() == "http" && cli.HTTP.MultiTenant && cli.APIToken == "" && cli.APITokenFrom1Password == ""
+}
+
+// newResponseCache returns the cache of API responses, kept in dir if one is given or otherwise in
+// memory within maxBytes, or nil if ttl is 0
+func newResponseCache(ttl time.Duration, dir string, maxBytes int64) (*cache.HTTPCache, error) {
+	if ttl <= 0 {
+		return nil, nil
+	}
+	if dir == "" {
+		return cache.NewHTTPCache(cache.NewMemoryStore(maxBytes), ttl), nil
+	}
+
+	store, err := cache.NewDiskStore(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open response cache: %w", err)
+	}
+	return cache.NewHTTPCache(store, ttl), nil
+}
+
+func kongOptions() []kong.Option {
+	return []kong.Option{
+		kong.Name("buildkite-mcp-server"),
+		kong.Description("A server that proxies requests to the Buildkite API."),
+		kong.Vars{
+			"version": version,
+		},
+		kong.Configuration(kong.JSON),
+	}
+}
+
+// newPolicies creates the policies, redaction and scrubbing rules, which can be changed by reloading
+// the configuration
+func newPolicies(allowedOrgs, allowedPipelines []string, expression string, redactPatterns []string, allowUnsafeEnvValues bool, scrubRules []scrub.Rule) (*commands.Globals, error) {
+	scopePolicy, err := policy.NewScopePolicy(allowedOrgs, allowedPipelines)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scope policy: %w", err)
+	}
+
+	celPolicy, err := policy.NewCELPolicy(expression)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create policy: %w", err)
+	}
+
+	redactor, err := redact.New(redactPatterns, redact.WithUnsafeEnvValues(allowUnsafeEnvValues))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create redactor: %w", err)
+	}
+
+	scrubber, err := scrub.New(scrubRules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scrubber: %w", err)
+	}
+
+	return &commands.Globals{ScopePolicy: scopePolicy, CELPolicy: celPolicy, Redactor: redactor, Scrubber: scrubber}, nil
+}
+
+// reloadConfig parses the flags, environment and config file again, returning the options which
+// apply the settings that can be changed without restarting the server
+func reloadConfig() ([]server.ToolsetOption, error) {
+	next := cli
+
+	parser, err := kong.New(&next, kongOptions()...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parser.Parse(os.Args[1:]); err != nil {
+		return nil, fmt.Errorf("failed to parse configuration: %w", err)
+	}
+
+	if err := toolsets.ValidateToolsets(next.HTTP.EnabledToolsets); err != nil {
+		return nil, err
+	}
+
+	policies, err := newPolicies(next.AllowedOrgs, next.AllowedPipelines, next.Policy, next.RedactPatterns, next.AllowUnsafeEnvValues, next.ScrubRules)
+	if err != nil {
+		return nil, err
+	}
+
+	displayTimezone, err := loadDisplayTimezone(next.DisplayTimezone)
+	if err != nil {
+		return nil, err
+	}
+
+	return []server.ToolsetOption{
+		server.WithToolsets(next.HTTP.EnabledToolsets...),
+		server.WithReadOnly(next.HTTP.ReadOnly),
+		server.WithScopePolicy(policies.ScopePolicy),
+		server.WithCELPolicy(policies.CELPolicy),
+		server.WithRedactor(policies.Redactor),
+		server.WithScrubber(policies.Scrubber),
+		server.WithDisplayTimezone(displayTimezone),
+		server.WithToolTimeout(next.ToolTimeout, next.ToolTimeouts),
+		server.WithMaxResultTokens(next.MaxResultTokens),
+	}, nil
+}
+
+// loadDisplayTimezone returns the named timezone, or nil when none is configured
+func loadDisplayTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load display timezone: %w", err)
+	}
+	return loc, nil
 }
 
-func setupLogger(debug bool) zerolog.Logger {
+func setupLogger(debug bool, sink string, w io.Writer) zerolog.Logger {
 	var logger zerolog.Logger
 	level := zerolog.InfoLevel
 	if debug {
 		level = zerolog.DebugLevel
 	}
 
-	logger = zerolog.New(os.Stderr).Level(level).With().Timestamp().Stack().Logger()
+	logger = zerolog.New(w).Level(level).With().Timestamp().Stack().Logger()
 
 	// are we in an interactive terminal use a console writer
-	if isatty.IsTerminal(os.Stdout.Fd()) {
+	if sink == logsink.SinkStderr && isatty.IsTerminal(os.Stdout.Fd()) {
 		logger = logger.Output(zerolog.ConsoleWriter{Out: os.Stderr, FormatTimestamp: func(i any) string {
 			return time.Now().Format(time.Stamp)
 		}}).Level(level).With().Stack().Logger()
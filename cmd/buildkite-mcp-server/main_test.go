@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/server"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloadConfigMaxResultTokens(t *testing.T) {
+	assert := require.New(t)
+
+	args := os.Args
+	t.Cleanup(func() { os.Args = args })
+	os.Args = []string{"buildkite-mcp-server", "http"}
+
+	reload := func() int {
+		opts, err := reloadConfig()
+		assert.NoError(err)
+
+		cfg := server.ToolsetConfig{MaxResultTokens: 25000}
+		for _, opt := range opts {
+			opt(&cfg)
+		}
+		return cfg.MaxResultTokens
+	}
+
+	t.Setenv("BUILDKITE_MAX_RESULT_TOKENS", "5000")
+	assert.Equal(5000, reload())
+
+	t.Setenv("BUILDKITE_MAX_RESULT_TOKENS", "0")
+	assert.Equal(0, reload())
+}
@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/audit"
+	"github.com/buildkite/buildkite-mcp-server/pkg/reltime"
+)
+
+type AuditCmd struct {
+	From   string `help:"Only export records at or after this time, either a duration ago such as -24h or -7d, 'today', 'yesterday', a date (YYYY-MM-DD) or an RFC 3339 timestamp."`
+	To     string `help:"Only export records before this time, in the same formats as --from."`
+	Output string `help:"File to write the bundle to. Defaults to stdout." short:"o"`
+}
+
+func (c *AuditCmd) Run(ctx context.Context, globals *Globals) error {
+	if globals.AuditLogPath == "" {
+		return errors.New("--audit-log must be set to export the audit log")
+	}
+
+	from, err := parseAuditTime("from", c.From)
+	if err != nil {
+		return err
+	}
+	to, err := parseAuditTime("to", c.To)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(globals.AuditLogPath)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	bundle, err := audit.Export(f, globals.AuditSigner, from, to)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if c.Output != "" {
+		outFile, err := os.Create(c.Output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer outFile.Close()
+		out = outFile
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(bundle)
+}
+
+// parseAuditTime resolves the time of a flag, with the zero time leaving the range open
+func parseAuditTime(flag, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := reltime.Parse(value, time.Now())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --%s: %w", flag, err)
+	}
+	return t, nil
+}
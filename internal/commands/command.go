@@ -3,10 +3,26 @@ package commands
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"os/exec"
 	"runtime"
+	"strings"
+	"time"
 
 	buildkitelogs "github.com/buildkite/buildkite-logs"
+	"github.com/buildkite/buildkite-mcp-server/pkg/audit"
+	"github.com/buildkite/buildkite-mcp-server/pkg/breaker"
+	"github.com/buildkite/buildkite-mcp-server/pkg/cache"
+	"github.com/buildkite/buildkite-mcp-server/pkg/graphql"
+	"github.com/buildkite/buildkite-mcp-server/pkg/logcache"
+	"github.com/buildkite/buildkite-mcp-server/pkg/policy"
+	"github.com/buildkite/buildkite-mcp-server/pkg/ratelimit"
+	"github.com/buildkite/buildkite-mcp-server/pkg/redact"
+	"github.com/buildkite/buildkite-mcp-server/pkg/retry"
+	"github.com/buildkite/buildkite-mcp-server/pkg/scrub"
+	"github.com/buildkite/buildkite-mcp-server/pkg/server"
+	"github.com/buildkite/buildkite-mcp-server/pkg/tenant"
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
 	gobuildkite "github.com/buildkite/go-buildkite/v4"
 	"github.com/rs/zerolog/log"
 )
@@ -14,7 +30,36 @@ import (
 type Globals struct {
 	Client              *gobuildkite.Client
 	BuildkiteLogsClient *buildkitelogs.Client
+	GraphQLClient       *graphql.Client
 	Version             string
+	ScopePolicy         policy.ScopePolicy
+	CELPolicy           *policy.CELPolicy
+	Redactor            *redact.Redactor
+	Scrubber            *scrub.Scrubber
+	AuditLogger         *audit.Logger
+	AuditLogPath        string
+	AuditSigner         *audit.Signer
+	Breaker             *breaker.Breaker
+	RateLimiter         *ratelimit.Limiter
+	ArtifactRetention   time.Duration
+	// MaxResultTokens is the estimated number of tokens of the largest tool result returned, or 0 for no limit
+	MaxResultTokens int
+	// ToolTimeout is how long a tool call may run for, or 0 for no limit
+	ToolTimeout time.Duration
+	// ToolTimeouts override the ToolTimeout of the tools they name
+	ToolTimeouts map[string]time.Duration
+	// DisplayTimezone is the timezone timestamps are displayed in, or nil to leave them as returned by the API
+	DisplayTimezone *time.Location
+	// LogsCacheURL is the blob storage URL of the job logs cache, or empty for the default directory
+	LogsCacheURL string
+	// LogCache keeps the job logs cache within its size and age, and reports and purges it
+	LogCache *logcache.Collector
+	// SharedLogCacheSocket is the unix socket through which servers on this machine share a job logs cache
+	SharedLogCacheSocket string
+	// Reload parses the configuration again, returning the options applying what can be changed at runtime
+	Reload func() ([]server.ToolsetOption, error)
+	// MetricsHandler serves the metrics in the Prometheus text format, or is nil when they aren't served
+	MetricsHandler http.Handler
 }
 
 func UserAgent(version string) string {
@@ -24,6 +69,43 @@ func UserAgent(version string) string {
 	return fmt.Sprintf("buildkite-mcp-server/%s (%s; %s)", version, os, arch)
 }
 
+// NewClient creates the Buildkite API client shared by the tools and the job logs client. Every
+// request, including log downloads, is sent below the base URL with the additional headers, waiting
+// or retrying to stay within the API's rate limit, retrying transient failures of requests which are
+// safe to send again, and failing fast while the breaker is open for its endpoint. Requests made on behalf of a caller who supplied
+// their own token are authorized with it instead of the API token, and read-only requests are served
+// from the response cache, which is keyed by that token.
+func NewClient(apiToken, version, baseURL string, headers map[string]string, b *breaker.Breaker, limiter *ratelimit.Limiter, retries *retry.Policy, responses *cache.HTTPCache) (*gobuildkite.Client, error) {
+	httpClient := trace.NewHTTPClientWithHeaders(headers)
+	httpClient.Transport = tenant.Transport(responses.Transport(limiter.Transport(retries.Transport(b.Transport(httpClient.Transport)))))
+
+	return gobuildkite.NewOpts(
+		gobuildkite.WithTokenAuth(apiToken),
+		gobuildkite.WithUserAgent(UserAgent(version)),
+		gobuildkite.WithHTTPClient(httpClient),
+		gobuildkite.WithBaseURL(NormalizeBaseURL(baseURL)),
+	)
+}
+
+// NewGraphQLClient creates the GraphQL API client used by the graphql toolset. GraphQL access is
+// granted to API tokens separately, so it may be given a token of its own. Queries are only retried
+// for read-only tools, as mutations are sent the same way.
+func NewGraphQLClient(token, version, endpoint string, headers map[string]string, b *breaker.Breaker, retries *retry.Policy) *graphql.Client {
+	httpClient := trace.NewHTTPClientWithHeaders(headers)
+	httpClient.Transport = tenant.Transport(retries.Transport(b.Transport(httpClient.Transport)))
+
+	return graphql.New(endpoint, token, UserAgent(version), httpClient)
+}
+
+// NormalizeBaseURL adds a trailing slash to the base URL, as without one request paths replace
+// its last path segment rather than being resolved below it, dropping the path prefix of a proxy
+func NormalizeBaseURL(baseURL string) string {
+	if baseURL == "" || strings.HasSuffix(baseURL, "/") {
+		return baseURL
+	}
+	return baseURL + "/"
+}
+
 func ResolveAPIToken(token, tokenFrom1Password string) (string, error) {
 	if token != "" && tokenFrom1Password != "" {
 		return "", fmt.Errorf("cannot specify both --api-token and --api-token-from-1password")
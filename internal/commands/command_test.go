@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeBaseURL(t *testing.T) {
+	assert := require.New(t)
+
+	assert.Equal("https://api.buildkite.com/", NormalizeBaseURL("https://api.buildkite.com/"))
+	assert.Equal("https://proxy.example.com/buildkite/", NormalizeBaseURL("https://proxy.example.com/buildkite"))
+	assert.Equal("", NormalizeBaseURL(""))
+}
+
+func TestNewClientLogsUsePrefixedBaseURL(t *testing.T) {
+	for _, baseURLPath := range []string{"/buildkite", "/buildkite/"} {
+		t.Run(baseURLPath, func(t *testing.T) {
+			assert := require.New(t)
+
+			var mu sync.Mutex
+			var paths []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				paths = append(paths, r.URL.Path)
+				mu.Unlock()
+
+				if r.Header.Get("X-Proxy-Auth") != "secret" {
+					http.Error(w, "missing proxy header", http.StatusForbidden)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				switch r.URL.Path {
+				case "/buildkite/v2/organizations/acme/pipelines/web/builds/1":
+					_ = json.NewEncoder(w).Encode(map[string]any{
+						"number": 1,
+						"jobs":   []map[string]any{{"id": "job-1", "state": "passed"}},
+					})
+				case "/buildkite/v2/organizations/acme/pipelines/web/builds/1/jobs/job-1/log":
+					_ = json.NewEncoder(w).Encode(map[string]any{"content": "hello\n"})
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer srv.Close()
+
+			client, err := NewClient("token", "test", srv.URL+baseURLPath, map[string]string{"X-Proxy-Auth": "secret"}, nil, nil, nil, nil)
+			assert.NoError(err)
+
+			logsClient, err := buildkitelogs.NewClient(context.Background(), client, "file://"+t.TempDir())
+			assert.NoError(err)
+
+			path, err := logsClient.DownloadAndCache(context.Background(), "acme", "web", "1", "job-1", 0, false)
+			assert.NoError(err)
+			assert.NotEmpty(path)
+			defer os.Remove(path)
+
+			assert.Equal([]string{
+				"/buildkite/v2/organizations/acme/pipelines/web/builds/1",
+				"/buildkite/v2/organizations/acme/pipelines/web/builds/1/jobs/job-1/log",
+			}, paths)
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package commands
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// compressEncodings are the content encodings responses can be compressed with, most preferred first
+var compressEncodings = []string{"zstd", "gzip"}
+
+// compressibleTypes are the content types of MCP responses, which are JSON or a stream of JSON events
+var compressibleTypes = []string{"application/json", "text/event-stream"}
+
+// encoder compresses a response, flushing what it has compressed so far for streamed events
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// compressHandler compresses the responses of the handler with the most preferred encoding the
+// client accepts. JSON responses smaller than minSize are sent as they are, as compressing them
+// saves little, while event streams are compressed from the start and flushed after each event.
+func compressHandler(next http.Handler, minSize int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := acceptedEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding, minSize: minSize, status: http.StatusOK}
+		defer cw.Close()
+
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// acceptedEncoding returns the most preferred encoding in an Accept-Encoding header, or "" when it
+// accepts none of them
+func acceptedEncoding(header string) string {
+	accepted := map[string]bool{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		if name != "" && q > 0 {
+			accepted[name] = true
+		}
+	}
+
+	for _, encoding := range compressEncodings {
+		if accepted[encoding] {
+			return encoding
+		}
+	}
+	if accepted["*"] {
+		return "gzip"
+	}
+	return ""
+}
+
+// compressWriter holds back the start of a response until it knows whether to compress it, which
+// is once minSize bytes are written, the response is flushed, or the handler returns
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+
+	status  int
+	buf     []byte
+	decided bool
+	encoder encoder
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if !w.decided {
+		w.status = status
+	}
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if w.decided {
+		if w.encoder != nil {
+			return w.encoder.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if !w.compressible() {
+		return len(p), w.send(false)
+	}
+	if len(w.buf) >= w.minSize {
+		return len(p), w.send(true)
+	}
+	return len(p), nil
+}
+
+// Flush sends what has been written so far, starting to compress event streams
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		contentType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+		if err := w.send(w.compressible() && contentType == "text/event-stream"); err != nil {
+			return
+		}
+	}
+	if w.encoder != nil {
+		if err := w.encoder.Flush(); err != nil {
+			return
+		}
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Close sends a response the handler returned before deciding whether to compress, and finishes
+// compressing one it did compress
+func (w *compressWriter) Close() error {
+	if !w.decided {
+		return w.send(false)
+	}
+	if w.encoder != nil {
+		return w.encoder.Close()
+	}
+	return nil
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// compressible returns whether the response can be compressed, being an MCP response which isn't
+// already encoded
+func (w *compressWriter) compressible() bool {
+	if w.Header().Get("Content-Encoding") != "" {
+		return false
+	}
+	if w.status < http.StatusOK || w.status == http.StatusNoContent || w.status == http.StatusNotModified {
+		return false
+	}
+
+	contentType, _, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	for _, compressible := range compressibleTypes {
+		if contentType == compressible {
+			return true
+		}
+	}
+	return false
+}
+
+// send writes the header and what's been held back, compressing it and the rest of the response or not
+func (w *compressWriter) send(compress bool) error {
+	w.decided = true
+
+	if compress {
+		w.Header().Del("Content-Length")
+		w.Header().Set("Content-Encoding", w.encoding)
+
+		switch w.encoding {
+		case "zstd":
+			// a single goroutine and a smaller window keep the memory of each open stream down, and
+			// only invalid options fail to create an encoder
+			encoder, _ := zstd.NewWriter(w.ResponseWriter, zstd.WithEncoderConcurrency(1), zstd.WithWindowSize(1<<20))
+			w.encoder = encoder
+		default:
+			w.encoder = gzip.NewWriter(w.ResponseWriter)
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	var err error
+	if w.encoder != nil {
+		_, err = w.encoder.Write(w.buf)
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf)
+	}
+	w.buf = nil
+	return err
+}
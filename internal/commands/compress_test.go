@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcceptedEncoding(t *testing.T) {
+	assert := require.New(t)
+
+	assert.Equal("zstd", acceptedEncoding("gzip, deflate, br, zstd"))
+	assert.Equal("gzip", acceptedEncoding("gzip;q=0.5, zstd;q=0"))
+	assert.Equal("gzip", acceptedEncoding("*"))
+	assert.Equal("", acceptedEncoding("identity"))
+	assert.Equal("", acceptedEncoding(""))
+}
+
+func TestCompressHandler(t *testing.T) {
+	assert := require.New(t)
+
+	body := `{"jsonrpc":"2.0","id":1,"result":{"content":[{"type":"text","text":"` + strings.Repeat("log line ", 500) + `"}]}}`
+	handler := compressHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, body)
+	}), 1024)
+
+	// large responses are compressed with the preferred encoding
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	req.Header.Set("Accept-Encoding", "gzip, zstd")
+	handler.ServeHTTP(rec, req)
+	assert.Equal("zstd", rec.Header().Get("Content-Encoding"))
+	assert.Equal("Accept-Encoding", rec.Header().Get("Vary"))
+	assert.Less(rec.Body.Len(), len(body))
+
+	decoder, err := zstd.NewReader(rec.Body)
+	assert.NoError(err)
+	decoded, err := io.ReadAll(decoder)
+	assert.NoError(err)
+	assert.Equal(body, string(decoded))
+
+	rec = httptest.NewRecorder()
+	req.Header.Set("Accept-Encoding", "gzip")
+	handler.ServeHTTP(rec, req)
+	assert.Equal("gzip", rec.Header().Get("Content-Encoding"))
+	reader, err := gzip.NewReader(rec.Body)
+	assert.NoError(err)
+	decoded, err = io.ReadAll(reader)
+	assert.NoError(err)
+	assert.Equal(body, string(decoded))
+
+	// clients which don't accept an encoding get the response as it is
+	rec = httptest.NewRecorder()
+	req.Header.Del("Accept-Encoding")
+	handler.ServeHTTP(rec, req)
+	assert.Empty(rec.Header().Get("Content-Encoding"))
+	assert.Equal(body, rec.Body.String())
+}
+
+func TestCompressHandlerSmallResponses(t *testing.T) {
+	assert := require.New(t)
+
+	handler := compressHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":{}}`)
+	}), 1024)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	handler.ServeHTTP(rec, req)
+	assert.Empty(rec.Header().Get("Content-Encoding"))
+	assert.Equal(`{"jsonrpc":"2.0","id":1,"result":{}}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodDelete, "/mcp", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	handler.ServeHTTP(rec, req)
+	assert.Equal(http.StatusAccepted, rec.Code)
+	assert.Empty(rec.Header().Get("Content-Encoding"))
+}
+
+func TestCompressHandlerEventStream(t *testing.T) {
+	assert := require.New(t)
+
+	flushed := make(chan struct{})
+	server := httptest.NewServer(compressHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, "event: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\"}\n\n")
+		w.(http.Flusher).Flush()
+		<-flushed
+	}), 1024))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	assert.NoError(err)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	assert.NoError(err)
+	defer resp.Body.Close()
+	assert.Equal("gzip", resp.Header.Get("Content-Encoding"))
+
+	// small events are compressed and flushed as they're sent, while the stream stays open
+	reader, err := gzip.NewReader(resp.Body)
+	assert.NoError(err)
+	line, err := bufio.NewReader(reader).ReadString('\n')
+	assert.NoError(err)
+	assert.Equal("event: message\n", line)
+	close(flushed)
+}
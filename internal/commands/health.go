@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+	gobuildkite "github.com/buildkite/go-buildkite/v4"
+	"gocloud.dev/blob"
+)
+
+const (
+	// healthCheckInterval is how long the result of the health checks is reused, so frequent probes
+	// from several load balancers don't each call the API and write to the cache
+	healthCheckInterval = 10 * time.Second
+	// healthCheckTimeout bounds how long a probe waits for the checks
+	healthCheckTimeout = 5 * time.Second
+)
+
+// healthCheck returns an error when a dependency of the server is unavailable
+type healthCheck func(ctx context.Context) error
+
+type healthResult struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// healthChecker runs the named checks, reusing their result for healthCheckInterval
+type healthChecker struct {
+	checks map[string]healthCheck
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	result    healthResult
+}
+
+func newHealthChecker(checks map[string]healthCheck) *healthChecker {
+	return &healthChecker{checks: checks}
+}
+
+// check runs the checks unless they ran recently, returning whether all of them passed
+func (h *healthChecker) check(ctx context.Context) (healthResult, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if time.Since(h.checkedAt) >= healthCheckInterval {
+		ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		defer cancel()
+
+		result := healthResult{Status: "ok", Checks: map[string]string{}}
+		for name, check := range h.checks {
+			result.Checks[name] = "ok"
+			if err := check(ctx); err != nil {
+				result.Status = "unavailable"
+				result.Checks[name] = err.Error()
+			}
+		}
+
+		h.result = result
+		h.checkedAt = time.Now()
+	}
+
+	return h.result, h.result.Status == "ok"
+}
+
+// healthHandler reports the process is alive, without checking its dependencies, so an outage of
+// the Buildkite API or the job logs cache doesn't get every replica restarted
+func healthHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, healthResult{Status: "ok"}, true)
+	}
+}
+
+// readinessHandler reports whether the server should be sent new sessions, which it shouldn't while
+// draining or while its dependencies are unavailable
+func readinessHandler(ready *atomic.Bool, checker *healthChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !ready.Load() {
+			http.Error(w, "draining", http.StatusServiceUnavailable)
+			return
+		}
+
+		result, ok := checker.check(r.Context())
+		writeHealth(w, result, ok)
+	}
+}
+
+func writeHealth(w http.ResponseWriter, result healthResult, ok bool) {
+	w.Header().Set("Content-Type", "application/json")
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+// apiHealthCheck checks the Buildkite API is reachable and accepts the server's token, by fetching
+// the token's details. A server calling the API with its callers' tokens may have no token of its
+// own, so any answer from the API will do.
+func apiHealthCheck(client *gobuildkite.Client, requireToken bool) healthCheck {
+	return func(ctx context.Context) error {
+		_, _, err := client.AccessTokens.Get(ctx)
+
+		var errorResponse *gobuildkite.ErrorResponse
+		if !requireToken && errors.As(err, &errorResponse) && errorResponse.Response.StatusCode == http.StatusUnauthorized {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to reach the Buildkite API: %w", err)
+		}
+
+		return nil
+	}
+}
+
+// cacheHealthCheck checks the job logs cache at the storage URL is writable, by writing and deleting
+// a blob
+func cacheHealthCheck(storageURL string) healthCheck {
+	return func(ctx context.Context) error {
+		storageURL, err := buildkitelogs.GetDefaultStorageURL(storageURL)
+		if err != nil {
+			return err
+		}
+
+		bucket, err := blob.OpenBucket(ctx, storageURL)
+		if err != nil {
+			return fmt.Errorf("failed to open the job logs cache: %w", err)
+		}
+		defer bucket.Close()
+
+		key := fmt.Sprintf("healthz-%d", time.Now().UnixNano())
+		if err := bucket.WriteAll(ctx, key, []byte("ok"), nil); err != nil {
+			return fmt.Errorf("failed to write to the job logs cache: %w", err)
+		}
+		if err := bucket.Delete(ctx, key); err != nil {
+			return fmt.Errorf("failed to delete from the job logs cache: %w", err)
+		}
+
+		return nil
+	}
+}
@@ -2,23 +2,52 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
+	"github.com/buildkite/buildkite-mcp-server/pkg/buildkite"
+	"github.com/buildkite/buildkite-mcp-server/pkg/logcache"
+	"github.com/buildkite/buildkite-mcp-server/pkg/oauth"
+	"github.com/buildkite/buildkite-mcp-server/pkg/policy"
 	"github.com/buildkite/buildkite-mcp-server/pkg/server"
+	"github.com/buildkite/buildkite-mcp-server/pkg/tenant"
 	"github.com/buildkite/buildkite-mcp-server/pkg/toolsets"
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/buildkite-mcp-server/pkg/webhook"
+	"github.com/mark3labs/mcp-go/mcp"
 	mcpserver "github.com/mark3labs/mcp-go/server"
 	"github.com/rs/zerolog/log"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 type HTTPCmd struct {
-	Listen          string   `help:"The address to listen on." default:"localhost:3000" env:"HTTP_LISTEN_ADDR"`
-	UseSSE          bool     `help:"Use deprecated SSS transport instead of Streamable HTTP." default:"false"`
-	EnabledToolsets []string `help:"Comma-separated list of toolsets to enable (e.g., 'pipelines,builds,clusters'). Use 'all' to enable all toolsets." default:"all" env:"BUILDKITE_TOOLSETS"`
-	ReadOnly        bool     `help:"Enable read-only mode, which filters out write operations from all toolsets." default:"false" env:"BUILDKITE_READ_ONLY"`
+	Listen              string        `help:"The address to listen on." default:"localhost:3000" env:"HTTP_LISTEN_ADDR"`
+	UseSSE              bool          `help:"Use deprecated SSS transport instead of Streamable HTTP." default:"false"`
+	EnabledToolsets     []string      `help:"Comma-separated list of toolsets to enable (e.g., 'pipelines,builds,clusters'). Use 'all' to enable all toolsets." default:"all" env:"BUILDKITE_TOOLSETS"`
+	ReadOnly            bool          `help:"Enable read-only mode, which filters out write operations from all toolsets." default:"false" env:"BUILDKITE_READ_ONLY"`
+	ShutdownGracePeriod time.Duration `help:"How long to keep serving existing connections after receiving SIGTERM, while reporting not ready. It ends early once no connection is serving a request or stream, and a second signal exits straight away." default:"30s" env:"HTTP_SHUTDOWN_GRACE_PERIOD"`
+	PrincipalHeader     string        `help:"Request header identifying the caller, such as X-Forwarded-Email set by an authenticating proxy, passed to the policy as principal. Can't be used with --oauth-issuer, which takes the principal from the access token." env:"HTTP_PRINCIPAL_HEADER"`
+	MultiTenant         bool          `help:"Require each request to carry a Buildkite API token as a bearer token in its Authorization header, and call the Buildkite API with it rather than the server's API token, so one server can be shared by users with their own credentials." default:"false" env:"HTTP_MULTI_TENANT"`
+	OAuthIssuer         string        `help:"URL of an OAuth 2.1 or OpenID Connect authorization server. Each request must then carry an access token it issued, whose buildkite:<toolset> scopes grant the toolsets it may call, with buildkite:all granting every toolset and buildkite:write permitting write tools." name:"oauth-issuer" env:"HTTP_OAUTH_ISSUER"`
+	OAuthResourceURL    string        `help:"Public URL of the MCP endpoint clients request access tokens for, e.g. 'https://mcp.example.com/mcp'. Defaults to the endpoint on the listen address." name:"oauth-resource-url" env:"HTTP_OAUTH_RESOURCE_URL"`
+	OAuthAudience       string        `help:"Audience access tokens must be issued for. Defaults to the resource URL." name:"oauth-audience" env:"HTTP_OAUTH_AUDIENCE"`
+	PrewarmPipelines    []string      `help:"Comma-separated list of pipelines, as org/pipeline, whose recent failed builds have their job logs downloaded into the cache in the background." env:"BUILDKITE_PREWARM_PIPELINES"`
+	PrewarmInterval     time.Duration `help:"How often to check the prewarmed pipelines for failed builds." default:"10m" env:"BUILDKITE_PREWARM_INTERVAL"`
+	PrewarmLookback     time.Duration `help:"How far back to look for failed builds to prewarm." default:"24h" env:"BUILDKITE_PREWARM_LOOKBACK"`
+	WebhookListen       string        `help:"The address to receive Buildkite webhooks on, whose build and job events are sent to the sessions subscribed to them with the subscribe_to_events tool." env:"WEBHOOK_LISTEN_ADDR"`
+	WebhookToken        string        `help:"The token of the Buildkite webhook, which each webhook must carry in X-Buildkite-Token or be signed with in X-Buildkite-Signature." env:"BUILDKITE_WEBHOOK_TOKEN"`
+	LogCacheGCInterval  time.Duration `help:"How often to delete the job logs over the cache's maximum size or age." name:"log-cache-gc-interval" default:"1h" env:"BUILDKITE_LOG_CACHE_GC_INTERVAL"`
+	Compress            bool          `help:"Compress responses of the Streamable HTTP transport with zstd or gzip for clients which accept them, such as large job logs." default:"false" env:"HTTP_COMPRESS"`
+	CompressMinSize     int           `help:"Size in bytes of the smallest JSON response compressed. Event streams are always compressed." default:"1024" env:"HTTP_COMPRESS_MIN_SIZE"`
+	Metrics             bool          `help:"Serve Prometheus metrics of tool calls, their latency, Buildkite API errors, cache hits and job log downloads at /metrics." default:"false" env:"HTTP_METRICS"`
 }
 
 func (c *HTTPCmd) Run(ctx context.Context, globals *Globals) error {
@@ -27,8 +56,66 @@ func (c *HTTPCmd) Run(ctx context.Context, globals *Globals) error {
 		return err
 	}
 
-	mcpServer := server.NewMCPServer(globals.Version, globals.Client, globals.BuildkiteLogsClient,
-		server.WithReadOnly(c.ReadOnly), server.WithToolsets(c.EnabledToolsets...))
+	if c.MultiTenant && c.OAuthIssuer != "" {
+		return fmt.Errorf("cannot specify both --multi-tenant and --oauth-issuer")
+	}
+	// the principal of an OAuth caller comes from its verified access token, not a header it could set
+	if c.PrincipalHeader != "" && c.OAuthIssuer != "" {
+		return fmt.Errorf("cannot specify both --principal-header and --oauth-issuer")
+	}
+
+	var receiver *webhook.Receiver
+	if c.WebhookListen != "" {
+		if c.WebhookToken == "" {
+			return fmt.Errorf("--webhook-token is required with --webhook-listen")
+		}
+		// webhook events belong to the organization of the webhook, not to the callers of a shared server
+		if c.MultiTenant {
+			return fmt.Errorf("cannot specify both --multi-tenant and --webhook-listen")
+		}
+		receiver = webhook.NewReceiver(c.WebhookToken)
+	}
+
+	endpoint := "/mcp"
+	if c.UseSSE {
+		endpoint = "/sse"
+	}
+
+	var authenticator *oauth.Authenticator
+	if c.OAuthIssuer != "" {
+		resourceURL := c.OAuthResourceURL
+		if resourceURL == "" {
+			resourceURL = fmt.Sprintf("http://%s%s", c.Listen, endpoint)
+		}
+
+		var err error
+		authenticator, err = oauth.NewAuthenticator(ctx, oauth.Config{
+			Issuer:      c.OAuthIssuer,
+			ResourceURL: resourceURL,
+			Audience:    c.OAuthAudience,
+			Scopes:      oauth.ToolsetScopes(toolsets.ValidToolsets),
+			HTTPClient:  trace.NewHTTPClient(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to set up OAuth: %w", err)
+		}
+	}
+
+	var prewarmer *buildkite.LogPrewarmer
+	if len(c.PrewarmPipelines) > 0 {
+		var err error
+		prewarmer, err = buildkite.NewLogPrewarmer(globals.Client.Builds, globals.BuildkiteLogsClient, c.PrewarmPipelines, c.PrewarmInterval, c.PrewarmLookback)
+		if err != nil {
+			return err
+		}
+	}
+
+	mcpServer, reloader := server.NewReloadableMCPServer(globals.Version, globals.Client, globals.BuildkiteLogsClient,
+		server.WithReadOnly(c.ReadOnly), server.WithToolsets(c.EnabledToolsets...), server.WithScopePolicy(globals.ScopePolicy),
+		server.WithCELPolicy(globals.CELPolicy), server.WithRedactor(globals.Redactor), server.WithScrubber(globals.Scrubber),
+		server.WithAuditLogger(globals.AuditLogger), server.WithArtifactRetention(globals.ArtifactRetention),
+		server.WithCircuitBreaker(globals.Breaker), server.WithRateLimiter(globals.RateLimiter), server.WithDisplayTimezone(globals.DisplayTimezone),
+		server.WithGraphQLClient(globals.GraphQLClient), server.WithMaxResultTokens(globals.MaxResultTokens), server.WithToolTimeout(globals.ToolTimeout, globals.ToolTimeouts), server.WithWebhookReceiver(receiver), server.WithLogCache(globals.LogCache))
 
 	listener, err := net.Listen("tcp", c.Listen)
 	if err != nil {
@@ -39,17 +126,249 @@ func (c *HTTPCmd) Run(ctx context.Context, globals *Globals) error {
 	mux := http.NewServeMux()
 	srv := newServerWithTimeouts(mux)
 
+	active := &activeConns{}
+	srv.ConnState = active.track
+
+	// request contexts derive from this so long lived streams can be ended once draining completes
+	baseCtx, cancelBase := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelBase()
+	srv.BaseContext = func(net.Listener) context.Context { return baseCtx }
+
+	var ready atomic.Bool
+	ready.Store(true)
+	checker := newHealthChecker(map[string]healthCheck{
+		"buildkite_api": apiHealthCheck(globals.Client, !c.MultiTenant),
+		"log_cache":     cacheHealthCheck(globals.LogsCacheURL),
+	})
+	mux.Handle("/healthz", healthHandler())
+	mux.Handle("/readyz", readinessHandler(&ready, checker))
+	if c.Metrics && globals.MetricsHandler != nil {
+		mux.Handle("/metrics", globals.MetricsHandler)
+	}
+
+	logEvent.Bool("multi_tenant", c.MultiTenant)
+
+	if authenticator != nil {
+		mux.Handle(oauth.ProtectedResourceMetadataPath, authenticator.ProtectedResourceMetadataHandler())
+		mux.Handle(oauth.ProtectedResourceMetadataPath+endpoint, authenticator.ProtectedResourceMetadataHandler())
+		mux.Handle(oauth.AuthorizationServerMetadataPath, authenticator.AuthorizationServerMetadataHandler())
+		logEvent.Str("oauth_issuer", c.OAuthIssuer)
+	}
+
 	if c.UseSSE {
-		handler := mcpserver.NewSSEServer(mcpServer)
-		mux.Handle("/sse", handler)
+		handler := mcpserver.NewSSEServer(mcpServer, mcpserver.WithSSEContextFunc(principalContext(c.PrincipalHeader)))
+		mux.Handle(endpoint, c.authenticate(handler, authenticator))
 		logEvent.Str("transport", "sse").Str("endpoint", fmt.Sprintf("http://%s/sse", listener.Addr())).Msg("Starting SSE HTTP server")
 	} else {
-		handler := mcpserver.NewStreamableHTTPServer(mcpServer)
-		mux.Handle("/mcp", handler)
+		var handler http.Handler = mcpserver.NewStreamableHTTPServer(mcpServer, mcpserver.WithHTTPContextFunc(principalContext(c.PrincipalHeader)))
+		if c.Compress {
+			handler = compressHandler(handler, c.CompressMinSize)
+			logEvent.Bool("compress", true)
+		}
+		mux.Handle(endpoint, c.authenticate(handler, authenticator))
 		logEvent.Str("transport", "streamable-http").Str("endpoint", fmt.Sprintf("http://%s/mcp", listener.Addr())).Msg("Starting Streamable HTTP server")
 	}
 
-	return srv.Serve(listener)
+	if globals.SharedLogCacheSocket != "" {
+		cacheListener, err := logcache.Listen(globals.SharedLogCacheSocket)
+		if err != nil {
+			return err
+		}
+		cacheSrv := &http.Server{
+			Handler:           logcache.NewHandler(globals.BuildkiteLogsClient),
+			ReadHeaderTimeout: 30 * time.Second,
+		}
+		defer cacheSrv.Close()
+
+		go func() {
+			if err := cacheSrv.Serve(cacheListener); !errors.Is(err, http.ErrServerClosed) {
+				log.Ctx(ctx).Error().Err(err).Msg("Shared log cache server failed")
+			}
+		}()
+		log.Ctx(ctx).Info().Str("socket", globals.SharedLogCacheSocket).Msg("Sharing job logs cache")
+	}
+
+	if receiver != nil {
+		webhookListener, err := net.Listen("tcp", c.WebhookListen)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", c.WebhookListen, err)
+		}
+		webhookSrv := &http.Server{
+			Handler:           receiver,
+			ReadHeaderTimeout: 30 * time.Second,
+			ReadTimeout:       30 * time.Second,
+			WriteTimeout:      30 * time.Second,
+		}
+		defer webhookSrv.Close()
+
+		go func() {
+			if err := webhookSrv.Serve(webhookListener); !errors.Is(err, http.ErrServerClosed) {
+				log.Ctx(ctx).Error().Err(err).Msg("Webhook server failed")
+			}
+		}()
+		log.Ctx(ctx).Info().Str("address", webhookListener.Addr().String()).Msg("Receiving Buildkite webhooks")
+	}
+
+	signalCtx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	if globals.Reload != nil {
+		hangup := make(chan os.Signal, 1)
+		signal.Notify(hangup, syscall.SIGHUP)
+		defer signal.Stop(hangup)
+
+		go reloadOnHangup(signalCtx, hangup, globals.Reload, reloader)
+	}
+
+	if prewarmer != nil {
+		log.Ctx(ctx).Info().Strs("pipelines", c.PrewarmPipelines).Dur("interval", c.PrewarmInterval).Msg("Prewarming job logs of recent failed builds")
+		go prewarmer.Run(signalCtx)
+	}
+
+	if globals.LogCache != nil && c.LogCacheGCInterval > 0 {
+		go globals.LogCache.Run(signalCtx, c.LogCacheGCInterval)
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-signalCtx.Done():
+	}
+
+	// a second signal exits straight away rather than waiting for draining to complete
+	stop()
+
+	// fail readiness straight away so the load balancer stops routing new sessions here
+	ready.Store(false)
+	log.Ctx(ctx).Info().Dur("grace_period", c.ShutdownGracePeriod).Msg("Received shutdown signal, draining HTTP server")
+
+	grace := time.NewTimer(c.ShutdownGracePeriod)
+	defer grace.Stop()
+	poll := time.NewTicker(drainPollInterval)
+	defer poll.Stop()
+
+DRAINLOOP:
+	for {
+		select {
+		case <-grace.C:
+			break DRAINLOOP
+		case err := <-serveErr:
+			return err
+		case <-poll.C:
+			// the grace period is cut short once no connection has a request or stream open
+			if active.len() == 0 {
+				log.Ctx(ctx).Info().Msg("No connections serving requests, ending the grace period")
+				break DRAINLOOP
+			}
+		}
+	}
+
+	mcpServer.SendNotificationToAllClients("notifications/message", map[string]any{
+		"level":  mcp.LoggingLevelWarning,
+		"logger": "buildkite-mcp-server",
+		"data":   "The server is shutting down, reconnect to start a new session.",
+	})
+
+	// end open streams, then wait for in-flight requests to complete
+	cancelBase()
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down HTTP server: %w", err)
+	}
+
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	log.Ctx(ctx).Info().Msg("HTTP server shut down")
+
+	return nil
+}
+
+// drainPollInterval is how often draining checks whether any connection is still serving a request
+const drainPollInterval = 250 * time.Millisecond
+
+// activeConns tracks the connections serving a request, including the long lived streams of
+// sessions, so draining can end once none are left. Idle keep-alive connections aren't counted.
+type activeConns struct {
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+}
+
+// track is the http.Server ConnState hook
+func (a *activeConns) track(conn net.Conn, state http.ConnState) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if state == http.StateActive {
+		if a.conns == nil {
+			a.conns = map[net.Conn]struct{}{}
+		}
+		a.conns[conn] = struct{}{}
+		return
+	}
+	delete(a.conns, conn)
+}
+
+func (a *activeConns) len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.conns)
+}
+
+// reloadOnHangup reloads the configuration each time the process receives SIGHUP, keeping the
+// current configuration when the new one is invalid
+func reloadOnHangup(ctx context.Context, hangup <-chan os.Signal, reload func() ([]server.ToolsetOption, error), reloader *server.Reloader) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+		}
+
+		log.Ctx(ctx).Info().Msg("Received SIGHUP, reloading configuration")
+
+		opts, err := reload()
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("Failed to reload configuration, keeping the current configuration")
+			continue
+		}
+
+		reloader.Reload(opts...)
+	}
+}
+
+// authenticate requires each request to carry an access token issued by the OAuth authorization
+// server, or in multi-tenant mode the caller's own API token, which the API clients then use for the
+// tool calls it makes
+func (c *HTTPCmd) authenticate(handler http.Handler, authenticator *oauth.Authenticator) http.Handler {
+	switch {
+	case authenticator != nil:
+		return authenticator.Middleware(handler)
+	case c.MultiTenant:
+		return tenant.RequireToken(handler)
+	default:
+		return handler
+	}
+}
+
+// principalContext records the value of the principal header of each request on its context. A
+// caller with a verified access token keeps the principal taken from it.
+func principalContext(header string) func(ctx context.Context, r *http.Request) context.Context {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		if header == "" || oauth.ClaimsFromContext(ctx) != nil {
+			return ctx
+		}
+		return policy.WithPrincipal(ctx, r.Header.Get(header))
+	}
 }
 
 func newServerWithTimeouts(mux *http.ServeMux) *http.Server {
@@ -0,0 +1,150 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/oauth"
+	"github.com/buildkite/buildkite-mcp-server/pkg/policy"
+	gobuildkite "github.com/buildkite/go-buildkite/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadinessHandler(t *testing.T) {
+	assert := require.New(t)
+
+	var ready atomic.Bool
+	ready.Store(true)
+	handler := readinessHandler(&ready, newHealthChecker(nil))
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(http.StatusOK, rec.Code)
+
+	ready.Store(false)
+
+	rec = httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestHealthHandler(t *testing.T) {
+	assert := require.New(t)
+
+	calls := 0
+	checker := newHealthChecker(map[string]healthCheck{
+		"ok": func(ctx context.Context) error { return nil },
+		"failing": func(ctx context.Context) error {
+			calls++
+			return errors.New("unreachable")
+		},
+	})
+
+	// liveness only reports the process is up, whatever the state of its dependencies
+	rec := httptest.NewRecorder()
+	healthHandler()(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(http.StatusOK, rec.Code)
+	assert.JSONEq(`{"status":"ok"}`, rec.Body.String())
+	assert.Equal(0, calls)
+
+	var ready atomic.Bool
+	ready.Store(true)
+	rec = httptest.NewRecorder()
+	readinessHandler(&ready, checker)(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(http.StatusServiceUnavailable, rec.Code)
+	assert.JSONEq(`{"status":"unavailable","checks":{"ok":"ok","failing":"unreachable"}}`, rec.Body.String())
+
+	// readiness reuses the recent result rather than checking again
+	rec = httptest.NewRecorder()
+	readinessHandler(&ready, checker)(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(1, calls)
+}
+
+func TestAPIHealthCheck(t *testing.T) {
+	assert := require.New(t)
+
+	status := http.StatusOK
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("/v2/access-token", r.URL.Path)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"uuid":"token","scopes":["read_builds"]}`))
+	}))
+	defer api.Close()
+
+	client, err := gobuildkite.NewOpts(gobuildkite.WithBaseURL(api.URL))
+	assert.NoError(err)
+
+	assert.NoError(apiHealthCheck(client, true)(context.Background()))
+
+	// a rejected token is only unhealthy when the server calls the API with its own
+	status = http.StatusUnauthorized
+	assert.Error(apiHealthCheck(client, true)(context.Background()))
+	assert.NoError(apiHealthCheck(client, false)(context.Background()))
+
+	status = http.StatusBadGateway
+	assert.Error(apiHealthCheck(client, false)(context.Background()))
+}
+
+func TestCacheHealthCheck(t *testing.T) {
+	assert := require.New(t)
+
+	dir := t.TempDir()
+	assert.NoError(cacheHealthCheck("file://" + dir)(context.Background()))
+
+	// the probe leaves nothing behind
+	entries, err := os.ReadDir(dir)
+	assert.NoError(err)
+	assert.Empty(entries)
+
+	assert.Error(cacheHealthCheck("file://" + dir + "/missing")(context.Background()))
+}
+
+func TestActiveConns(t *testing.T) {
+	assert := require.New(t)
+
+	active := &activeConns{}
+	first, second := &net.TCPConn{}, &net.TCPConn{}
+
+	active.track(first, http.StateNew)
+	assert.Equal(0, active.len())
+
+	active.track(first, http.StateActive)
+	active.track(second, http.StateActive)
+	assert.Equal(2, active.len())
+
+	// connections kept alive between requests don't hold up draining
+	active.track(first, http.StateIdle)
+	assert.Equal(1, active.len())
+
+	active.track(second, http.StateClosed)
+	assert.Equal(0, active.len())
+}
+
+func TestPrincipalContext(t *testing.T) {
+	assert := require.New(t)
+
+	contextFunc := principalContext("X-Forwarded-Email")
+
+	req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	req.Header.Set("X-Forwarded-Email", "mallory@example.com")
+
+	ctx := contextFunc(context.Background(), req)
+	assert.Equal("mallory@example.com", policy.PrincipalFromContext(ctx))
+
+	// the header can't override the principal of a verified access token
+	ctx = policy.WithPrincipal(oauth.WithClaims(context.Background(), &oauth.Claims{}), "alice@example.com")
+	ctx = contextFunc(ctx, req)
+	assert.Equal("alice@example.com", policy.PrincipalFromContext(ctx))
+}
+
+func TestHTTPCmdRejectsPrincipalHeaderWithOAuth(t *testing.T) {
+	cmd := &HTTPCmd{PrincipalHeader: "X-Forwarded-Email", OAuthIssuer: "https://auth.example.com"}
+	require.ErrorContains(t, cmd.Run(context.Background(), &Globals{}), "cannot specify both --principal-header and --oauth-issuer")
+}
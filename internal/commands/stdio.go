@@ -3,6 +3,9 @@ package commands
 import (
 	"context"
 
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+	"github.com/buildkite/buildkite-mcp-server/pkg/buildkite"
+	"github.com/buildkite/buildkite-mcp-server/pkg/logcache"
 	"github.com/buildkite/buildkite-mcp-server/pkg/server"
 	"github.com/buildkite/buildkite-mcp-server/pkg/toolsets"
 	mcpserver "github.com/mark3labs/mcp-go/server"
@@ -20,8 +23,17 @@ func (c *StdioCmd) Run(ctx context.Context, globals *Globals) error {
 		return err
 	}
 
-	s := server.NewMCPServer(globals.Version, globals.Client, globals.BuildkiteLogsClient,
-		server.WithReadOnly(c.ReadOnly), server.WithToolsets(c.EnabledToolsets...))
+	var logsClient buildkite.BuildkiteLogsClient = globals.BuildkiteLogsClient
+	if globals.SharedLogCacheSocket != "" {
+		logsClient = logcache.NewClient(globals.SharedLogCacheSocket, globals.BuildkiteLogsClient, buildkitelogs.NewBuildkiteAPIExistingClient(globals.Client))
+	}
+
+	s := server.NewMCPServer(globals.Version, globals.Client, logsClient,
+		server.WithReadOnly(c.ReadOnly), server.WithToolsets(c.EnabledToolsets...), server.WithScopePolicy(globals.ScopePolicy),
+		server.WithCELPolicy(globals.CELPolicy), server.WithRedactor(globals.Redactor), server.WithScrubber(globals.Scrubber),
+		server.WithAuditLogger(globals.AuditLogger), server.WithArtifactRetention(globals.ArtifactRetention),
+		server.WithCircuitBreaker(globals.Breaker), server.WithRateLimiter(globals.RateLimiter), server.WithDisplayTimezone(globals.DisplayTimezone),
+		server.WithGraphQLClient(globals.GraphQLClient), server.WithMaxResultTokens(globals.MaxResultTokens), server.WithToolTimeout(globals.ToolTimeout, globals.ToolTimeouts), server.WithLogCache(globals.LogCache))
 
 	return mcpserver.ServeStdio(s,
 		mcpserver.WithStdioContextFunc(
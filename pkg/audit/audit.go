@@ -0,0 +1,257 @@
+package audit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/policy"
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	StatusSuccess = "success"
+	StatusError   = "error"
+)
+
+// Record is a single audited tool invocation, written as one line of JSON. Records are numbered in
+// the order they're written and carry the signature of the record before them, so a signed log
+// forms a chain in which a deleted, truncated or reordered record fails verification.
+type Record struct {
+	Seq           uint64    `json:"seq"`
+	Time          time.Time `json:"time"`
+	RequestID     string    `json:"request_id,omitempty"`
+	Actor         string    `json:"actor,omitempty"`
+	Tool          string    `json:"tool"`
+	ArgsHash      string    `json:"args_hash"`
+	Status        string    `json:"status"`
+	Error         string    `json:"error,omitempty"`
+	PrevSignature string    `json:"prev_signature,omitempty"`
+	Signature     string    `json:"signature,omitempty"`
+}
+
+// Signer signs and verifies records using HMAC-SHA256. A nil Signer leaves records unsigned.
+type Signer struct {
+	key []byte
+}
+
+// NewSigner returns a signer for the key, or nil if the key is empty
+func NewSigner(key string) *Signer {
+	if key == "" {
+		return nil
+	}
+	return &Signer{key: []byte(key)}
+}
+
+// sign returns the hex encoded HMAC of the JSON encoded value
+func (s *Signer) sign(value any) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Sign returns the signature for the record, covering its sequence number and the signature of the
+// record before it
+func (s *Signer) Sign(record Record) (string, error) {
+	record.Signature = ""
+	return s.sign(record)
+}
+
+// Verify returns true if the record carries a valid signature
+func (s *Signer) Verify(record Record) bool {
+	expected, err := s.Sign(record)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal([]byte(expected), []byte(record.Signature))
+}
+
+// Follows returns true if the record comes directly after prev in the chain, or starts the chain if
+// prev is nil. It doesn't check the record's own signature, which Verify does.
+func (s *Signer) Follows(record Record, prev *Record) bool {
+	if prev == nil {
+		return record.Seq == 1 && record.PrevSignature == ""
+	}
+	return record.Seq == prev.Seq+1 && hmac.Equal([]byte(record.PrevSignature), []byte(prev.Signature))
+}
+
+// Logger appends audit records to a writer as JSON lines
+type Logger struct {
+	mu     sync.Mutex
+	w      io.Writer
+	signer *Signer
+
+	// seq and prev are the sequence number and signature of the last record written, which the next
+	// record links on to
+	seq  uint64
+	prev string
+}
+
+// NewLogger creates a logger writing to w, signing records if a signer is provided
+func NewLogger(w io.Writer, signer *Signer) *Logger {
+	return &Logger{w: w, signer: signer}
+}
+
+// OpenFile creates a logger which appends to the file at path, creating it if needed and carrying on
+// the chain of records already in it
+func OpenFile(path string, signer *Signer) (*Logger, error) {
+	last, err := LastRecord(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := AppendFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := NewLogger(f, signer)
+	logger.ContinueFrom(last)
+	return logger, nil
+}
+
+// LastRecord returns the last record in the audit log at path, or the zero record if the log is
+// empty or doesn't exist yet
+func LastRecord(path string) (Record, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Record{}, nil
+	}
+	if err != nil {
+		return Record{}, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var last Record
+	err = readRecords(f, func(_ int, record Record) error {
+		last = record
+		return nil
+	})
+	return last, err
+}
+
+// ContinueFrom links the next record written on to last, so records appended to an existing log
+// carry on its chain rather than starting a new one
+func (l *Logger) ContinueFrom(last Record) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.seq = last.Seq
+	l.prev = last.Signature
+}
+
+// AppendFile opens the audit log at path for appending records, creating it if needed
+func AppendFile(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	return f, nil
+}
+
+// Log numbers the record, links it to the record before it, signs it if configured and appends it to
+// the log. The chain moves on even if a sink fails to write the record, leaving a gap which
+// verification reports rather than hiding it.
+func (l *Logger) Log(record Record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	record.Seq = l.seq + 1
+	record.PrevSignature = l.prev
+	if l.signer != nil {
+		signature, err := l.signer.Sign(record)
+		if err != nil {
+			return fmt.Errorf("failed to sign audit record: %w", err)
+		}
+		record.Signature = signature
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit record: %w", err)
+	}
+
+	l.seq = record.Seq
+	l.prev = record.Signature
+
+	_, err = l.w.Write(append(data, '\n'))
+	return err
+}
+
+// HashArguments returns a stable SHA-256 digest of the tool arguments, so
+// records can be correlated without storing potentially sensitive values.
+func HashArguments(args any) string {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ToolHandlerMiddleware records every invocation of a tool which is not annotated as read-only, along
+// with the principal making it as the actor
+func (l *Logger) ToolHandlerMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if isReadOnlyTool(ctx, request.Params.Name) {
+			return next(ctx, request)
+		}
+
+		record := Record{
+			Time:      time.Now().UTC(),
+			RequestID: trace.RequestIDFromContext(ctx),
+			Actor:     policy.PrincipalFromContext(ctx),
+			Tool:      request.Params.Name,
+			ArgsHash:  HashArguments(request.GetArguments()),
+			Status:    StatusSuccess,
+		}
+
+		result, err := next(ctx, request)
+		switch {
+		case err != nil:
+			record.Status = StatusError
+			record.Error = err.Error()
+		case result != nil && result.IsError:
+			record.Status = StatusError
+		}
+
+		if logErr := l.Log(record); logErr != nil {
+			log.Ctx(ctx).Error().Err(logErr).Str("mcp.tool.name", request.Params.Name).Msg("Failed to write audit record")
+		}
+
+		return result, err
+	}
+}
+
+func isReadOnlyTool(ctx context.Context, name string) bool {
+	s := server.ServerFromContext(ctx)
+	if s == nil {
+		return false
+	}
+
+	tool := s.GetTool(name)
+	if tool == nil || tool.Tool.Annotations.ReadOnlyHint == nil {
+		return false
+	}
+
+	return *tool.Tool.Annotations.ReadOnlyHint
+}
@@ -0,0 +1,187 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/policy"
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignerNilKey(t *testing.T) {
+	require.Nil(t, NewSigner(""))
+}
+
+func TestLoggerSignsRecords(t *testing.T) {
+	assert := require.New(t)
+
+	signer := NewSigner("secret")
+	buf := new(bytes.Buffer)
+	logger := NewLogger(buf, signer)
+
+	assert.NoError(logger.Log(Record{Time: time.Now().UTC(), Tool: "create_build", Status: StatusSuccess}))
+
+	var record Record
+	assert.NoError(json.Unmarshal(buf.Bytes(), &record))
+	assert.NotEmpty(record.Signature)
+	assert.True(signer.Verify(record))
+
+	record.Tool = "update_pipeline"
+	assert.False(signer.Verify(record))
+	assert.False(NewSigner("other").Verify(record))
+}
+
+func TestHashArguments(t *testing.T) {
+	assert := require.New(t)
+
+	a := HashArguments(map[string]any{"org_slug": "acme", "pipeline_slug": "web"})
+	b := HashArguments(map[string]any{"pipeline_slug": "web", "org_slug": "acme"})
+	assert.Equal(a, b)
+	assert.NotEqual(a, HashArguments(map[string]any{"org_slug": "other"}))
+}
+
+func TestToolHandlerMiddleware(t *testing.T) {
+	assert := require.New(t)
+
+	buf := new(bytes.Buffer)
+	logger := NewLogger(buf, nil)
+
+	handler := logger.ToolHandlerMiddleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultError("failed"), nil
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "create_build"
+	request.Params.Arguments = map[string]any{"org_slug": "acme"}
+
+	ctx := policy.WithPrincipal(trace.WithRequestID(context.Background(), "req-123"), "alice@example.com")
+	_, err := handler(ctx, request)
+	assert.NoError(err)
+
+	var record Record
+	assert.NoError(json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal("req-123", record.RequestID)
+	assert.Equal("alice@example.com", record.Actor)
+	assert.Equal("create_build", record.Tool)
+	assert.Equal(StatusError, record.Status)
+	assert.Equal(HashArguments(request.Params.Arguments), record.ArgsHash)
+	assert.Empty(record.Signature)
+}
+
+func TestExport(t *testing.T) {
+	assert := require.New(t)
+
+	signer := NewSigner("secret")
+	buf := new(bytes.Buffer)
+	logger := NewLogger(buf, signer)
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 3 {
+		assert.NoError(logger.Log(Record{Time: start.Add(time.Duration(i) * time.Hour), Tool: "create_build", Status: StatusSuccess}))
+	}
+
+	bundle, err := Export(bytes.NewReader(buf.Bytes()), signer, start.Add(time.Hour), start.Add(2*time.Hour))
+	assert.NoError(err)
+	assert.Len(bundle.Records, 1)
+	assert.Equal(start.Add(time.Hour), bundle.Records[0].Time)
+	assert.True(signer.VerifyBundle(*bundle))
+
+	bundle.Records[0].Tool = "unblock_job"
+	assert.False(signer.VerifyBundle(*bundle))
+
+	t.Run("open range", func(t *testing.T) {
+		bundle, err := Export(bytes.NewReader(buf.Bytes()), nil, time.Time{}, time.Time{})
+		require.NoError(t, err)
+		require.Len(t, bundle.Records, 3)
+		require.Empty(t, bundle.Signature)
+	})
+
+	t.Run("tampered record", func(t *testing.T) {
+		tampered := strings.Replace(buf.String(), "create_build", "delete_pipeline", 1)
+		_, err := Export(strings.NewReader(tampered), signer, time.Time{}, time.Time{})
+		require.ErrorContains(t, err, "line 1 failed signature verification")
+	})
+
+	lines := strings.SplitAfter(buf.String(), "\n")
+
+	t.Run("deleted record", func(t *testing.T) {
+		_, err := Export(strings.NewReader(lines[0]+lines[2]), signer, time.Time{}, time.Time{})
+		require.ErrorContains(t, err, "line 2 does not follow on")
+	})
+
+	t.Run("truncated log", func(t *testing.T) {
+		_, err := Export(strings.NewReader(lines[1]+lines[2]), signer, time.Time{}, time.Time{})
+		require.ErrorContains(t, err, "line 1 does not follow on")
+	})
+
+	t.Run("reordered records", func(t *testing.T) {
+		_, err := Export(strings.NewReader(lines[0]+lines[2]+lines[1]), signer, time.Time{}, time.Time{})
+		require.ErrorContains(t, err, "line 2 does not follow on")
+	})
+
+	t.Run("record dropped from bundle", func(t *testing.T) {
+		bundle, err := Export(bytes.NewReader(buf.Bytes()), signer, time.Time{}, time.Time{})
+		require.NoError(t, err)
+		require.True(t, signer.VerifyBundle(*bundle))
+
+		bundle.Records = append(bundle.Records[:1], bundle.Records[2:]...)
+		bundle.Signature, err = signer.SignBundle(*bundle)
+		require.NoError(t, err)
+		require.False(t, signer.VerifyBundle(*bundle))
+	})
+}
+
+func TestExportKeepsRunUnbroken(t *testing.T) {
+	assert := require.New(t)
+
+	signer := NewSigner("secret")
+	buf := new(bytes.Buffer)
+	logger := NewLogger(buf, signer)
+
+	// a long running call is written after calls made later than it started
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, hour := range []int{1, 0, 2, 3} {
+		assert.NoError(logger.Log(Record{Time: start.Add(time.Duration(hour) * time.Hour), Tool: "create_build", Status: StatusSuccess}))
+	}
+
+	bundle, err := Export(bytes.NewReader(buf.Bytes()), signer, start.Add(time.Hour), start.Add(3*time.Hour))
+	assert.NoError(err)
+	assert.Len(bundle.Records, 3)
+	assert.Equal([]uint64{1, 2, 3}, []uint64{bundle.Records[0].Seq, bundle.Records[1].Seq, bundle.Records[2].Seq})
+	assert.True(signer.VerifyBundle(*bundle))
+}
+
+func TestOpenFileContinuesChain(t *testing.T) {
+	assert := require.New(t)
+
+	signer := NewSigner("secret")
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	for range 2 {
+		logger, err := OpenFile(path, signer)
+		assert.NoError(err)
+		assert.NoError(logger.Log(Record{Time: time.Now().UTC(), Tool: "create_build", Status: StatusSuccess}))
+		assert.NoError(logger.w.(io.Closer).Close())
+	}
+
+	last, err := LastRecord(path)
+	assert.NoError(err)
+	assert.Equal(uint64(2), last.Seq)
+
+	f, err := os.Open(path)
+	assert.NoError(err)
+	defer f.Close()
+
+	bundle, err := Export(f, signer, time.Time{}, time.Time{})
+	assert.NoError(err)
+	assert.Len(bundle.Records, 2)
+}
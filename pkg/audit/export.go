@@ -0,0 +1,135 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Bundle is an exported slice of the audit log covering a time range
+type Bundle struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	Records     []Record  `json:"records"`
+	Signature   string    `json:"signature,omitempty"`
+}
+
+// Export reads JSON line records and returns those with a time in [from, to).
+// A zero from or to leaves that end of the range open.
+//
+// Records are timed when a tool is called but written when it finishes, so the bundle holds the
+// unbroken run of records from the first to the last in the range, which can include records of
+// calls made just outside it. A bundle never has gaps in its sequence numbers.
+//
+// When a signer is provided every record in the log must carry a valid signature and follow on from
+// the record before it, and the bundle itself is signed so it can be verified as a whole.
+func Export(r io.Reader, signer *Signer, from, to time.Time) (*Bundle, error) {
+	bundle := &Bundle{
+		GeneratedAt: time.Now().UTC(),
+		From:        from,
+		To:          to,
+		Records:     []Record{},
+	}
+
+	var prev *Record
+
+	// pending holds the records after the last one in the range, which are only exported if
+	// another record in the range follows them
+	var pending []Record
+
+	err := readRecords(r, func(line int, record Record) error {
+		if signer != nil {
+			if !signer.Verify(record) {
+				return fmt.Errorf("audit record on line %d failed signature verification", line)
+			}
+			if !signer.Follows(record, prev) {
+				return fmt.Errorf("audit record on line %d does not follow on from the record before it, so records have been removed or reordered", line)
+			}
+		}
+		prev = &record
+
+		inRange := (from.IsZero() || !record.Time.Before(from)) && (to.IsZero() || record.Time.Before(to))
+		if !inRange {
+			if len(bundle.Records) > 0 {
+				pending = append(pending, record)
+			}
+			return nil
+		}
+
+		bundle.Records = append(bundle.Records, pending...)
+		bundle.Records = append(bundle.Records, record)
+		pending = pending[:0]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if signer != nil {
+		signature, err := signer.SignBundle(*bundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign audit bundle: %w", err)
+		}
+		bundle.Signature = signature
+	}
+
+	return bundle, nil
+}
+
+// readRecords calls fn with each record read from the JSON lines, along with its line number
+func readRecords(r io.Reader, fn func(line int, record Record) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return fmt.Errorf("invalid audit record on line %d: %w", line, err)
+		}
+
+		if err := fn(line, record); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	return nil
+}
+
+// SignBundle returns the signature for the bundle
+func (s *Signer) SignBundle(bundle Bundle) (string, error) {
+	bundle.Signature = ""
+	return s.sign(bundle)
+}
+
+// VerifyBundle returns true if the bundle and every record in it carry valid signatures, and each
+// record follows on from the one before it
+func (s *Signer) VerifyBundle(bundle Bundle) bool {
+	expected, err := s.SignBundle(bundle)
+	if err != nil || !hmac.Equal([]byte(expected), []byte(bundle.Signature)) {
+		return false
+	}
+
+	for i, record := range bundle.Records {
+		if !s.Verify(record) {
+			return false
+		}
+		if i > 0 && !s.Follows(record, &bundle.Records[i-1]) {
+			return false
+		}
+	}
+
+	return true
+}
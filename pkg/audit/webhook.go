@@ -0,0 +1,163 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// webhookTimeout bounds how long the webhook has to accept each record
+	webhookTimeout = 10 * time.Second
+
+	// webhookQueueSize bounds how many records wait to be sent, past which records are dropped rather
+	// than holding up tool calls
+	webhookQueueSize = 1000
+)
+
+// ErrWebhookQueueFull is returned when a record is dropped because the webhook is too far behind
+var ErrWebhookQueueFull = errors.New("audit webhook is not keeping up, dropped record")
+
+// Webhook posts each record written to it to a URL as JSON, for collecting audit records somewhere
+// the server can't rewrite them. Records are queued and sent in the background, so a slow webhook
+// doesn't hold up the tool calls being audited.
+type Webhook struct {
+	url    string
+	client *http.Client
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan []byte
+	done   chan struct{}
+}
+
+// NewWebhook returns a webhook posting records to the http or https URL
+func NewWebhook(rawURL string) (*Webhook, error) {
+	return newWebhook(rawURL, webhookQueueSize)
+}
+
+func newWebhook(rawURL string, queueSize int) (*Webhook, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid audit webhook URL %q: must be an http or https URL", rawURL)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &Webhook{
+		url:    rawURL,
+		client: &http.Client{},
+		ctx:    ctx,
+		cancel: cancel,
+		queue:  make(chan []byte, queueSize),
+		done:   make(chan struct{}),
+	}
+	go w.run()
+
+	return w, nil
+}
+
+// Write queues the JSON record to be sent, failing with ErrWebhookQueueFull rather than waiting
+// when the queue is full
+func (w *Webhook) Write(p []byte) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return 0, errors.New("audit webhook is closed")
+	}
+
+	select {
+	case w.queue <- bytes.Clone(bytes.TrimSpace(p)):
+		return len(p), nil
+	default:
+		return 0, ErrWebhookQueueFull
+	}
+}
+
+// Close stops accepting records and waits for those queued to be sent, giving up on any still
+// queued once ctx is done
+func (w *Webhook) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		w.cancel()
+		<-w.done
+		return fmt.Errorf("audit webhook records were not all sent: %w", ctx.Err())
+	}
+}
+
+func (w *Webhook) run() {
+	defer close(w.done)
+	defer w.cancel()
+
+	for record := range w.queue {
+		if err := w.send(record); err != nil {
+			log.Error().Err(err).Msg("Failed to send audit record to webhook")
+		}
+	}
+}
+
+// send posts the record, failing unless the webhook answers with a 2xx status
+func (w *Webhook) send(record []byte) error {
+	ctx, cancel := context.WithTimeout(w.ctx, webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(record))
+	if err != nil {
+		return fmt.Errorf("failed to create audit webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send audit record to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("audit webhook responded with %s", resp.Status)
+	}
+
+	return nil
+}
+
+// Sinks returns a writer writing each record to every writer, unlike io.MultiWriter carrying on
+// past a writer which fails so one unavailable sink doesn't lose the record from the others
+func Sinks(writers ...io.Writer) io.Writer {
+	if len(writers) == 1 {
+		return writers[0]
+	}
+	return sinks(writers)
+}
+
+type sinks []io.Writer
+
+func (s sinks) Write(p []byte) (int, error) {
+	var errs []error
+	for _, w := range s {
+		if _, err := w.Write(p); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return len(p), errors.Join(errs...)
+}
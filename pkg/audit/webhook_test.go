@@ -0,0 +1,89 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestWebhook(t *testing.T) {
+	assert := require.New(t)
+
+	received := make(chan Record, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(http.MethodPost, r.Method)
+		assert.Equal("application/json", r.Header.Get("Content-Type"))
+
+		var record Record
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(err)
+		assert.NoError(json.Unmarshal(body, &record))
+		received <- record
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	webhook, err := NewWebhook(srv.URL)
+	assert.NoError(err)
+
+	signer := NewSigner("secret")
+	file := new(bytes.Buffer)
+	logger := NewLogger(Sinks(failingWriter{}, file, webhook), signer)
+
+	// a failing sink is reported without losing the record from the others
+	err = logger.Log(Record{Time: time.Now().UTC(), Tool: "create_build", Actor: "alice", Status: StatusSuccess})
+	assert.ErrorContains(err, "disk full")
+	assert.NotEmpty(file.String())
+
+	assert.NoError(webhook.Close(context.Background()))
+	record := <-received
+	assert.Equal("alice", record.Actor)
+	assert.True(signer.Verify(record))
+
+	_, err = webhook.Write([]byte("{}"))
+	assert.ErrorContains(err, "closed")
+
+	_, err = NewWebhook("file:///var/log/audit")
+	assert.Error(err)
+}
+
+func TestWebhookDropsRecordsWhenBehind(t *testing.T) {
+	assert := require.New(t)
+
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	webhook, err := newWebhook(srv.URL, 1)
+	assert.NoError(err)
+
+	logger := NewLogger(webhook, nil)
+
+	// the first record is being sent and the second waits in the queue, so logging the third
+	// reports the drop rather than waiting on the webhook
+	assert.NoError(logger.Log(Record{Tool: "create_build"}))
+	assert.Eventually(func() bool { return len(webhook.queue) == 0 }, time.Second, time.Millisecond)
+	assert.NoError(logger.Log(Record{Tool: "create_build"}))
+	assert.ErrorIs(logger.Log(Record{Tool: "create_build"}), ErrWebhookQueueFull)
+
+	// records still queued are given up on once the context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(webhook.Close(ctx), context.DeadlineExceeded)
+}
@@ -0,0 +1,269 @@
+// Package breaker stops calling parts of the Buildkite API which keep failing, so a failing
+// endpoint fails fast instead of each call waiting to time out.
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog/log"
+)
+
+// UnavailableError is the code of the structured error returned for tool calls failed by an open breaker
+const UnavailableError = "temporarily_unavailable"
+
+type state int
+
+const (
+	closed state = iota
+	open
+	halfOpen
+)
+
+// OpenError is returned for requests to an endpoint class whose breaker is open
+type OpenError struct {
+	Class      string
+	RetryAfter time.Duration
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("the Buildkite %s API is temporarily unavailable after repeated failures, retry in %s", e.Class, e.RetryAfter.Round(time.Second))
+}
+
+// Unavailable is the structured content of a tool result failed by an open breaker
+type Unavailable struct {
+	Error             string `json:"error"`
+	EndpointClass     string `json:"endpoint_class"`
+	RetryAfterSeconds int    `json:"retry_after_seconds"`
+	Reason            string `json:"reason"`
+}
+
+type endpoint struct {
+	state    state
+	failures int
+	openedAt time.Time
+}
+
+// Breaker tracks the failures of each class of API endpoint, such as artifacts or builds. After
+// threshold consecutive failures the class is opened and its requests fail fast. Once cooldown has
+// passed a single probe request is let through, closing the class again if it succeeds.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	endpoints map[string]*endpoint
+}
+
+// New returns a breaker opening after threshold consecutive failures, or nil if threshold is 0,
+// which never opens
+func New(threshold int, cooldown time.Duration) *Breaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &Breaker{threshold: threshold, cooldown: cooldown, now: time.Now, endpoints: map[string]*endpoint{}}
+}
+
+// allow returns an error if requests to the class should fail fast
+func (b *Breaker) allow(class string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	e, ok := b.endpoints[class]
+	if !ok {
+		return nil
+	}
+
+	switch e.state {
+	case open:
+		if elapsed := b.now().Sub(e.openedAt); elapsed < b.cooldown {
+			return &OpenError{Class: class, RetryAfter: b.cooldown - elapsed}
+		}
+		// let this request through as the probe
+		e.state = halfOpen
+		return nil
+	case halfOpen:
+		return &OpenError{Class: class, RetryAfter: b.cooldown}
+	default:
+		return nil
+	}
+}
+
+func (b *Breaker) record(class string, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	e, ok := b.endpoints[class]
+	if !ok {
+		e = &endpoint{}
+		b.endpoints[class] = e
+	}
+
+	if !failed {
+		if e.state != closed {
+			log.Info().Str("endpoint_class", class).Msg("Buildkite API recovered, closing circuit breaker")
+		}
+		*e = endpoint{}
+		return
+	}
+
+	e.failures++
+	if e.state == halfOpen || e.failures >= b.threshold {
+		if e.state != open {
+			log.Warn().Str("endpoint_class", class).Int("failures", e.failures).Dur("cooldown", b.cooldown).Msg("Buildkite API failing, opening circuit breaker")
+		}
+		e.state = open
+		e.openedAt = b.now()
+	}
+}
+
+// release lets another request probe the class after a probe was cancelled
+func (b *Breaker) release(class string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if e, ok := b.endpoints[class]; ok && e.state == halfOpen {
+		e.state = open
+	}
+}
+
+// isFailure is whether a response means the endpoint is unhealthy. Client errors such as not found
+// are the caller's problem, not the endpoint's.
+func isFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
+
+// Transport wraps the transport of the API client with the breaker. A nil breaker returns the
+// transport unchanged.
+func (b *Breaker) Transport(next http.RoundTripper) http.RoundTripper {
+	if b == nil {
+		return next
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{breaker: b, next: next}
+}
+
+type transport struct {
+	breaker *Breaker
+	next    http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	class := EndpointClass(req.URL.Path)
+
+	if err := t.breaker.allow(class); err != nil {
+		if t, ok := req.Context().Value(tripKey{}).(*trip); ok {
+			t.set(err)
+		}
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+
+	// calls cancelled by the caller say nothing about the endpoint
+	if err != nil && errors.Is(err, context.Canceled) {
+		t.breaker.release(class)
+		return resp, err
+	}
+
+	t.breaker.record(class, isFailure(resp, err))
+	return resp, err
+}
+
+// actions are path segments which act on a resource rather than name a collection of them
+var actions = map[string]bool{
+	"download": true,
+	"cancel":   true,
+	"rebuild":  true,
+	"retry":    true,
+	"unblock":  true,
+	"archive":  true,
+	"webhook":  true,
+}
+
+// EndpointClass groups API paths by the resource they act on, such as artifacts, builds or log, so a
+// failing part of the API doesn't open the breaker for the rest of it
+func EndpointClass(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	// ignore any path prefix of the base URL
+	for i, segment := range segments {
+		if segment == "v2" {
+			segments = segments[i+1:]
+			break
+		}
+	}
+
+	// paths alternate between collections and identifiers, e.g.
+	// organizations/{org}/pipelines/{pipeline}/builds/{number}/jobs/{id}/log
+	class := ""
+	for i := 0; i < len(segments); i += 2 {
+		if actions[segments[i]] {
+			break
+		}
+		class = segments[i]
+	}
+	if class == "" {
+		return "api"
+	}
+	return class
+}
+
+type tripKey struct{}
+
+// trip records the open breaker a tool call's request failed on, as tools report API errors as text
+type trip struct {
+	mu  sync.Mutex
+	err *OpenError
+}
+
+func (t *trip) set(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	errors.As(err, &t.err)
+}
+
+func (t *trip) get() *OpenError {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+// ToolHandlerMiddleware replaces the result of tool calls which failed on an open breaker with a
+// structured error, telling the client when to retry
+func (b *Breaker) ToolHandlerMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		t := &trip{}
+		result, err := next(context.WithValue(ctx, tripKey{}, t), request)
+
+		openErr := t.get()
+		if openErr == nil || (err == nil && result != nil && !result.IsError) {
+			return result, err
+		}
+
+		log.Ctx(ctx).Warn().Str("mcp.tool.name", request.Params.Name).Str("endpoint_class", openErr.Class).Msg("Tool call failed by open circuit breaker")
+
+		unavailable := mcp.NewToolResultStructured(Unavailable{
+			Error:             UnavailableError,
+			EndpointClass:     openErr.Class,
+			RetryAfterSeconds: int(math.Ceil(openErr.RetryAfter.Seconds())),
+			Reason:            openErr.Error(),
+		}, openErr.Error())
+		unavailable.IsError = true
+		return unavailable, nil
+	}
+}
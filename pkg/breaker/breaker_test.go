@@ -0,0 +1,121 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointClass(t *testing.T) {
+	assert := require.New(t)
+
+	assert.Equal("artifacts", EndpointClass("/v2/organizations/acme/pipelines/web/builds/1/jobs/abc/artifacts/def/download"))
+	assert.Equal("artifacts", EndpointClass("/v2/organizations/acme/pipelines/web/builds/1/artifacts"))
+	assert.Equal("log", EndpointClass("/v2/organizations/acme/pipelines/web/builds/1/jobs/abc/log"))
+	assert.Equal("builds", EndpointClass("/v2/organizations/acme/pipelines/web/builds/1/rebuild"))
+	assert.Equal("builds", EndpointClass("/proxy/v2/organizations/acme/builds"))
+	assert.Equal("pipelines", EndpointClass("/v2/organizations/acme/pipelines"))
+	assert.Equal("user", EndpointClass("/v2/user"))
+	assert.Equal("api", EndpointClass("/"))
+}
+
+func TestTransport(t *testing.T) {
+	assert := require.New(t)
+
+	var requests atomic.Int32
+	var healthy atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if !healthy.Load() && r.URL.Path == "/v2/organizations/acme/pipelines/web/builds/1/artifacts" {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("[]"))
+	}))
+	defer srv.Close()
+
+	now := time.Now()
+	b := New(2, 30*time.Second)
+	b.now = func() time.Time { return now }
+	client := &http.Client{Transport: b.Transport(http.DefaultTransport)}
+
+	get := func(path string) error {
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	artifacts := "/v2/organizations/acme/pipelines/web/builds/1/artifacts"
+	assert.NoError(get(artifacts))
+	assert.NoError(get(artifacts))
+	assert.Equal(int32(2), requests.Load())
+
+	// the breaker is open, so requests fail fast without reaching the server
+	var openErr *OpenError
+	assert.ErrorAs(get(artifacts), &openErr)
+	assert.Equal("artifacts", openErr.Class)
+	assert.Equal(int32(2), requests.Load())
+
+	// other endpoints are unaffected
+	assert.NoError(get("/v2/organizations/acme/pipelines/web/builds"))
+
+	// a failed probe after the cooldown opens the breaker again
+	now = now.Add(31 * time.Second)
+	assert.NoError(get(artifacts))
+	assert.ErrorAs(get(artifacts), &openErr)
+
+	// a successful probe closes it
+	healthy.Store(true)
+	now = now.Add(31 * time.Second)
+	assert.NoError(get(artifacts))
+	assert.NoError(get(artifacts))
+}
+
+func TestNewDisabled(t *testing.T) {
+	assert := require.New(t)
+
+	b := New(0, time.Second)
+	assert.Nil(b)
+	assert.Equal(http.DefaultTransport, b.Transport(http.DefaultTransport))
+}
+
+func TestToolHandlerMiddleware(t *testing.T) {
+	assert := require.New(t)
+
+	b := New(1, time.Minute)
+	b.record("artifacts", true)
+
+	handler := b.ToolHandlerMiddleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.buildkite.com/v2/organizations/acme/pipelines/web/builds/1/artifacts", nil)
+		assert.NoError(err)
+
+		_, err = b.Transport(http.DefaultTransport).RoundTrip(req)
+		return mcp.NewToolResultError(err.Error()), nil
+	})
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	assert.NoError(err)
+	assert.True(result.IsError)
+
+	unavailable, ok := result.StructuredContent.(Unavailable)
+	assert.True(ok)
+	assert.Equal(UnavailableError, unavailable.Error)
+	assert.Equal("artifacts", unavailable.EndpointClass)
+	assert.Equal(60, unavailable.RetryAfterSeconds)
+
+	// results of calls which didn't fail on the breaker are left alone
+	handler = b.ToolHandlerMiddleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return nil, errors.New("boom")
+	})
+	_, err = handler(context.Background(), mcp.CallToolRequest{})
+	assert.EqualError(err, "boom")
+}
@@ -0,0 +1,301 @@
+package buildkite
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	AgentStateIdle = "idle"
+	AgentStateBusy = "busy"
+)
+
+// AgentCurrentJob is the job an agent is running
+type AgentCurrentJob struct {
+	ID     string `json:"id"`
+	Label  string `json:"label"`
+	State  string `json:"state"`
+	WebURL string `json:"web_url,omitempty"`
+}
+
+// AgentSummary describes an agent without its access token
+type AgentSummary struct {
+	ID                string               `json:"id"`
+	Name              string               `json:"name"`
+	ConnectionState   string               `json:"connection_state"`
+	Busy              bool                 `json:"busy"`
+	Queue             string               `json:"queue,omitempty"`
+	Hostname          string               `json:"hostname,omitempty"`
+	IPAddress         string               `json:"ip_address,omitempty"`
+	Version           string               `json:"version,omitempty"`
+	Tags              []string             `json:"tags,omitempty"`
+	CreatedAt         *buildkite.Timestamp `json:"created_at,omitempty"`
+	LastJobFinishedAt *buildkite.Timestamp `json:"last_job_finished_at,omitempty"`
+	Job               *AgentCurrentJob     `json:"job,omitempty"`
+	WebURL            string               `json:"web_url,omitempty"`
+}
+
+// summarizeAgent converts an agent to a summary, leaving out its access token
+func summarizeAgent(agent buildkite.Agent) AgentSummary {
+	summary := AgentSummary{
+		ID:                agent.ID,
+		Name:              agent.Name,
+		ConnectionState:   agent.ConnectedState,
+		Busy:              agent.Job != nil,
+		Hostname:          agent.Hostname,
+		IPAddress:         agent.IPAddress,
+		Version:           agent.Version,
+		Tags:              agent.Metadata,
+		CreatedAt:         agent.CreatedAt,
+		LastJobFinishedAt: agent.LastJobFinishedAt,
+		WebURL:            agent.WebURL,
+	}
+
+	for _, tag := range agent.Metadata {
+		if queue, ok := strings.CutPrefix(tag, "queue="); ok {
+			summary.Queue = queue
+		}
+	}
+
+	if agent.Job != nil {
+		summary.Job = &AgentCurrentJob{
+			ID:     agent.Job.ID,
+			Label:  jobLabel(*agent.Job),
+			State:  agent.Job.State,
+			WebURL: agent.Job.WebURL,
+		}
+	}
+
+	return summary
+}
+
+type ListAgentsArgs struct {
+	OrgSlug  string `json:"org_slug"`
+	Name     string `json:"name"`
+	Hostname string `json:"hostname"`
+	Version  string `json:"version"`
+	State    string `json:"state"`
+	Queue    string `json:"queue"`
+}
+
+func ListAgents(client AgentsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[ListAgentsArgs], scopes []string) {
+	return mcp.NewTool("list_agents",
+			mcp.WithDescription("List the agents connected to an organization with their connection state, whether they are idle or busy, queue, host, version, tags and the job they are running"),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("name",
+				mcp.Description("Filter agents by name"),
+			),
+			mcp.WithString("hostname",
+				mcp.Description("Filter agents by hostname"),
+			),
+			mcp.WithString("version",
+				mcp.Description("Filter agents by exact agent version"),
+			),
+			mcp.WithString("state",
+				mcp.Description("Filter agents by whether they are running a job. The API can't filter on this, so it's applied to each page and pages may hold fewer than perPage agents"),
+				mcp.Enum(AgentStateIdle, AgentStateBusy),
+			),
+			mcp.WithString("queue",
+				mcp.Description("Filter agents by their queue tag. Applied to each page like state"),
+			),
+			withPagination(),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "List Agents",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args ListAgentsArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.ListAgents")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.State != "" && args.State != AgentStateIdle && args.State != AgentStateBusy {
+				return mcp.NewToolResultError(fmt.Sprintf("state must be one of: %s, %s", AgentStateIdle, AgentStateBusy)), nil
+			}
+
+			paginationParams, err := optionalPaginationParams(request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("name", args.Name),
+				attribute.String("hostname", args.Hostname),
+				attribute.String("version", args.Version),
+				attribute.String("state", args.State),
+				attribute.String("queue", args.Queue),
+				attribute.Int("page", paginationParams.Page),
+				attribute.Int("per_page", paginationParams.PerPage),
+			)
+
+			agents, resp, err := client.List(ctx, args.OrgSlug, &buildkite.AgentListOptions{
+				Name:        args.Name,
+				Hostname:    args.Hostname,
+				Version:     args.Version,
+				ListOptions: paginationParams,
+			})
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			summaries := []AgentSummary{}
+			for _, agent := range agents {
+				summary := summarizeAgent(agent)
+				if args.State != "" && summary.Busy != (args.State == AgentStateBusy) {
+					continue
+				}
+				if args.Queue != "" && summary.Queue != args.Queue {
+					continue
+				}
+				summaries = append(summaries, summary)
+			}
+
+			result := PaginatedResult[AgentSummary]{
+				Items: summaries,
+				Headers: map[string]string{
+					"Link": resp.Header.Get("Link"),
+				},
+			}
+
+			span.SetAttributes(
+				attribute.Int("item_count", len(summaries)),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_agents"}
+}
+
+type GetAgentArgs struct {
+	OrgSlug string `json:"org_slug"`
+	AgentID string `json:"agent_id"`
+}
+
+func GetAgent(client AgentsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[GetAgentArgs], scopes []string) {
+	return mcp.NewTool("get_agent",
+			mcp.WithDescription("Get an agent's connection state, queue, host, version, tags and the job it is running"),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("agent_id",
+				mcp.Required(),
+				mcp.Description("The UUID of the agent"),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Get Agent",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args GetAgentArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.GetAgent")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.AgentID == "" {
+				return mcp.NewToolResultError("agent_id parameter is required"), nil
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("agent_id", args.AgentID),
+			)
+
+			agent, _, err := client.Get(ctx, args.OrgSlug, args.AgentID)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			summary := summarizeAgent(agent)
+			return mcpTextResult(span, &summary)
+		}, []string{"read_agents"}
+}
+
+type StopAgentArgs struct {
+	OrgSlug string `json:"org_slug"`
+	AgentID string `json:"agent_id"`
+	Force   bool   `json:"force"`
+}
+
+// StopAgentResult is the agent which was asked to stop
+type StopAgentResult struct {
+	AgentID string `json:"agent_id"`
+	Force   bool   `json:"force"`
+	Message string `json:"message"`
+}
+
+// stoppableAgentStates are the connection states of agents which can be stopped
+var stoppableAgentStates = []string{"connected", "stopping"}
+
+func StopAgent(client AgentsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[StopAgentArgs], scopes []string) {
+	return mcp.NewTool("stop_agent",
+			mcp.WithDescription("Stop an agent, for example one stuck on a job. By default the agent finishes its current job before stopping; set force to stop it immediately, cancelling the job it is running"),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("agent_id",
+				mcp.Required(),
+				mcp.Description("The UUID of the agent"),
+			),
+			mcp.WithBoolean("force",
+				mcp.Description("Stop the agent immediately, cancelling its current job, instead of waiting for the job to finish"),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:           "Stop Agent",
+				ReadOnlyHint:    mcp.ToBoolPtr(false),
+				DestructiveHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args StopAgentArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.StopAgent")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.AgentID == "" {
+				return mcp.NewToolResultError("agent_id parameter is required"), nil
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("agent_id", args.AgentID),
+				attribute.Bool("force", args.Force),
+			)
+
+			agent, _, err := client.Get(ctx, args.OrgSlug, args.AgentID)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if !slices.Contains(stoppableAgentStates, agent.ConnectedState) {
+				return mcp.NewToolResultError(fmt.Sprintf("agent %s can't be stopped as it is %s", args.AgentID, agent.ConnectedState)), nil
+			}
+
+			if _, err := client.Stop(ctx, args.OrgSlug, args.AgentID, args.Force); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			result := StopAgentResult{
+				AgentID: args.AgentID,
+				Force:   args.Force,
+				Message: "agent will stop after finishing its current job",
+			}
+			if args.Force || agent.Job == nil {
+				result.Message = "agent is stopping"
+			}
+
+			return mcpTextResult(span, &result)
+		}, []string{"write_agents"}
+}
@@ -0,0 +1,112 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListAgents(t *testing.T) {
+	assert := require.New(t)
+
+	var capturedOptions *buildkite.AgentListOptions
+	client := &MockAgentsClient{
+		ListFunc: func(ctx context.Context, org string, opt *buildkite.AgentListOptions) ([]buildkite.Agent, *buildkite.Response, error) {
+			capturedOptions = opt
+			return []buildkite.Agent{
+				{ID: "agent-1", Name: "idle", ConnectedState: "connected", AgentToken: "secret", Metadata: []string{"queue=default"}},
+				{ID: "agent-2", Name: "busy", ConnectedState: "connected", Metadata: []string{"queue=default"}, Job: &buildkite.Job{ID: "job-1", Label: "test", State: "running"}},
+				{ID: "agent-3", Name: "other", ConnectedState: "connected", Metadata: []string{"queue=deploy"}},
+			}, &buildkite.Response{Response: &http.Response{StatusCode: 200}}, nil
+		},
+	}
+
+	tool, handler, scopes := ListAgents(client)
+	assert.Equal("list_agents", tool.Name)
+	assert.True(*tool.Annotations.ReadOnlyHint)
+	assert.Equal([]string{"read_agents"}, scopes)
+
+	request := createMCPRequest(t, map[string]any{"page": 2, "perPage": 10})
+	result, err := handler(context.Background(), request, ListAgentsArgs{OrgSlug: "org", Hostname: "host-1", Queue: "default"})
+	assert.NoError(err)
+
+	text := getTextResult(t, result).Text
+	assert.NotContains(text, "secret")
+	assert.Equal("host-1", capturedOptions.Hostname)
+	assert.Equal(2, capturedOptions.Page)
+
+	var agents PaginatedResult[AgentSummary]
+	assert.NoError(json.Unmarshal([]byte(text), &agents))
+	assert.Len(agents.Items, 2)
+	assert.False(agents.Items[0].Busy)
+	assert.Equal("default", agents.Items[0].Queue)
+	assert.True(agents.Items[1].Busy)
+	assert.Equal("test", agents.Items[1].Job.Label)
+
+	result, err = handler(context.Background(), request, ListAgentsArgs{OrgSlug: "org", State: AgentStateBusy})
+	assert.NoError(err)
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &agents))
+	assert.Len(agents.Items, 1)
+	assert.Equal("agent-2", agents.Items[0].ID)
+}
+
+func TestGetAgent(t *testing.T) {
+	assert := require.New(t)
+
+	client := &MockAgentsClient{
+		GetFunc: func(ctx context.Context, org, id string) (buildkite.Agent, *buildkite.Response, error) {
+			return buildkite.Agent{ID: id, Name: "agent", ConnectedState: "connected", AgentToken: "secret"}, &buildkite.Response{}, nil
+		},
+	}
+
+	_, handler, _ := GetAgent(client)
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, GetAgentArgs{OrgSlug: "org", AgentID: "agent-1"})
+	assert.NoError(err)
+
+	text := getTextResult(t, result).Text
+	assert.Contains(text, `"id":"agent-1"`)
+	assert.NotContains(text, "secret")
+
+	result, err = handler(context.Background(), mcp.CallToolRequest{}, GetAgentArgs{OrgSlug: "org"})
+	assert.NoError(err)
+	assert.True(result.IsError)
+}
+
+func TestStopAgent(t *testing.T) {
+	assert := require.New(t)
+
+	state := "connected"
+	var stopped bool
+	var forced bool
+	client := &MockAgentsClient{
+		GetFunc: func(ctx context.Context, org, id string) (buildkite.Agent, *buildkite.Response, error) {
+			return buildkite.Agent{ID: id, ConnectedState: state, Job: &buildkite.Job{ID: "job-1"}}, &buildkite.Response{}, nil
+		},
+		StopFunc: func(ctx context.Context, org, id string, force bool) (*buildkite.Response, error) {
+			stopped, forced = true, force
+			return &buildkite.Response{}, nil
+		},
+	}
+
+	tool, handler, scopes := StopAgent(client)
+	assert.False(*tool.Annotations.ReadOnlyHint)
+	assert.Equal([]string{"write_agents"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, StopAgentArgs{OrgSlug: "org", AgentID: "agent-1", Force: true})
+	assert.NoError(err)
+	assert.True(stopped)
+	assert.True(forced)
+	assert.Contains(getTextResult(t, result).Text, "agent is stopping")
+
+	stopped = false
+	state = "disconnected"
+	result, err = handler(context.Background(), mcp.CallToolRequest{}, StopAgentArgs{OrgSlug: "org", AgentID: "agent-1"})
+	assert.NoError(err)
+	assert.True(result.IsError)
+	assert.False(stopped)
+}
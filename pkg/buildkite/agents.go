@@ -0,0 +1,216 @@
+package buildkite
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// AgentsClient describes the subset of the Buildkite client we need for agents.
+type AgentsClient interface {
+	Get(ctx context.Context, org, id string) (buildkite.Agent, *buildkite.Response, error)
+	List(ctx context.Context, org string, opt *buildkite.AgentListOptions) ([]buildkite.Agent, *buildkite.Response, error)
+	Stop(ctx context.Context, org, id string, force bool) (*buildkite.Response, error)
+}
+
+// agentUserAgentPlatform extracts the platform from an agent user agent such as
+// "buildkite-agent/3.80.0.10000 (linux; amd64)"
+var agentUserAgentPlatform = regexp.MustCompile(`\(([^;)]+);\s*([^)]+)\)`)
+
+type GetJobAgentInfoArgs struct {
+	OrgSlug      string `json:"org_slug"`
+	PipelineSlug string `json:"pipeline_slug"`
+	BuildNumber  string `json:"build_number"`
+	JobID        string `json:"job_id"`
+}
+
+// AgentJob is a job running on an agent
+type AgentJob struct {
+	AgentName string `json:"agent_name"`
+	JobID     string `json:"job_id"`
+	Label     string `json:"label"`
+	State     string `json:"state"`
+	WebURL    string `json:"web_url,omitempty"`
+	SameBuild bool   `json:"same_build,omitempty"`
+}
+
+// JobAgentInfo describes the agent and host a job ran on
+type JobAgentInfo struct {
+	JobID             string               `json:"job_id"`
+	JobState          string               `json:"job_state"`
+	ExitStatus        *int                 `json:"exit_status,omitempty"`
+	AgentID           string               `json:"agent_id"`
+	AgentName         string               `json:"agent_name"`
+	Queue             string               `json:"queue,omitempty"`
+	Hostname          string               `json:"hostname,omitempty"`
+	IPAddress         string               `json:"ip_address,omitempty"`
+	OS                string               `json:"os,omitempty"`
+	Arch              string               `json:"arch,omitempty"`
+	Version           string               `json:"version,omitempty"`
+	ConnectionState   string               `json:"connection_state,omitempty"`
+	LastJobFinishedAt *buildkite.Timestamp `json:"last_job_finished_at,omitempty"`
+	Tags              []string             `json:"tags,omitempty"`
+	ConcurrentJobs    []AgentJob           `json:"concurrent_jobs"`
+	Note              string               `json:"note,omitempty"`
+}
+
+// newJobAgentInfo combines a job with the current details of the agent which ran it
+func newJobAgentInfo(job buildkite.Job, agent buildkite.Agent) JobAgentInfo {
+	info := JobAgentInfo{
+		JobID:             job.ID,
+		JobState:          job.State,
+		ExitStatus:        job.ExitStatus,
+		AgentID:           agent.ID,
+		AgentName:         agent.Name,
+		Hostname:          agent.Hostname,
+		IPAddress:         agent.IPAddress,
+		Version:           agent.Version,
+		ConnectionState:   agent.ConnectedState,
+		LastJobFinishedAt: agent.LastJobFinishedAt,
+		Tags:              agent.Metadata,
+		ConcurrentJobs:    []AgentJob{},
+	}
+
+	for _, tag := range agent.Metadata {
+		key, value, _ := strings.Cut(tag, "=")
+		switch key {
+		case "queue":
+			info.Queue = value
+		case "os":
+			info.OS = value
+		case "arch":
+			info.Arch = value
+		}
+	}
+
+	if match := agentUserAgentPlatform.FindStringSubmatch(agent.UserAgent); match != nil {
+		if info.OS == "" {
+			info.OS = match[1]
+		}
+		if info.Arch == "" {
+			info.Arch = match[2]
+		}
+	}
+
+	return info
+}
+
+func GetJobAgentInfo(buildsClient BuildsClient, agentsClient AgentsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[GetJobAgentInfoArgs], scopes []string) {
+	return mcp.NewTool("get_job_agent_info",
+			mcp.WithDescription("Get the agent and host a job ran on: queue, hostname, OS, agent version, tags, current connection state, and the jobs currently running on agents on the same host. Use this to tell failures caused by a bad host apart from failures caused by the code."),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("build_number",
+				mcp.Required(),
+			),
+			mcp.WithString("job_id",
+				mcp.Required(),
+				mcp.Description("The UUID of the job"),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Get Job Agent Info",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args GetJobAgentInfoArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.GetJobAgentInfo")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if args.BuildNumber == "" {
+				return mcp.NewToolResultError("build_number parameter is required"), nil
+			}
+			if args.JobID == "" {
+				return mcp.NewToolResultError("job_id parameter is required"), nil
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+				attribute.String("build_number", args.BuildNumber),
+				attribute.String("job_id", args.JobID),
+			)
+
+			build, _, err := buildsClient.Get(ctx, args.OrgSlug, args.PipelineSlug, args.BuildNumber, &buildkite.BuildGetOptions{})
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			var job *buildkite.Job
+			for i := range build.Jobs {
+				if build.Jobs[i].ID == args.JobID {
+					job = &build.Jobs[i]
+					break
+				}
+			}
+			if job == nil {
+				return mcp.NewToolResultError(fmt.Sprintf("job %s not found in build %s", args.JobID, args.BuildNumber)), nil
+			}
+			if job.Agent.ID == "" {
+				return mcp.NewToolResultError(fmt.Sprintf("job %s has not been assigned to an agent", args.JobID)), nil
+			}
+
+			agent, _, err := agentsClient.Get(ctx, args.OrgSlug, job.Agent.ID)
+			if err != nil {
+				// agents are removed shortly after they disconnect, fall back to the snapshot on the job
+				agent = job.Agent
+			}
+
+			info := newJobAgentInfo(*job, agent)
+			if err != nil {
+				info.Note = fmt.Sprintf("agent is no longer registered, showing the details recorded on the job: %v", err)
+			}
+
+			if agent.Hostname != "" {
+				agents, _, err := agentsClient.List(ctx, args.OrgSlug, &buildkite.AgentListOptions{
+					Hostname:    agent.Hostname,
+					ListOptions: buildkite.ListOptions{PerPage: 100},
+				})
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+
+				for _, hostAgent := range agents {
+					if hostAgent.Job == nil || hostAgent.Job.ID == job.ID {
+						continue
+					}
+					info.ConcurrentJobs = append(info.ConcurrentJobs, AgentJob{
+						AgentName: hostAgent.Name,
+						JobID:     hostAgent.Job.ID,
+						Label:     jobLabel(*hostAgent.Job),
+						State:     hostAgent.Job.State,
+						WebURL:    hostAgent.Job.WebURL,
+						SameBuild: buildWebURL(hostAgent.Job.WebURL) == buildWebURL(job.WebURL),
+					})
+				}
+			}
+
+			span.SetAttributes(
+				attribute.String("agent_id", info.AgentID),
+				attribute.Int("concurrent_jobs", len(info.ConcurrentJobs)),
+			)
+
+			return mcpTextResult(span, &info)
+		}, []string{"read_builds", "read_agents"}
+}
+
+// buildWebURL strips the job anchor from a job web URL, leaving the URL of its build
+func buildWebURL(jobWebURL string) string {
+	build, _, _ := strings.Cut(jobWebURL, "#")
+	return build
+}
@@ -0,0 +1,133 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+type MockAgentsClient struct {
+	GetFunc  func(ctx context.Context, org, id string) (buildkite.Agent, *buildkite.Response, error)
+	ListFunc func(ctx context.Context, org string, opt *buildkite.AgentListOptions) ([]buildkite.Agent, *buildkite.Response, error)
+	StopFunc func(ctx context.Context, org, id string, force bool) (*buildkite.Response, error)
+}
+
+func (m *MockAgentsClient) Get(ctx context.Context, org, id string) (buildkite.Agent, *buildkite.Response, error) {
+	if m.GetFunc != nil {
+		return m.GetFunc(ctx, org, id)
+	}
+	return buildkite.Agent{}, nil, nil
+}
+
+func (m *MockAgentsClient) List(ctx context.Context, org string, opt *buildkite.AgentListOptions) ([]buildkite.Agent, *buildkite.Response, error) {
+	if m.ListFunc != nil {
+		return m.ListFunc(ctx, org, opt)
+	}
+	return nil, nil, nil
+}
+
+func (m *MockAgentsClient) Stop(ctx context.Context, org, id string, force bool) (*buildkite.Response, error) {
+	if m.StopFunc != nil {
+		return m.StopFunc(ctx, org, id, force)
+	}
+	return nil, nil
+}
+
+var _ AgentsClient = (*MockAgentsClient)(nil)
+
+func TestGetJobAgentInfo(t *testing.T) {
+	assert := require.New(t)
+
+	buildsClient := &MockBuildsClient{
+		GetFunc: func(ctx context.Context, org string, pipeline string, id string, opt *buildkite.BuildGetOptions) (buildkite.Build, *buildkite.Response, error) {
+			return buildkite.Build{Jobs: []buildkite.Job{{
+				ID:     "job-1",
+				State:  "failed",
+				WebURL: "https://buildkite.com/org/pipeline/builds/1#job-1",
+				Agent:  buildkite.Agent{ID: "agent-1"},
+			}}}, &buildkite.Response{}, nil
+		},
+	}
+	agentsClient := &MockAgentsClient{
+		GetFunc: func(ctx context.Context, org, id string) (buildkite.Agent, *buildkite.Response, error) {
+			assert.Equal("agent-1", id)
+			return buildkite.Agent{
+				ID:             "agent-1",
+				Name:           "host-a-1",
+				Hostname:       "host-a",
+				Version:        "3.80.0",
+				ConnectedState: "connected",
+				UserAgent:      "buildkite-agent/3.80.0.10000 (linux; amd64)",
+				Metadata:       []string{"queue=default", "docker=true"},
+			}, &buildkite.Response{}, nil
+		},
+		ListFunc: func(ctx context.Context, org string, opt *buildkite.AgentListOptions) ([]buildkite.Agent, *buildkite.Response, error) {
+			assert.Equal("host-a", opt.Hostname)
+			return []buildkite.Agent{
+				{Name: "host-a-1"},
+				{Name: "host-a-2", Job: &buildkite.Job{ID: "job-2", Label: "lint", State: "running", WebURL: "https://buildkite.com/org/pipeline/builds/1#job-2"}},
+				{Name: "host-a-3", Job: &buildkite.Job{ID: "job-3", Label: "deploy", State: "running", WebURL: "https://buildkite.com/org/other/builds/7#job-3"}},
+			}, &buildkite.Response{}, nil
+		},
+	}
+
+	_, handler, scopes := GetJobAgentInfo(buildsClient, agentsClient)
+	assert.Equal([]string{"read_builds", "read_agents"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, GetJobAgentInfoArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+		BuildNumber:  "1",
+		JobID:        "job-1",
+	})
+	assert.NoError(err)
+
+	var info JobAgentInfo
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &info))
+	assert.Equal("default", info.Queue)
+	assert.Equal("linux", info.OS)
+	assert.Equal("amd64", info.Arch)
+	assert.Equal("connected", info.ConnectionState)
+	assert.Len(info.ConcurrentJobs, 2)
+	assert.True(info.ConcurrentJobs[0].SameBuild)
+	assert.False(info.ConcurrentJobs[1].SameBuild)
+}
+
+func TestGetJobAgentInfoDisconnectedAgent(t *testing.T) {
+	assert := require.New(t)
+
+	buildsClient := &MockBuildsClient{
+		GetFunc: func(ctx context.Context, org string, pipeline string, id string, opt *buildkite.BuildGetOptions) (buildkite.Build, *buildkite.Response, error) {
+			return buildkite.Build{Jobs: []buildkite.Job{{
+				ID:    "job-1",
+				Agent: buildkite.Agent{ID: "agent-1", Name: "gone", Metadata: []string{"queue=macos", "os=darwin"}},
+			}}}, &buildkite.Response{}, nil
+		},
+	}
+	agentsClient := &MockAgentsClient{
+		GetFunc: func(ctx context.Context, org, id string) (buildkite.Agent, *buildkite.Response, error) {
+			return buildkite.Agent{}, nil, errors.New("404 Not Found")
+		},
+	}
+
+	_, handler, _ := GetJobAgentInfo(buildsClient, agentsClient)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, GetJobAgentInfoArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+		BuildNumber:  "1",
+		JobID:        "job-1",
+	})
+	assert.NoError(err)
+
+	var info JobAgentInfo
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &info))
+	assert.Equal("macos", info.Queue)
+	assert.Equal("darwin", info.OS)
+	assert.Contains(info.Note, "no longer registered")
+}
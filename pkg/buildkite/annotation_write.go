@@ -0,0 +1,486 @@
+package buildkite
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// annotationStyles are the styles an annotation can be created with
+var annotationStyles = []string{"success", "info", "warning", "error"}
+
+// AnnotationCodeBlock is a fenced code block added to an annotation
+type AnnotationCodeBlock struct {
+	Language string `json:"language,omitempty"`
+	Content  string `json:"content"`
+}
+
+// AnnotationLink is an entry of the list of links added to an annotation
+type AnnotationLink struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// AnnotationContent is the body of an annotation, followed by tables, code and links which are
+// rendered to markdown
+type AnnotationContent struct {
+	Body      string               `json:"body,omitempty"`
+	Table     [][]string           `json:"table,omitempty"`
+	CodeBlock *AnnotationCodeBlock `json:"code_block,omitempty"`
+	LinkList  []AnnotationLink     `json:"link_list,omitempty"`
+}
+
+// CreateAnnotationArgs struct for typed parameters
+type CreateAnnotationArgs struct {
+	OrgSlug      string `json:"org_slug"`
+	PipelineSlug string `json:"pipeline_slug"`
+	BuildNumber  string `json:"build_number"`
+	Context      string `json:"context,omitempty"`
+	Style        string `json:"style,omitempty"`
+	Append       bool   `json:"append,omitempty"`
+	AnnotationContent
+}
+
+// UpdateAnnotationArgs struct for typed parameters
+type UpdateAnnotationArgs struct {
+	OrgSlug      string `json:"org_slug"`
+	PipelineSlug string `json:"pipeline_slug"`
+	BuildNumber  string `json:"build_number"`
+	Context      string `json:"context"`
+	Style        string `json:"style,omitempty"`
+	Append       bool   `json:"append,omitempty"`
+	AnnotationContent
+}
+
+// DeleteAnnotationArgs struct for typed parameters
+type DeleteAnnotationArgs struct {
+	OrgSlug      string `json:"org_slug"`
+	PipelineSlug string `json:"pipeline_slug"`
+	BuildNumber  string `json:"build_number"`
+	Context      string `json:"context"`
+}
+
+// DeleteAnnotationResult is the annotation which was deleted
+type DeleteAnnotationResult struct {
+	ID      string `json:"id"`
+	Context string `json:"context"`
+	Deleted bool   `json:"deleted"`
+}
+
+// AnnotationDeleteClient deletes annotations from builds
+type AnnotationDeleteClient interface {
+	DeleteAnnotation(ctx context.Context, org, pipelineSlug, buildNumber, id string) (*buildkite.Response, error)
+}
+
+// DeleteAnnotation implements AnnotationDeleteClient, go-buildkite doesn't cover deleting annotations yet
+func (a *BuildkiteClientAdapter) DeleteAnnotation(ctx context.Context, org, pipelineSlug, buildNumber, id string) (*buildkite.Response, error) {
+	u := fmt.Sprintf("v2/organizations/%s/pipelines/%s/builds/%s/annotations/%s", org, pipelineSlug, buildNumber, id)
+
+	req, err := a.NewRequest(ctx, "DELETE", u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.Do(req, nil)
+}
+
+// withAnnotationContent adds the parameters making up the body of an annotation
+func withAnnotationContent() mcp.ToolOption {
+	return func(tool *mcp.Tool) {
+		mcp.WithString("body",
+			mcp.Description("The markdown body of the annotation"),
+		)(tool)
+		mcp.WithArray("table",
+			mcp.Description("Rows of a table to add, the first row being the header. Cells are escaped, so they can contain | and newlines"),
+			mcp.Items(map[string]any{
+				"type":  "array",
+				"items": map[string]any{"type": "string"},
+			}),
+		)(tool)
+		mcp.WithObject("code_block",
+			mcp.Description("A fenced code block to add"),
+			mcp.Properties(map[string]any{
+				"language": map[string]any{
+					"type":        "string",
+					"description": "The language of the code for syntax highlighting, e.g. go or shell",
+				},
+				"content": map[string]any{
+					"type":        "string",
+					"description": "The code",
+				},
+			}),
+		)(tool)
+		mcp.WithArray("link_list",
+			mcp.Description("A list of links to add"),
+			mcp.Items(map[string]any{
+				"type":     "object",
+				"required": []string{"title", "url"},
+				"properties": map[string]any{
+					"title": map[string]any{"type": "string"},
+					"url":   map[string]any{"type": "string"},
+				},
+			}),
+		)(tool)
+	}
+}
+
+// CreateAnnotation creates an annotation on a build, rendering tables, code and links to markdown
+// so they are well formed
+func CreateAnnotation(client AnnotationsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[CreateAnnotationArgs], scopes []string) {
+	return mcp.NewTool("create_annotation",
+			mcp.WithDescription("Create an annotation on a build, or append to the annotation with the same context. The body is markdown, followed by any table, code_block and link_list, which are rendered to markdown so they display correctly in Buildkite"),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("build_number",
+				mcp.Required(),
+			),
+			mcp.WithString("context",
+				mcp.Description("Identifies the annotation, so later annotations with the same context replace or append to it (default 'default')"),
+			),
+			mcp.WithString("style",
+				mcp.Description("The style of the annotation"),
+				mcp.Enum(annotationStyles...),
+			),
+			mcp.WithBoolean("append",
+				mcp.Description("Append to the annotation with the same context instead of replacing it"),
+			),
+			withAnnotationContent(),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Create Annotation",
+				ReadOnlyHint: mcp.ToBoolPtr(false),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args CreateAnnotationArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.CreateAnnotation")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if args.BuildNumber == "" {
+				return mcp.NewToolResultError("build_number parameter is required"), nil
+			}
+			if args.Style != "" && !slices.Contains(annotationStyles, args.Style) {
+				return mcp.NewToolResultError(fmt.Sprintf("style must be one of: %s", strings.Join(annotationStyles, ", "))), nil
+			}
+
+			body, err := annotationBody(args.AnnotationContent)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+				attribute.String("build_number", args.BuildNumber),
+				attribute.String("context", args.Context),
+				attribute.String("style", args.Style),
+				attribute.Bool("append", args.Append),
+			)
+
+			annotation, _, err := client.Create(ctx, args.OrgSlug, args.PipelineSlug, args.BuildNumber, buildkite.AnnotationCreate{
+				Body:    body,
+				Context: args.Context,
+				Style:   args.Style,
+				Append:  args.Append,
+			})
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			return mcpTextResult(span, &annotation)
+		}, []string{"write_builds"}
+}
+
+// UpdateAnnotation replaces the body of the annotation with a context, or appends to it. The API
+// replaces annotations created with the same context, so this checks the annotation exists first.
+func UpdateAnnotation(client AnnotationsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[UpdateAnnotationArgs], scopes []string) {
+	return mcp.NewTool("update_annotation",
+			mcp.WithDescription("Replace the body of an existing annotation on a build, identified by its context, or append to it. The style is kept unless a new one is given"),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("build_number",
+				mcp.Required(),
+			),
+			mcp.WithString("context",
+				mcp.Required(),
+				mcp.Description("The context of the annotation to update"),
+			),
+			mcp.WithString("style",
+				mcp.Description("A new style for the annotation"),
+				mcp.Enum(annotationStyles...),
+			),
+			mcp.WithBoolean("append",
+				mcp.Description("Append to the annotation instead of replacing its body"),
+			),
+			withAnnotationContent(),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Update Annotation",
+				ReadOnlyHint: mcp.ToBoolPtr(false),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args UpdateAnnotationArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.UpdateAnnotation")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if args.BuildNumber == "" {
+				return mcp.NewToolResultError("build_number parameter is required"), nil
+			}
+			if args.Context == "" {
+				return mcp.NewToolResultError("context parameter is required"), nil
+			}
+			if args.Style != "" && !slices.Contains(annotationStyles, args.Style) {
+				return mcp.NewToolResultError(fmt.Sprintf("style must be one of: %s", strings.Join(annotationStyles, ", "))), nil
+			}
+
+			body, err := annotationBody(args.AnnotationContent)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+				attribute.String("build_number", args.BuildNumber),
+				attribute.String("context", args.Context),
+				attribute.String("style", args.Style),
+				attribute.Bool("append", args.Append),
+			)
+
+			existing, err := findAnnotation(ctx, client, args.OrgSlug, args.PipelineSlug, args.BuildNumber, args.Context)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			style := args.Style
+			if style == "" {
+				style = existing.Style
+			}
+
+			annotation, _, err := client.Create(ctx, args.OrgSlug, args.PipelineSlug, args.BuildNumber, buildkite.AnnotationCreate{
+				Body:    body,
+				Context: args.Context,
+				Style:   style,
+				Append:  args.Append,
+			})
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			return mcpTextResult(span, &annotation)
+		}, []string{"write_builds"}
+}
+
+// DeleteAnnotation deletes the annotation with a context from a build
+func DeleteAnnotation(client AnnotationsClient, deleteClient AnnotationDeleteClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[DeleteAnnotationArgs], scopes []string) {
+	return mcp.NewTool("delete_annotation",
+			mcp.WithDescription("Delete an annotation from a build, identified by its context"),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("build_number",
+				mcp.Required(),
+			),
+			mcp.WithString("context",
+				mcp.Required(),
+				mcp.Description("The context of the annotation to delete"),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:           "Delete Annotation",
+				ReadOnlyHint:    mcp.ToBoolPtr(false),
+				DestructiveHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args DeleteAnnotationArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.DeleteAnnotation")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if args.BuildNumber == "" {
+				return mcp.NewToolResultError("build_number parameter is required"), nil
+			}
+			if args.Context == "" {
+				return mcp.NewToolResultError("context parameter is required"), nil
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+				attribute.String("build_number", args.BuildNumber),
+				attribute.String("context", args.Context),
+			)
+
+			annotation, err := findAnnotation(ctx, client, args.OrgSlug, args.PipelineSlug, args.BuildNumber, args.Context)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			if _, err := deleteClient.DeleteAnnotation(ctx, args.OrgSlug, args.PipelineSlug, args.BuildNumber, annotation.ID); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			result := DeleteAnnotationResult{
+				ID:      annotation.ID,
+				Context: annotation.Context,
+				Deleted: true,
+			}
+
+			return mcpTextResult(span, &result)
+		}, []string{"write_builds"}
+}
+
+// findAnnotation returns the build's annotation with the context
+func findAnnotation(ctx context.Context, client AnnotationsClient, org, pipelineSlug, buildNumber, annotationContext string) (buildkite.Annotation, error) {
+	annotations, _, err := client.ListByBuild(ctx, org, pipelineSlug, buildNumber, &buildkite.AnnotationListOptions{
+		ListOptions: buildkite.ListOptions{PerPage: 100},
+	})
+	if err != nil {
+		return buildkite.Annotation{}, err
+	}
+
+	for _, annotation := range annotations {
+		if annotation.Context == annotationContext {
+			return annotation, nil
+		}
+	}
+
+	return buildkite.Annotation{}, fmt.Errorf("annotation with context %s not found", annotationContext)
+}
+
+// annotationBody joins the body and the rendered table, code block and links into the markdown of an annotation
+func annotationBody(args AnnotationContent) (string, error) {
+	var sections []string
+	if body := strings.TrimSpace(args.Body); body != "" {
+		sections = append(sections, body)
+	}
+	if len(args.Table) > 0 {
+		table, err := markdownTable(args.Table)
+		if err != nil {
+			return "", err
+		}
+		sections = append(sections, table)
+	}
+	if args.CodeBlock != nil {
+		sections = append(sections, markdownCodeBlock(args.CodeBlock.Language, args.CodeBlock.Content))
+	}
+	if len(args.LinkList) > 0 {
+		links, err := markdownLinkList(args.LinkList)
+		if err != nil {
+			return "", err
+		}
+		sections = append(sections, links)
+	}
+
+	if len(sections) == 0 {
+		return "", fmt.Errorf("one of body, table, code_block or link_list is required")
+	}
+	return strings.Join(sections, "\n\n") + "\n", nil
+}
+
+// markdownTable renders rows as a markdown table, the first row being the header. Short rows are
+// padded to the width of the widest row.
+func markdownTable(rows [][]string) (string, error) {
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	if columns == 0 {
+		return "", fmt.Errorf("table must have at least one column")
+	}
+
+	var sb strings.Builder
+	writeRow := func(row []string) {
+		sb.WriteString("|")
+		for i := range columns {
+			cell := ""
+			if i < len(row) {
+				cell = markdownTableCell(row[i])
+			}
+			sb.WriteString(" " + cell + " |")
+		}
+		sb.WriteString("\n")
+	}
+
+	writeRow(rows[0])
+	sb.WriteString("|" + strings.Repeat(" --- |", columns) + "\n")
+	for _, row := range rows[1:] {
+		writeRow(row)
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
+// markdownTableCell escapes the pipes and newlines which would break a table row
+func markdownTableCell(cell string) string {
+	cell = strings.ReplaceAll(cell, `|`, `\|`)
+	cell = strings.ReplaceAll(cell, "\r\n", "\n")
+	return strings.ReplaceAll(strings.TrimSpace(cell), "\n", "<br>")
+}
+
+// markdownCodeBlock fences the content with more backticks than any run of them in it
+func markdownCodeBlock(language, content string) string {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+
+	return fence + strings.TrimSpace(language) + "\n" + strings.TrimRight(content, "\n") + "\n" + fence
+}
+
+// markdownLinkList renders the links as a bulleted list
+func markdownLinkList(links []AnnotationLink) (string, error) {
+	items := make([]string, 0, len(links))
+	for i, link := range links {
+		if link.URL == "" {
+			return "", fmt.Errorf("link_list item %d: url is required", i)
+		}
+		title := link.Title
+		if title == "" {
+			title = link.URL
+		}
+		items = append(items, fmt.Sprintf("- [%s](%s)", markdownLinkTitle.Replace(title), markdownLinkURL.Replace(link.URL)))
+	}
+	return strings.Join(items, "\n"), nil
+}
+
+var (
+	markdownLinkTitle = strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`, "\n", " ")
+	markdownLinkURL   = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29")
+)
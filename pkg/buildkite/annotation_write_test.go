@@ -0,0 +1,152 @@
+package buildkite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAnnotation(t *testing.T) {
+	assert := require.New(t)
+
+	var created buildkite.AnnotationCreate
+	client := &MockAnnotationsClient{
+		CreateFunc: func(ctx context.Context, org, pipelineSlug, buildNumber string, annotation buildkite.AnnotationCreate) (buildkite.Annotation, *buildkite.Response, error) {
+			created = annotation
+			return buildkite.Annotation{ID: "annotation-1", Context: annotation.Context, Style: annotation.Style}, &buildkite.Response{}, nil
+		},
+	}
+
+	tool, handler, scopes := CreateAnnotation(client)
+	assert.Equal("create_annotation", tool.Name)
+	assert.Equal([]string{"write_builds"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, CreateAnnotationArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+		BuildNumber:  "1",
+		Context:      "flaky",
+		Style:        "warning",
+		AnnotationContent: AnnotationContent{
+			Body: "## Flaky tests",
+			Table: [][]string{
+				{"Test", "Failures"},
+				{"a | b", "2"},
+				{"multi\nline"},
+			},
+			CodeBlock: &AnnotationCodeBlock{Language: "go", Content: "x := \"```\"\n"},
+			LinkList:  []AnnotationLink{{Title: "Run [1]", URL: "https://example.com/a (b)"}},
+		},
+	})
+	assert.NoError(err)
+	assert.Contains(getTextResult(t, result).Text, `"annotation-1"`)
+
+	assert.Equal("flaky", created.Context)
+	assert.Equal("warning", created.Style)
+	assert.Equal("## Flaky tests\n\n"+
+		"| Test | Failures |\n"+
+		"| --- | --- |\n"+
+		"| a \\| b | 2 |\n"+
+		"| multi<br>line |  |\n\n"+
+		"````go\nx := \"```\"\n````\n\n"+
+		"- [Run \\[1\\]](https://example.com/a%20%28b%29)\n", created.Body)
+}
+
+func TestCreateAnnotationValidation(t *testing.T) {
+	assert := require.New(t)
+
+	_, handler, _ := CreateAnnotation(&MockAnnotationsClient{})
+
+	args := CreateAnnotationArgs{OrgSlug: "org", PipelineSlug: "pipeline", BuildNumber: "1"}
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, args)
+	assert.NoError(err)
+	assert.True(result.IsError)
+	assert.Contains(getTextResult(t, result).Text, "one of body, table, code_block or link_list is required")
+
+	args.Body = "hello"
+	args.Style = "loud"
+	result, err = handler(context.Background(), mcp.CallToolRequest{}, args)
+	assert.NoError(err)
+	assert.True(result.IsError)
+
+	args.Style = ""
+	args.LinkList = []AnnotationLink{{Title: "missing"}}
+	result, err = handler(context.Background(), mcp.CallToolRequest{}, args)
+	assert.NoError(err)
+	assert.True(result.IsError)
+	assert.Contains(getTextResult(t, result).Text, "url is required")
+}
+
+type mockAnnotationDeleteClient struct {
+	deleted []string
+}
+
+func (m *mockAnnotationDeleteClient) DeleteAnnotation(ctx context.Context, org, pipelineSlug, buildNumber, id string) (*buildkite.Response, error) {
+	m.deleted = append(m.deleted, id)
+	return &buildkite.Response{}, nil
+}
+
+func TestUpdateAnnotation(t *testing.T) {
+	assert := require.New(t)
+
+	var created buildkite.AnnotationCreate
+	client := &MockAnnotationsClient{
+		ListByBuildFunc: func(ctx context.Context, org, pipelineSlug, buildNumber string, opts *buildkite.AnnotationListOptions) ([]buildkite.Annotation, *buildkite.Response, error) {
+			return []buildkite.Annotation{{ID: "annotation-1", Context: "summary", Style: "error"}}, &buildkite.Response{}, nil
+		},
+		CreateFunc: func(ctx context.Context, org, pipelineSlug, buildNumber string, annotation buildkite.AnnotationCreate) (buildkite.Annotation, *buildkite.Response, error) {
+			created = annotation
+			return buildkite.Annotation{ID: "annotation-1", Context: annotation.Context, Style: annotation.Style}, &buildkite.Response{}, nil
+		},
+	}
+
+	_, handler, scopes := UpdateAnnotation(client)
+	assert.Equal([]string{"write_builds"}, scopes)
+
+	args := UpdateAnnotationArgs{
+		OrgSlug:           "org",
+		PipelineSlug:      "pipeline",
+		BuildNumber:       "1",
+		Context:           "summary",
+		AnnotationContent: AnnotationContent{Body: "Fixed by retrying"},
+	}
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, args)
+	assert.NoError(err)
+	assert.False(result.IsError)
+
+	// the existing style is kept
+	assert.Equal(buildkite.AnnotationCreate{Body: "Fixed by retrying\n", Context: "summary", Style: "error"}, created)
+
+	args.Context = "missing"
+	result, err = handler(context.Background(), mcp.CallToolRequest{}, args)
+	assert.NoError(err)
+	assert.True(result.IsError)
+	assert.Contains(getTextResult(t, result).Text, "annotation with context missing not found")
+}
+
+func TestDeleteAnnotation(t *testing.T) {
+	assert := require.New(t)
+
+	client := &MockAnnotationsClient{
+		ListByBuildFunc: func(ctx context.Context, org, pipelineSlug, buildNumber string, opts *buildkite.AnnotationListOptions) ([]buildkite.Annotation, *buildkite.Response, error) {
+			return []buildkite.Annotation{{ID: "annotation-1", Context: "summary"}}, &buildkite.Response{}, nil
+		},
+	}
+	deleteClient := &mockAnnotationDeleteClient{}
+
+	tool, handler, _ := DeleteAnnotation(client, deleteClient)
+	assert.True(*tool.Annotations.DestructiveHint)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, DeleteAnnotationArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+		BuildNumber:  "1",
+		Context:      "summary",
+	})
+	assert.NoError(err)
+	assert.Contains(getTextResult(t, result).Text, `"deleted":true`)
+	assert.Equal([]string{"annotation-1"}, deleteClient.deleted)
+}
@@ -2,17 +2,23 @@ package buildkite
 
 import (
 	"context"
+	"slices"
+	"strconv"
 
+	"github.com/buildkite/buildkite-mcp-server/pkg/htmlmd"
 	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
 	"github.com/buildkite/go-buildkite/v4"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog/log"
 	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/errgroup"
 )
 
 // AnnotationsClient describes the subset of the Buildkite client we need for annotations.
 type AnnotationsClient interface {
 	ListByBuild(ctx context.Context, org, pipelineSlug, buildNumber string, opts *buildkite.AnnotationListOptions) ([]buildkite.Annotation, *buildkite.Response, error)
+	Create(ctx context.Context, org, pipelineSlug, buildNumber string, annotation buildkite.AnnotationCreate) (buildkite.Annotation, *buildkite.Response, error)
 }
 
 // ListAnnotations returns an MCP tool + handler pair that lists annotations for a build.
@@ -86,3 +92,197 @@ func ListAnnotations(client AnnotationsClient) (tool mcp.Tool, handler server.To
 			return mcpTextResult(span, &result)
 		}, []string{"read_builds"}
 }
+
+const (
+	defaultAnnotationMaxChars     = 4000
+	defaultAnnotationMaxTableRows = 20
+
+	annotationCountsConcurrency = 4
+	// builds rarely have more than a page of annotations, so counting stops after a few pages
+	maxAnnotationCountPages = 5
+)
+
+// withAnnotationCounts adds the include_annotation_counts parameter to build tools
+func withAnnotationCounts() mcp.ToolOption {
+	return mcp.WithBoolean("include_annotation_counts",
+		mcp.Description("In detailed mode, include the number of annotations of each style (error, warning, info, success) on the build, to find builds whose failures have been explained in annotations"),
+	)
+}
+
+// countAnnotations returns the number of the build's annotations of each style, or nil if they can't be listed
+func countAnnotations(ctx context.Context, client AnnotationsClient, org, pipeline, buildNumber string) map[string]int {
+	if client == nil {
+		return nil
+	}
+
+	counts := map[string]int{}
+	options := &buildkite.AnnotationListOptions{ListOptions: buildkite.ListOptions{PerPage: 100}}
+	for range maxAnnotationCountPages {
+		annotations, resp, err := client.ListByBuild(ctx, org, pipeline, buildNumber, options)
+		if err != nil {
+			log.Ctx(ctx).Debug().Err(err).Str("build_number", buildNumber).Msg("Failed to count build annotations")
+			return nil
+		}
+		for _, annotation := range annotations {
+			counts[annotation.Style]++
+		}
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		options.Page = resp.NextPage
+	}
+
+	return counts
+}
+
+// addAnnotationCounts counts the annotations of the builds concurrently
+func addAnnotationCounts(ctx context.Context, client AnnotationsClient, org, pipeline string, builds []BuildDetail) {
+	var g errgroup.Group
+	g.SetLimit(annotationCountsConcurrency)
+	for i := range builds {
+		g.Go(func() error {
+			builds[i].AnnotationCounts = countAnnotations(ctx, client, org, pipeline, strconv.Itoa(builds[i].Number))
+			return nil
+		})
+	}
+	_ = g.Wait()
+}
+
+// problemAnnotationStyles are the styles returned when only problems are requested
+var problemAnnotationStyles = []string{"error", "warning"}
+
+// GetAnnotationArgs struct for typed parameters
+type GetAnnotationArgs struct {
+	OrgSlug      string `json:"org_slug"`
+	PipelineSlug string `json:"pipeline_slug"`
+	BuildNumber  string `json:"build_number"`
+	Context      string `json:"context,omitempty"`
+	ProblemsOnly bool   `json:"problems_only,omitempty"`
+	MaxChars     int    `json:"max_chars,omitempty"`
+	MaxTableRows int    `json:"max_table_rows,omitempty"`
+}
+
+// AnnotationMarkdown is an annotation with its body converted to markdown
+type AnnotationMarkdown struct {
+	ID        string               `json:"id"`
+	Context   string               `json:"context"`
+	Style     string               `json:"style"`
+	Body      string               `json:"body"`
+	Truncated bool                 `json:"truncated,omitempty"`
+	CreatedAt *buildkite.Timestamp `json:"created_at,omitempty"`
+}
+
+// GetAnnotation returns annotations for a build as compact markdown, limiting the size of each body
+func GetAnnotation(client AnnotationsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[GetAnnotationArgs], scopes []string) {
+	return mcp.NewTool("get_annotation",
+			mcp.WithDescription("Get the annotations for a build converted from HTML to compact markdown. Bodies are truncated to max_chars and long tables keep their header plus the first and last rows. Filter by context, or set problems_only to return only error and warning annotations."),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("build_number",
+				mcp.Required(),
+			),
+			mcp.WithString("context",
+				mcp.Description("Only return the annotation with this context"),
+			),
+			mcp.WithBoolean("problems_only",
+				mcp.Description("Only return annotations with the error or warning style"),
+			),
+			mcp.WithNumber("max_chars",
+				mcp.Description("Maximum number of characters of markdown to return for each annotation (default 4000)"),
+				mcp.Min(1),
+			),
+			mcp.WithNumber("max_table_rows",
+				mcp.Description("Maximum number of body rows to keep in each table (default 20)"),
+				mcp.Min(1),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Get Annotation",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args GetAnnotationArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.GetAnnotation")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if args.BuildNumber == "" {
+				return mcp.NewToolResultError("build_number parameter is required"), nil
+			}
+			if args.MaxChars <= 0 {
+				args.MaxChars = defaultAnnotationMaxChars
+			}
+			if args.MaxTableRows <= 0 {
+				args.MaxTableRows = defaultAnnotationMaxTableRows
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+				attribute.String("build_number", args.BuildNumber),
+				attribute.String("context", args.Context),
+				attribute.Bool("problems_only", args.ProblemsOnly),
+				attribute.Int("max_chars", args.MaxChars),
+			)
+
+			annotations, _, err := client.ListByBuild(ctx, args.OrgSlug, args.PipelineSlug, args.BuildNumber, &buildkite.AnnotationListOptions{
+				ListOptions: buildkite.ListOptions{PerPage: 100},
+			})
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			result := []AnnotationMarkdown{}
+			for _, annotation := range annotations {
+				if args.Context != "" && annotation.Context != args.Context {
+					continue
+				}
+				if args.ProblemsOnly && !slices.Contains(problemAnnotationStyles, annotation.Style) {
+					continue
+				}
+
+				body, err := htmlmd.Convert(annotation.BodyHTML, htmlmd.Options{MaxTableRows: args.MaxTableRows})
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+
+				body, truncated := truncateRunes(body, args.MaxChars)
+
+				result = append(result, AnnotationMarkdown{
+					ID:        annotation.ID,
+					Context:   annotation.Context,
+					Style:     annotation.Style,
+					Body:      body,
+					Truncated: truncated,
+					CreatedAt: annotation.CreatedAt,
+				})
+			}
+
+			if args.Context != "" && len(result) == 0 {
+				return mcp.NewToolResultError("annotation with context " + args.Context + " not found"), nil
+			}
+
+			span.SetAttributes(
+				attribute.Int("item_count", len(result)),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_builds"}
+}
+
+// truncateRunes shortens s to at most limit characters, reporting whether it was cut
+func truncateRunes(s string, limit int) (string, bool) {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s, false
+	}
+	return string(runes[:limit]), true
+}
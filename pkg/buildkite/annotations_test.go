@@ -2,16 +2,20 @@ package buildkite
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/stretchr/testify/require"
 )
 
 type MockAnnotationsClient struct {
 	ListByBuildFunc func(ctx context.Context, org, pipelineSlug, buildNumber string, opts *buildkite.AnnotationListOptions) ([]buildkite.Annotation, *buildkite.Response, error)
 	GetFunc         func(ctx context.Context, org, pipelineSlug, buildNumber, id string) (buildkite.Annotation, *buildkite.Response, error)
+	CreateFunc      func(ctx context.Context, org, pipelineSlug, buildNumber string, annotation buildkite.AnnotationCreate) (buildkite.Annotation, *buildkite.Response, error)
 }
 
 func (m *MockAnnotationsClient) ListByBuild(ctx context.Context, org, pipelineSlug, buildNumber string, opts *buildkite.AnnotationListOptions) ([]buildkite.Annotation, *buildkite.Response, error) {
@@ -21,6 +25,13 @@ func (m *MockAnnotationsClient) ListByBuild(ctx context.Context, org, pipelineSl
 	return nil, nil, nil
 }
 
+func (m *MockAnnotationsClient) Create(ctx context.Context, org, pipelineSlug, buildNumber string, annotation buildkite.AnnotationCreate) (buildkite.Annotation, *buildkite.Response, error) {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, org, pipelineSlug, buildNumber, annotation)
+	}
+	return buildkite.Annotation{}, nil, nil
+}
+
 var _ AnnotationsClient = (*MockAnnotationsClient)(nil)
 
 func TestListAnnotations(t *testing.T) {
@@ -61,3 +72,92 @@ func TestListAnnotations(t *testing.T) {
 
 	assert.Equal(`{"headers":{"Link":""},"items":[{"id":"1","body_html":"Test annotation 1"},{"id":"2","body_html":"Test annotation 2"}]}`, textContent.Text)
 }
+
+func TestGetAnnotation(t *testing.T) {
+	assert := require.New(t)
+
+	ctx := context.Background()
+
+	client := &MockAnnotationsClient{
+		ListByBuildFunc: func(ctx context.Context, org, pipelineSlug, buildNumber string, opts *buildkite.AnnotationListOptions) ([]buildkite.Annotation, *buildkite.Response, error) {
+			return []buildkite.Annotation{
+				{ID: "1", Context: "coverage", Style: "info", BodyHTML: "<p>Coverage is <strong>80%</strong></p>"},
+				{ID: "2", Context: "test-failures", Style: "error", BodyHTML: "<h2>Failures</h2><p>" + strings.Repeat("x", 50) + "</p>"},
+			}, &buildkite.Response{}, nil
+		},
+	}
+
+	_, handler, scopes := GetAnnotation(client)
+	assert.Equal([]string{"read_builds"}, scopes)
+
+	result, err := handler(ctx, mcp.CallToolRequest{}, GetAnnotationArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+		BuildNumber:  "1",
+	})
+	assert.NoError(err)
+
+	var annotations []AnnotationMarkdown
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &annotations))
+	assert.Len(annotations, 2)
+	assert.Equal("Coverage is **80%**", annotations[0].Body)
+	assert.False(annotations[0].Truncated)
+
+	result, err = handler(ctx, mcp.CallToolRequest{}, GetAnnotationArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+		BuildNumber:  "1",
+		ProblemsOnly: true,
+		MaxChars:     20,
+	})
+	assert.NoError(err)
+
+	annotations = nil
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &annotations))
+	assert.Len(annotations, 1)
+	assert.Equal("test-failures", annotations[0].Context)
+	assert.Equal("## Failures\n\nxxxxxxx", annotations[0].Body)
+	assert.True(annotations[0].Truncated)
+
+	result, err = handler(ctx, mcp.CallToolRequest{}, GetAnnotationArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+		BuildNumber:  "1",
+		Context:      "missing",
+	})
+	assert.NoError(err)
+	assert.True(result.IsError)
+}
+
+func TestListBuildsAnnotationCounts(t *testing.T) {
+	assert := require.New(t)
+
+	client := &MockBuildsClient{
+		ListByPipelineFunc: func(ctx context.Context, org string, pipeline string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error) {
+			return []buildkite.Build{{Number: 1, State: "failed"}, {Number: 2, State: "passed"}},
+				&buildkite.Response{Response: &http.Response{StatusCode: 200}}, nil
+		},
+	}
+	annotations := &MockAnnotationsClient{
+		ListByBuildFunc: func(ctx context.Context, org, pipelineSlug, buildNumber string, opts *buildkite.AnnotationListOptions) ([]buildkite.Annotation, *buildkite.Response, error) {
+			if buildNumber == "2" {
+				return nil, &buildkite.Response{}, nil
+			}
+			return []buildkite.Annotation{{Style: "error"}, {Style: "error"}, {Style: "info"}}, &buildkite.Response{}, nil
+		},
+	}
+
+	_, handler, _ := ListBuilds(client, nil, nil, annotations, nil)
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, ListBuildsArgs{
+		OrgSlug:                 "org",
+		PipelineSlug:            "pipeline",
+		DetailLevel:             "detailed",
+		IncludeAnnotationCounts: true,
+	})
+	assert.NoError(err)
+
+	var builds PaginatedResult[BuildDetail]
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &builds))
+	assert.Equal(map[string]int{"error": 2, "info": 1}, builds.Items[0].AnnotationCounts)
+	assert.Empty(builds.Items[1].AnnotationCounts)
+}
@@ -0,0 +1,242 @@
+package buildkite
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"path"
+	"slices"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// maxArtifactPages bounds how many pages of artifacts are read per build
+const maxArtifactPages = 10
+
+type DiffArtifactsArgs struct {
+	OrgSlug              string  `json:"org_slug"`
+	PipelineSlug         string  `json:"pipeline_slug"`
+	BuildA               string  `json:"build_a"`
+	BuildB               string  `json:"build_b"`
+	Glob                 string  `json:"glob,omitempty"`
+	MinSizeChangePercent float64 `json:"min_size_change_percent,omitempty"`
+}
+
+// ArtifactFile is an artifact present in only one of the builds
+type ArtifactFile struct {
+	Path     string `json:"path"`
+	FileSize int64  `json:"file_size"`
+}
+
+// ArtifactSizeChange is an artifact whose size differs between the builds
+type ArtifactSizeChange struct {
+	Path         string  `json:"path"`
+	SizeA        int64   `json:"size_a"`
+	SizeB        int64   `json:"size_b"`
+	Delta        int64   `json:"delta"`
+	DeltaPercent float64 `json:"delta_percent"`
+}
+
+type ArtifactDiff struct {
+	BuildA            string               `json:"build_a"`
+	BuildB            string               `json:"build_b"`
+	TotalSizeA        int64                `json:"total_size_a"`
+	TotalSizeB        int64                `json:"total_size_b"`
+	TotalDeltaPercent float64              `json:"total_delta_percent"`
+	Unchanged         int                  `json:"unchanged"`
+	Added             []ArtifactFile       `json:"added"`
+	Removed           []ArtifactFile       `json:"removed"`
+	Changed           []ArtifactSizeChange `json:"changed"`
+}
+
+// listBuildArtifactsMatching returns the artifacts of a build whose paths match the glob, or all of
+// them when the glob is empty
+func listBuildArtifactsMatching(ctx context.Context, client ArtifactsClient, org, pipeline, build, glob string) ([]buildkite.Artifact, error) {
+	options := &buildkite.ArtifactListOptions{
+		ListOptions: buildkite.ListOptions{PerPage: 100},
+	}
+
+	var artifacts []buildkite.Artifact
+	for range maxArtifactPages {
+		page, resp, err := client.ListByBuild(ctx, org, pipeline, build, options)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, artifact := range page {
+			if glob == "" || matchArtifactGlob(glob, artifact.Path) {
+				artifacts = append(artifacts, artifact)
+			}
+		}
+
+		if resp == nil || resp.NextPage == 0 || len(page) == 0 {
+			break
+		}
+		options.Page = resp.NextPage
+	}
+
+	return artifacts, nil
+}
+
+// listBuildArtifacts returns the sizes of the artifacts of a build keyed by path. When several jobs
+// upload the same path their sizes are summed, as they're compared as one output.
+func listBuildArtifacts(ctx context.Context, client ArtifactsClient, org, pipeline, build, glob string) (map[string]int64, error) {
+	artifacts, err := listBuildArtifactsMatching(ctx, client, org, pipeline, build, glob)
+	if err != nil {
+		return nil, err
+	}
+
+	sizes := map[string]int64{}
+	for _, artifact := range artifacts {
+		sizes[artifact.Path] += artifact.FileSize
+	}
+	return sizes, nil
+}
+
+// percentChange returns the change from a to b as a percentage rounded to one decimal place
+func percentChange(a, b int64) float64 {
+	if a == 0 {
+		if b == 0 {
+			return 0
+		}
+		return 100
+	}
+	return math.Round(float64(b-a)/float64(a)*1000) / 10
+}
+
+// diffArtifacts compares two sets of artifacts by path
+func diffArtifacts(a, b map[string]int64, minChangePercent float64) ArtifactDiff {
+	diff := ArtifactDiff{
+		Added:   []ArtifactFile{},
+		Removed: []ArtifactFile{},
+		Changed: []ArtifactSizeChange{},
+	}
+
+	for artifactPath, sizeA := range a {
+		diff.TotalSizeA += sizeA
+
+		sizeB, ok := b[artifactPath]
+		if !ok {
+			diff.Removed = append(diff.Removed, ArtifactFile{Path: artifactPath, FileSize: sizeA})
+			continue
+		}
+
+		change := percentChange(sizeA, sizeB)
+		if sizeA == sizeB || math.Abs(change) < minChangePercent {
+			diff.Unchanged++
+			continue
+		}
+		diff.Changed = append(diff.Changed, ArtifactSizeChange{
+			Path:         artifactPath,
+			SizeA:        sizeA,
+			SizeB:        sizeB,
+			Delta:        sizeB - sizeA,
+			DeltaPercent: change,
+		})
+	}
+
+	for artifactPath, sizeB := range b {
+		diff.TotalSizeB += sizeB
+		if _, ok := a[artifactPath]; !ok {
+			diff.Added = append(diff.Added, ArtifactFile{Path: artifactPath, FileSize: sizeB})
+		}
+	}
+
+	diff.TotalDeltaPercent = percentChange(diff.TotalSizeA, diff.TotalSizeB)
+
+	byPath := func(x, y ArtifactFile) int { return cmp.Compare(x.Path, y.Path) }
+	slices.SortFunc(diff.Added, byPath)
+	slices.SortFunc(diff.Removed, byPath)
+	// the biggest changes are the most likely regressions
+	slices.SortFunc(diff.Changed, func(x, y ArtifactSizeChange) int {
+		return cmp.Or(
+			cmp.Compare(math.Abs(float64(y.Delta)), math.Abs(float64(x.Delta))),
+			cmp.Compare(x.Path, y.Path),
+		)
+	})
+
+	return diff
+}
+
+func DiffArtifacts(client ArtifactsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[DiffArtifactsArgs], scopes []string) {
+	return mcp.NewTool("diff_artifacts",
+			mcp.WithDescription("Compare the artifacts of two builds of a pipeline by path: artifacts added in build_b, removed since build_a, and those whose size changed, with percentage deltas. Catches bundle size regressions and missing build outputs."),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("build_a",
+				mcp.Required(),
+				mcp.Description("The base build number"),
+			),
+			mcp.WithString("build_b",
+				mcp.Required(),
+				mcp.Description("The build number to compare with the base"),
+			),
+			mcp.WithString("glob",
+				mcp.Description("Only compare artifacts matching this glob, e.g. 'dist/*.js'"),
+			),
+			mcp.WithNumber("min_size_change_percent",
+				mcp.Description("Ignore size changes smaller than this percentage (default: report any change)"),
+				mcp.Min(0),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Diff Artifacts",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args DiffArtifactsArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.DiffArtifacts")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if args.BuildA == "" {
+				return mcp.NewToolResultError("build_a parameter is required"), nil
+			}
+			if args.BuildB == "" {
+				return mcp.NewToolResultError("build_b parameter is required"), nil
+			}
+			if _, err := path.Match(args.Glob, ""); err != nil {
+				return mcp.NewToolResultError("invalid glob: " + err.Error()), nil
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+				attribute.String("build_a", args.BuildA),
+				attribute.String("build_b", args.BuildB),
+				attribute.String("glob", args.Glob),
+			)
+
+			artifactsA, err := listBuildArtifacts(ctx, client, args.OrgSlug, args.PipelineSlug, args.BuildA, args.Glob)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			artifactsB, err := listBuildArtifacts(ctx, client, args.OrgSlug, args.PipelineSlug, args.BuildB, args.Glob)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			result := diffArtifacts(artifactsA, artifactsB, args.MinSizeChangePercent)
+			result.BuildA = args.BuildA
+			result.BuildB = args.BuildB
+
+			span.SetAttributes(
+				attribute.Int("added", len(result.Added)),
+				attribute.Int("removed", len(result.Removed)),
+				attribute.Int("changed", len(result.Changed)),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_artifacts"}
+}
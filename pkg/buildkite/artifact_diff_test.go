@@ -0,0 +1,60 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffArtifacts(t *testing.T) {
+	assert := require.New(t)
+
+	client := &MockArtifactsClient{
+		ListByBuildFunc: func(ctx context.Context, org, pipelineSlug, buildNumber string, opts *buildkite.ArtifactListOptions) ([]buildkite.Artifact, *buildkite.Response, error) {
+			if buildNumber == "1" {
+				return []buildkite.Artifact{
+					{Path: "dist/app.js", FileSize: 1000},
+					{Path: "dist/vendor.js", FileSize: 5000},
+					{Path: "dist/legacy.js", FileSize: 300},
+					{Path: "dist/styles.css", FileSize: 200},
+					{Path: "coverage/index.html", FileSize: 50},
+				}, &buildkite.Response{}, nil
+			}
+			return []buildkite.Artifact{
+				{Path: "dist/app.js", FileSize: 1500},
+				{Path: "dist/vendor.js", FileSize: 5010},
+				{Path: "dist/styles.css", FileSize: 200},
+				{Path: "dist/chunk.js", FileSize: 400},
+				{Path: "coverage/index.html", FileSize: 80},
+			}, &buildkite.Response{}, nil
+		},
+	}
+
+	_, handler, scopes := DiffArtifacts(client)
+	assert.Equal([]string{"read_artifacts"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, DiffArtifactsArgs{
+		OrgSlug:              "org",
+		PipelineSlug:         "pipeline",
+		BuildA:               "1",
+		BuildB:               "2",
+		Glob:                 "dist/*",
+		MinSizeChangePercent: 1,
+	})
+	assert.NoError(err)
+
+	var diff ArtifactDiff
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &diff))
+	assert.Equal([]ArtifactFile{{Path: "dist/chunk.js", FileSize: 400}}, diff.Added)
+	assert.Equal([]ArtifactFile{{Path: "dist/legacy.js", FileSize: 300}}, diff.Removed)
+	assert.Equal([]ArtifactSizeChange{{Path: "dist/app.js", SizeA: 1000, SizeB: 1500, Delta: 500, DeltaPercent: 50}}, diff.Changed)
+	// vendor.js changed by less than 1% and styles.css not at all
+	assert.Equal(2, diff.Unchanged)
+	assert.Equal(int64(6500), diff.TotalSizeA)
+	assert.Equal(int64(7110), diff.TotalSizeB)
+	assert.Equal(9.4, diff.TotalDeltaPercent)
+}
@@ -0,0 +1,306 @@
+package buildkite
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// maxArtifactDownloadAttempts is how many times a download is resumed after a failure
+	maxArtifactDownloadAttempts = 5
+	// artifactProgressInterval throttles progress notifications
+	artifactProgressInterval = time.Second
+	// partialDownloadSuffix marks an incomplete download, which is resumed from its size
+	partialDownloadSuffix = ".part"
+	// partialDownloadInfoSuffix marks the file recording which artifact a partial download is of
+	partialDownloadInfoSuffix = ".part.json"
+)
+
+// DefaultArtifactDownloadDir is where artifacts are downloaded when no directory is configured
+func DefaultArtifactDownloadDir() string {
+	return filepath.Join(os.TempDir(), "buildkite-mcp-server", "artifacts")
+}
+
+type DownloadArtifactToFileArgs struct {
+	URL      string `json:"url"`
+	Filename string `json:"filename,omitempty"`
+}
+
+type DownloadedArtifact struct {
+	Path     string `json:"path"`
+	FileSize int64  `json:"file_size"`
+	SHA1     string `json:"sha1sum"`
+	Verified bool   `json:"verified"`
+	Resumed  bool   `json:"resumed,omitempty"`
+	Attempts int    `json:"attempts"`
+}
+
+// progressWriter counts the bytes written and reports them at most once per interval
+type progressWriter struct {
+	writer   io.Writer
+	written  int64
+	interval time.Duration
+	last     time.Time
+	report   func(written int64)
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.written += int64(n)
+	if w.report != nil && time.Since(w.last) >= w.interval {
+		w.last = time.Now()
+		w.report(w.written)
+	}
+	return n, err
+}
+
+// downloadDestination resolves the file an artifact is downloaded to, rejecting names which would
+// escape the download directory
+func downloadDestination(dir, filename string) (string, error) {
+	if filename == "" || filepath.IsAbs(filename) {
+		return "", fmt.Errorf("invalid filename %q", filename)
+	}
+
+	destination := filepath.Join(dir, filename)
+	if destination == filepath.Clean(dir) || !withinDir(dir, destination) {
+		return "", fmt.Errorf("filename %q is outside the download directory", filename)
+	}
+	return destination, nil
+}
+
+// partialDownloadInfo identifies the artifact a partial download is of, so a partial file left by a
+// different artifact downloaded to the same name isn't resumed
+type partialDownloadInfo struct {
+	ArtifactID string `json:"artifact_id"`
+	SHA1       string `json:"sha1"`
+}
+
+// preparePartialDownload keeps the partial download of the artifact to be resumed, discarding a
+// partial file which can't be shown to be of the same artifact
+func preparePartialDownload(partial, info string, artifact buildkite.Artifact) error {
+	want := partialDownloadInfo{ArtifactID: artifact.ID, SHA1: artifact.SHA1}
+
+	var got partialDownloadInfo
+	if data, err := os.ReadFile(info); err == nil && json.Unmarshal(data, &got) == nil && got == want && artifact.ID != "" {
+		return nil
+	}
+
+	if err := os.Remove(partial); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	data, err := json.Marshal(want)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(info, data, 0o600)
+}
+
+// resumeDownload downloads an artifact into the partial file, continuing from its current size. It
+// returns whether it resumed a previous download.
+func resumeDownload(ctx context.Context, client ArtifactMetadataClient, downloadURL, partial string, progress func(written int64)) (bool, error) {
+	file, err := os.OpenFile(partial, os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	offset, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return false, err
+	}
+
+	// a server which ignores the range sends the whole artifact, which mustn't be appended after the
+	// partial download, so the server is asked for a single byte first to check it honours the range
+	if offset > 0 {
+		resp, err := client.DownloadArtifactRange(ctx, downloadURL, offset, 1, io.Discard)
+		switch {
+		case resp != nil && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+			// the partial download already holds the whole artifact, which is verified before use
+			return true, nil
+		case err != nil:
+			return false, err
+		case resp == nil || resp.StatusCode != http.StatusPartialContent:
+			return false, errRangeNotSupported
+		}
+	}
+
+	writer := &progressWriter{writer: file, written: offset, interval: artifactProgressInterval, report: progress}
+
+	resp, err := client.DownloadArtifactRange(ctx, downloadURL, offset, 0, writer)
+
+	// the server could still send the whole artifact, in which case what was written is dropped again
+	if offset > 0 && resp != nil && resp.StatusCode != http.StatusPartialContent {
+		if truncateErr := file.Truncate(offset); truncateErr != nil {
+			return false, truncateErr
+		}
+		return false, errRangeNotSupported
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return offset > 0, file.Sync()
+}
+
+// errRangeNotSupported means the partial download must be discarded and downloaded again
+var errRangeNotSupported = errors.New("server does not support ranged downloads")
+
+// fileSHA1 returns the hex SHA-1 of a file
+func fileSHA1(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha1.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func DownloadArtifactToFile(client ArtifactMetadataClient, downloadDir string) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[DownloadArtifactToFileArgs], scopes []string) {
+	if downloadDir == "" {
+		downloadDir = DefaultArtifactDownloadDir()
+	}
+
+	return mcp.NewTool("download_artifact_to_file",
+			mcp.WithDescription("Download an artifact to a file on the server, for artifacts too large to return inline. Interrupted downloads are resumed with ranged requests, progress is reported with progress notifications, and the file is verified against the artifact's SHA-1 checksum. Returns the path to the downloaded file"),
+			mcp.WithString("url",
+				mcp.Required(),
+				mcp.Description("The artifact's url or download_url, as returned by list_artifacts"),
+			),
+			mcp.WithString("filename",
+				mcp.Description("Name of the file to write within the download directory (default: the artifact's file name)"),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Download Artifact to File",
+				ReadOnlyHint: mcp.ToBoolPtr(false),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args DownloadArtifactToFileArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.DownloadArtifactToFile")
+			defer span.End()
+
+			if args.URL == "" {
+				return mcp.NewToolResultError("url parameter is required"), nil
+			}
+
+			span.SetAttributes(attribute.String("url", args.URL))
+
+			artifact, _, err := client.GetArtifactByURL(ctx, strings.TrimSuffix(args.URL, "/download"))
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			if args.Filename == "" {
+				args.Filename = filepath.Base(artifact.Path)
+			}
+			destination, err := downloadDestination(downloadDir, args.Filename)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if err := os.MkdirAll(filepath.Dir(destination), 0o700); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to create download directory: %v", err)), nil
+			}
+
+			var progress func(written int64)
+			if mcpServer := server.ServerFromContext(ctx); mcpServer != nil && request.Params.Meta != nil && request.Params.Meta.ProgressToken != nil {
+				progressToken := request.Params.Meta.ProgressToken
+				progress = func(written int64) {
+					// progress is best effort, so a client which has gone away doesn't fail the download
+					_ = mcpServer.SendNotificationToClient(ctx, "notifications/progress", map[string]any{
+						"progressToken": progressToken,
+						"progress":      written,
+						"total":         artifact.FileSize,
+						"message":       fmt.Sprintf("downloaded %d of %d bytes of %s", written, artifact.FileSize, artifact.Path),
+					})
+				}
+			}
+
+			result := DownloadedArtifact{Path: destination}
+			partial := destination + partialDownloadSuffix
+			partialInfo := destination + partialDownloadInfoSuffix
+
+			if err := preparePartialDownload(partial, partialInfo, artifact); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to prepare download: %v", err)), nil
+			}
+
+			for result.Attempts < maxArtifactDownloadAttempts {
+				result.Attempts++
+
+				var resumed bool
+				resumed, err = resumeDownload(ctx, client, artifact.DownloadURL, partial, progress)
+				result.Resumed = result.Resumed || resumed
+				if err == nil {
+					break
+				}
+				if errors.Is(err, errRangeNotSupported) {
+					_ = os.Remove(partial)
+				}
+				if ctx.Err() != nil {
+					break
+				}
+			}
+			if err != nil {
+				// the partial file is kept so a later call can resume the download
+				return mcp.NewToolResultError(fmt.Sprintf("failed to download artifact after %d attempts: %v", result.Attempts, err)), nil
+			}
+
+			info, err := os.Stat(partial)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			result.FileSize = info.Size()
+
+			result.SHA1, err = fileSHA1(partial)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if artifact.SHA1 != "" {
+				if result.SHA1 != artifact.SHA1 {
+					// the next call downloads the artifact again from the start
+					_ = os.Remove(partial)
+					_ = os.Remove(partialInfo)
+					return mcp.NewToolResultError(fmt.Sprintf("downloaded file has SHA-1 %s but the artifact's is %s", result.SHA1, artifact.SHA1)), nil
+				}
+				result.Verified = true
+			}
+
+			if err := os.Rename(partial, destination); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			_ = os.Remove(partialInfo)
+
+			if progress != nil {
+				progress(result.FileSize)
+			}
+
+			span.SetAttributes(
+				attribute.Int64("file_size", result.FileSize),
+				attribute.Int("attempts", result.Attempts),
+				attribute.Bool("resumed", result.Resumed),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_artifacts"}
+}
@@ -0,0 +1,266 @@
+package buildkite
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadArtifactToFile(t *testing.T) {
+	assert := require.New(t)
+
+	content := strings.Repeat("artifact content ", 1000)
+	sum := sha1.Sum([]byte(content))
+	artifact := buildkite.Artifact{
+		Path:        "dist/app.tar.gz",
+		FileSize:    int64(len(content)),
+		SHA1:        hex.EncodeToString(sum[:]),
+		DownloadURL: "https://api.buildkite.com/v2/artifacts/1/download",
+	}
+
+	var offsets []int64
+	client := &MockArtifactMetadataClient{
+		GetArtifactByURLFunc: func(ctx context.Context, url string) (buildkite.Artifact, *buildkite.Response, error) {
+			return artifact, &buildkite.Response{}, nil
+		},
+		DownloadArtifactRangeFunc: func(ctx context.Context, url string, offset, length int64, writer io.Writer) (*buildkite.Response, error) {
+			offsets = append(offsets, offset)
+			if len(offsets) == 1 {
+				// the connection drops half way through the first attempt
+				_, _ = io.WriteString(writer, content[:len(content)/2])
+				return nil, errors.New("unexpected EOF")
+			}
+			_, err := io.WriteString(writer, content[offset:])
+			return &buildkite.Response{Response: &http.Response{StatusCode: http.StatusPartialContent}}, err
+		},
+	}
+
+	dir := t.TempDir()
+	_, handler, _ := DownloadArtifactToFile(client, dir)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, DownloadArtifactToFileArgs{URL: artifact.DownloadURL})
+	assert.NoError(err)
+	assert.False(result.IsError, getTextResult(t, result).Text)
+
+	var downloaded DownloadedArtifact
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &downloaded))
+	assert.Equal(filepath.Join(dir, "app.tar.gz"), downloaded.Path)
+	assert.Equal(artifact.FileSize, downloaded.FileSize)
+	assert.True(downloaded.Verified)
+	assert.True(downloaded.Resumed)
+	assert.Equal(2, downloaded.Attempts)
+	// the resumed download checks the server honours the range before writing to the file
+	assert.Equal([]int64{0, int64(len(content) / 2), int64(len(content) / 2)}, offsets)
+
+	data, err := os.ReadFile(downloaded.Path)
+	assert.NoError(err)
+	assert.Equal(content, string(data))
+	assert.NoFileExists(downloaded.Path + partialDownloadSuffix)
+	assert.NoFileExists(downloaded.Path + partialDownloadInfoSuffix)
+}
+
+// writePartialDownload leaves a partial download of the artifact, as an earlier call would have
+func writePartialDownload(t *testing.T, dir, filename, content string, artifact buildkite.Artifact) {
+	t.Helper()
+
+	destination := filepath.Join(dir, filename)
+	require.NoError(t, os.WriteFile(destination+partialDownloadSuffix, []byte(content), 0o600))
+
+	info, err := json.Marshal(partialDownloadInfo{ArtifactID: artifact.ID, SHA1: artifact.SHA1})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(destination+partialDownloadInfoSuffix, info, 0o600))
+}
+
+func TestDownloadArtifactToFileRangeIgnored(t *testing.T) {
+	content := "0123456789"
+	sum := sha1.Sum([]byte(content))
+	artifact := buildkite.Artifact{ID: "artifact-1", Path: "out.txt", FileSize: 10, SHA1: hex.EncodeToString(sum[:])}
+
+	tests := []struct {
+		name    string
+		offsets []int64
+		status  func(call int) int
+	}{
+		{
+			name:    "range ignored",
+			offsets: []int64{4, 0},
+			status:  func(call int) int { return http.StatusOK },
+		},
+		{
+			// the server honours the single byte asked for, but then sends the whole artifact
+			name:    "range ignored after check",
+			offsets: []int64{4, 4, 0},
+			status: func(call int) int {
+				if call == 1 {
+					return http.StatusPartialContent
+				}
+				return http.StatusOK
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := require.New(t)
+
+			dir := t.TempDir()
+			writePartialDownload(t, dir, "out.txt", content[:4], artifact)
+
+			var offsets []int64
+			client := &MockArtifactMetadataClient{
+				GetArtifactByURLFunc: func(ctx context.Context, url string) (buildkite.Artifact, *buildkite.Response, error) {
+					return artifact, &buildkite.Response{}, nil
+				},
+				DownloadArtifactRangeFunc: func(ctx context.Context, url string, offset, length int64, writer io.Writer) (*buildkite.Response, error) {
+					offsets = append(offsets, offset)
+					_, err := io.WriteString(writer, content)
+					return &buildkite.Response{Response: &http.Response{StatusCode: tt.status(len(offsets))}}, err
+				},
+			}
+
+			_, handler, _ := DownloadArtifactToFile(client, dir)
+
+			result, err := handler(context.Background(), mcp.CallToolRequest{}, DownloadArtifactToFileArgs{URL: "https://example.com/artifact"})
+			assert.NoError(err)
+			assert.False(result.IsError, getTextResult(t, result).Text)
+			assert.Equal(tt.offsets, offsets)
+
+			data, err := os.ReadFile(filepath.Join(dir, "out.txt"))
+			assert.NoError(err)
+			assert.Equal(content, string(data))
+		})
+	}
+}
+
+func TestDownloadArtifactToFilePartialDownloads(t *testing.T) {
+	content := "0123456789"
+	sum := sha1.Sum([]byte(content))
+	artifact := buildkite.Artifact{ID: "artifact-1", Path: "out.txt", FileSize: 10, SHA1: hex.EncodeToString(sum[:])}
+
+	tests := []struct {
+		name     string
+		partial  string
+		of       buildkite.Artifact
+		offsets  []int64
+		resumed  bool
+		attempts int
+		wantErr  string
+	}{
+		{
+			name:     "of the same artifact",
+			partial:  content[:4],
+			of:       artifact,
+			offsets:  []int64{4, 4},
+			resumed:  true,
+			attempts: 1,
+		},
+		{
+			name:     "of a different artifact",
+			partial:  "abcd",
+			of:       buildkite.Artifact{ID: "artifact-2", Path: "out.txt"},
+			offsets:  []int64{0},
+			attempts: 1,
+		},
+		{
+			// the server has nothing left to send, so the partial download is verified as it is
+			name:     "already complete",
+			partial:  content,
+			of:       artifact,
+			offsets:  []int64{10},
+			resumed:  true,
+			attempts: 1,
+		},
+		{
+			// a complete download which fails verification is downloaded again by the next call
+			name:     "complete but corrupt",
+			partial:  "abcdefghij",
+			of:       artifact,
+			offsets:  []int64{10},
+			attempts: 1,
+			wantErr:  "SHA-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := require.New(t)
+
+			dir := t.TempDir()
+			writePartialDownload(t, dir, "out.txt", tt.partial, tt.of)
+
+			var offsets []int64
+			client := &MockArtifactMetadataClient{
+				GetArtifactByURLFunc: func(ctx context.Context, url string) (buildkite.Artifact, *buildkite.Response, error) {
+					return artifact, &buildkite.Response{}, nil
+				},
+				DownloadArtifactRangeFunc: func(ctx context.Context, url string, offset, length int64, writer io.Writer) (*buildkite.Response, error) {
+					offsets = append(offsets, offset)
+					if offset >= int64(len(content)) {
+						resp := &http.Response{StatusCode: http.StatusRequestedRangeNotSatisfiable}
+						return &buildkite.Response{Response: resp}, &buildkite.ErrorResponse{Response: resp}
+					}
+
+					status := http.StatusOK
+					if offset > 0 {
+						status = http.StatusPartialContent
+					}
+					_, err := io.WriteString(writer, content[offset:])
+					return &buildkite.Response{Response: &http.Response{StatusCode: status}}, err
+				},
+			}
+
+			_, handler, _ := DownloadArtifactToFile(client, dir)
+
+			result, err := handler(context.Background(), mcp.CallToolRequest{}, DownloadArtifactToFileArgs{URL: "https://example.com/artifact"})
+			assert.NoError(err)
+			assert.Equal(tt.offsets, offsets)
+
+			if tt.wantErr != "" {
+				assert.True(result.IsError)
+				assert.Contains(getTextResult(t, result).Text, tt.wantErr)
+				assert.NoFileExists(filepath.Join(dir, "out.txt"+partialDownloadSuffix))
+				assert.NoFileExists(filepath.Join(dir, "out.txt"+partialDownloadInfoSuffix))
+				return
+			}
+
+			assert.False(result.IsError, getTextResult(t, result).Text)
+
+			var downloaded DownloadedArtifact
+			assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &downloaded))
+			assert.Equal(tt.resumed, downloaded.Resumed)
+			assert.Equal(tt.attempts, downloaded.Attempts)
+			assert.True(downloaded.Verified)
+
+			data, err := os.ReadFile(downloaded.Path)
+			assert.NoError(err)
+			assert.Equal(content, string(data))
+		})
+	}
+}
+
+func TestDownloadDestination(t *testing.T) {
+	assert := require.New(t)
+
+	destination, err := downloadDestination("/downloads", "reports/junit.xml")
+	assert.NoError(err)
+	assert.Equal("/downloads/reports/junit.xml", destination)
+
+	_, err = downloadDestination("/downloads", "../etc/passwd")
+	assert.Error(err)
+	_, err = downloadDestination("/downloads", "/etc/passwd")
+	assert.Error(err)
+	_, err = downloadDestination("/downloads", "")
+	assert.Error(err)
+}
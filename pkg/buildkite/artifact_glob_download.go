@@ -0,0 +1,271 @@
+package buildkite
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	maxGlobDownloadArtifacts = 500
+	maxGlobDownloadBytes     = 5 * 1024 * 1024 * 1024
+	globDownloadConcurrency  = 4
+)
+
+// SessionWithRoots is a client session which can list the client's MCP roots, the directories the
+// server is permitted to write to
+type SessionWithRoots interface {
+	server.ClientSession
+	ListRoots(ctx context.Context, request mcp.ListRootsRequest) (*mcp.ListRootsResult, error)
+}
+
+// clientRoots returns the local directories of the client's roots, or false when the client
+// doesn't share its roots
+func clientRoots(ctx context.Context) ([]string, bool) {
+	session, ok := server.ClientSessionFromContext(ctx).(SessionWithRoots)
+	if !ok {
+		return nil, false
+	}
+
+	result, err := session.ListRoots(ctx, mcp.ListRootsRequest{})
+	if err != nil || len(result.Roots) == 0 {
+		return nil, false
+	}
+
+	var roots []string
+	for _, root := range result.Roots {
+		u, err := url.Parse(root.URI)
+		if err != nil || u.Scheme != "file" {
+			continue
+		}
+		roots = append(roots, filepath.Clean(u.Path))
+	}
+	return roots, len(roots) > 0
+}
+
+// withinDir reports whether target is dir or inside it
+func withinDir(dir, target string) bool {
+	rel, err := filepath.Rel(dir, target)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+type DownloadArtifactsArgs struct {
+	OrgSlug      string `json:"org_slug"`
+	PipelineSlug string `json:"pipeline_slug"`
+	BuildNumber  string `json:"build_number"`
+	Glob         string `json:"glob"`
+	Directory    string `json:"directory,omitempty"`
+}
+
+// ManifestEntry is an artifact downloaded into the directory tree
+type ManifestEntry struct {
+	Path      string `json:"path"`
+	LocalPath string `json:"local_path"`
+	JobID     string `json:"job_id"`
+	FileSize  int64  `json:"file_size"`
+	SHA1      string `json:"sha1sum"`
+	Verified  bool   `json:"verified"`
+}
+
+type DownloadedArtifacts struct {
+	Directory string          `json:"directory"`
+	FileCount int             `json:"file_count"`
+	TotalSize int64           `json:"total_size"`
+	Files     []ManifestEntry `json:"files"`
+	Notes     []string        `json:"notes,omitempty"`
+}
+
+// downloadArtifactFile downloads an artifact to a file, writing it under a temporary name until it's
+// complete and verified
+func downloadArtifactFile(ctx context.Context, client ArtifactsClient, downloadURL, destination, expectedSHA1 string) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(destination), 0o700); err != nil {
+		return "", err
+	}
+
+	partial := destination + partialDownloadSuffix
+	file, err := os.OpenFile(partial, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = client.DownloadArtifactByURL(ctx, downloadURL, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(partial)
+		return "", err
+	}
+
+	sum, err := fileSHA1(partial)
+	if err != nil {
+		return "", err
+	}
+	if expectedSHA1 != "" && sum != expectedSHA1 {
+		_ = os.Remove(partial)
+		return "", fmt.Errorf("downloaded file has SHA-1 %s but the artifact's is %s", sum, expectedSHA1)
+	}
+
+	return sum, os.Rename(partial, destination)
+}
+
+func DownloadArtifacts(client ArtifactsClient, downloadDir string) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[DownloadArtifactsArgs], scopes []string) {
+	if downloadDir == "" {
+		downloadDir = DefaultArtifactDownloadDir()
+	}
+
+	return mcp.NewTool("download_artifacts",
+			mcp.WithDescription("Download all the artifacts of a build matching a glob into a local directory, preserving their relative paths, so the whole set can be analysed locally. Returns a manifest of the files written. When the client shares its MCP roots the directory must be within one of them"),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("build_number",
+				mcp.Required(),
+			),
+			mcp.WithString("glob",
+				mcp.Required(),
+				mcp.Description("Glob matched against artifact paths, e.g. 'coverage/**' or 'reports/*.xml'. A glob without a slash also matches file names in any directory"),
+			),
+			mcp.WithString("directory",
+				mcp.Description("Absolute path of the directory to download into (default: a directory for the build within the client's first root, or the server's download directory)"),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Download Artifacts",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args DownloadArtifactsArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.DownloadArtifacts")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if args.BuildNumber == "" {
+				return mcp.NewToolResultError("build_number parameter is required"), nil
+			}
+			if args.Glob == "" {
+				return mcp.NewToolResultError("glob parameter is required"), nil
+			}
+			if _, err := path.Match(args.Glob, ""); err != nil {
+				return mcp.NewToolResultError("invalid glob: " + err.Error()), nil
+			}
+
+			allowed, ok := clientRoots(ctx)
+			if !ok {
+				allowed = []string{downloadDir}
+			}
+
+			directory := args.Directory
+			if directory == "" {
+				directory = filepath.Join(allowed[0], "buildkite-artifacts", fmt.Sprintf("%s-%s-%s", args.OrgSlug, args.PipelineSlug, args.BuildNumber))
+			}
+			if !filepath.IsAbs(directory) {
+				return mcp.NewToolResultError("directory must be an absolute path"), nil
+			}
+			directory = filepath.Clean(directory)
+			if !slices.ContainsFunc(allowed, func(dir string) bool { return withinDir(dir, directory) }) {
+				return mcp.NewToolResultError(fmt.Sprintf("directory %s is outside the permitted directories: %s", directory, strings.Join(allowed, ", "))), nil
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+				attribute.String("build_number", args.BuildNumber),
+				attribute.String("glob", args.Glob),
+			)
+
+			artifacts, err := listBuildArtifactsMatching(ctx, client, args.OrgSlug, args.PipelineSlug, args.BuildNumber, args.Glob)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if len(artifacts) > maxGlobDownloadArtifacts {
+				return mcp.NewToolResultError(fmt.Sprintf("%d artifacts match the glob, more than the %d which can be downloaded at once; use a narrower glob", len(artifacts), maxGlobDownloadArtifacts)), nil
+			}
+
+			var totalSize int64
+			for _, artifact := range artifacts {
+				totalSize += artifact.FileSize
+			}
+			if totalSize > maxGlobDownloadBytes {
+				return mcp.NewToolResultError(fmt.Sprintf("matching artifacts total %d bytes, more than the %d which can be downloaded at once; use a narrower glob", totalSize, int64(maxGlobDownloadBytes))), nil
+			}
+
+			result := DownloadedArtifacts{
+				Directory: directory,
+				Files:     []ManifestEntry{},
+			}
+
+			var mu sync.Mutex
+			seen := map[string]bool{}
+
+			g, gctx := errgroup.WithContext(ctx)
+			g.SetLimit(globDownloadConcurrency)
+			for _, artifact := range artifacts {
+				destination, err := downloadDestination(directory, filepath.FromSlash(artifact.Path))
+				if err != nil {
+					result.Notes = append(result.Notes, fmt.Sprintf("skipped %s: %v", artifact.Path, err))
+					continue
+				}
+				// several jobs can upload the same path, and only one of them can be written there
+				if seen[destination] {
+					result.Notes = append(result.Notes, fmt.Sprintf("skipped %s from job %s, as another job uploaded the same path", artifact.Path, artifact.JobID))
+					continue
+				}
+				seen[destination] = true
+
+				g.Go(func() error {
+					sum, err := downloadArtifactFile(gctx, client, artifact.DownloadURL, destination, artifact.SHA1)
+					if err != nil {
+						return fmt.Errorf("failed to download %s: %w", artifact.Path, err)
+					}
+
+					mu.Lock()
+					defer mu.Unlock()
+
+					result.TotalSize += artifact.FileSize
+					result.Files = append(result.Files, ManifestEntry{
+						Path:      artifact.Path,
+						LocalPath: destination,
+						JobID:     artifact.JobID,
+						FileSize:  artifact.FileSize,
+						SHA1:      sum,
+						Verified:  artifact.SHA1 != "",
+					})
+					return nil
+				})
+			}
+			if err := g.Wait(); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			slices.SortFunc(result.Files, func(a, b ManifestEntry) int { return cmp.Compare(a.Path, b.Path) })
+			result.FileCount = len(result.Files)
+
+			span.SetAttributes(
+				attribute.Int("file_count", result.FileCount),
+				attribute.Int64("total_size", result.TotalSize),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_artifacts"}
+}
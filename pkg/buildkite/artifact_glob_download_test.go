@@ -0,0 +1,102 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/require"
+)
+
+type rootsSession struct {
+	roots []mcp.Root
+}
+
+func (s *rootsSession) Initialize()                                         {}
+func (s *rootsSession) Initialized() bool                                   { return true }
+func (s *rootsSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return nil }
+func (s *rootsSession) SessionID() string                                   { return "session" }
+func (s *rootsSession) ListRoots(ctx context.Context, request mcp.ListRootsRequest) (*mcp.ListRootsResult, error) {
+	return &mcp.ListRootsResult{Roots: s.roots}, nil
+}
+
+func TestDownloadArtifacts(t *testing.T) {
+	assert := require.New(t)
+
+	client := &MockArtifactsClient{
+		ListByBuildFunc: func(ctx context.Context, org, pipelineSlug, buildNumber string, opts *buildkite.ArtifactListOptions) ([]buildkite.Artifact, *buildkite.Response, error) {
+			return []buildkite.Artifact{
+				{JobID: "job-1", Path: "coverage/index.html", FileSize: 4, DownloadURL: "coverage/index.html"},
+				{JobID: "job-1", Path: "coverage/src/app.js.html", FileSize: 6, DownloadURL: "coverage/src/app.js.html"},
+				{JobID: "job-2", Path: "coverage/index.html", FileSize: 4, DownloadURL: "coverage/index.html"},
+				{JobID: "job-1", Path: "logs/build.log", FileSize: 3, DownloadURL: "logs/build.log"},
+			}, &buildkite.Response{}, nil
+		},
+		DownloadArtifactByURLFunc: func(ctx context.Context, url string, writer io.Writer) (*buildkite.Response, error) {
+			_, err := io.WriteString(writer, "content of "+url)
+			return &buildkite.Response{}, err
+		},
+	}
+
+	downloadDir := t.TempDir()
+	_, handler, scopes := DownloadArtifacts(client, downloadDir)
+	assert.Equal([]string{"read_artifacts"}, scopes)
+
+	args := DownloadArtifactsArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+		BuildNumber:  "42",
+		Glob:         "coverage/**",
+	}
+
+	t.Run("downloads into the download directory", func(t *testing.T) {
+		result, err := handler(context.Background(), mcp.CallToolRequest{}, args)
+		assert.NoError(err)
+		assert.False(result.IsError, getTextResult(t, result).Text)
+
+		var downloaded DownloadedArtifacts
+		assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &downloaded))
+
+		directory := filepath.Join(downloadDir, "buildkite-artifacts", "org-pipeline-42")
+		assert.Equal(directory, downloaded.Directory)
+		assert.Equal(2, downloaded.FileCount)
+		assert.Equal("coverage/index.html", downloaded.Files[0].Path)
+		assert.Equal(filepath.Join(directory, "coverage", "src", "app.js.html"), downloaded.Files[1].LocalPath)
+		assert.Len(downloaded.Notes, 1)
+
+		data, err := os.ReadFile(filepath.Join(directory, "coverage", "src", "app.js.html"))
+		assert.NoError(err)
+		assert.Equal("content of coverage/src/app.js.html", string(data))
+	})
+
+	t.Run("rejects directories outside the download directory", func(t *testing.T) {
+		args := args
+		args.Directory = t.TempDir()
+
+		result, err := handler(context.Background(), mcp.CallToolRequest{}, args)
+		assert.NoError(err)
+		assert.True(result.IsError)
+		assert.Contains(getTextResult(t, result).Text, "outside the permitted directories")
+	})
+
+	t.Run("downloads within the client's roots", func(t *testing.T) {
+		root := t.TempDir()
+		ctx := server.NewMCPServer("test", "1.0").WithContext(context.Background(), &rootsSession{
+			roots: []mcp.Root{{URI: "file://" + root}},
+		})
+
+		args := args
+		args.Directory = filepath.Join(root, "artifacts")
+
+		result, err := handler(ctx, mcp.CallToolRequest{}, args)
+		assert.NoError(err)
+		assert.False(result.IsError, getTextResult(t, result).Text)
+		assert.FileExists(filepath.Join(root, "artifacts", "coverage", "index.html"))
+	})
+}
@@ -0,0 +1,249 @@
+package buildkite
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ArtifactMetadataClient reads artifact metadata and byte ranges without downloading whole artifacts
+type ArtifactMetadataClient interface {
+	GetArtifactByURL(ctx context.Context, url string) (buildkite.Artifact, *buildkite.Response, error)
+	DownloadArtifactRange(ctx context.Context, url string, offset, length int64, writer io.Writer) (*buildkite.Response, error)
+}
+
+// GetArtifactByURL implements ArtifactMetadataClient
+func (a *BuildkiteClientAdapter) GetArtifactByURL(ctx context.Context, url string) (buildkite.Artifact, *buildkite.Response, error) {
+	req, err := a.NewRequest(ctx, "GET", a.rewriteArtifactURL(url), nil)
+	if err != nil {
+		return buildkite.Artifact{}, nil, err
+	}
+
+	var artifact buildkite.Artifact
+	resp, err := a.Do(req, &artifact)
+	return artifact, resp, err
+}
+
+// errRangeComplete stops reading a response once the requested range has been written
+var errRangeComplete = errors.New("range complete")
+
+// rangeWriter writes up to remaining bytes, then cancels the request so servers which ignore the
+// Range header don't stream the rest of the artifact
+type rangeWriter struct {
+	writer    io.Writer
+	remaining int64
+	cancel    context.CancelCauseFunc
+}
+
+func (w *rangeWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) > w.remaining {
+		p = p[:w.remaining]
+	}
+	n, err := w.writer.Write(p)
+	w.remaining -= int64(n)
+	if err == nil && w.remaining == 0 {
+		w.cancel(errRangeComplete)
+		return n, errRangeComplete
+	}
+	return n, err
+}
+
+// DownloadArtifactRange implements ArtifactMetadataClient. It downloads length bytes from offset, or
+// everything from offset when length isn't positive. Callers check for a 206 status to know whether
+// the server honoured the range.
+func (a *BuildkiteClientAdapter) DownloadArtifactRange(ctx context.Context, url string, offset, length int64, writer io.Writer) (*buildkite.Response, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	req, err := a.NewRequest(ctx, "GET", a.rewriteArtifactURL(url), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if length > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+		writer = &rangeWriter{writer: writer, remaining: length, cancel: cancel}
+	} else if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := a.Do(req, writer)
+	if err != nil && errors.Is(context.Cause(ctx), errRangeComplete) {
+		return resp, nil
+	}
+	return resp, err
+}
+
+const (
+	// artifactSniffBytes is how much of an artifact is read to detect its content type
+	artifactSniffBytes = 512
+	// maxPreviewableTextBytes is the largest text artifact worth reading inline rather than downloading
+	maxPreviewableTextBytes = 1024 * 1024
+)
+
+type HeadArtifactArgs struct {
+	URL          string `json:"url,omitempty"`
+	OrgSlug      string `json:"org_slug,omitempty"`
+	PipelineSlug string `json:"pipeline_slug,omitempty"`
+	BuildNumber  string `json:"build_number,omitempty"`
+	ArtifactID   string `json:"artifact_id,omitempty"`
+}
+
+type ArtifactHead struct {
+	ID                 string `json:"id"`
+	JobID              string `json:"job_id"`
+	Path               string `json:"path"`
+	State              string `json:"state,omitempty"`
+	FileSize           int64  `json:"file_size"`
+	SHA1               string `json:"sha1sum,omitempty"`
+	MimeType           string `json:"mime_type,omitempty"`
+	SniffedContentType string `json:"sniffed_content_type,omitempty"`
+	RangeSupported     bool   `json:"range_supported"`
+	Previewable        bool   `json:"previewable"`
+	DownloadURL        string `json:"download_url"`
+}
+
+// findBuildArtifact finds an artifact of a build by ID
+func findBuildArtifact(ctx context.Context, client ArtifactsClient, org, pipeline, build, id string) (*buildkite.Artifact, error) {
+	options := &buildkite.ArtifactListOptions{
+		ListOptions: buildkite.ListOptions{PerPage: 100},
+	}
+
+	for range maxArtifactPages {
+		page, resp, err := client.ListByBuild(ctx, org, pipeline, build, options)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, artifact := range page {
+			if artifact.ID == id {
+				return &artifact, nil
+			}
+		}
+
+		if resp == nil || resp.NextPage == 0 || len(page) == 0 {
+			break
+		}
+		options.Page = resp.NextPage
+	}
+
+	return nil, fmt.Errorf("artifact %s not found in build %s", id, build)
+}
+
+// rangeTotalSize returns the total size from a Content-Range header such as "bytes 0-511/1234"
+func rangeTotalSize(contentRange string) (int64, bool) {
+	_, total, ok := strings.Cut(contentRange, "/")
+	if !ok {
+		return 0, false
+	}
+	size, err := strconv.ParseInt(total, 10, 64)
+	return size, err == nil
+}
+
+// isPreviewable reports whether an artifact is small enough and of a type that can be read inline
+func isPreviewable(contentType string, size int64) bool {
+	if contentType == "image/png" || contentType == "image/jpeg" {
+		return size <= maxInlineImageBytes
+	}
+	if strings.HasPrefix(contentType, "text/") || strings.HasPrefix(contentType, "application/json") {
+		return size <= maxPreviewableTextBytes
+	}
+	return false
+}
+
+func HeadArtifact(artifactsClient ArtifactsClient, metadataClient ArtifactMetadataClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[HeadArtifactArgs], scopes []string) {
+	return mcp.NewTool("head_artifact",
+			mcp.WithDescription("Get an artifact's size, SHA-1 checksum, and content type, sniffed from its first bytes, without downloading the whole artifact. Use it to decide whether to preview an artifact with get_artifact or download it to a file. Identify the artifact by its url or download_url, or by artifact_id with the org, pipeline, and build number"),
+			mcp.WithString("url",
+				mcp.Description("The artifact's url or download_url, as returned by list_artifacts"),
+			),
+			mcp.WithString("org_slug"),
+			mcp.WithString("pipeline_slug"),
+			mcp.WithString("build_number"),
+			mcp.WithString("artifact_id"),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Head Artifact",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args HeadArtifactArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.HeadArtifact")
+			defer span.End()
+
+			var artifact buildkite.Artifact
+			switch {
+			case args.URL != "":
+				span.SetAttributes(attribute.String("url", args.URL))
+
+				var err error
+				artifact, _, err = metadataClient.GetArtifactByURL(ctx, strings.TrimSuffix(args.URL, "/download"))
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+			case args.ArtifactID != "":
+				if args.OrgSlug == "" || args.PipelineSlug == "" || args.BuildNumber == "" {
+					return mcp.NewToolResultError("org_slug, pipeline_slug and build_number are required with artifact_id"), nil
+				}
+
+				span.SetAttributes(
+					attribute.String("org_slug", args.OrgSlug),
+					attribute.String("pipeline_slug", args.PipelineSlug),
+					attribute.String("build_number", args.BuildNumber),
+					attribute.String("artifact_id", args.ArtifactID),
+				)
+
+				found, err := findBuildArtifact(ctx, artifactsClient, args.OrgSlug, args.PipelineSlug, args.BuildNumber, args.ArtifactID)
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				artifact = *found
+			default:
+				return mcp.NewToolResultError("either url or artifact_id parameter is required"), nil
+			}
+
+			result := ArtifactHead{
+				ID:          artifact.ID,
+				JobID:       artifact.JobID,
+				Path:        artifact.Path,
+				State:       artifact.State,
+				FileSize:    artifact.FileSize,
+				SHA1:        artifact.SHA1,
+				MimeType:    artifact.MimeType,
+				DownloadURL: artifact.DownloadURL,
+			}
+
+			var head bytes.Buffer
+			resp, err := metadataClient.DownloadArtifactRange(ctx, artifact.DownloadURL, 0, artifactSniffBytes, &head)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to read artifact: %v", err)), nil
+			}
+
+			if head.Len() > 0 {
+				result.SniffedContentType = http.DetectContentType(head.Bytes())
+			}
+			if resp != nil && resp.StatusCode == http.StatusPartialContent {
+				result.RangeSupported = true
+				if size, ok := rangeTotalSize(resp.Header.Get("Content-Range")); ok {
+					result.FileSize = size
+				}
+			}
+			result.Previewable = isPreviewable(result.SniffedContentType, result.FileSize)
+
+			span.SetAttributes(
+				attribute.Int64("file_size", result.FileSize),
+				attribute.String("sniffed_content_type", result.SniffedContentType),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_artifacts"}
+}
@@ -0,0 +1,143 @@
+package buildkite
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+type MockArtifactMetadataClient struct {
+	GetArtifactByURLFunc      func(ctx context.Context, url string) (buildkite.Artifact, *buildkite.Response, error)
+	DownloadArtifactRangeFunc func(ctx context.Context, url string, offset, length int64, writer io.Writer) (*buildkite.Response, error)
+}
+
+func (m *MockArtifactMetadataClient) GetArtifactByURL(ctx context.Context, url string) (buildkite.Artifact, *buildkite.Response, error) {
+	if m.GetArtifactByURLFunc != nil {
+		return m.GetArtifactByURLFunc(ctx, url)
+	}
+	return buildkite.Artifact{}, nil, nil
+}
+
+func (m *MockArtifactMetadataClient) DownloadArtifactRange(ctx context.Context, url string, offset, length int64, writer io.Writer) (*buildkite.Response, error) {
+	if m.DownloadArtifactRangeFunc != nil {
+		return m.DownloadArtifactRangeFunc(ctx, url, offset, length, writer)
+	}
+	return nil, nil
+}
+
+var _ ArtifactMetadataClient = (*MockArtifactMetadataClient)(nil)
+
+func TestHeadArtifact(t *testing.T) {
+	assert := require.New(t)
+
+	artifact := buildkite.Artifact{
+		ID:          "artifact-1",
+		JobID:       "job-1",
+		Path:        "logs/output.txt",
+		FileSize:    2048,
+		SHA1:        "abc123",
+		MimeType:    "text/plain",
+		DownloadURL: "https://api.buildkite.com/v2/artifacts/artifact-1/download",
+	}
+
+	metadataClient := &MockArtifactMetadataClient{
+		GetArtifactByURLFunc: func(ctx context.Context, url string) (buildkite.Artifact, *buildkite.Response, error) {
+			assert.Equal("https://api.buildkite.com/v2/artifacts/artifact-1", url)
+			return artifact, &buildkite.Response{}, nil
+		},
+		DownloadArtifactRangeFunc: func(ctx context.Context, url string, offset, length int64, writer io.Writer) (*buildkite.Response, error) {
+			assert.Equal(artifact.DownloadURL, url)
+			assert.Equal(int64(artifactSniffBytes), length)
+			_, err := writer.Write([]byte("hello world\n"))
+			return &buildkite.Response{Response: &http.Response{
+				StatusCode: http.StatusPartialContent,
+				Header:     http.Header{"Content-Range": []string{"bytes 0-511/4096"}},
+			}}, err
+		},
+	}
+	artifactsClient := &MockArtifactsClient{
+		ListByBuildFunc: func(ctx context.Context, org, pipelineSlug, buildNumber string, opts *buildkite.ArtifactListOptions) ([]buildkite.Artifact, *buildkite.Response, error) {
+			return []buildkite.Artifact{{ID: "other"}, artifact}, &buildkite.Response{}, nil
+		},
+	}
+
+	_, handler, _ := HeadArtifact(artifactsClient, metadataClient)
+
+	expected := ArtifactHead{
+		ID:                 "artifact-1",
+		JobID:              "job-1",
+		Path:               "logs/output.txt",
+		FileSize:           4096,
+		SHA1:               "abc123",
+		MimeType:           "text/plain",
+		SniffedContentType: "text/plain; charset=utf-8",
+		RangeSupported:     true,
+		Previewable:        true,
+		DownloadURL:        artifact.DownloadURL,
+	}
+
+	t.Run("by download url", func(t *testing.T) {
+		result, err := handler(context.Background(), mcp.CallToolRequest{}, HeadArtifactArgs{URL: artifact.DownloadURL})
+		assert.NoError(err)
+
+		var head ArtifactHead
+		assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &head))
+		assert.Equal(expected, head)
+	})
+
+	t.Run("by id", func(t *testing.T) {
+		result, err := handler(context.Background(), mcp.CallToolRequest{}, HeadArtifactArgs{
+			OrgSlug:      "org",
+			PipelineSlug: "pipeline",
+			BuildNumber:  "1",
+			ArtifactID:   "artifact-1",
+		})
+		assert.NoError(err)
+
+		var head ArtifactHead
+		assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &head))
+		assert.Equal(expected, head)
+	})
+
+	t.Run("requires url or id", func(t *testing.T) {
+		result, err := handler(context.Background(), mcp.CallToolRequest{}, HeadArtifactArgs{})
+		assert.NoError(err)
+		assert.True(result.IsError)
+	})
+}
+
+func TestBuildkiteClientAdapter_DownloadArtifactRange(t *testing.T) {
+	assert := require.New(t)
+
+	body := strings.Repeat("0123456789", 100_000)
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		// ignore the range, as some storage proxies do
+		_, _ = io.WriteString(w, body)
+	}))
+	defer server.Close()
+
+	client, err := buildkite.NewOpts(
+		buildkite.WithTokenAuth("fake-token"),
+		buildkite.WithBaseURL(server.URL),
+	)
+	assert.NoError(err)
+	adapter := &BuildkiteClientAdapter{Client: client}
+
+	var buf bytes.Buffer
+	resp, err := adapter.DownloadArtifactRange(context.Background(), server.URL+"/artifact", 0, 512, &buf)
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.Equal(body[:512], buf.String())
+	assert.Equal([]string{"bytes=0-511"}, ranges)
+}
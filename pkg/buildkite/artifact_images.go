@@ -0,0 +1,158 @@
+package buildkite
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// maxInlineImageBytes is the largest image returned as image content, beyond which clients
+	// tend to reject or truncate the response
+	maxInlineImageBytes = 5 * 1024 * 1024
+	// maxImageDimension bounds the max_image_dimension parameter
+	maxImageDimension = 4096
+)
+
+// InlineImage describes an image artifact returned as MCP image content
+type InlineImage struct {
+	MimeType       string `json:"mime_type"`
+	Width          int    `json:"width"`
+	Height         int    `json:"height"`
+	Size           int    `json:"size"`
+	Downscaled     bool   `json:"downscaled,omitempty"`
+	OriginalWidth  int    `json:"original_width,omitempty"`
+	OriginalHeight int    `json:"original_height,omitempty"`
+	OriginalSize   int    `json:"original_size,omitempty"`
+}
+
+// sniffImageType returns the MIME type of PNG and JPEG data, or "" for anything else
+func sniffImageType(data []byte) string {
+	switch mimeType := http.DetectContentType(data); mimeType {
+	case "image/png", "image/jpeg":
+		return mimeType
+	default:
+		return ""
+	}
+}
+
+// prepareInlineImage decodes an image, downscaling it to fit within maxDimension pixels on its
+// longest side when maxDimension is set, and re-encoding it in its original format
+func prepareInlineImage(data []byte, mimeType string, maxDimension int) ([]byte, InlineImage, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, InlineImage{}, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	info := InlineImage{
+		MimeType: mimeType,
+		Width:    config.Width,
+		Height:   config.Height,
+		Size:     len(data),
+	}
+	if maxDimension <= 0 || max(config.Width, config.Height) <= maxDimension {
+		return data, info, nil
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, InlineImage{}, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	scale := float64(maxDimension) / float64(max(config.Width, config.Height))
+	dst := downscaleImage(src, max(1, int(float64(config.Width)*scale)), max(1, int(float64(config.Height)*scale)))
+
+	var buf bytes.Buffer
+	if mimeType == "image/png" {
+		err = png.Encode(&buf, dst)
+	} else {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+	}
+	if err != nil {
+		return nil, InlineImage{}, fmt.Errorf("failed to encode image: %w", err)
+	}
+
+	info.Downscaled = true
+	info.OriginalWidth, info.OriginalHeight, info.OriginalSize = info.Width, info.Height, info.Size
+	info.Width, info.Height, info.Size = dst.Bounds().Dx(), dst.Bounds().Dy(), buf.Len()
+
+	return buf.Bytes(), info, nil
+}
+
+// downscaleImage resizes an image by averaging the source pixels covered by each destination pixel,
+// which keeps the text in screenshots legible better than sampling would
+func downscaleImage(src image.Image, width, height int) *image.NRGBA {
+	bounds := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+
+	for y := range height {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*bounds.Dy()/height)
+		for x := range width {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := max(x0+1, bounds.Min.X+(x+1)*bounds.Dx()/width)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := color.NRGBA64Model.Convert(src.At(sx, sy)).(color.NRGBA64)
+					r += uint64(c.R)
+					g += uint64(c.G)
+					b += uint64(c.B)
+					a += uint64(c.A)
+					n++
+				}
+			}
+			dst.Set(x, y, color.NRGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+
+	return dst
+}
+
+// imageArtifactResult returns an image artifact as image content so clients can render it inline,
+// alongside a text description of the image
+func imageArtifactResult(span trace.Span, resp *buildkite.Response, data []byte, mimeType string, maxDimension int) (*mcp.CallToolResult, error) {
+	inlineImage, info, err := prepareInlineImage(data, mimeType, maxDimension)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	span.SetAttributes(
+		attribute.String("mime_type", mimeType),
+		attribute.Int("image_size", info.Size),
+		attribute.Bool("downscaled", info.Downscaled),
+	)
+
+	if info.Size > maxInlineImageBytes {
+		return mcp.NewToolResultError(fmt.Sprintf("image is %d bytes, more than the %d byte limit for inline images; use max_image_dimension to downscale it", info.Size, maxInlineImageBytes)), nil
+	}
+
+	result := map[string]any{
+		"status":     resp.Status,
+		"statusCode": resp.StatusCode,
+		"image":      info,
+	}
+	r, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal artifact: %w", err)
+	}
+
+	return mcp.NewToolResultImage(string(r), base64.StdEncoding.EncodeToString(inlineImage), mimeType), nil
+}
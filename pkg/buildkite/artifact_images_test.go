@@ -0,0 +1,102 @@
+package buildkite
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestGetArtifactImage(t *testing.T) {
+	assert := require.New(t)
+
+	data := testPNG(t, 200, 100)
+	client := &MockArtifactsClient{
+		DownloadArtifactByURLFunc: func(ctx context.Context, url string, writer io.Writer) (*buildkite.Response, error) {
+			_, err := writer.Write(data)
+			return &buildkite.Response{Response: &http.Response{StatusCode: 200, Status: "200 OK"}}, err
+		},
+	}
+
+	_, handler, _ := GetArtifact(client)
+
+	t.Run("returns image content", func(t *testing.T) {
+		result, err := handler(context.Background(), createMCPRequest(t, map[string]any{
+			"url": "https://example.com/screenshot.png",
+		}))
+		assert.NoError(err)
+		assert.False(result.IsError)
+		assert.Len(result.Content, 2)
+
+		var metadata struct {
+			Image InlineImage `json:"image"`
+		}
+		assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &metadata))
+		assert.Equal(InlineImage{MimeType: "image/png", Width: 200, Height: 100, Size: len(data)}, metadata.Image)
+
+		imageContent, ok := result.Content[1].(mcp.ImageContent)
+		assert.True(ok)
+		assert.Equal("image/png", imageContent.MIMEType)
+		assert.Equal(base64.StdEncoding.EncodeToString(data), imageContent.Data)
+	})
+
+	t.Run("downscales to the max dimension", func(t *testing.T) {
+		result, err := handler(context.Background(), createMCPRequest(t, map[string]any{
+			"url":                 "https://example.com/screenshot.png",
+			"max_image_dimension": 50,
+		}))
+		assert.NoError(err)
+		assert.False(result.IsError)
+
+		imageContent, ok := result.Content[1].(mcp.ImageContent)
+		assert.True(ok)
+		decoded, err := base64.StdEncoding.DecodeString(imageContent.Data)
+		assert.NoError(err)
+
+		config, err := png.DecodeConfig(bytes.NewReader(decoded))
+		assert.NoError(err)
+		assert.Equal(50, config.Width)
+		assert.Equal(25, config.Height)
+
+		var metadata struct {
+			Image InlineImage `json:"image"`
+		}
+		assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &metadata))
+		assert.True(metadata.Image.Downscaled)
+		assert.Equal(200, metadata.Image.OriginalWidth)
+	})
+}
+
+func TestSniffImageType(t *testing.T) {
+	assert := require.New(t)
+
+	assert.Equal("image/png", sniffImageType(testPNG(t, 1, 1)))
+	assert.Equal("image/jpeg", sniffImageType([]byte("\xFF\xD8\xFF\xE0\x00\x10JFIF")))
+	assert.Equal("", sniffImageType([]byte("GIF89a")))
+	assert.Equal("", sniffImageType([]byte("plain text")))
+}
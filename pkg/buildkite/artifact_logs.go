@@ -0,0 +1,408 @@
+package buildkite
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+	"github.com/buildkite/buildkite-mcp-server/pkg/redact"
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	ArtifactLogsOperationSearch = "search"
+	ArtifactLogsOperationTail   = "tail"
+	ArtifactLogsOperationRead   = "read"
+	ArtifactLogsOperationInfo   = "info"
+)
+
+var (
+	// the fields structured loggers commonly use, in order of preference
+	jsonLogMessageFields   = []string{"message", "msg", "log", "text"}
+	jsonLogTimestampFields = []string{"timestamp", "time", "ts", "@timestamp"}
+	jsonLogLevelFields     = []string{"level", "severity", "lvl"}
+)
+
+// DefaultArtifactLogsCacheDir is where converted artifact logs are cached when no directory is
+// configured
+func DefaultArtifactLogsCacheDir() string {
+	return filepath.Join(os.TempDir(), "buildkite-mcp-server", "artifact-logs")
+}
+
+type QueryArtifactLogsArgs struct {
+	URL            string `json:"url"`
+	Operation      string `json:"operation"`
+	Pattern        string `json:"pattern"`
+	Context        int    `json:"context"`
+	BeforeContext  int    `json:"before_context"`
+	AfterContext   int    `json:"after_context"`
+	CaseSensitive  bool   `json:"case_sensitive"`
+	InvertMatch    bool   `json:"invert_match"`
+	Reverse        bool   `json:"reverse"`
+	Tail           int    `json:"tail"`
+	Seek           int    `json:"seek"`
+	Limit          int    `json:"limit"`
+	MessageField   string `json:"message_field,omitempty"`
+	TimestampField string `json:"timestamp_field,omitempty"`
+	ForceRefresh   bool   `json:"force_refresh"`
+}
+
+// jsonLogFields are the fields of a structured log line which become the entry's timestamp and
+// content
+type jsonLogFields struct {
+	message   []string
+	timestamp []string
+}
+
+// parseJSONLogTimestamp reads an RFC 3339 string or a unix timestamp in seconds or milliseconds
+func parseJSONLogTimestamp(value any) (time.Time, bool) {
+	switch v := value.(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		return t, err == nil
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil || f <= 0 {
+			return time.Time{}, false
+		}
+		// timestamps beyond the year 33658 in seconds are taken to be milliseconds
+		if f > 1e12 {
+			return time.UnixMilli(int64(f)), true
+		}
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(frac*1e9)), true
+	}
+	return time.Time{}, false
+}
+
+// firstField removes and returns the first of the named fields present in a log line
+func firstField(fields map[string]any, names []string) (any, bool) {
+	for _, name := range names {
+		if value, ok := fields[name]; ok {
+			delete(fields, name)
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// parseJSONLogLine converts a structured log line into a log entry. The content is the level and
+// message followed by the remaining fields as key=value pairs, so they can still be searched. Lines
+// which aren't JSON objects are kept as they are.
+func parseJSONLogLine(line []byte, fieldNames jsonLogFields) *buildkitelogs.LogEntry {
+	entry := &buildkitelogs.LogEntry{Content: string(line)}
+
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+	var fields map[string]any
+	if err := decoder.Decode(&fields); err != nil || fields == nil {
+		return entry
+	}
+
+	if value, ok := firstField(fields, fieldNames.timestamp); ok {
+		if ts, ok := parseJSONLogTimestamp(value); ok {
+			entry.Timestamp = ts
+		}
+	}
+
+	var parts []string
+	if level, ok := firstField(fields, jsonLogLevelFields); ok {
+		parts = append(parts, strings.ToUpper(fmt.Sprint(level)))
+	}
+	if message, ok := firstField(fields, fieldNames.message); ok {
+		parts = append(parts, fmt.Sprint(message))
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		value, ok := fields[key].(string)
+		if !ok {
+			encoded, _ := json.Marshal(fields[key])
+			value = string(encoded)
+		}
+		parts = append(parts, key+"="+value)
+	}
+
+	entry.Content = strings.Join(parts, " ")
+	return entry
+}
+
+// jsonLogEntries parses each line of a JSONL stream into a log entry, skipping blank lines
+func jsonLogEntries(reader io.Reader, fieldNames jsonLogFields) iter.Seq2[*buildkitelogs.LogEntry, error] {
+	return func(yield func(*buildkitelogs.LogEntry, error) bool) {
+		scanner := bufio.NewScanner(reader)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxArtifactScanLine)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			if bytes.IndexByte(line, 0) >= 0 {
+				yield(nil, errBinaryArtifact)
+				return
+			}
+			if !yield(parseJSONLogLine(line, fieldNames), nil) {
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			if errors.Is(err, bufio.ErrTooLong) {
+				err = errBinaryArtifact
+			}
+			yield(nil, err)
+		}
+	}
+}
+
+// convertArtifactLogs downloads a JSONL artifact and converts it into a Parquet log file, which is
+// cached as artifacts don't change once uploaded
+func convertArtifactLogs(ctx context.Context, client ArtifactsClient, cacheDir, downloadURL string, fieldNames jsonLogFields, forceRefresh bool) (string, error) {
+	key := sha1.Sum([]byte(strings.Join([]string{downloadURL, strings.Join(fieldNames.message, ","), strings.Join(fieldNames.timestamp, ",")}, "\n")))
+	cacheFile := filepath.Join(cacheDir, hex.EncodeToString(key[:])+".parquet")
+
+	if !forceRefresh {
+		if _, err := os.Stat(cacheFile); err == nil {
+			return cacheFile, nil
+		}
+	}
+
+	if err := os.MkdirAll(cacheDir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	reader, writer := io.Pipe()
+	downloaded := make(chan error, 1)
+	go func() {
+		_, err := client.DownloadArtifactByURL(ctx, downloadURL, writer)
+		_ = writer.CloseWithError(err)
+		downloaded <- err
+	}()
+
+	partial := cacheFile + partialDownloadSuffix
+	err := buildkitelogs.ExportSeq2ToParquet(jsonLogEntries(reader, fieldNames), partial)
+
+	// stop the download when the conversion failed part way through
+	cancel()
+	_ = reader.Close()
+	downloadErr := <-downloaded
+
+	if err == nil && downloadErr != nil {
+		err = downloadErr
+	}
+	if err != nil {
+		_ = os.Remove(partial)
+		return "", fmt.Errorf("failed to convert artifact logs: %w", err)
+	}
+
+	return cacheFile, os.Rename(partial, cacheFile)
+}
+
+func QueryArtifactLogs(client ArtifactsClient, redactor *redact.Redactor, cacheDir string) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[QueryArtifactLogsArgs], scopes []string) {
+	if cacheDir == "" {
+		cacheDir = DefaultArtifactLogsCacheDir()
+	}
+
+	return mcp.NewTool("query_artifact_logs",
+			mcp.WithDescription("Query structured logs uploaded as a JSONL artifact, such as application logs from integration tests, with the same search, tail, and read operations as the job log tools. Each line's level, message, and timestamp are recognised and the remaining fields are kept as key=value pairs. 💡 Use 'info' first to check the number of entries. The json format: {ts: timestamp_ms, c: content, rn: row_number}."),
+			mcp.WithString("url",
+				mcp.Required(),
+				mcp.Description("The artifact's download_url, as returned by list_artifacts"),
+			),
+			mcp.WithString("operation",
+				mcp.Required(),
+				mcp.Enum(ArtifactLogsOperationSearch, ArtifactLogsOperationTail, ArtifactLogsOperationRead, ArtifactLogsOperationInfo),
+				mcp.Description("search: regex search like search_logs; tail: the last entries like tail_logs; read: entries from a row like read_logs; info: the number of entries and file size"),
+			),
+			mcp.WithString("pattern",
+				mcp.Description("Regex pattern to search for (search only)"),
+			),
+			mcp.WithNumber("context",
+				mcp.Description("Show NUM lines before and after each match (search only, default: 0)"),
+				mcp.Min(0),
+			),
+			mcp.WithNumber("before_context",
+				mcp.Description("Show NUM lines before each match (search only, default: 0)"),
+				mcp.Min(0),
+			),
+			mcp.WithNumber("after_context",
+				mcp.Description("Show NUM lines after each match (search only, default: 0)"),
+				mcp.Min(0),
+			),
+			mcp.WithBoolean("case_sensitive",
+				mcp.Description("Case-sensitive search (search only, default: false)"),
+			),
+			mcp.WithBoolean("invert_match",
+				mcp.Description("Show non-matching lines (search only, default: false)"),
+			),
+			mcp.WithBoolean("reverse",
+				mcp.Description("Search backwards from the end (search only, default: false)"),
+			),
+			mcp.WithNumber("tail",
+				mcp.Description("Number of entries to show from end (tail only, default: 10)"),
+				mcp.Min(1),
+				mcp.DefaultNumber(10),
+			),
+			mcp.WithNumber("seek",
+				mcp.Description("Row number to start from (read only, 0-based, default: 0)"),
+				mcp.Min(0),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Limit number of matches or entries returned (search and read, default: 100, 0 = no limit)"),
+				mcp.Min(0),
+				mcp.DefaultNumber(100),
+			),
+			mcp.WithString("message_field",
+				mcp.Description("Field holding the log message (default: the first of message, msg, log, or text)"),
+			),
+			mcp.WithString("timestamp_field",
+				mcp.Description("Field holding the timestamp, as RFC 3339 or unix seconds or milliseconds (default: the first of timestamp, time, ts, or @timestamp)"),
+			),
+			mcp.WithBoolean("force_refresh",
+				mcp.Description("Convert the artifact again rather than using the cached conversion (default: false)"),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Query Artifact Logs",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args QueryArtifactLogsArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.QueryArtifactLogs")
+			defer span.End()
+
+			startTime := time.Now()
+
+			if args.URL == "" {
+				return mcp.NewToolResultError("url parameter is required"), nil
+			}
+
+			switch args.Operation {
+			case ArtifactLogsOperationSearch:
+				if args.Pattern == "" {
+					return mcp.NewToolResultError("pattern parameter is required for search"), nil
+				}
+				if err := validateSearchPattern(args.Pattern); err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+			case ArtifactLogsOperationTail, ArtifactLogsOperationRead, ArtifactLogsOperationInfo:
+			default:
+				return mcp.NewToolResultError("operation must be one of search, tail, read, or info"), nil
+			}
+
+			span.SetAttributes(
+				attribute.String("url", args.URL),
+				attribute.String("operation", args.Operation),
+			)
+
+			fieldNames := jsonLogFields{message: jsonLogMessageFields, timestamp: jsonLogTimestampFields}
+			if args.MessageField != "" {
+				fieldNames.message = []string{args.MessageField}
+			}
+			if args.TimestampField != "" {
+				fieldNames.timestamp = []string{args.TimestampField}
+			}
+
+			cacheFile, err := convertArtifactLogs(ctx, client, cacheDir, args.URL, fieldNames, args.ForceRefresh)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			reader := buildkitelogs.NewParquetReader(cacheFile)
+
+			var response LogResponse
+			var entries []buildkitelogs.ParquetLogEntry
+
+			switch args.Operation {
+			case ArtifactLogsOperationSearch:
+				opts := SearchOptions{
+					Pattern:       args.Pattern,
+					CaseSensitive: args.CaseSensitive,
+					InvertMatch:   args.InvertMatch,
+					Reverse:       args.Reverse,
+					Context:       args.Context,
+					BeforeContext: args.BeforeContext,
+					AfterContext:  args.AfterContext,
+				}
+
+				var results []SearchResult
+				for result, err := range reader.SearchEntriesIter(opts) {
+					if err != nil {
+						return mcp.NewToolResultError(fmt.Sprintf("Search error: %v", err)), nil
+					}
+					results = append(results, result)
+					if args.Limit > 0 && len(results) >= args.Limit {
+						break
+					}
+				}
+
+				response.Results = results
+				response.MatchCount = len(results)
+				response.Redactions = redactSearchResults(results, redactor)
+
+			case ArtifactLogsOperationInfo:
+				libFileInfo, err := reader.GetFileInfo()
+				if err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("Failed to get file info: %v", err)), nil
+				}
+				response.FileInfo = &FileInfo{ParquetFileInfo: *libFileInfo, CacheFile: cacheFile}
+
+			case ArtifactLogsOperationTail:
+				if args.Tail <= 0 {
+					args.Tail = 10
+				}
+				fileInfo, err := reader.GetFileInfo()
+				if err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("Failed to get file info: %v", err)), nil
+				}
+				for entry, err := range reader.SeekToRow(max(fileInfo.RowCount-int64(args.Tail), 0)) {
+					if err != nil {
+						return mcp.NewToolResultError(fmt.Sprintf("Failed to read tail entries: %v", err)), nil
+					}
+					entries = append(entries, entry)
+				}
+				response.TotalRows = fileInfo.RowCount
+				response.Entries, response.Redactions = formatLogEntries(entries, redactor, nil)
+
+			case ArtifactLogsOperationRead:
+				for entry, err := range reader.SeekToRow(int64(args.Seek)) {
+					if err != nil {
+						return mcp.NewToolResultError(fmt.Sprintf("Failed to read entries: %v", err)), nil
+					}
+					entries = append(entries, entry)
+					if args.Limit > 0 && len(entries) >= args.Limit {
+						break
+					}
+				}
+				response.Entries, response.Redactions = formatLogEntries(entries, redactor, nil)
+			}
+
+			response.QueryTimeMS = time.Since(startTime).Milliseconds()
+
+			span.SetAttributes(
+				attribute.Int("item_count", max(response.MatchCount, len(entries))),
+			)
+
+			return mcpTextResult(span, &response)
+		}, []string{"read_artifacts"}
+}
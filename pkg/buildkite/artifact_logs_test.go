@@ -0,0 +1,139 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+const testJSONLogs = `{"time":"2025-01-02T03:04:05Z","level":"info","msg":"server started","port":8080}
+{"time":"2025-01-02T03:04:06Z","level":"error","msg":"connection refused","host":"db"}
+
+not json
+{"ts":1735787047,"message":"shutting down"}
+`
+
+func TestParseJSONLogLine(t *testing.T) {
+	assert := require.New(t)
+
+	fields := jsonLogFields{message: jsonLogMessageFields, timestamp: jsonLogTimestampFields}
+
+	entry := parseJSONLogLine([]byte(`{"time":"2025-01-02T03:04:05Z","level":"warn","msg":"slow query","duration":1.5,"table":"users"}`), fields)
+	assert.Equal("WARN slow query duration=1.5 table=users", entry.Content)
+	assert.Equal(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), entry.Timestamp.UTC())
+
+	entry = parseJSONLogLine([]byte(`{"ts":1735787045123,"message":"done"}`), fields)
+	assert.Equal("done", entry.Content)
+	assert.Equal(int64(1735787045123), entry.Timestamp.UnixMilli())
+
+	entry = parseJSONLogLine([]byte(`{"event":"done","at":"2025-01-02T03:04:05Z"}`), jsonLogFields{message: []string{"event"}, timestamp: []string{"at"}})
+	assert.Equal("done", entry.Content)
+	assert.False(entry.Timestamp.IsZero())
+
+	entry = parseJSONLogLine([]byte("plain text"), fields)
+	assert.Equal("plain text", entry.Content)
+	assert.True(entry.Timestamp.IsZero())
+}
+
+func TestQueryArtifactLogs(t *testing.T) {
+	assert := require.New(t)
+
+	downloads := 0
+	client := &MockArtifactsClient{
+		DownloadArtifactByURLFunc: func(ctx context.Context, url string, writer io.Writer) (*buildkite.Response, error) {
+			downloads++
+			_, err := io.WriteString(writer, testJSONLogs)
+			return &buildkite.Response{}, err
+		},
+	}
+
+	_, handler, scopes := QueryArtifactLogs(client, nil, t.TempDir())
+	assert.Equal([]string{"read_artifacts"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, QueryArtifactLogsArgs{
+		URL:       "https://example.com/app.jsonl",
+		Operation: ArtifactLogsOperationSearch,
+		Pattern:   "refused",
+		Context:   1,
+	})
+	assert.NoError(err)
+	assert.False(result.IsError, getTextResult(t, result).Text)
+
+	var search struct {
+		Results    []SearchResult `json:"results"`
+		MatchCount int            `json:"match_count"`
+	}
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &search))
+	assert.Equal(1, search.MatchCount)
+	assert.Equal("ERROR connection refused host=db", search.Results[0].Match.Content)
+	assert.Equal(int64(1), search.Results[0].Match.RowNumber)
+	assert.Equal(time.Date(2025, 1, 2, 3, 4, 6, 0, time.UTC).UnixMilli(), search.Results[0].Match.Timestamp)
+	assert.Len(search.Results[0].BeforeContext, 1)
+	assert.Len(search.Results[0].AfterContext, 1)
+
+	result, err = handler(context.Background(), mcp.CallToolRequest{}, QueryArtifactLogsArgs{
+		URL:       "https://example.com/app.jsonl",
+		Operation: ArtifactLogsOperationTail,
+		Tail:      2,
+	})
+	assert.NoError(err)
+	assert.False(result.IsError, getTextResult(t, result).Text)
+
+	var tail struct {
+		Entries   []TerseLogEntry `json:"entries"`
+		TotalRows int64           `json:"total_rows"`
+	}
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &tail))
+	assert.Equal(int64(4), tail.TotalRows)
+	assert.Equal([]TerseLogEntry{
+		{C: "not json", RN: 2},
+		{TS: 1735787047000, C: "shutting down", RN: 3},
+	}, tail.Entries)
+
+	// the conversion is cached, so later queries don't download the artifact again
+	assert.Equal(1, downloads)
+
+	result, err = handler(context.Background(), mcp.CallToolRequest{}, QueryArtifactLogsArgs{
+		URL:          "https://example.com/app.jsonl",
+		Operation:    ArtifactLogsOperationRead,
+		Seek:         1,
+		Limit:        1,
+		ForceRefresh: true,
+	})
+	assert.NoError(err)
+	assert.False(result.IsError, getTextResult(t, result).Text)
+
+	var read struct {
+		Entries []TerseLogEntry `json:"entries"`
+	}
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &read))
+	assert.Equal([]TerseLogEntry{{TS: 1735787046000, C: "ERROR connection refused host=db", RN: 1}}, read.Entries)
+	assert.Equal(2, downloads)
+}
+
+func TestQueryArtifactLogsValidation(t *testing.T) {
+	assert := require.New(t)
+
+	_, handler, _ := QueryArtifactLogs(&MockArtifactsClient{}, nil, t.TempDir())
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, QueryArtifactLogsArgs{
+		URL:       "https://example.com/app.jsonl",
+		Operation: "grep",
+	})
+	assert.NoError(err)
+	assert.True(result.IsError)
+
+	result, err = handler(context.Background(), mcp.CallToolRequest{}, QueryArtifactLogsArgs{
+		URL:       "https://example.com/app.jsonl",
+		Operation: ArtifactLogsOperationSearch,
+	})
+	assert.NoError(err)
+	assert.True(result.IsError)
+	assert.Contains(getTextResult(t, result).Text, "pattern")
+}
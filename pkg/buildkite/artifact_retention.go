@@ -0,0 +1,30 @@
+package buildkite
+
+import (
+	"time"
+
+	"github.com/buildkite/go-buildkite/v4"
+)
+
+// defaultExpiryWarningDays is the horizon within which artifacts are flagged as expiring soon
+const defaultExpiryWarningDays = 14
+
+// ArtifactExpiry is when an artifact is expected to be deleted under the retention period
+type ArtifactExpiry struct {
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	ExpiringSoon bool       `json:"expiring_soon,omitempty"`
+}
+
+// artifactExpiry estimates when the artifacts of a build created at createdAt expire, flagging them
+// when that's within horizon of now. Nothing is estimated without a retention period.
+func artifactExpiry(createdAt *buildkite.Timestamp, retention, horizon time.Duration, now time.Time) ArtifactExpiry {
+	if createdAt == nil || retention <= 0 {
+		return ArtifactExpiry{}
+	}
+
+	expiresAt := createdAt.Add(retention).UTC()
+	return ArtifactExpiry{
+		ExpiresAt:    &expiresAt,
+		ExpiringSoon: expiresAt.Before(now.Add(horizon)),
+	}
+}
@@ -0,0 +1,31 @@
+package buildkite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArtifactExpiry(t *testing.T) {
+	assert := require.New(t)
+
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	retention := 30 * 24 * time.Hour
+	horizon := 7 * 24 * time.Hour
+
+	expiry := artifactExpiry(&buildkite.Timestamp{Time: now.Add(-10 * 24 * time.Hour)}, retention, horizon, now)
+	assert.Equal(time.Date(2025, 6, 21, 0, 0, 0, 0, time.UTC), *expiry.ExpiresAt)
+	assert.False(expiry.ExpiringSoon)
+
+	expiry = artifactExpiry(&buildkite.Timestamp{Time: now.Add(-25 * 24 * time.Hour)}, retention, horizon, now)
+	assert.True(expiry.ExpiringSoon)
+
+	// already past retention
+	expiry = artifactExpiry(&buildkite.Timestamp{Time: now.Add(-40 * 24 * time.Hour)}, retention, horizon, now)
+	assert.True(expiry.ExpiringSoon)
+
+	assert.Equal(ArtifactExpiry{}, artifactExpiry(nil, retention, horizon, now))
+	assert.Equal(ArtifactExpiry{}, artifactExpiry(&buildkite.Timestamp{Time: now}, 0, horizon, now))
+}
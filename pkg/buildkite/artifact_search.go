@@ -0,0 +1,273 @@
+package buildkite
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/redact"
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	defaultArtifactSearchLimit = 100
+	// maxArtifactLineLength truncates the very long lines of minified or generated artifacts
+	maxArtifactLineLength = 2000
+	// maxArtifactScanLine is the longest line scanned, beyond which an artifact isn't treated as text
+	maxArtifactScanLine = 4 * 1024 * 1024
+)
+
+var errBinaryArtifact = errors.New("artifact is not a text file")
+
+type SearchArtifactArgs struct {
+	URL           string `json:"url,omitempty"`
+	OrgSlug       string `json:"org_slug,omitempty"`
+	PipelineSlug  string `json:"pipeline_slug,omitempty"`
+	BuildNumber   string `json:"build_number,omitempty"`
+	ArtifactID    string `json:"artifact_id,omitempty"`
+	Pattern       string `json:"pattern"`
+	Context       int    `json:"context"`
+	BeforeContext int    `json:"before_context"`
+	AfterContext  int    `json:"after_context"`
+	CaseSensitive bool   `json:"case_sensitive"`
+	InvertMatch   bool   `json:"invert_match"`
+	Limit         *int   `json:"limit,omitempty"`
+}
+
+// ArtifactSearchResult is a matching line of an artifact with its surrounding lines, in the same
+// shape as search_logs results
+type ArtifactSearchResult struct {
+	Match         TerseLogEntry   `json:"match"`
+	BeforeContext []TerseLogEntry `json:"before_context,omitempty"`
+	AfterContext  []TerseLogEntry `json:"after_context,omitempty"`
+}
+
+// artifactLineSearch finds matching lines in a stream, keeping only the lines needed for context
+type artifactLineSearch struct {
+	pattern       *regexp.Regexp
+	invert        bool
+	before, after int
+	limit         int
+	redactor      *redact.Redactor
+
+	results    []ArtifactSearchResult
+	redactions int
+	previous   []TerseLogEntry
+	// results still collecting their after context
+	pending []int
+}
+
+// add processes the next line, returning false once the limit has been reached and the last
+// match's context is complete
+func (s *artifactLineSearch) add(row int64, line string) bool {
+	content, _ := truncateRunes(line, maxArtifactLineLength)
+	content, n := s.redactor.Redact(content)
+	s.redactions += n
+	entry := TerseLogEntry{C: content, RN: row}
+
+	pending := s.pending[:0]
+	for _, i := range s.pending {
+		s.results[i].AfterContext = append(s.results[i].AfterContext, entry)
+		if len(s.results[i].AfterContext) < s.after {
+			pending = append(pending, i)
+		}
+	}
+	s.pending = pending
+
+	limitReached := s.limit > 0 && len(s.results) >= s.limit
+	if !limitReached && s.pattern.MatchString(line) != s.invert {
+		s.results = append(s.results, ArtifactSearchResult{
+			Match:         entry,
+			BeforeContext: append([]TerseLogEntry(nil), s.previous...),
+		})
+		if s.after > 0 {
+			s.pending = append(s.pending, len(s.results)-1)
+		}
+		limitReached = s.limit > 0 && len(s.results) >= s.limit
+	}
+
+	if s.before > 0 {
+		s.previous = append(s.previous, entry)
+		if len(s.previous) > s.before {
+			s.previous = s.previous[1:]
+		}
+	}
+
+	return !limitReached || len(s.pending) > 0
+}
+
+// searchArtifactStream streams an artifact through the search, stopping the download once enough
+// matches have been found
+func searchArtifactStream(ctx context.Context, client ArtifactsClient, url string, search *artifactLineSearch) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	reader, writer := io.Pipe()
+	downloaded := make(chan error, 1)
+	go func() {
+		_, err := client.DownloadArtifactByURL(ctx, url, writer)
+		_ = writer.CloseWithError(err)
+		downloaded <- err
+	}()
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxArtifactScanLine)
+
+	var row int64
+	stopped := false
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if bytes.IndexByte(line, 0) >= 0 {
+			cancel()
+			_ = reader.CloseWithError(errBinaryArtifact)
+			<-downloaded
+			return errBinaryArtifact
+		}
+
+		if !search.add(row, strings.TrimSuffix(string(line), "\r")) {
+			stopped = true
+			break
+		}
+		row++
+	}
+	scanErr := scanner.Err()
+
+	// stop the download when the search finished early
+	cancel()
+	_ = reader.Close()
+	downloadErr := <-downloaded
+
+	if stopped {
+		return nil
+	}
+	if errors.Is(scanErr, bufio.ErrTooLong) {
+		return errBinaryArtifact
+	}
+	if downloadErr != nil {
+		return downloadErr
+	}
+	return scanErr
+}
+
+func SearchArtifact(client ArtifactsClient, redactor *redact.Redactor) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[SearchArtifactArgs], scopes []string) {
+	return mcp.NewTool("search_artifact",
+			mcp.WithDescription("Search a text artifact, such as a test report or application log, using a regex pattern with optional context lines, like search_logs does for job logs. The artifact is streamed rather than returned, so it works for artifacts too large to read whole. Identify the artifact by its download_url, or by artifact_id with the org, pipeline, and build number. The json format: {c: content, rn: row_number}."),
+			mcp.WithString("url",
+				mcp.Description("The artifact's download_url, as returned by list_artifacts"),
+			),
+			mcp.WithString("org_slug"),
+			mcp.WithString("pipeline_slug"),
+			mcp.WithString("build_number"),
+			mcp.WithString("artifact_id"),
+			mcp.WithString("pattern",
+				mcp.Required(),
+				mcp.Description("Regex pattern to search for"),
+			),
+			mcp.WithNumber("context",
+				mcp.Description("Show NUM lines before and after each match (default: 0)"),
+				mcp.Min(0),
+			),
+			mcp.WithNumber("before_context",
+				mcp.Description("Show NUM lines before each match (default: 0)"),
+				mcp.Min(0),
+			),
+			mcp.WithNumber("after_context",
+				mcp.Description("Show NUM lines after each match (default: 0)"),
+				mcp.Min(0),
+			),
+			mcp.WithBoolean("case_sensitive",
+				mcp.Description("Case-sensitive search (default: false)"),
+			),
+			mcp.WithBoolean("invert_match",
+				mcp.Description("Show non-matching lines (default: false)"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Limit number of matches returned (default: 100, 0 = no limit)"),
+				mcp.Min(0),
+				mcp.DefaultNumber(defaultArtifactSearchLimit),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Search Artifact",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args SearchArtifactArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.SearchArtifact")
+			defer span.End()
+
+			startTime := time.Now()
+
+			if args.Pattern == "" {
+				return mcp.NewToolResultError("pattern parameter is required"), nil
+			}
+			if err := validateSearchPattern(args.Pattern); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			pattern := args.Pattern
+			if !args.CaseSensitive {
+				pattern = "(?i)" + pattern
+			}
+
+			limit := defaultArtifactSearchLimit
+			if args.Limit != nil {
+				limit = *args.Limit
+			}
+
+			downloadURL := args.URL
+			if downloadURL == "" {
+				if args.ArtifactID == "" {
+					return mcp.NewToolResultError("either url or artifact_id parameter is required"), nil
+				}
+				if args.OrgSlug == "" || args.PipelineSlug == "" || args.BuildNumber == "" {
+					return mcp.NewToolResultError("org_slug, pipeline_slug and build_number are required with artifact_id"), nil
+				}
+
+				artifact, err := findBuildArtifact(ctx, client, args.OrgSlug, args.PipelineSlug, args.BuildNumber, args.ArtifactID)
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				downloadURL = artifact.DownloadURL
+			}
+
+			span.SetAttributes(
+				attribute.String("url", downloadURL),
+				attribute.String("pattern", args.Pattern),
+				attribute.Bool("case_sensitive", args.CaseSensitive),
+				attribute.Bool("invert_match", args.InvertMatch),
+				attribute.Int("limit", limit),
+			)
+
+			search := &artifactLineSearch{
+				pattern:  regexp.MustCompile(pattern),
+				invert:   args.InvertMatch,
+				before:   max(args.Context, args.BeforeContext),
+				after:    max(args.Context, args.AfterContext),
+				limit:    limit,
+				redactor: redactor,
+			}
+			if err := searchArtifactStream(ctx, client, downloadURL, search); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Search error: %v", err)), nil
+			}
+
+			response := LogResponse{
+				Results:     search.results,
+				MatchCount:  len(search.results),
+				Redactions:  search.redactions,
+				QueryTimeMS: time.Since(startTime).Milliseconds(),
+			}
+
+			span.SetAttributes(
+				attribute.Int("item_count", len(search.results)),
+			)
+
+			return mcpTextResult(span, &response)
+		}, []string{"read_artifacts"}
+}
@@ -0,0 +1,85 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchArtifact(t *testing.T) {
+	assert := require.New(t)
+
+	written := 0
+	client := &MockArtifactsClient{
+		DownloadArtifactByURLFunc: func(ctx context.Context, url string, writer io.Writer) (*buildkite.Response, error) {
+			for i := range 100_000 {
+				line := fmt.Sprintf("line %d\n", i)
+				if i%10 == 5 {
+					line = fmt.Sprintf("ERROR at line %d\n", i)
+				}
+				if _, err := io.WriteString(writer, line); err != nil {
+					return nil, err
+				}
+				written++
+			}
+			return &buildkite.Response{}, nil
+		},
+	}
+
+	_, handler, scopes := SearchArtifact(client, nil)
+	assert.Equal([]string{"read_artifacts"}, scopes)
+
+	limit := 2
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, SearchArtifactArgs{
+		URL:     "https://example.com/app.log",
+		Pattern: "error",
+		Context: 1,
+		Limit:   &limit,
+	})
+	assert.NoError(err)
+	assert.False(result.IsError, getTextResult(t, result).Text)
+
+	var response struct {
+		Results    []ArtifactSearchResult `json:"results"`
+		MatchCount int                    `json:"match_count"`
+	}
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &response))
+	assert.Equal(2, response.MatchCount)
+	assert.Equal(ArtifactSearchResult{
+		Match:         TerseLogEntry{C: "ERROR at line 5", RN: 5},
+		BeforeContext: []TerseLogEntry{{C: "line 4", RN: 4}},
+		AfterContext:  []TerseLogEntry{{C: "line 6", RN: 6}},
+	}, response.Results[0])
+	assert.Equal("ERROR at line 15", response.Results[1].Match.C)
+	assert.Equal([]TerseLogEntry{{C: "line 16", RN: 16}}, response.Results[1].AfterContext)
+
+	// the download stops once the limit is reached rather than reading the whole artifact
+	assert.Less(written, 100_000)
+}
+
+func TestSearchArtifactBinary(t *testing.T) {
+	assert := require.New(t)
+
+	client := &MockArtifactsClient{
+		DownloadArtifactByURLFunc: func(ctx context.Context, url string, writer io.Writer) (*buildkite.Response, error) {
+			_, err := writer.Write([]byte("PK\x03\x04\x00\x00binary\n"))
+			return &buildkite.Response{}, err
+		},
+	}
+
+	_, handler, _ := SearchArtifact(client, nil)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, SearchArtifactArgs{
+		URL:     "https://example.com/archive.zip",
+		Pattern: "binary",
+	})
+	assert.NoError(err)
+	assert.True(result.IsError)
+	assert.Contains(getTextResult(t, result).Text, "not a text file")
+}
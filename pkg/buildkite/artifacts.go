@@ -9,6 +9,7 @@ import (
 	"io"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/buildkite/buildkite-mcp-server/pkg/tokens"
 	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
@@ -18,6 +19,10 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 )
 
+// maxInlineArtifactBytes is the largest non-image artifact get_artifact returns inline, as base64
+// content beyond this consumes much of a client's context
+const maxInlineArtifactBytes = 64 * 1024
+
 type ArtifactsClient interface {
 	ListByBuild(ctx context.Context, org, pipelineSlug, buildNumber string, opts *buildkite.ArtifactListOptions) ([]buildkite.Artifact, *buildkite.Response, error)
 	DownloadArtifactByURL(ctx context.Context, url string, writer io.Writer) (*buildkite.Response, error)
@@ -55,27 +60,39 @@ func (a *BuildkiteClientAdapter) rewriteArtifactURL(inputURL string) string {
 		return inputURL
 	}
 
-	// Only rewrite if the base URL is different from the input URL's host and scheme
-	// and the base URL is non-empty
-	if baseURL.Host != parsedURL.Host || baseURL.Scheme != parsedURL.Scheme {
-		// Replace the host and scheme with the configured base URL
-		parsedURL.Scheme = baseURL.Scheme
-		parsedURL.Host = baseURL.Host
-
-		// If the base URL has a path prefix, prepend it to the existing path
-		if baseURL.Path != "" && baseURL.Path != "/" {
-			// Remove trailing slash from base path if present
-			basePath := strings.TrimSuffix(baseURL.Path, "/")
-			parsedURL.Path = basePath + parsedURL.Path
+	basePath := strings.TrimSuffix(baseURL.Path, "/")
+
+	// Leave URLs which already point below the base URL alone
+	if baseURL.Host == parsedURL.Host && baseURL.Scheme == parsedURL.Scheme &&
+		(basePath == "" || parsedURL.Path == basePath || strings.HasPrefix(parsedURL.Path, basePath+"/")) {
+		return inputURL
+	}
+
+	// Replace the host and scheme with the configured base URL
+	parsedURL.Scheme = baseURL.Scheme
+	parsedURL.Host = baseURL.Host
+
+	// If the base URL has a path prefix, prepend it to the existing path, including for URLs on the
+	// base URL's host which are missing it
+	if basePath != "" {
+		parsedURL.Path = basePath + parsedURL.Path
+		if parsedURL.RawPath != "" {
+			parsedURL.RawPath = basePath + parsedURL.RawPath
 		}
 	}
 
 	return parsedURL.String()
 }
 
-func ListArtifacts(client ArtifactsClient) (tool mcp.Tool, handler server.ToolHandlerFunc, scopes []string) {
+// ListedArtifact is an artifact with its estimated expiry
+type ListedArtifact struct {
+	buildkite.Artifact
+	ArtifactExpiry
+}
+
+func ListArtifacts(client ArtifactsClient, buildsClient BuildsClient, retention time.Duration) (tool mcp.Tool, handler server.ToolHandlerFunc, scopes []string) {
 	return mcp.NewTool("list_artifacts",
-			mcp.WithDescription("List all artifacts for a build across all jobs, including file details, paths, sizes, MIME types, and download URLs"),
+			mcp.WithDescription("List all artifacts for a build across all jobs, including file details, paths, sizes, MIME types, and download URLs. Each artifact includes an estimated expires_at under the retention period, and expiring_soon for artifacts worth downloading before they're deleted"),
 			mcp.WithString("org_slug",
 				mcp.Required(),
 			),
@@ -85,6 +102,10 @@ func ListArtifacts(client ArtifactsClient) (tool mcp.Tool, handler server.ToolHa
 			mcp.WithString("build_number",
 				mcp.Required(),
 			),
+			mcp.WithNumber("expiry_warning_days",
+				mcp.Description("Flag artifacts expiring within this many days as expiring_soon (default 14)"),
+				mcp.Min(0),
+			),
 			mcp.WithToolAnnotation(mcp.ToolAnnotation{
 				Title:        "Artifact List",
 				ReadOnlyHint: mcp.ToBoolPtr(true),
@@ -129,8 +150,26 @@ func ListArtifacts(client ArtifactsClient) (tool mcp.Tool, handler server.ToolHa
 				return mcp.NewToolResultError(err.Error()), nil
 			}
 
-			result := PaginatedResult[buildkite.Artifact]{
-				Items: artifacts,
+			// expiry is advisory, so artifacts are still listed when the build can't be read
+			var buildCreatedAt *buildkite.Timestamp
+			if buildsClient != nil && retention > 0 {
+				build, _, err := buildsClient.Get(ctx, orgSlug, pipelineSlug, buildNumber, &buildkite.BuildGetOptions{})
+				if err == nil {
+					buildCreatedAt = build.CreatedAt
+				}
+			}
+			horizon := time.Duration(request.GetInt("expiry_warning_days", defaultExpiryWarningDays)) * 24 * time.Hour
+
+			items := make([]ListedArtifact, 0, len(artifacts))
+			for _, artifact := range artifacts {
+				items = append(items, ListedArtifact{
+					Artifact:       artifact,
+					ArtifactExpiry: artifactExpiry(buildCreatedAt, retention, horizon, time.Now()),
+				})
+			}
+
+			result := PaginatedResult[ListedArtifact]{
+				Items: items,
 				Headers: map[string]string{
 					"Link": resp.Header.Get("Link"),
 				},
@@ -152,10 +191,15 @@ func ListArtifacts(client ArtifactsClient) (tool mcp.Tool, handler server.ToolHa
 
 func GetArtifact(client ArtifactsClient) (tool mcp.Tool, handler server.ToolHandlerFunc, scopes []string) {
 	return mcp.NewTool("get_artifact",
-			mcp.WithDescription("Get detailed information about a specific artifact including its metadata, file size, SHA-1 hash, and download URL"),
+			mcp.WithDescription("Get detailed information about a specific artifact including its metadata, file size, SHA-1 hash, and download URL. PNG and JPEG artifacts, such as test screenshots, are returned as image content. Other artifacts are returned base64 encoded up to 64KiB; use download_artifact_to_file for larger ones"),
 			mcp.WithString("url",
 				mcp.Required(),
 			),
+			mcp.WithNumber("max_image_dimension",
+				mcp.Description("Downscale image artifacts so their longest side is at most this many pixels, to fit large screenshots within size limits"),
+				mcp.Min(1),
+				mcp.Max(maxImageDimension),
+			),
 			mcp.WithToolAnnotation(mcp.ToolAnnotation{
 				Title:        "Get Artifact",
 				ReadOnlyHint: mcp.ToBoolPtr(true),
@@ -175,6 +219,8 @@ func GetArtifact(client ArtifactsClient) (tool mcp.Tool, handler server.ToolHand
 				return mcp.NewToolResultError(fmt.Sprintf("invalid URL format: %s", err.Error())), nil
 			}
 
+			maxDimension := min(request.GetInt("max_image_dimension", 0), maxImageDimension)
+
 			span.SetAttributes(attribute.String("url", artifactURL))
 
 			// Use a buffer to capture the artifact data instead of writing directly to stdout
@@ -184,6 +230,15 @@ func GetArtifact(client ArtifactsClient) (tool mcp.Tool, handler server.ToolHand
 				return mcp.NewToolResultError(fmt.Sprintf("response failed with error %s", err.Error())), nil
 			}
 
+			if mimeType := sniffImageType(buffer.Bytes()); mimeType != "" {
+				return imageArtifactResult(span, resp, buffer.Bytes(), mimeType, maxDimension)
+			}
+
+			if buffer.Len() > maxInlineArtifactBytes {
+				span.SetAttributes(attribute.Int("artifact_size", buffer.Len()))
+				return mcp.NewToolResultError(fmt.Sprintf("artifact is %d bytes, larger than the %d bytes returned inline; use download_artifact_to_file to download it to a file instead", buffer.Len(), maxInlineArtifactBytes)), nil
+			}
+
 			// Create a response with the artifact data encoded safely for JSON
 			result := map[string]any{
 				"status":     resp.Status,
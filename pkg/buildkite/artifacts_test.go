@@ -54,7 +54,7 @@ func TestListArtifacts(t *testing.T) {
 		},
 	}
 
-	tool, handler, _ := ListArtifacts(mockArtifactsClient)
+	tool, handler, _ := ListArtifacts(mockArtifactsClient, nil, 0)
 	assert.NotNil(tool)
 	assert.NotNil(handler)
 
@@ -115,13 +115,33 @@ func TestGetArtifact(t *testing.T) {
 	assert.Contains(textContent.Text, `"data":"VGhpcyBpcyB0ZXN0IGFydGlmYWN0IGNvbnRlbnQ="`)
 }
 
+func TestGetArtifact_TooLargeToInline(t *testing.T) {
+	assert := require.New(t)
+
+	client := &MockArtifactsClient{
+		DownloadArtifactByURLFunc: func(ctx context.Context, url string, writer io.Writer) (*buildkite.Response, error) {
+			_, err := writer.Write(bytes.Repeat([]byte("x"), maxInlineArtifactBytes+1))
+			return &buildkite.Response{Response: &http.Response{StatusCode: 200, Status: "200 OK"}}, err
+		},
+	}
+
+	_, handler, _ := GetArtifact(client)
+
+	result, err := handler(context.Background(), createMCPRequest(t, map[string]any{
+		"url": "https://example.com/artifact",
+	}))
+	assert.NoError(err)
+	assert.True(result.IsError)
+	assert.Contains(getTextResult(t, result).Text, "use download_artifact_to_file")
+}
+
 func TestListArtifacts_MissingParameters(t *testing.T) {
 	assert := require.New(t)
 
 	ctx := context.Background()
 	client := &MockArtifactsClient{}
 
-	_, handler, _ := ListArtifacts(client)
+	_, handler, _ := ListArtifacts(client, nil, 0)
 
 	// Test missing org parameter
 	req := createMCPRequest(t, map[string]any{
@@ -251,6 +271,24 @@ func TestBuildkiteClientAdapter_URLRewriting(t *testing.T) {
 			inputURL:    "https://api.buildkite.com/v2/orgs/test",
 			expectedURL: "https://proxy.example.com/buildkite/api/v2/orgs/test",
 		},
+		{
+			name:        "should add the path prefix to URLs on the base URL host which are missing it",
+			baseURL:     "https://proxy.example.com/buildkite/",
+			inputURL:    "https://proxy.example.com/v2/orgs/test",
+			expectedURL: "https://proxy.example.com/buildkite/v2/orgs/test",
+		},
+		{
+			name:        "should not rewrite URLs which already have the path prefix",
+			baseURL:     "https://proxy.example.com/buildkite/",
+			inputURL:    "https://proxy.example.com/buildkite/v2/orgs/test",
+			expectedURL: "https://proxy.example.com/buildkite/v2/orgs/test",
+		},
+		{
+			name:        "should keep the query when adding the path prefix",
+			baseURL:     "https://proxy.example.com/buildkite",
+			inputURL:    "https://api.buildkite.com/v2/orgs/test/download?token=abc",
+			expectedURL: "https://proxy.example.com/buildkite/v2/orgs/test/download?token=abc",
+		},
 		{
 			name:        "should return original URL when input URL is malformed",
 			baseURL:     "https://buildkite.proxy.com/",
@@ -0,0 +1,244 @@
+package buildkite
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	maxStatusMatrixBranches = 20
+	maxBranchPatternPages   = 5
+	branchStatusConcurrency = 4
+)
+
+type GetBranchStatusMatrixArgs struct {
+	OrgSlug      string   `json:"org_slug"`
+	PipelineSlug string   `json:"pipeline_slug"`
+	Branches     []string `json:"branches"`
+}
+
+// BranchStatus is the latest build of a branch, with the pattern it was matched by when it was
+// found through one
+type BranchStatus struct {
+	Branch     string               `json:"branch"`
+	Pattern    string               `json:"pattern,omitempty"`
+	Found      bool                 `json:"found"`
+	State      string               `json:"state,omitempty"`
+	Number     int                  `json:"number,omitempty"`
+	Commit     string               `json:"commit,omitempty"`
+	WebURL     string               `json:"web_url,omitempty"`
+	CreatedAt  *buildkite.Timestamp `json:"created_at,omitempty"`
+	FinishedAt *buildkite.Timestamp `json:"finished_at,omitempty"`
+}
+
+type BranchStatusMatrix struct {
+	// AllPassed is whether every requested branch was found and its latest build passed
+	AllPassed bool           `json:"all_passed"`
+	Branches  []BranchStatus `json:"branches"`
+	Notes     []string       `json:"notes,omitempty"`
+}
+
+func isBranchPattern(branch string) bool {
+	return strings.ContainsAny(branch, "*?[")
+}
+
+func branchStatus(branch string, build buildkite.Build) BranchStatus {
+	return BranchStatus{
+		Branch:     branch,
+		Found:      true,
+		State:      build.State,
+		Number:     build.Number,
+		Commit:     build.Commit,
+		WebURL:     build.WebURL,
+		CreatedAt:  build.CreatedAt,
+		FinishedAt: build.FinishedAt,
+	}
+}
+
+// latestBranchBuilds scans a pipeline's recent builds for the latest build of each branch matching
+// the patterns, returning whether there were more builds than could be scanned
+func latestBranchBuilds(ctx context.Context, client BuildsClient, org, pipeline string, patterns []string) (map[string][]BranchStatus, bool, error) {
+	options := &buildkite.BuildsListOptions{
+		ExcludeJobs:     true,
+		ExcludePipeline: true,
+		ListOptions:     buildkite.ListOptions{PerPage: 100},
+	}
+
+	matched := map[string][]BranchStatus{}
+	seen := map[string]bool{}
+	for range maxBranchPatternPages {
+		page, resp, err := client.ListByPipeline(ctx, org, pipeline, options)
+		if err != nil {
+			return nil, false, err
+		}
+
+		// builds are listed newest first, so the first build of each branch is its latest
+		for _, build := range page {
+			if seen[build.Branch] {
+				continue
+			}
+			seen[build.Branch] = true
+			for _, pattern := range patterns {
+				if ok, _ := path.Match(pattern, build.Branch); ok {
+					status := branchStatus(build.Branch, build)
+					status.Pattern = pattern
+					matched[pattern] = append(matched[pattern], status)
+				}
+			}
+		}
+
+		if resp == nil || resp.NextPage == 0 || len(page) == 0 {
+			return matched, false, nil
+		}
+		options.Page = resp.NextPage
+	}
+	return matched, true, nil
+}
+
+func GetBranchStatusMatrix(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[GetBranchStatusMatrixArgs], scopes []string) {
+	return mcp.NewTool("get_branch_status_matrix",
+			mcp.WithDescription("Get the state of the latest build on each of several branches of a pipeline in one compact response, such as main and the release branches when checking release readiness. Branches may be glob patterns such as release/*, which match against the branches of the pipeline's recent builds"),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithArray("branches",
+				mcp.Required(),
+				mcp.Description("Branch names or glob patterns such as release/* (max 20)"),
+				mcp.WithStringItems(),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Get Branch Status Matrix",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args GetBranchStatusMatrixArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.GetBranchStatusMatrix")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if len(args.Branches) == 0 {
+				return mcp.NewToolResultError("branches parameter is required"), nil
+			}
+			if len(args.Branches) > maxStatusMatrixBranches {
+				return mcp.NewToolResultError(fmt.Sprintf("at most %d branches can be requested at once", maxStatusMatrixBranches)), nil
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+				attribute.StringSlice("branches", args.Branches),
+			)
+
+			var branches, patterns []string
+			for _, branch := range args.Branches {
+				if isBranchPattern(branch) {
+					if _, err := path.Match(branch, ""); err != nil {
+						return mcp.NewToolResultError(fmt.Sprintf("invalid branch pattern %q: %v", branch, err)), nil
+					}
+					patterns = append(patterns, branch)
+				} else {
+					branches = append(branches, branch)
+				}
+			}
+
+			result := BranchStatusMatrix{Branches: []BranchStatus{}}
+			latest := map[string]BranchStatus{}
+			var mu sync.Mutex
+
+			g, gctx := errgroup.WithContext(ctx)
+			g.SetLimit(branchStatusConcurrency)
+			for _, branch := range branches {
+				g.Go(func() error {
+					builds, _, err := client.ListByPipeline(gctx, args.OrgSlug, args.PipelineSlug, &buildkite.BuildsListOptions{
+						Branch:          []string{branch},
+						ExcludeJobs:     true,
+						ExcludePipeline: true,
+						ListOptions:     buildkite.ListOptions{PerPage: 1},
+					})
+					if err != nil {
+						return fmt.Errorf("failed to get the latest build of %s: %w", branch, err)
+					}
+
+					status := BranchStatus{Branch: branch}
+					if len(builds) > 0 {
+						status = branchStatus(branch, builds[0])
+					}
+
+					mu.Lock()
+					defer mu.Unlock()
+					latest[branch] = status
+					return nil
+				})
+			}
+			var matched map[string][]BranchStatus
+			if len(patterns) > 0 {
+				g.Go(func() error {
+					var truncated bool
+					var err error
+					matched, truncated, err = latestBranchBuilds(gctx, client, args.OrgSlug, args.PipelineSlug, patterns)
+					if err != nil {
+						return fmt.Errorf("failed to list recent builds: %w", err)
+					}
+					if truncated {
+						mu.Lock()
+						defer mu.Unlock()
+						result.Notes = append(result.Notes, fmt.Sprintf("branch patterns were only matched against the last %d builds", maxBranchPatternPages*100))
+					}
+					return nil
+				})
+			}
+			if err := g.Wait(); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// branches are reported in the order they were requested, with the matches of a pattern
+			// sorted by name
+			for _, branch := range args.Branches {
+				if !isBranchPattern(branch) {
+					result.Branches = append(result.Branches, latest[branch])
+					continue
+				}
+				statuses := matched[branch]
+				if len(statuses) == 0 {
+					result.Branches = append(result.Branches, BranchStatus{Branch: branch, Pattern: branch})
+					continue
+				}
+				slices.SortFunc(statuses, func(a, b BranchStatus) int {
+					return cmp.Compare(a.Branch, b.Branch)
+				})
+				result.Branches = append(result.Branches, statuses...)
+			}
+
+			result.AllPassed = true
+			for _, status := range result.Branches {
+				if !status.Found || status.State != "passed" {
+					result.AllPassed = false
+				}
+			}
+
+			span.SetAttributes(
+				attribute.Int("item_count", len(result.Branches)),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_builds"}
+}
@@ -0,0 +1,91 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBranchStatusMatrix(t *testing.T) {
+	assert := require.New(t)
+
+	client := &MockBuildsClient{
+		ListByPipelineFunc: func(ctx context.Context, org string, pipeline string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error) {
+			if len(opt.Branch) == 0 {
+				// the recent builds scanned for patterns, newest first
+				return []buildkite.Build{
+					{Number: 9, Branch: "release/2.0", State: "running"},
+					{Number: 8, Branch: "main", State: "passed"},
+					{Number: 7, Branch: "release/1.0", State: "passed"},
+					{Number: 6, Branch: "release/2.0", State: "failed"},
+				}, &buildkite.Response{}, nil
+			}
+
+			assert.Equal(1, opt.PerPage)
+			switch opt.Branch[0] {
+			case "main":
+				return []buildkite.Build{{Number: 8, Branch: "main", State: "passed"}}, &buildkite.Response{}, nil
+			default:
+				return nil, &buildkite.Response{}, nil
+			}
+		},
+	}
+
+	_, handler, scopes := GetBranchStatusMatrix(client)
+	assert.Equal([]string{"read_builds"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, GetBranchStatusMatrixArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+		Branches:     []string{"main", "release/*", "hotfix/*", "gone"},
+	})
+	assert.NoError(err)
+	assert.False(result.IsError, getTextResult(t, result).Text)
+
+	var matrix BranchStatusMatrix
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &matrix))
+	assert.False(matrix.AllPassed)
+	assert.Len(matrix.Branches, 5)
+
+	assert.Equal("main", matrix.Branches[0].Branch)
+	assert.Equal("passed", matrix.Branches[0].State)
+
+	assert.Equal("release/1.0", matrix.Branches[1].Branch)
+	assert.Equal("release/*", matrix.Branches[1].Pattern)
+	assert.Equal("passed", matrix.Branches[1].State)
+	assert.Equal("release/2.0", matrix.Branches[2].Branch)
+	assert.Equal(9, matrix.Branches[2].Number)
+	assert.Equal("running", matrix.Branches[2].State)
+
+	assert.Equal("hotfix/*", matrix.Branches[3].Branch)
+	assert.False(matrix.Branches[3].Found)
+	assert.Equal("gone", matrix.Branches[4].Branch)
+	assert.False(matrix.Branches[4].Found)
+}
+
+func TestGetBranchStatusMatrixAllPassed(t *testing.T) {
+	assert := require.New(t)
+
+	client := &MockBuildsClient{
+		ListByPipelineFunc: func(ctx context.Context, org string, pipeline string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error) {
+			return []buildkite.Build{{Number: 1, Branch: opt.Branch[0], State: "passed"}}, &buildkite.Response{}, nil
+		},
+	}
+
+	_, handler, _ := GetBranchStatusMatrix(client)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, GetBranchStatusMatrixArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+		Branches:     []string{"main", "develop"},
+	})
+	assert.NoError(err)
+
+	var matrix BranchStatusMatrix
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &matrix))
+	assert.True(matrix.AllPassed)
+}
@@ -0,0 +1,100 @@
+package buildkite
+
+import (
+	"context"
+	"slices"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/redact"
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type GetBuildMetadataArgs struct {
+	OrgSlug      string   `json:"org_slug"`
+	PipelineSlug string   `json:"pipeline_slug"`
+	BuildNumber  string   `json:"build_number"`
+	Keys         []string `json:"keys,omitempty"`
+}
+
+type BuildMetadata struct {
+	BuildNumber int               `json:"build_number"`
+	MetaData    map[string]string `json:"meta_data"`
+	// MissingKeys are the keys asked for which the build has no meta-data for
+	MissingKeys []string `json:"missing_keys,omitempty"`
+	Redactions  int      `json:"redactions,omitempty"`
+}
+
+func GetBuildMetadata(client BuildsClient, redactor *redact.Redactor) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[GetBuildMetadataArgs], scopes []string) {
+	return mcp.NewTool("get_build_metadata",
+			mcp.WithDescription("Get the key/value meta-data of a build, such as values set at creation or recorded by steps with buildkite-agent meta-data set, like a version number emitted by an earlier step"),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("build_number",
+				mcp.Required(),
+			),
+			mcp.WithArray("keys",
+				mcp.Description("Only return the meta-data with these keys. Defaults to all of it"),
+				mcp.WithStringItems(),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Get Build Metadata",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args GetBuildMetadataArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.GetBuildMetadata")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if args.BuildNumber == "" {
+				return mcp.NewToolResultError("build_number parameter is required"), nil
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+				attribute.String("build_number", args.BuildNumber),
+				attribute.StringSlice("keys", args.Keys),
+			)
+
+			build, _, err := client.Get(ctx, args.OrgSlug, args.PipelineSlug, args.BuildNumber, nil)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			result := BuildMetadata{
+				BuildNumber: build.Number,
+				MetaData:    map[string]string{},
+			}
+			for key, value := range build.MetaData {
+				if len(args.Keys) > 0 && !slices.Contains(args.Keys, key) {
+					continue
+				}
+
+				redacted, count := redactor.Redact(value)
+				result.MetaData[key] = redacted
+				result.Redactions += count
+			}
+			for _, key := range args.Keys {
+				if _, ok := build.MetaData[key]; !ok {
+					result.MissingKeys = append(result.MissingKeys, key)
+				}
+			}
+
+			span.SetAttributes(
+				attribute.Int("item_count", len(result.MetaData)),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_builds"}
+}
@@ -0,0 +1,262 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/htmlmd"
+	"github.com/buildkite/buildkite-mcp-server/pkg/tokens"
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	defaultBuildReportMaxTokens = 4000
+	minBuildReportMaxTokens     = 500
+	maxBuildReportMaxTokens     = 50000
+	// annotations whose body can't keep at least this many characters within the budget are left out
+	minReportAnnotationChars = 80
+)
+
+type GetBuildFullReportArgs struct {
+	OrgSlug      string `json:"org_slug"`
+	PipelineSlug string `json:"pipeline_slug"`
+	BuildNumber  string `json:"build_number"`
+	MaxTokens    int    `json:"max_tokens,omitempty"`
+}
+
+// ReportJob is a failed job of a build report
+type ReportJob struct {
+	ID         string `json:"id"`
+	Step       string `json:"step"`
+	Label      string `json:"label"`
+	State      string `json:"state"`
+	ExitStatus *int   `json:"exit_status,omitempty"`
+	WebURL     string `json:"web_url"`
+}
+
+// ReportTestRun is the number of distinct failed tests in one of a build's Test Engine runs
+type ReportTestRun struct {
+	Suite       string `json:"suite"`
+	RunID       string `json:"run_id"`
+	FailedTests *int   `json:"failed_tests"`
+}
+
+type ReportTests struct {
+	Runs        []ReportTestRun `json:"runs"`
+	FailedTests int             `json:"failed_tests"`
+}
+
+// BuildReport is everything needed to describe a build, reduced to fit a token budget. Sections are
+// always present, with what was left out to fit the budget counted in Omitted
+type BuildReport struct {
+	Build       BuildDetail          `json:"build"`
+	FailedJobs  []ReportJob          `json:"failed_jobs"`
+	Annotations []AnnotationMarkdown `json:"annotations"`
+	Tests       ReportTests          `json:"tests"`
+	Omitted     ReportOmissions      `json:"omitted"`
+	Notes       []string             `json:"notes,omitempty"`
+}
+
+type ReportOmissions struct {
+	FailedJobs  int `json:"failed_jobs"`
+	Annotations int `json:"annotations"`
+}
+
+func estimateReportTokens(report *BuildReport) int {
+	r, err := json.Marshal(report)
+	if err != nil {
+		return 0
+	}
+	return tokens.EstimateTokens(string(r))
+}
+
+// fitBuildReport adds failed jobs and then annotations to a report until it reaches the token budget,
+// truncating the body of the annotation which crosses it
+func fitBuildReport(report *BuildReport, jobs []ReportJob, annotations []AnnotationMarkdown, maxTokens int) {
+	for i, job := range jobs {
+		report.FailedJobs = append(report.FailedJobs, job)
+		if estimateReportTokens(report) > maxTokens {
+			report.FailedJobs = report.FailedJobs[:len(report.FailedJobs)-1]
+			report.Omitted.FailedJobs = len(jobs) - i
+			break
+		}
+	}
+
+	for i, annotation := range annotations {
+		report.Annotations = append(report.Annotations, annotation)
+		if estimateReportTokens(report) <= maxTokens {
+			continue
+		}
+
+		// shrink the body of the annotation crossing the budget, leaving it out when too little of it
+		// would be left to be useful
+		last := &report.Annotations[len(report.Annotations)-1]
+		fits := false
+		for chars := len([]rune(annotation.Body)) / 2; chars >= minReportAnnotationChars; chars /= 2 {
+			last.Body, _ = truncateRunes(annotation.Body, chars)
+			last.Truncated = true
+			if estimateReportTokens(report) <= maxTokens {
+				fits = true
+				break
+			}
+		}
+		if fits {
+			report.Omitted.Annotations = len(annotations) - i - 1
+		} else {
+			report.Annotations = report.Annotations[:len(report.Annotations)-1]
+			report.Omitted.Annotations = len(annotations) - i
+		}
+		break
+	}
+}
+
+// reportTestRuns counts the distinct failed tests in each of a build's Test Engine runs
+func reportTestRuns(ctx context.Context, client TestExecutionsClient, org string, build buildkite.Build) (ReportTests, error) {
+	tests := ReportTests{Runs: []ReportTestRun{}}
+	if build.TestEngine == nil {
+		return tests, nil
+	}
+
+	var errs []error
+	for _, run := range build.TestEngine.Runs {
+		reportRun := ReportTestRun{Suite: run.Suite.Slug, RunID: run.ID}
+
+		executions, _, err := client.GetFailedExecutions(ctx, org, run.Suite.Slug, run.ID, &buildkite.FailedExecutionsOptions{})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("suite %s: %w", run.Suite.Slug, err))
+		} else {
+			failed := map[string]bool{}
+			for _, execution := range executions {
+				failed[execution.TestID] = true
+			}
+			count := len(failed)
+			reportRun.FailedTests = &count
+			tests.FailedTests += count
+		}
+
+		tests.Runs = append(tests.Runs, reportRun)
+	}
+	return tests, errors.Join(errs...)
+}
+
+func GetBuildFullReport(buildsClient BuildsClient, annotationsClient AnnotationsClient, testExecutionsClient TestExecutionsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[GetBuildFullReportArgs], scopes []string) {
+	return mcp.NewTool("get_build_full_report",
+			mcp.WithDescription("Get one report describing a build: its details and job summary, its failed jobs, its error annotations converted to plain markdown, and the number of failed tests in each of its Test Engine runs. The report is reduced to fit max_tokens, shortening or leaving out annotations and then failed jobs, with what was left out counted under omitted. Start here when asked about a build"),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("build_number",
+				mcp.Required(),
+			),
+			mcp.WithNumber("max_tokens",
+				mcp.Description("Approximate maximum size of the report in tokens (default 4000)"),
+				mcp.Min(minBuildReportMaxTokens),
+				mcp.Max(maxBuildReportMaxTokens),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Get Build Full Report",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args GetBuildFullReportArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.GetBuildFullReport")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if args.BuildNumber == "" {
+				return mcp.NewToolResultError("build_number parameter is required"), nil
+			}
+			if args.MaxTokens <= 0 {
+				args.MaxTokens = defaultBuildReportMaxTokens
+			}
+			args.MaxTokens = min(max(args.MaxTokens, minBuildReportMaxTokens), maxBuildReportMaxTokens)
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+				attribute.String("build_number", args.BuildNumber),
+				attribute.Int("max_tokens", args.MaxTokens),
+			)
+
+			build, _, err := buildsClient.Get(ctx, args.OrgSlug, args.PipelineSlug, args.BuildNumber, &buildkite.BuildGetOptions{
+				IncludeTestEngine: true,
+			})
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			report := BuildReport{
+				Build:       detailBuild(build),
+				FailedJobs:  []ReportJob{},
+				Annotations: []AnnotationMarkdown{},
+			}
+
+			report.Tests, err = reportTestRuns(ctx, testExecutionsClient, args.OrgSlug, build)
+			if err != nil {
+				report.Notes = append(report.Notes, fmt.Sprintf("failed tests couldn't be counted for some Test Engine runs: %v", err))
+			}
+
+			var jobs []ReportJob
+			for _, job := range build.Jobs {
+				if !isFailedJob(job) {
+					continue
+				}
+				jobs = append(jobs, ReportJob{
+					ID:         job.ID,
+					Step:       stepIdentity(job),
+					Label:      jobLabel(job),
+					State:      job.State,
+					ExitStatus: job.ExitStatus,
+					WebURL:     job.WebURL,
+				})
+			}
+
+			var annotations []AnnotationMarkdown
+			buildAnnotations, _, err := annotationsClient.ListByBuild(ctx, args.OrgSlug, args.PipelineSlug, args.BuildNumber, &buildkite.AnnotationListOptions{
+				ListOptions: buildkite.ListOptions{PerPage: 100},
+			})
+			if err != nil {
+				report.Notes = append(report.Notes, fmt.Sprintf("annotations couldn't be listed: %v", err))
+			}
+			for _, annotation := range buildAnnotations {
+				if annotation.Style != "error" {
+					continue
+				}
+				body, err := htmlmd.Convert(annotation.BodyHTML, htmlmd.Options{MaxTableRows: defaultAnnotationMaxTableRows})
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				body, truncated := truncateRunes(body, defaultAnnotationMaxChars)
+				annotations = append(annotations, AnnotationMarkdown{
+					ID:        annotation.ID,
+					Context:   annotation.Context,
+					Style:     annotation.Style,
+					Body:      body,
+					Truncated: truncated,
+					CreatedAt: annotation.CreatedAt,
+				})
+			}
+
+			fitBuildReport(&report, jobs, annotations, args.MaxTokens)
+
+			span.SetAttributes(
+				attribute.Int("failed_jobs", len(report.FailedJobs)),
+				attribute.Int("annotations", len(report.Annotations)),
+			)
+
+			return mcpTextResult(span, &report)
+		}, []string{"read_builds"}
+}
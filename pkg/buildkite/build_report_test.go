@@ -0,0 +1,104 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBuildFullReport(t *testing.T) {
+	assert := require.New(t)
+
+	exitStatus := 1
+	buildsClient := &MockBuildsClient{
+		GetFunc: func(ctx context.Context, org string, pipeline string, id string, opt *buildkite.BuildGetOptions) (buildkite.Build, *buildkite.Response, error) {
+			assert.True(opt.IncludeTestEngine)
+			return buildkite.Build{
+				Number: 42,
+				State:  "failed",
+				Jobs: []buildkite.Job{
+					{ID: "a", Type: "script", StepKey: "test", Label: "Test", State: "failed", ExitStatus: &exitStatus},
+					{ID: "b", Type: "script", StepKey: "lint", Label: "Lint", State: "passed"},
+				},
+				TestEngine: &buildkite.TestEngineProperty{
+					Runs: []buildkite.TestEngineRun{
+						{ID: "run-1", Suite: buildkite.TestEngineSuite{Slug: "rspec"}},
+						{ID: "run-2", Suite: buildkite.TestEngineSuite{Slug: "jest"}},
+					},
+				},
+			}, &buildkite.Response{}, nil
+		},
+	}
+	annotationsClient := &MockAnnotationsClient{
+		ListByBuildFunc: func(ctx context.Context, org, pipelineSlug, buildNumber string, opts *buildkite.AnnotationListOptions) ([]buildkite.Annotation, *buildkite.Response, error) {
+			return []buildkite.Annotation{
+				{Context: "tests", Style: "error", BodyHTML: "<p><strong>3 tests</strong> failed</p>"},
+				{Context: "coverage", Style: "info", BodyHTML: "<p>Coverage 80%</p>"},
+			}, &buildkite.Response{}, nil
+		},
+	}
+	testsClient := &MockTestExecutionsClient{
+		GetFailedExecutionsFunc: func(ctx context.Context, org, slug, runID string, opt *buildkite.FailedExecutionsOptions) ([]buildkite.FailedExecution, *buildkite.Response, error) {
+			if slug == "jest" {
+				return nil, nil, errors.New("forbidden")
+			}
+			return []buildkite.FailedExecution{{TestID: "t1"}, {TestID: "t1"}, {TestID: "t2"}}, &buildkite.Response{}, nil
+		},
+	}
+
+	_, handler, scopes := GetBuildFullReport(buildsClient, annotationsClient, testsClient)
+	assert.Equal([]string{"read_builds"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, GetBuildFullReportArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+		BuildNumber:  "42",
+	})
+	assert.NoError(err)
+	assert.False(result.IsError, getTextResult(t, result).Text)
+
+	var report BuildReport
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &report))
+	assert.Equal(42, report.Build.Number)
+	assert.Equal(2, report.Build.JobSummary.Total)
+
+	assert.Len(report.FailedJobs, 1)
+	assert.Equal("test", report.FailedJobs[0].Step)
+	assert.Equal(1, *report.FailedJobs[0].ExitStatus)
+
+	assert.Len(report.Annotations, 1)
+	assert.Equal("tests", report.Annotations[0].Context)
+	assert.Equal("**3 tests** failed", report.Annotations[0].Body)
+
+	assert.Equal(2, report.Tests.FailedTests)
+	assert.Equal(2, *report.Tests.Runs[0].FailedTests)
+	assert.Nil(report.Tests.Runs[1].FailedTests)
+	assert.Len(report.Notes, 1)
+}
+
+func TestFitBuildReport(t *testing.T) {
+	assert := require.New(t)
+
+	words := strings.Repeat("word ", 400)
+	annotations := []AnnotationMarkdown{
+		{Context: "first", Style: "error", Body: words},
+		{Context: "second", Style: "error", Body: words},
+	}
+	jobs := []ReportJob{{ID: "a", Step: "test"}}
+
+	report := BuildReport{FailedJobs: []ReportJob{}, Annotations: []AnnotationMarkdown{}}
+	fitBuildReport(&report, jobs, annotations, 500)
+
+	assert.Len(report.FailedJobs, 1)
+	assert.Len(report.Annotations, 1)
+	assert.True(report.Annotations[0].Truncated)
+	assert.Less(len(report.Annotations[0].Body), len(words))
+	assert.Equal(1, report.Omitted.Annotations)
+	assert.LessOrEqual(estimateReportTokens(&report), 500)
+}
@@ -5,8 +5,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/buildkite/buildkite-mcp-server/pkg/cache"
+	"github.com/buildkite/buildkite-mcp-server/pkg/redact"
+	"github.com/buildkite/buildkite-mcp-server/pkg/reltime"
 	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
 	"github.com/buildkite/go-buildkite/v4"
 	"github.com/cenkalti/backoff/v5"
@@ -19,13 +24,25 @@ import (
 type BuildsClient interface {
 	Get(ctx context.Context, org, pipelineSlug, buildNumber string, options *buildkite.BuildGetOptions) (buildkite.Build, *buildkite.Response, error)
 	ListByPipeline(ctx context.Context, org, pipelineSlug string, options *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error)
+	ListByOrg(ctx context.Context, org string, options *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error)
 	Create(ctx context.Context, org string, pipeline string, b buildkite.CreateBuild) (buildkite.Build, *buildkite.Response, error)
+	Cancel(ctx context.Context, org, pipelineSlug, buildNumber string) (buildkite.Build, error)
+	Rebuild(ctx context.Context, org, pipelineSlug, buildNumber string) (buildkite.Build, error)
 }
 
 // JobSummary represents a summary of jobs grouped by state, with finished jobs classified as passed/failed
 type JobSummary struct {
 	Total   int            `json:"total"`
 	ByState map[string]int `json:"by_state"`
+	// SoftFailed are the jobs which failed without failing the build, which a passed build can hide
+	SoftFailed []SoftFailedJob `json:"soft_failed,omitempty"`
+}
+
+// SoftFailedJob is a job which failed without failing its build
+type SoftFailedJob struct {
+	ID         string `json:"id"`
+	Label      string `json:"label"`
+	ExitStatus *int   `json:"exit_status,omitempty"`
 }
 
 // BuildSummary - Essential fields (~85% token reduction)
@@ -39,6 +56,8 @@ type BuildSummary struct {
 	WebURL    string               `json:"web_url"`
 	CreatedAt *buildkite.Timestamp `json:"created_at"`
 	JobsTotal int                  `json:"jobs_total"`
+	// CreatedAgo is how long ago the build was created, when displayed in a timezone
+	CreatedAgo string `json:"created_ago,omitempty"`
 }
 
 // BuildDetail - Medium detail (~60% token reduction)
@@ -49,6 +68,13 @@ type BuildDetail struct {
 	StartedAt    *buildkite.Timestamp `json:"started_at"`
 	FinishedAt   *buildkite.Timestamp `json:"finished_at"`
 	JobSummary   *JobSummary          `json:"job_summary"`
+	// TestFailuresCount is the number of distinct failed tests across the build's Test Engine runs
+	TestFailuresCount *int `json:"test_failures_count,omitempty"`
+	// StartedAgo and FinishedAgo are how long ago the build started and finished, when displayed in a timezone
+	StartedAgo  string `json:"started_ago,omitempty"`
+	FinishedAgo string `json:"finished_ago,omitempty"`
+	// AnnotationCounts is the number of the build's annotations of each style, when requested
+	AnnotationCounts map[string]int `json:"annotation_counts,omitempty"`
 	// Exclude: Jobs[], Env{}, MetaData{}, Pipeline{}, TestEngine{}
 }
 
@@ -58,6 +84,12 @@ type BuildWithSummary struct {
 	JobSummary *JobSummary `json:"job_summary"`
 }
 
+// RedactedBuild represents a full build with secrets redacted from its environment
+type RedactedBuild struct {
+	buildkite.Build
+	Redactions int `json:"redactions,omitempty"`
+}
+
 // ListBuildsArgs struct with enhanced filtering
 type ListBuildsArgs struct {
 	OrgSlug      string `json:"org_slug"`
@@ -66,17 +98,39 @@ type ListBuildsArgs struct {
 	State        string `json:"state"`        // NEW: running, passed, failed, etc.
 	Commit       string `json:"commit"`       // NEW: specific commit SHA
 	Creator      string `json:"creator"`      // NEW: filter by build creator
+	Source       string `json:"source"`       // ui, api, webhook, schedule, trigger_job
 	DetailLevel  string `json:"detail_level"` // summary, detailed, full
+	CreatedFrom  string `json:"created_from"`
+	CreatedTo    string `json:"created_to"`
+	FinishedFrom string `json:"finished_from"`
+	Timezone     string `json:"timezone"`
 	Page         int    `json:"page"`
 	PerPage      int    `json:"per_page"`
+
+	// MetaData only lists builds with all of the meta-data keys set to these values
+	MetaData map[string]string `json:"meta_data"`
+
+	// IncludeAnnotationCounts fetches the annotation counts of each build in detailed mode
+	IncludeAnnotationCounts bool `json:"include_annotation_counts"`
+	// UnsafeIncludeEnvValues returns secret-like environment variables unredacted in full mode
+	UnsafeIncludeEnvValues bool `json:"unsafe_include_env_values"`
 }
 
+// buildSources are the ways a build can be created
+var buildSources = []string{"ui", "api", "webhook", "schedule", "trigger_job"}
+
 // GetBuildArgs struct
 type GetBuildArgs struct {
 	OrgSlug      string `json:"org_slug"`
 	PipelineSlug string `json:"pipeline_slug"`
 	BuildNumber  string `json:"build_number"`
 	DetailLevel  string `json:"detail_level"` // summary, detailed, full
+	Timezone     string `json:"timezone"`
+
+	// IncludeAnnotationCounts fetches the build's annotation counts in detailed mode
+	IncludeAnnotationCounts bool `json:"include_annotation_counts"`
+	// UnsafeIncludeEnvValues returns secret-like environment variables unredacted in full mode
+	UnsafeIncludeEnvValues bool `json:"unsafe_include_env_values"`
 }
 
 // GetBuildTestEngineRunsArgs struct
@@ -118,6 +172,9 @@ func detailBuild(build buildkite.Build) BuildDetail {
 			continue
 		}
 		jobSummary.ByState[job.State]++
+		if job.SoftFailed {
+			jobSummary.SoftFailed = append(jobSummary.SoftFailed, SoftFailedJob{ID: job.ID, Label: jobLabel(job), ExitStatus: job.ExitStatus})
+		}
 	}
 
 	return BuildDetail{
@@ -130,6 +187,87 @@ func detailBuild(build buildkite.Build) BuildDetail {
 	}
 }
 
+// localizeTimestamp returns the timestamp in the timezone, with how long before now it was
+func localizeTimestamp(ts *buildkite.Timestamp, loc *time.Location, now time.Time) (*buildkite.Timestamp, string) {
+	if ts == nil {
+		return nil, ""
+	}
+	return buildkite.NewTimestamp(ts.In(loc)), relativeTime(ts.Time, now)
+}
+
+// localizeBuild displays the timestamps of a build summary in the timezone, if there is one
+func localizeBuild(summary BuildSummary, loc *time.Location, now time.Time) BuildSummary {
+	if loc != nil {
+		summary.CreatedAt, summary.CreatedAgo = localizeTimestamp(summary.CreatedAt, loc, now)
+	}
+	return summary
+}
+
+// localizeBuildDetail displays the timestamps of a build detail in the timezone, if there is one
+func localizeBuildDetail(detail BuildDetail, loc *time.Location, now time.Time) BuildDetail {
+	if loc != nil {
+		detail.BuildSummary = localizeBuild(detail.BuildSummary, loc, now)
+		detail.StartedAt, detail.StartedAgo = localizeTimestamp(detail.StartedAt, loc, now)
+		detail.FinishedAt, detail.FinishedAgo = localizeTimestamp(detail.FinishedAt, loc, now)
+	}
+	return detail
+}
+
+// countTestFailures counts the distinct failed tests across a build's Test Engine runs, returning nil
+// when the build has no runs or they can't be read, as the count is only a hint
+func countTestFailures(ctx context.Context, client TestExecutionsClient, org string, build buildkite.Build) *int {
+	if client == nil || build.TestEngine == nil || len(build.TestEngine.Runs) == 0 {
+		return nil
+	}
+
+	count := 0
+	for _, run := range build.TestEngine.Runs {
+		executions, _, err := client.GetFailedExecutions(ctx, org, run.Suite.Slug, run.ID, &buildkite.FailedExecutionsOptions{})
+		if err != nil {
+			return nil
+		}
+
+		tests := map[string]bool{}
+		for _, execution := range executions {
+			tests[execution.TestID] = true
+		}
+		count += len(tests)
+	}
+
+	return &count
+}
+
+// redactBuild redacts secrets from the build environment, including the values of variables with
+// secret-like names unless includeEnvValues is set
+func redactBuild(build buildkite.Build, redactor *redact.Redactor, includeEnvValues bool) RedactedBuild {
+	redactions := 0
+	if includeEnvValues {
+		redactions = redactor.RedactMap(build.Env)
+	} else {
+		redactions = redactor.RedactEnv(build.Env)
+	}
+
+	return RedactedBuild{
+		Build:      build,
+		Redactions: redactions,
+	}
+}
+
+// withUnsafeIncludeEnvValues adds the unsafe_include_env_values parameter to build tools
+func withUnsafeIncludeEnvValues() mcp.ToolOption {
+	return mcp.WithBoolean("unsafe_include_env_values",
+		mcp.Description("In full mode, return the values of environment variables with secret-like names such as *_TOKEN, *_KEY and *PASSWORD* instead of redacting them. Only allowed if the server permits it"),
+	)
+}
+
+// checkUnsafeIncludeEnvValues returns an error if env values were asked for but the server doesn't permit it
+func checkUnsafeIncludeEnvValues(includeEnvValues bool, redactor *redact.Redactor) error {
+	if includeEnvValues && !redactor.AllowsUnsafeEnvValues() {
+		return errors.New("unsafe_include_env_values is not permitted by this server's policy")
+	}
+	return nil
+}
+
 // createPaginatedBuildResult creates a paginated result with the appropriate converter
 func createPaginatedBuildResult[T any](builds []buildkite.Build, converter func(buildkite.Build) T, headers map[string]string) PaginatedResult[T] {
 	items := make([]T, len(builds))
@@ -143,7 +281,7 @@ func createPaginatedBuildResult[T any](builds []buildkite.Build, converter func(
 	}
 }
 
-func ListBuilds(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[ListBuildsArgs], scopes []string) {
+func ListBuilds(client BuildsClient, users UserClient, members OrganizationMembersClient, annotations AnnotationsClient, redactor *redact.Redactor) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[ListBuildsArgs], scopes []string) {
 	return mcp.NewTool("list_builds",
 			mcp.WithDescription("List all builds for a pipeline with their status, commit information, and metadata"),
 			mcp.WithString("org_slug",
@@ -162,11 +300,30 @@ func ListBuilds(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHandle
 				mcp.Description("Filter builds by specific commit SHA"),
 			),
 			mcp.WithString("creator",
-				mcp.Description("Filter builds by build creator"),
+				mcp.Description("Filter builds by build creator: 'me' for the owner of the API token, an email address of a member of the organization, or a user ID"),
+			),
+			mcp.WithString("source",
+				mcp.Description("Filter builds by how they were created, e.g. 'schedule' for scheduled nightly builds or 'webhook' for builds from pushes and pull requests. The API can't filter on source, so it's applied to each page and pages may hold fewer than per_page builds"),
+				mcp.Enum(buildSources...),
+			),
+			mcp.WithString("created_from",
+				mcp.Description("Only list builds created at or after this, either "+reltime.Formats),
+			),
+			mcp.WithString("created_to",
+				mcp.Description("Only list builds created before this, either "+reltime.Formats),
+			),
+			mcp.WithString("finished_from",
+				mcp.Description("Only list builds finished at or after this, either "+reltime.Formats),
+			),
+			mcp.WithObject("meta_data",
+				mcp.Description("Only list builds whose meta-data has all of these keys set to these string values, e.g. {\"release-version\": \"1.2.0\"}"),
 			),
 			mcp.WithString("detail_level",
 				mcp.Description("Response detail level: 'summary' (essential fields), 'detailed' (medium detail), or 'full' (complete build data). Default: 'summary'"),
 			),
+			withTimezone(),
+			withAnnotationCounts(),
+			withUnsafeIncludeEnvValues(),
 			mcp.WithNumber("page",
 				mcp.Description("Page number for pagination (min 1)"),
 			),
@@ -189,6 +346,9 @@ func ListBuilds(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHandle
 			if args.PipelineSlug == "" {
 				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
 			}
+			if args.Source != "" && !slices.Contains(buildSources, args.Source) {
+				return mcp.NewToolResultError(fmt.Sprintf("source must be one of: %s", strings.Join(buildSources, ", "))), nil
+			}
 
 			span.SetAttributes(
 				attribute.String("org_slug", args.OrgSlug),
@@ -197,11 +357,28 @@ func ListBuilds(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHandle
 				attribute.String("state", args.State),
 				attribute.String("commit", args.Commit),
 				attribute.String("creator", args.Creator),
+				attribute.String("source", args.Source),
+				attribute.String("created_from", args.CreatedFrom),
+				attribute.String("created_to", args.CreatedTo),
+				attribute.String("finished_from", args.FinishedFrom),
+				attribute.Int("meta_data_count", len(args.MetaData)),
 				attribute.String("detail_level", args.DetailLevel),
+				attribute.String("timezone", args.Timezone),
+				attribute.Bool("include_annotation_counts", args.IncludeAnnotationCounts),
+				attribute.Bool("unsafe_include_env_values", args.UnsafeIncludeEnvValues),
 				attribute.Int("page", args.Page),
 				attribute.Int("per_page", args.PerPage),
 			)
 
+			if err := checkUnsafeIncludeEnvValues(args.UnsafeIncludeEnvValues, redactor); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			loc, err := displayLocation(ctx, args.Timezone)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
 			// Set default detail level
 			detailLevel := args.DetailLevel
 			if detailLevel == "" {
@@ -250,7 +427,27 @@ func ListBuilds(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHandle
 				options.Commit = args.Commit
 			}
 			if args.Creator != "" {
-				options.Creator = args.Creator
+				if options.Creator, err = resolveCreator(ctx, users, members, args.OrgSlug, args.Creator); err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+			}
+			if args.CreatedFrom != "" {
+				if options.CreatedFrom, err = parseRelativeTime("created_from", args.CreatedFrom, time.Now()); err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+			}
+			if args.CreatedTo != "" {
+				if options.CreatedTo, err = parseRelativeTime("created_to", args.CreatedTo, time.Now()); err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+			}
+			if args.FinishedFrom != "" {
+				if options.FinishedFrom, err = parseRelativeTime("finished_from", args.FinishedFrom, time.Now()); err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+			}
+			if len(args.MetaData) > 0 {
+				options.MetaData = buildkite.MetaDataFilters{MetaData: args.MetaData}
 			}
 
 			builds, resp, err := client.ListByPipeline(ctx, args.OrgSlug, args.PipelineSlug, options)
@@ -265,21 +462,35 @@ func ListBuilds(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHandle
 				return mcp.NewToolResultError(err.Error()), nil
 			}
 
+			if args.Source != "" {
+				builds = slices.DeleteFunc(builds, func(build buildkite.Build) bool {
+					return build.Source != args.Source
+				})
+			}
+
 			headers := map[string]string{
 				"Link": resp.Header.Get("Link"),
 			}
 
+			now := time.Now()
 			var result any
 			switch detailLevel {
 			case "summary":
-				result = createPaginatedBuildResult(builds, summarizeBuild, headers)
+				result = createPaginatedBuildResult(builds, func(build buildkite.Build) BuildSummary {
+					return localizeBuild(summarizeBuild(build), loc, now)
+				}, headers)
 			case "detailed":
-				result = createPaginatedBuildResult(builds, detailBuild, headers)
-			case "full":
-				result = PaginatedResult[buildkite.Build]{
-					Items:   builds,
-					Headers: headers,
+				details := createPaginatedBuildResult(builds, func(build buildkite.Build) BuildDetail {
+					return localizeBuildDetail(detailBuild(build), loc, now)
+				}, headers)
+				if args.IncludeAnnotationCounts {
+					addAnnotationCounts(ctx, annotations, args.OrgSlug, args.PipelineSlug, details.Items)
 				}
+				result = details
+			case "full":
+				result = createPaginatedBuildResult(builds, func(build buildkite.Build) RedactedBuild {
+					return redactBuild(build, redactor, args.UnsafeIncludeEnvValues)
+				}, headers)
 			}
 
 			r, err := json.Marshal(result)
@@ -353,7 +564,7 @@ func GetBuildTestEngineRuns(client BuildsClient) (tool mcp.Tool, handler mcp.Typ
 		}, []string{"read_builds"}
 }
 
-func GetBuild(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[GetBuildArgs], scopes []string) {
+func GetBuild(client BuildsClient, testExecutionsClient TestExecutionsClient, annotations AnnotationsClient, redactor *redact.Redactor) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[GetBuildArgs], scopes []string) {
 	return mcp.NewTool("get_build",
 			mcp.WithDescription("Get detailed information about a specific build including its jobs, timing, and execution details"),
 			mcp.WithString("org_slug",
@@ -366,8 +577,11 @@ func GetBuild(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerF
 				mcp.Required(),
 			),
 			mcp.WithString("detail_level",
-				mcp.Description("Response detail level: 'summary' (essential fields), 'detailed' (medium detail), or 'full' (complete build data). Default: 'detailed'"),
+				mcp.Description("Response detail level: 'summary' (essential fields), 'detailed' (medium detail, with the number of failed tests when the build reported to Test Engine), or 'full' (complete build data). Default: 'detailed'"),
 			),
+			withTimezone(),
+			withAnnotationCounts(),
+			withUnsafeIncludeEnvValues(),
 			mcp.WithToolAnnotation(mcp.ToolAnnotation{
 				Title:        "Get Build",
 				ReadOnlyHint: mcp.ToBoolPtr(true),
@@ -393,8 +607,20 @@ func GetBuild(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerF
 				attribute.String("pipeline_slug", args.PipelineSlug),
 				attribute.String("build_number", args.BuildNumber),
 				attribute.String("detail_level", args.DetailLevel),
+				attribute.String("timezone", args.Timezone),
+				attribute.Bool("include_annotation_counts", args.IncludeAnnotationCounts),
+				attribute.Bool("unsafe_include_env_values", args.UnsafeIncludeEnvValues),
 			)
 
+			if err := checkUnsafeIncludeEnvValues(args.UnsafeIncludeEnvValues, redactor); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			loc, err := displayLocation(ctx, args.Timezone)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
 			// Set default detail level
 			detailLevel := args.DetailLevel
 			if detailLevel == "" {
@@ -421,11 +647,16 @@ func GetBuild(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerF
 			var result any
 			switch detailLevel {
 			case "summary":
-				result = summarizeBuild(build)
+				result = localizeBuild(summarizeBuild(build), loc, time.Now())
 			case "detailed":
-				result = detailBuild(build)
+				detail := localizeBuildDetail(detailBuild(build), loc, time.Now())
+				detail.TestFailuresCount = countTestFailures(ctx, testExecutionsClient, args.OrgSlug, build)
+				if args.IncludeAnnotationCounts {
+					detail.AnnotationCounts = countAnnotations(ctx, annotations, args.OrgSlug, args.PipelineSlug, args.BuildNumber)
+				}
+				result = detail
 			case "full":
-				result = build
+				result = redactBuild(build, redactor, args.UnsafeIncludeEnvValues)
 			default:
 				return mcp.NewToolResultError("detail_level must be 'summary', 'detailed', or 'full'"), nil
 			}
@@ -549,6 +780,23 @@ type WaitForBuildArgs struct {
 	PipelineSlug string `json:"pipeline_slug"`
 	BuildNumber  string `json:"build_number"`
 	WaitTimeout  int    `json:"wait_timeout"`
+	FailFast     bool   `json:"fail_fast"`
+}
+
+// FailedJob is the job whose failure ended a fail fast wait for a build
+type FailedJob struct {
+	ID         string `json:"id"`
+	Label      string `json:"label,omitempty"`
+	StepKey    string `json:"step_key,omitempty"`
+	State      string `json:"state"`
+	ExitStatus *int   `json:"exit_status,omitempty"`
+	WebURL     string `json:"web_url,omitempty"`
+}
+
+// WaitForBuildResult is the build waited for, with the failed job when the wait ended early on a failure
+type WaitForBuildResult struct {
+	BuildDetail
+	FailedJob *FailedJob `json:"failed_job,omitempty"`
 }
 
 func WaitForBuild(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[WaitForBuildArgs], scopes []string) {
@@ -567,6 +815,9 @@ func WaitForBuild(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHand
 				mcp.Description("Timeout in seconds to wait for job completion"),
 				mcp.DefaultNumber(300), // 5 minutes
 			),
+			mcp.WithBoolean("fail_fast",
+				mcp.Description("Return as soon as any job fails, with the failed job and its exit status, instead of waiting for the build to finish"),
+			),
 			mcp.WithToolAnnotation(mcp.ToolAnnotation{
 				Title:        "Wait for Build",
 				ReadOnlyHint: mcp.ToBoolPtr(true),
@@ -592,6 +843,7 @@ func WaitForBuild(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHand
 				attribute.String("pipeline_slug", args.PipelineSlug),
 				attribute.String("build_number", args.BuildNumber),
 				attribute.Int("wait_timeout", args.WaitTimeout),
+				attribute.Bool("fail_fast", args.FailFast),
 			)
 
 			build, _, err := client.Get(ctx, args.OrgSlug, args.PipelineSlug, args.BuildNumber, &buildkite.BuildGetOptions{})
@@ -614,14 +866,20 @@ func WaitForBuild(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHand
 			ticker := backoff.NewTicker(b)
 			defer ticker.Stop()
 
-			ctx, cancel := context.WithTimeout(ctx, time.Duration(args.WaitTimeout)*time.Second)
+			// each poll checks the build's state with the API rather than reading a cached response
+			ctx, cancel := context.WithTimeout(cache.Revalidate(ctx), time.Duration(args.WaitTimeout)*time.Second)
 			defer cancel()
 
 			progressToken := request.Params.Meta.ProgressToken
 			server := server.ServerFromContext(ctx)
 
+			var failedJob *FailedJob
+			if args.FailFast {
+				failedJob = firstFailedJob(build.Jobs)
+			}
+
 		WAITLOOP:
-			for {
+			for failedJob == nil {
 				select {
 				case <-ctx.Done():
 					log.Ctx(ctx).Info().Msg("Context cancelled, stopping build wait loop")
@@ -661,6 +919,8 @@ func WaitForBuild(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHand
 								"total_job_count":     total,
 								"remaining_job_count": remaining,
 								"percentage_complete": calculatePercentage(total, remaining),
+								"jobs_by_state":       detailBuild(build).JobSummary.ByState,
+								"running_steps":       runningStepLabels(build.Jobs),
 								"created_at":          getTimestampStringOrNil(build.CreatedAt),
 								"started_at":          getTimestampStringOrNil(build.StartedAt),
 							},
@@ -671,14 +931,26 @@ func WaitForBuild(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHand
 
 					}
 
+					if args.FailFast {
+						failedJob = firstFailedJob(build.Jobs)
+					}
+
 					if isTerminalState(build.State) {
 						break WAITLOOP
 					}
 				}
 			}
 
+			if failedJob != nil {
+				log.Ctx(ctx).Info().Str("build_id", build.ID).Str("job_id", failedJob.ID).Msg("Job failed, stopping build wait loop")
+				span.SetAttributes(attribute.String("failed_job_id", failedJob.ID))
+			}
+
 			// default to detailed
-			result := detailBuild(build)
+			result := WaitForBuildResult{
+				BuildDetail: detailBuild(build),
+				FailedJob:   failedJob,
+			}
 
 			return mcpTextResult(span, &result)
 		}, []string{"read_builds"}
@@ -724,6 +996,35 @@ func completedJobs(jobs []buildkite.Job) (total int, remaining int) {
 	return total, remaining
 }
 
+// firstFailedJob returns the first job to have failed, ignoring soft failures and jobs which were retried
+func firstFailedJob(jobs []buildkite.Job) *FailedJob {
+	for _, job := range jobs {
+		if !isFailedJob(job) || job.SoftFailed || job.Retried {
+			continue
+		}
+		return &FailedJob{
+			ID:         job.ID,
+			Label:      jobLabel(job),
+			StepKey:    job.StepKey,
+			State:      job.State,
+			ExitStatus: job.ExitStatus,
+			WebURL:     job.WebURL,
+		}
+	}
+	return nil
+}
+
+// runningStepLabels returns the labels of the jobs which are currently running
+func runningStepLabels(jobs []buildkite.Job) []string {
+	labels := []string{}
+	for _, job := range jobs {
+		if job.State == "running" {
+			labels = append(labels, jobLabel(job))
+		}
+	}
+	return labels
+}
+
 // safely calculate the percentage complete
 func calculatePercentage(total, remaining int) int {
 	if total == 0 {
@@ -0,0 +1,146 @@
+package buildkite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const maxBatchBuilds = 50
+
+type GetBuildsBatchArgs struct {
+	OrgSlug      string   `json:"org_slug"`
+	PipelineSlug string   `json:"pipeline_slug"`
+	BuildNumbers []string `json:"build_numbers"`
+	DetailLevel  string   `json:"detail_level"` // summary, detailed
+	Timezone     string   `json:"timezone"`
+}
+
+// BatchedBuild is a build fetched in a batch, or why it couldn't be
+type BatchedBuild struct {
+	BuildNumber string `json:"build_number"`
+	Build       any    `json:"build,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+type GetBuildsBatchResult struct {
+	Builds []BatchedBuild `json:"builds"`
+	Failed int            `json:"failed"`
+}
+
+func GetBuildsBatch(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[GetBuildsBatchArgs], scopes []string) {
+	return mcp.NewTool("get_builds_batch",
+			mcp.WithDescription(fmt.Sprintf("Get up to %d builds of a pipeline by number in one call, fetching them in parallel. Use this rather than calling get_build for each build when analyzing several builds, such as the last 20 of a pipeline. A build which can't be fetched is returned with its error rather than failing the others", maxBatchBuilds)),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithArray("build_numbers",
+				mcp.Required(),
+				mcp.Description("Numbers of the builds to get"),
+				mcp.WithStringItems(),
+			),
+			mcp.WithString("detail_level",
+				mcp.Description("Response detail level: 'summary' (essential fields) or 'detailed' (adding the source, author, timing and a summary of the jobs by state). Default: 'summary'"),
+				mcp.Enum("summary", "detailed"),
+			),
+			withTimezone(),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Get Builds Batch",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args GetBuildsBatchArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.GetBuildsBatch")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if len(args.BuildNumbers) == 0 {
+				return mcp.NewToolResultError("build_numbers parameter is required"), nil
+			}
+
+			// the same build is only fetched once
+			var numbers []string
+			for _, number := range args.BuildNumbers {
+				if number == "" {
+					return mcp.NewToolResultError("build_numbers must not contain empty build numbers"), nil
+				}
+				if !slices.Contains(numbers, number) {
+					numbers = append(numbers, number)
+				}
+			}
+			if len(numbers) > maxBatchBuilds {
+				return mcp.NewToolResultError(fmt.Sprintf("at most %d builds can be fetched at once, got %d", maxBatchBuilds, len(numbers))), nil
+			}
+
+			detailLevel := args.DetailLevel
+			if detailLevel == "" {
+				detailLevel = "summary"
+			}
+			if detailLevel != "summary" && detailLevel != "detailed" {
+				return mcp.NewToolResultError("detail_level must be 'summary' or 'detailed'"), nil
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+				attribute.StringSlice("build_numbers", numbers),
+				attribute.String("detail_level", detailLevel),
+				attribute.String("timezone", args.Timezone),
+			)
+
+			loc, err := displayLocation(ctx, args.Timezone)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			builds := fetchAll(ctx, numbers, fetchConcurrency, func(ctx context.Context, number string) (buildkite.Build, error) {
+				build, _, err := client.Get(ctx, args.OrgSlug, args.PipelineSlug, number, &buildkite.BuildGetOptions{})
+				return build, err
+			})
+
+			now := time.Now()
+			result := GetBuildsBatchResult{Builds: make([]BatchedBuild, 0, len(numbers))}
+			for i, fetched := range builds {
+				batched := BatchedBuild{BuildNumber: numbers[i]}
+				switch {
+				case fetched.Err != nil:
+					batched.Error = buildErrorMessage(fetched.Err)
+					result.Failed++
+				case detailLevel == "detailed":
+					batched.Build = localizeBuildDetail(detailBuild(fetched.Value), loc, now)
+				default:
+					batched.Build = localizeBuild(summarizeBuild(fetched.Value), loc, now)
+				}
+				result.Builds = append(result.Builds, batched)
+			}
+
+			span.SetAttributes(attribute.Int("failed", result.Failed))
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_builds"}
+}
+
+// buildErrorMessage returns the body of the API's error response when it has one, which explains
+// why better than the status alone
+func buildErrorMessage(err error) string {
+	var errResp *buildkite.ErrorResponse
+	if errors.As(err, &errResp) && errResp.RawBody != nil {
+		return string(errResp.RawBody)
+	}
+	return err.Error()
+}
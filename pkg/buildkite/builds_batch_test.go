@@ -0,0 +1,97 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchAll(t *testing.T) {
+	assert := require.New(t)
+
+	var running, most atomic.Int32
+	results := fetchAll(context.Background(), []int{1, 2, 3, 4, 5, 6}, 2, func(ctx context.Context, n int) (int, error) {
+		current := running.Add(1)
+		defer running.Add(-1)
+		for {
+			seen := most.Load()
+			if current <= seen || most.CompareAndSwap(seen, current) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		if n == 3 {
+			return 0, errors.New("not found")
+		}
+		return n * 10, nil
+	})
+
+	assert.LessOrEqual(most.Load(), int32(2))
+	assert.Len(results, 6)
+	assert.Equal(10, results[0].Value)
+	assert.EqualError(results[2].Err, "not found")
+	assert.Equal(60, results[5].Value)
+}
+
+func TestGetBuildsBatch(t *testing.T) {
+	assert := require.New(t)
+
+	var fetched atomic.Int32
+	client := &MockBuildsClient{
+		GetFunc: func(ctx context.Context, org string, pipeline string, id string, opt *buildkite.BuildGetOptions) (buildkite.Build, *buildkite.Response, error) {
+			fetched.Add(1)
+			if id == "404" {
+				return buildkite.Build{}, nil, &buildkite.ErrorResponse{RawBody: []byte(`{"message":"No build found"}`)}
+			}
+			return buildkite.Build{Number: 1, State: "passed", Jobs: []buildkite.Job{{ID: "job", State: "passed"}}}, nil, nil
+		},
+	}
+
+	tool, handler, scopes := GetBuildsBatch(client)
+	assert.Equal("get_builds_batch", tool.Name)
+	assert.Equal([]string{"read_builds"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, GetBuildsBatchArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+		BuildNumbers: []string{"1", "404", "1"},
+		DetailLevel:  "detailed",
+	})
+	assert.NoError(err)
+
+	var batch GetBuildsBatchResult
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &batch))
+	assert.Equal(int32(2), fetched.Load())
+	assert.Len(batch.Builds, 2)
+	assert.Equal(1, batch.Failed)
+	assert.Equal("1", batch.Builds[0].BuildNumber)
+	assert.Equal("passed", batch.Builds[0].Build.(map[string]any)["state"])
+	assert.NotNil(batch.Builds[0].Build.(map[string]any)["job_summary"])
+	assert.Equal("404", batch.Builds[1].BuildNumber)
+	assert.Contains(batch.Builds[1].Error, "No build found")
+	assert.Nil(batch.Builds[1].Build)
+}
+
+func TestGetBuildsBatchLimit(t *testing.T) {
+	assert := require.New(t)
+
+	numbers := make([]string, maxBatchBuilds+1)
+	for i := range numbers {
+		numbers[i] = strconv.Itoa(i + 1)
+	}
+
+	_, handler, _ := GetBuildsBatch(&MockBuildsClient{})
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, GetBuildsBatchArgs{OrgSlug: "org", PipelineSlug: "pipeline", BuildNumbers: numbers})
+	assert.NoError(err)
+	assert.True(result.IsError)
+	assert.Contains(getTextResult(t, result).Text, "at most 50 builds")
+}
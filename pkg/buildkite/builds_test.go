@@ -2,9 +2,12 @@ package buildkite
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"testing"
+	"time"
 
+	"github.com/buildkite/buildkite-mcp-server/pkg/redact"
 	"github.com/buildkite/go-buildkite/v4"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/stretchr/testify/require"
@@ -12,8 +15,11 @@ import (
 
 type MockBuildsClient struct {
 	ListByPipelineFunc func(ctx context.Context, org string, pipeline string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error)
+	ListByOrgFunc      func(ctx context.Context, org string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error)
 	GetFunc            func(ctx context.Context, org string, pipeline string, id string, opt *buildkite.BuildGetOptions) (buildkite.Build, *buildkite.Response, error)
 	CreateFunc         func(ctx context.Context, org string, pipeline string, b buildkite.CreateBuild) (buildkite.Build, *buildkite.Response, error)
+	CancelFunc         func(ctx context.Context, org string, pipeline string, build string) (buildkite.Build, error)
+	RebuildFunc        func(ctx context.Context, org string, pipeline string, build string) (buildkite.Build, error)
 }
 
 func (m *MockBuildsClient) Get(ctx context.Context, org string, pipeline string, id string, opt *buildkite.BuildGetOptions) (buildkite.Build, *buildkite.Response, error) {
@@ -30,6 +36,13 @@ func (m *MockBuildsClient) ListByPipeline(ctx context.Context, org string, pipel
 	return nil, nil, nil
 }
 
+func (m *MockBuildsClient) ListByOrg(ctx context.Context, org string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error) {
+	if m.ListByOrgFunc != nil {
+		return m.ListByOrgFunc(ctx, org, opt)
+	}
+	return nil, nil, nil
+}
+
 func (m *MockBuildsClient) Create(ctx context.Context, org string, pipeline string, b buildkite.CreateBuild) (buildkite.Build, *buildkite.Response, error) {
 	if m.CreateFunc != nil {
 		return m.CreateFunc(ctx, org, pipeline, b)
@@ -37,6 +50,20 @@ func (m *MockBuildsClient) Create(ctx context.Context, org string, pipeline stri
 	return buildkite.Build{}, nil, nil
 }
 
+func (m *MockBuildsClient) Cancel(ctx context.Context, org string, pipeline string, build string) (buildkite.Build, error) {
+	if m.CancelFunc != nil {
+		return m.CancelFunc(ctx, org, pipeline, build)
+	}
+	return buildkite.Build{}, nil
+}
+
+func (m *MockBuildsClient) Rebuild(ctx context.Context, org string, pipeline string, build string) (buildkite.Build, error) {
+	if m.RebuildFunc != nil {
+		return m.RebuildFunc(ctx, org, pipeline, build)
+	}
+	return buildkite.Build{}, nil
+}
+
 var _ BuildsClient = (*MockBuildsClient)(nil)
 
 func TestWaitForBuildCompletes(t *testing.T) {
@@ -153,6 +180,70 @@ func TestWaitForBuildTimeout(t *testing.T) {
 	assert.Contains(textContent.Text, `"state":"running"`)
 }
 
+func TestWaitForBuildFailFast(t *testing.T) {
+	assert := require.New(t)
+
+	ctx := context.Background()
+
+	softExitStatus, exitStatus := 1, 2
+	callCount := 0
+	client := &MockBuildsClient{
+		GetFunc: func(ctx context.Context, org string, pipeline string, id string, opt *buildkite.BuildGetOptions) (buildkite.Build, *buildkite.Response, error) {
+			callCount++
+			return buildkite.Build{
+					ID:     "123",
+					Number: 1,
+					State:  "failing",
+					Jobs: []buildkite.Job{
+						{ID: "job-1", Type: "script", Label: "lint", State: "failed", ExitStatus: &softExitStatus, SoftFailed: true},
+						{ID: "job-2", Type: "script", Label: "test", StepKey: "test", State: "failed", ExitStatus: &exitStatus},
+						{ID: "job-3", Type: "script", Label: "build", State: "running"},
+					},
+				}, &buildkite.Response{
+					Response: &http.Response{
+						StatusCode: 200,
+					},
+				}, nil
+		},
+	}
+
+	_, typedHandler, _ := WaitForBuild(client)
+	handler := mcp.NewTypedToolHandler(typedHandler)
+
+	request := createMCPRequest(t, map[string]any{
+		"org_slug":      "org",
+		"pipeline_slug": "pipeline",
+		"build_number":  "1",
+		"wait_timeout":  10,
+		"fail_fast":     true,
+	})
+	request.Params.Meta = &mcp.Meta{}
+	result, err := handler(ctx, request)
+	assert.NoError(err)
+
+	var waited WaitForBuildResult
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &waited))
+
+	// the build is already failing, so there is no waiting
+	assert.Equal(1, callCount)
+	assert.Equal("failing", waited.State)
+	assert.NotNil(waited.FailedJob)
+	assert.Equal("job-2", waited.FailedJob.ID)
+	assert.Equal("test", waited.FailedJob.Label)
+	assert.Equal(2, *waited.FailedJob.ExitStatus)
+}
+
+func TestRunningStepLabels(t *testing.T) {
+	assert := require.New(t)
+
+	labels := runningStepLabels([]buildkite.Job{
+		{Label: "lint", State: "passed"},
+		{Label: "test", State: "running"},
+		{Name: "build", State: "running"},
+	})
+	assert.Equal([]string{"test", "build"}, labels)
+}
+
 func TestWaitForBuildMissingParameters(t *testing.T) {
 	assert := require.New(t)
 
@@ -215,7 +306,7 @@ func TestGetBuildDefault(t *testing.T) {
 		},
 	}
 
-	tool, typedHandler, _ := GetBuild(client)
+	tool, typedHandler, _ := GetBuild(client, nil, nil, nil)
 	handler := mcp.NewTypedToolHandler(typedHandler)
 	assert.NotNil(tool)
 	assert.NotNil(handler)
@@ -264,7 +355,7 @@ func TestGetBuildWithJobSummary(t *testing.T) {
 		},
 	}
 
-	tool, typedHandler, _ := GetBuild(client)
+	tool, typedHandler, _ := GetBuild(client, nil, nil, nil)
 	handler := mcp.NewTypedToolHandler(typedHandler)
 	assert.NotNil(tool)
 	assert.NotNil(handler)
@@ -308,7 +399,7 @@ func TestListBuilds(t *testing.T) {
 		},
 	}
 
-	tool, typedHandler, _ := ListBuilds(client)
+	tool, typedHandler, _ := ListBuilds(client, nil, nil, nil, nil)
 	handler := mcp.NewTypedToolHandler(typedHandler)
 	assert.NotNil(tool)
 	assert.NotNil(handler)
@@ -360,7 +451,7 @@ func TestListBuildsWithCustomPagination(t *testing.T) {
 		},
 	}
 
-	tool, typedHandler, _ := ListBuilds(client)
+	tool, typedHandler, _ := ListBuilds(client, nil, nil, nil, nil)
 	handler := mcp.NewTypedToolHandler(typedHandler)
 	assert.NotNil(tool)
 	assert.NotNil(handler)
@@ -405,7 +496,7 @@ func TestListBuildsWithBranchFilter(t *testing.T) {
 		},
 	}
 
-	tool, typedHandler, _ := ListBuilds(client)
+	tool, typedHandler, _ := ListBuilds(client, nil, nil, nil, nil)
 	handler := mcp.NewTypedToolHandler(typedHandler)
 	assert.NotNil(tool)
 	assert.NotNil(handler)
@@ -426,6 +517,132 @@ func TestListBuildsWithBranchFilter(t *testing.T) {
 	assert.Equal(30, capturedOptions.PerPage) // New default
 }
 
+func TestListBuildsWithCreatedRange(t *testing.T) {
+	assert := require.New(t)
+
+	ctx := context.Background()
+	var capturedOptions *buildkite.BuildsListOptions
+	client := &MockBuildsClient{
+		ListByPipelineFunc: func(ctx context.Context, org string, pipeline string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error) {
+			capturedOptions = opt
+			return []buildkite.Build{}, &buildkite.Response{
+				Response: &http.Response{
+					StatusCode: 200,
+				},
+			}, nil
+		},
+	}
+
+	_, typedHandler, _ := ListBuilds(client, nil, nil, nil, nil)
+	handler := mcp.NewTypedToolHandler(typedHandler)
+
+	request := createMCPRequest(t, map[string]any{
+		"org_slug":      "org",
+		"pipeline_slug": "pipeline",
+		"created_from":  "-7d",
+		"created_to":    "2025-06-01",
+	})
+	_, err := handler(ctx, request)
+	assert.NoError(err)
+
+	assert.WithinDuration(time.Now().AddDate(0, 0, -7), capturedOptions.CreatedFrom, time.Minute)
+	assert.Equal(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), capturedOptions.CreatedTo)
+
+	request = createMCPRequest(t, map[string]any{
+		"org_slug":      "org",
+		"pipeline_slug": "pipeline",
+		"created_from":  "last week",
+	})
+	result, err := handler(ctx, request)
+	assert.NoError(err)
+	assert.True(result.IsError)
+	assert.Contains(getTextResult(t, result).Text, `invalid created_from "last week"`)
+}
+
+func TestListBuildsWithFinishedFromAndMetaData(t *testing.T) {
+	assert := require.New(t)
+
+	ctx := context.Background()
+	var capturedOptions *buildkite.BuildsListOptions
+	client := &MockBuildsClient{
+		ListByPipelineFunc: func(ctx context.Context, org string, pipeline string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error) {
+			capturedOptions = opt
+			return []buildkite.Build{}, &buildkite.Response{
+				Response: &http.Response{
+					StatusCode: 200,
+				},
+			}, nil
+		},
+	}
+
+	_, typedHandler, _ := ListBuilds(client, nil, nil, nil, nil)
+	handler := mcp.NewTypedToolHandler(typedHandler)
+
+	request := createMCPRequest(t, map[string]any{
+		"org_slug":      "org",
+		"pipeline_slug": "pipeline",
+		"finished_from": "-24h",
+		"meta_data":     map[string]any{"release-version": "1.2.0"},
+	})
+	_, err := handler(ctx, request)
+	assert.NoError(err)
+
+	assert.WithinDuration(time.Now().Add(-24*time.Hour), capturedOptions.FinishedFrom, time.Minute)
+	assert.Equal(map[string]string{"release-version": "1.2.0"}, capturedOptions.MetaData.MetaData)
+
+	request = createMCPRequest(t, map[string]any{
+		"org_slug":      "org",
+		"pipeline_slug": "pipeline",
+		"finished_from": "yesterday-ish",
+	})
+	result, err := handler(ctx, request)
+	assert.NoError(err)
+	assert.True(result.IsError)
+	assert.Contains(getTextResult(t, result).Text, `invalid finished_from "yesterday-ish"`)
+}
+
+func TestListBuildsWithSourceFilter(t *testing.T) {
+	assert := require.New(t)
+
+	ctx := context.Background()
+	client := &MockBuildsClient{
+		ListByPipelineFunc: func(ctx context.Context, org string, pipeline string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error) {
+			return []buildkite.Build{
+					{Number: 3, Source: "schedule"},
+					{Number: 2, Source: "webhook"},
+					{Number: 1, Source: "schedule"},
+				}, &buildkite.Response{
+					Response: &http.Response{
+						StatusCode: 200,
+					},
+				}, nil
+		},
+	}
+
+	_, handler, _ := ListBuilds(client, nil, nil, nil, nil)
+
+	result, err := handler(ctx, mcp.CallToolRequest{}, ListBuildsArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+		Source:       "schedule",
+	})
+	assert.NoError(err)
+
+	var builds PaginatedResult[BuildSummary]
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &builds))
+	assert.Len(builds.Items, 2)
+	assert.Equal(3, builds.Items[0].Number)
+	assert.Equal(1, builds.Items[1].Number)
+
+	result, err = handler(ctx, mcp.CallToolRequest{}, ListBuildsArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+		Source:       "cron",
+	})
+	assert.NoError(err)
+	assert.True(result.IsError)
+}
+
 func TestGetBuildTestEngineRuns(t *testing.T) {
 	assert := require.New(t)
 
@@ -645,3 +862,101 @@ func TestCalculatePercentage(t *testing.T) {
 	result = calculatePercentage(1, 0)
 	assert.Equal(100, result) // (1-0)*100/1 = 100%
 }
+
+func TestGetBuildTestFailuresCount(t *testing.T) {
+	assert := require.New(t)
+
+	ctx := context.Background()
+	client := &MockBuildsClient{
+		GetFunc: func(ctx context.Context, org string, pipeline string, id string, opt *buildkite.BuildGetOptions) (buildkite.Build, *buildkite.Response, error) {
+			return buildkite.Build{
+				Number: 1,
+				State:  "failed",
+				TestEngine: &buildkite.TestEngineProperty{Runs: []buildkite.TestEngineRun{
+					{ID: "run-1", Suite: buildkite.TestEngineSuite{Slug: "unit"}},
+					{ID: "run-2", Suite: buildkite.TestEngineSuite{Slug: "e2e"}},
+				}},
+			}, &buildkite.Response{}, nil
+		},
+	}
+	testExecutionsClient := &MockTestExecutionsClient{
+		GetFailedExecutionsFunc: func(ctx context.Context, org, slug, runID string, opt *buildkite.FailedExecutionsOptions) ([]buildkite.FailedExecution, *buildkite.Response, error) {
+			if slug == "unit" {
+				// a retried test reports each failed attempt
+				return []buildkite.FailedExecution{{TestID: "t1"}, {TestID: "t1"}, {TestID: "t2"}}, &buildkite.Response{}, nil
+			}
+			return []buildkite.FailedExecution{{TestID: "t3"}}, &buildkite.Response{}, nil
+		},
+	}
+
+	_, typedHandler, _ := GetBuild(client, testExecutionsClient, nil, nil)
+	handler := mcp.NewTypedToolHandler(typedHandler)
+
+	result, err := handler(ctx, createMCPRequest(t, map[string]any{
+		"org_slug":      "org",
+		"pipeline_slug": "pipeline",
+		"build_number":  "1",
+	}))
+	assert.NoError(err)
+	assert.Contains(getTextResult(t, result).Text, `"test_failures_count":3`)
+
+	// builds without Test Engine runs don't report a count
+	assert.Nil(countTestFailures(ctx, testExecutionsClient, "org", buildkite.Build{}))
+}
+
+func TestDetailBuildSoftFailedJobs(t *testing.T) {
+	assert := require.New(t)
+
+	exitStatus := 1
+	detail := detailBuild(buildkite.Build{
+		State: "passed",
+		Jobs: []buildkite.Job{
+			{ID: "job-1", Label: "test", State: "passed"},
+			{ID: "job-2", Label: "audit", State: "failed", SoftFailed: true, ExitStatus: &exitStatus},
+		},
+	})
+
+	assert.Equal([]SoftFailedJob{{ID: "job-2", Label: "audit", ExitStatus: &exitStatus}}, detail.JobSummary.SoftFailed)
+}
+
+func TestGetBuildFullRedactsSecretEnv(t *testing.T) {
+	assert := require.New(t)
+
+	client := &MockBuildsClient{
+		GetFunc: func(ctx context.Context, org string, pipeline string, id string, opt *buildkite.BuildGetOptions) (buildkite.Build, *buildkite.Response, error) {
+			return buildkite.Build{
+				Number: 1,
+				Env:    map[string]any{"NPM_TOKEN": "abc123", "BRANCH": "main"},
+			}, &buildkite.Response{}, nil
+		},
+	}
+
+	getBuild := func(redactor *redact.Redactor, unsafe bool) *mcp.CallToolResult {
+		_, handler, _ := GetBuild(client, nil, nil, redactor)
+		result, err := handler(context.Background(), mcp.CallToolRequest{}, GetBuildArgs{
+			OrgSlug:                "org",
+			PipelineSlug:           "pipeline",
+			BuildNumber:            "1",
+			DetailLevel:            "full",
+			UnsafeIncludeEnvValues: unsafe,
+		})
+		assert.NoError(err)
+		return result
+	}
+
+	redactor, err := redact.New(nil)
+	assert.NoError(err)
+
+	text := getTextResult(t, getBuild(redactor, false)).Text
+	assert.Contains(text, `"NPM_TOKEN":"[REDACTED]"`)
+	assert.Contains(text, `"BRANCH":"main"`)
+	assert.Contains(text, `"redactions":1`)
+
+	result := getBuild(redactor, true)
+	assert.True(result.IsError)
+	assert.Contains(getTextResult(t, result).Text, "not permitted")
+
+	redactor, err = redact.New(nil, redact.WithUnsafeEnvValues(true))
+	assert.NoError(err)
+	assert.Contains(getTextResult(t, getBuild(redactor, true)).Text, `"NPM_TOKEN":"abc123"`)
+}
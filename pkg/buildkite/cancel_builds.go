@@ -0,0 +1,211 @@
+package buildkite
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/reltime"
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	maxCancelBuildPages     = 10
+	cancelBuildsConcurrency = 4
+)
+
+type CancelBuildsArgs struct {
+	OrgSlug      string `json:"org_slug"`
+	PipelineSlug string `json:"pipeline_slug"`
+	Branch       string `json:"branch"`
+	OlderThan    string `json:"older_than,omitempty"`
+	DryRun       bool   `json:"dry_run,omitempty"`
+}
+
+// CancelledBuild is the outcome of cancelling one build, or of matching it in a dry run
+type CancelledBuild struct {
+	Number    int                  `json:"number"`
+	State     string               `json:"state"`
+	Commit    string               `json:"commit"`
+	WebURL    string               `json:"web_url"`
+	CreatedAt *buildkite.Timestamp `json:"created_at"`
+	Cancelled bool                 `json:"cancelled"`
+	Error     string               `json:"error,omitempty"`
+}
+
+type CancelBuildsResult struct {
+	DryRun    bool             `json:"dry_run"`
+	Branch    string           `json:"branch"`
+	OlderThan *time.Time       `json:"older_than,omitempty"`
+	Matched   int              `json:"matched"`
+	Cancelled int              `json:"cancelled"`
+	Failed    int              `json:"failed"`
+	Builds    []CancelledBuild `json:"builds"`
+	Notes     []string         `json:"notes,omitempty"`
+}
+
+// parseRelativeTime resolves the value of a time filter parameter, which can be relative to now
+func parseRelativeTime(param, value string, now time.Time) (time.Time, error) {
+	t, err := reltime.Parse(value, now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q, expected %s", param, value, reltime.Formats)
+	}
+	return t, nil
+}
+
+// unfinishedBuildStates are the states of builds which can still be cancelled. Builds which are
+// failing still have jobs running, and blocked builds are waiting on a block step.
+var unfinishedBuildStates = []string{"creating", "scheduled", "running", "failing", "blocked"}
+
+// listUnfinishedBuilds returns the unfinished builds on a branch created before a cutoff,
+// when there is one, and whether there were more than could be listed
+func listUnfinishedBuilds(ctx context.Context, client BuildsClient, org, pipeline, branch string, createdBefore *time.Time) ([]buildkite.Build, bool, error) {
+	options := &buildkite.BuildsListOptions{
+		Branch:          []string{branch},
+		State:           unfinishedBuildStates,
+		ExcludeJobs:     true,
+		ExcludePipeline: true,
+		ListOptions:     buildkite.ListOptions{PerPage: 100},
+	}
+	if createdBefore != nil {
+		options.CreatedTo = *createdBefore
+	}
+
+	var builds []buildkite.Build
+	for range maxCancelBuildPages {
+		page, resp, err := client.ListByPipeline(ctx, org, pipeline, options)
+		if err != nil {
+			return nil, false, err
+		}
+
+		builds = append(builds, page...)
+
+		if resp == nil || resp.NextPage == 0 || len(page) == 0 {
+			return builds, false, nil
+		}
+		options.Page = resp.NextPage
+	}
+	return builds, true, nil
+}
+
+func CancelBuilds(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[CancelBuildsArgs], scopes []string) {
+	return mcp.NewTool("cancel_builds",
+			mcp.WithDescription("Cancel every unfinished build of a pipeline, whether creating, scheduled, running, failing or blocked, on a branch, optionally only those created before a cutoff, such as when a pull request has been superseded. Returns the outcome for each build. Use dry_run to list the builds which would be cancelled without cancelling them"),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("branch",
+				mcp.Required(),
+				mcp.Description("The branch whose builds are cancelled"),
+			),
+			mcp.WithString("older_than",
+				mcp.Description("Only cancel builds created before this, either "+reltime.Formats),
+			),
+			mcp.WithBoolean("dry_run",
+				mcp.Description("List the builds which would be cancelled without cancelling them"),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Cancel Builds",
+				ReadOnlyHint: mcp.ToBoolPtr(false),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args CancelBuildsArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.CancelBuilds")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if args.Branch == "" {
+				return mcp.NewToolResultError("branch parameter is required"), nil
+			}
+
+			result := CancelBuildsResult{
+				DryRun: args.DryRun,
+				Branch: args.Branch,
+				Builds: []CancelledBuild{},
+			}
+			if args.OlderThan != "" {
+				cutoff, err := parseRelativeTime("older_than", args.OlderThan, time.Now())
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				result.OlderThan = &cutoff
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+				attribute.String("branch", args.Branch),
+				attribute.Bool("dry_run", args.DryRun),
+			)
+
+			builds, truncated, err := listUnfinishedBuilds(ctx, client, args.OrgSlug, args.PipelineSlug, args.Branch, result.OlderThan)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if truncated {
+				result.Notes = append(result.Notes, fmt.Sprintf("only the first %d unfinished builds were considered, run again to cancel the rest", len(builds)))
+			}
+
+			for _, build := range builds {
+				result.Builds = append(result.Builds, CancelledBuild{
+					Number:    build.Number,
+					State:     build.State,
+					Commit:    build.Commit,
+					WebURL:    build.WebURL,
+					CreatedAt: build.CreatedAt,
+				})
+			}
+			result.Matched = len(result.Builds)
+
+			if !args.DryRun {
+				// each build's outcome is recorded in place, so one failure doesn't stop the others
+				g, gctx := errgroup.WithContext(ctx)
+				g.SetLimit(cancelBuildsConcurrency)
+				for i := range result.Builds {
+					g.Go(func() error {
+						build := &result.Builds[i]
+						cancelled, err := client.Cancel(gctx, args.OrgSlug, args.PipelineSlug, strconv.Itoa(build.Number))
+						if err != nil {
+							build.Error = err.Error()
+							return nil
+						}
+						build.Cancelled = true
+						if cancelled.State != "" {
+							build.State = cancelled.State
+						}
+						return nil
+					})
+				}
+				_ = g.Wait()
+
+				for _, build := range result.Builds {
+					if build.Cancelled {
+						result.Cancelled++
+					} else {
+						result.Failed++
+					}
+				}
+			}
+
+			span.SetAttributes(
+				attribute.Int("item_count", result.Matched),
+				attribute.Int("cancelled", result.Cancelled),
+				attribute.Int("failed", result.Failed),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_builds", "write_builds"}
+}
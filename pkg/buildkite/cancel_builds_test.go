@@ -0,0 +1,124 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCancelBuilds(t *testing.T) {
+	assert := require.New(t)
+
+	var mu sync.Mutex
+	var cancelled []string
+	client := &MockBuildsClient{
+		ListByPipelineFunc: func(ctx context.Context, org string, pipeline string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error) {
+			assert.Equal([]string{"feature"}, opt.Branch)
+			assert.Equal([]string{"creating", "scheduled", "running", "failing", "blocked"}, opt.State)
+			assert.Equal(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), opt.CreatedTo)
+			return []buildkite.Build{
+				{Number: 7, State: "running"},
+				{Number: 8, State: "scheduled"},
+				{Number: 9, State: "failing"},
+				{Number: 10, State: "blocked"},
+			}, &buildkite.Response{}, nil
+		},
+		CancelFunc: func(ctx context.Context, org string, pipeline string, build string) (buildkite.Build, error) {
+			if build == "8" {
+				return buildkite.Build{}, errors.New("forbidden")
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			cancelled = append(cancelled, build)
+			number, _ := strconv.Atoi(build)
+			return buildkite.Build{Number: number, State: "canceling"}, nil
+		},
+	}
+
+	_, handler, scopes := CancelBuilds(client)
+	assert.Equal([]string{"read_builds", "write_builds"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, CancelBuildsArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+		Branch:       "feature",
+		OlderThan:    "2025-06-01",
+	})
+	assert.NoError(err)
+	assert.False(result.IsError, getTextResult(t, result).Text)
+
+	var response CancelBuildsResult
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &response))
+	assert.Equal(4, response.Matched)
+	assert.Equal(3, response.Cancelled)
+	assert.Equal(1, response.Failed)
+	assert.ElementsMatch([]string{"7", "9", "10"}, cancelled)
+	assert.Equal("canceling", response.Builds[0].State)
+	assert.True(response.Builds[0].Cancelled)
+	assert.Equal("forbidden", response.Builds[1].Error)
+}
+
+func TestCancelBuildsDryRun(t *testing.T) {
+	assert := require.New(t)
+
+	client := &MockBuildsClient{
+		ListByPipelineFunc: func(ctx context.Context, org string, pipeline string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error) {
+			assert.True(opt.CreatedTo.IsZero())
+			return []buildkite.Build{{Number: 7, State: "running"}}, &buildkite.Response{}, nil
+		},
+		CancelFunc: func(ctx context.Context, org string, pipeline string, build string) (buildkite.Build, error) {
+			t.Fatal("builds should not be cancelled in a dry run")
+			return buildkite.Build{}, nil
+		},
+	}
+
+	_, handler, _ := CancelBuilds(client)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, CancelBuildsArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+		Branch:       "feature",
+		DryRun:       true,
+	})
+	assert.NoError(err)
+
+	var response CancelBuildsResult
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &response))
+	assert.True(response.DryRun)
+	assert.Equal(1, response.Matched)
+	assert.Equal(0, response.Cancelled)
+	assert.False(response.Builds[0].Cancelled)
+}
+
+func TestParseRelativeTime(t *testing.T) {
+	assert := require.New(t)
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	cutoff, err := parseRelativeTime("older_than", "2h", now)
+	assert.NoError(err)
+	assert.Equal(time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC), cutoff)
+
+	cutoff, err = parseRelativeTime("older_than", "2025-05-01T00:00:00Z", now)
+	assert.NoError(err)
+	assert.Equal(time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC), cutoff)
+
+	cutoff, err = parseRelativeTime("older_than", "-1h", now)
+	assert.NoError(err)
+	assert.Equal(time.Date(2025, 6, 1, 11, 0, 0, 0, time.UTC), cutoff)
+
+	cutoff, err = parseRelativeTime("older_than", "yesterday", now)
+	assert.NoError(err)
+	assert.Equal(time.Date(2025, 5, 31, 0, 0, 0, 0, time.UTC), cutoff)
+
+	_, err = parseRelativeTime("older_than", "a while ago", now)
+	assert.ErrorContains(err, `invalid older_than "a while ago"`)
+}
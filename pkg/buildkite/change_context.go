@@ -0,0 +1,200 @@
+package buildkite
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const defaultChangedFilesMetaDataKey = "changed_files"
+
+var (
+	coAuthorTrailer   = regexp.MustCompile(`(?mi)^co-authored-by:\s*(.+?)\s*$`)
+	githubRepository  = regexp.MustCompile(`github\.com[:/]([^/]+/[^/]+?)(?:\.git)?/?$`)
+	changedFilesSplit = regexp.MustCompile(`[\n,]+`)
+)
+
+type GetBuildChangeContextArgs struct {
+	OrgSlug             string `json:"org_slug"`
+	PipelineSlug        string `json:"pipeline_slug"`
+	BuildNumber         string `json:"build_number"`
+	ChangedFilesMetaKey string `json:"changed_files_meta_data_key,omitempty"`
+}
+
+// CommitPerson is a person attached to a commit
+type CommitPerson struct {
+	Name     string `json:"name,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+// CommitRange describes the commits built since the previous build on the same branch
+type CommitRange struct {
+	PreviousBuildNumber int    `json:"previous_build_number"`
+	PreviousBuildState  string `json:"previous_build_state"`
+	From                string `json:"from"`
+	To                  string `json:"to"`
+	CompareURL          string `json:"compare_url,omitempty"`
+}
+
+// BuildChangeContext gives code level context for the changes a build ran against
+type BuildChangeContext struct {
+	BuildNumber  int                    `json:"build_number"`
+	State        string                 `json:"state"`
+	Branch       string                 `json:"branch"`
+	Commit       string                 `json:"commit"`
+	Subject      string                 `json:"subject"`
+	Author       CommitPerson           `json:"author"`
+	CoAuthors    []string               `json:"co_authors,omitempty"`
+	Creator      *CommitPerson          `json:"creator,omitempty"`
+	PullRequest  *buildkite.PullRequest `json:"pull_request,omitempty"`
+	CommitRange  *CommitRange           `json:"commit_range,omitempty"`
+	ChangedFiles []string               `json:"changed_files,omitempty"`
+}
+
+func GetBuildChangeContext(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[GetBuildChangeContextArgs], scopes []string) {
+	return mcp.NewTool("get_build_change_context",
+			mcp.WithDescription("Get the code changes behind a build: the commit subject, author, co-authors and build creator, the commit range since the previous build on the same branch, and any list of changed files recorded in build meta-data. Use this for code-level context when investigating a failure."),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("build_number",
+				mcp.Required(),
+			),
+			mcp.WithString("changed_files_meta_data_key",
+				mcp.Description("Build meta-data key holding a newline or comma separated list of changed files (default changed_files)"),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Get Build Change Context",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args GetBuildChangeContextArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.GetBuildChangeContext")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if args.BuildNumber == "" {
+				return mcp.NewToolResultError("build_number parameter is required"), nil
+			}
+			if args.ChangedFilesMetaKey == "" {
+				args.ChangedFilesMetaKey = defaultChangedFilesMetaDataKey
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+				attribute.String("build_number", args.BuildNumber),
+			)
+
+			build, _, err := client.Get(ctx, args.OrgSlug, args.PipelineSlug, args.BuildNumber, &buildkite.BuildGetOptions{})
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			result := buildChangeContext(build, args.ChangedFilesMetaKey)
+
+			previous, err := previousBranchBuild(ctx, client, args.OrgSlug, args.PipelineSlug, build)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if previous != nil {
+				result.CommitRange = &CommitRange{
+					PreviousBuildNumber: previous.Number,
+					PreviousBuildState:  previous.State,
+					From:                previous.Commit,
+					To:                  build.Commit,
+				}
+				if build.Pipeline != nil {
+					result.CommitRange.CompareURL = compareURL(build.Pipeline.Repository, previous.Commit, build.Commit)
+				}
+			}
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_builds"}
+}
+
+// buildChangeContext extracts the commit details recorded on the build itself
+func buildChangeContext(build buildkite.Build, changedFilesKey string) BuildChangeContext {
+	subject, _, _ := strings.Cut(build.Message, "\n")
+
+	result := BuildChangeContext{
+		BuildNumber: build.Number,
+		State:       build.State,
+		Branch:      build.Branch,
+		Commit:      build.Commit,
+		Subject:     strings.TrimSpace(subject),
+		Author: CommitPerson{
+			Name:     build.Author.Name,
+			Email:    build.Author.Email,
+			Username: build.Author.Username,
+		},
+		PullRequest: build.PullRequest,
+	}
+
+	for _, match := range coAuthorTrailer.FindAllStringSubmatch(build.Message, -1) {
+		result.CoAuthors = append(result.CoAuthors, match[1])
+	}
+
+	if build.Creator.Email != "" && build.Creator.Email != build.Author.Email {
+		result.Creator = &CommitPerson{Name: build.Creator.Name, Email: build.Creator.Email}
+	}
+
+	for _, file := range changedFilesSplit.Split(build.MetaData[changedFilesKey], -1) {
+		if file = strings.TrimSpace(file); file != "" {
+			result.ChangedFiles = append(result.ChangedFiles, file)
+		}
+	}
+
+	return result
+}
+
+// previousBranchBuild finds the most recent build on the same branch created before the given build
+func previousBranchBuild(ctx context.Context, client BuildsClient, org, pipeline string, build buildkite.Build) (*buildkite.Build, error) {
+	if build.Branch == "" {
+		return nil, nil
+	}
+
+	builds, _, err := client.ListByPipeline(ctx, org, pipeline, &buildkite.BuildsListOptions{
+		Branch:      []string{build.Branch},
+		ListOptions: buildkite.ListOptions{PerPage: 20},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, candidate := range builds {
+		if candidate.Number < build.Number && candidate.Commit != "" && candidate.Commit != "HEAD" {
+			return &candidate, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// compareURL returns a link to the diff between two commits for GitHub repositories
+func compareURL(repository, from, to string) string {
+	if from == "" || to == "" || from == to {
+		return ""
+	}
+
+	match := githubRepository.FindStringSubmatch(repository)
+	if match == nil {
+		return ""
+	}
+
+	return "https://github.com/" + match[1] + "/compare/" + from + "..." + to
+}
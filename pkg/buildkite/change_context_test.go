@@ -0,0 +1,66 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBuildChangeContext(t *testing.T) {
+	assert := require.New(t)
+
+	client := &MockBuildsClient{
+		GetFunc: func(ctx context.Context, org string, pipeline string, id string, opt *buildkite.BuildGetOptions) (buildkite.Build, *buildkite.Response, error) {
+			return buildkite.Build{
+				Number:  12,
+				State:   "failed",
+				Branch:  "main",
+				Commit:  "def456",
+				Message: "Fix the flaky test\n\nCo-authored-by: Sam <sam@example.com>",
+				Author:  buildkite.Author{Name: "Alex", Email: "alex@example.com"},
+				Creator: buildkite.Creator{Name: "Release Bot", Email: "bot@example.com"},
+				MetaData: map[string]string{
+					"changed_files": "main.go\nmain_test.go\n",
+				},
+				Pipeline: &buildkite.Pipeline{Repository: "git@github.com:acme/app.git"},
+			}, &buildkite.Response{}, nil
+		},
+		ListByPipelineFunc: func(ctx context.Context, org string, pipeline string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error) {
+			assert.Equal([]string{"main"}, opt.Branch)
+			return []buildkite.Build{
+				{Number: 13, Commit: "aaa111"},
+				{Number: 12, Commit: "def456"},
+				{Number: 11, Commit: "abc123", State: "passed"},
+			}, &buildkite.Response{}, nil
+		},
+	}
+
+	_, handler, scopes := GetBuildChangeContext(client)
+	assert.Equal([]string{"read_builds"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, GetBuildChangeContextArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+		BuildNumber:  "12",
+	})
+	assert.NoError(err)
+
+	var changes BuildChangeContext
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &changes))
+	assert.Equal("Fix the flaky test", changes.Subject)
+	assert.Equal("alex@example.com", changes.Author.Email)
+	assert.Equal([]string{"Sam <sam@example.com>"}, changes.CoAuthors)
+	assert.Equal("bot@example.com", changes.Creator.Email)
+	assert.Equal([]string{"main.go", "main_test.go"}, changes.ChangedFiles)
+	assert.Equal(&CommitRange{
+		PreviousBuildNumber: 11,
+		PreviousBuildState:  "passed",
+		From:                "abc123",
+		To:                  "def456",
+		CompareURL:          "https://github.com/acme/app/compare/abc123...def456",
+	}, changes.CommitRange)
+}
@@ -11,7 +11,10 @@ import (
 
 type mockClusterQueuesClient struct {
 	ListFunc func(ctx context.Context, org, clusterID string, opts *buildkite.ClusterQueuesListOptions) ([]buildkite.ClusterQueue, *buildkite.Response, error)
-	GetFunc  func(ctx context.Context, org, clusterID, queueID string) (buildkite.ClusterQueue, *buildkite.Response, error)
+	GetFunc    func(ctx context.Context, org, clusterID, queueID string) (buildkite.ClusterQueue, *buildkite.Response, error)
+	CreateFunc func(ctx context.Context, org, clusterID string, qc buildkite.ClusterQueueCreate) (buildkite.ClusterQueue, *buildkite.Response, error)
+	PauseFunc  func(ctx context.Context, org, clusterID, queueID string, qp buildkite.ClusterQueuePause) (buildkite.ClusterQueue, *buildkite.Response, error)
+	ResumeFunc func(ctx context.Context, org, clusterID, queueID string) (*buildkite.Response, error)
 }
 
 func (m *mockClusterQueuesClient) List(ctx context.Context, org, clusterID string, opts *buildkite.ClusterQueuesListOptions) ([]buildkite.ClusterQueue, *buildkite.Response, error) {
@@ -27,6 +30,27 @@ func (m *mockClusterQueuesClient) Get(ctx context.Context, org, clusterID, queue
 	return buildkite.ClusterQueue{}, nil, nil
 }
 
+func (m *mockClusterQueuesClient) Create(ctx context.Context, org, clusterID string, qc buildkite.ClusterQueueCreate) (buildkite.ClusterQueue, *buildkite.Response, error) {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, org, clusterID, qc)
+	}
+	return buildkite.ClusterQueue{}, nil, nil
+}
+
+func (m *mockClusterQueuesClient) Pause(ctx context.Context, org, clusterID, queueID string, qp buildkite.ClusterQueuePause) (buildkite.ClusterQueue, *buildkite.Response, error) {
+	if m.PauseFunc != nil {
+		return m.PauseFunc(ctx, org, clusterID, queueID, qp)
+	}
+	return buildkite.ClusterQueue{}, nil, nil
+}
+
+func (m *mockClusterQueuesClient) Resume(ctx context.Context, org, clusterID, queueID string) (*buildkite.Response, error) {
+	if m.ResumeFunc != nil {
+		return m.ResumeFunc(ctx, org, clusterID, queueID)
+	}
+	return nil, nil
+}
+
 var _ ClusterQueuesClient = (*mockClusterQueuesClient)(nil)
 
 func TestListClusterQueues(t *testing.T) {
@@ -0,0 +1,193 @@
+package buildkite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type CreateClusterQueueArgs struct {
+	OrgSlug     string `json:"org_slug"`
+	ClusterID   string `json:"cluster_id"`
+	Key         string `json:"key"`
+	Description string `json:"description,omitempty"`
+}
+
+func CreateClusterQueue(client ClusterQueuesClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[CreateClusterQueueArgs], scopes []string) {
+	return mcp.NewTool("create_cluster_queue",
+			mcp.WithDescription("Create a self-hosted queue in a cluster, which agents started with a matching queue tag pick up jobs from"),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("cluster_id",
+				mcp.Required(),
+			),
+			mcp.WithString("key",
+				mcp.Required(),
+				mcp.Description("The key of the queue, which steps target with agents: queue=<key>"),
+			),
+			mcp.WithString("description",
+				mcp.Description("A description of the queue, such as the agents which serve it"),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Create Cluster Queue",
+				ReadOnlyHint: mcp.ToBoolPtr(false),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args CreateClusterQueueArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.CreateClusterQueue")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.ClusterID == "" {
+				return mcp.NewToolResultError("cluster_id parameter is required"), nil
+			}
+			if args.Key == "" {
+				return mcp.NewToolResultError("key parameter is required"), nil
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("cluster_id", args.ClusterID),
+				attribute.String("key", args.Key),
+			)
+
+			queue, _, err := client.Create(ctx, args.OrgSlug, args.ClusterID, buildkite.ClusterQueueCreate{
+				Key:         args.Key,
+				Description: args.Description,
+			})
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			return mcpTextResult(span, &queue)
+		}, []string{"write_clusters"}
+}
+
+type PauseClusterQueueArgs struct {
+	OrgSlug   string `json:"org_slug"`
+	ClusterID string `json:"cluster_id"`
+	QueueID   string `json:"queue_id"`
+	Note      string `json:"note,omitempty"`
+}
+
+func PauseClusterQueue(client ClusterQueuesClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[PauseClusterQueueArgs], scopes []string) {
+	return mcp.NewTool("pause_cluster_queue",
+			mcp.WithDescription("Pause dispatch on a cluster queue so no new jobs are assigned to its agents, for example to drain the queue during an incident. Jobs already running carry on and waiting jobs stay queued until it is resumed with resume_cluster_queue"),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("cluster_id",
+				mcp.Required(),
+			),
+			mcp.WithString("queue_id",
+				mcp.Required(),
+			),
+			mcp.WithString("note",
+				mcp.Description("Why dispatch is paused, shown on the queue in Buildkite"),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:           "Pause Cluster Queue",
+				ReadOnlyHint:    mcp.ToBoolPtr(false),
+				DestructiveHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args PauseClusterQueueArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.PauseClusterQueue")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.ClusterID == "" {
+				return mcp.NewToolResultError("cluster_id parameter is required"), nil
+			}
+			if args.QueueID == "" {
+				return mcp.NewToolResultError("queue_id parameter is required"), nil
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("cluster_id", args.ClusterID),
+				attribute.String("queue_id", args.QueueID),
+			)
+
+			queue, _, err := client.Get(ctx, args.OrgSlug, args.ClusterID, args.QueueID)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if queue.DispatchPaused {
+				return mcp.NewToolResultError(fmt.Sprintf("dispatch on queue %s is already paused", queue.Key)), nil
+			}
+
+			queue, _, err = client.Pause(ctx, args.OrgSlug, args.ClusterID, args.QueueID, buildkite.ClusterQueuePause{Note: args.Note})
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			return mcpTextResult(span, &queue)
+		}, []string{"write_clusters"}
+}
+
+type ResumeClusterQueueArgs struct {
+	OrgSlug   string `json:"org_slug"`
+	ClusterID string `json:"cluster_id"`
+	QueueID   string `json:"queue_id"`
+}
+
+func ResumeClusterQueue(client ClusterQueuesClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[ResumeClusterQueueArgs], scopes []string) {
+	return mcp.NewTool("resume_cluster_queue",
+			mcp.WithDescription("Resume dispatch on a paused cluster queue so its agents are assigned jobs again"),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("cluster_id",
+				mcp.Required(),
+			),
+			mcp.WithString("queue_id",
+				mcp.Required(),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Resume Cluster Queue",
+				ReadOnlyHint: mcp.ToBoolPtr(false),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args ResumeClusterQueueArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.ResumeClusterQueue")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.ClusterID == "" {
+				return mcp.NewToolResultError("cluster_id parameter is required"), nil
+			}
+			if args.QueueID == "" {
+				return mcp.NewToolResultError("queue_id parameter is required"), nil
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("cluster_id", args.ClusterID),
+				attribute.String("queue_id", args.QueueID),
+			)
+
+			if _, err := client.Resume(ctx, args.OrgSlug, args.ClusterID, args.QueueID); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// resuming doesn't return the queue, so fetch it to show the dispatch status
+			queue, _, err := client.Get(ctx, args.OrgSlug, args.ClusterID, args.QueueID)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			return mcpTextResult(span, &queue)
+		}, []string{"write_clusters"}
+}
@@ -0,0 +1,141 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateClusterQueue(t *testing.T) {
+	assert := require.New(t)
+
+	var created buildkite.ClusterQueueCreate
+	client := &mockClusterQueuesClient{
+		CreateFunc: func(ctx context.Context, org, clusterID string, qc buildkite.ClusterQueueCreate) (buildkite.ClusterQueue, *buildkite.Response, error) {
+			created = qc
+			return buildkite.ClusterQueue{ID: "queue-1", Key: qc.Key}, &buildkite.Response{}, nil
+		},
+	}
+
+	tool, handler, scopes := CreateClusterQueue(client)
+	assert.Equal("create_cluster_queue", tool.Name)
+	assert.False(*tool.Annotations.ReadOnlyHint)
+	assert.Equal([]string{"write_clusters"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, CreateClusterQueueArgs{OrgSlug: "org", ClusterID: "cluster-1", Key: "linux", Description: "Linux agents"})
+	assert.NoError(err)
+	assert.Equal(buildkite.ClusterQueueCreate{Key: "linux", Description: "Linux agents"}, created)
+	assert.Contains(getTextResult(t, result).Text, `"id":"queue-1"`)
+
+	result, err = handler(context.Background(), mcp.CallToolRequest{}, CreateClusterQueueArgs{OrgSlug: "org", ClusterID: "cluster-1"})
+	assert.NoError(err)
+	assert.True(result.IsError)
+}
+
+func TestPauseClusterQueue(t *testing.T) {
+	assert := require.New(t)
+
+	queue := buildkite.ClusterQueue{ID: "queue-1", Key: "linux"}
+	var note string
+	client := &mockClusterQueuesClient{
+		GetFunc: func(ctx context.Context, org, clusterID, queueID string) (buildkite.ClusterQueue, *buildkite.Response, error) {
+			return queue, &buildkite.Response{}, nil
+		},
+		PauseFunc: func(ctx context.Context, org, clusterID, queueID string, qp buildkite.ClusterQueuePause) (buildkite.ClusterQueue, *buildkite.Response, error) {
+			note = qp.Note
+			queue.DispatchPaused = true
+			queue.DispatchPausedNote = qp.Note
+			return queue, &buildkite.Response{}, nil
+		},
+	}
+
+	tool, handler, scopes := PauseClusterQueue(client)
+	assert.Equal("pause_cluster_queue", tool.Name)
+	assert.True(*tool.Annotations.DestructiveHint)
+	assert.Equal([]string{"write_clusters"}, scopes)
+
+	args := PauseClusterQueueArgs{OrgSlug: "org", ClusterID: "cluster-1", QueueID: "queue-1", Note: "incident 42"}
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, args)
+	assert.NoError(err)
+	assert.Equal("incident 42", note)
+
+	var paused buildkite.ClusterQueue
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &paused))
+	assert.True(paused.DispatchPaused)
+
+	result, err = handler(context.Background(), mcp.CallToolRequest{}, args)
+	assert.NoError(err)
+	assert.True(result.IsError)
+	assert.Contains(getTextResult(t, result).Text, "already paused")
+}
+
+func TestResumeClusterQueue(t *testing.T) {
+	assert := require.New(t)
+
+	queue := buildkite.ClusterQueue{ID: "queue-1", Key: "linux", DispatchPaused: true}
+	client := &mockClusterQueuesClient{
+		GetFunc: func(ctx context.Context, org, clusterID, queueID string) (buildkite.ClusterQueue, *buildkite.Response, error) {
+			return queue, &buildkite.Response{}, nil
+		},
+		ResumeFunc: func(ctx context.Context, org, clusterID, queueID string) (*buildkite.Response, error) {
+			queue.DispatchPaused = false
+			return &buildkite.Response{}, nil
+		},
+	}
+
+	_, handler, scopes := ResumeClusterQueue(client)
+	assert.Equal([]string{"write_clusters"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, ResumeClusterQueueArgs{OrgSlug: "org", ClusterID: "cluster-1", QueueID: "queue-1"})
+	assert.NoError(err)
+
+	var resumed buildkite.ClusterQueue
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &resumed))
+	assert.False(resumed.DispatchPaused)
+}
+
+type mockClusterTokensClient struct {
+	ListFunc func(ctx context.Context, org, clusterID string, opts *buildkite.ClusterTokensListOptions) ([]buildkite.ClusterToken, *buildkite.Response, error)
+}
+
+func (m *mockClusterTokensClient) List(ctx context.Context, org, clusterID string, opts *buildkite.ClusterTokensListOptions) ([]buildkite.ClusterToken, *buildkite.Response, error) {
+	if m.ListFunc != nil {
+		return m.ListFunc(ctx, org, clusterID, opts)
+	}
+	return nil, nil, nil
+}
+
+var _ ClusterTokensClient = (*mockClusterTokensClient)(nil)
+
+func TestListClusterAgentTokens(t *testing.T) {
+	assert := require.New(t)
+
+	client := &mockClusterTokensClient{
+		ListFunc: func(ctx context.Context, org, clusterID string, opts *buildkite.ClusterTokensListOptions) ([]buildkite.ClusterToken, *buildkite.Response, error) {
+			return []buildkite.ClusterToken{{
+				ID:          "token-1",
+				Description: "Default agent token",
+				Token:       "secret-agent-token",
+				CreatedBy:   buildkite.ClusterCreator{Name: "Keith"},
+			}}, &buildkite.Response{}, nil
+		},
+	}
+
+	tool, handler, scopes := ListClusterAgentTokens(client)
+	assert.Equal("list_cluster_agent_tokens", tool.Name)
+	assert.Equal([]string{"read_clusters"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, ListClusterAgentTokensArgs{OrgSlug: "org", ClusterID: "cluster-1"})
+	assert.NoError(err)
+
+	text := getTextResult(t, result).Text
+	assert.NotContains(text, "secret-agent-token")
+
+	var tokens PaginatedResult[ClusterAgentToken]
+	assert.NoError(json.Unmarshal([]byte(text), &tokens))
+	assert.Equal([]ClusterAgentToken{{ID: "token-1", Description: "Default agent token", CreatedBy: "Keith"}}, tokens.Items)
+}
@@ -0,0 +1,99 @@
+package buildkite
+
+import (
+	"context"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type ClusterTokensClient interface {
+	List(ctx context.Context, org, clusterID string, opts *buildkite.ClusterTokensListOptions) ([]buildkite.ClusterToken, *buildkite.Response, error)
+}
+
+type ListClusterAgentTokensArgs struct {
+	OrgSlug   string `json:"org_slug"`
+	ClusterID string `json:"cluster_id"`
+}
+
+// ClusterAgentToken describes an agent token of a cluster, leaving out the token itself
+type ClusterAgentToken struct {
+	ID                 string               `json:"id"`
+	Description        string               `json:"description,omitempty"`
+	AllowedIPAddresses string               `json:"allowed_ip_addresses,omitempty"`
+	CreatedAt          *buildkite.Timestamp `json:"created_at,omitempty"`
+	CreatedBy          string               `json:"created_by,omitempty"`
+}
+
+func ListClusterAgentTokens(client ClusterTokensClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[ListClusterAgentTokensArgs], scopes []string) {
+	return mcp.NewTool("list_cluster_agent_tokens",
+			mcp.WithDescription("List the agent tokens of a cluster with their descriptions, allowed IP addresses and who created them. The token values are never returned"),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("cluster_id",
+				mcp.Required(),
+			),
+			withPagination(),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "List Cluster Agent Tokens",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args ListClusterAgentTokensArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.ListClusterAgentTokens")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.ClusterID == "" {
+				return mcp.NewToolResultError("cluster_id parameter is required"), nil
+			}
+
+			paginationParams, err := optionalPaginationParams(request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("cluster_id", args.ClusterID),
+				attribute.Int("page", paginationParams.Page),
+				attribute.Int("per_page", paginationParams.PerPage),
+			)
+
+			tokens, resp, err := client.List(ctx, args.OrgSlug, args.ClusterID, &buildkite.ClusterTokensListOptions{
+				ListOptions: paginationParams,
+			})
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			result := PaginatedResult[ClusterAgentToken]{
+				Items: make([]ClusterAgentToken, 0, len(tokens)),
+			}
+			if resp != nil && resp.Response != nil {
+				result.Headers = map[string]string{
+					"Link": resp.Header.Get("Link"),
+				}
+			}
+			for _, token := range tokens {
+				result.Items = append(result.Items, ClusterAgentToken{
+					ID:                 token.ID,
+					Description:        token.Description,
+					AllowedIPAddresses: token.AllowedIPAddresses,
+					CreatedAt:          token.CreatedAt,
+					CreatedBy:          token.CreatedBy.Name,
+				})
+			}
+
+			span.SetAttributes(
+				attribute.Int("item_count", len(result.Items)),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_clusters"}
+}
@@ -0,0 +1,97 @@
+package buildkite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/buildkite/go-buildkite/v4"
+)
+
+const (
+	// creatorMe is the creator filter value meaning the owner of the API token
+	creatorMe = "me"
+	// maximum number of pages of organization members searched for an email
+	maxMemberPages = 10
+)
+
+// OrganizationMembersClient lists the members of an organization
+type OrganizationMembersClient interface {
+	ListMembers(ctx context.Context, org string, opt *buildkite.ListOptions) ([]OrganizationMember, *buildkite.Response, error)
+}
+
+// OrganizationMember is a user belonging to an organization
+type OrganizationMember struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// ListMembers implements OrganizationMembersClient, go-buildkite doesn't cover the organization members API yet
+func (a *BuildkiteClientAdapter) ListMembers(ctx context.Context, org string, opt *buildkite.ListOptions) ([]OrganizationMember, *buildkite.Response, error) {
+	u := fmt.Sprintf("v2/organizations/%s/members", org)
+	if opt != nil {
+		u = fmt.Sprintf("%s?page=%d&per_page=%d", u, opt.Page, opt.PerPage)
+	}
+
+	req, err := a.NewRequest(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var members []OrganizationMember
+	resp, err := a.Do(req, &members)
+	if err != nil {
+		return nil, resp, err
+	}
+	return members, resp, nil
+}
+
+// resolveCreator turns the creator filter into the user ID the API filters on, looking up "me" as
+// the owner of the API token and email addresses among the organization's members. Anything else is
+// taken to be a user ID already.
+func resolveCreator(ctx context.Context, users UserClient, members OrganizationMembersClient, org, creator string) (string, error) {
+	switch {
+	case strings.EqualFold(creator, creatorMe):
+		if users == nil {
+			return "", errors.New("creator 'me' can't be resolved without access to the current user")
+		}
+		user, _, err := users.CurrentUser(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to look up the current user: %w", err)
+		}
+		return user.ID, nil
+	case strings.Contains(creator, "@"):
+		if members == nil {
+			return "", errors.New("creator emails can't be resolved without access to the organization's members")
+		}
+		return findMemberByEmail(ctx, members, org, creator)
+	default:
+		return creator, nil
+	}
+}
+
+func findMemberByEmail(ctx context.Context, client OrganizationMembersClient, org, email string) (string, error) {
+	options := &buildkite.ListOptions{Page: 1, PerPage: 100}
+
+	for range maxMemberPages {
+		page, resp, err := client.ListMembers(ctx, org, options)
+		if err != nil {
+			return "", fmt.Errorf("failed to list organization members: %w", err)
+		}
+
+		for _, member := range page {
+			if strings.EqualFold(member.Email, email) {
+				return member.ID, nil
+			}
+		}
+
+		if resp == nil || resp.NextPage == 0 || len(page) == 0 {
+			break
+		}
+		options.Page = resp.NextPage
+	}
+
+	return "", fmt.Errorf("no member of %s has the email %s", org, email)
+}
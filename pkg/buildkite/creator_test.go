@@ -0,0 +1,75 @@
+package buildkite
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+type MockOrganizationMembersClient struct {
+	ListMembersFunc func(ctx context.Context, org string, opt *buildkite.ListOptions) ([]OrganizationMember, *buildkite.Response, error)
+}
+
+func (m *MockOrganizationMembersClient) ListMembers(ctx context.Context, org string, opt *buildkite.ListOptions) ([]OrganizationMember, *buildkite.Response, error) {
+	if m.ListMembersFunc != nil {
+		return m.ListMembersFunc(ctx, org, opt)
+	}
+	return nil, nil, nil
+}
+
+var _ OrganizationMembersClient = (*MockOrganizationMembersClient)(nil)
+
+func TestListBuildsCreator(t *testing.T) {
+	assert := require.New(t)
+
+	var capturedOptions *buildkite.BuildsListOptions
+	client := &MockBuildsClient{
+		ListByPipelineFunc: func(ctx context.Context, org string, pipeline string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error) {
+			capturedOptions = opt
+			return []buildkite.Build{}, &buildkite.Response{Response: &http.Response{StatusCode: 200}}, nil
+		},
+	}
+	users := &MockUserClient{
+		CurrentUserFunc: func(ctx context.Context) (buildkite.User, *buildkite.Response, error) {
+			return buildkite.User{ID: "user-me"}, &buildkite.Response{}, nil
+		},
+	}
+	members := &MockOrganizationMembersClient{
+		ListMembersFunc: func(ctx context.Context, org string, opt *buildkite.ListOptions) ([]OrganizationMember, *buildkite.Response, error) {
+			if opt.Page == 1 {
+				return []OrganizationMember{{ID: "user-1", Email: "alice@example.com"}}, &buildkite.Response{NextPage: 2}, nil
+			}
+			return []OrganizationMember{{ID: "user-2", Email: "bob@example.com"}}, &buildkite.Response{}, nil
+		},
+	}
+
+	_, typedHandler, _ := ListBuilds(client, users, members, nil, nil)
+	handler := mcp.NewTypedToolHandler(typedHandler)
+
+	listBuilds := func(creator string) *mcp.CallToolResult {
+		result, err := handler(context.Background(), createMCPRequest(t, map[string]any{
+			"org_slug":      "org",
+			"pipeline_slug": "pipeline",
+			"creator":       creator,
+		}))
+		assert.NoError(err)
+		return result
+	}
+
+	assert.False(listBuilds("me").IsError)
+	assert.Equal("user-me", capturedOptions.Creator)
+
+	assert.False(listBuilds("Bob@Example.com").IsError)
+	assert.Equal("user-2", capturedOptions.Creator)
+
+	assert.False(listBuilds("0183c9d2-user-id").IsError)
+	assert.Equal("0183c9d2-user-id", capturedOptions.Creator)
+
+	result := listBuilds("carol@example.com")
+	assert.True(result.IsError)
+	assert.Contains(getTextResult(t, result).Text, "no member of org has the email carol@example.com")
+}
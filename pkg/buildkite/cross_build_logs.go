@@ -0,0 +1,258 @@
+package buildkite
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/redact"
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	defaultCrossBuildSearchBuilds = 10
+	maxCrossBuildSearchBuilds     = 50
+	crossBuildSearchConcurrency   = 4
+	// bounds the size of the response, matches beyond these are counted but not returned
+	maxCrossBuildJobsPerBuild   = 10
+	maxCrossBuildMatchesPerJob  = 3
+	maxCrossBuildMatchesCounted = 1000
+	maxCrossBuildMatchChars     = 300
+)
+
+type SearchLogsAcrossBuildsArgs struct {
+	OrgSlug       string `json:"org_slug"`
+	PipelineSlug  string `json:"pipeline_slug"`
+	Pattern       string `json:"pattern"`
+	Builds        int    `json:"builds,omitempty"`
+	JobFilter     string `json:"job_filter,omitempty"`
+	Branch        string `json:"branch,omitempty"`
+	CaseSensitive bool   `json:"case_sensitive,omitempty"`
+}
+
+// JobLogMatches are the matches found in a single job's log
+type JobLogMatches struct {
+	JobID      string          `json:"job_id"`
+	Label      string          `json:"label"`
+	MatchCount int             `json:"match_count"`
+	Matches    []TerseLogEntry `json:"matches,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// BuildLogMatches are the matches found across the matching jobs of a build
+type BuildLogMatches struct {
+	BuildNumber int                  `json:"build_number"`
+	State       string               `json:"state"`
+	Commit      string               `json:"commit"`
+	CreatedAt   *buildkite.Timestamp `json:"created_at,omitempty"`
+	Matched     bool                 `json:"matched"`
+	Jobs        []JobLogMatches      `json:"jobs,omitempty"`
+}
+
+type CrossBuildLogSearch struct {
+	Pattern           string            `json:"pattern"`
+	BuildsSearched    int               `json:"builds_searched"`
+	BuildsWithMatches int               `json:"builds_with_matches"`
+	FirstSeenBuild    int               `json:"first_seen_build,omitempty"`
+	LastSeenBuild     int               `json:"last_seen_build,omitempty"`
+	Redactions        int               `json:"redactions,omitempty"`
+	Builds            []BuildLogMatches `json:"builds"`
+}
+
+// searchJobLog returns up to maxCrossBuildMatchesPerJob matches from a job log, counting the rest
+func searchJobLog(ctx context.Context, client BuildkiteLogsClient, params JobLogsBaseParams, opts SearchOptions, redactor *redact.Redactor) (JobLogMatches, int, error) {
+	var result JobLogMatches
+	redactions := 0
+
+	reader, err := newParquetReader(ctx, client, params)
+	if err != nil {
+		return result, 0, err
+	}
+
+	for match, err := range reader.SearchEntriesIter(opts) {
+		if err != nil {
+			return result, redactions, fmt.Errorf("search error: %w", err)
+		}
+
+		result.MatchCount++
+		if len(result.Matches) < maxCrossBuildMatchesPerJob {
+			content, n := redactor.Redact(match.Match.CleanContent(true))
+			redactions += n
+			content, _ = truncateRunes(content, maxCrossBuildMatchChars)
+			result.Matches = append(result.Matches, TerseLogEntry{C: content, RN: match.Match.RowNumber})
+		}
+
+		if result.MatchCount >= maxCrossBuildMatchesCounted || ctx.Err() != nil {
+			break
+		}
+	}
+
+	return result, redactions, nil
+}
+
+// searchableJobs returns the script jobs in a build matching the filter, skipping retried attempts
+func searchableJobs(build buildkite.Build, filter string) []buildkite.Job {
+	var jobs []buildkite.Job
+	for _, job := range build.Jobs {
+		if (job.Type != "" && job.Type != "script") || job.Retried {
+			continue
+		}
+		if filter != "" && job.StepKey != filter && jobLabel(job) != filter {
+			continue
+		}
+		jobs = append(jobs, job)
+		if len(jobs) == maxCrossBuildJobsPerBuild {
+			break
+		}
+	}
+	return jobs
+}
+
+func SearchLogsAcrossBuilds(buildsClient BuildsClient, logsClient BuildkiteLogsClient, redactor *redact.Redactor) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[SearchLogsAcrossBuildsArgs], scopes []string) {
+	return mcp.NewTool("search_logs_across_builds",
+			mcp.WithDescription("Search the logs of the same step across a pipeline's most recent finished builds and report which builds contain matches, with the first and last build the pattern was seen in. Ideal for 'when did this error first appear?'. Only the first few matches per job are returned, so use search_logs on a specific job for full detail."),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pattern",
+				mcp.Required(),
+				mcp.Description("Regex pattern to search for"),
+			),
+			mcp.WithNumber("builds",
+				mcp.Description("Number of recent builds to search (default 10, max 50)"),
+				mcp.Min(1),
+				mcp.Max(maxCrossBuildSearchBuilds),
+			),
+			mcp.WithString("job_filter",
+				mcp.Description("Step key or label of the jobs to search. Strongly recommended, otherwise the first 10 jobs of each build are searched"),
+			),
+			mcp.WithString("branch",
+				mcp.Description("Only search builds on this branch"),
+			),
+			mcp.WithBoolean("case_sensitive",
+				mcp.Description("Case-sensitive search (default: false)"),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Search Logs Across Builds",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args SearchLogsAcrossBuildsArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.SearchLogsAcrossBuilds")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if err := validateSearchPattern(args.Pattern); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if args.Builds <= 0 {
+				args.Builds = defaultCrossBuildSearchBuilds
+			}
+			args.Builds = min(args.Builds, maxCrossBuildSearchBuilds)
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+				attribute.String("pattern", args.Pattern),
+				attribute.String("job_filter", args.JobFilter),
+				attribute.String("branch", args.Branch),
+				attribute.Int("builds", args.Builds),
+			)
+
+			builds, err := listRecentBuilds(ctx, buildsClient, args.OrgSlug, args.PipelineSlug, args.Branch, args.Builds)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			opts := SearchOptions{
+				Pattern:       args.Pattern,
+				CaseSensitive: args.CaseSensitive,
+			}
+
+			result := CrossBuildLogSearch{
+				Pattern:        args.Pattern,
+				BuildsSearched: len(builds),
+				Builds:         make([]BuildLogMatches, len(builds)),
+			}
+
+			var mu sync.Mutex
+			g, gctx := errgroup.WithContext(ctx)
+			g.SetLimit(crossBuildSearchConcurrency)
+
+			for i, build := range builds {
+				result.Builds[i] = BuildLogMatches{
+					BuildNumber: build.Number,
+					State:       build.State,
+					Commit:      build.Commit,
+					CreatedAt:   build.CreatedAt,
+				}
+
+				for _, job := range searchableJobs(build, args.JobFilter) {
+					g.Go(func() error {
+						matches, redactions, err := searchJobLog(gctx, logsClient, JobLogsBaseParams{
+							OrgSlug:      args.OrgSlug,
+							PipelineSlug: args.PipelineSlug,
+							BuildNumber:  fmt.Sprint(build.Number),
+							JobID:        job.ID,
+						}, opts, redactor)
+						matches.JobID = job.ID
+						matches.Label = jobLabel(job)
+						if err != nil {
+							matches.Error = err.Error()
+						}
+
+						mu.Lock()
+						defer mu.Unlock()
+
+						result.Redactions += redactions
+						if matches.MatchCount > 0 || matches.Error != "" {
+							result.Builds[i].Jobs = append(result.Builds[i].Jobs, matches)
+						}
+						if matches.MatchCount > 0 {
+							result.Builds[i].Matched = true
+						}
+
+						// a failure to read one log shouldn't abandon the rest of the search
+						return nil
+					})
+				}
+			}
+
+			if err := g.Wait(); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// builds are newest first, so the last match seen is the earliest occurrence
+			for _, build := range result.Builds {
+				slices.SortFunc(build.Jobs, func(a, b JobLogMatches) int { return cmp.Compare(a.Label, b.Label) })
+				if !build.Matched {
+					continue
+				}
+				result.BuildsWithMatches++
+				if result.LastSeenBuild == 0 {
+					result.LastSeenBuild = build.BuildNumber
+				}
+				result.FirstSeenBuild = build.BuildNumber
+			}
+
+			span.SetAttributes(
+				attribute.Int("builds_with_matches", result.BuildsWithMatches),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_builds", "read_build_logs"}
+}
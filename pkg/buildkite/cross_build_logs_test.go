@@ -0,0 +1,78 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"iter"
+	"path/filepath"
+	"testing"
+	"time"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestLog(t *testing.T, lines ...string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "log.parquet")
+	seq := func(yield func(*buildkitelogs.LogEntry, error) bool) {
+		for _, line := range lines {
+			if !yield(&buildkitelogs.LogEntry{Timestamp: time.Now(), Content: line}, nil) {
+				return
+			}
+		}
+	}
+	require.NoError(t, buildkitelogs.ExportSeq2ToParquet(iter.Seq2[*buildkitelogs.LogEntry, error](seq), path))
+
+	return path
+}
+
+func TestSearchLogsAcrossBuilds(t *testing.T) {
+	assert := require.New(t)
+
+	clean := writeTestLog(t, "running tests", "ok")
+	failing := writeTestLog(t, "running tests", "panic: connection refused", "panic: connection refused again")
+
+	buildsClient := &MockBuildsClient{
+		ListByPipelineFunc: func(ctx context.Context, org string, pipeline string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error) {
+			return []buildkite.Build{
+				{Number: 3, Jobs: []buildkite.Job{{ID: "job-3", StepKey: "test"}, {ID: "job-3-lint", StepKey: "lint"}}},
+				{Number: 2, Jobs: []buildkite.Job{{ID: "job-2", StepKey: "test"}}},
+				{Number: 1, Jobs: []buildkite.Job{{ID: "job-1", StepKey: "test"}}},
+			}, &buildkite.Response{}, nil
+		},
+	}
+	logsClient := &MockBuildkiteLogsClient{
+		DownloadAndCacheFunc: func(ctx context.Context, org, pipeline, build, job string, cacheTTL time.Duration, forceRefresh bool) (string, error) {
+			assert.NotEqual("job-3-lint", job)
+			if build == "1" {
+				return clean, nil
+			}
+			return failing, nil
+		},
+	}
+
+	_, handler, scopes := SearchLogsAcrossBuilds(buildsClient, logsClient, nil)
+	assert.Equal([]string{"read_builds", "read_build_logs"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, SearchLogsAcrossBuildsArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+		Pattern:      "connection refused",
+		JobFilter:    "test",
+	})
+	assert.NoError(err)
+
+	var search CrossBuildLogSearch
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &search))
+	assert.Equal(3, search.BuildsSearched)
+	assert.Equal(2, search.BuildsWithMatches)
+	assert.Equal(2, search.FirstSeenBuild)
+	assert.Equal(3, search.LastSeenBuild)
+	assert.False(search.Builds[2].Matched)
+	assert.Equal(2, search.Builds[0].Jobs[0].MatchCount)
+	assert.Equal("panic: connection refused", search.Builds[0].Jobs[0].Matches[0].C)
+}
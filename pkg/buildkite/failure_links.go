@@ -0,0 +1,250 @@
+package buildkite
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// bounds the size of the log search pattern, failures beyond this are reported unlinked
+	maxLinkedTestFailures = 50
+	maxLinkedJobsSearched = 20
+)
+
+type LinkBuildFailuresToTestsArgs struct {
+	OrgSlug      string `json:"org_slug"`
+	PipelineSlug string `json:"pipeline_slug"`
+	BuildNumber  string `json:"build_number"`
+}
+
+// LinkedTestFailure is a failed test execution reported to Test Engine by a build
+type LinkedTestFailure struct {
+	Suite         string `json:"suite"`
+	RunID         string `json:"run_id"`
+	ExecutionID   string `json:"execution_id"`
+	TestName      string `json:"test_name"`
+	Location      string `json:"location,omitempty"`
+	FailureReason string `json:"failure_reason,omitempty"`
+}
+
+// FailedJobTests are the test failures which explain a failed job
+type FailedJobTests struct {
+	JobID        string              `json:"job_id"`
+	Label        string              `json:"label"`
+	ExitStatus   *int                `json:"exit_status,omitempty"`
+	WebURL       string              `json:"web_url,omitempty"`
+	TestFailures []LinkedTestFailure `json:"test_failures"`
+	// Explained is false when no test failures were linked, which suggests an infrastructure or compile problem
+	Explained bool   `json:"explained"`
+	Note      string `json:"note,omitempty"`
+}
+
+type BuildFailureLinks struct {
+	BuildNumber      int                 `json:"build_number"`
+	State            string              `json:"state"`
+	TestRuns         int                 `json:"test_runs"`
+	Jobs             []FailedJobTests    `json:"jobs"`
+	UnlinkedFailures []LinkedTestFailure `json:"unlinked_test_failures"`
+	JobsWithoutTests []string            `json:"jobs_without_test_failures"`
+	Notes            []string            `json:"notes,omitempty"`
+}
+
+// testFailureName returns the name a test failure is most likely to be logged under
+func testFailureName(failure LinkedTestFailure) string {
+	if failure.TestName != "" {
+		return failure.TestName
+	}
+	return failure.Location
+}
+
+// failureNamesPattern builds a single pattern matching any of the failures, so each job log is only read once
+func failureNamesPattern(failures []LinkedTestFailure) string {
+	var names []string
+	for _, failure := range failures {
+		if name := testFailureName(failure); name != "" {
+			names = append(names, regexp.QuoteMeta(name))
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// linkJobFailures searches a job log for the names of the failed tests, returning the indexes of those found
+func linkJobFailures(ctx context.Context, client BuildkiteLogsClient, params JobLogsBaseParams, failures []LinkedTestFailure, pattern string) (map[int]bool, error) {
+	reader, err := newParquetReader(ctx, client, params)
+	if err != nil {
+		return nil, err
+	}
+
+	found := map[int]bool{}
+	for match, err := range reader.SearchEntriesIter(SearchOptions{Pattern: pattern, CaseSensitive: true}) {
+		if err != nil {
+			return found, fmt.Errorf("search error: %w", err)
+		}
+
+		content := match.Match.CleanContent(true)
+		for i, failure := range failures {
+			if name := testFailureName(failure); name != "" && strings.Contains(content, name) {
+				found[i] = true
+			}
+		}
+
+		if len(found) == len(failures) || ctx.Err() != nil {
+			break
+		}
+	}
+
+	return found, nil
+}
+
+func LinkBuildFailuresToTests(buildsClient BuildsClient, testRunsClient TestRunsClient, logsClient BuildkiteLogsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[LinkBuildFailuresToTestsArgs], scopes []string) {
+	return mcp.NewTool("link_build_failures_to_tests",
+			mcp.WithDescription("Cross-reference a failed build's Test Engine runs with its failed jobs. Returns, for each failed job, the test failures that explain it, found by looking for the failed test names in the job's log. Jobs which failed without any test failures usually point to infrastructure, dependency, or compile problems rather than broken tests."),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("build_number",
+				mcp.Required(),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Link Build Failures to Tests",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args LinkBuildFailuresToTestsArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.LinkBuildFailuresToTests")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if args.BuildNumber == "" {
+				return mcp.NewToolResultError("build_number parameter is required"), nil
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+				attribute.String("build_number", args.BuildNumber),
+			)
+
+			build, _, err := buildsClient.Get(ctx, args.OrgSlug, args.PipelineSlug, args.BuildNumber, &buildkite.BuildGetOptions{})
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			result := BuildFailureLinks{
+				BuildNumber:      build.Number,
+				State:            build.State,
+				Jobs:             []FailedJobTests{},
+				UnlinkedFailures: []LinkedTestFailure{},
+				JobsWithoutTests: []string{},
+			}
+
+			var failures []LinkedTestFailure
+			if build.TestEngine != nil {
+				result.TestRuns = len(build.TestEngine.Runs)
+				for _, run := range build.TestEngine.Runs {
+					executions, _, err := testRunsClient.GetFailedExecutions(ctx, args.OrgSlug, run.Suite.Slug, run.ID, &buildkite.FailedExecutionsOptions{})
+					if err != nil {
+						result.Notes = append(result.Notes, fmt.Sprintf("failed to get failed executions for run %s of suite %s: %v", run.ID, run.Suite.Slug, err))
+						continue
+					}
+					for _, execution := range executions {
+						failures = append(failures, LinkedTestFailure{
+							Suite:         run.Suite.Slug,
+							RunID:         run.ID,
+							ExecutionID:   execution.ExecutionID,
+							TestName:      execution.TestName,
+							Location:      execution.Location,
+							FailureReason: execution.FailureReason,
+						})
+					}
+				}
+			}
+			if result.TestRuns == 0 {
+				result.Notes = append(result.Notes, "the build did not report any Test Engine runs")
+			}
+			if len(failures) > maxLinkedTestFailures {
+				result.Notes = append(result.Notes, fmt.Sprintf("only the first %d of %d test failures were linked", maxLinkedTestFailures, len(failures)))
+				result.UnlinkedFailures = append(result.UnlinkedFailures, failures[maxLinkedTestFailures:]...)
+				failures = failures[:maxLinkedTestFailures]
+			}
+
+			var failedJobs []buildkite.Job
+			for _, job := range build.Jobs {
+				if isFailedJob(job) && !job.Retried {
+					failedJobs = append(failedJobs, job)
+				}
+			}
+
+			linked := map[int]bool{}
+			pattern := failureNamesPattern(failures)
+			buildNumber := strconv.Itoa(build.Number)
+
+			for i, job := range failedJobs {
+				jobTests := FailedJobTests{
+					JobID:        job.ID,
+					Label:        jobLabel(job),
+					ExitStatus:   job.ExitStatus,
+					WebURL:       job.WebURL,
+					TestFailures: []LinkedTestFailure{},
+				}
+
+				switch {
+				case pattern == "":
+				case i >= maxLinkedJobsSearched:
+					jobTests.Note = fmt.Sprintf("log not searched, only the first %d failed jobs are searched", maxLinkedJobsSearched)
+				default:
+					found, err := linkJobFailures(ctx, logsClient, JobLogsBaseParams{
+						OrgSlug:      args.OrgSlug,
+						PipelineSlug: args.PipelineSlug,
+						BuildNumber:  buildNumber,
+						JobID:        job.ID,
+					}, failures, pattern)
+					if err != nil {
+						jobTests.Note = fmt.Sprintf("log unavailable: %v", err)
+					}
+					for index := range failures {
+						if found[index] {
+							jobTests.TestFailures = append(jobTests.TestFailures, failures[index])
+							linked[index] = true
+						}
+					}
+				}
+
+				jobTests.Explained = len(jobTests.TestFailures) > 0
+				if !jobTests.Explained {
+					result.JobsWithoutTests = append(result.JobsWithoutTests, job.ID)
+				}
+				result.Jobs = append(result.Jobs, jobTests)
+			}
+
+			for index, failure := range failures {
+				if !linked[index] {
+					result.UnlinkedFailures = append(result.UnlinkedFailures, failure)
+				}
+			}
+
+			span.SetAttributes(
+				attribute.Int("failed_jobs", len(result.Jobs)),
+				attribute.Int("test_failures", len(failures)),
+				attribute.Int("jobs_without_test_failures", len(result.JobsWithoutTests)),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_builds", "read_build_logs", "read_suites"}
+}
@@ -0,0 +1,76 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkBuildFailuresToTests(t *testing.T) {
+	assert := require.New(t)
+
+	unitLog := writeTestLog(t, "=== RUN   TestCheckout", "--- FAIL: TestCheckout (0.12s)", "--- FAIL: TestRefund (0.01s)")
+	compileLog := writeTestLog(t, "go build ./...", "main.go:12: undefined: foo")
+
+	buildsClient := &MockBuildsClient{
+		GetFunc: func(ctx context.Context, org string, pipeline string, id string, opt *buildkite.BuildGetOptions) (buildkite.Build, *buildkite.Response, error) {
+			return buildkite.Build{
+				Number: 7,
+				State:  "failed",
+				TestEngine: &buildkite.TestEngineProperty{Runs: []buildkite.TestEngineRun{
+					{ID: "run-1", Suite: buildkite.TestEngineSuite{Slug: "unit"}},
+				}},
+				Jobs: []buildkite.Job{
+					{ID: "job-unit", Type: "script", Label: "unit", State: "failed"},
+					{ID: "job-build", Type: "script", Label: "build", State: "failed"},
+					{ID: "job-lint", Type: "script", Label: "lint", State: "passed"},
+				},
+			}, &buildkite.Response{}, nil
+		},
+	}
+	testRunsClient := &MockTestRunsClient{
+		GetFailedExecutionsFunc: func(ctx context.Context, org, slug, runID string, opt *buildkite.FailedExecutionsOptions) ([]buildkite.FailedExecution, *buildkite.Response, error) {
+			assert.Equal("unit", slug)
+			return []buildkite.FailedExecution{
+				{ExecutionID: "exec-1", TestName: "TestCheckout"},
+				{ExecutionID: "exec-2", TestName: "TestRefund"},
+				{ExecutionID: "exec-3", TestName: "TestInvoice"},
+			}, &buildkite.Response{}, nil
+		},
+	}
+	logsClient := &MockBuildkiteLogsClient{
+		DownloadAndCacheFunc: func(ctx context.Context, org, pipeline, build, job string, cacheTTL time.Duration, forceRefresh bool) (string, error) {
+			if job == "job-unit" {
+				return unitLog, nil
+			}
+			return compileLog, nil
+		},
+	}
+
+	_, handler, scopes := LinkBuildFailuresToTests(buildsClient, testRunsClient, logsClient)
+	assert.Equal([]string{"read_builds", "read_build_logs", "read_suites"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, LinkBuildFailuresToTestsArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+		BuildNumber:  "7",
+	})
+	assert.NoError(err)
+
+	var links BuildFailureLinks
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &links))
+	assert.Equal(1, links.TestRuns)
+	assert.Len(links.Jobs, 2)
+	assert.True(links.Jobs[0].Explained)
+	assert.Len(links.Jobs[0].TestFailures, 2)
+	assert.Equal("exec-1", links.Jobs[0].TestFailures[0].ExecutionID)
+	assert.False(links.Jobs[1].Explained)
+	assert.Equal([]string{"job-build"}, links.JobsWithoutTests)
+	assert.Len(links.UnlinkedFailures, 1)
+	assert.Equal("TestInvoice", links.UnlinkedFailures[0].TestName)
+}
@@ -0,0 +1,430 @@
+package buildkite
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/redact"
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	FailureCategoryInfrastructure = "infrastructure"
+	FailureCategoryDependency     = "dependency"
+	FailureCategoryCompile        = "compile"
+	FailureCategoryTest           = "test"
+	FailureCategoryUnknown        = "unknown"
+
+	// number of trailing log lines inspected for failure signatures
+	failureLogTailLines = 200
+	// maximum number of evidence lines returned per job
+	maxFailureEvidence = 5
+	// maximum number of failed jobs whose logs are read to classify a build, so a wide parallel step
+	// failing doesn't download hundreds of logs in one call
+	maxClassifiedJobs = 20
+	// number of job logs read at once when classifying a build
+	classifyJobsConcurrency = 4
+)
+
+// failedJobStates are the job states treated as failures when classifying a build
+var failedJobStates = []string{"failed", "timed_out", "broken", "expired"}
+
+type failureSignature struct {
+	category string
+	pattern  *regexp.Regexp
+}
+
+// failureSignatures are checked in order, so more specific categories come first
+var failureSignatures = []failureSignature{
+	{FailureCategoryInfrastructure, regexp.MustCompile(`(?i)out of memory|oomkilled|cannot allocate memory|no space left on device|agent (was )?lost|lost connection to agent|signal: killed|exited with status 137`)},
+	{FailureCategoryDependency, regexp.MustCompile(`(?i)could not resolve host|temporary failure in name resolution|connection (reset|refused|timed out)|tls handshake timeout|i/o timeout|dial tcp|too ?many ?requests|\b429\b|\b50[234] (bad gateway|service unavailable|gateway timeout)|npm err! network|failed to fetch|unable to access '|could not download|error pulling image|manifest unknown`)},
+	{FailureCategoryCompile, regexp.MustCompile(`(?i)compilation failed|build failed|cannot find symbol|undefined: |syntaxerror|error ts\d+:|error\[e\d+\]|\berror: .*\.(go|c|cc|cpp|h|rs|java|kt|swift|ts|tsx):\d+|: error: |cannot find module|could not compile`)},
+	{FailureCategoryTest, regexp.MustCompile(`(?i)--- fail:|^fail\b|\bfailed tests?\b|\b[1-9]\d* (tests? )?fail(ed|ures?)|assertionerror|assert(ion)? failed|expected .+ (but )?(got|to (be|equal))|test(s)? failed|failures:`)},
+}
+
+// FailureClassification is the heuristic classification of a single failed job
+type FailureClassification struct {
+	JobID      string   `json:"job_id"`
+	Label      string   `json:"label"`
+	StepKey    string   `json:"step_key,omitempty"`
+	State      string   `json:"state"`
+	ExitStatus *int     `json:"exit_status,omitempty"`
+	Category   string   `json:"category"`
+	Confidence float64  `json:"confidence"`
+	Evidence   []string `json:"evidence,omitempty"`
+	Note       string   `json:"note,omitempty"`
+}
+
+// BuildFailureClassification summarises the classifications for every failed job in a build
+type BuildFailureClassification struct {
+	BuildNumber int                     `json:"build_number"`
+	State       string                  `json:"state"`
+	ByCategory  map[string]int          `json:"by_category"`
+	Jobs        []FailureClassification `json:"jobs"`
+	// Unclassified lists the failed jobs past the first maxClassifiedJobs, which weren't classified
+	Unclassified []string `json:"unclassified_job_ids,omitempty"`
+	Note         string   `json:"note,omitempty"`
+}
+
+type ClassifyBuildFailureArgs struct {
+	OrgSlug      string `json:"org_slug"`
+	PipelineSlug string `json:"pipeline_slug"`
+	BuildNumber  string `json:"build_number"`
+}
+
+// isFailedJob returns true for script jobs which ended in a failure state
+func isFailedJob(job buildkite.Job) bool {
+	if job.Type != "" && job.Type != "script" {
+		return false
+	}
+	if slices.Contains(failedJobStates, job.State) {
+		return true
+	}
+	return job.ExitStatus != nil && *job.ExitStatus != 0
+}
+
+// classifyJobFailure labels a failed job using its state, exit status and log lines
+func classifyJobFailure(job buildkite.Job, lines []string) FailureClassification {
+	classification := FailureClassification{
+		JobID:      job.ID,
+		Label:      jobLabel(job),
+		StepKey:    job.StepKey,
+		State:      job.State,
+		ExitStatus: job.ExitStatus,
+		Category:   FailureCategoryUnknown,
+	}
+
+	// the state and exit status are strong signals of infrastructure problems
+	switch {
+	case job.State == "timed_out":
+		classification.Category = FailureCategoryInfrastructure
+		classification.Confidence = 0.9
+		classification.Evidence = append(classification.Evidence, "job timed out")
+		return classification
+	case job.State == "expired":
+		classification.Category = FailureCategoryInfrastructure
+		classification.Confidence = 0.9
+		classification.Evidence = append(classification.Evidence, "job expired before an agent accepted it")
+		return classification
+	case job.ExitStatus != nil && *job.ExitStatus == -1:
+		classification.Category = FailureCategoryInfrastructure
+		classification.Confidence = 0.85
+		classification.Evidence = append(classification.Evidence, "exit status -1 (agent lost or job was stopped)")
+		return classification
+	}
+
+	matches := map[string][]string{}
+	for _, line := range lines {
+		for _, sig := range failureSignatures {
+			if sig.pattern.MatchString(line) {
+				matches[sig.category] = append(matches[sig.category], line)
+				break
+			}
+		}
+	}
+
+	// pick the category with the most matching lines, preferring the signature order on ties
+	best := 0
+	for _, sig := range failureSignatures {
+		if n := len(matches[sig.category]); n > best {
+			best = n
+			classification.Category = sig.category
+		}
+	}
+
+	if job.ExitStatus != nil && *job.ExitStatus == 137 && classification.Category != FailureCategoryInfrastructure && best < 3 {
+		classification.Category = FailureCategoryInfrastructure
+		classification.Confidence = 0.7
+		classification.Evidence = append(classification.Evidence, "exit status 137 (killed, likely out of memory)")
+		return classification
+	}
+
+	if best == 0 {
+		return classification
+	}
+
+	classification.Confidence = min(0.5+0.1*float64(best), 0.9)
+	evidence := matches[classification.Category]
+	classification.Evidence = evidence[max(len(evidence)-maxFailureEvidence, 0):]
+
+	return classification
+}
+
+func jobLabel(job buildkite.Job) string {
+	if job.Label != "" {
+		return job.Label
+	}
+	return job.Name
+}
+
+func ClassifyBuildFailure(client BuildsClient, logsClient BuildkiteLogsClient, redactor *redact.Redactor) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[ClassifyBuildFailureArgs], scopes []string) {
+	return mcp.NewTool("classify_build_failure",
+			mcp.WithDescription("Heuristically classify each failed job in a build as an infrastructure (agent lost, timeout, out of memory), dependency (network or registry errors), compile, or test failure, based on exit status and log signatures. Returns a confidence and the log lines used as evidence for each job. At most 20 failed jobs are classified, the IDs of the rest are listed as unclassified."),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("build_number",
+				mcp.Required(),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Classify Build Failure",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args ClassifyBuildFailureArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.ClassifyBuildFailure")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if args.BuildNumber == "" {
+				return mcp.NewToolResultError("build_number parameter is required"), nil
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+				attribute.String("build_number", args.BuildNumber),
+			)
+
+			build, _, err := client.Get(ctx, args.OrgSlug, args.PipelineSlug, args.BuildNumber, &buildkite.BuildGetOptions{})
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			result := BuildFailureClassification{
+				BuildNumber: build.Number,
+				State:       build.State,
+				ByCategory:  map[string]int{},
+				Jobs:        []FailureClassification{},
+			}
+
+			var failed []buildkite.Job
+			for _, job := range build.Jobs {
+				if isFailedJob(job) {
+					failed = append(failed, job)
+				}
+			}
+			if len(failed) > maxClassifiedJobs {
+				for _, job := range failed[maxClassifiedJobs:] {
+					result.Unclassified = append(result.Unclassified, job.ID)
+				}
+				result.Note = fmt.Sprintf("only the first %d of %d failed jobs were classified, use should_retry or tail_logs for the others", maxClassifiedJobs, len(failed))
+				failed = failed[:maxClassifiedJobs]
+			}
+
+			result.Jobs = make([]FailureClassification, len(failed))
+
+			var g errgroup.Group
+			g.SetLimit(classifyJobsConcurrency)
+			for i, job := range failed {
+				g.Go(func() error {
+					lines, err := tailLogLines(ctx, logsClient, JobLogsBaseParams{
+						OrgSlug:      args.OrgSlug,
+						PipelineSlug: args.PipelineSlug,
+						BuildNumber:  args.BuildNumber,
+						JobID:        job.ID,
+					}, failureLogTailLines)
+
+					classification := classifyJobFailure(job, lines)
+					if err != nil {
+						classification.Note = fmt.Sprintf("log unavailable, classified from job state only: %v", err)
+					}
+
+					for j, line := range classification.Evidence {
+						classification.Evidence[j], _ = redactor.Redact(line)
+					}
+
+					result.Jobs[i] = classification
+					return nil
+				})
+			}
+			_ = g.Wait()
+
+			for _, classification := range result.Jobs {
+				result.ByCategory[classification.Category]++
+			}
+
+			span.SetAttributes(
+				attribute.Int("item_count", len(result.Jobs)),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_builds", "read_build_logs"}
+}
+
+const (
+	RetryRecommendationRetry       = "retry"
+	RetryRecommendationDontRetry   = "dont_retry"
+	RetryRecommendationFixRequired = "fix_required"
+
+	// transient failures which keep happening after this many retries need fixing
+	maxRecommendedRetries = 2
+)
+
+// RetryRecommendation describes whether a failed job is worth retrying
+type RetryRecommendation struct {
+	JobID          string                `json:"job_id"`
+	Label          string                `json:"label"`
+	State          string                `json:"state"`
+	ExitStatus     *int                  `json:"exit_status,omitempty"`
+	RetriesCount   int                   `json:"retries_count"`
+	Recommendation string                `json:"recommendation"`
+	Reasons        []string              `json:"reasons"`
+	Classification FailureClassification `json:"classification"`
+}
+
+type ShouldRetryArgs struct {
+	OrgSlug      string `json:"org_slug"`
+	PipelineSlug string `json:"pipeline_slug"`
+	BuildNumber  string `json:"build_number"`
+	JobID        string `json:"job_id"`
+}
+
+// recommendRetry decides whether to retry a job from its retry history and failure classification
+func recommendRetry(job buildkite.Job, classification FailureClassification) RetryRecommendation {
+	recommendation := RetryRecommendation{
+		JobID:          job.ID,
+		Label:          jobLabel(job),
+		State:          job.State,
+		ExitStatus:     job.ExitStatus,
+		RetriesCount:   job.RetriesCount,
+		Classification: classification,
+	}
+
+	dontRetry := func(reason string) RetryRecommendation {
+		recommendation.Recommendation = RetryRecommendationDontRetry
+		recommendation.Reasons = append(recommendation.Reasons, reason)
+		return recommendation
+	}
+
+	switch {
+	case !isFailedJob(job):
+		return dontRetry(fmt.Sprintf("job is %s, not failed", job.State))
+	case job.Retried:
+		return dontRetry(fmt.Sprintf("job has already been retried as job %s", job.RetriedInJobID))
+	}
+
+	switch classification.Category {
+	case FailureCategoryInfrastructure, FailureCategoryDependency:
+		if job.RetriesCount >= maxRecommendedRetries {
+			recommendation.Recommendation = RetryRecommendationFixRequired
+			recommendation.Reasons = append(recommendation.Reasons,
+				fmt.Sprintf("%s failure has persisted across %d retries", classification.Category, job.RetriesCount))
+			return recommendation
+		}
+
+		recommendation.Recommendation = RetryRecommendationRetry
+		recommendation.Reasons = append(recommendation.Reasons,
+			fmt.Sprintf("%s failures are usually transient", classification.Category))
+	case FailureCategoryCompile, FailureCategoryTest:
+		recommendation.Recommendation = RetryRecommendationFixRequired
+		recommendation.Reasons = append(recommendation.Reasons,
+			fmt.Sprintf("%s failures are caused by the code and will fail again when retried", classification.Category))
+	default:
+		return dontRetry("no known transient failure signature was found, investigate the log before retrying")
+	}
+
+	if job.RetriesCount > 0 {
+		recommendation.Reasons = append(recommendation.Reasons, fmt.Sprintf("job has been retried %d time(s)", job.RetriesCount))
+	}
+
+	return recommendation
+}
+
+func ShouldRetry(client BuildsClient, logsClient BuildkiteLogsClient, redactor *redact.Redactor) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[ShouldRetryArgs], scopes []string) {
+	return mcp.NewTool("should_retry",
+			mcp.WithDescription("Recommend whether a failed job should be retried, based on its exit status, retry history, and known transient failure signatures in its log. Returns 'retry', 'dont_retry', or 'fix_required' with the reasons and supporting evidence."),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("build_number",
+				mcp.Required(),
+			),
+			mcp.WithString("job_id",
+				mcp.Required(),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Should Retry Job",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args ShouldRetryArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.ShouldRetry")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if args.BuildNumber == "" {
+				return mcp.NewToolResultError("build_number parameter is required"), nil
+			}
+			if args.JobID == "" {
+				return mcp.NewToolResultError("job_id parameter is required"), nil
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+				attribute.String("build_number", args.BuildNumber),
+				attribute.String("job_id", args.JobID),
+			)
+
+			build, _, err := client.Get(ctx, args.OrgSlug, args.PipelineSlug, args.BuildNumber, &buildkite.BuildGetOptions{})
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			idx := slices.IndexFunc(build.Jobs, func(job buildkite.Job) bool { return job.ID == args.JobID })
+			if idx == -1 {
+				return mcp.NewToolResultError(fmt.Sprintf("job %s not found in build %s", args.JobID, args.BuildNumber)), nil
+			}
+			job := build.Jobs[idx]
+
+			var classification FailureClassification
+			if isFailedJob(job) {
+				lines, err := tailLogLines(ctx, logsClient, JobLogsBaseParams{
+					OrgSlug:      args.OrgSlug,
+					PipelineSlug: args.PipelineSlug,
+					BuildNumber:  args.BuildNumber,
+					JobID:        job.ID,
+				}, failureLogTailLines)
+
+				classification = classifyJobFailure(job, lines)
+				if err != nil {
+					classification.Note = fmt.Sprintf("log unavailable, classified from job state only: %v", err)
+				}
+
+				for i, line := range classification.Evidence {
+					classification.Evidence[i], _ = redactor.Redact(line)
+				}
+			}
+
+			result := recommendRetry(job, classification)
+
+			span.SetAttributes(
+				attribute.String("recommendation", result.Recommendation),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_builds", "read_build_logs"}
+}
@@ -0,0 +1,276 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyJobFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		job      buildkite.Job
+		lines    []string
+		category string
+	}{
+		{
+			name:     "timed out",
+			job:      buildkite.Job{State: "timed_out"},
+			category: FailureCategoryInfrastructure,
+		},
+		{
+			name:     "agent lost",
+			job:      buildkite.Job{State: "failed", ExitStatus: intPtr(-1)},
+			category: FailureCategoryInfrastructure,
+		},
+		{
+			name:     "out of memory exit status",
+			job:      buildkite.Job{State: "failed", ExitStatus: intPtr(137)},
+			lines:    []string{"running tests"},
+			category: FailureCategoryInfrastructure,
+		},
+		{
+			name:     "registry error",
+			job:      buildkite.Job{State: "failed", ExitStatus: intPtr(1)},
+			lines:    []string{"npm ERR! network request failed", "dial tcp 10.0.0.1:443: i/o timeout"},
+			category: FailureCategoryDependency,
+		},
+		{
+			name:     "compile error",
+			job:      buildkite.Job{State: "failed", ExitStatus: intPtr(2)},
+			lines:    []string{"# example.com/app", "./main.go:10:2: undefined: foo"},
+			category: FailureCategoryCompile,
+		},
+		{
+			name:     "test failure",
+			job:      buildkite.Job{State: "failed", ExitStatus: intPtr(1)},
+			lines:    []string{"--- FAIL: TestThing (0.00s)", "FAIL\texample.com/app\t0.123s"},
+			category: FailureCategoryTest,
+		},
+		{
+			name:     "failure count",
+			job:      buildkite.Job{State: "failed", ExitStatus: intPtr(1)},
+			lines:    []string{"42 examples, 3 failures"},
+			category: FailureCategoryTest,
+		},
+		{
+			// a summary of a passing test run isn't evidence of a test failure
+			name:     "zero failures",
+			job:      buildkite.Job{State: "failed", ExitStatus: intPtr(1)},
+			lines:    []string{"42 examples, 0 failures", "10 tests, 0 failed"},
+			category: FailureCategoryUnknown,
+		},
+		{
+			name:     "no signatures",
+			job:      buildkite.Job{State: "failed", ExitStatus: intPtr(1)},
+			lines:    []string{"done"},
+			category: FailureCategoryUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := classifyJobFailure(tt.job, tt.lines)
+			require.Equal(t, tt.category, result.Category)
+			if tt.category == FailureCategoryUnknown {
+				require.Zero(t, result.Confidence)
+			} else {
+				require.NotEmpty(t, result.Evidence)
+				require.Greater(t, result.Confidence, 0.0)
+			}
+		})
+	}
+}
+
+func TestIsFailedJob(t *testing.T) {
+	assert := require.New(t)
+
+	assert.True(isFailedJob(buildkite.Job{Type: "script", State: "failed"}))
+	assert.True(isFailedJob(buildkite.Job{Type: "script", State: "timed_out"}))
+	assert.False(isFailedJob(buildkite.Job{Type: "script", State: "passed", ExitStatus: intPtr(0)}))
+	assert.False(isFailedJob(buildkite.Job{Type: "waiter"}))
+}
+
+func TestClassifyBuildFailure(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	client := &MockBuildsClient{
+		GetFunc: func(ctx context.Context, org string, pipeline string, id string, opt *buildkite.BuildGetOptions) (buildkite.Build, *buildkite.Response, error) {
+			return buildkite.Build{
+				Number: 42,
+				State:  "failed",
+				Jobs: []buildkite.Job{
+					{ID: "job-1", Type: "script", Label: "test", State: "passed", ExitStatus: intPtr(0)},
+					{ID: "job-2", Type: "script", Label: "deploy", State: "timed_out"},
+				},
+			}, &buildkite.Response{}, nil
+		},
+	}
+	logsClient := &MockBuildkiteLogsClient{
+		DownloadAndCacheFunc: func(ctx context.Context, org, pipeline, build, job string, cacheTTL time.Duration, forceRefresh bool) (string, error) {
+			return "", errors.New("download failed")
+		},
+	}
+
+	_, handler, scopes := ClassifyBuildFailure(client, logsClient, nil)
+	assert.Equal([]string{"read_builds", "read_build_logs"}, scopes)
+
+	result, err := handler(ctx, mcp.CallToolRequest{}, ClassifyBuildFailureArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+		BuildNumber:  "42",
+	})
+	assert.NoError(err)
+
+	textContent := getTextResult(t, result)
+
+	var classification BuildFailureClassification
+	assert.NoError(json.Unmarshal([]byte(textContent.Text), &classification))
+	assert.Len(classification.Jobs, 1)
+	assert.Equal("job-2", classification.Jobs[0].JobID)
+	assert.Equal(FailureCategoryInfrastructure, classification.Jobs[0].Category)
+	assert.Contains(classification.Jobs[0].Note, "log unavailable")
+	assert.Equal(map[string]int{FailureCategoryInfrastructure: 1}, classification.ByCategory)
+}
+
+func TestClassifyBuildFailureLimitsJobs(t *testing.T) {
+	assert := require.New(t)
+
+	var jobs []buildkite.Job
+	for i := range maxClassifiedJobs + 5 {
+		jobs = append(jobs, buildkite.Job{ID: fmt.Sprintf("job-%d", i), Type: "script", Label: "test", State: "failed", ExitStatus: intPtr(1)})
+	}
+
+	client := &MockBuildsClient{
+		GetFunc: func(ctx context.Context, org string, pipeline string, id string, opt *buildkite.BuildGetOptions) (buildkite.Build, *buildkite.Response, error) {
+			return buildkite.Build{Number: 42, State: "failed", Jobs: jobs}, &buildkite.Response{}, nil
+		},
+	}
+
+	var downloads, inFlight, maxInFlight atomic.Int32
+	logsClient := &MockBuildkiteLogsClient{
+		DownloadAndCacheFunc: func(ctx context.Context, org, pipeline, build, job string, cacheTTL time.Duration, forceRefresh bool) (string, error) {
+			downloads.Add(1)
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				peak := maxInFlight.Load()
+				if n <= peak || maxInFlight.CompareAndSwap(peak, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			return "", errors.New("download failed")
+		},
+	}
+
+	_, handler, _ := ClassifyBuildFailure(client, logsClient, nil)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, ClassifyBuildFailureArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+		BuildNumber:  "42",
+	})
+	assert.NoError(err)
+
+	var classification BuildFailureClassification
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &classification))
+	assert.Len(classification.Jobs, maxClassifiedJobs)
+	assert.Equal("job-0", classification.Jobs[0].JobID)
+	assert.Equal([]string{"job-20", "job-21", "job-22", "job-23", "job-24"}, classification.Unclassified)
+	assert.Contains(classification.Note, "only the first 20 of 25 failed jobs")
+	assert.Equal(map[string]int{FailureCategoryUnknown: maxClassifiedJobs}, classification.ByCategory)
+
+	assert.Equal(int32(maxClassifiedJobs), downloads.Load())
+	assert.LessOrEqual(maxInFlight.Load(), int32(classifyJobsConcurrency))
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestRecommendRetry(t *testing.T) {
+	failed := buildkite.Job{ID: "job-1", Type: "script", State: "failed", ExitStatus: intPtr(1)}
+
+	tests := []struct {
+		name           string
+		job            buildkite.Job
+		category       string
+		recommendation string
+	}{
+		{
+			name:           "passed job",
+			job:            buildkite.Job{Type: "script", State: "passed", ExitStatus: intPtr(0)},
+			recommendation: RetryRecommendationDontRetry,
+		},
+		{
+			name:           "already retried",
+			job:            buildkite.Job{Type: "script", State: "failed", Retried: true, RetriedInJobID: "job-2"},
+			category:       FailureCategoryDependency,
+			recommendation: RetryRecommendationDontRetry,
+		},
+		{
+			name:           "transient failure",
+			job:            failed,
+			category:       FailureCategoryDependency,
+			recommendation: RetryRecommendationRetry,
+		},
+		{
+			name:           "transient failure retried too often",
+			job:            buildkite.Job{Type: "script", State: "failed", RetriesCount: 2},
+			category:       FailureCategoryInfrastructure,
+			recommendation: RetryRecommendationFixRequired,
+		},
+		{
+			name:           "test failure",
+			job:            failed,
+			category:       FailureCategoryTest,
+			recommendation: RetryRecommendationFixRequired,
+		},
+		{
+			name:           "unknown failure",
+			job:            failed,
+			category:       FailureCategoryUnknown,
+			recommendation: RetryRecommendationDontRetry,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := recommendRetry(tt.job, FailureClassification{Category: tt.category})
+			require.Equal(t, tt.recommendation, result.Recommendation)
+			require.NotEmpty(t, result.Reasons)
+		})
+	}
+}
+
+func TestShouldRetryJobNotFound(t *testing.T) {
+	assert := require.New(t)
+
+	client := &MockBuildsClient{
+		GetFunc: func(ctx context.Context, org string, pipeline string, id string, opt *buildkite.BuildGetOptions) (buildkite.Build, *buildkite.Response, error) {
+			return buildkite.Build{Jobs: []buildkite.Job{{ID: "job-1"}}}, &buildkite.Response{}, nil
+		},
+	}
+
+	_, handler, _ := ShouldRetry(client, &MockBuildkiteLogsClient{}, nil)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, ShouldRetryArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+		BuildNumber:  "1",
+		JobID:        "missing",
+	})
+	assert.NoError(err)
+	assert.True(result.IsError)
+	assert.Contains(getTextResult(t, result).Text, "job missing not found")
+}
@@ -0,0 +1,40 @@
+package buildkite
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// fetchConcurrency is how many requests fetchAll makes at once, enough to hide the latency of
+// fetching a pipeline's recent builds one by one without using much of the rate limit at once
+const fetchConcurrency = 8
+
+// fetched is the value fetched for a key, or the error fetching it
+type fetched[T any] struct {
+	Value T
+	Err   error
+}
+
+// fetchAll fetches the value of each key with at most concurrency fetches at once, returning them
+// in the order of the keys. A failed fetch doesn't stop the others, which only stop early when ctx
+// is done.
+func fetchAll[K, T any](ctx context.Context, keys []K, concurrency int, fetch func(ctx context.Context, key K) (T, error)) []fetched[T] {
+	results := make([]fetched[T], len(keys))
+
+	var g errgroup.Group
+	g.SetLimit(concurrency)
+	for i, key := range keys {
+		g.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				results[i].Err = err
+				return nil
+			}
+			results[i].Value, results[i].Err = fetch(ctx, key)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	return results
+}
@@ -0,0 +1,247 @@
+package buildkite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	StepOutcomePassed  = "passed"
+	StepOutcomeFailed  = "failed"
+	StepOutcomeUnknown = "unknown"
+
+	defaultBisectMaxBuilds = 50
+	maxBisectBuilds        = 500
+)
+
+// finishedBuildStates are the build states which have a final outcome
+var finishedBuildStates = []string{"passed", "failed", "canceled"}
+
+type FindFirstFailureArgs struct {
+	OrgSlug      string `json:"org_slug"`
+	PipelineSlug string `json:"pipeline_slug"`
+	Branch       string `json:"branch"`
+	Step         string `json:"step,omitempty"`
+	MaxBuilds    int    `json:"max_builds,omitempty"`
+}
+
+// BuildRef identifies a build and the commit it ran against
+type BuildRef struct {
+	Number    int                  `json:"number"`
+	State     string               `json:"state"`
+	Commit    string               `json:"commit"`
+	Message   string               `json:"message,omitempty"`
+	WebURL    string               `json:"web_url,omitempty"`
+	CreatedAt *buildkite.Timestamp `json:"created_at,omitempty"`
+}
+
+// FirstFailure is the result of walking back through a branch's builds to find where a failure started
+type FirstFailure struct {
+	Branch         string       `json:"branch"`
+	Step           string       `json:"step,omitempty"`
+	LatestFailure  *BuildRef    `json:"latest_failure,omitempty"`
+	FirstFailure   *BuildRef    `json:"first_failure,omitempty"`
+	LastPassing    *BuildRef    `json:"last_passing,omitempty"`
+	FailingBuilds  int          `json:"failing_builds"`
+	CommitRange    *CommitRange `json:"culprit_commit_range,omitempty"`
+	BuildsSearched int          `json:"builds_searched"`
+	Note           string       `json:"note,omitempty"`
+}
+
+func newBuildRef(build buildkite.Build) *BuildRef {
+	return &BuildRef{
+		Number:    build.Number,
+		State:     build.State,
+		Commit:    build.Commit,
+		Message:   build.Message,
+		WebURL:    build.WebURL,
+		CreatedAt: build.CreatedAt,
+	}
+}
+
+// stepJobs returns the jobs in a build which belong to the step, matched on step key or label
+func stepJobs(build buildkite.Build, step string) []buildkite.Job {
+	var jobs []buildkite.Job
+	for _, job := range build.Jobs {
+		if job.Type != "" && job.Type != "script" {
+			continue
+		}
+		if job.StepKey == step || jobLabel(job) == step {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs
+}
+
+// stepOutcome reports whether a step passed or failed in a build, using the final attempt of each of
+// its jobs. With no step the outcome of the whole build is used.
+func stepOutcome(build buildkite.Build, step string) string {
+	if step == "" {
+		switch build.State {
+		case "passed":
+			return StepOutcomePassed
+		case "failed":
+			return StepOutcomeFailed
+		}
+		return StepOutcomeUnknown
+	}
+
+	outcome := StepOutcomeUnknown
+	for _, job := range stepJobs(build, step) {
+		if job.Retried {
+			continue
+		}
+		switch {
+		case isFailedJob(job) && !job.SoftFailed:
+			return StepOutcomeFailed
+		case job.State == "passed" || job.SoftFailed:
+			outcome = StepOutcomePassed
+		}
+	}
+	return outcome
+}
+
+// listRecentBuilds returns up to limit of the most recent finished builds, newest first
+func listRecentBuilds(ctx context.Context, client BuildsClient, org, pipeline, branch string, limit int) ([]buildkite.Build, error) {
+	options := &buildkite.BuildsListOptions{
+		State:       finishedBuildStates,
+		ListOptions: buildkite.ListOptions{PerPage: min(limit, 100)},
+	}
+	if branch != "" {
+		options.Branch = []string{branch}
+	}
+
+	var builds []buildkite.Build
+	for len(builds) < limit {
+		page, resp, err := client.ListByPipeline(ctx, org, pipeline, options)
+		if err != nil {
+			return nil, err
+		}
+
+		builds = append(builds, page...)
+
+		if resp == nil || resp.NextPage == 0 || len(page) == 0 {
+			break
+		}
+		options.Page = resp.NextPage
+	}
+
+	return builds[:min(len(builds), limit)], nil
+}
+
+// findFirstFailure walks back from the most recent failure through a newest-first list of builds
+// until it reaches a passing build, ignoring builds where the step has no outcome
+func findFirstFailure(builds []buildkite.Build, step string) FirstFailure {
+	result := FirstFailure{Step: step, BuildsSearched: len(builds)}
+
+	for _, build := range builds {
+		switch stepOutcome(build, step) {
+		case StepOutcomeFailed:
+			if result.LatestFailure == nil {
+				result.LatestFailure = newBuildRef(build)
+			}
+			result.FirstFailure = newBuildRef(build)
+			result.FailingBuilds++
+		case StepOutcomePassed:
+			if result.LatestFailure != nil {
+				result.LastPassing = newBuildRef(build)
+				return result
+			}
+		}
+	}
+
+	return result
+}
+
+func FindFirstFailure(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[FindFirstFailureArgs], scopes []string) {
+	return mcp.NewTool("find_first_failure",
+			mcp.WithDescription("Walk backwards through the finished builds on a branch from the most recent failure to find the first build where a step (or the whole build) started failing. Returns the first failing build, the last passing build, and the culprit commit range between them."),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("branch",
+				mcp.Required(),
+			),
+			mcp.WithString("step",
+				mcp.Description("Step key or label to track. When omitted the overall build state is used"),
+			),
+			mcp.WithNumber("max_builds",
+				mcp.Description("Maximum number of builds to search (default 50, max 500)"),
+				mcp.Min(1),
+				mcp.Max(maxBisectBuilds),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Find First Failure",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args FindFirstFailureArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.FindFirstFailure")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if args.Branch == "" {
+				return mcp.NewToolResultError("branch parameter is required"), nil
+			}
+			if args.MaxBuilds <= 0 {
+				args.MaxBuilds = defaultBisectMaxBuilds
+			}
+			args.MaxBuilds = min(args.MaxBuilds, maxBisectBuilds)
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+				attribute.String("branch", args.Branch),
+				attribute.String("step", args.Step),
+				attribute.Int("max_builds", args.MaxBuilds),
+			)
+
+			builds, err := listRecentBuilds(ctx, client, args.OrgSlug, args.PipelineSlug, args.Branch, args.MaxBuilds)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			result := findFirstFailure(builds, args.Step)
+			result.Branch = args.Branch
+
+			switch {
+			case result.LatestFailure == nil:
+				result.Note = fmt.Sprintf("no failures found in the last %d finished builds", len(builds))
+			case result.LastPassing == nil:
+				result.Note = fmt.Sprintf("no passing build found in the last %d finished builds, the failure started earlier; increase max_builds to search further", len(builds))
+			default:
+				result.CommitRange = &CommitRange{
+					PreviousBuildNumber: result.LastPassing.Number,
+					PreviousBuildState:  result.LastPassing.State,
+					From:                result.LastPassing.Commit,
+					To:                  result.FirstFailure.Commit,
+				}
+				for _, build := range builds {
+					if build.Pipeline != nil {
+						result.CommitRange.CompareURL = compareURL(build.Pipeline.Repository, result.LastPassing.Commit, result.FirstFailure.Commit)
+						break
+					}
+				}
+			}
+
+			span.SetAttributes(
+				attribute.Int("builds_searched", result.BuildsSearched),
+				attribute.Int("failing_builds", result.FailingBuilds),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_builds"}
+}
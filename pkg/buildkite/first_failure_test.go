@@ -0,0 +1,80 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepOutcome(t *testing.T) {
+	assert := require.New(t)
+
+	build := buildkite.Build{
+		State: "failed",
+		Jobs: []buildkite.Job{
+			{Type: "script", StepKey: "lint", State: "passed"},
+			{Type: "script", StepKey: "test", State: "failed", Retried: true},
+			{Type: "script", StepKey: "test", State: "passed"},
+			{Type: "script", StepKey: "deploy", State: "failed"},
+			{Type: "script", StepKey: "audit", State: "failed", SoftFailed: true},
+		},
+	}
+
+	assert.Equal(StepOutcomeFailed, stepOutcome(build, ""))
+	assert.Equal(StepOutcomePassed, stepOutcome(build, "lint"))
+	assert.Equal(StepOutcomePassed, stepOutcome(build, "test"))
+	assert.Equal(StepOutcomeFailed, stepOutcome(build, "deploy"))
+	assert.Equal(StepOutcomePassed, stepOutcome(build, "audit"))
+	assert.Equal(StepOutcomeUnknown, stepOutcome(build, "missing"))
+}
+
+func TestFindFirstFailure(t *testing.T) {
+	assert := require.New(t)
+
+	step := func(number int, commit, state string) buildkite.Build {
+		return buildkite.Build{
+			Number:   number,
+			Commit:   commit,
+			State:    state,
+			Jobs:     []buildkite.Job{{Type: "script", StepKey: "test", State: state}},
+			Pipeline: &buildkite.Pipeline{Repository: "https://github.com/acme/app.git"},
+		}
+	}
+
+	client := &MockBuildsClient{
+		ListByPipelineFunc: func(ctx context.Context, org string, pipeline string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error) {
+			assert.Equal([]string{"main"}, opt.Branch)
+			return []buildkite.Build{
+				step(10, "e", "failed"),
+				step(9, "d", "failed"),
+				{Number: 8, Commit: "c", State: "canceled"},
+				step(7, "b", "failed"),
+				step(6, "a", "passed"),
+				step(5, "z", "failed"),
+			}, &buildkite.Response{}, nil
+		},
+	}
+
+	_, handler, scopes := FindFirstFailure(client)
+	assert.Equal([]string{"read_builds"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, FindFirstFailureArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+		Branch:       "main",
+		Step:         "test",
+	})
+	assert.NoError(err)
+
+	var firstFailure FirstFailure
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &firstFailure))
+	assert.Equal(10, firstFailure.LatestFailure.Number)
+	assert.Equal(7, firstFailure.FirstFailure.Number)
+	assert.Equal(6, firstFailure.LastPassing.Number)
+	assert.Equal(3, firstFailure.FailingBuilds)
+	assert.Equal("https://github.com/acme/app/compare/a...b", firstFailure.CommitRange.CompareURL)
+}
@@ -0,0 +1,215 @@
+package buildkite
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	defaultFlakyStepsWindow = 50
+	maxFlakyStepsWindow     = 500
+	maxFlakyStepExamples    = 5
+)
+
+type DetectFlakyStepsArgs struct {
+	OrgSlug      string `json:"org_slug"`
+	PipelineSlug string `json:"pipeline_slug"`
+	Branch       string `json:"branch,omitempty"`
+	Window       int    `json:"window,omitempty"`
+}
+
+// FlakyStep describes a step which both passed and failed without a change to the code
+type FlakyStep struct {
+	Step          string  `json:"step"`
+	Label         string  `json:"label"`
+	Runs          int     `json:"runs"`
+	RetryPasses   int     `json:"retry_passes"`
+	CommitFlips   int     `json:"commit_flips"`
+	FlakeRate     float64 `json:"flake_rate"`
+	ExampleBuilds []int   `json:"example_builds"`
+}
+
+type FlakyStepsResult struct {
+	BuildsAnalyzed int         `json:"builds_analyzed"`
+	Steps          []FlakyStep `json:"steps"`
+}
+
+// stepIdentity identifies a step across builds, using the step key when there is one
+func stepIdentity(job buildkite.Job) string {
+	if job.StepKey != "" {
+		return job.StepKey
+	}
+	return jobLabel(job)
+}
+
+// detectFlakySteps finds steps which failed and then passed on retry within a build, or which both
+// passed and failed across builds of the same commit, ranked by flake rate
+func detectFlakySteps(builds []buildkite.Build) []FlakyStep {
+	steps := map[string]*FlakyStep{}
+	// the first build number seen for each step, commit and outcome
+	commitBuilds := map[string]map[string]map[string]int{}
+
+	for _, build := range builds {
+		seen := map[string]bool{}
+		for _, job := range build.Jobs {
+			if job.Type != "" && job.Type != "script" {
+				continue
+			}
+
+			id := stepIdentity(job)
+			if id == "" || seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			step, ok := steps[id]
+			if !ok {
+				step = &FlakyStep{Step: id, Label: jobLabel(job), ExampleBuilds: []int{}}
+				steps[id] = step
+			}
+
+			outcome := stepOutcome(build, id)
+			if outcome == StepOutcomeUnknown {
+				continue
+			}
+			step.Runs++
+
+			if outcome == StepOutcomePassed && retriedAfterFailure(build, id) {
+				step.RetryPasses++
+				step.addExample(build.Number)
+			}
+
+			if build.Commit == "" || build.Commit == "HEAD" {
+				continue
+			}
+			if commitBuilds[id] == nil {
+				commitBuilds[id] = map[string]map[string]int{}
+			}
+			if commitBuilds[id][build.Commit] == nil {
+				commitBuilds[id][build.Commit] = map[string]int{}
+			}
+			if _, ok := commitBuilds[id][build.Commit][outcome]; !ok {
+				commitBuilds[id][build.Commit][outcome] = build.Number
+			}
+		}
+	}
+
+	for id, commits := range commitBuilds {
+		for _, outcomes := range commits {
+			passed, passedOK := outcomes[StepOutcomePassed]
+			failed, failedOK := outcomes[StepOutcomeFailed]
+			if passedOK && failedOK {
+				steps[id].CommitFlips++
+				steps[id].addExample(failed)
+				steps[id].addExample(passed)
+			}
+		}
+	}
+
+	var flaky []FlakyStep
+	for _, step := range steps {
+		if step.RetryPasses == 0 && step.CommitFlips == 0 {
+			continue
+		}
+		step.FlakeRate = min(float64(step.RetryPasses+step.CommitFlips)/float64(step.Runs), 1)
+		slices.Sort(step.ExampleBuilds)
+		flaky = append(flaky, *step)
+	}
+
+	slices.SortFunc(flaky, func(a, b FlakyStep) int {
+		return cmp.Or(
+			cmp.Compare(b.FlakeRate, a.FlakeRate),
+			cmp.Compare(b.Runs, a.Runs),
+			cmp.Compare(a.Step, b.Step),
+		)
+	})
+
+	return flaky
+}
+
+func (s *FlakyStep) addExample(buildNumber int) {
+	if len(s.ExampleBuilds) < maxFlakyStepExamples && !slices.Contains(s.ExampleBuilds, buildNumber) {
+		s.ExampleBuilds = append(s.ExampleBuilds, buildNumber)
+	}
+}
+
+// retriedAfterFailure returns true when a failed attempt of the step was retried
+func retriedAfterFailure(build buildkite.Build, step string) bool {
+	for _, job := range stepJobs(build, step) {
+		if job.Retried && isFailedJob(job) {
+			return true
+		}
+	}
+	return false
+}
+
+func DetectFlakySteps(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[DetectFlakyStepsArgs], scopes []string) {
+	return mcp.NewTool("detect_flaky_steps",
+			mcp.WithDescription("Identify flaky steps (jobs, rather than individual tests) across a pipeline's recent finished builds. A step is flaky when a failed attempt passed on retry, or when it both passed and failed on the same commit. Results are ranked by flake rate with example build numbers."),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("branch",
+				mcp.Description("Only analyze builds on this branch"),
+			),
+			mcp.WithNumber("window",
+				mcp.Description("Number of recent builds to analyze (default 50, max 500)"),
+				mcp.Min(1),
+				mcp.Max(maxFlakyStepsWindow),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Detect Flaky Steps",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args DetectFlakyStepsArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.DetectFlakySteps")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if args.Window <= 0 {
+				args.Window = defaultFlakyStepsWindow
+			}
+			args.Window = min(args.Window, maxFlakyStepsWindow)
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+				attribute.String("branch", args.Branch),
+				attribute.Int("window", args.Window),
+			)
+
+			builds, err := listRecentBuilds(ctx, client, args.OrgSlug, args.PipelineSlug, args.Branch, args.Window)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			result := FlakyStepsResult{
+				BuildsAnalyzed: len(builds),
+				Steps:          detectFlakySteps(builds),
+			}
+			if result.Steps == nil {
+				result.Steps = []FlakyStep{}
+			}
+
+			span.SetAttributes(
+				attribute.Int("item_count", len(result.Steps)),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_builds"}
+}
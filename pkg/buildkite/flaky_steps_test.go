@@ -0,0 +1,71 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectFlakySteps(t *testing.T) {
+	assert := require.New(t)
+
+	builds := []buildkite.Build{
+		{Number: 4, Commit: "b", Jobs: []buildkite.Job{
+			{Type: "script", StepKey: "test", State: "failed", Retried: true},
+			{Type: "script", StepKey: "test", State: "passed"},
+			{Type: "script", StepKey: "lint", State: "passed"},
+		}},
+		{Number: 3, Commit: "a", Jobs: []buildkite.Job{
+			{Type: "script", StepKey: "test", State: "passed"},
+			{Type: "script", StepKey: "lint", State: "passed"},
+		}},
+		{Number: 2, Commit: "a", Jobs: []buildkite.Job{
+			{Type: "script", StepKey: "test", State: "passed"},
+			{Type: "script", StepKey: "lint", State: "failed"},
+		}},
+		{Number: 1, Commit: "z", Jobs: []buildkite.Job{
+			{Type: "script", StepKey: "test", State: "failed"},
+			{Type: "script", StepKey: "lint", State: "passed"},
+			{Type: "script", StepKey: "deploy", State: "failed"},
+		}},
+	}
+
+	flaky := detectFlakySteps(builds)
+	assert.Len(flaky, 2)
+
+	assert.Equal("lint", flaky[0].Step)
+	assert.Equal(1, flaky[0].CommitFlips)
+	assert.Equal(0.25, flaky[0].FlakeRate)
+	assert.Equal([]int{2, 3}, flaky[0].ExampleBuilds)
+
+	assert.Equal("test", flaky[1].Step)
+	assert.Equal(1, flaky[1].RetryPasses)
+	assert.Equal([]int{4}, flaky[1].ExampleBuilds)
+}
+
+func TestDetectFlakyStepsTool(t *testing.T) {
+	assert := require.New(t)
+
+	client := &MockBuildsClient{
+		ListByPipelineFunc: func(ctx context.Context, org string, pipeline string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error) {
+			return []buildkite.Build{}, &buildkite.Response{}, nil
+		},
+	}
+
+	_, handler, scopes := DetectFlakySteps(client)
+	assert.Equal([]string{"read_builds"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, DetectFlakyStepsArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+	})
+	assert.NoError(err)
+
+	var flaky FlakyStepsResult
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &flaky))
+	assert.Empty(flaky.Steps)
+}
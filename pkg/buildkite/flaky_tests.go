@@ -0,0 +1,310 @@
+package buildkite
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	defaultFlakyTestsDays = 7
+	maxFlakyTestsDays     = 90
+	defaultFlakyTestLimit = 20
+	maxFlakyTestLimit     = 100
+
+	defaultTestStabilityDays = 7
+	maxTestStabilityDays     = 30
+)
+
+type ListFlakyTestsArgs struct {
+	OrgSlug       string `json:"org_slug"`
+	TestSuiteSlug string `json:"test_suite_slug"`
+	Days          int    `json:"days,omitempty"`
+	Query         string `json:"query,omitempty"`
+	Limit         int    `json:"limit,omitempty"`
+}
+
+// FlakyTestSummary is a test Test Engine has seen flake
+type FlakyTestSummary struct {
+	ID                   string               `json:"id"`
+	Name                 string               `json:"name"`
+	Location             string               `json:"location,omitempty"`
+	Instances            int                  `json:"instances"`
+	MostRecentInstanceAt *buildkite.Timestamp `json:"most_recent_instance_at,omitempty"`
+	WebURL               string               `json:"web_url,omitempty"`
+}
+
+type FlakyTests struct {
+	Days int `json:"days"`
+	// MatchingTests is the number of flaky tests matching the filters, of which the flakiest are listed
+	MatchingTests int                `json:"matching_tests"`
+	Tests         []FlakyTestSummary `json:"tests"`
+	Notes         []string           `json:"notes,omitempty"`
+}
+
+// filterFlakyTests returns the tests which flaked since the time and whose name or location contains
+// the query, flakiest first
+func filterFlakyTests(tests []buildkite.FlakyTest, since time.Time, query string) []buildkite.FlakyTest {
+	query = strings.ToLower(query)
+
+	var matching []buildkite.FlakyTest
+	for _, test := range tests {
+		if test.MostRecentInstanceAt == nil || test.MostRecentInstanceAt.Before(since) {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(test.Name), query) && !strings.Contains(strings.ToLower(test.Location), query) {
+			continue
+		}
+		matching = append(matching, test)
+	}
+
+	slices.SortStableFunc(matching, func(a, b buildkite.FlakyTest) int { return cmp.Compare(b.Instances, a.Instances) })
+	return matching
+}
+
+func ListFlakyTests(client FlakyTestsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[ListFlakyTestsArgs], scopes []string) {
+	return mcp.NewTool("list_flaky_tests",
+			mcp.WithDescription("List the flakiest tests of a Buildkite Test Engine suite, which have flaked within the last number of days, ordered by how many times they have flaked. Answers 'which tests are flakiest this week?'. Test Engine detects flakes across all branches, so use get_test_stability for a single branch"),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("test_suite_slug",
+				mcp.Required(),
+			),
+			mcp.WithNumber("days",
+				mcp.Description("Only include tests which flaked within this many days (default 7, max 90)"),
+				mcp.Min(1),
+				mcp.Max(maxFlakyTestsDays),
+			),
+			mcp.WithString("query",
+				mcp.Description("Only include tests whose name or location contains this text, ignoring case"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Maximum number of tests to return (default 20, max 100)"),
+				mcp.Min(1),
+				mcp.Max(maxFlakyTestLimit),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "List Flaky Tests",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args ListFlakyTestsArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.ListFlakyTests")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.TestSuiteSlug == "" {
+				return mcp.NewToolResultError("test_suite_slug parameter is required"), nil
+			}
+			if args.Days <= 0 {
+				args.Days = defaultFlakyTestsDays
+			}
+			args.Days = min(args.Days, maxFlakyTestsDays)
+			if args.Limit <= 0 {
+				args.Limit = defaultFlakyTestLimit
+			}
+			args.Limit = min(args.Limit, maxFlakyTestLimit)
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("test_suite_slug", args.TestSuiteSlug),
+				attribute.Int("days", args.Days),
+				attribute.String("query", args.Query),
+				attribute.Int("limit", args.Limit),
+			)
+
+			tests, err := listFlakyTests(ctx, client, args.OrgSlug, args.TestSuiteSlug)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			matching := filterFlakyTests(tests, time.Now().AddDate(0, 0, -args.Days), args.Query)
+
+			result := FlakyTests{
+				Days:          args.Days,
+				MatchingTests: len(matching),
+				Tests:         []FlakyTestSummary{},
+			}
+			if len(tests) == maxSuiteHealthFlakyTests {
+				result.Notes = append(result.Notes, fmt.Sprintf("only the first %d flaky tests of the suite were considered", maxSuiteHealthFlakyTests))
+			}
+
+			for _, test := range matching[:min(len(matching), args.Limit)] {
+				result.Tests = append(result.Tests, FlakyTestSummary{
+					ID:                   test.ID,
+					Name:                 test.Name,
+					Location:             test.Location,
+					Instances:            test.Instances,
+					MostRecentInstanceAt: test.MostRecentInstanceAt,
+					WebURL:               test.WebURL,
+				})
+			}
+
+			span.SetAttributes(
+				attribute.Int("item_count", len(result.Tests)),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_suites"}
+}
+
+type GetTestStabilityArgs struct {
+	OrgSlug       string `json:"org_slug"`
+	TestSuiteSlug string `json:"test_suite_slug"`
+	TestID        string `json:"test_id"`
+	Branch        string `json:"branch,omitempty"`
+	Days          int    `json:"days,omitempty"`
+}
+
+type TestStability struct {
+	TestReliability
+	Branch string `json:"branch,omitempty"`
+	Days   int    `json:"days"`
+	// Flips counts how often the test changed between passing and failing from one run to the next
+	Flips    int     `json:"flips"`
+	FlipRate float64 `json:"flip_rate"`
+	// Flaky is whether Test Engine has detected the test flaking, on any branch
+	Flaky          bool     `json:"flaky"`
+	FlakyInstances int      `json:"flaky_instances,omitempty"`
+	Notes          []string `json:"notes,omitempty"`
+}
+
+// countFlips counts the changes between passing and failing across runs, which are ordered newest first
+func countFlips(runs []buildkite.TestRun, failures map[string]buildkite.FailedExecution) int {
+	flips := 0
+	for i := 1; i < len(runs); i++ {
+		_, failed := failures[runs[i].ID]
+		_, previousFailed := failures[runs[i-1].ID]
+		if failed != previousFailed {
+			flips++
+		}
+	}
+	return flips
+}
+
+func GetTestStability(testsClient TestsClient, testRunsClient TestRunsClient, flakyTestsClient FlakyTestsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[GetTestStabilityArgs], scopes []string) {
+	return mcp.NewTool("get_test_stability",
+			mcp.WithDescription("Get how stable a test in Buildkite Test Engine has been over the last number of days, optionally on one branch: its pass rate per day, how often it flipped between passing and failing from one run to the next, its recent failures, and whether Test Engine has detected it flaking. A run counts as a pass unless it reported a failed execution for the test."),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("test_suite_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("test_id",
+				mcp.Required(),
+			),
+			mcp.WithString("branch",
+				mcp.Description("Only analyze runs on this branch"),
+			),
+			mcp.WithNumber("days",
+				mcp.Description("Number of days of runs to analyze (default 7, max 30)"),
+				mcp.Min(1),
+				mcp.Max(maxTestStabilityDays),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Get Test Stability",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args GetTestStabilityArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.GetTestStability")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.TestSuiteSlug == "" {
+				return mcp.NewToolResultError("test_suite_slug parameter is required"), nil
+			}
+			if args.TestID == "" {
+				return mcp.NewToolResultError("test_id parameter is required"), nil
+			}
+			if args.Days <= 0 {
+				args.Days = defaultTestStabilityDays
+			}
+			args.Days = min(args.Days, maxTestStabilityDays)
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("test_suite_slug", args.TestSuiteSlug),
+				attribute.String("test_id", args.TestID),
+				attribute.String("branch", args.Branch),
+				attribute.Int("days", args.Days),
+			)
+
+			test, _, err := testsClient.Get(ctx, args.OrgSlug, args.TestSuiteSlug, args.TestID)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			allRuns, truncated, err := listTestRunsSince(ctx, testRunsClient, args.OrgSlug, args.TestSuiteSlug, time.Now().AddDate(0, 0, -args.Days))
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			var runs []buildkite.TestRun
+			for _, run := range allRuns {
+				if run.State != "finished" || (args.Branch != "" && run.Branch != args.Branch) {
+					continue
+				}
+				runs = append(runs, run)
+			}
+
+			failures, err := findTestFailures(ctx, testRunsClient, args.OrgSlug, args.TestSuiteSlug, args.TestID, runs)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			flakyTests, err := listFlakyTests(ctx, flakyTestsClient, args.OrgSlug, args.TestSuiteSlug)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			result := TestStability{
+				TestReliability: testReliability(args.TestID, runs, failures, TestReliabilityBucketDay),
+				Branch:          args.Branch,
+				Days:            args.Days,
+				Flips:           countFlips(runs, failures),
+			}
+			result.Name = test.Name
+			result.Location = test.Location
+			if len(runs) > 1 {
+				result.FlipRate = float64(result.Flips) / float64(len(runs)-1)
+			}
+
+			for _, flaky := range flakyTests {
+				if flaky.ID == args.TestID {
+					result.Flaky = true
+					result.FlakyInstances = flaky.Instances
+					break
+				}
+			}
+
+			if truncated {
+				result.Notes = append(result.Notes, fmt.Sprintf("only the most recent %d runs of the suite were analyzed", maxSuiteHealthRuns))
+			}
+			if len(runs) == 0 {
+				result.Notes = append(result.Notes, "no finished runs matched the branch and time window")
+			}
+
+			span.SetAttributes(
+				attribute.Int("runs_analyzed", result.RunsAnalyzed),
+				attribute.Int("failures", result.Failures),
+				attribute.Int("flips", result.Flips),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_suites"}
+}
@@ -0,0 +1,108 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListFlakyTests(t *testing.T) {
+	assert := require.New(t)
+
+	ago := func(d time.Duration) *buildkite.Timestamp {
+		return &buildkite.Timestamp{Time: time.Now().Add(-d)}
+	}
+
+	client := &MockFlakyTestsClient{
+		ListFunc: func(ctx context.Context, org, slug string, opt *buildkite.FlakyTestsListOptions) ([]buildkite.FlakyTest, *buildkite.Response, error) {
+			return []buildkite.FlakyTest{
+				{ID: "t1", Name: "login works", Location: "spec/login_spec.rb:4", Instances: 3, MostRecentInstanceAt: ago(time.Hour)},
+				{ID: "t2", Name: "checkout works", Location: "spec/checkout_spec.rb:9", Instances: 12, MostRecentInstanceAt: ago(2 * 24 * time.Hour)},
+				{ID: "t3", Name: "old flake", Instances: 40, MostRecentInstanceAt: ago(20 * 24 * time.Hour)},
+				{ID: "t4", Name: "login redirects", Location: "spec/login_spec.rb:20", Instances: 5, MostRecentInstanceAt: ago(3 * time.Hour)},
+			}, &buildkite.Response{}, nil
+		},
+	}
+
+	tool, handler, scopes := ListFlakyTests(client)
+	assert.Equal("list_flaky_tests", tool.Name)
+	assert.Equal([]string{"read_suites"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, ListFlakyTestsArgs{OrgSlug: "org", TestSuiteSlug: "suite"})
+	assert.NoError(err)
+
+	var flaky FlakyTests
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &flaky))
+	assert.Equal(7, flaky.Days)
+	assert.Equal(3, flaky.MatchingTests)
+	assert.Equal("t2", flaky.Tests[0].ID)
+	assert.Equal("t4", flaky.Tests[1].ID)
+
+	result, err = handler(context.Background(), mcp.CallToolRequest{}, ListFlakyTestsArgs{OrgSlug: "org", TestSuiteSlug: "suite", Days: 30, Query: "LOGIN", Limit: 1})
+	assert.NoError(err)
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &flaky))
+	assert.Equal(2, flaky.MatchingTests)
+	assert.Len(flaky.Tests, 1)
+	assert.Equal("t4", flaky.Tests[0].ID)
+}
+
+func TestGetTestStability(t *testing.T) {
+	assert := require.New(t)
+
+	ago := func(d time.Duration) *buildkite.Timestamp {
+		return &buildkite.Timestamp{Time: time.Now().Add(-d)}
+	}
+
+	testsClient := &MockTestsClient{
+		GetFunc: func(ctx context.Context, org, slug, testID string) (buildkite.Test, *buildkite.Response, error) {
+			return buildkite.Test{ID: testID, Name: "login works"}, &buildkite.Response{}, nil
+		},
+	}
+	testRunsClient := &MockTestRunsClient{
+		ListFunc: func(ctx context.Context, org, slug string, opt *buildkite.TestRunsListOptions) ([]buildkite.TestRun, *buildkite.Response, error) {
+			return []buildkite.TestRun{
+				{ID: "run-6", State: "running", Branch: "main", CreatedAt: ago(time.Minute)},
+				{ID: "run-5", State: "finished", Branch: "main", CreatedAt: ago(time.Hour)},
+				{ID: "run-4", State: "finished", Branch: "main", CreatedAt: ago(2 * time.Hour)},
+				{ID: "run-3", State: "finished", Branch: "feature", CreatedAt: ago(3 * time.Hour)},
+				{ID: "run-2", State: "finished", Branch: "main", CreatedAt: ago(4 * time.Hour)},
+				{ID: "run-1", State: "finished", Branch: "main", CreatedAt: ago(5 * time.Hour)},
+				{ID: "run-0", State: "finished", Branch: "main", CreatedAt: ago(10 * 24 * time.Hour)},
+			}, &buildkite.Response{}, nil
+		},
+		GetFailedExecutionsFunc: func(ctx context.Context, org, slug, runID string, opt *buildkite.FailedExecutionsOptions) ([]buildkite.FailedExecution, *buildkite.Response, error) {
+			assert.NotEqual("run-3", runID)
+			if runID == "run-4" || runID == "run-1" {
+				return []buildkite.FailedExecution{{TestID: "t1", FailureReason: "timeout"}}, &buildkite.Response{}, nil
+			}
+			return nil, &buildkite.Response{}, nil
+		},
+	}
+	flakyTestsClient := &MockFlakyTestsClient{
+		ListFunc: func(ctx context.Context, org, slug string, opt *buildkite.FlakyTestsListOptions) ([]buildkite.FlakyTest, *buildkite.Response, error) {
+			return []buildkite.FlakyTest{{ID: "t1", Instances: 4}}, &buildkite.Response{}, nil
+		},
+	}
+
+	_, handler, scopes := GetTestStability(testsClient, testRunsClient, flakyTestsClient)
+	assert.Equal([]string{"read_suites"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, GetTestStabilityArgs{OrgSlug: "org", TestSuiteSlug: "suite", TestID: "t1", Branch: "main"})
+	assert.NoError(err)
+
+	var stability TestStability
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &stability))
+	assert.Equal("login works", stability.Name)
+	assert.Equal(4, stability.RunsAnalyzed)
+	assert.Equal(2, stability.Failures)
+	assert.Equal(3, stability.Flips)
+	assert.InDelta(1.0, stability.FlipRate, 0.001)
+	assert.True(stability.Flaky)
+	assert.Equal(4, stability.FlakyInstances)
+	assert.Equal("run-4", stability.RecentFailures[0].RunID)
+}
@@ -0,0 +1,367 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/graphql"
+	"github.com/buildkite/buildkite-mcp-server/pkg/policy"
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// GraphQLClient runs queries against the Buildkite GraphQL API
+type GraphQLClient interface {
+	Query(ctx context.Context, query string, variables map[string]any) (*graphql.Response, error)
+}
+
+// errGraphQLNotConfigured is returned by the graphql tools when the server has no GraphQL client
+const errGraphQLNotConfigured = "the GraphQL API is not configured for this server"
+
+// graphQLOperationTypes are the operation types run_graphql_query refuses to run, as it is read-only
+var graphQLOperationTypes = []string{"mutation", "subscription"}
+
+// graphQLWriteOperation returns the type of the first operation in the document which isn't a
+// query, or "" if there are none. Only words outside selection sets are considered, skipping
+// comments and strings, so fields and arguments named like an operation type don't match.
+func graphQLWriteOperation(document string) string {
+	depth := 0
+	var word strings.Builder
+
+	endWord := func() string {
+		defer word.Reset()
+		if depth == 0 {
+			for _, operation := range graphQLOperationTypes {
+				if word.String() == operation {
+					return operation
+				}
+			}
+		}
+		return ""
+	}
+
+	for i := 0; i < len(document); i++ {
+		c := document[i]
+
+		if c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+			word.WriteByte(c)
+			continue
+		}
+		if operation := endWord(); operation != "" {
+			return operation
+		}
+
+		switch {
+		case c == '#':
+			for i < len(document) && document[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(document[i:], `"""`):
+			end := strings.Index(document[i+3:], `"""`)
+			if end < 0 {
+				return ""
+			}
+			i += end + 5
+		case c == '"':
+			for i++; i < len(document) && document[i] != '"' && document[i] != '\n'; i++ {
+				if document[i] == '\\' {
+					i++
+				}
+			}
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+		}
+	}
+
+	return endWord()
+}
+
+// RunGraphQLQueryToolName is the name of the tool running GraphQL queries, which isn't registered
+// while a scope policy is set
+const RunGraphQLQueryToolName = "run_graphql_query"
+
+type RunGraphQLQueryArgs struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables"`
+}
+
+func RunGraphQLQuery(client GraphQLClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[RunGraphQLQueryArgs], scopes []string) {
+	return mcp.NewTool(RunGraphQLQueryToolName,
+			mcp.WithDescription("Run a query against the Buildkite GraphQL API, which exposes data the other tools can't reach such as queue wait times and cluster utilization. Returns the data and any errors from the API, which can accompany partial data. Mutations and subscriptions are refused"),
+			mcp.WithString("query",
+				mcp.Required(),
+				mcp.Description("The GraphQL query document"),
+			),
+			mcp.WithObject("variables",
+				mcp.Description("Values of the variables declared by the query"),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Run GraphQL Query",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args RunGraphQLQueryArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.RunGraphQLQuery")
+			defer span.End()
+
+			if client == nil {
+				return mcp.NewToolResultError(errGraphQLNotConfigured), nil
+			}
+			// a query isn't confined to the organizations and pipelines a scope policy permits
+			if !policy.ScopePolicyFromContext(ctx).IsEmpty() {
+				return mcp.NewToolResultError("GraphQL queries can't be run while the server has a scope policy"), nil
+			}
+			if strings.TrimSpace(args.Query) == "" {
+				return mcp.NewToolResultError("query parameter is required"), nil
+			}
+			if operation := graphQLWriteOperation(args.Query); operation != "" {
+				return mcp.NewToolResultError(fmt.Sprintf("%s operations are not permitted, only queries can be run", operation)), nil
+			}
+
+			span.SetAttributes(
+				attribute.Int("query_length", len(args.Query)),
+				attribute.Int("variable_count", len(args.Variables)),
+			)
+
+			resp, err := client.Query(ctx, args.Query, args.Variables)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// errors only fail the call when there is no partial data to return alongside them
+			if err := resp.Err(); err != nil && (len(resp.Data) == 0 || string(resp.Data) == "null") {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			span.SetAttributes(
+				attribute.Int("error_count", len(resp.Errors)),
+			)
+
+			return mcpTextResult(span, resp)
+		}, []string{"graphql"}
+}
+
+// organizationUsageQuery counts an organization's members, pipelines, teams and agents, and the
+// jobs waiting for or running on an agent
+const organizationUsageQuery = `query OrganizationUsage($slug: ID!) {
+  organization(slug: $slug) {
+    name
+    slug
+    members { count }
+    pipelines { count }
+    teams { count }
+    agents { count }
+    scheduledJobs: jobs(state: [SCHEDULED], type: [COMMAND]) { count }
+    runningJobs: jobs(state: [RUNNING], type: [COMMAND]) { count }
+  }
+}`
+
+type graphQLCount struct {
+	Count int `json:"count"`
+}
+
+// OrganizationUsage counts the resources used by an organization
+type OrganizationUsage struct {
+	Name          string `json:"name"`
+	Slug          string `json:"slug"`
+	Members       int    `json:"members"`
+	Pipelines     int    `json:"pipelines"`
+	Teams         int    `json:"teams"`
+	Agents        int    `json:"agents"`
+	ScheduledJobs int    `json:"scheduled_jobs"`
+	RunningJobs   int    `json:"running_jobs"`
+	// Errors are reported by the API for counts it couldn't return, which are left as zero
+	Errors []graphql.Error `json:"errors,omitempty"`
+}
+
+type GetOrganizationUsageArgs struct {
+	OrgSlug string `json:"org_slug"`
+}
+
+func GetOrganizationUsage(client GraphQLClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[GetOrganizationUsageArgs], scopes []string) {
+	return mcp.NewTool("get_organization_usage",
+			mcp.WithDescription("Get how many members, pipelines, teams and agents an organization has, along with the number of command jobs waiting for an agent and running"),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Get Organization Usage",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args GetOrganizationUsageArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.GetOrganizationUsage")
+			defer span.End()
+
+			if client == nil {
+				return mcp.NewToolResultError(errGraphQLNotConfigured), nil
+			}
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+			)
+
+			resp, err := client.Query(ctx, organizationUsageQuery, map[string]any{"slug": args.OrgSlug})
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			var data struct {
+				Organization *struct {
+					Name          string       `json:"name"`
+					Slug          string       `json:"slug"`
+					Members       graphQLCount `json:"members"`
+					Pipelines     graphQLCount `json:"pipelines"`
+					Teams         graphQLCount `json:"teams"`
+					Agents        graphQLCount `json:"agents"`
+					ScheduledJobs graphQLCount `json:"scheduledJobs"`
+					RunningJobs   graphQLCount `json:"runningJobs"`
+				} `json:"organization"`
+			}
+			if len(resp.Data) > 0 {
+				if err := json.Unmarshal(resp.Data, &data); err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("failed to decode organization usage: %v", err)), nil
+				}
+			}
+			if data.Organization == nil {
+				if err := resp.Err(); err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				return mcp.NewToolResultError(fmt.Sprintf("organization %s not found", args.OrgSlug)), nil
+			}
+
+			org := data.Organization
+			usage := OrganizationUsage{
+				Name:          org.Name,
+				Slug:          org.Slug,
+				Members:       org.Members.Count,
+				Pipelines:     org.Pipelines.Count,
+				Teams:         org.Teams.Count,
+				Agents:        org.Agents.Count,
+				ScheduledJobs: org.ScheduledJobs.Count,
+				RunningJobs:   org.RunningJobs.Count,
+				Errors:        resp.Errors,
+			}
+
+			return mcpTextResult(span, &usage)
+		}, []string{"graphql"}
+}
+
+// pipelineDashboardMetricsQuery fetches the metrics Buildkite shows for a pipeline on its dashboard,
+// such as its speed and reliability over recent builds
+const pipelineDashboardMetricsQuery = `query PipelineDashboardMetrics($slug: ID!) {
+  pipeline(slug: $slug) {
+    name
+    slug
+    metrics {
+      edges {
+        node { label value url }
+      }
+    }
+  }
+}`
+
+// PipelineDashboardMetric is one of the metrics Buildkite calculates for a pipeline
+type PipelineDashboardMetric struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+	URL   string `json:"url,omitempty"`
+}
+
+// PipelineDashboardMetrics are the metrics Buildkite calculates for a pipeline
+type PipelineDashboardMetrics struct {
+	Name    string                    `json:"name"`
+	Slug    string                    `json:"slug"`
+	Metrics []PipelineDashboardMetric `json:"metrics"`
+}
+
+type GetPipelineDashboardMetricsArgs struct {
+	OrgSlug      string `json:"org_slug"`
+	PipelineSlug string `json:"pipeline_slug"`
+}
+
+func GetPipelineDashboardMetrics(client GraphQLClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[GetPipelineDashboardMetricsArgs], scopes []string) {
+	return mcp.NewTool("get_pipeline_dashboard_metrics",
+			mcp.WithDescription("Get the metrics Buildkite calculates for a pipeline from its recent builds, such as speed and reliability, as shown on the pipelines page. Use get_pipeline_metrics for pass rate, duration and queue wait statistics over a chosen window"),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Get Pipeline Dashboard Metrics",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args GetPipelineDashboardMetricsArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.GetPipelineDashboardMetrics")
+			defer span.End()
+
+			if client == nil {
+				return mcp.NewToolResultError(errGraphQLNotConfigured), nil
+			}
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+			)
+
+			resp, err := client.Query(ctx, pipelineDashboardMetricsQuery, map[string]any{"slug": args.OrgSlug + "/" + args.PipelineSlug})
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			var data struct {
+				Pipeline *struct {
+					Name    string `json:"name"`
+					Slug    string `json:"slug"`
+					Metrics struct {
+						Edges []struct {
+							Node PipelineDashboardMetric `json:"node"`
+						} `json:"edges"`
+					} `json:"metrics"`
+				} `json:"pipeline"`
+			}
+			if len(resp.Data) > 0 {
+				if err := json.Unmarshal(resp.Data, &data); err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("failed to decode pipeline metrics: %v", err)), nil
+				}
+			}
+			if data.Pipeline == nil {
+				if err := resp.Err(); err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				return mcp.NewToolResultError(fmt.Sprintf("pipeline %s/%s not found", args.OrgSlug, args.PipelineSlug)), nil
+			}
+
+			metrics := PipelineDashboardMetrics{
+				Name:    data.Pipeline.Name,
+				Slug:    data.Pipeline.Slug,
+				Metrics: make([]PipelineDashboardMetric, 0, len(data.Pipeline.Metrics.Edges)),
+			}
+			for _, edge := range data.Pipeline.Metrics.Edges {
+				metrics.Metrics = append(metrics.Metrics, edge.Node)
+			}
+
+			span.SetAttributes(
+				attribute.Int("item_count", len(metrics.Metrics)),
+			)
+
+			return mcpTextResult(span, &metrics)
+		}, []string{"graphql"}
+}
@@ -0,0 +1,148 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/graphql"
+	"github.com/buildkite/buildkite-mcp-server/pkg/policy"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+type MockGraphQLClient struct {
+	QueryFunc func(ctx context.Context, query string, variables map[string]any) (*graphql.Response, error)
+}
+
+func (m *MockGraphQLClient) Query(ctx context.Context, query string, variables map[string]any) (*graphql.Response, error) {
+	if m.QueryFunc != nil {
+		return m.QueryFunc(ctx, query, variables)
+	}
+	return nil, nil
+}
+
+var _ GraphQLClient = (*MockGraphQLClient)(nil)
+
+func TestGraphQLWriteOperation(t *testing.T) {
+	assert := require.New(t)
+
+	assert.Equal("", graphQLWriteOperation(`{ viewer { user { name } } }`))
+	assert.Equal("", graphQLWriteOperation(`query Q { mutation: pipeline(slug: "mutation") { name } }`))
+	assert.Equal("", graphQLWriteOperation("# mutation\nquery { viewer { id } }"))
+	assert.Equal("", graphQLWriteOperation(`query { a(b: """ mutation { x } """) { c } }`))
+	assert.Equal("mutation", graphQLWriteOperation(`mutation { buildCancel(input: {id: "1"}) { build { id } } }`))
+	assert.Equal("mutation", graphQLWriteOperation(`query A { viewer { id } } mutation B($id: ID!) { agentStop(input: {id: $id}) { agent { id } } }`))
+	assert.Equal("subscription", graphQLWriteOperation(`subscription{build{id}}`))
+}
+
+func TestRunGraphQLQuery(t *testing.T) {
+	assert := require.New(t)
+
+	var capturedVariables map[string]any
+	client := &MockGraphQLClient{
+		QueryFunc: func(ctx context.Context, query string, variables map[string]any) (*graphql.Response, error) {
+			capturedVariables = variables
+			return &graphql.Response{Data: json.RawMessage(`{"viewer":{"id":"1"}}`)}, nil
+		},
+	}
+
+	tool, handler, scopes := RunGraphQLQuery(client)
+	assert.Equal("run_graphql_query", tool.Name)
+	assert.True(*tool.Annotations.ReadOnlyHint)
+	assert.Equal([]string{"graphql"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, RunGraphQLQueryArgs{Query: "{ viewer { id } }", Variables: map[string]any{"a": 1}})
+	assert.NoError(err)
+	assert.Equal(`{"data":{"viewer":{"id":"1"}}}`, getTextResult(t, result).Text)
+	assert.Equal(map[string]any{"a": 1}, capturedVariables)
+
+	result, err = handler(context.Background(), mcp.CallToolRequest{}, RunGraphQLQueryArgs{Query: "mutation { x }"})
+	assert.NoError(err)
+	assert.True(result.IsError)
+	assert.Contains(getTextResult(t, result).Text, "mutation operations are not permitted")
+
+	client.QueryFunc = func(ctx context.Context, query string, variables map[string]any) (*graphql.Response, error) {
+		return &graphql.Response{Data: json.RawMessage(`null`), Errors: []graphql.Error{{Message: "Field 'nope' doesn't exist"}}}, nil
+	}
+	result, err = handler(context.Background(), mcp.CallToolRequest{}, RunGraphQLQueryArgs{Query: "{ nope }"})
+	assert.NoError(err)
+	assert.True(result.IsError)
+	assert.Contains(getTextResult(t, result).Text, "Field 'nope' doesn't exist")
+
+	_, handler, _ = RunGraphQLQuery(nil)
+	result, err = handler(context.Background(), mcp.CallToolRequest{}, RunGraphQLQueryArgs{Query: "{ viewer { id } }"})
+	assert.NoError(err)
+	assert.True(result.IsError)
+}
+
+func TestRunGraphQLQueryRefusedUnderScopePolicy(t *testing.T) {
+	assert := require.New(t)
+
+	client := &MockGraphQLClient{
+		QueryFunc: func(ctx context.Context, query string, variables map[string]any) (*graphql.Response, error) {
+			t.Fatal("query should not be run")
+			return nil, nil
+		},
+	}
+	_, handler, _ := RunGraphQLQuery(client)
+
+	scopePolicy, err := policy.NewScopePolicy([]string{"acme"}, nil)
+	assert.NoError(err)
+
+	result, err := handler(policy.WithScopePolicy(context.Background(), scopePolicy), mcp.CallToolRequest{}, RunGraphQLQueryArgs{Query: "{ viewer { id } }"})
+	assert.NoError(err)
+	assert.True(result.IsError)
+	assert.Contains(getTextResult(t, result).Text, "scope policy")
+}
+
+func TestGetOrganizationUsage(t *testing.T) {
+	assert := require.New(t)
+
+	client := &MockGraphQLClient{
+		QueryFunc: func(ctx context.Context, query string, variables map[string]any) (*graphql.Response, error) {
+			assert.Equal("org", variables["slug"])
+			return &graphql.Response{Data: json.RawMessage(`{"organization":{"name":"Org","slug":"org","members":{"count":3},"pipelines":{"count":12},"teams":{"count":2},"agents":{"count":5},"scheduledJobs":{"count":7},"runningJobs":{"count":4}}}`)}, nil
+		},
+	}
+
+	_, handler, _ := GetOrganizationUsage(client)
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, GetOrganizationUsageArgs{OrgSlug: "org"})
+	assert.NoError(err)
+
+	var usage OrganizationUsage
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &usage))
+	assert.Equal(OrganizationUsage{Name: "Org", Slug: "org", Members: 3, Pipelines: 12, Teams: 2, Agents: 5, ScheduledJobs: 7, RunningJobs: 4}, usage)
+
+	client.QueryFunc = func(ctx context.Context, query string, variables map[string]any) (*graphql.Response, error) {
+		return &graphql.Response{Data: json.RawMessage(`{"organization":null}`)}, nil
+	}
+	result, err = handler(context.Background(), mcp.CallToolRequest{}, GetOrganizationUsageArgs{OrgSlug: "missing"})
+	assert.NoError(err)
+	assert.True(result.IsError)
+	assert.Contains(getTextResult(t, result).Text, "organization missing not found")
+}
+
+func TestGetPipelineDashboardMetrics(t *testing.T) {
+	assert := require.New(t)
+
+	client := &MockGraphQLClient{
+		QueryFunc: func(ctx context.Context, query string, variables map[string]any) (*graphql.Response, error) {
+			assert.Equal("org/pipeline", variables["slug"])
+			return &graphql.Response{Data: json.RawMessage(`{"pipeline":{"name":"Pipeline","slug":"pipeline","metrics":{"edges":[{"node":{"label":"Speed","value":"4m"}},{"node":{"label":"Reliability","value":"92%"}}]}}}`)}, nil
+		},
+	}
+
+	_, handler, _ := GetPipelineDashboardMetrics(client)
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, GetPipelineDashboardMetricsArgs{OrgSlug: "org", PipelineSlug: "pipeline"})
+	assert.NoError(err)
+
+	var metrics PipelineDashboardMetrics
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &metrics))
+	assert.Equal("Pipeline", metrics.Name)
+	assert.Equal([]PipelineDashboardMetric{{Label: "Speed", Value: "4m"}, {Label: "Reliability", Value: "92%"}}, metrics.Metrics)
+
+	result, err = handler(context.Background(), mcp.CallToolRequest{}, GetPipelineDashboardMetricsArgs{OrgSlug: "org"})
+	assert.NoError(err)
+	assert.True(result.IsError)
+}
@@ -4,12 +4,20 @@ import (
 	"context"
 	"fmt"
 	"iter"
+	"maps"
 	"regexp"
+	"slices"
 	"time"
 
 	buildkitelogs "github.com/buildkite/buildkite-logs"
+	"github.com/buildkite/buildkite-mcp-server/pkg/cache"
+	"github.com/buildkite/buildkite-mcp-server/pkg/redact"
+	"github.com/buildkite/buildkite-mcp-server/pkg/scrub"
 	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog/log"
 	"go.opentelemetry.io/otel/attribute"
 )
 
@@ -46,17 +54,20 @@ type SearchLogsParams struct {
 
 type TailLogsParams struct {
 	JobLogsBaseParams
-	Tail int `json:"tail"`
+	Tail     int    `json:"tail"`
+	Timezone string `json:"timezone"`
 }
 
 type ReadLogsParams struct {
 	JobLogsBaseParams
-	Seek  int `json:"seek"`
-	Limit int `json:"limit"`
+	Seek     int    `json:"seek"`
+	Limit    int    `json:"limit"`
+	Timezone string `json:"timezone"`
 }
 
 type TerseLogEntry struct {
 	TS int64  `json:"ts,omitempty"`
+	T  string `json:"t,omitempty"` // timestamp in the display timezone
 	C  string `json:"c"`
 	RN int64  `json:"rn,omitempty"`
 }
@@ -74,6 +85,7 @@ type LogResponse struct {
 	FileInfo    *FileInfo `json:"file_info,omitempty"`
 	MatchCount  int       `json:"match_count,omitempty"`
 	TotalRows   int64     `json:"total_rows,omitempty"`
+	Redactions  int       `json:"redactions,omitempty"`
 	QueryTimeMS int64     `json:"query_time_ms"`
 }
 
@@ -85,6 +97,11 @@ func newParquetReader(ctx context.Context, client BuildkiteLogsClient, params Jo
 	// Parse cache TTL
 	ttl := parseCacheTTL(params.CacheTTL)
 
+	// a refresh must not be served the log or job state from the API response cache either
+	if params.ForceRefresh {
+		ctx = cache.Revalidate(ctx)
+	}
+
 	// Download and cache the logs using injected client
 	cacheFilePath, err := client.DownloadAndCache(ctx, params.OrgSlug, params.PipelineSlug, params.BuildNumber, params.JobID, ttl, params.ForceRefresh)
 	if err != nil {
@@ -96,6 +113,31 @@ func newParquetReader(ctx context.Context, client BuildkiteLogsClient, params Jo
 	return reader, nil
 }
 
+// tailLogLines returns the cleaned content of the last n entries of a job log
+func tailLogLines(ctx context.Context, client BuildkiteLogsClient, params JobLogsBaseParams, n int) ([]string, error) {
+	reader, err := newParquetReader(ctx, client, params)
+	if err != nil {
+		return nil, err
+	}
+
+	fileInfo, err := reader.GetFileInfo()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file info: %w", err)
+	}
+
+	startRow := max(fileInfo.RowCount-int64(n), 0)
+
+	var lines []string
+	for entry, err := range reader.SeekToRow(startRow) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to read log entries: %w", err)
+		}
+		lines = append(lines, entry.CleanContent(true))
+	}
+
+	return lines, nil
+}
+
 func parseCacheTTL(ttlStr string) time.Duration {
 	if ttlStr == "" {
 		return 30 * time.Second
@@ -115,23 +157,87 @@ func validateSearchPattern(pattern string) error {
 	return nil
 }
 
-func formatLogEntries(entries []buildkitelogs.ParquetLogEntry) any {
+// formatLogEntries redacts the entries into their terse form, displaying their timestamps in the
+// timezone when there is one
+func formatLogEntries(entries []buildkitelogs.ParquetLogEntry, redactor *redact.Redactor, loc *time.Location) (any, int) {
+	now := time.Now()
+	redactions := 0
+	lines := redactor.Lines()
 	result := make([]TerseLogEntry, len(entries))
 	for i, entry := range entries {
-		content := entry.CleanContent(true)
+		content, n := lines.Redact(entry.CleanContent(true))
+		redactions += n
 
 		terse := TerseLogEntry{C: content, RN: entry.RowNumber}
 		if entry.HasTime() {
 			terse.TS = entry.Timestamp
+			if loc != nil {
+				terse.T = displayTime(time.UnixMilli(entry.Timestamp), loc, now)
+			}
 		}
 
 		result[i] = terse
 	}
-	return result
+	return result, redactions
+}
+
+// formatProgressEntries formats log entries sent in progress notifications, which don't pass through
+// the scrubbing of tool results, so the scrubber the call is made under is applied to them here
+func formatProgressEntries(ctx context.Context, entries []buildkitelogs.ParquetLogEntry, redactor *redact.Redactor, loc *time.Location) any {
+	formatted, _ := formatLogEntries(entries, redactor, loc)
+
+	scrubber := scrub.FromContext(ctx)
+	if terse, ok := formatted.([]TerseLogEntry); ok && !scrubber.IsEmpty() {
+		for i := range terse {
+			terse[i].C, _ = scrubber.Scrub(ctx, terse[i].C)
+		}
+	}
+	return formatted
+}
+
+// redactSearchResults redacts secrets from matches and their context lines in place. Results can
+// overlap and come in any order, so the lines are redacted in the order of their rows, following the
+// lines of a private key from one row to the next for as long as the rows are consecutive.
+func redactSearchResults(results []SearchResult, redactor *redact.Redactor) int {
+	var entries []*buildkitelogs.ParquetLogEntry
+	for i := range results {
+		for j := range results[i].BeforeContext {
+			entries = append(entries, &results[i].BeforeContext[j])
+		}
+		entries = append(entries, &results[i].Match)
+		for j := range results[i].AfterContext {
+			entries = append(entries, &results[i].AfterContext[j])
+		}
+	}
+
+	rows := make(map[int64]string, len(entries))
+	for _, entry := range entries {
+		rows[entry.RowNumber] = entry.Content
+	}
+
+	redactions := 0
+	lines := redactor.Lines()
+	lastRow := int64(-1)
+	for _, row := range slices.Sorted(maps.Keys(rows)) {
+		if row != lastRow+1 {
+			lines.Reset()
+		}
+		lastRow = row
+
+		var n int
+		rows[row], n = lines.Redact(rows[row])
+		redactions += n
+	}
+
+	for _, entry := range entries {
+		entry.Content = rows[entry.RowNumber]
+	}
+
+	return redactions
 }
 
 // SearchLogs implements the search_logs MCP tool
-func SearchLogs(client BuildkiteLogsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[SearchLogsParams], scopes []string) {
+func SearchLogs(client BuildkiteLogsClient, redactor *redact.Redactor) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[SearchLogsParams], scopes []string) {
 	return mcp.NewTool("search_logs",
 			mcp.WithDescription("Search log entries using regex patterns with optional context lines. 💡 For recent failures, try 'tail_logs' first, then use search_logs with patterns like 'error|failed|exception' and limit: 10-20. The json format: {ts: timestamp_ms, c: content, rn: row_number}."),
 			mcp.WithString("org_slug",
@@ -249,10 +355,13 @@ func SearchLogs(client BuildkiteLogsClient) (tool mcp.Tool, handler mcp.TypedToo
 				}
 			}
 
+			redactions := redactSearchResults(results, redactor)
+
 			queryTime := time.Since(startTime)
 			response := LogResponse{
 				Results:     results,
 				MatchCount:  len(results),
+				Redactions:  redactions,
 				QueryTimeMS: queryTime.Milliseconds(),
 			}
 
@@ -266,9 +375,9 @@ func SearchLogs(client BuildkiteLogsClient) (tool mcp.Tool, handler mcp.TypedToo
 }
 
 // TailLogs implements the tail_logs MCP tool
-func TailLogs(client BuildkiteLogsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[TailLogsParams], scopes []string) {
+func TailLogs(client BuildkiteLogsClient, redactor *redact.Redactor) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[TailLogsParams], scopes []string) {
 	return mcp.NewTool("tail_logs",
-			mcp.WithDescription("Show the last N entries from the log file. 🔥 RECOMMENDED for failure diagnosis - most build failures appear in the final log entries. More token-efficient than read_logs for recent issues. The json format: {ts: timestamp_ms, c: content, rn: row_number}."),
+			mcp.WithDescription("Show the last N entries from the log file. 🔥 RECOMMENDED for failure diagnosis - most build failures appear in the final log entries. More token-efficient than read_logs for recent issues. The json format: {ts: timestamp_ms, t: timestamp in the display timezone, c: content, rn: row_number}."),
 			mcp.WithString("org_slug",
 				mcp.Required(),
 			),
@@ -292,6 +401,7 @@ func TailLogs(client BuildkiteLogsClient) (tool mcp.Tool, handler mcp.TypedToolH
 			mcp.WithBoolean("force_refresh",
 				mcp.Description("Force refresh cached entry (default: false)"),
 			),
+			withTimezone(),
 			mcp.WithToolAnnotation(mcp.ToolAnnotation{
 				Title:        "Tail Logs",
 				ReadOnlyHint: mcp.ToBoolPtr(true),
@@ -314,8 +424,14 @@ func TailLogs(client BuildkiteLogsClient) (tool mcp.Tool, handler mcp.TypedToolH
 				attribute.String("build_number", params.BuildNumber),
 				attribute.String("job_id", params.JobID),
 				attribute.Int("tail", params.Tail),
+				attribute.String("timezone", params.Timezone),
 			)
 
+			loc, err := displayLocation(ctx, params.Timezone)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
 			// Create parquet reader
 			reader, err := newParquetReader(ctx, client, params.JobLogsBaseParams)
 			if err != nil {
@@ -344,11 +460,12 @@ func TailLogs(client BuildkiteLogsClient) (tool mcp.Tool, handler mcp.TypedToolH
 			}
 
 			queryTime := time.Since(startTime)
-			formattedEntries := formatLogEntries(entries)
+			formattedEntries, redactions := formatLogEntries(entries, redactor, loc)
 
 			response := LogResponse{
 				Entries:     formattedEntries,
 				TotalRows:   fileInfo.RowCount,
+				Redactions:  redactions,
 				QueryTimeMS: queryTime.Milliseconds(),
 			}
 
@@ -437,9 +554,9 @@ func GetLogsInfo(client BuildkiteLogsClient) (tool mcp.Tool, handler mcp.TypedTo
 }
 
 // ReadLogs implements the read_logs MCP tool
-func ReadLogs(client BuildkiteLogsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[ReadLogsParams], scopes []string) {
+func ReadLogs(client BuildkiteLogsClient, redactor *redact.Redactor) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[ReadLogsParams], scopes []string) {
 	return mcp.NewTool("read_logs",
-			mcp.WithDescription("Read log entries from the file, optionally starting from a specific row number. ⚠️ ALWAYS use 'limit' parameter to avoid excessive tokens. For recent failures, use 'tail_logs' instead. Recommended limits: investigation (100-500), exploration (use seek + small limits). The json format: {ts: timestamp_ms, c: content, rn: row_number}."),
+			mcp.WithDescription("Read log entries from the file, optionally starting from a specific row number. ⚠️ ALWAYS use 'limit' parameter to avoid excessive tokens. For recent failures, use 'tail_logs' instead. Recommended limits: investigation (100-500), exploration (use seek + small limits). The json format: {ts: timestamp_ms, t: timestamp in the display timezone, c: content, rn: row_number}."),
 			mcp.WithString("org_slug",
 				mcp.Required(),
 			),
@@ -467,6 +584,7 @@ func ReadLogs(client BuildkiteLogsClient) (tool mcp.Tool, handler mcp.TypedToolH
 			mcp.WithBoolean("force_refresh",
 				mcp.Description("Force refresh cached entry (default: false)"),
 			),
+			withTimezone(),
 			mcp.WithToolAnnotation(mcp.ToolAnnotation{
 				Title:        "Read Logs",
 				ReadOnlyHint: mcp.ToBoolPtr(true),
@@ -485,8 +603,14 @@ func ReadLogs(client BuildkiteLogsClient) (tool mcp.Tool, handler mcp.TypedToolH
 				attribute.String("job_id", params.JobID),
 				attribute.Int("seek", params.Seek),
 				attribute.Int("limit", params.Limit),
+				attribute.String("timezone", params.Timezone),
 			)
 
+			loc, err := displayLocation(ctx, params.Timezone)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
 			// Create parquet reader
 			reader, err := newParquetReader(ctx, client, params.JobLogsBaseParams)
 			if err != nil {
@@ -520,10 +644,11 @@ func ReadLogs(client BuildkiteLogsClient) (tool mcp.Tool, handler mcp.TypedToolH
 			}
 
 			queryTime := time.Since(startTime)
-			formattedEntries := formatLogEntries(entries)
+			formattedEntries, redactions := formatLogEntries(entries, redactor, loc)
 
 			response := LogResponse{
 				Entries:     formattedEntries,
+				Redactions:  redactions,
 				QueryTimeMS: queryTime.Milliseconds(),
 			}
 
@@ -535,3 +660,267 @@ func ReadLogs(client BuildkiteLogsClient) (tool mcp.Tool, handler mcp.TypedToolH
 		},
 		[]string{"read_build_logs"}
 }
+
+type FollowLogsParams struct {
+	OrgSlug      string `json:"org_slug"`
+	PipelineSlug string `json:"pipeline_slug"`
+	BuildNumber  string `json:"build_number"`
+	JobID        string `json:"job_id"`
+	Tail         int    `json:"tail"`
+	WaitTimeout  int    `json:"wait_timeout"`
+	PollInterval int    `json:"poll_interval"`
+	Timezone     string `json:"timezone"`
+}
+
+// FollowLogsResult is the state of a followed job when it finished or following timed out
+type FollowLogsResult struct {
+	JobState   string `json:"job_state"`
+	ExitStatus *int   `json:"exit_status,omitempty"`
+	// Finished is false when following timed out before the job reached a terminal state
+	Finished bool `json:"finished"`
+	// Entries are the last entries of the log
+	Entries      any   `json:"entries"`
+	TotalRows    int64 `json:"total_rows"`
+	FollowedRows int64 `json:"followed_rows"`
+	Redactions   int   `json:"redactions,omitempty"`
+}
+
+// isTerminalJobState reports whether a job in the state will not run or write to its log again
+func isTerminalJobState(state string) bool {
+	switch state {
+	case "passed", "failed", "canceled", "timed_out", "skipped", "broken", "expired", "not_run", "finished":
+		return true
+	default:
+		return false
+	}
+}
+
+// readLogRows returns the entries of a job log from the row onwards, along with its row count
+func readLogRows(ctx context.Context, client BuildkiteLogsClient, params JobLogsBaseParams, fromRow int64) ([]buildkitelogs.ParquetLogEntry, int64, error) {
+	reader, err := newParquetReader(ctx, client, params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	fileInfo, err := reader.GetFileInfo()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get file info: %w", err)
+	}
+	if fromRow >= fileInfo.RowCount {
+		return nil, fileInfo.RowCount, nil
+	}
+
+	var entries []buildkitelogs.ParquetLogEntry
+	for entry, err := range reader.SeekToRow(max(fromRow, 0)) {
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read log entries: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, fileInfo.RowCount, nil
+}
+
+// FollowLogs implements the follow_logs MCP tool
+func FollowLogs(client BuildkiteLogsClient, buildsClient BuildsClient, redactor *redact.Redactor) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[FollowLogsParams], scopes []string) {
+	return mcp.NewTool("follow_logs",
+			mcp.WithDescription("Follow the log of a running job, such as an in-flight deploy, until it finishes or the timeout is reached. New entries are sent as progress notifications as they are written, when the request has a progress token, and the last entries are returned once following stops. The json format: {ts: timestamp_ms, t: timestamp in the display timezone, c: content, rn: row_number}."),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("build_number",
+				mcp.Required(),
+			),
+			mcp.WithString("job_id",
+				mcp.Required(),
+			),
+			mcp.WithNumber("tail",
+				mcp.Description("Number of entries from the end of the log to return once following stops (default: 20)"),
+				mcp.Min(1),
+				mcp.DefaultNumber(20),
+			),
+			mcp.WithNumber("wait_timeout",
+				mcp.Description("Timeout in seconds to follow the log for"),
+				mcp.DefaultNumber(300), // 5 minutes
+			),
+			mcp.WithNumber("poll_interval",
+				mcp.Description("Seconds between checks for new log entries (default: 5)"),
+				mcp.Min(1),
+				mcp.DefaultNumber(5),
+			),
+			withTimezone(),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Follow Logs",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, params FollowLogsParams) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.FollowLogs")
+			defer span.End()
+
+			if params.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if params.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if params.BuildNumber == "" {
+				return mcp.NewToolResultError("build_number parameter is required"), nil
+			}
+			if params.JobID == "" {
+				return mcp.NewToolResultError("job_id parameter is required"), nil
+			}
+
+			// Set defaults
+			if params.Tail <= 0 {
+				params.Tail = 20
+			}
+			if params.WaitTimeout <= 0 {
+				params.WaitTimeout = 300
+			}
+			if params.PollInterval <= 0 {
+				params.PollInterval = 5
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", params.OrgSlug),
+				attribute.String("pipeline_slug", params.PipelineSlug),
+				attribute.String("build_number", params.BuildNumber),
+				attribute.String("job_id", params.JobID),
+				attribute.Int("wait_timeout", params.WaitTimeout),
+				attribute.Int("poll_interval", params.PollInterval),
+				attribute.String("timezone", params.Timezone),
+			)
+
+			loc, err := displayLocation(ctx, params.Timezone)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			var notify func(state string, entries []buildkitelogs.ParquetLogEntry, totalRows int64)
+			if mcpServer := server.ServerFromContext(ctx); mcpServer != nil && request.Params.Meta != nil && request.Params.Meta.ProgressToken != nil {
+				progressToken := request.Params.Meta.ProgressToken
+				notify = func(state string, entries []buildkitelogs.ParquetLogEntry, totalRows int64) {
+					formattedEntries := formatProgressEntries(ctx, entries, redactor, loc)
+
+					// progress is best effort, so a client which has gone away doesn't stop the follow
+					_ = mcpServer.SendNotificationToClient(ctx, "notifications/progress", map[string]any{
+						"progressToken": progressToken,
+						"progress":      totalRows,
+						"message":       fmt.Sprintf("%d new log entries, job is %s", len(entries), state),
+						"job_state":     state,
+						"entries":       formattedEntries,
+					})
+				}
+			}
+
+			// logs of a running job are always downloaded again, as the cached copy is out of date
+			logParams := JobLogsBaseParams{
+				OrgSlug:      params.OrgSlug,
+				PipelineSlug: params.PipelineSlug,
+				BuildNumber:  params.BuildNumber,
+				JobID:        params.JobID,
+				ForceRefresh: true,
+			}
+
+			// each poll checks the job's state with the API rather than reading a cached response
+			ctx, cancel := context.WithTimeout(cache.Revalidate(ctx), time.Duration(params.WaitTimeout)*time.Second)
+			defer cancel()
+
+			ticker := time.NewTicker(time.Duration(params.PollInterval) * time.Second)
+			defer ticker.Stop()
+
+			var job buildkite.Job
+			var startRow, nextRow int64 = -1, 0
+
+		FOLLOWLOOP:
+			for {
+				// the job's state is checked before reading its log, so the read after it finishes has every entry
+				build, _, err := buildsClient.Get(ctx, params.OrgSlug, params.PipelineSlug, params.BuildNumber, nil)
+				if err != nil {
+					if ctx.Err() != nil {
+						break FOLLOWLOOP
+					}
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+
+				var found bool
+				for _, j := range build.Jobs {
+					if j.ID == params.JobID {
+						job, found = j, true
+						break
+					}
+				}
+				if !found {
+					return mcp.NewToolResultError(fmt.Sprintf("job %s not found in build %s", params.JobID, params.BuildNumber)), nil
+				}
+
+				// jobs which haven't started don't have a log yet
+				if job.StartedAt != nil {
+					entries, totalRows, err := readLogRows(ctx, client, logParams, nextRow)
+					if err != nil {
+						if ctx.Err() != nil {
+							break FOLLOWLOOP
+						}
+						return mcp.NewToolResultError(fmt.Sprintf("Failed to read logs: %v", err)), nil
+					}
+
+					// entries already in the log when following started are only returned in the final tail
+					if startRow < 0 {
+						startRow = totalRows
+					} else if len(entries) > 0 && notify != nil {
+						notify(job.State, entries, totalRows)
+					}
+					nextRow = totalRows
+				}
+
+				if isTerminalJobState(job.State) {
+					break FOLLOWLOOP
+				}
+
+				select {
+				case <-ctx.Done():
+					log.Ctx(ctx).Info().Str("job_id", params.JobID).Msg("Timed out following job logs")
+					break FOLLOWLOOP
+				case <-ticker.C:
+				}
+			}
+
+			var entries []buildkitelogs.ParquetLogEntry
+			var totalRows int64
+			if job.StartedAt != nil {
+				// the last entries come from the copy cached by the last poll, read without the timed out context
+				tailParams := logParams
+				tailParams.ForceRefresh = false
+
+				entries, totalRows, err = readLogRows(context.WithoutCancel(ctx), client, tailParams, nextRow-int64(params.Tail))
+				if err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("Failed to read logs: %v", err)), nil
+				}
+			}
+
+			formattedEntries, redactions := formatLogEntries(entries, redactor, loc)
+
+			result := FollowLogsResult{
+				JobState:     job.State,
+				ExitStatus:   job.ExitStatus,
+				Finished:     isTerminalJobState(job.State),
+				Entries:      formattedEntries,
+				TotalRows:    totalRows,
+				FollowedRows: max(totalRows-max(startRow, 0), 0),
+				Redactions:   redactions,
+			}
+
+			span.SetAttributes(
+				attribute.String("job_state", job.State),
+				attribute.Bool("finished", result.Finished),
+				attribute.Int64("followed_rows", result.FollowedRows),
+			)
+
+			return mcpTextResult(span, &result)
+		},
+		[]string{"read_builds", "read_build_logs"}
+}
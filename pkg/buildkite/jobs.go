@@ -1,12 +1,16 @@
 package buildkite
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
 	"github.com/buildkite/go-buildkite/v4"
@@ -14,6 +18,11 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 )
 
+const (
+	JobSortDurationDesc = "duration_desc"
+	JobSortStartedAt    = "started_at"
+)
+
 type JobsClient interface {
 	UnblockJob(ctx context.Context, org string, pipeline string, buildNumber string, jobID string, opt *buildkite.JobUnblockOptions) (buildkite.Job, *buildkite.Response, error)
 }
@@ -24,9 +33,31 @@ type GetJobsArgs struct {
 	PipelineSlug string `json:"pipeline_slug"`
 	BuildNumber  string `json:"build_number"`
 	JobState     string `json:"job_state"`
+	Sort         string `json:"sort"`
 	IncludeAgent bool   `json:"include_agent"`
+	GroupByStep  bool   `json:"group_by_step"`
 	Page         int    `json:"page"`
 	PerPage      int    `json:"perPage"`
+	// IncludeSoftFailed defaults to true when not given
+	IncludeSoftFailed *bool `json:"include_soft_failed,omitempty"`
+	OnlySoftFailed    bool  `json:"only_soft_failed"`
+}
+
+// JobGroup is the jobs of a step collapsed into one entry, so a step with a parallelism of 100 is
+// listed once rather than 100 times. Steps without parallelism are a group of their one job.
+type JobGroup struct {
+	StepKey  string         `json:"step_key,omitempty"`
+	Label    string         `json:"label"`
+	Type     string         `json:"type,omitempty"`
+	JobCount int            `json:"job_count"`
+	ByState  map[string]int `json:"by_state"`
+	// WorstExitStatus is the first non-zero exit status of the group's jobs, or zero if they all succeeded
+	WorstExitStatus *int `json:"worst_exit_status,omitempty"`
+	// JobID is the ID of the job of a group of one
+	JobID        string   `json:"job_id,omitempty"`
+	FailedJobIDs []string `json:"failed_job_ids,omitempty"`
+	// SoftFailedCount is how many of the group's jobs failed without failing the build
+	SoftFailedCount int `json:"soft_failed_count,omitempty"`
 }
 
 // GetJobLogsArgs struct for typed parameters
@@ -59,11 +90,25 @@ func GetJobs(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFu
 				mcp.Required(),
 			),
 			mcp.WithString("job_state",
-				mcp.Description("Filter jobs by state. Supports actual states (scheduled, running, passed, failed, canceled, skipped, etc.)"),
+				mcp.Description("Filter jobs by state, or a comma-separated list of states, e.g. 'failed,timed_out,broken'. Supports actual states (scheduled, running, passed, failed, canceled, skipped, etc.)"),
+			),
+			mcp.WithString("sort",
+				mcp.Description("Order jobs by 'duration_desc', longest running first, or 'started_at', earliest first, with jobs which haven't started last. Defaults to the order of the build's steps. Grouped steps are ordered by their first job"),
+				mcp.Enum(JobSortDurationDesc, JobSortStartedAt),
 			),
 			mcp.WithBoolean("include_agent",
 				mcp.Description("Include detailed agent information in the response. When false (default), only agent ID is included to reduce response size."),
 			),
+			mcp.WithBoolean("include_soft_failed",
+				mcp.Description("Include jobs which soft failed, i.e. failed without failing the build"),
+				mcp.DefaultBool(true),
+			),
+			mcp.WithBoolean("only_soft_failed",
+				mcp.Description("Only return jobs which soft failed. Useful for finding problems hidden in a passed build"),
+			),
+			mcp.WithBoolean("group_by_step",
+				mcp.Description("Collapse the parallel jobs of each step into one entry with counts of their states, the worst exit status and the IDs of the failed jobs. Pagination then applies to the steps rather than the jobs."),
+			),
 			mcp.WithNumber("page",
 				mcp.Description("Page number for pagination (min 1)"),
 				mcp.Min(1),
@@ -93,6 +138,15 @@ func GetJobs(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFu
 				return mcp.NewToolResultError("build_number parameter is required"), nil
 			}
 
+			if args.Sort != "" && args.Sort != JobSortDurationDesc && args.Sort != JobSortStartedAt {
+				return mcp.NewToolResultError("sort must be 'duration_desc' or 'started_at'"), nil
+			}
+
+			includeSoftFailed := args.IncludeSoftFailed == nil || *args.IncludeSoftFailed
+			if args.OnlySoftFailed && !includeSoftFailed {
+				return mcp.NewToolResultError("only_soft_failed can't be used with include_soft_failed set to false"), nil
+			}
+
 			// Set defaults for pagination
 			page := args.Page
 			if page == 0 {
@@ -113,7 +167,11 @@ func GetJobs(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFu
 				attribute.String("pipeline_slug", args.PipelineSlug),
 				attribute.String("build_number", args.BuildNumber),
 				attribute.String("job_state", args.JobState),
+				attribute.String("sort", args.Sort),
 				attribute.Bool("include_agent", args.IncludeAgent),
+				attribute.Bool("group_by_step", args.GroupByStep),
+				attribute.Bool("include_soft_failed", includeSoftFailed),
+				attribute.Bool("only_soft_failed", args.OnlySoftFailed),
 				attribute.Int("page", paginationParams.Page),
 				attribute.Int("per_page", paginationParams.PerPage),
 			)
@@ -134,16 +192,38 @@ func GetJobs(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFu
 			jobs := build.Jobs
 
 			// Filter jobs by state if specified
-			if args.JobState != "" {
+			if states := jobStates(args.JobState); len(states) > 0 {
 				filteredJobs := make([]buildkite.Job, 0)
 				for _, job := range build.Jobs {
-					if job.State == args.JobState {
+					if slices.Contains(states, job.State) {
+						filteredJobs = append(filteredJobs, job)
+					}
+				}
+				jobs = filteredJobs
+			}
+
+			if args.OnlySoftFailed || !includeSoftFailed {
+				filteredJobs := make([]buildkite.Job, 0)
+				for _, job := range jobs {
+					if job.SoftFailed == args.OnlySoftFailed {
 						filteredJobs = append(filteredJobs, job)
 					}
 				}
 				jobs = filteredJobs
 			}
 
+			sortJobs(jobs, args.Sort, time.Now())
+
+			if args.GroupByStep {
+				result := applyClientSidePagination(groupJobsByStep(jobs), paginationParams)
+				r, err := json.Marshal(&result)
+				if err != nil {
+					return nil, fmt.Errorf("failed to marshal job groups: %w", err)
+				}
+
+				return mcp.NewToolResultText(string(r)), nil
+			}
+
 			// Remove agent details if not requested to reduce response size, but keep agent ID
 			if !args.IncludeAgent {
 				jobsWithoutAgent := make([]buildkite.Job, len(jobs))
@@ -167,6 +247,101 @@ func GetJobs(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFu
 		}, []string{"read_builds"}
 }
 
+// jobStates returns the states in a comma-separated list, ignoring blanks
+func jobStates(list string) []string {
+	states := []string{}
+	for _, state := range strings.Split(list, ",") {
+		if state = strings.TrimSpace(state); state != "" {
+			states = append(states, state)
+		}
+	}
+	return states
+}
+
+// jobDuration is how long a job has run for, until now if it's still running, or zero if it hasn't started
+func jobDuration(job buildkite.Job, now time.Time) time.Duration {
+	if job.StartedAt == nil {
+		return 0
+	}
+	if job.FinishedAt == nil {
+		return now.Sub(job.StartedAt.Time)
+	}
+	return job.FinishedAt.Sub(job.StartedAt.Time)
+}
+
+// sortJobs sorts jobs in place, keeping the order of the build's steps for jobs which compare equal
+func sortJobs(jobs []buildkite.Job, sort string, now time.Time) {
+	switch sort {
+	case JobSortDurationDesc:
+		slices.SortStableFunc(jobs, func(a, b buildkite.Job) int {
+			return cmp.Compare(jobDuration(b, now), jobDuration(a, now))
+		})
+	case JobSortStartedAt:
+		slices.SortStableFunc(jobs, func(a, b buildkite.Job) int {
+			switch {
+			case a.StartedAt == nil && b.StartedAt == nil:
+				return 0
+			case a.StartedAt == nil:
+				return 1
+			case b.StartedAt == nil:
+				return -1
+			}
+			return a.StartedAt.Compare(b.StartedAt.Time)
+		})
+	}
+}
+
+// groupJobsByStep collapses the parallel jobs of each step into a group, in the order the steps' first
+// jobs appear. Parallel jobs are grouped by step key, or by command for steps without a key.
+func groupJobsByStep(jobs []buildkite.Job) []JobGroup {
+	groups := []JobGroup{}
+	index := map[string]int{}
+
+	for _, job := range jobs {
+		key := "job:" + job.ID
+		if job.ParallelGroupTotal != nil {
+			if job.StepKey != "" {
+				key = "step:" + job.StepKey
+			} else {
+				key = "command:" + job.Command
+			}
+		}
+
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, JobGroup{
+				StepKey: job.StepKey,
+				Label:   jobLabel(job),
+				Type:    job.Type,
+				ByState: map[string]int{},
+				JobID:   job.ID,
+			})
+		}
+
+		group := &groups[i]
+		group.JobCount++
+		if group.JobCount > 1 {
+			group.JobID = ""
+		}
+		if job.State != "" {
+			group.ByState[job.State]++
+		}
+		if job.ExitStatus != nil && (group.WorstExitStatus == nil || *group.WorstExitStatus == 0) {
+			group.WorstExitStatus = job.ExitStatus
+		}
+		if isFailedJob(job) {
+			group.FailedJobIDs = append(group.FailedJobIDs, job.ID)
+		}
+		if job.SoftFailed {
+			group.SoftFailedCount++
+		}
+	}
+
+	return groups
+}
+
 func UnblockJob(client JobsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[UnblockJobArgs], scopes []string) {
 	return mcp.NewTool("unblock_job",
 			mcp.WithDescription("Unblock a blocked job in a Buildkite build to allow it to continue execution"),
@@ -2,9 +2,11 @@ package buildkite
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/buildkite/go-buildkite/v4"
 	"github.com/mark3labs/mcp-go/mcp"
@@ -670,3 +672,153 @@ func TestUnblockJob(t *testing.T) {
 		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "job_id parameter is required")
 	})
 }
+
+func TestGetJobsGroupByStep(t *testing.T) {
+	assert := require.New(t)
+
+	total := 3
+	index := func(i int) *int { return &i }
+	exitStatus := func(status int) *int { return &status }
+
+	client := &MockBuildsClient{
+		GetFunc: func(ctx context.Context, org string, pipeline string, id string, opt *buildkite.BuildGetOptions) (buildkite.Build, *buildkite.Response, error) {
+			return buildkite.Build{
+					ID:     "123",
+					Number: 1,
+					State:  "failed",
+					Jobs: []buildkite.Job{
+						{ID: "lint", Type: "script", Label: "lint", State: "passed", ExitStatus: exitStatus(0)},
+						{ID: "test-0", Type: "script", StepKey: "test", Label: "test 1/3", State: "passed", ExitStatus: exitStatus(0), ParallelGroupIndex: index(0), ParallelGroupTotal: &total},
+						{ID: "test-1", Type: "script", StepKey: "test", Label: "test 2/3", State: "failed", ExitStatus: exitStatus(2), ParallelGroupIndex: index(1), ParallelGroupTotal: &total},
+						{ID: "test-2", Type: "script", StepKey: "test", Label: "test 3/3", State: "failed", ExitStatus: exitStatus(1), ParallelGroupIndex: index(2), ParallelGroupTotal: &total},
+					},
+				}, &buildkite.Response{
+					Response: &http.Response{
+						StatusCode: 200,
+					},
+				}, nil
+		},
+	}
+
+	_, handler, _ := GetJobs(client)
+	result, err := handler(context.Background(), createMCPRequest(t, map[string]any{}), GetJobsArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+		BuildNumber:  "1",
+		GroupByStep:  true,
+	})
+	assert.NoError(err)
+
+	var groups ClientSidePaginatedResult[JobGroup]
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &groups))
+	assert.Len(groups.Items, 2)
+
+	assert.Equal("lint", groups.Items[0].JobID)
+	assert.Equal(1, groups.Items[0].JobCount)
+	assert.Equal(0, *groups.Items[0].WorstExitStatus)
+
+	test := groups.Items[1]
+	assert.Equal("test", test.StepKey)
+	assert.Empty(test.JobID)
+	assert.Equal(3, test.JobCount)
+	assert.Equal(map[string]int{"passed": 1, "failed": 2}, test.ByState)
+	assert.Equal(2, *test.WorstExitStatus)
+	assert.Equal([]string{"test-1", "test-2"}, test.FailedJobIDs)
+}
+
+func TestGetJobsSoftFailedFilters(t *testing.T) {
+	assert := require.New(t)
+
+	client := &MockBuildsClient{
+		GetFunc: func(ctx context.Context, org string, pipeline string, id string, opt *buildkite.BuildGetOptions) (buildkite.Build, *buildkite.Response, error) {
+			return buildkite.Build{
+					ID:     "123",
+					Number: 1,
+					State:  "passed",
+					Jobs: []buildkite.Job{
+						{ID: "job1", State: "passed"},
+						{ID: "job2", State: "failed", SoftFailed: true},
+					},
+				}, &buildkite.Response{
+					Response: &http.Response{
+						StatusCode: 200,
+					},
+				}, nil
+		},
+	}
+
+	_, handler, _ := GetJobs(client)
+	getJobs := func(args GetJobsArgs) *mcp.CallToolResult {
+		args.OrgSlug, args.PipelineSlug, args.BuildNumber = "org", "pipeline", "1"
+		result, err := handler(context.Background(), createMCPRequest(t, map[string]any{}), args)
+		assert.NoError(err)
+		return result
+	}
+
+	text := getTextResult(t, getJobs(GetJobsArgs{OnlySoftFailed: true})).Text
+	assert.Contains(text, `"job2"`)
+	assert.NotContains(text, `"job1"`)
+
+	exclude := false
+	text = getTextResult(t, getJobs(GetJobsArgs{IncludeSoftFailed: &exclude})).Text
+	assert.Contains(text, `"job1"`)
+	assert.NotContains(text, `"job2"`)
+
+	result := getJobs(GetJobsArgs{IncludeSoftFailed: &exclude, OnlySoftFailed: true})
+	assert.True(result.IsError)
+}
+
+func TestGetJobsMultipleStatesAndSort(t *testing.T) {
+	assert := require.New(t)
+
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := func(minutes int) *buildkite.Timestamp {
+		return buildkite.NewTimestamp(start.Add(time.Duration(minutes) * time.Minute))
+	}
+
+	client := &MockBuildsClient{
+		GetFunc: func(ctx context.Context, org string, pipeline string, id string, opt *buildkite.BuildGetOptions) (buildkite.Build, *buildkite.Response, error) {
+			return buildkite.Build{
+					ID:     "123",
+					Number: 1,
+					State:  "failed",
+					Jobs: []buildkite.Job{
+						{ID: "job1", State: "failed", StartedAt: at(2), FinishedAt: at(5)},
+						{ID: "job2", State: "passed", StartedAt: at(0), FinishedAt: at(30)},
+						{ID: "job3", State: "timed_out", StartedAt: at(1), FinishedAt: at(21)},
+						{ID: "job4", State: "broken"},
+						{ID: "job5", State: "failed", StartedAt: at(0), FinishedAt: at(10)},
+					},
+				}, &buildkite.Response{
+					Response: &http.Response{
+						StatusCode: 200,
+					},
+				}, nil
+		},
+	}
+
+	_, handler, _ := GetJobs(client)
+	getJobIDs := func(args GetJobsArgs) []string {
+		args.OrgSlug, args.PipelineSlug, args.BuildNumber = "org", "pipeline", "1"
+		result, err := handler(context.Background(), createMCPRequest(t, map[string]any{}), args)
+		assert.NoError(err)
+		assert.False(result.IsError, getTextResult(t, result).Text)
+
+		var jobs ClientSidePaginatedResult[buildkite.Job]
+		assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &jobs))
+		ids := []string{}
+		for _, job := range jobs.Items {
+			ids = append(ids, job.ID)
+		}
+		return ids
+	}
+
+	assert.Equal([]string{"job1", "job3", "job4", "job5"}, getJobIDs(GetJobsArgs{JobState: "failed, timed_out,broken"}))
+	assert.Equal([]string{"job3", "job5", "job1", "job4"}, getJobIDs(GetJobsArgs{JobState: "failed,timed_out,broken", Sort: JobSortDurationDesc}))
+	assert.Equal([]string{"job2", "job5", "job3", "job1", "job4"}, getJobIDs(GetJobsArgs{Sort: JobSortStartedAt}))
+
+	result, err := handler(context.Background(), createMCPRequest(t, map[string]any{}), GetJobsArgs{OrgSlug: "org", PipelineSlug: "pipeline", BuildNumber: "1", Sort: "duration"})
+	assert.NoError(err)
+	assert.True(result.IsError)
+	assert.Equal("sort must be 'duration_desc' or 'started_at'", getTextResult(t, result).Text)
+}
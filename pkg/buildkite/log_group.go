@@ -0,0 +1,215 @@
+package buildkite
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+	"github.com/buildkite/buildkite-mcp-server/pkg/redact"
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	logGroupFormatJSON = "json"
+	logGroupFormatText = "text"
+)
+
+type ReadLogGroupParams struct {
+	JobLogsBaseParams
+	Group         string `json:"group"`
+	CaseSensitive bool   `json:"case_sensitive"`
+	Limit         int    `json:"limit"`
+	Tail          bool   `json:"tail"`
+	Format        string `json:"format"`
+	Timezone      string `json:"timezone"`
+}
+
+// LogGroupMatch is a group of the log whose name matched, with the rows it spans
+type LogGroupMatch struct {
+	Name     string `json:"name"`
+	StartRow int64  `json:"start_row"`
+	EndRow   int64  `json:"end_row"`
+	Rows     int64  `json:"rows"`
+}
+
+type ReadLogGroupResult struct {
+	Groups []LogGroupMatch `json:"groups"`
+	// Entries are the entries of the matching groups, either terse entries or lines of text
+	Entries any `json:"entries"`
+	// MatchedRows is the number of entries in the matching groups, of which at most limit are returned
+	MatchedRows int64 `json:"matched_rows"`
+	Truncated   bool  `json:"truncated,omitempty"`
+	Redactions  int   `json:"redactions,omitempty"`
+	QueryTimeMS int64 `json:"query_time_ms"`
+}
+
+// formatLogGroupText formats the entries as lines of text prefixed with their row numbers
+func formatLogGroupText(entries []buildkitelogs.ParquetLogEntry, redactor *redact.Redactor) (string, int) {
+	redactions := 0
+	lines := redactor.Lines()
+	var sb strings.Builder
+	for _, entry := range entries {
+		content, n := lines.Redact(entry.CleanContent(true))
+		redactions += n
+		fmt.Fprintf(&sb, "%d: %s\n", entry.RowNumber, content)
+	}
+	return sb.String(), redactions
+}
+
+// ReadLogGroup implements the read_log_group MCP tool
+func ReadLogGroup(client BuildkiteLogsClient, redactor *redact.Redactor) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[ReadLogGroupParams], scopes []string) {
+	return mcp.NewTool("read_log_group",
+			mcp.WithDescription("Read only the entries of the log groups (the sections started by ---, ~~~ or +++ headers) whose name matches a regex, such as 'Run tests' or ':rspec:', without working out their row ranges first. summarize_log lists the groups of a log. The json format: {ts: timestamp_ms, t: timestamp in the display timezone, c: content, rn: row_number}."),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("build_number",
+				mcp.Required(),
+			),
+			mcp.WithString("job_id",
+				mcp.Required(),
+			),
+			mcp.WithString("group",
+				mcp.Required(),
+				mcp.Description("Regular expression matched against group names, without their ---, ~~~ or +++ prefix"),
+			),
+			mcp.WithBoolean("case_sensitive",
+				mcp.Description("Match group names case sensitively (default: false)"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Limit number of entries returned (default: 100, 0 = no limit)"),
+				mcp.Min(0),
+				mcp.DefaultNumber(100),
+			),
+			mcp.WithBoolean("tail",
+				mcp.Description("Return the last entries of the matching groups rather than the first, as a failing command's output usually ends its group (default: false)"),
+			),
+			mcp.WithString("format",
+				mcp.Description("Return the entries as json objects, or as lines of text prefixed with their row number, which takes fewer tokens (default: json)"),
+				mcp.Enum(logGroupFormatJSON, logGroupFormatText),
+			),
+			mcp.WithString("cache_ttl",
+				mcp.Description(`Cache TTL for non-terminal jobs (default: "30s")`),
+			),
+			mcp.WithBoolean("force_refresh",
+				mcp.Description("Force refresh cached entry (default: false)"),
+			),
+			withTimezone(),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Read Log Group",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, params ReadLogGroupParams) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.ReadLogGroup")
+			defer span.End()
+
+			startTime := time.Now()
+
+			if params.Group == "" {
+				return mcp.NewToolResultError("group parameter is required"), nil
+			}
+			if params.Format == "" {
+				params.Format = logGroupFormatJSON
+			}
+			if params.Format != logGroupFormatJSON && params.Format != logGroupFormatText {
+				return mcp.NewToolResultError(fmt.Sprintf("format must be %q or %q", logGroupFormatJSON, logGroupFormatText)), nil
+			}
+			// limit may be 0 for no limit, so only its default is applied
+			if _, ok := request.GetArguments()["limit"]; !ok {
+				params.Limit = 100
+			}
+
+			pattern := params.Group
+			if !params.CaseSensitive {
+				pattern = "(?i)" + pattern
+			}
+			groupPattern, err := regexp.Compile(pattern)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("invalid regex pattern: %v", err)), nil
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", params.OrgSlug),
+				attribute.String("pipeline_slug", params.PipelineSlug),
+				attribute.String("build_number", params.BuildNumber),
+				attribute.String("job_id", params.JobID),
+				attribute.String("group", params.Group),
+				attribute.Int("limit", params.Limit),
+				attribute.Bool("tail", params.Tail),
+				attribute.String("format", params.Format),
+			)
+
+			loc, err := displayLocation(ctx, params.Timezone)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			reader, err := newParquetReader(ctx, client, params.JobLogsBaseParams)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to create log reader: %v", err)), nil
+			}
+
+			result := ReadLogGroupResult{Groups: []LogGroupMatch{}}
+			var entries []buildkitelogs.ParquetLogEntry
+
+			// entries carry the header of their group, which changes at each new group
+			currentGroup, matched := "", false
+			for entry, err := range reader.ReadEntriesIter() {
+				if err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("Failed to read entries: %v", err)), nil
+				}
+
+				if entry.Group != currentGroup {
+					currentGroup = entry.Group
+					matched = entry.Group != "" && groupPattern.MatchString(groupName(entry.Group))
+					if matched {
+						result.Groups = append(result.Groups, LogGroupMatch{Name: groupName(entry.Group), StartRow: entry.RowNumber})
+					}
+				}
+				if !matched {
+					continue
+				}
+
+				group := &result.Groups[len(result.Groups)-1]
+				group.EndRow = entry.RowNumber
+				group.Rows++
+				result.MatchedRows++
+
+				switch {
+				case params.Limit == 0 || len(entries) < params.Limit:
+					entries = append(entries, entry)
+				case params.Tail:
+					// keep the last limit entries
+					entries = append(entries[1:], entry)
+				}
+			}
+
+			if len(result.Groups) == 0 {
+				return mcp.NewToolResultError(fmt.Sprintf("no log group matches %q, use summarize_log to list the groups of the log", params.Group)), nil
+			}
+			result.Truncated = int64(len(entries)) < result.MatchedRows
+
+			if params.Format == logGroupFormatText {
+				result.Entries, result.Redactions = formatLogGroupText(entries, redactor)
+			} else {
+				result.Entries, result.Redactions = formatLogEntries(entries, redactor, loc)
+			}
+			result.QueryTimeMS = time.Since(startTime).Milliseconds()
+
+			span.SetAttributes(
+				attribute.Int("item_count", len(entries)),
+			)
+
+			return mcpTextResult(span, &result)
+		},
+		[]string{"read_build_logs"}
+}
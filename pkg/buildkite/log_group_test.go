@@ -0,0 +1,73 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadLogGroup(t *testing.T) {
+	assert := require.New(t)
+
+	path := writeTestGroupedLog(t,
+		"preamble",
+		"--- :go: Build",
+		"go build ./...",
+		"+++ :test_tube: Run tests",
+		"=== RUN TestA",
+		"=== RUN TestB",
+		"--- :test_tube: Run tests again",
+		"=== RUN TestC",
+		"~~~ Cleanup",
+		"done",
+	)
+	client := &MockBuildkiteLogsClient{
+		DownloadAndCacheFunc: func(ctx context.Context, org, pipeline, build, job string, cacheTTL time.Duration, forceRefresh bool) (string, error) {
+			return path, nil
+		},
+	}
+
+	tool, handler, scopes := ReadLogGroup(client, nil)
+	assert.Equal("read_log_group", tool.Name)
+	assert.Equal([]string{"read_build_logs"}, scopes)
+
+	base := JobLogsBaseParams{OrgSlug: "org", PipelineSlug: "pipeline", BuildNumber: "1", JobID: "job-1"}
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"limit": 2}
+
+	result, err := handler(context.Background(), request, ReadLogGroupParams{JobLogsBaseParams: base, Group: "run TESTS", Limit: 2, Tail: true, Format: "text"})
+	assert.NoError(err)
+
+	var read ReadLogGroupResult
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &read))
+	assert.Equal([]LogGroupMatch{
+		{Name: ":test_tube: Run tests", StartRow: 3, EndRow: 5, Rows: 3},
+		{Name: ":test_tube: Run tests again", StartRow: 6, EndRow: 7, Rows: 2},
+	}, read.Groups)
+	assert.Equal(int64(5), read.MatchedRows)
+	assert.True(read.Truncated)
+	assert.Equal("6: --- :test_tube: Run tests again\n7: === RUN TestC\n", read.Entries)
+
+	request.Params.Arguments = map[string]any{}
+	result, err = handler(context.Background(), request, ReadLogGroupParams{JobLogsBaseParams: base, Group: "^:go:"})
+	assert.NoError(err)
+	var entries struct {
+		Entries []TerseLogEntry `json:"entries"`
+	}
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &entries))
+	assert.Len(entries.Entries, 2)
+	assert.Equal("go build ./...", entries.Entries[1].C)
+
+	result, err = handler(context.Background(), request, ReadLogGroupParams{JobLogsBaseParams: base, Group: "deploy"})
+	assert.NoError(err)
+	assert.True(result.IsError)
+	assert.Contains(getTextResult(t, result).Text, "summarize_log")
+
+	result, err = handler(context.Background(), request, ReadLogGroupParams{JobLogsBaseParams: base, Group: "("})
+	assert.NoError(err)
+	assert.True(result.IsError)
+}
@@ -0,0 +1,498 @@
+package buildkite
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+	"github.com/buildkite/buildkite-mcp-server/pkg/redact"
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// defaultLogSummarySignatures is how many error and warning signatures are returned by default
+	defaultLogSummarySignatures = 10
+	// defaultLogSummaryBlocks is how many error blocks are returned by default
+	defaultLogSummaryBlocks = 5
+	// maxLogSummaryGroups bounds the groups returned, keeping the failed and slowest ones
+	maxLogSummaryGroups = 30
+	// maxLogBlockLines bounds the lines returned for a single error block
+	maxLogBlockLines = 20
+	// logBlockGap is how many lines without an error may separate two error lines of the same block
+	logBlockGap = 2
+	// maxLogSignatures bounds the distinct signatures tracked, so a log of unique lines can't exhaust memory
+	maxLogSignatures = 50000
+	// maxSignatureLength truncates signatures and samples, as a cluster is identified by its start
+	maxSignatureLength = 200
+)
+
+var (
+	errorLinePattern   = regexp.MustCompile(`(?i)\b(error|errors|fatal|panic|exception|failed|failure|traceback)\b|^e\d{4} |\bfail:`)
+	warningLinePattern = regexp.MustCompile(`(?i)\b(warn|warning|warnings|deprecated|deprecation)\b`)
+	// noErrorPattern matches lines which mention errors without reporting any, such as test summaries
+	noErrorPattern = regexp.MustCompile(`(?i)\b(0|no) (errors?|failures?|failed)\b|\bfailed: 0\b|\berrors?: 0\b`)
+	// failedCommandPattern matches the agent's report of a command exiting unsuccessfully
+	failedCommandPattern = regexp.MustCompile(`(?i)exited with (status|code) [1-9]\d*|^\^\^\^ \+\+\+$`)
+
+	signatureTimestamp = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[t ]\d{2}:\d{2}:\d{2}(\.\d+)?(z|[+-]\d{2}:?\d{2})?`)
+	signatureUUID      = regexp.MustCompile(`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`)
+	signatureHex       = regexp.MustCompile(`\b(0x)?[0-9a-f]{7,}\b`)
+	signatureNumber    = regexp.MustCompile(`\d+(\.\d+)?`)
+	signatureSpace     = regexp.MustCompile(`\s+`)
+)
+
+type SummarizeLogParams struct {
+	JobLogsBaseParams
+	MaxSignatures int `json:"max_signatures"`
+	MaxBlocks     int `json:"max_blocks"`
+}
+
+// LogSignature is a cluster of log lines which only differ in numbers, ids and timestamps
+type LogSignature struct {
+	Signature string `json:"signature"`
+	Count     int    `json:"count"`
+	// Sample is the first line of the cluster
+	Sample   string `json:"sample"`
+	FirstRow int64  `json:"first_row"`
+	LastRow  int64  `json:"last_row"`
+	Group    string `json:"group,omitempty"`
+}
+
+// LogGroupSummary is a group of the log, as started by a ---, ~~~ or +++ header
+type LogGroupSummary struct {
+	Name            string  `json:"name"`
+	StartRow        int64   `json:"start_row"`
+	EndRow          int64   `json:"end_row"`
+	DurationSeconds float64 `json:"duration_seconds,omitempty"`
+	Errors          int     `json:"errors,omitempty"`
+	Warnings        int     `json:"warnings,omitempty"`
+	Failed          bool    `json:"failed,omitempty"`
+}
+
+// LogBlock is a run of error lines close to each other, with the lines between them
+type LogBlock struct {
+	Group    string   `json:"group,omitempty"`
+	StartRow int64    `json:"start_row"`
+	EndRow   int64    `json:"end_row"`
+	Lines    []string `json:"lines"`
+	// Truncated is set when the block had more lines than were returned
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+type LogSummary struct {
+	TotalRows     int64             `json:"total_rows"`
+	ErrorLines    int               `json:"error_lines"`
+	WarningLines  int               `json:"warning_lines"`
+	FailedGroups  []string          `json:"failed_groups,omitempty"`
+	TopErrors     []LogSignature    `json:"top_errors"`
+	TopWarnings   []LogSignature    `json:"top_warnings"`
+	RepeatedLines []LogSignature    `json:"repeated_lines,omitempty"`
+	ErrorBlocks   []LogBlock        `json:"error_blocks"`
+	Groups        []LogGroupSummary `json:"groups"`
+	// GroupCount is the number of groups in the log, of which only the failed and slowest are returned
+	GroupCount  int   `json:"group_count"`
+	Redactions  int   `json:"redactions,omitempty"`
+	QueryTimeMS int64 `json:"query_time_ms"`
+}
+
+// logSignature normalizes a line to the signature of its cluster, replacing the parts which vary
+// between repeats of the same message
+func logSignature(line string) string {
+	signature := strings.ToLower(line)
+	signature = signatureTimestamp.ReplaceAllString(signature, "<ts>")
+	signature = signatureUUID.ReplaceAllString(signature, "<id>")
+	signature = signatureHex.ReplaceAllString(signature, "<hex>")
+	signature = signatureNumber.ReplaceAllString(signature, "<n>")
+	signature = signatureSpace.ReplaceAllString(signature, " ")
+	return truncateLogLine(strings.TrimSpace(signature))
+}
+
+// truncateLogLine shortens a line to maxSignatureLength characters, marking where it was cut
+func truncateLogLine(line string) string {
+	if truncated, cut := truncateRunes(line, maxSignatureLength); cut {
+		return truncated + "…"
+	}
+	return line
+}
+
+// groupName returns the name of a group header without its ---, ~~~ or +++ prefix
+func groupName(header string) string {
+	header = buildkitelogs.StripANSI(header)
+	for _, prefix := range []string{"--- ", "~~~ ", "+++ "} {
+		if name, ok := strings.CutPrefix(header, prefix); ok {
+			return strings.TrimSpace(name)
+		}
+	}
+	return strings.TrimSpace(header)
+}
+
+// signatureCounter clusters lines by their signature
+type signatureCounter struct {
+	clusters map[string]*LogSignature
+}
+
+func newSignatureCounter() *signatureCounter {
+	return &signatureCounter{clusters: map[string]*LogSignature{}}
+}
+
+func (c *signatureCounter) add(line string, row int64, group string) {
+	signature := logSignature(line)
+	if signature == "" {
+		return
+	}
+
+	cluster, ok := c.clusters[signature]
+	if !ok {
+		if len(c.clusters) >= maxLogSignatures {
+			return
+		}
+		cluster = &LogSignature{Signature: signature, Sample: truncateLogLine(line), FirstRow: row, Group: group}
+		c.clusters[signature] = cluster
+	}
+	cluster.Count++
+	cluster.LastRow = row
+}
+
+// top returns the n largest clusters with at least minCount lines, largest first
+func (c *signatureCounter) top(n, minCount int) []LogSignature {
+	signatures := make([]LogSignature, 0, len(c.clusters))
+	for _, cluster := range c.clusters {
+		if cluster.Count >= minCount {
+			signatures = append(signatures, *cluster)
+		}
+	}
+	slices.SortFunc(signatures, func(a, b LogSignature) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.FirstRow, b.FirstRow))
+	})
+	if len(signatures) > n {
+		signatures = signatures[:n]
+	}
+	return signatures
+}
+
+// logSummarizer accumulates the summary of a log one entry at a time
+type logSummarizer struct {
+	maxBlocks int
+
+	summary  LogSummary
+	errors   *signatureCounter
+	warnings *signatureCounter
+	lines    *signatureCounter
+
+	groups     []LogGroupSummary
+	groupStart []int64 // timestamp of the first entry of each group, 0 when it has none
+	lastTime   int64
+
+	block     *LogBlock
+	lastError int64
+	// linesAtError is how many lines the block had at its last error line
+	linesAtError int
+	blocks       []LogBlock
+}
+
+func newLogSummarizer(maxBlocks int) *logSummarizer {
+	return &logSummarizer{
+		maxBlocks: maxBlocks,
+		errors:    newSignatureCounter(),
+		warnings:  newSignatureCounter(),
+		lines:     newSignatureCounter(),
+		lastError: -1,
+	}
+}
+
+func (s *logSummarizer) add(entry buildkitelogs.ParquetLogEntry) {
+	line := entry.CleanContent(true)
+	row := entry.RowNumber
+	s.summary.TotalRows++
+
+	if entry.IsGroup() || len(s.groups) == 0 {
+		// close the previous group at this entry, which starts the next one
+		if n := len(s.groups); n > 0 && s.groupStart[n-1] > 0 && entry.HasTime() {
+			s.groups[n-1].DurationSeconds = float64(entry.Timestamp-s.groupStart[n-1]) / 1000
+		}
+
+		name := ""
+		if entry.IsGroup() {
+			name = groupName(line)
+		}
+		var start int64
+		if entry.HasTime() {
+			start = entry.Timestamp
+		}
+		s.groups = append(s.groups, LogGroupSummary{Name: name, StartRow: row})
+		s.groupStart = append(s.groupStart, start)
+	}
+
+	group := &s.groups[len(s.groups)-1]
+	group.EndRow = row
+	if entry.HasTime() {
+		s.lastTime = entry.Timestamp
+		if s.groupStart[len(s.groups)-1] == 0 {
+			s.groupStart[len(s.groups)-1] = entry.Timestamp
+		}
+	}
+
+	if line == "" {
+		return
+	}
+	s.lines.add(line, row, group.Name)
+
+	if failedCommandPattern.MatchString(line) {
+		group.Failed = true
+	}
+
+	switch {
+	case errorLinePattern.MatchString(line) && !noErrorPattern.MatchString(line):
+		s.summary.ErrorLines++
+		group.Errors++
+		s.errors.add(line, row, group.Name)
+		s.addToBlock(line, row, group.Name, true)
+	case warningLinePattern.MatchString(line):
+		s.summary.WarningLines++
+		group.Warnings++
+		s.warnings.add(line, row, group.Name)
+		s.addToBlock(line, row, group.Name, false)
+	default:
+		s.addToBlock(line, row, group.Name, false)
+	}
+}
+
+// addToBlock extends the current error block with the line, starting a new block at an error line
+// too far from the last
+func (s *logSummarizer) addToBlock(line string, row int64, group string, isError bool) {
+	if s.block != nil && row-s.lastError > logBlockGap {
+		s.closeBlock()
+	}
+
+	if s.block == nil {
+		if !isError {
+			return
+		}
+		s.block = &LogBlock{Group: group, StartRow: row}
+	}
+
+	if len(s.block.Lines) < maxLogBlockLines {
+		s.block.Lines = append(s.block.Lines, line)
+	} else {
+		s.block.Truncated = true
+	}
+	if isError {
+		s.lastError = row
+		s.linesAtError = len(s.block.Lines)
+	}
+}
+
+// closeBlock ends the current block at its last error line, keeping only the latest blocks as the
+// errors nearest the end of a log are usually the ones which failed it
+func (s *logSummarizer) closeBlock() {
+	if s.block == nil {
+		return
+	}
+
+	// drop the lines read after the last error while looking for another
+	s.block.Lines = s.block.Lines[:s.linesAtError]
+	s.block.EndRow = s.lastError
+
+	s.blocks = append(s.blocks, *s.block)
+	if len(s.blocks) > s.maxBlocks {
+		s.blocks = s.blocks[1:]
+	}
+	s.block = nil
+}
+
+func (s *logSummarizer) finish(maxSignatures int) LogSummary {
+	s.closeBlock()
+
+	if n := len(s.groups); n > 0 && s.groupStart[n-1] > 0 && s.lastTime > s.groupStart[n-1] {
+		s.groups[n-1].DurationSeconds = float64(s.lastTime-s.groupStart[n-1]) / 1000
+	}
+
+	summary := s.summary
+	summary.TopErrors = s.errors.top(maxSignatures, 1)
+	summary.TopWarnings = s.warnings.top(maxSignatures, 1)
+	summary.RepeatedLines = s.lines.top(maxSignatures, 3)
+	summary.ErrorBlocks = s.blocks
+	if summary.ErrorBlocks == nil {
+		summary.ErrorBlocks = []LogBlock{}
+	}
+
+	// groups without a header hold the lines before the first one, which are only worth
+	// returning when something happened in them
+	groups := make([]LogGroupSummary, 0, len(s.groups))
+	for _, group := range s.groups {
+		if group.Name == "" && group.Errors == 0 && !group.Failed {
+			continue
+		}
+		groups = append(groups, group)
+		if group.Failed {
+			summary.FailedGroups = append(summary.FailedGroups, group.Name)
+		}
+	}
+	summary.GroupCount = len(groups)
+	summary.Groups = selectLogGroups(groups, maxLogSummaryGroups)
+
+	return summary
+}
+
+// selectLogGroups returns up to n groups in log order, preferring failed groups, then those with
+// errors, then the slowest
+func selectLogGroups(groups []LogGroupSummary, n int) []LogGroupSummary {
+	if len(groups) <= n {
+		return groups
+	}
+
+	ranked := slices.Clone(groups)
+	slices.SortStableFunc(ranked, func(a, b LogGroupSummary) int {
+		if a.Failed != b.Failed {
+			if a.Failed {
+				return -1
+			}
+			return 1
+		}
+		if (a.Errors > 0) != (b.Errors > 0) {
+			if a.Errors > 0 {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(b.DurationSeconds, a.DurationSeconds)
+	})
+	ranked = ranked[:n]
+
+	slices.SortFunc(ranked, func(a, b LogGroupSummary) int {
+		return cmp.Compare(a.StartRow, b.StartRow)
+	})
+	return ranked
+}
+
+// redactLogSummary redacts secrets from the lines of the summary in place
+func redactLogSummary(summary *LogSummary, redactor *redact.Redactor) {
+	redactSignatures := func(signatures []LogSignature) {
+		for i := range signatures {
+			var n int
+			signatures[i].Sample, n = redactor.Redact(signatures[i].Sample)
+			summary.Redactions += n
+			signatures[i].Signature, n = redactor.Redact(signatures[i].Signature)
+			summary.Redactions += n
+		}
+	}
+	redactSignatures(summary.TopErrors)
+	redactSignatures(summary.TopWarnings)
+	redactSignatures(summary.RepeatedLines)
+
+	for i := range summary.ErrorBlocks {
+		lines := redactor.Lines()
+		for j := range summary.ErrorBlocks[i].Lines {
+			var n int
+			summary.ErrorBlocks[i].Lines[j], n = lines.Redact(summary.ErrorBlocks[i].Lines[j])
+			summary.Redactions += n
+		}
+	}
+}
+
+// SummarizeLog implements the summarize_log MCP tool
+func SummarizeLog(client BuildkiteLogsClient, redactor *redact.Redactor) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[SummarizeLogParams], scopes []string) {
+	return mcp.NewTool("summarize_log",
+			mcp.WithDescription("Summarize a whole job log in one compact result: the most frequent error and warning signatures, with lines differing only in numbers, ids and timestamps clustered together, the last blocks of error lines, the groups whose commands failed and the timings of the slowest groups. 🔥 RECOMMENDED first step for long logs (100k+ lines), then read the rows it points at with read_logs"),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("build_number",
+				mcp.Required(),
+			),
+			mcp.WithString("job_id",
+				mcp.Required(),
+			),
+			mcp.WithNumber("max_signatures",
+				mcp.Description("Maximum number of error, warning and repeated line signatures returned (default: 10)"),
+				mcp.Min(1),
+				mcp.DefaultNumber(defaultLogSummarySignatures),
+			),
+			mcp.WithNumber("max_blocks",
+				mcp.Description("Maximum number of error blocks returned, keeping the last ones in the log (default: 5)"),
+				mcp.Min(0),
+				mcp.DefaultNumber(defaultLogSummaryBlocks),
+			),
+			mcp.WithString("cache_ttl",
+				mcp.Description(`Cache TTL for non-terminal jobs (default: "30s")`),
+			),
+			mcp.WithBoolean("force_refresh",
+				mcp.Description("Force refresh cached entry (default: false)"),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Summarize Log",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, params SummarizeLogParams) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.SummarizeLog")
+			defer span.End()
+
+			startTime := time.Now()
+
+			if params.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if params.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if params.BuildNumber == "" {
+				return mcp.NewToolResultError("build_number parameter is required"), nil
+			}
+			if params.JobID == "" {
+				return mcp.NewToolResultError("job_id parameter is required"), nil
+			}
+
+			if params.MaxSignatures <= 0 {
+				params.MaxSignatures = defaultLogSummarySignatures
+			}
+			// max_blocks may be 0 to leave the blocks out, so only its default is applied
+			if _, ok := request.GetArguments()["max_blocks"]; !ok {
+				params.MaxBlocks = defaultLogSummaryBlocks
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", params.OrgSlug),
+				attribute.String("pipeline_slug", params.PipelineSlug),
+				attribute.String("build_number", params.BuildNumber),
+				attribute.String("job_id", params.JobID),
+				attribute.Int("max_signatures", params.MaxSignatures),
+				attribute.Int("max_blocks", params.MaxBlocks),
+			)
+
+			reader, err := newParquetReader(ctx, client, params.JobLogsBaseParams)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to create log reader: %v", err)), nil
+			}
+
+			summarizer := newLogSummarizer(max(params.MaxBlocks, 0))
+			for entry, err := range reader.ReadEntriesIter() {
+				if err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("Failed to read entries: %v", err)), nil
+				}
+				summarizer.add(entry)
+			}
+
+			summary := summarizer.finish(params.MaxSignatures)
+			redactLogSummary(&summary, redactor)
+			summary.QueryTimeMS = time.Since(startTime).Milliseconds()
+
+			span.SetAttributes(
+				attribute.Int64("total_rows", summary.TotalRows),
+				attribute.Int("error_lines", summary.ErrorLines),
+			)
+
+			return mcpTextResult(span, &summary)
+		},
+		[]string{"read_build_logs"}
+}
@@ -0,0 +1,128 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"iter"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+	"github.com/buildkite/buildkite-mcp-server/pkg/redact"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestGroupedLog writes a log whose lines are a second apart, assigning them to groups the
+// way the log parser does
+func writeTestGroupedLog(t *testing.T, lines ...string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "log.parquet")
+	start := time.Now().Add(-time.Hour)
+	seq := func(yield func(*buildkitelogs.LogEntry, error) bool) {
+		group := ""
+		for i, line := range lines {
+			entry := &buildkitelogs.LogEntry{Timestamp: start.Add(time.Duration(i) * time.Second), Content: line}
+			if entry.IsGroup() {
+				group = line
+			}
+			entry.Group = group
+			if !yield(entry, nil) {
+				return
+			}
+		}
+	}
+	require.NoError(t, buildkitelogs.ExportSeq2ToParquet(iter.Seq2[*buildkitelogs.LogEntry, error](seq), path))
+
+	return path
+}
+
+func TestLogSignature(t *testing.T) {
+	assert := require.New(t)
+
+	assert.Equal(logSignature("ERROR 2024-05-01T10:00:00Z request 42 failed after 1.5s"), logSignature("error 2024-05-02T11:30:00Z request 7 failed after 30.25s"))
+	assert.Equal(logSignature("commit deadbeef1234 id 123e4567-e89b-12d3-a456-426614174000"), logSignature("commit cafebabe9876 id 00000000-0000-0000-0000-000000000000"))
+	assert.NotEqual(logSignature("error: connection refused"), logSignature("error: no such host"))
+}
+
+func TestSummarizeLog(t *testing.T) {
+	assert := require.New(t)
+
+	lines := []string{
+		"--- :go: Build",
+		"go build ./...",
+		"--- :test_tube: Test",
+		"ok  example.com/a 0.1s",
+		"WARNING: deprecated flag --foo",
+	}
+	for i := range 50 {
+		lines = append(lines, "downloading chunk "+strings.Repeat("x", i%3))
+	}
+	lines = append(lines,
+		"Error: connection refused to 10.0.0.1:5432 token=secret-value",
+		"    at db.connect",
+		"Error: connection refused to 10.0.0.2:5432 token=secret-value",
+		"TestDatabase failed after 0.50s",
+		"0 errors in lint",
+		"🚨 Error: The command exited with status 1",
+		"^^^ +++",
+		"~~~ Running post-command hook",
+		"cleanup done",
+	)
+	path := writeTestGroupedLog(t, lines...)
+
+	client := &MockBuildkiteLogsClient{
+		DownloadAndCacheFunc: func(ctx context.Context, org, pipeline, build, job string, cacheTTL time.Duration, forceRefresh bool) (string, error) {
+			return path, nil
+		},
+	}
+	redactor, err := redact.New([]string{`secret-value`})
+	assert.NoError(err)
+
+	tool, handler, scopes := SummarizeLog(client, redactor)
+	assert.Equal("summarize_log", tool.Name)
+	assert.Equal([]string{"read_build_logs"}, scopes)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{}
+	result, err := handler(context.Background(), request, SummarizeLogParams{
+		JobLogsBaseParams: JobLogsBaseParams{OrgSlug: "org", PipelineSlug: "pipeline", BuildNumber: "1", JobID: "job-1"},
+	})
+	assert.NoError(err)
+
+	text := getTextResult(t, result).Text
+	assert.NotContains(text, "secret-value")
+
+	var summary LogSummary
+	assert.NoError(json.Unmarshal([]byte(text), &summary))
+	assert.Equal(int64(len(lines)), summary.TotalRows)
+	assert.Equal(1, summary.WarningLines)
+
+	// the two connection errors only differ in their address, so are clustered together
+	assert.Equal(2, summary.TopErrors[0].Count)
+	assert.Contains(summary.TopErrors[0].Sample, "connection refused")
+	assert.Equal(int64(55), summary.TopErrors[0].FirstRow)
+	assert.Equal(int64(57), summary.TopErrors[0].LastRow)
+	assert.Greater(summary.Redactions, 0)
+
+	// the test group failed, ending in a block of errors
+	assert.Equal([]string{":test_tube: Test"}, summary.FailedGroups)
+	assert.Len(summary.ErrorBlocks, 1)
+	block := summary.ErrorBlocks[0]
+	assert.Equal(int64(55), block.StartRow)
+	assert.Equal(int64(60), block.EndRow)
+	assert.Equal(":test_tube: Test", block.Group)
+	assert.Len(block.Lines, 6)
+
+	assert.Equal(3, summary.GroupCount)
+	assert.Equal(":go: Build", summary.Groups[0].Name)
+	assert.Equal(float64(2), summary.Groups[0].DurationSeconds)
+	assert.True(summary.Groups[1].Failed)
+
+	// the downloads repeat often enough to be reported as noise
+	assert.NotEmpty(summary.RepeatedLines)
+	assert.Contains(summary.RepeatedLines[0].Sample, "downloading chunk")
+}
@@ -0,0 +1,82 @@
+package buildkite
+
+import (
+	"context"
+	"strings"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func ListOrganizationMembers(client OrganizationMembersClient) (tool mcp.Tool, handler server.ToolHandlerFunc, scopes []string) {
+	return mcp.NewTool("list_organization_members",
+			mcp.WithDescription("List the members of an organization with their user ID, name and email, optionally filtered by name or email. Use it to resolve a person's name or handle, such as a build's creator or a commit author, to their email or user ID"),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("search",
+				mcp.Description("Only list members whose name or email contains this, ignoring case"),
+			),
+			withPagination(),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "List Organization Members",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.ListOrganizationMembers")
+			defer span.End()
+
+			orgSlug, err := request.RequireString("org_slug")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			search := strings.ToLower(request.GetString("search", ""))
+
+			paginationParams, err := optionalPaginationParams(request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			// a filtered page needs more than one member to search
+			if search != "" && request.GetInt("perPage", 0) == 0 {
+				paginationParams.PerPage = 100
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", orgSlug),
+				attribute.Int("page", paginationParams.Page),
+				attribute.Int("per_page", paginationParams.PerPage),
+			)
+
+			members, resp, err := client.ListMembers(ctx, orgSlug, &paginationParams)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// the API can't search members, so the page is filtered here
+			if search != "" {
+				filtered := members[:0]
+				for _, member := range members {
+					if strings.Contains(strings.ToLower(member.Name), search) || strings.Contains(strings.ToLower(member.Email), search) {
+						filtered = append(filtered, member)
+					}
+				}
+				members = filtered
+			}
+
+			result := PaginatedResult[OrganizationMember]{
+				Items:   members,
+				Headers: map[string]string{},
+			}
+			if resp != nil {
+				result.Headers["Link"] = resp.Header.Get("Link")
+			}
+
+			span.SetAttributes(attribute.Int("item_count", len(members)))
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_organizations"}
+}
@@ -0,0 +1,44 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListOrganizationMembers(t *testing.T) {
+	assert := require.New(t)
+
+	var options *buildkite.ListOptions
+	client := &MockOrganizationMembersClient{
+		ListMembersFunc: func(ctx context.Context, org string, opt *buildkite.ListOptions) ([]OrganizationMember, *buildkite.Response, error) {
+			assert.Equal("org", org)
+			options = opt
+			return []OrganizationMember{
+				{ID: "u1", Name: "Sam Smith", Email: "sam@example.com"},
+				{ID: "u2", Name: "Alex Jones", Email: "alex@example.com"},
+			}, nil, nil
+		},
+	}
+
+	tool, handler, scopes := ListOrganizationMembers(client)
+	assert.Equal("list_organization_members", tool.Name)
+	assert.Equal([]string{"read_organizations"}, scopes)
+
+	result, err := handler(context.Background(), createMCPRequest(t, map[string]any{"org_slug": "org", "page": 2, "perPage": 10}))
+	assert.NoError(err)
+	var members PaginatedResult[OrganizationMember]
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &members))
+	assert.Len(members.Items, 2)
+	assert.Equal(buildkite.ListOptions{Page: 2, PerPage: 10}, *options)
+
+	// searching matches names and emails
+	result, err = handler(context.Background(), createMCPRequest(t, map[string]any{"org_slug": "org", "search": "ALEX@"}))
+	assert.NoError(err)
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &members))
+	assert.Equal([]OrganizationMember{{ID: "u2", Name: "Alex Jones", Email: "alex@example.com"}}, members.Items)
+	assert.Equal(100, options.PerPage)
+}
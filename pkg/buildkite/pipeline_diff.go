@@ -0,0 +1,189 @@
+package buildkite
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/policy"
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/pmezard/go-difflib/difflib"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/errgroup"
+)
+
+type DiffPipelinesArgs struct {
+	OrgSlug string `json:"org_slug"`
+	SlugA   string `json:"slug_a"`
+	SlugB   string `json:"slug_b"`
+}
+
+// SettingDifference is a pipeline setting whose value differs between the two pipelines
+type SettingDifference struct {
+	Setting string `json:"setting"`
+	A       any    `json:"a"`
+	B       any    `json:"b"`
+}
+
+type PipelineDiff struct {
+	SlugA             string              `json:"slug_a"`
+	SlugB             string              `json:"slug_b"`
+	ConfigurationDiff string              `json:"configuration_diff,omitempty"`
+	IdenticalConfig   bool                `json:"identical_configuration"`
+	Settings          []SettingDifference `json:"settings"`
+	TagsOnlyInA       []string            `json:"tags_only_in_a,omitempty"`
+	TagsOnlyInB       []string            `json:"tags_only_in_b,omitempty"`
+}
+
+// diffPipelineSettings compares the settings which commonly drift between similar pipelines
+func diffPipelineSettings(a, b buildkite.Pipeline) []SettingDifference {
+	settings := []struct {
+		name string
+		a, b any
+	}{
+		{"repository", a.Repository, b.Repository},
+		{"cluster_id", a.ClusterID, b.ClusterID},
+		{"default_branch", a.DefaultBranch, b.DefaultBranch},
+		{"branch_configuration", a.BranchConfiguration, b.BranchConfiguration},
+		{"skip_queued_branch_builds", a.SkipQueuedBranchBuilds, b.SkipQueuedBranchBuilds},
+		{"skip_queued_branch_builds_filter", a.SkipQueuedBranchBuildsFilter, b.SkipQueuedBranchBuildsFilter},
+		{"cancel_running_branch_builds", a.CancelRunningBranchBuilds, b.CancelRunningBranchBuilds},
+		{"cancel_running_branch_builds_filter", a.CancelRunningBranchBuildsFilter, b.CancelRunningBranchBuildsFilter},
+		{"visibility", a.Visibility, b.Visibility},
+		{"archived", a.ArchivedAt != nil, b.ArchivedAt != nil},
+	}
+
+	differences := []SettingDifference{}
+	for _, setting := range settings {
+		if setting.a != setting.b {
+			differences = append(differences, SettingDifference{Setting: setting.name, A: setting.a, B: setting.b})
+		}
+	}
+	return differences
+}
+
+// tagsOnlyIn returns the tags of a which b doesn't have
+func tagsOnlyIn(a, b []string) []string {
+	var only []string
+	for _, tag := range a {
+		if !slices.Contains(b, tag) {
+			only = append(only, tag)
+		}
+	}
+	slices.Sort(only)
+	return only
+}
+
+// configurationLines splits a configuration into lines for diffing, without the spurious empty line
+// difflib adds after a trailing newline
+func configurationLines(configuration string) []string {
+	if configuration == "" {
+		return nil
+	}
+	return difflib.SplitLines(strings.TrimSuffix(configuration, "\n"))
+}
+
+// diffPipelines compares the configuration and settings of two pipelines
+func diffPipelines(a, b buildkite.Pipeline) (PipelineDiff, error) {
+	diff := PipelineDiff{
+		SlugA:       a.Slug,
+		SlugB:       b.Slug,
+		Settings:    diffPipelineSettings(a, b),
+		TagsOnlyInA: tagsOnlyIn(a.Tags, b.Tags),
+		TagsOnlyInB: tagsOnlyIn(b.Tags, a.Tags),
+	}
+
+	configDiff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        configurationLines(a.Configuration),
+		B:        configurationLines(b.Configuration),
+		FromFile: a.Slug,
+		ToFile:   b.Slug,
+		Context:  3,
+	})
+	if err != nil {
+		return diff, fmt.Errorf("failed to diff configurations: %w", err)
+	}
+	diff.ConfigurationDiff = configDiff
+	diff.IdenticalConfig = configDiff == ""
+
+	return diff, nil
+}
+
+func DiffPipelines(client PipelinesClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[DiffPipelinesArgs], scopes []string) {
+	return mcp.NewTool("diff_pipelines",
+			mcp.WithDescription("Compare two pipelines, returning a unified diff of their YAML configurations and the settings which differ, such as cluster, branch filtering, build skipping and cancelling, and tags. Useful for aligning many similar service pipelines"),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("slug_a",
+				mcp.Required(),
+				mcp.Description("The slug of the first pipeline, the 'from' side of the diff"),
+			),
+			mcp.WithString("slug_b",
+				mcp.Required(),
+				mcp.Description("The slug of the second pipeline, the 'to' side of the diff"),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Diff Pipelines",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args DiffPipelinesArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.DiffPipelines")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.SlugA == "" {
+				return mcp.NewToolResultError("slug_a parameter is required"), nil
+			}
+			if args.SlugB == "" {
+				return mcp.NewToolResultError("slug_b parameter is required"), nil
+			}
+
+			scopePolicy := policy.ScopePolicyFromContext(ctx)
+			for _, slug := range []string{args.SlugA, args.SlugB} {
+				if err := scopePolicy.CheckPipeline(args.OrgSlug, slug); err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("slug_a", args.SlugA),
+				attribute.String("slug_b", args.SlugB),
+			)
+
+			var pipelineA, pipelineB buildkite.Pipeline
+			g, gctx := errgroup.WithContext(ctx)
+			g.Go(func() error {
+				var err error
+				pipelineA, _, err = client.Get(gctx, args.OrgSlug, args.SlugA)
+				return err
+			})
+			g.Go(func() error {
+				var err error
+				pipelineB, _, err = client.Get(gctx, args.OrgSlug, args.SlugB)
+				return err
+			})
+			if err := g.Wait(); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			result, err := diffPipelines(pipelineA, pipelineB)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			span.SetAttributes(
+				attribute.Bool("identical_configuration", result.IdenticalConfig),
+				attribute.Int("item_count", len(result.Settings)),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_pipelines"}
+}
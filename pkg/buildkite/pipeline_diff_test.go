@@ -0,0 +1,98 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/policy"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffPipelines(t *testing.T) {
+	assert := require.New(t)
+
+	pipelines := map[string]buildkite.Pipeline{
+		"service-a": {
+			Slug:                   "service-a",
+			ClusterID:              "cluster-1",
+			DefaultBranch:          "main",
+			SkipQueuedBranchBuilds: true,
+			Tags:                   []string{"go", "team-a"},
+			Configuration:          "steps:\n  - command: make test\n  - command: make deploy\n",
+		},
+		"service-b": {
+			Slug:          "service-b",
+			ClusterID:     "cluster-1",
+			DefaultBranch: "master",
+			Tags:          []string{"go"},
+			Configuration: "steps:\n  - command: make test\n  - command: make release\n",
+		},
+	}
+	client := &MockPipelinesClient{
+		GetFunc: func(ctx context.Context, org string, pipeline string) (buildkite.Pipeline, *buildkite.Response, error) {
+			return pipelines[pipeline], &buildkite.Response{}, nil
+		},
+	}
+
+	_, handler, scopes := DiffPipelines(client)
+	assert.Equal([]string{"read_pipelines"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, DiffPipelinesArgs{
+		OrgSlug: "org",
+		SlugA:   "service-a",
+		SlugB:   "service-b",
+	})
+	assert.NoError(err)
+	assert.False(result.IsError, getTextResult(t, result).Text)
+
+	var diff PipelineDiff
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &diff))
+	assert.False(diff.IdenticalConfig)
+	assert.Equal("--- service-a\n+++ service-b\n@@ -1,3 +1,3 @@\n steps:\n   - command: make test\n-  - command: make deploy\n+  - command: make release\n", diff.ConfigurationDiff)
+	assert.Equal([]SettingDifference{
+		{Setting: "default_branch", A: "main", B: "master"},
+		{Setting: "skip_queued_branch_builds", A: true, B: false},
+	}, diff.Settings)
+	assert.Equal([]string{"team-a"}, diff.TagsOnlyInA)
+	assert.Empty(diff.TagsOnlyInB)
+}
+
+func TestDiffPipelinesIdentical(t *testing.T) {
+	assert := require.New(t)
+
+	pipeline := buildkite.Pipeline{Slug: "service", Configuration: "steps:\n  - command: make\n"}
+	diff, err := diffPipelines(pipeline, pipeline)
+	assert.NoError(err)
+	assert.True(diff.IdenticalConfig)
+	assert.Empty(diff.ConfigurationDiff)
+	assert.Empty(diff.Settings)
+}
+
+func TestDiffPipelinesChecksScopePolicy(t *testing.T) {
+	assert := require.New(t)
+
+	client := &MockPipelinesClient{
+		GetFunc: func(ctx context.Context, org string, pipeline string) (buildkite.Pipeline, *buildkite.Response, error) {
+			t.Fatalf("pipeline %s should not be fetched", pipeline)
+			return buildkite.Pipeline{}, nil, nil
+		},
+	}
+	_, handler, _ := DiffPipelines(client)
+
+	scopePolicy, err := policy.NewScopePolicy(nil, []string{"service-*"})
+	assert.NoError(err)
+	ctx := policy.WithScopePolicy(context.Background(), scopePolicy)
+
+	for _, args := range []DiffPipelinesArgs{
+		{OrgSlug: "org", SlugA: "secret", SlugB: "service-b"},
+		{OrgSlug: "org", SlugA: "service-a", SlugB: "secret"},
+	} {
+		result, err := handler(ctx, mcp.CallToolRequest{}, args)
+		assert.NoError(err)
+		assert.True(result.IsError)
+		assert.Contains(getTextResult(t, result).Text, "secret")
+	}
+}
@@ -0,0 +1,299 @@
+package buildkite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	maxClusterQueuePages = 10
+
+	LintSeverityError   = "error"
+	LintSeverityWarning = "warning"
+	LintSeverityInfo    = "info"
+)
+
+// commandStepFields are the attributes which make a step run on an agent
+var commandStepFields = []string{"command", "commands", "plugins"}
+
+type LintPipelineAgainstClusterArgs struct {
+	OrgSlug      string `json:"org_slug"`
+	PipelineSlug string `json:"pipeline_slug"`
+}
+
+// PipelineLintFinding is a step whose queue targeting won't dispatch as expected
+type PipelineLintFinding struct {
+	Step     string `json:"step"`
+	Queue    string `json:"queue,omitempty"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+type PipelineClusterLint struct {
+	PipelineSlug string                `json:"pipeline_slug"`
+	ClusterID    string                `json:"cluster_id,omitempty"`
+	DefaultQueue string                `json:"default_queue,omitempty"`
+	StepsChecked int                   `json:"steps_checked"`
+	Findings     []PipelineLintFinding `json:"findings"`
+	Notes        []string              `json:"notes,omitempty"`
+}
+
+// agentsQueue returns the queue targeted by an agents attribute, which is either a mapping or a
+// list of key=value strings
+func agentsQueue(node *yaml.Node) (string, bool) {
+	agents := mappingValue(node, "agents")
+	if agents == nil {
+		return "", false
+	}
+
+	switch agents.Kind {
+	case yaml.MappingNode:
+		if queue := mappingValue(agents, "queue"); queue != nil && queue.Kind == yaml.ScalarNode {
+			return queue.Value, true
+		}
+	case yaml.SequenceNode:
+		for _, tag := range agents.Content {
+			if value, ok := strings.CutPrefix(tag.Value, "queue="); ok && tag.Kind == yaml.ScalarNode {
+				return value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// stepName identifies a step in findings by its key or label, falling back to its position
+func stepName(step *yaml.Node, position int) string {
+	for _, field := range append(append([]string{}, stepKeyFields...), stepLabelFields...) {
+		if node := mappingValue(step, field); node != nil && node.Kind == yaml.ScalarNode && node.Value != "" {
+			return node.Value
+		}
+	}
+	return fmt.Sprintf("step %d", position+1)
+}
+
+func isCommandStep(step *yaml.Node) bool {
+	for _, field := range commandStepFields {
+		if mappingValue(step, field) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// uploadsPipeline reports whether a step's commands run 'buildkite-agent pipeline upload'
+func uploadsPipeline(step *yaml.Node) bool {
+	for _, field := range []string{"command", "commands"} {
+		node := mappingValue(step, field)
+		if node == nil {
+			continue
+		}
+		commands := []*yaml.Node{node}
+		if node.Kind == yaml.SequenceNode {
+			commands = node.Content
+		}
+		for _, command := range commands {
+			if strings.Contains(command.Value, "pipeline upload") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lintPipelineQueues checks the queue each command step targets against the cluster's queues
+func lintPipelineQueues(configuration string, queues map[string]buildkite.ClusterQueue, defaultQueue string) (PipelineClusterLint, error) {
+	result := PipelineClusterLint{
+		DefaultQueue: defaultQueue,
+		Findings:     []PipelineLintFinding{},
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(configuration), &doc); err != nil {
+		return result, fmt.Errorf("failed to parse pipeline configuration: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		result.Notes = append(result.Notes, "the pipeline has no configuration")
+		return result, nil
+	}
+
+	root := doc.Content[0]
+	pipelineQueue, pipelineHasQueue := "", false
+	if root.Kind == yaml.MappingNode {
+		pipelineQueue, pipelineHasQueue = agentsQueue(root)
+	}
+
+	uploads := false
+	for i, step := range collectSteps(stepsNode(root)) {
+		if !isCommandStep(step) {
+			continue
+		}
+		result.StepsChecked++
+		uploads = uploads || uploadsPipeline(step)
+
+		name := stepName(step, i)
+		queue, ok := agentsQueue(step)
+		if !ok {
+			queue, ok = pipelineQueue, pipelineHasQueue
+		}
+		if !ok {
+			if defaultQueue == "" {
+				result.Findings = append(result.Findings, PipelineLintFinding{
+					Step:     name,
+					Severity: LintSeverityError,
+					Message:  "the step doesn't target a queue and the cluster has no default queue, so it will never be dispatched",
+				})
+			}
+			queue = defaultQueue
+		}
+		if queue == "" {
+			continue
+		}
+
+		if strings.Contains(queue, "$") {
+			result.Findings = append(result.Findings, PipelineLintFinding{
+				Step:     name,
+				Queue:    queue,
+				Severity: LintSeverityInfo,
+				Message:  "the queue is interpolated from an environment variable, so it can't be checked until the build runs",
+			})
+			continue
+		}
+
+		clusterQueue, exists := queues[queue]
+		switch {
+		case !exists:
+			result.Findings = append(result.Findings, PipelineLintFinding{
+				Step:     name,
+				Queue:    queue,
+				Severity: LintSeverityError,
+				Message:  "the queue doesn't exist in the pipeline's cluster, so the step will never be dispatched",
+			})
+		case clusterQueue.DispatchPaused:
+			message := "dispatch is paused on the queue, so the step won't run until it's resumed"
+			if clusterQueue.DispatchPausedNote != "" {
+				message += fmt.Sprintf(" (paused with note: %s)", clusterQueue.DispatchPausedNote)
+			}
+			result.Findings = append(result.Findings, PipelineLintFinding{
+				Step:     name,
+				Queue:    queue,
+				Severity: LintSeverityWarning,
+				Message:  message,
+			})
+		}
+	}
+
+	if uploads {
+		result.Notes = append(result.Notes, "the pipeline uploads further steps with 'buildkite-agent pipeline upload', which aren't part of the stored configuration and weren't checked")
+	}
+
+	return result, nil
+}
+
+// listAllClusterQueues returns the queues of a cluster keyed by queue key
+func listAllClusterQueues(ctx context.Context, client ClusterQueuesClient, org, clusterID string) (map[string]buildkite.ClusterQueue, error) {
+	options := &buildkite.ClusterQueuesListOptions{ListOptions: buildkite.ListOptions{PerPage: 100}}
+
+	queues := map[string]buildkite.ClusterQueue{}
+	for range maxClusterQueuePages {
+		page, resp, err := client.List(ctx, org, clusterID, options)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, queue := range page {
+			queues[queue.Key] = queue
+		}
+
+		if resp == nil || resp.NextPage == 0 || len(page) == 0 {
+			break
+		}
+		options.Page = resp.NextPage
+	}
+
+	return queues, nil
+}
+
+func LintPipelineAgainstCluster(pipelinesClient PipelinesClient, clustersClient ClustersClient, queuesClient ClusterQueuesClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[LintPipelineAgainstClusterArgs], scopes []string) {
+	return mcp.NewTool("lint_pipeline_against_cluster",
+			mcp.WithDescription("Check the queues targeted by a pipeline's YAML configuration against the queues of its cluster, flagging steps which target a queue that doesn't exist or is paused. These steps will never be dispatched, the most common cause of builds stuck scheduling forever"),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Lint Pipeline Against Cluster",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args LintPipelineAgainstClusterArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.LintPipelineAgainstCluster")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+			)
+
+			pipeline, _, err := pipelinesClient.Get(ctx, args.OrgSlug, args.PipelineSlug)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			if pipeline.ClusterID == "" {
+				result := PipelineClusterLint{
+					PipelineSlug: args.PipelineSlug,
+					Findings:     []PipelineLintFinding{},
+					Notes:        []string{"the pipeline isn't in a cluster, so its steps target unclustered agents by tags and there are no queues to check against"},
+				}
+				return mcpTextResult(span, &result)
+			}
+
+			cluster, _, err := clustersClient.Get(ctx, args.OrgSlug, pipeline.ClusterID)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			queues, err := listAllClusterQueues(ctx, queuesClient, args.OrgSlug, pipeline.ClusterID)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			var defaultQueue string
+			for key, queue := range queues {
+				if queue.ID == cluster.DefaultQueueID {
+					defaultQueue = key
+				}
+			}
+
+			result, err := lintPipelineQueues(pipeline.Configuration, queues, defaultQueue)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			result.PipelineSlug = args.PipelineSlug
+			result.ClusterID = pipeline.ClusterID
+
+			span.SetAttributes(
+				attribute.String("cluster_id", pipeline.ClusterID),
+				attribute.Int("steps_checked", result.StepsChecked),
+				attribute.Int("item_count", len(result.Findings)),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_pipelines", "read_clusters"}
+}
@@ -0,0 +1,101 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+const testLintConfiguration = `
+agents:
+  queue: linux
+steps:
+  - label: ":pipeline: upload"
+    command: buildkite-agent pipeline upload
+  - key: mac
+    command: make test
+    agents:
+      queue: macos
+  - wait
+  - group: deploy
+    steps:
+      - label: deploy
+        command: make deploy
+        agents:
+          - "queue=deploy"
+      - label: dynamic
+        command: make
+        agents:
+          queue: "${QUEUE}"
+  - block: release
+  - trigger: other-pipeline
+`
+
+func TestLintPipelineQueues(t *testing.T) {
+	assert := require.New(t)
+
+	queues := map[string]buildkite.ClusterQueue{
+		"linux":  {Key: "linux"},
+		"deploy": {Key: "deploy", DispatchPaused: true, DispatchPausedNote: "freeze"},
+	}
+
+	result, err := lintPipelineQueues(testLintConfiguration, queues, "linux")
+	assert.NoError(err)
+	assert.Equal(4, result.StepsChecked)
+	assert.Equal([]PipelineLintFinding{
+		{Step: "mac", Queue: "macos", Severity: LintSeverityError, Message: "the queue doesn't exist in the pipeline's cluster, so the step will never be dispatched"},
+		{Step: "deploy", Queue: "deploy", Severity: LintSeverityWarning, Message: "dispatch is paused on the queue, so the step won't run until it's resumed (paused with note: freeze)"},
+		{Step: "dynamic", Queue: "${QUEUE}", Severity: LintSeverityInfo, Message: "the queue is interpolated from an environment variable, so it can't be checked until the build runs"},
+	}, result.Findings)
+	assert.Len(result.Notes, 1)
+
+	// without a pipeline or cluster default, untargeted steps are never dispatched
+	result, err = lintPipelineQueues("steps:\n  - command: make\n", queues, "")
+	assert.NoError(err)
+	assert.Equal([]PipelineLintFinding{
+		{Step: "step 1", Severity: LintSeverityError, Message: "the step doesn't target a queue and the cluster has no default queue, so it will never be dispatched"},
+	}, result.Findings)
+}
+
+func TestLintPipelineAgainstCluster(t *testing.T) {
+	assert := require.New(t)
+
+	pipelines := &MockPipelinesClient{
+		GetFunc: func(ctx context.Context, org string, pipeline string) (buildkite.Pipeline, *buildkite.Response, error) {
+			return buildkite.Pipeline{Slug: pipeline, ClusterID: "cluster-1", Configuration: "steps:\n  - command: make\n  - command: make\n    agents:\n      queue: gone\n"}, &buildkite.Response{}, nil
+		},
+	}
+	clusters := &mockClustersClient{
+		GetFunc: func(ctx context.Context, org, id string) (buildkite.Cluster, *buildkite.Response, error) {
+			return buildkite.Cluster{ID: id, DefaultQueueID: "queue-1"}, &buildkite.Response{}, nil
+		},
+	}
+	queues := &mockClusterQueuesClient{
+		ListFunc: func(ctx context.Context, org, clusterID string, opts *buildkite.ClusterQueuesListOptions) ([]buildkite.ClusterQueue, *buildkite.Response, error) {
+			assert.Equal("cluster-1", clusterID)
+			return []buildkite.ClusterQueue{{ID: "queue-1", Key: "default"}}, &buildkite.Response{}, nil
+		},
+	}
+
+	_, handler, scopes := LintPipelineAgainstCluster(pipelines, clusters, queues)
+	assert.Equal([]string{"read_pipelines", "read_clusters"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, LintPipelineAgainstClusterArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+	})
+	assert.NoError(err)
+	assert.False(result.IsError, getTextResult(t, result).Text)
+
+	var lint PipelineClusterLint
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &lint))
+	assert.Equal("default", lint.DefaultQueue)
+	assert.Equal(2, lint.StepsChecked)
+	assert.Len(lint.Findings, 1)
+	assert.Equal("gone", lint.Findings[0].Queue)
+	assert.Equal(LintSeverityError, lint.Findings[0].Severity)
+}
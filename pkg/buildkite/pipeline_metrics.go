@@ -0,0 +1,207 @@
+package buildkite
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	defaultPipelineMetricsDays = 14
+	maxPipelineMetricsDays     = 90
+)
+
+type GetPipelineMetricsArgs struct {
+	OrgSlug      string `json:"org_slug"`
+	PipelineSlug string `json:"pipeline_slug"`
+	Branch       string `json:"branch,omitempty"`
+	Days         int    `json:"days,omitempty"`
+}
+
+// DurationStats summarises a set of durations in seconds
+type DurationStats struct {
+	Count       int     `json:"count"`
+	MeanSeconds float64 `json:"mean_seconds"`
+	P50Seconds  float64 `json:"p50_seconds"`
+	P95Seconds  float64 `json:"p95_seconds"`
+	MaxSeconds  float64 `json:"max_seconds"`
+}
+
+// FailureStreak is a run of consecutive failed builds
+type FailureStreak struct {
+	Builds      int `json:"builds"`
+	FirstBuild  int `json:"first_build"`
+	LatestBuild int `json:"latest_build"`
+}
+
+type PipelineMetrics struct {
+	Branch         string         `json:"branch,omitempty"`
+	Days           int            `json:"days"`
+	BuildsAnalyzed int            `json:"builds_analyzed"`
+	ByState        map[string]int `json:"by_state"`
+	// PassRate is the share of passed and failed builds which passed, leaving out canceled builds
+	PassRate  float64       `json:"pass_rate"`
+	Duration  DurationStats `json:"duration"`
+	QueueWait DurationStats `json:"queue_wait"`
+	// LongestFailureStreak is the longest run of consecutive failures, ignoring canceled builds
+	LongestFailureStreak *FailureStreak `json:"longest_failure_streak,omitempty"`
+	// CurrentFailureStreak is the run of failures up to the latest build, if it failed
+	CurrentFailureStreak *FailureStreak `json:"current_failure_streak,omitempty"`
+	Notes                []string       `json:"notes,omitempty"`
+}
+
+// durationStats summarises durations in seconds, rounding to the nearest tenth of a second
+func durationStats(seconds []float64) DurationStats {
+	if len(seconds) == 0 {
+		return DurationStats{}
+	}
+
+	sorted := slices.Clone(seconds)
+	slices.Sort(sorted)
+
+	var total float64
+	for _, s := range sorted {
+		total += s
+	}
+
+	round := func(s float64) float64 { return math.Round(s*10) / 10 }
+	return DurationStats{
+		Count:       len(sorted),
+		MeanSeconds: round(total / float64(len(sorted))),
+		P50Seconds:  round(percentile(sorted, 50)),
+		P95Seconds:  round(percentile(sorted, 95)),
+		MaxSeconds:  round(sorted[len(sorted)-1]),
+	}
+}
+
+// pipelineMetrics aggregates newest-first finished builds
+func pipelineMetrics(builds []buildkite.Build) PipelineMetrics {
+	result := PipelineMetrics{
+		BuildsAnalyzed: len(builds),
+		ByState:        map[string]int{},
+	}
+
+	var durations, waits []float64
+	var streak *FailureStreak
+
+	// walk oldest first so streaks run forwards
+	for i := len(builds) - 1; i >= 0; i-- {
+		build := builds[i]
+		result.ByState[build.State]++
+
+		if build.StartedAt != nil && build.FinishedAt != nil {
+			durations = append(durations, max(build.FinishedAt.Sub(build.StartedAt.Time), 0).Seconds())
+		}
+		if build.StartedAt != nil {
+			scheduled := build.ScheduledAt
+			if scheduled == nil {
+				scheduled = build.CreatedAt
+			}
+			if scheduled != nil {
+				waits = append(waits, max(build.StartedAt.Sub(scheduled.Time), 0).Seconds())
+			}
+		}
+
+		switch build.State {
+		case "failed":
+			if streak == nil {
+				streak = &FailureStreak{FirstBuild: build.Number}
+			}
+			streak.Builds++
+			streak.LatestBuild = build.Number
+			if result.LongestFailureStreak == nil || streak.Builds > result.LongestFailureStreak.Builds {
+				longest := *streak
+				result.LongestFailureStreak = &longest
+			}
+		case "passed":
+			streak = nil
+		}
+	}
+	result.CurrentFailureStreak = streak
+
+	if decided := result.ByState["passed"] + result.ByState["failed"]; decided > 0 {
+		result.PassRate = math.Round(float64(result.ByState["passed"])/float64(decided)*1000) / 1000
+	}
+	result.Duration = durationStats(durations)
+	result.QueueWait = durationStats(waits)
+
+	return result
+}
+
+func GetPipelineMetrics(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[GetPipelineMetricsArgs], scopes []string) {
+	return mcp.NewTool("get_pipeline_metrics",
+			mcp.WithDescription("Summarize a pipeline's finished builds over the last number of days: pass rate, mean, median and 95th percentile build duration and queue wait, the longest failure streak and whether it is failing now. Builds are aggregated on the server so only the statistics are returned. Queue wait is from a build being scheduled to its first job starting"),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("branch",
+				mcp.Description("Only include builds on this branch"),
+			),
+			mcp.WithNumber("days",
+				mcp.Description("Number of days of builds to include (default 14, max 90)"),
+				mcp.Min(1),
+				mcp.Max(maxPipelineMetricsDays),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Get Pipeline Metrics",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args GetPipelineMetricsArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.GetPipelineMetrics")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if args.Days <= 0 {
+				args.Days = defaultPipelineMetricsDays
+			}
+			args.Days = min(args.Days, maxPipelineMetricsDays)
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+				attribute.String("branch", args.Branch),
+				attribute.Int("days", args.Days),
+			)
+
+			allBuilds, truncated, err := listBuildsCreatedSince(ctx, client, args.OrgSlug, args.PipelineSlug, args.Branch, time.Now().AddDate(0, 0, -args.Days))
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			var builds []buildkite.Build
+			for _, build := range allBuilds {
+				if slices.Contains(finishedBuildStates, build.State) {
+					builds = append(builds, build)
+				}
+			}
+
+			result := pipelineMetrics(builds)
+			result.Branch = args.Branch
+			result.Days = args.Days
+			if truncated {
+				result.Notes = append(result.Notes, fmt.Sprintf("only the most recent %d builds of the pipeline were considered", len(allBuilds)))
+			}
+
+			span.SetAttributes(
+				attribute.Int("builds_analyzed", result.BuildsAnalyzed),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_builds"}
+}
@@ -0,0 +1,72 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPipelineMetrics(t *testing.T) {
+	assert := require.New(t)
+
+	base := time.Now().Add(-24 * time.Hour)
+	build := func(number int, state string, wait, duration time.Duration) buildkite.Build {
+		scheduled := base.Add(time.Duration(number) * time.Hour)
+		return buildkite.Build{
+			Number:      number,
+			State:       state,
+			ScheduledAt: &buildkite.Timestamp{Time: scheduled},
+			StartedAt:   &buildkite.Timestamp{Time: scheduled.Add(wait)},
+			FinishedAt:  &buildkite.Timestamp{Time: scheduled.Add(wait + duration)},
+		}
+	}
+
+	var capturedOptions *buildkite.BuildsListOptions
+	client := &MockBuildsClient{
+		ListByPipelineFunc: func(ctx context.Context, org, pipeline string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error) {
+			capturedOptions = opt
+			return []buildkite.Build{
+				{Number: 8, State: "running"},
+				build(7, "failed", 10*time.Second, 100*time.Second),
+				build(6, "canceled", 10*time.Second, 20*time.Second),
+				build(5, "failed", 30*time.Second, 100*time.Second),
+				build(4, "passed", 10*time.Second, 200*time.Second),
+				build(3, "failed", 10*time.Second, 100*time.Second),
+				build(2, "failed", 10*time.Second, 100*time.Second),
+				build(1, "failed", 20*time.Second, 100*time.Second),
+				build(0, "passed", 10*time.Second, 300*time.Second),
+			}, &buildkite.Response{}, nil
+		},
+	}
+
+	tool, handler, scopes := GetPipelineMetrics(client)
+	assert.Equal("get_pipeline_metrics", tool.Name)
+	assert.True(*tool.Annotations.ReadOnlyHint)
+	assert.Equal([]string{"read_builds"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, GetPipelineMetricsArgs{OrgSlug: "org", PipelineSlug: "pipeline", Branch: "main"})
+	assert.NoError(err)
+	assert.Equal([]string{"main"}, capturedOptions.Branch)
+	assert.True(capturedOptions.ExcludeJobs)
+
+	var metrics PipelineMetrics
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &metrics))
+	assert.Equal(14, metrics.Days)
+	assert.Equal(8, metrics.BuildsAnalyzed)
+	assert.Equal(map[string]int{"passed": 2, "failed": 5, "canceled": 1}, metrics.ByState)
+	assert.InDelta(2.0/7.0, metrics.PassRate, 0.001)
+	assert.Equal(DurationStats{Count: 8, MeanSeconds: 127.5, P50Seconds: 100, P95Seconds: 300, MaxSeconds: 300}, metrics.Duration)
+	assert.Equal(8, metrics.QueueWait.Count)
+	assert.Equal(30.0, metrics.QueueWait.MaxSeconds)
+	assert.Equal(&FailureStreak{Builds: 3, FirstBuild: 1, LatestBuild: 3}, metrics.LongestFailureStreak)
+	assert.Equal(&FailureStreak{Builds: 2, FirstBuild: 5, LatestBuild: 7}, metrics.CurrentFailureStreak)
+
+	result, err = handler(context.Background(), mcp.CallToolRequest{}, GetPipelineMetricsArgs{OrgSlug: "org"})
+	assert.NoError(err)
+	assert.True(result.IsError)
+}
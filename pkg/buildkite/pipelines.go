@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"slices"
 
+	"github.com/buildkite/buildkite-mcp-server/pkg/policy"
 	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
 	"github.com/buildkite/go-buildkite/v4"
 	"github.com/mark3labs/mcp-go/mcp"
@@ -107,6 +109,12 @@ func ListPipelines(client PipelinesClient) (tool mcp.Tool, handler mcp.TypedTool
 				return mcp.NewToolResultError(err.Error()), nil
 			}
 
+			// pages may hold fewer than per_page pipelines once those the scope policy doesn't permit are left out
+			scopePolicy := policy.ScopePolicyFromContext(ctx)
+			pipelines = slices.DeleteFunc(pipelines, func(p buildkite.Pipeline) bool {
+				return scopePolicy.CheckPipeline(args.OrgSlug, p.Slug) != nil
+			})
+
 			headers := map[string]string{"Link": resp.Header.Get("Link")}
 
 			var result any
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"testing"
 
+	"github.com/buildkite/buildkite-mcp-server/pkg/policy"
 	"github.com/buildkite/go-buildkite/v4"
 	"github.com/stretchr/testify/require"
 )
@@ -94,6 +95,31 @@ func TestListPipelines(t *testing.T) {
 	assert.Equal(`{"headers":{"Link":""},"items":[{"id":"123","name":"Test Pipeline","slug":"test-pipeline","repository":"","default_branch":"","web_url":"","visibility":"","created_at":"0001-01-01T00:00:00Z"}]}`, textContent.Text)
 }
 
+func TestListPipelinesFiltersByScopePolicy(t *testing.T) {
+	assert := require.New(t)
+
+	client := &MockPipelinesClient{
+		ListFunc: func(ctx context.Context, org string, opt *buildkite.PipelineListOptions) ([]buildkite.Pipeline, *buildkite.Response, error) {
+			return []buildkite.Pipeline{{Slug: "frontend-web"}, {Slug: "backend"}}, &buildkite.Response{
+				Response: &http.Response{StatusCode: 200},
+			}, nil
+		},
+	}
+
+	_, handler, _ := ListPipelines(client)
+
+	scopePolicy, err := policy.NewScopePolicy(nil, []string{"frontend-*"})
+	assert.NoError(err)
+	ctx := policy.WithScopePolicy(context.Background(), scopePolicy)
+
+	result, err := handler(ctx, createMCPRequest(t, map[string]any{}), ListPipelinesArgs{OrgSlug: "org", DetailLevel: "summary"})
+	assert.NoError(err)
+
+	text := getTextResult(t, result).Text
+	assert.Contains(text, `"frontend-web"`)
+	assert.NotContains(text, `"backend"`)
+}
+
 func TestGetPipeline(t *testing.T) {
 	assert := require.New(t)
 
@@ -0,0 +1,122 @@
+package buildkite
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// maximum number of recent failed builds of each pipeline prewarmed per cycle
+	maxPrewarmBuilds = 10
+	// prewarmed jobs are forgotten once this many are remembered, bounding memory use
+	maxPrewarmedJobs = 10000
+)
+
+type prewarmPipeline struct {
+	org      string
+	pipeline string
+}
+
+// LogPrewarmer periodically downloads the logs of failed jobs in the recent failed builds of some
+// pipelines into the job logs cache, so triaging them starts with the logs already cached
+type LogPrewarmer struct {
+	builds    BuildsClient
+	logs      BuildkiteLogsClient
+	pipelines []prewarmPipeline
+	interval  time.Duration
+	lookback  time.Duration
+
+	// prewarmed are the jobs already cached, which don't need checking again as failed jobs are finished
+	prewarmed map[string]bool
+}
+
+// NewLogPrewarmer returns a prewarmer for the pipelines, given as org/pipeline, which every interval
+// prewarms the failed builds created within lookback
+func NewLogPrewarmer(builds BuildsClient, logs BuildkiteLogsClient, pipelines []string, interval, lookback time.Duration) (*LogPrewarmer, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("prewarm interval must be positive")
+	}
+
+	p := &LogPrewarmer{builds: builds, logs: logs, interval: interval, lookback: lookback, prewarmed: map[string]bool{}}
+	for _, pipeline := range pipelines {
+		org, slug, ok := strings.Cut(pipeline, "/")
+		if !ok || org == "" || slug == "" {
+			return nil, fmt.Errorf("invalid prewarm pipeline %q, expected org/pipeline", pipeline)
+		}
+		p.pipelines = append(p.pipelines, prewarmPipeline{org: org, pipeline: slug})
+	}
+
+	return p, nil
+}
+
+// Run prewarms the logs straight away and then every interval, until the context is done
+func (p *LogPrewarmer) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		jobs := p.Prewarm(ctx)
+		log.Ctx(ctx).Debug().Int("jobs", jobs).Msg("Prewarmed job logs of recent failed builds")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Prewarm downloads the logs of failed jobs which haven't been prewarmed yet, returning how many were
+func (p *LogPrewarmer) Prewarm(ctx context.Context) int {
+	if len(p.prewarmed) > maxPrewarmedJobs {
+		p.prewarmed = map[string]bool{}
+	}
+
+	createdFrom := time.Now().Add(-p.lookback)
+
+	count := 0
+	for _, pipeline := range p.pipelines {
+		builds, _, err := p.builds.ListByPipeline(ctx, pipeline.org, pipeline.pipeline, &buildkite.BuildsListOptions{
+			State:           []string{"failed"},
+			CreatedFrom:     createdFrom,
+			ExcludePipeline: true,
+			ListOptions:     buildkite.ListOptions{PerPage: maxPrewarmBuilds},
+		})
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Str("org", pipeline.org).Str("pipeline", pipeline.pipeline).Msg("Failed to list failed builds to prewarm")
+			continue
+		}
+
+		for _, build := range builds {
+			for _, job := range build.Jobs {
+				if ctx.Err() != nil {
+					return count
+				}
+				if !isFailedJob(job) || p.prewarmed[job.ID] {
+					continue
+				}
+
+				path, err := p.logs.DownloadAndCache(ctx, pipeline.org, pipeline.pipeline, strconv.Itoa(build.Number), job.ID, 0, false)
+				if err != nil {
+					log.Ctx(ctx).Warn().Err(err).Str("org", pipeline.org).Str("pipeline", pipeline.pipeline).Int("build", build.Number).Str("job", job.ID).Msg("Failed to prewarm job logs")
+					continue
+				}
+
+				// only the cache is wanted, not the local copy of it
+				_ = os.Remove(path)
+
+				p.prewarmed[job.ID] = true
+				count++
+			}
+		}
+	}
+
+	return count
+}
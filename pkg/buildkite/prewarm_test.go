@@ -0,0 +1,74 @@
+package buildkite
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogPrewarmer(t *testing.T) {
+	assert := require.New(t)
+
+	var listOpts *buildkite.BuildsListOptions
+	builds := &MockBuildsClient{
+		ListByPipelineFunc: func(ctx context.Context, org string, pipeline string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error) {
+			if pipeline == "broken" {
+				return nil, nil, errors.New("not found")
+			}
+			listOpts = opt
+			return []buildkite.Build{{
+				Number: 42,
+				State:  "failed",
+				Jobs: []buildkite.Job{
+					{ID: "job-1", Type: "script", State: "passed"},
+					{ID: "job-2", Type: "script", State: "failed"},
+					{ID: "job-3", Type: "script", State: "timed_out"},
+				},
+			}}, &buildkite.Response{}, nil
+		},
+	}
+
+	dir := t.TempDir()
+	var downloaded []string
+	logs := &MockBuildkiteLogsClient{
+		DownloadAndCacheFunc: func(ctx context.Context, org, pipeline, build, job string, cacheTTL time.Duration, forceRefresh bool) (string, error) {
+			if job == "job-3" && len(downloaded) == 1 {
+				downloaded = append(downloaded, job)
+				return "", errors.New("download failed")
+			}
+			downloaded = append(downloaded, org+"/"+pipeline+"/"+build+"/"+job)
+			path := filepath.Join(dir, job+".parquet")
+			return path, os.WriteFile(path, nil, 0o600)
+		},
+	}
+
+	prewarmer, err := NewLogPrewarmer(builds, logs, []string{"acme/broken", "acme/web"}, time.Minute, 24*time.Hour)
+	assert.NoError(err)
+
+	assert.Equal(1, prewarmer.Prewarm(context.Background()))
+	assert.Equal([]string{"failed"}, listOpts.State)
+	assert.WithinDuration(time.Now().Add(-24*time.Hour), listOpts.CreatedFrom, time.Minute)
+	assert.Equal([]string{"acme/web/42/job-2", "job-3"}, downloaded)
+
+	// the local copies are removed, leaving only the cache
+	_, err = os.Stat(filepath.Join(dir, "job-2.parquet"))
+	assert.True(os.IsNotExist(err))
+
+	// only jobs which failed to prewarm are tried again
+	assert.Equal(1, prewarmer.Prewarm(context.Background()))
+	assert.Equal([]string{"acme/web/42/job-2", "job-3", "acme/web/42/job-3"}, downloaded)
+	assert.Equal(0, prewarmer.Prewarm(context.Background()))
+}
+
+func TestNewLogPrewarmerInvalidPipeline(t *testing.T) {
+	assert := require.New(t)
+
+	_, err := NewLogPrewarmer(&MockBuildsClient{}, &MockBuildkiteLogsClient{}, []string{"web"}, time.Minute, time.Hour)
+	assert.ErrorContains(err, "expected org/pipeline")
+}
@@ -0,0 +1,169 @@
+package buildkite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// TestStatesClient changes the state of tests in Test Engine
+type TestStatesClient interface {
+	MuteTest(ctx context.Context, org, suite, testID, reason string) (*buildkite.Response, error)
+}
+
+// testStateUpdate is the body of a Test Engine test state change
+type testStateUpdate struct {
+	State  string `json:"state"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// MuteTest implements TestStatesClient, go-buildkite doesn't cover the test state API yet
+func (a *BuildkiteClientAdapter) MuteTest(ctx context.Context, org, suite, testID, reason string) (*buildkite.Response, error) {
+	u := fmt.Sprintf("v2/analytics/organizations/%s/suites/%s/tests/%s/state", org, suite, testID)
+	req, err := a.NewRequest(ctx, "PUT", u, testStateUpdate{State: "muted", Reason: reason})
+	if err != nil {
+		return nil, err
+	}
+	return a.Do(req, nil)
+}
+
+type QuarantineTestArgs struct {
+	OrgSlug       string `json:"org_slug"`
+	TestSuiteSlug string `json:"test_suite_slug"`
+	TestID        string `json:"test_id"`
+	PipelineSlug  string `json:"pipeline_slug"`
+	BuildNumber   string `json:"build_number"`
+	Reason        string `json:"reason"`
+	RequestedBy   string `json:"requested_by"`
+}
+
+type QuarantineResult struct {
+	TestID            string `json:"test_id"`
+	TestName          string `json:"test_name,omitempty"`
+	Muted             bool   `json:"muted"`
+	AnnotationID      string `json:"annotation_id,omitempty"`
+	AnnotationContext string `json:"annotation_context"`
+}
+
+// quarantineAnnotation records who quarantined a test and why
+func quarantineAnnotation(test buildkite.Test, args QuarantineTestArgs) string {
+	var sb strings.Builder
+	name := test.Name
+	if name == "" {
+		name = args.TestID
+	}
+	fmt.Fprintf(&sb, "**Quarantined test:** `%s`\n\n", name)
+	if test.Location != "" {
+		fmt.Fprintf(&sb, "- Location: `%s`\n", test.Location)
+	}
+	fmt.Fprintf(&sb, "- Suite: `%s`\n", args.TestSuiteSlug)
+	fmt.Fprintf(&sb, "- Requested by: %s\n", args.RequestedBy)
+	fmt.Fprintf(&sb, "- Reason: %s\n", args.Reason)
+	if test.WebURL != "" {
+		fmt.Fprintf(&sb, "\n[View test in Test Engine](%s)\n", test.WebURL)
+	}
+	return sb.String()
+}
+
+func QuarantineTest(testsClient TestsClient, testStatesClient TestStatesClient, annotationsClient AnnotationsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[QuarantineTestArgs], scopes []string) {
+	return mcp.NewTool("quarantine_test",
+			mcp.WithDescription("Quarantine a test: mute it in Buildkite Test Engine so its failures no longer fail builds, and post a warning annotation on a build recording who quarantined it and why, so the quarantine stays visible."),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("test_suite_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("test_id",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+				mcp.Description("The pipeline of the build to annotate"),
+			),
+			mcp.WithString("build_number",
+				mcp.Required(),
+				mcp.Description("The build to annotate, usually the one where the test failed"),
+			),
+			mcp.WithString("reason",
+				mcp.Required(),
+				mcp.Description("Why the test is being quarantined"),
+			),
+			mcp.WithString("requested_by",
+				mcp.Required(),
+				mcp.Description("Who requested the quarantine"),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Quarantine Test",
+				ReadOnlyHint: mcp.ToBoolPtr(false),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args QuarantineTestArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.QuarantineTest")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.TestSuiteSlug == "" {
+				return mcp.NewToolResultError("test_suite_slug parameter is required"), nil
+			}
+			if args.TestID == "" {
+				return mcp.NewToolResultError("test_id parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if args.BuildNumber == "" {
+				return mcp.NewToolResultError("build_number parameter is required"), nil
+			}
+			if args.Reason == "" {
+				return mcp.NewToolResultError("reason parameter is required"), nil
+			}
+			if args.RequestedBy == "" {
+				return mcp.NewToolResultError("requested_by parameter is required"), nil
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("test_suite_slug", args.TestSuiteSlug),
+				attribute.String("test_id", args.TestID),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+				attribute.String("build_number", args.BuildNumber),
+			)
+
+			test, _, err := testsClient.Get(ctx, args.OrgSlug, args.TestSuiteSlug, args.TestID)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			if _, err := testStatesClient.MuteTest(ctx, args.OrgSlug, args.TestSuiteSlug, args.TestID, args.Reason); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to mute test: %v", err)), nil
+			}
+
+			result := QuarantineResult{
+				TestID:            args.TestID,
+				TestName:          test.Name,
+				Muted:             true,
+				AnnotationContext: "quarantine-" + args.TestID,
+			}
+
+			annotation, _, err := annotationsClient.Create(ctx, args.OrgSlug, args.PipelineSlug, args.BuildNumber, buildkite.AnnotationCreate{
+				Body:    quarantineAnnotation(test, args),
+				Context: result.AnnotationContext,
+				Style:   "warning",
+			})
+			if err != nil {
+				// the mute has already happened, so make sure the caller knows it isn't recorded
+				return mcp.NewToolResultError(fmt.Sprintf("test %s was muted but the build annotation could not be created: %v", args.TestID, err)), nil
+			}
+			result.AnnotationID = annotation.ID
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_suites", "write_suites", "write_builds"}
+}
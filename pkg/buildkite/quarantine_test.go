@@ -0,0 +1,84 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+type MockTestStatesClient struct {
+	MuteTestFunc func(ctx context.Context, org, suite, testID, reason string) (*buildkite.Response, error)
+}
+
+func (m *MockTestStatesClient) MuteTest(ctx context.Context, org, suite, testID, reason string) (*buildkite.Response, error) {
+	if m.MuteTestFunc != nil {
+		return m.MuteTestFunc(ctx, org, suite, testID, reason)
+	}
+	return nil, nil
+}
+
+var _ TestStatesClient = (*MockTestStatesClient)(nil)
+
+func TestQuarantineTest(t *testing.T) {
+	assert := require.New(t)
+
+	testsClient := &MockTestsClient{
+		GetFunc: func(ctx context.Context, org, slug, testID string) (buildkite.Test, *buildkite.Response, error) {
+			return buildkite.Test{ID: testID, Name: "checkout works", Location: "spec/checkout_spec.rb:12"}, &buildkite.Response{}, nil
+		},
+	}
+	var mutedReason string
+	statesClient := &MockTestStatesClient{
+		MuteTestFunc: func(ctx context.Context, org, suite, testID, reason string) (*buildkite.Response, error) {
+			mutedReason = reason
+			return &buildkite.Response{}, nil
+		},
+	}
+	var created buildkite.AnnotationCreate
+	annotationsClient := &MockAnnotationsClient{
+		CreateFunc: func(ctx context.Context, org, pipelineSlug, buildNumber string, annotation buildkite.AnnotationCreate) (buildkite.Annotation, *buildkite.Response, error) {
+			assert.Equal("42", buildNumber)
+			created = annotation
+			return buildkite.Annotation{ID: "annotation-1"}, &buildkite.Response{}, nil
+		},
+	}
+
+	_, handler, scopes := QuarantineTest(testsClient, statesClient, annotationsClient)
+	assert.Equal([]string{"read_suites", "write_suites", "write_builds"}, scopes)
+
+	args := QuarantineTestArgs{
+		OrgSlug:       "org",
+		TestSuiteSlug: "suite",
+		TestID:        "test-1",
+		PipelineSlug:  "pipeline",
+		BuildNumber:   "42",
+		Reason:        "fails on slow agents",
+		RequestedBy:   "alex",
+	}
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, args)
+	assert.NoError(err)
+
+	var quarantine QuarantineResult
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &quarantine))
+	assert.True(quarantine.Muted)
+	assert.Equal("annotation-1", quarantine.AnnotationID)
+	assert.Equal("fails on slow agents", mutedReason)
+	assert.Equal("quarantine-test-1", created.Context)
+	assert.Equal("warning", created.Style)
+	assert.Contains(created.Body, "Requested by: alex")
+	assert.Contains(created.Body, "spec/checkout_spec.rb:12")
+
+	// a failed annotation is reported even though the test was muted
+	annotationsClient.CreateFunc = func(ctx context.Context, org, pipelineSlug, buildNumber string, annotation buildkite.AnnotationCreate) (buildkite.Annotation, *buildkite.Response, error) {
+		return buildkite.Annotation{}, nil, errors.New("forbidden")
+	}
+	result, err = handler(context.Background(), mcp.CallToolRequest{}, args)
+	assert.NoError(err)
+	assert.True(result.IsError)
+	assert.Contains(getTextResult(t, result).Text, "was muted")
+}
@@ -0,0 +1,298 @@
+package buildkite
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultContentionWindow   = 50
+	maxContentionWindow       = 500
+	defaultContentionMinWait  = 60
+	maxContentionStepsInGroup = 5
+
+	ContentionKindConcurrencyGroup = "concurrency_group"
+	ContentionKindQueue            = "queue"
+)
+
+type AnalyzeQueueContentionArgs struct {
+	OrgSlug        string `json:"org_slug"`
+	PipelineSlug   string `json:"pipeline_slug"`
+	Branch         string `json:"branch,omitempty"`
+	Window         int    `json:"window,omitempty"`
+	MinWaitSeconds *int   `json:"min_wait_seconds,omitempty"`
+}
+
+// ContentionStep is a step whose jobs waited to start within a contention group
+type ContentionStep struct {
+	Step             string  `json:"step"`
+	Label            string  `json:"label"`
+	Jobs             int     `json:"jobs"`
+	WaitedJobs       int     `json:"waited_jobs"`
+	P95WaitSeconds   float64 `json:"p95_wait_seconds"`
+	MaxWaitSeconds   float64 `json:"max_wait_seconds"`
+	TotalWaitSeconds float64 `json:"total_wait_seconds"`
+}
+
+// ContentionGroup is the wait between jobs being scheduled and starting, for the jobs sharing a
+// concurrency group or, without one, an agent queue
+type ContentionGroup struct {
+	Kind              string           `json:"kind"`
+	Key               string           `json:"key"`
+	Jobs              int              `json:"jobs"`
+	WaitedJobs        int              `json:"waited_jobs"`
+	WaitedPercent     float64          `json:"waited_percent"`
+	MedianWaitSeconds float64          `json:"median_wait_seconds"`
+	P95WaitSeconds    float64          `json:"p95_wait_seconds"`
+	MaxWaitSeconds    float64          `json:"max_wait_seconds"`
+	TotalWaitSeconds  float64          `json:"total_wait_seconds"`
+	Steps             []ContentionStep `json:"steps"`
+}
+
+type QueueContention struct {
+	BuildsAnalyzed int               `json:"builds_analyzed"`
+	JobsAnalyzed   int               `json:"jobs_analyzed"`
+	MinWaitSeconds int               `json:"min_wait_seconds"`
+	Groups         []ContentionGroup `json:"groups"`
+	Notes          []string          `json:"notes,omitempty"`
+}
+
+// stepConcurrencyGroups maps the keys and labels of the steps in a pipeline configuration to their
+// concurrency groups
+func stepConcurrencyGroups(configuration string) map[string]string {
+	groups := map[string]string{}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(configuration), &doc); err != nil || len(doc.Content) == 0 {
+		return groups
+	}
+
+	for _, step := range collectSteps(stepsNode(doc.Content[0])) {
+		group := mappingValue(step, "concurrency_group")
+		if group == nil || group.Kind != yaml.ScalarNode || group.Value == "" {
+			continue
+		}
+		for _, field := range append(append([]string{}, stepKeyFields...), stepLabelFields...) {
+			if node := mappingValue(step, field); node != nil && node.Kind == yaml.ScalarNode && node.Value != "" {
+				groups[node.Value] = group.Value
+			}
+		}
+	}
+	return groups
+}
+
+// jobQueue returns the queue a job targeted, which is the default queue when it didn't name one
+func jobQueue(job buildkite.Job) string {
+	for _, rule := range job.AgentQueryRules {
+		if queue, ok := strings.CutPrefix(rule, "queue="); ok {
+			return queue
+		}
+	}
+	return "default"
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	return sorted[max(rank, 0)]
+}
+
+type contentionWaits struct {
+	waits []float64
+	steps map[string]*ContentionStep
+	// the waits of each step, for its percentile
+	stepWaits map[string][]float64
+}
+
+// analyzeQueueContention groups the scheduled to started waits of jobs by concurrency group or
+// queue, ranking the groups by the total time jobs spent waiting
+func analyzeQueueContention(builds []buildkite.Build, concurrencyGroups map[string]string, minWait time.Duration) ([]ContentionGroup, int) {
+	type groupKey struct{ kind, key string }
+	groups := map[groupKey]*contentionWaits{}
+	jobs := 0
+
+	for _, build := range builds {
+		for _, job := range build.Jobs {
+			if job.Type != "" && job.Type != "script" {
+				continue
+			}
+			if job.ScheduledAt == nil || job.StartedAt == nil {
+				continue
+			}
+			wait := max(job.StartedAt.Sub(job.ScheduledAt.Time), 0)
+			jobs++
+
+			step := stepIdentity(job)
+			key := groupKey{ContentionKindQueue, jobQueue(job)}
+			for _, name := range []string{job.StepKey, job.Label, job.Name} {
+				if group, ok := concurrencyGroups[name]; ok && name != "" {
+					key = groupKey{ContentionKindConcurrencyGroup, group}
+					break
+				}
+			}
+
+			g, ok := groups[key]
+			if !ok {
+				g = &contentionWaits{steps: map[string]*ContentionStep{}, stepWaits: map[string][]float64{}}
+				groups[key] = g
+			}
+			s, ok := g.steps[step]
+			if !ok {
+				s = &ContentionStep{Step: step, Label: jobLabel(job)}
+				g.steps[step] = s
+			}
+
+			seconds := wait.Seconds()
+			g.waits = append(g.waits, seconds)
+			g.stepWaits[step] = append(g.stepWaits[step], seconds)
+			s.Jobs++
+			s.TotalWaitSeconds += seconds
+			s.MaxWaitSeconds = max(s.MaxWaitSeconds, seconds)
+			if wait >= minWait {
+				s.WaitedJobs++
+			}
+		}
+	}
+
+	result := []ContentionGroup{}
+	for key, g := range groups {
+		slices.Sort(g.waits)
+
+		group := ContentionGroup{
+			Kind:              key.kind,
+			Key:               key.key,
+			Jobs:              len(g.waits),
+			MedianWaitSeconds: percentile(g.waits, 50),
+			P95WaitSeconds:    percentile(g.waits, 95),
+			MaxWaitSeconds:    g.waits[len(g.waits)-1],
+			Steps:             []ContentionStep{},
+		}
+		for step, s := range g.steps {
+			waits := g.stepWaits[step]
+			slices.Sort(waits)
+			s.P95WaitSeconds = percentile(waits, 95)
+
+			group.WaitedJobs += s.WaitedJobs
+			group.TotalWaitSeconds += s.TotalWaitSeconds
+			if s.WaitedJobs > 0 {
+				group.Steps = append(group.Steps, *s)
+			}
+		}
+		group.WaitedPercent = math.Round(float64(group.WaitedJobs)/float64(group.Jobs)*1000) / 10
+
+		slices.SortFunc(group.Steps, func(a, b ContentionStep) int {
+			return cmp.Or(
+				cmp.Compare(b.TotalWaitSeconds, a.TotalWaitSeconds),
+				cmp.Compare(a.Step, b.Step),
+			)
+		})
+		if len(group.Steps) > maxContentionStepsInGroup {
+			group.Steps = group.Steps[:maxContentionStepsInGroup]
+		}
+
+		result = append(result, group)
+	}
+
+	slices.SortFunc(result, func(a, b ContentionGroup) int {
+		return cmp.Or(
+			cmp.Compare(b.TotalWaitSeconds, a.TotalWaitSeconds),
+			cmp.Compare(a.Key, b.Key),
+		)
+	})
+
+	return result, jobs
+}
+
+func AnalyzeQueueContention(buildsClient BuildsClient, pipelinesClient PipelinesClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[AnalyzeQueueContentionArgs], scopes []string) {
+	return mcp.NewTool("analyze_queue_contention",
+			mcp.WithDescription("Measure how long a pipeline's jobs waited between being scheduled and starting across its recent finished builds, grouped by the step's concurrency group or, without one, its agent queue. Waits in a concurrency group point at its concurrency limit, waits in a queue at a shortage of agents. Groups are ranked by total wait time, with the steps which waited longest in each"),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("branch",
+				mcp.Description("Only analyze builds on this branch"),
+			),
+			mcp.WithNumber("window",
+				mcp.Description("Number of recent builds to analyze (default 50, max 500)"),
+				mcp.Min(1),
+				mcp.Max(maxContentionWindow),
+			),
+			mcp.WithNumber("min_wait_seconds",
+				mcp.Description("Count jobs which waited at least this long to start as queued (default 60)"),
+				mcp.Min(0),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Analyze Queue Contention",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args AnalyzeQueueContentionArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.AnalyzeQueueContention")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if args.Window <= 0 {
+				args.Window = defaultContentionWindow
+			}
+			args.Window = min(args.Window, maxContentionWindow)
+			minWaitSeconds := defaultContentionMinWait
+			if args.MinWaitSeconds != nil {
+				minWaitSeconds = *args.MinWaitSeconds
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+				attribute.String("branch", args.Branch),
+				attribute.Int("window", args.Window),
+			)
+
+			result := QueueContention{MinWaitSeconds: minWaitSeconds}
+
+			// concurrency groups are only in the stored configuration, so without it jobs are grouped
+			// by queue alone
+			concurrencyGroups := map[string]string{}
+			pipeline, _, err := pipelinesClient.Get(ctx, args.OrgSlug, args.PipelineSlug)
+			if err != nil {
+				result.Notes = append(result.Notes, "the pipeline configuration couldn't be read, so jobs are grouped by queue without concurrency groups")
+			} else {
+				concurrencyGroups = stepConcurrencyGroups(pipeline.Configuration)
+			}
+
+			builds, err := listRecentBuilds(ctx, buildsClient, args.OrgSlug, args.PipelineSlug, args.Branch, args.Window)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			result.BuildsAnalyzed = len(builds)
+			result.Groups, result.JobsAnalyzed = analyzeQueueContention(builds, concurrencyGroups, time.Duration(minWaitSeconds)*time.Second)
+
+			span.SetAttributes(
+				attribute.Int("jobs_analyzed", result.JobsAnalyzed),
+				attribute.Int("item_count", len(result.Groups)),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_builds", "read_pipelines"}
+}
@@ -0,0 +1,113 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func contentionJob(step, queue string, wait time.Duration) buildkite.Job {
+	scheduled := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	return buildkite.Job{
+		Type:            "script",
+		StepKey:         step,
+		Label:           step,
+		AgentQueryRules: []string{"queue=" + queue},
+		ScheduledAt:     buildkite.NewTimestamp(scheduled),
+		StartedAt:       buildkite.NewTimestamp(scheduled.Add(wait)),
+	}
+}
+
+func TestAnalyzeQueueContention(t *testing.T) {
+	assert := require.New(t)
+
+	builds := []buildkite.Build{
+		{Number: 2, Jobs: []buildkite.Job{
+			contentionJob("deploy", "deploy", 10*time.Minute),
+			contentionJob("test", "linux", 2*time.Minute),
+			contentionJob("lint", "linux", 5*time.Second),
+			{Type: "waiter"},
+		}},
+		{Number: 1, Jobs: []buildkite.Job{
+			contentionJob("deploy", "deploy", 20*time.Minute),
+			contentionJob("test", "linux", 4*time.Minute),
+			contentionJob("lint", "linux", 10*time.Second),
+		}},
+	}
+
+	groups, jobs := analyzeQueueContention(builds, map[string]string{"deploy": "production"}, time.Minute)
+	assert.Equal(6, jobs)
+	assert.Len(groups, 2)
+
+	assert.Equal(ContentionKindConcurrencyGroup, groups[0].Kind)
+	assert.Equal("production", groups[0].Key)
+	assert.Equal(2, groups[0].WaitedJobs)
+	assert.Equal(1800.0, groups[0].TotalWaitSeconds)
+	assert.Equal(1200.0, groups[0].MaxWaitSeconds)
+
+	assert.Equal(ContentionKindQueue, groups[1].Kind)
+	assert.Equal("linux", groups[1].Key)
+	assert.Equal(4, groups[1].Jobs)
+	assert.Equal(2, groups[1].WaitedJobs)
+	assert.Equal(50.0, groups[1].WaitedPercent)
+	// only steps which waited are listed
+	assert.Len(groups[1].Steps, 1)
+	assert.Equal("test", groups[1].Steps[0].Step)
+	assert.Equal(240.0, groups[1].Steps[0].P95WaitSeconds)
+}
+
+func TestStepConcurrencyGroups(t *testing.T) {
+	assert := require.New(t)
+
+	groups := stepConcurrencyGroups(`
+steps:
+  - key: deploy
+    label: ":rocket: Deploy"
+    command: deploy.sh
+    concurrency: 1
+    concurrency_group: app/deploy
+  - command: test.sh
+`)
+	assert.Equal(map[string]string{"deploy": "app/deploy", ":rocket: Deploy": "app/deploy"}, groups)
+}
+
+func TestAnalyzeQueueContentionTool(t *testing.T) {
+	assert := require.New(t)
+
+	buildsClient := &MockBuildsClient{
+		ListByPipelineFunc: func(ctx context.Context, org string, pipeline string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error) {
+			return []buildkite.Build{
+				{Number: 1, Jobs: []buildkite.Job{contentionJob("test", "linux", 3*time.Minute)}},
+			}, &buildkite.Response{}, nil
+		},
+	}
+	pipelinesClient := &MockPipelinesClient{
+		GetFunc: func(ctx context.Context, org string, pipeline string) (buildkite.Pipeline, *buildkite.Response, error) {
+			return buildkite.Pipeline{}, nil, errors.New("forbidden")
+		},
+	}
+
+	_, handler, scopes := AnalyzeQueueContention(buildsClient, pipelinesClient)
+	assert.Equal([]string{"read_builds", "read_pipelines"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, AnalyzeQueueContentionArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+	})
+	assert.NoError(err)
+	assert.False(result.IsError, getTextResult(t, result).Text)
+
+	var contention QueueContention
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &contention))
+	assert.Equal(1, contention.BuildsAnalyzed)
+	assert.Equal(60, contention.MinWaitSeconds)
+	assert.Len(contention.Notes, 1)
+	assert.Equal("linux", contention.Groups[0].Key)
+	assert.Equal(1, contention.Groups[0].WaitedJobs)
+}
@@ -0,0 +1,246 @@
+package buildkite
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/reltime"
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	defaultQueueWaitWindow = "-24h"
+	defaultQueueWaitLimit  = 10
+	maxQueueWaitLimit      = 50
+	maxQueueWaitBuildPages = 10
+)
+
+// queueWaitBuckets are the upper bounds of the wait histogram buckets, with a last bucket for
+// longer waits
+var queueWaitBuckets = []time.Duration{
+	10 * time.Second,
+	30 * time.Second,
+	time.Minute,
+	5 * time.Minute,
+	15 * time.Minute,
+	time.Hour,
+}
+
+type GetQueueWaitTimesArgs struct {
+	OrgSlug     string `json:"org_slug"`
+	ClusterID   string `json:"cluster_id"`
+	QueueID     string `json:"queue_id"`
+	CreatedFrom string `json:"created_from,omitempty"`
+	CreatedTo   string `json:"created_to,omitempty"`
+	Limit       int    `json:"limit,omitempty"`
+}
+
+// WaitBucket counts the jobs which waited up to a duration to start, and longer than the bucket
+// before it
+type WaitBucket struct {
+	// UpToSeconds is the longest wait in the bucket, or 0 for the last bucket
+	UpToSeconds int `json:"up_to_seconds,omitempty"`
+	Jobs        int `json:"jobs"`
+}
+
+// WaitingJob is a job which waited to start on the queue
+type WaitingJob struct {
+	PipelineSlug string               `json:"pipeline_slug"`
+	BuildNumber  int                  `json:"build_number"`
+	JobID        string               `json:"job_id"`
+	Label        string               `json:"label"`
+	WaitSeconds  float64              `json:"wait_seconds"`
+	ScheduledAt  *buildkite.Timestamp `json:"scheduled_at"`
+	WebURL       string               `json:"web_url,omitempty"`
+}
+
+type QueueWaitTimes struct {
+	QueueKey       string        `json:"queue_key"`
+	CreatedFrom    time.Time     `json:"created_from"`
+	BuildsAnalyzed int           `json:"builds_analyzed"`
+	Wait           DurationStats `json:"wait"`
+	Histogram      []WaitBucket  `json:"histogram"`
+	// WorstOffenders are the jobs which waited longest
+	WorstOffenders []WaitingJob `json:"worst_offenders"`
+	Truncated      bool         `json:"truncated,omitempty"`
+	Notes          []string     `json:"notes,omitempty"`
+}
+
+// onQueue returns whether a job ran on the queue, by its ID or, for jobs which don't record it, by
+// the queue's key in the same cluster
+func onQueue(job buildkite.Job, queue buildkite.ClusterQueue, clusterID string) bool {
+	if job.ClusterQueueID != "" {
+		return job.ClusterQueueID == queue.ID
+	}
+	return job.ClusterID == clusterID && jobQueue(job) == queue.Key
+}
+
+// queueWaitTimes measures how long the jobs of the builds which ran on the queue waited between
+// being scheduled and starting
+func queueWaitTimes(builds []buildkite.Build, queue buildkite.ClusterQueue, clusterID string, limit int) ([]float64, []WaitBucket, []WaitingJob) {
+	histogram := make([]WaitBucket, len(queueWaitBuckets)+1)
+	for i, bound := range queueWaitBuckets {
+		histogram[i].UpToSeconds = int(bound.Seconds())
+	}
+
+	var waits []float64
+	var jobs []WaitingJob
+	for _, build := range builds {
+		for _, job := range build.Jobs {
+			if job.Type != "" && job.Type != "script" {
+				continue
+			}
+			if job.ScheduledAt == nil || job.StartedAt == nil || !onQueue(job, queue, clusterID) {
+				continue
+			}
+
+			wait := max(job.StartedAt.Sub(job.ScheduledAt.Time), 0)
+			bucket, _ := slices.BinarySearch(queueWaitBuckets, wait)
+			histogram[bucket].Jobs++
+			waits = append(waits, wait.Seconds())
+
+			waiting := WaitingJob{
+				BuildNumber: build.Number,
+				JobID:       job.ID,
+				Label:       jobLabel(job),
+				WaitSeconds: wait.Seconds(),
+				ScheduledAt: job.ScheduledAt,
+				WebURL:      job.WebURL,
+			}
+			if build.Pipeline != nil {
+				waiting.PipelineSlug = build.Pipeline.Slug
+			}
+			jobs = append(jobs, waiting)
+		}
+	}
+
+	slices.SortFunc(jobs, func(a, b WaitingJob) int {
+		return cmp.Or(
+			cmp.Compare(b.WaitSeconds, a.WaitSeconds),
+			cmp.Compare(a.JobID, b.JobID),
+		)
+	})
+
+	return waits, histogram, jobs[:min(len(jobs), limit)]
+}
+
+func GetQueueWaitTimes(buildsClient BuildsClient, queuesClient ClusterQueuesClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[GetQueueWaitTimesArgs], scopes []string) {
+	return mcp.NewTool("get_queue_wait_times",
+			mcp.WithDescription("Measure how long jobs waited between being scheduled and starting on a cluster queue over a time window, across every pipeline of the organization: mean, median, 95th percentile and longest wait, a histogram of waits and the jobs which waited longest. Long waits point at too few agents for the queue, for planning its capacity"),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("cluster_id",
+				mcp.Required(),
+			),
+			mcp.WithString("queue_id",
+				mcp.Required(),
+			),
+			mcp.WithString("created_from",
+				mcp.Description("Only include builds created at or after this, either "+reltime.Formats+" (default: -24h)"),
+			),
+			mcp.WithString("created_to",
+				mcp.Description("Only include builds created before this, either "+reltime.Formats),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description(fmt.Sprintf("Number of the longest waiting jobs to return (default %d, max %d)", defaultQueueWaitLimit, maxQueueWaitLimit)),
+				mcp.Min(1),
+				mcp.Max(maxQueueWaitLimit),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Get Queue Wait Times",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args GetQueueWaitTimesArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.GetQueueWaitTimes")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.ClusterID == "" {
+				return mcp.NewToolResultError("cluster_id parameter is required"), nil
+			}
+			if args.QueueID == "" {
+				return mcp.NewToolResultError("queue_id parameter is required"), nil
+			}
+			if args.CreatedFrom == "" {
+				args.CreatedFrom = defaultQueueWaitWindow
+			}
+			if args.Limit <= 0 {
+				args.Limit = defaultQueueWaitLimit
+			}
+			args.Limit = min(args.Limit, maxQueueWaitLimit)
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("cluster_id", args.ClusterID),
+				attribute.String("queue_id", args.QueueID),
+				attribute.String("created_from", args.CreatedFrom),
+				attribute.String("created_to", args.CreatedTo),
+				attribute.Int("limit", args.Limit),
+			)
+
+			now := time.Now()
+			options := &buildkite.BuildsListOptions{ListOptions: buildkite.ListOptions{PerPage: 100}}
+			var err error
+			if options.CreatedFrom, err = parseRelativeTime("created_from", args.CreatedFrom, now); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if args.CreatedTo != "" {
+				if options.CreatedTo, err = parseRelativeTime("created_to", args.CreatedTo, now); err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+			}
+
+			queue, _, err := queuesClient.Get(ctx, args.OrgSlug, args.ClusterID, args.QueueID)
+			if err != nil {
+				return mcp.NewToolResultError(buildErrorMessage(err)), nil
+			}
+
+			// builds of every pipeline can run jobs on the queue, with their jobs for the waits
+			var builds []buildkite.Build
+			result := QueueWaitTimes{QueueKey: queue.Key, CreatedFrom: options.CreatedFrom}
+			for page := 0; ; page++ {
+				if page == maxQueueWaitBuildPages {
+					result.Truncated = true
+					result.Notes = append(result.Notes, fmt.Sprintf("only the newest %d builds since %s were analyzed, narrow the window to cover it all", len(builds), options.CreatedFrom.Format(time.RFC3339)))
+					break
+				}
+
+				pageBuilds, resp, err := buildsClient.ListByOrg(ctx, args.OrgSlug, options)
+				if err != nil {
+					return mcp.NewToolResultError(buildErrorMessage(err)), nil
+				}
+				builds = append(builds, pageBuilds...)
+
+				if resp == nil || resp.NextPage == 0 || len(pageBuilds) == 0 {
+					break
+				}
+				options.Page = resp.NextPage
+			}
+
+			waits, histogram, worst := queueWaitTimes(builds, queue, args.ClusterID, args.Limit)
+			result.BuildsAnalyzed = len(builds)
+			result.Wait = durationStats(waits)
+			result.Histogram = histogram
+			result.WorstOffenders = worst
+			if len(waits) == 0 {
+				result.Notes = append(result.Notes, "no jobs of the builds in the window ran on the queue")
+			}
+
+			span.SetAttributes(
+				attribute.Int("builds_analyzed", result.BuildsAnalyzed),
+				attribute.Int("item_count", result.Wait.Count),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_builds", "read_clusters"}
+}
@@ -0,0 +1,89 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetQueueWaitTimes(t *testing.T) {
+	assert := require.New(t)
+
+	scheduled := time.Now().Add(-time.Hour)
+	job := func(id string, wait time.Duration, queueID string, rules ...string) buildkite.Job {
+		return buildkite.Job{
+			ID:              id,
+			Type:            "script",
+			Label:           "Test " + id,
+			ClusterID:       "cluster",
+			ClusterQueueID:  queueID,
+			AgentQueryRules: rules,
+			ScheduledAt:     &buildkite.Timestamp{Time: scheduled},
+			StartedAt:       &buildkite.Timestamp{Time: scheduled.Add(wait)},
+		}
+	}
+
+	var capturedOptions []buildkite.BuildsListOptions
+	buildsClient := &MockBuildsClient{
+		ListByOrgFunc: func(ctx context.Context, org string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error) {
+			capturedOptions = append(capturedOptions, *opt)
+			if opt.Page == 0 {
+				return []buildkite.Build{{
+					Number:   1,
+					Pipeline: &buildkite.Pipeline{Slug: "web"},
+					Jobs: []buildkite.Job{
+						job("fast", 5*time.Second, "queue-id"),
+						job("slow", 20*time.Minute, "queue-id"),
+						job("elsewhere", time.Hour, "other-queue"),
+						{Type: "waiter"},
+					},
+				}}, &buildkite.Response{NextPage: 2}, nil
+			}
+			return []buildkite.Build{{
+				Number:   2,
+				Pipeline: &buildkite.Pipeline{Slug: "api"},
+				// jobs which don't record their queue are matched by its key
+				Jobs: []buildkite.Job{job("by-key", 2*time.Minute, "", "queue=linux")},
+			}}, &buildkite.Response{}, nil
+		},
+	}
+	queuesClient := &mockClusterQueuesClient{
+		GetFunc: func(ctx context.Context, org, clusterID, queueID string) (buildkite.ClusterQueue, *buildkite.Response, error) {
+			return buildkite.ClusterQueue{ID: queueID, Key: "linux"}, nil, nil
+		},
+	}
+
+	tool, handler, scopes := GetQueueWaitTimes(buildsClient, queuesClient)
+	assert.Equal("get_queue_wait_times", tool.Name)
+	assert.True(*tool.Annotations.ReadOnlyHint)
+	assert.Equal([]string{"read_builds", "read_clusters"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, GetQueueWaitTimesArgs{OrgSlug: "org", ClusterID: "cluster", QueueID: "queue-id", Limit: 2})
+	assert.NoError(err)
+
+	var waits QueueWaitTimes
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &waits))
+	assert.Len(capturedOptions, 2)
+	assert.WithinDuration(time.Now().Add(-24*time.Hour), capturedOptions[0].CreatedFrom, time.Minute)
+	assert.Equal("linux", waits.QueueKey)
+	assert.Equal(2, waits.BuildsAnalyzed)
+	assert.Equal(3, waits.Wait.Count)
+	assert.Equal(1200.0, waits.Wait.MaxSeconds)
+
+	assert.Len(waits.Histogram, 7)
+	assert.Equal(WaitBucket{UpToSeconds: 10, Jobs: 1}, waits.Histogram[0])
+	assert.Equal(WaitBucket{UpToSeconds: 300, Jobs: 1}, waits.Histogram[3])
+	assert.Equal(WaitBucket{UpToSeconds: 3600, Jobs: 1}, waits.Histogram[5])
+	assert.Equal(WaitBucket{Jobs: 0}, waits.Histogram[6])
+
+	assert.Len(waits.WorstOffenders, 2)
+	assert.Equal("slow", waits.WorstOffenders[0].JobID)
+	assert.Equal("web", waits.WorstOffenders[0].PipelineSlug)
+	assert.Equal("by-key", waits.WorstOffenders[1].JobID)
+	assert.Equal("api", waits.WorstOffenders[1].PipelineSlug)
+}
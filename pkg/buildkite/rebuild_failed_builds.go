@@ -0,0 +1,231 @@
+package buildkite
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/reltime"
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	defaultRebuildLimit      = 20
+	maxRebuildLimit          = 100
+	maxRebuildBuildPages     = 10
+	rebuildBuildsConcurrency = 4
+)
+
+type RebuildFailedBuildsArgs struct {
+	OrgSlug      string `json:"org_slug"`
+	PipelineSlug string `json:"pipeline_slug"`
+	Since        string `json:"since"`
+	Branch       string `json:"branch,omitempty"`
+	Limit        int    `json:"limit,omitempty"`
+}
+
+// RebuiltBuild is the outcome of rebuilding one failed build
+type RebuiltBuild struct {
+	Number        int    `json:"number"`
+	Branch        string `json:"branch"`
+	Commit        string `json:"commit"`
+	RebuildNumber int    `json:"rebuild_number,omitempty"`
+	RebuildURL    string `json:"rebuild_url,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+type RebuildFailedBuildsResult struct {
+	Since          time.Time      `json:"since"`
+	FailedBuilds   int            `json:"failed_builds"`
+	AlreadyRebuilt int            `json:"already_rebuilt"`
+	Rebuilt        int            `json:"rebuilt"`
+	Failed         int            `json:"failed"`
+	Builds         []RebuiltBuild `json:"builds"`
+	Notes          []string       `json:"notes,omitempty"`
+}
+
+// listBuildsCreatedSince returns the builds of a pipeline created since a time, and whether there
+// were more than could be listed
+func listBuildsCreatedSince(ctx context.Context, client BuildsClient, org, pipeline, branch string, since time.Time) ([]buildkite.Build, bool, error) {
+	options := &buildkite.BuildsListOptions{
+		CreatedFrom:     since,
+		ExcludeJobs:     true,
+		ExcludePipeline: true,
+		ListOptions:     buildkite.ListOptions{PerPage: 100},
+	}
+	if branch != "" {
+		options.Branch = []string{branch}
+	}
+
+	var builds []buildkite.Build
+	for range maxRebuildBuildPages {
+		page, resp, err := client.ListByPipeline(ctx, org, pipeline, options)
+		if err != nil {
+			return nil, false, err
+		}
+
+		builds = append(builds, page...)
+
+		if resp == nil || resp.NextPage == 0 || len(page) == 0 {
+			return builds, false, nil
+		}
+		options.Page = resp.NextPage
+	}
+	return builds, true, nil
+}
+
+// failedBuildsToRebuild returns the failed builds, oldest first, leaving out those which a later
+// build in the list was already rebuilt from, along with how many were left out
+func failedBuildsToRebuild(builds []buildkite.Build) ([]buildkite.Build, int) {
+	rebuilt := map[int]bool{}
+	for _, build := range builds {
+		if build.RebuiltFrom != nil {
+			rebuilt[build.RebuiltFrom.Number] = true
+		}
+	}
+
+	var failed []buildkite.Build
+	alreadyRebuilt := 0
+	for _, build := range builds {
+		if build.State != "failed" {
+			continue
+		}
+		if rebuilt[build.Number] {
+			alreadyRebuilt++
+			continue
+		}
+		failed = append(failed, build)
+	}
+
+	slices.SortFunc(failed, func(a, b buildkite.Build) int {
+		return cmp.Compare(a.Number, b.Number)
+	})
+	return failed, alreadyRebuilt
+}
+
+func RebuildFailedBuilds(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[RebuildFailedBuildsArgs], scopes []string) {
+	return mcp.NewTool("rebuild_failed_builds",
+			mcp.WithDescription("Rebuild every failed build of a pipeline created since a time, optionally on one branch, such as after an infrastructure outage. Builds which have already been rebuilt are skipped. Returns the number of the new build created for each failed build"),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("since",
+				mcp.Required(),
+				mcp.Description("Rebuild failed builds created since this, either "+reltime.Formats),
+			),
+			mcp.WithString("branch",
+				mcp.Description("Only rebuild failed builds on this branch"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Maximum number of builds to rebuild, oldest first (default 20, max 100)"),
+				mcp.Min(1),
+				mcp.Max(maxRebuildLimit),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Rebuild Failed Builds",
+				ReadOnlyHint: mcp.ToBoolPtr(false),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args RebuildFailedBuildsArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.RebuildFailedBuilds")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if args.Since == "" {
+				return mcp.NewToolResultError("since parameter is required"), nil
+			}
+			since, err := parseRelativeTime("since", args.Since, time.Now())
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if args.Limit <= 0 {
+				args.Limit = defaultRebuildLimit
+			}
+			args.Limit = min(args.Limit, maxRebuildLimit)
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+				attribute.String("branch", args.Branch),
+				attribute.Int("limit", args.Limit),
+			)
+
+			builds, truncated, err := listBuildsCreatedSince(ctx, client, args.OrgSlug, args.PipelineSlug, args.Branch, since)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			failed, alreadyRebuilt := failedBuildsToRebuild(builds)
+
+			result := RebuildFailedBuildsResult{
+				Since:          since,
+				FailedBuilds:   len(failed),
+				AlreadyRebuilt: alreadyRebuilt,
+				Builds:         []RebuiltBuild{},
+			}
+			if truncated {
+				result.Notes = append(result.Notes, fmt.Sprintf("only the first %d builds since %s were considered", len(builds), since.Format(time.RFC3339)))
+			}
+			if len(failed) > args.Limit {
+				result.Notes = append(result.Notes, fmt.Sprintf("only the oldest %d of %d failed builds were rebuilt, run again to rebuild the rest", args.Limit, len(failed)))
+				failed = failed[:args.Limit]
+			}
+
+			for _, build := range failed {
+				result.Builds = append(result.Builds, RebuiltBuild{
+					Number: build.Number,
+					Branch: build.Branch,
+					Commit: build.Commit,
+				})
+			}
+
+			// each build's outcome is recorded in place, so one failure doesn't stop the others
+			g, gctx := errgroup.WithContext(ctx)
+			g.SetLimit(rebuildBuildsConcurrency)
+			for i := range result.Builds {
+				g.Go(func() error {
+					build := &result.Builds[i]
+					rebuild, err := client.Rebuild(gctx, args.OrgSlug, args.PipelineSlug, strconv.Itoa(build.Number))
+					if err != nil {
+						build.Error = err.Error()
+						return nil
+					}
+					build.RebuildNumber = rebuild.Number
+					build.RebuildURL = rebuild.WebURL
+					return nil
+				})
+			}
+			_ = g.Wait()
+
+			for _, build := range result.Builds {
+				if build.Error == "" {
+					result.Rebuilt++
+				} else {
+					result.Failed++
+				}
+			}
+
+			span.SetAttributes(
+				attribute.Int("item_count", len(result.Builds)),
+				attribute.Int("rebuilt", result.Rebuilt),
+				attribute.Int("failed", result.Failed),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_builds", "write_builds"}
+}
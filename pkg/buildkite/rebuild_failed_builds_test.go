@@ -0,0 +1,109 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRebuildFailedBuilds(t *testing.T) {
+	assert := require.New(t)
+
+	client := &MockBuildsClient{
+		ListByPipelineFunc: func(ctx context.Context, org string, pipeline string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error) {
+			assert.False(opt.CreatedFrom.IsZero())
+			assert.Equal([]string{"main"}, opt.Branch)
+			return []buildkite.Build{
+				{Number: 14, State: "passed", RebuiltFrom: &buildkite.RebuiltFrom{Number: 10}},
+				{Number: 13, State: "failed"},
+				{Number: 12, State: "failed"},
+				{Number: 11, State: "passed"},
+				{Number: 10, State: "failed"},
+			}, &buildkite.Response{}, nil
+		},
+		RebuildFunc: func(ctx context.Context, org string, pipeline string, build string) (buildkite.Build, error) {
+			if build == "13" {
+				return buildkite.Build{}, errors.New("forbidden")
+			}
+			return buildkite.Build{Number: 20, WebURL: "https://buildkite.com/org/pipeline/builds/20"}, nil
+		},
+	}
+
+	_, handler, scopes := RebuildFailedBuilds(client)
+	assert.Equal([]string{"read_builds", "write_builds"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, RebuildFailedBuildsArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+		Since:        "6h",
+		Branch:       "main",
+	})
+	assert.NoError(err)
+	assert.False(result.IsError, getTextResult(t, result).Text)
+
+	var response RebuildFailedBuildsResult
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &response))
+	assert.Equal(2, response.FailedBuilds)
+	assert.Equal(1, response.AlreadyRebuilt)
+	assert.Equal(1, response.Rebuilt)
+	assert.Equal(1, response.Failed)
+	assert.Len(response.Builds, 2)
+	assert.Equal(12, response.Builds[0].Number)
+	assert.Equal(20, response.Builds[0].RebuildNumber)
+	assert.Equal(13, response.Builds[1].Number)
+	assert.Equal("forbidden", response.Builds[1].Error)
+}
+
+func TestRebuildFailedBuildsLimit(t *testing.T) {
+	assert := require.New(t)
+
+	var rebuilt []string
+	client := &MockBuildsClient{
+		ListByPipelineFunc: func(ctx context.Context, org string, pipeline string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error) {
+			return []buildkite.Build{
+				{Number: 3, State: "failed"},
+				{Number: 2, State: "failed"},
+				{Number: 1, State: "failed"},
+			}, &buildkite.Response{}, nil
+		},
+		RebuildFunc: func(ctx context.Context, org string, pipeline string, build string) (buildkite.Build, error) {
+			rebuilt = append(rebuilt, build)
+			return buildkite.Build{Number: 10}, nil
+		},
+	}
+
+	_, handler, _ := RebuildFailedBuilds(client)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, RebuildFailedBuildsArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+		Since:        "2025-01-01",
+		Limit:        1,
+	})
+	assert.NoError(err)
+
+	var response RebuildFailedBuildsResult
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &response))
+	assert.Equal([]string{"1"}, rebuilt)
+	assert.Equal(3, response.FailedBuilds)
+	assert.Len(response.Notes, 1)
+}
+
+func TestRebuildFailedBuildsRequiresSince(t *testing.T) {
+	assert := require.New(t)
+
+	_, handler, _ := RebuildFailedBuilds(&MockBuildsClient{})
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, RebuildFailedBuildsArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+	})
+	assert.NoError(err)
+	assert.True(result.IsError)
+	assert.Contains(getTextResult(t, result).Text, "since parameter is required")
+}
@@ -0,0 +1,215 @@
+package buildkite
+
+import (
+	"cmp"
+	"context"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	defaultRecentArtifactBuilds = 10
+	maxRecentArtifactBuilds     = 50
+	maxRecentArtifacts          = 100
+	recentArtifactsConcurrency  = 4
+)
+
+type ListRecentArtifactsArgs struct {
+	OrgSlug           string `json:"org_slug"`
+	PipelineSlug      string `json:"pipeline_slug"`
+	Glob              string `json:"glob"`
+	Builds            int    `json:"builds,omitempty"`
+	Branch            string `json:"branch,omitempty"`
+	ExpiryWarningDays *int   `json:"expiry_warning_days,omitempty"`
+}
+
+// BuildArtifact is an artifact with the context of the build which uploaded it
+type BuildArtifact struct {
+	BuildNumber int                  `json:"build_number"`
+	BuildState  string               `json:"build_state"`
+	Branch      string               `json:"branch"`
+	Commit      string               `json:"commit"`
+	CreatedAt   *buildkite.Timestamp `json:"created_at,omitempty"`
+	ArtifactID  string               `json:"artifact_id"`
+	JobID       string               `json:"job_id"`
+	Path        string               `json:"path"`
+	FileSize    int64                `json:"file_size"`
+	SHA1        string               `json:"sha1sum,omitempty"`
+	ArtifactExpiry
+}
+
+type RecentArtifacts struct {
+	Glob           string          `json:"glob"`
+	BuildsSearched int             `json:"builds_searched"`
+	Total          int             `json:"total"`
+	Artifacts      []BuildArtifact `json:"artifacts"`
+}
+
+// matchArtifactGlob matches an artifact path against a glob. Globs without a slash also match the
+// file name alone, so "*.tar.gz" finds archives in any directory, and globs ending in "/**" match
+// everything beneath the directories they match.
+func matchArtifactGlob(glob, artifactPath string) bool {
+	if dirGlob, ok := strings.CutSuffix(glob, "/**"); ok {
+		for dir := path.Dir(artifactPath); dir != "." && dir != "/"; dir = path.Dir(dir) {
+			if ok, _ := path.Match(dirGlob, dir); ok {
+				return true
+			}
+		}
+		return false
+	}
+	if ok, _ := path.Match(glob, artifactPath); ok {
+		return true
+	}
+	if !strings.Contains(glob, "/") {
+		ok, _ := path.Match(glob, path.Base(artifactPath))
+		return ok
+	}
+	return false
+}
+
+func ListRecentArtifacts(buildsClient BuildsClient, artifactsClient ArtifactsClient, retention time.Duration) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[ListRecentArtifactsArgs], scopes []string) {
+	return mcp.NewTool("list_recent_artifacts",
+			mcp.WithDescription("Find artifacts matching a glob, such as release binaries, across a pipeline's most recent finished builds. Returns them newest first with the build number, branch, commit, checksum, and estimated expiry of each, for release and provenance questions."),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("glob",
+				mcp.Required(),
+				mcp.Description("Glob matched against artifact paths, e.g. 'dist/*.tar.gz'. A glob without a slash also matches file names in any directory"),
+			),
+			mcp.WithNumber("builds",
+				mcp.Description("Number of recent builds to search (default 10, max 50)"),
+				mcp.Min(1),
+				mcp.Max(maxRecentArtifactBuilds),
+			),
+			mcp.WithString("branch",
+				mcp.Description("Only search builds on this branch"),
+			),
+			mcp.WithNumber("expiry_warning_days",
+				mcp.Description("Flag artifacts expiring within this many days as expiring_soon (default 14)"),
+				mcp.Min(0),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "List Recent Artifacts",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args ListRecentArtifactsArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.ListRecentArtifacts")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if args.Glob == "" {
+				return mcp.NewToolResultError("glob parameter is required"), nil
+			}
+			if _, err := path.Match(args.Glob, ""); err != nil {
+				return mcp.NewToolResultError("invalid glob: " + err.Error()), nil
+			}
+			if args.Builds <= 0 {
+				args.Builds = defaultRecentArtifactBuilds
+			}
+			args.Builds = min(args.Builds, maxRecentArtifactBuilds)
+			expiryWarningDays := defaultExpiryWarningDays
+			if args.ExpiryWarningDays != nil {
+				expiryWarningDays = *args.ExpiryWarningDays
+			}
+			horizon := time.Duration(expiryWarningDays) * 24 * time.Hour
+			now := time.Now()
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+				attribute.String("glob", args.Glob),
+				attribute.String("branch", args.Branch),
+				attribute.Int("builds", args.Builds),
+			)
+
+			builds, err := listRecentBuilds(ctx, buildsClient, args.OrgSlug, args.PipelineSlug, args.Branch, args.Builds)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			var mu sync.Mutex
+			var artifacts []BuildArtifact
+
+			g, gctx := errgroup.WithContext(ctx)
+			g.SetLimit(recentArtifactsConcurrency)
+			for _, build := range builds {
+				g.Go(func() error {
+					buildArtifacts, _, err := artifactsClient.ListByBuild(gctx, args.OrgSlug, args.PipelineSlug, strconv.Itoa(build.Number), &buildkite.ArtifactListOptions{
+						ListOptions: buildkite.ListOptions{PerPage: 100},
+					})
+					if err != nil {
+						return err
+					}
+
+					mu.Lock()
+					defer mu.Unlock()
+
+					for _, artifact := range buildArtifacts {
+						if !matchArtifactGlob(args.Glob, artifact.Path) {
+							continue
+						}
+						artifacts = append(artifacts, BuildArtifact{
+							BuildNumber:    build.Number,
+							BuildState:     build.State,
+							Branch:         build.Branch,
+							Commit:         build.Commit,
+							CreatedAt:      build.CreatedAt,
+							ArtifactID:     artifact.ID,
+							JobID:          artifact.JobID,
+							Path:           artifact.Path,
+							FileSize:       artifact.FileSize,
+							SHA1:           artifact.SHA1,
+							ArtifactExpiry: artifactExpiry(build.CreatedAt, retention, horizon, now),
+						})
+					}
+					return nil
+				})
+			}
+			if err := g.Wait(); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			slices.SortFunc(artifacts, func(a, b BuildArtifact) int {
+				return cmp.Or(
+					cmp.Compare(b.BuildNumber, a.BuildNumber),
+					cmp.Compare(a.Path, b.Path),
+				)
+			})
+
+			result := RecentArtifacts{
+				Glob:           args.Glob,
+				BuildsSearched: len(builds),
+				Total:          len(artifacts),
+				Artifacts:      artifacts[:min(len(artifacts), maxRecentArtifacts)],
+			}
+			if result.Artifacts == nil {
+				result.Artifacts = []BuildArtifact{}
+			}
+
+			span.SetAttributes(
+				attribute.Int("item_count", result.Total),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_builds", "read_artifacts"}
+}
@@ -0,0 +1,78 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchArtifactGlob(t *testing.T) {
+	assert := require.New(t)
+
+	assert.True(matchArtifactGlob("dist/*.tar.gz", "dist/app-linux.tar.gz"))
+	assert.False(matchArtifactGlob("dist/*.tar.gz", "build/dist/app-linux.tar.gz"))
+	assert.True(matchArtifactGlob("*.tar.gz", "build/dist/app-linux.tar.gz"))
+	assert.False(matchArtifactGlob("*.zip", "build/dist/app-linux.tar.gz"))
+	assert.True(matchArtifactGlob("coverage/**", "coverage/src/app.js.html"))
+	assert.True(matchArtifactGlob("*/coverage/**", "web/coverage/index.html"))
+	assert.False(matchArtifactGlob("coverage/**", "coverage"))
+	assert.False(matchArtifactGlob("coverage/**", "logs/coverage.txt"))
+}
+
+func TestListRecentArtifacts(t *testing.T) {
+	assert := require.New(t)
+
+	buildsClient := &MockBuildsClient{
+		ListByPipelineFunc: func(ctx context.Context, org string, pipeline string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error) {
+			assert.Equal([]string{"main"}, opt.Branch)
+			return []buildkite.Build{
+				{Number: 12, Branch: "main", Commit: "bbb", CreatedAt: &buildkite.Timestamp{Time: time.Now().Add(-10 * 24 * time.Hour)}},
+				{Number: 11, Branch: "main", Commit: "aaa", CreatedAt: &buildkite.Timestamp{Time: time.Now().Add(-175 * 24 * time.Hour)}},
+			}, &buildkite.Response{}, nil
+		},
+	}
+	artifactsClient := &MockArtifactsClient{
+		ListByBuildFunc: func(ctx context.Context, org, pipelineSlug, buildNumber string, opts *buildkite.ArtifactListOptions) ([]buildkite.Artifact, *buildkite.Response, error) {
+			return []buildkite.Artifact{
+				{ID: "linux-" + buildNumber, Path: "dist/app-linux.tar.gz", FileSize: 100, SHA1: "sha-" + buildNumber},
+				{ID: "log-" + buildNumber, Path: "logs/test.log"},
+			}, &buildkite.Response{}, nil
+		},
+	}
+
+	_, handler, scopes := ListRecentArtifacts(buildsClient, artifactsClient, 180*24*time.Hour)
+	assert.Equal([]string{"read_builds", "read_artifacts"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, ListRecentArtifactsArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+		Glob:         "*.tar.gz",
+		Branch:       "main",
+	})
+	assert.NoError(err)
+
+	var recent RecentArtifacts
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &recent))
+	assert.Equal(2, recent.BuildsSearched)
+	assert.Equal(2, recent.Total)
+	assert.Equal(12, recent.Artifacts[0].BuildNumber)
+	assert.Equal("bbb", recent.Artifacts[0].Commit)
+	assert.Equal("sha-12", recent.Artifacts[0].SHA1)
+	assert.Equal(11, recent.Artifacts[1].BuildNumber)
+	assert.NotNil(recent.Artifacts[0].ExpiresAt)
+	assert.False(recent.Artifacts[0].ExpiringSoon)
+	assert.True(recent.Artifacts[1].ExpiringSoon)
+
+	result, err = handler(context.Background(), mcp.CallToolRequest{}, ListRecentArtifactsArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+		Glob:         "[",
+	})
+	assert.NoError(err)
+	assert.True(result.IsError)
+}
@@ -0,0 +1,220 @@
+package buildkite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/policy"
+	"github.com/buildkite/buildkite-mcp-server/pkg/reltime"
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	defaultSearchBuildsLimit  = 30
+	maxSearchBuildsLimit      = 100
+	maxSearchBuildPages       = 10
+	defaultSearchBuildsWindow = "-7d"
+)
+
+type SearchBuildsArgs struct {
+	OrgSlug     string   `json:"org_slug"`
+	State       []string `json:"state,omitempty"`
+	Branch      []string `json:"branch,omitempty"`
+	Creator     string   `json:"creator,omitempty"`
+	Commit      string   `json:"commit,omitempty"`
+	Message     string   `json:"message,omitempty"`
+	CreatedFrom string   `json:"created_from,omitempty"`
+	CreatedTo   string   `json:"created_to,omitempty"`
+	Limit       int      `json:"limit,omitempty"`
+	Timezone    string   `json:"timezone,omitempty"`
+}
+
+// SearchedBuild is a build found by a search, with the pipeline it belongs to
+type SearchedBuild struct {
+	PipelineSlug string `json:"pipeline_slug"`
+	BuildSummary
+	Creator string `json:"creator,omitempty"`
+}
+
+type SearchBuildsResult struct {
+	CreatedFrom time.Time       `json:"created_from"`
+	Builds      []SearchedBuild `json:"builds"`
+	// Scanned is the number of builds listed from the API, which is more than were returned when
+	// filtering by message
+	Scanned   int      `json:"scanned"`
+	Truncated bool     `json:"truncated,omitempty"`
+	Notes     []string `json:"notes,omitempty"`
+}
+
+// searchedBuild summarizes a build listed across an organization
+func searchedBuild(build buildkite.Build) SearchedBuild {
+	searched := SearchedBuild{
+		BuildSummary: summarizeBuild(build),
+		Creator:      build.Creator.Name,
+	}
+	if build.Pipeline != nil {
+		searched.PipelineSlug = build.Pipeline.Slug
+	}
+	return searched
+}
+
+func SearchBuilds(client BuildsClient, users UserClient, members OrganizationMembersClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[SearchBuildsArgs], scopes []string) {
+	return mcp.NewTool("search_builds",
+			mcp.WithDescription("Search the builds of every pipeline in an organization, newest first, by state, branch, creator, commit or a substring of the build message, such as to find your failing builds anywhere. Pages of builds are fetched until limit builds match, so a message search may scan many builds"),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithArray("state",
+				mcp.Description("Only find builds in one of these states, such as failed, running or passed"),
+				mcp.WithStringItems(),
+			),
+			mcp.WithArray("branch",
+				mcp.Description("Only find builds on one of these branches"),
+				mcp.WithStringItems(),
+			),
+			mcp.WithString("creator",
+				mcp.Description("Only find builds created by: 'me' for the owner of the API token, an email address of a member of the organization, or a user ID"),
+			),
+			mcp.WithString("commit",
+				mcp.Description("Only find builds of this commit SHA"),
+			),
+			mcp.WithString("message",
+				mcp.Description("Only find builds whose message contains this, ignoring case. The API can't filter on message, so it's applied to each page of builds"),
+			),
+			mcp.WithString("created_from",
+				mcp.Description("Only find builds created at or after this, either "+reltime.Formats+" (default: -7d)"),
+			),
+			mcp.WithString("created_to",
+				mcp.Description("Only find builds created before this, either "+reltime.Formats),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Maximum number of builds to return (default 30, max 100)"),
+				mcp.Min(1),
+				mcp.Max(maxSearchBuildsLimit),
+			),
+			withTimezone(),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Search Builds",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args SearchBuildsArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.SearchBuilds")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.Limit <= 0 {
+				args.Limit = defaultSearchBuildsLimit
+			}
+			args.Limit = min(args.Limit, maxSearchBuildsLimit)
+			if args.CreatedFrom == "" {
+				args.CreatedFrom = defaultSearchBuildsWindow
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.StringSlice("state", args.State),
+				attribute.StringSlice("branch", args.Branch),
+				attribute.String("creator", args.Creator),
+				attribute.String("commit", args.Commit),
+				attribute.String("message", args.Message),
+				attribute.String("created_from", args.CreatedFrom),
+				attribute.String("created_to", args.CreatedTo),
+				attribute.Int("limit", args.Limit),
+			)
+
+			loc, err := displayLocation(ctx, args.Timezone)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// the pipelines of the builds are included, as they're needed to tell the builds apart
+			now := time.Now()
+			options := &buildkite.BuildsListOptions{
+				State:       args.State,
+				Branch:      args.Branch,
+				Commit:      args.Commit,
+				ExcludeJobs: true,
+				ListOptions: buildkite.ListOptions{PerPage: 100},
+			}
+			if options.CreatedFrom, err = parseRelativeTime("created_from", args.CreatedFrom, now); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if args.CreatedTo != "" {
+				if options.CreatedTo, err = parseRelativeTime("created_to", args.CreatedTo, now); err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+			}
+			if args.Creator != "" {
+				if options.Creator, err = resolveCreator(ctx, users, members, args.OrgSlug, args.Creator); err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+			}
+
+			// builds of pipelines the scope policy doesn't permit are scanned but left out
+			scopePolicy := policy.ScopePolicyFromContext(ctx)
+			message := strings.ToLower(args.Message)
+			result := SearchBuildsResult{
+				CreatedFrom: options.CreatedFrom,
+				Builds:      []SearchedBuild{},
+			}
+
+			more := true
+			for page := 0; page < maxSearchBuildPages && more && len(result.Builds) < args.Limit; page++ {
+				builds, resp, err := client.ListByOrg(ctx, args.OrgSlug, options)
+				if err != nil {
+					var errResp *buildkite.ErrorResponse
+					if errors.As(err, &errResp) && errResp.RawBody != nil {
+						return mcp.NewToolResultError(string(errResp.RawBody)), nil
+					}
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				more = resp != nil && resp.NextPage != 0 && len(builds) > 0
+				if more {
+					options.Page = resp.NextPage
+				}
+
+				for _, build := range builds {
+					if len(result.Builds) == args.Limit {
+						// the rest of the page might have matched
+						more = true
+						break
+					}
+					result.Scanned++
+					if message != "" && !strings.Contains(strings.ToLower(build.Message), message) {
+						continue
+					}
+					searched := searchedBuild(build)
+					if scopePolicy.CheckPipeline(args.OrgSlug, searched.PipelineSlug) != nil {
+						continue
+					}
+					searched.BuildSummary = localizeBuild(searched.BuildSummary, loc, now)
+					result.Builds = append(result.Builds, searched)
+				}
+			}
+
+			if more {
+				result.Truncated = true
+				if len(result.Builds) == args.Limit {
+					result.Notes = append(result.Notes, fmt.Sprintf("only the newest %d matching builds were returned, narrow the search or raise limit to find more", args.Limit))
+				} else {
+					result.Notes = append(result.Notes, fmt.Sprintf("only the newest %d builds since %s were searched, narrow the search to find older builds", result.Scanned, options.CreatedFrom.Format(time.RFC3339)))
+				}
+			}
+
+			span.SetAttributes(
+				attribute.Int("item_count", len(result.Builds)),
+				attribute.Int("scanned", result.Scanned),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_builds"}
+}
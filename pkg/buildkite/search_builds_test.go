@@ -0,0 +1,147 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/policy"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchBuilds(t *testing.T) {
+	assert := require.New(t)
+
+	// three pages of builds across two pipelines, every third of which is a deploy
+	var listed []*buildkite.BuildsListOptions
+	client := &MockBuildsClient{
+		ListByOrgFunc: func(ctx context.Context, org string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error) {
+			options := *opt
+			listed = append(listed, &options)
+
+			page := max(opt.Page, 1)
+			var builds []buildkite.Build
+			for i := range 4 {
+				number := (page-1)*4 + i + 1
+				message := fmt.Sprintf("Fix bug %d", number)
+				if number%3 == 0 {
+					message = fmt.Sprintf("Deploy release %d", number)
+				}
+				builds = append(builds, buildkite.Build{
+					Number:   number,
+					State:    "failed",
+					Message:  message,
+					Creator:  buildkite.Creator{Name: "Jane"},
+					Pipeline: &buildkite.Pipeline{Slug: fmt.Sprintf("pipeline-%d", number%2)},
+				})
+			}
+
+			resp := &buildkite.Response{}
+			if page < 3 {
+				resp.NextPage = page + 1
+			}
+			return builds, resp, nil
+		},
+	}
+
+	tool, handler, scopes := SearchBuilds(client, nil, nil)
+	assert.Equal("search_builds", tool.Name)
+	assert.Equal([]string{"read_builds"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, SearchBuildsArgs{
+		OrgSlug: "org",
+		State:   []string{"failed"},
+		Branch:  []string{"main"},
+		Message: "DEPLOY",
+	})
+	assert.NoError(err)
+
+	var searched SearchBuildsResult
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &searched))
+
+	// every page was searched for matching messages
+	assert.Len(listed, 3)
+	assert.Equal([]string{"failed"}, listed[0].State)
+	assert.Equal([]string{"main"}, listed[0].Branch)
+	assert.True(listed[0].ExcludeJobs)
+	assert.False(listed[0].ExcludePipeline)
+	assert.False(listed[0].CreatedFrom.IsZero())
+
+	assert.Equal(12, searched.Scanned)
+	assert.False(searched.Truncated)
+	assert.Len(searched.Builds, 4)
+	assert.Equal(3, searched.Builds[0].Number)
+	assert.Equal("pipeline-1", searched.Builds[0].PipelineSlug)
+	assert.Equal("Jane", searched.Builds[0].Creator)
+	assert.Equal(6, searched.Builds[1].Number)
+	assert.Equal("pipeline-0", searched.Builds[1].PipelineSlug)
+}
+
+func TestSearchBuildsStopsAtLimit(t *testing.T) {
+	assert := require.New(t)
+
+	pages := 0
+	client := &MockBuildsClient{
+		ListByOrgFunc: func(ctx context.Context, org string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error) {
+			pages++
+			builds := make([]buildkite.Build, 5)
+			for i := range builds {
+				builds[i] = buildkite.Build{Number: i + 1, Pipeline: &buildkite.Pipeline{Slug: "pipeline"}}
+			}
+			return builds, &buildkite.Response{NextPage: pages + 1}, nil
+		},
+	}
+
+	_, handler, _ := SearchBuilds(client, nil, nil)
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, SearchBuildsArgs{OrgSlug: "org", Limit: 3})
+	assert.NoError(err)
+
+	var searched SearchBuildsResult
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &searched))
+	assert.Equal(1, pages)
+	assert.Len(searched.Builds, 3)
+	assert.True(searched.Truncated)
+	assert.Len(searched.Notes, 1)
+}
+
+func TestSearchBuildsFiltersByScopePolicy(t *testing.T) {
+	assert := require.New(t)
+
+	client := &MockBuildsClient{
+		ListByOrgFunc: func(ctx context.Context, org string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error) {
+			return []buildkite.Build{
+				{Number: 1, Pipeline: &buildkite.Pipeline{Slug: "web"}},
+				{Number: 2, Pipeline: &buildkite.Pipeline{Slug: "secret"}},
+				{Number: 3, Pipeline: &buildkite.Pipeline{Slug: "web"}},
+			}, &buildkite.Response{}, nil
+		},
+	}
+
+	scopePolicy, err := policy.NewScopePolicy(nil, []string{"web"})
+	assert.NoError(err)
+
+	_, handler, _ := SearchBuilds(client, nil, nil)
+	result, err := handler(policy.WithScopePolicy(context.Background(), scopePolicy), mcp.CallToolRequest{}, SearchBuildsArgs{OrgSlug: "org"})
+	assert.NoError(err)
+
+	var searched SearchBuildsResult
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &searched))
+	assert.Equal(3, searched.Scanned)
+	assert.Len(searched.Builds, 2)
+	for _, build := range searched.Builds {
+		assert.Equal("web", build.PipelineSlug)
+	}
+}
+
+func TestSearchBuildsRequiresOrg(t *testing.T) {
+	assert := require.New(t)
+
+	_, handler, _ := SearchBuilds(&MockBuildsClient{}, nil, nil)
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, SearchBuildsArgs{})
+	assert.NoError(err)
+	assert.True(result.IsError)
+	assert.Contains(getTextResult(t, result).Text, "org_slug parameter is required")
+}
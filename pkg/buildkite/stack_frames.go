@@ -0,0 +1,101 @@
+package buildkite
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/buildkite/go-buildkite/v4"
+)
+
+// StackFrame is a single parsed frame of a backtrace
+type StackFrame struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Function string `json:"function,omitempty"`
+	Raw      string `json:"raw"`
+}
+
+// stackFramePatterns match a frame in the common backtrace formats. Each has file, line and an optional
+// function group, named so they can be looked up regardless of order
+var stackFramePatterns = []*regexp.Regexp{
+	// Python: File "/app/foo.py", line 12, in method
+	regexp.MustCompile(`File "(?P<file>[^"]+)", line (?P<line>\d+)(?:, in (?P<function>\S+))?`),
+	// Java and Kotlin: at com.example.Foo.method(Foo.java:12)
+	regexp.MustCompile(`at (?P<function>[\w$.<>]+)\((?P<file>[^():]+):(?P<line>\d+)\)`),
+	// JavaScript: at method (/app/foo.js:12:5) or at /app/foo.js:12:5
+	regexp.MustCompile(`at (?:(?P<function>[^\s(]+) \()?(?P<file>[^\s()]+?):(?P<line>\d+):\d+\)?`),
+	// Ruby: /app/foo.rb:12:in 'method'
+	regexp.MustCompile("(?P<file>[^\\s:]+):(?P<line>\\d+):in [`'](?P<function>[^'`]+)'"),
+	// Go, Rust, C and anything else which prints path:line
+	regexp.MustCompile(`(?P<file>[\w./\\-]+\.\w+):(?P<line>\d+)`),
+}
+
+// vendoredPathMarkers identify frames outside the repository when no path prefixes are configured
+var vendoredPathMarkers = []string{
+	"node_modules/", "/gems/", "site-packages/", "dist-packages/", "/vendor/", "/usr/lib/", "/usr/local/lib/",
+	"/go/pkg/mod/", "/rustc/", "/.cargo/", "<internal", "node:internal", "runtime/", "java.base/",
+}
+
+// parseStackFrame parses a single backtrace line, returning false when it isn't a frame
+func parseStackFrame(line string) (StackFrame, bool) {
+	line = strings.TrimSpace(line)
+	for _, pattern := range stackFramePatterns {
+		match := pattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		frame := StackFrame{Raw: line}
+		for i, name := range pattern.SubexpNames() {
+			switch name {
+			case "file":
+				frame.File = match[i]
+			case "line":
+				frame.Line, _ = strconv.Atoi(match[i])
+			case "function":
+				frame.Function = match[i]
+			}
+		}
+		return frame, true
+	}
+	return StackFrame{}, false
+}
+
+// inRepoFrame reports whether a frame is in the repository, returning the file relative to the
+// matching prefix. Frames in known dependency and runtime paths never count, and without prefixes
+// any other frame does.
+func inRepoFrame(frame StackFrame, prefixes []string) (string, bool) {
+	for _, marker := range vendoredPathMarkers {
+		if strings.Contains(frame.File, marker) {
+			return "", false
+		}
+	}
+	if len(prefixes) == 0 {
+		return frame.File, true
+	}
+
+	for _, prefix := range prefixes {
+		if relative, ok := strings.CutPrefix(frame.File, prefix); ok {
+			return strings.TrimPrefix(relative, "/"), true
+		}
+	}
+	return "", false
+}
+
+// firstRepoFrame finds the first frame in the repository across the backtraces of a failure
+func firstRepoFrame(failures []buildkite.FailureExpanded, prefixes []string) *StackFrame {
+	for _, failure := range failures {
+		for _, line := range failure.Backtrace {
+			frame, ok := parseStackFrame(line)
+			if !ok {
+				continue
+			}
+			if file, ok := inRepoFrame(frame, prefixes); ok {
+				frame.File = file
+				return &frame
+			}
+		}
+	}
+	return nil
+}
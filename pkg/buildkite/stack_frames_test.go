@@ -0,0 +1,74 @@
+package buildkite
+
+import (
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStackFrame(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected StackFrame
+	}{
+		{
+			line:     `  File "/app/shop/cart.py", line 42, in add_item`,
+			expected: StackFrame{File: "/app/shop/cart.py", Line: 42, Function: "add_item"},
+		},
+		{
+			line:     "/app/app/models/cart.rb:17:in 'add_item'",
+			expected: StackFrame{File: "/app/app/models/cart.rb", Line: 17, Function: "add_item"},
+		},
+		{
+			line:     "    at addItem (/app/src/cart.js:12:5)",
+			expected: StackFrame{File: "/app/src/cart.js", Line: 12, Function: "addItem"},
+		},
+		{
+			line:     "    at /app/src/cart.js:12:5",
+			expected: StackFrame{File: "/app/src/cart.js", Line: 12},
+		},
+		{
+			line:     "\tat com.example.Cart.addItem(Cart.java:88)",
+			expected: StackFrame{File: "Cart.java", Line: 88, Function: "com.example.Cart.addItem"},
+		},
+		{
+			line:     "\t/workdir/pkg/cart/cart.go:31 +0x1d",
+			expected: StackFrame{File: "/workdir/pkg/cart/cart.go", Line: 31},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.line, func(t *testing.T) {
+			frame, ok := parseStackFrame(tc.line)
+			require.True(t, ok)
+			frame.Raw = ""
+			require.Equal(t, tc.expected, frame)
+		})
+	}
+
+	_, ok := parseStackFrame("expected 200, got 500")
+	require.False(t, ok)
+}
+
+func TestFirstRepoFrame(t *testing.T) {
+	assert := require.New(t)
+
+	failures := []buildkite.FailureExpanded{{
+		Backtrace: []string{
+			"/usr/local/bundle/gems/rspec-expectations-3.13.0/lib/rspec/expectations/fail_with.rb:35:in 'fail_with'",
+			"/workdir/spec/cart_spec.rb:12:in 'block (2 levels) in <top (required)>'",
+		},
+	}}
+
+	frame := firstRepoFrame(failures, nil)
+	assert.NotNil(frame)
+	assert.Equal("/workdir/spec/cart_spec.rb", frame.File)
+	assert.Equal(12, frame.Line)
+
+	frame = firstRepoFrame(failures, []string{"/workdir/"})
+	assert.NotNil(frame)
+	assert.Equal("spec/cart_spec.rb", frame.File)
+
+	assert.Nil(firstRepoFrame(failures, []string{"/app/"}))
+}
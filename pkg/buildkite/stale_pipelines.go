@@ -0,0 +1,220 @@
+package buildkite
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/policy"
+	"github.com/buildkite/buildkite-mcp-server/pkg/reltime"
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	maxStalePipelinePages    = 20
+	stalePipelineConcurrency = 4
+)
+
+type ListStalePipelinesArgs struct {
+	OrgSlug       string `json:"org_slug"`
+	NoBuildsSince string `json:"no_builds_since"`
+}
+
+// StalePipeline is a pipeline which hasn't built since the cutoff
+type StalePipeline struct {
+	Slug            string               `json:"slug"`
+	Name            string               `json:"name"`
+	Repository      string               `json:"repository"`
+	WebURL          string               `json:"web_url"`
+	Archived        bool                 `json:"archived"`
+	ArchivedAt      *buildkite.Timestamp `json:"archived_at,omitempty"`
+	CreatedAt       *buildkite.Timestamp `json:"created_at,omitempty"`
+	NeverBuilt      bool                 `json:"never_built,omitempty"`
+	LastBuildNumber int                  `json:"last_build_number,omitempty"`
+	LastBuildState  string               `json:"last_build_state,omitempty"`
+	LastBuildAt     *buildkite.Timestamp `json:"last_build_at,omitempty"`
+}
+
+type StalePipelines struct {
+	NoBuildsSince    time.Time       `json:"no_builds_since"`
+	PipelinesChecked int             `json:"pipelines_checked"`
+	Total            int             `json:"total"`
+	Pipelines        []StalePipeline `json:"pipelines"`
+	Notes            []string        `json:"notes,omitempty"`
+}
+
+// listAllPipelines returns the pipelines of an organization, and whether there were more than could
+// be listed
+func listAllPipelines(ctx context.Context, client PipelinesClient, org string) ([]buildkite.Pipeline, bool, error) {
+	options := &buildkite.PipelineListOptions{ListOptions: buildkite.ListOptions{PerPage: 100}}
+
+	var pipelines []buildkite.Pipeline
+	for range maxStalePipelinePages {
+		page, resp, err := client.List(ctx, org, options)
+		if err != nil {
+			return nil, false, err
+		}
+
+		pipelines = append(pipelines, page...)
+
+		if resp == nil || resp.NextPage == 0 || len(page) == 0 {
+			return pipelines, false, nil
+		}
+		options.Page = resp.NextPage
+	}
+
+	return pipelines, true, nil
+}
+
+// lastBuild returns the most recently created build of a pipeline, or nil when it has never built
+func lastBuild(ctx context.Context, client BuildsClient, org, pipeline string) (*buildkite.Build, error) {
+	builds, _, err := client.ListByPipeline(ctx, org, pipeline, &buildkite.BuildsListOptions{
+		ListOptions: buildkite.ListOptions{PerPage: 1},
+	})
+	if err != nil || len(builds) == 0 {
+		return nil, err
+	}
+	return &builds[0], nil
+}
+
+// stalePipeline reports whether a pipeline hasn't built since the cutoff. Pipelines which have never
+// built are only stale once they were created before the cutoff.
+func stalePipeline(pipeline buildkite.Pipeline, build *buildkite.Build, cutoff time.Time) (StalePipeline, bool) {
+	stale := StalePipeline{
+		Slug:       pipeline.Slug,
+		Name:       pipeline.Name,
+		Repository: pipeline.Repository,
+		WebURL:     pipeline.WebURL,
+		Archived:   pipeline.ArchivedAt != nil,
+		ArchivedAt: pipeline.ArchivedAt,
+		CreatedAt:  pipeline.CreatedAt,
+	}
+
+	if build == nil {
+		stale.NeverBuilt = true
+		return stale, pipeline.CreatedAt == nil || pipeline.CreatedAt.Before(cutoff)
+	}
+
+	stale.LastBuildNumber = build.Number
+	stale.LastBuildState = build.State
+	stale.LastBuildAt = build.CreatedAt
+	return stale, build.CreatedAt != nil && build.CreatedAt.Before(cutoff)
+}
+
+func ListStalePipelines(pipelinesClient PipelinesClient, buildsClient BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[ListStalePipelinesArgs], scopes []string) {
+	return mcp.NewTool("list_stale_pipelines",
+			mcp.WithDescription("Find the pipelines in an organization with no builds since a cutoff date, with their last build and whether they're already archived. Returns those never built first, then the longest idle, for cleanup conversations and choosing pipelines to archive"),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("no_builds_since",
+				mcp.Required(),
+				mcp.Description("The cutoff, as "+reltime.Formats+". Pipelines whose last build was created before it are stale"),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "List Stale Pipelines",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args ListStalePipelinesArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.ListStalePipelines")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.NoBuildsSince == "" {
+				return mcp.NewToolResultError("no_builds_since parameter is required"), nil
+			}
+			cutoff, err := parseRelativeTime("no_builds_since", args.NoBuildsSince, time.Now())
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("no_builds_since", args.NoBuildsSince),
+			)
+
+			pipelines, truncated, err := listAllPipelines(ctx, pipelinesClient, args.OrgSlug)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// pipelines the scope policy doesn't permit aren't checked or returned
+			scopePolicy := policy.ScopePolicyFromContext(ctx)
+			pipelines = slices.DeleteFunc(pipelines, func(p buildkite.Pipeline) bool {
+				return scopePolicy.CheckPipeline(args.OrgSlug, p.Slug) != nil
+			})
+
+			result := StalePipelines{
+				NoBuildsSince:    cutoff,
+				PipelinesChecked: len(pipelines),
+				Pipelines:        []StalePipeline{},
+			}
+			if truncated {
+				result.Notes = append(result.Notes, fmt.Sprintf("only the first %d pipelines were checked", len(pipelines)))
+			}
+
+			var mu sync.Mutex
+
+			g, gctx := errgroup.WithContext(ctx)
+			g.SetLimit(stalePipelineConcurrency)
+			for _, pipeline := range pipelines {
+				g.Go(func() error {
+					build, err := lastBuild(gctx, buildsClient, args.OrgSlug, pipeline.Slug)
+					if err != nil {
+						return fmt.Errorf("failed to get the last build of %s: %w", pipeline.Slug, err)
+					}
+
+					stale, ok := stalePipeline(pipeline, build, cutoff)
+					if !ok {
+						return nil
+					}
+
+					mu.Lock()
+					defer mu.Unlock()
+					result.Pipelines = append(result.Pipelines, stale)
+					return nil
+				})
+			}
+			if err := g.Wait(); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			slices.SortFunc(result.Pipelines, func(a, b StalePipeline) int {
+				return cmp.Or(
+					compareTimestamps(a.LastBuildAt, b.LastBuildAt),
+					cmp.Compare(a.Slug, b.Slug),
+				)
+			})
+			result.Total = len(result.Pipelines)
+
+			span.SetAttributes(
+				attribute.Int("pipelines_checked", result.PipelinesChecked),
+				attribute.Int("item_count", result.Total),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_pipelines", "read_builds"}
+}
+
+// compareTimestamps orders timestamps oldest first, with missing timestamps before them all
+func compareTimestamps(a, b *buildkite.Timestamp) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	return a.Compare(b.Time)
+}
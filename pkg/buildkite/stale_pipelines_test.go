@@ -0,0 +1,119 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/policy"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListStalePipelines(t *testing.T) {
+	assert := require.New(t)
+
+	at := func(date string) *buildkite.Timestamp {
+		t, _ := time.Parse(time.DateOnly, date)
+		return buildkite.NewTimestamp(t)
+	}
+
+	pipelines := &MockPipelinesClient{
+		ListFunc: func(ctx context.Context, org string, opt *buildkite.PipelineListOptions) ([]buildkite.Pipeline, *buildkite.Response, error) {
+			return []buildkite.Pipeline{
+				{Slug: "active", CreatedAt: at("2024-01-01")},
+				{Slug: "idle", CreatedAt: at("2024-01-01"), ArchivedAt: at("2025-03-01")},
+				{Slug: "older", CreatedAt: at("2024-01-01")},
+				{Slug: "empty", CreatedAt: at("2024-01-01")},
+				{Slug: "new", CreatedAt: at("2025-06-01")},
+			}, &buildkite.Response{}, nil
+		},
+	}
+	lastBuilds := map[string]buildkite.Build{
+		"active": {Number: 30, State: "passed", CreatedAt: at("2025-06-10")},
+		"idle":   {Number: 12, State: "failed", CreatedAt: at("2025-02-01")},
+		"older":  {Number: 4, State: "passed", CreatedAt: at("2024-06-01")},
+	}
+	builds := &MockBuildsClient{
+		ListByPipelineFunc: func(ctx context.Context, org string, pipeline string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error) {
+			assert.Equal(1, opt.PerPage)
+			if build, ok := lastBuilds[pipeline]; ok {
+				return []buildkite.Build{build}, &buildkite.Response{}, nil
+			}
+			return nil, &buildkite.Response{}, nil
+		},
+	}
+
+	_, handler, scopes := ListStalePipelines(pipelines, builds)
+	assert.Equal([]string{"read_pipelines", "read_builds"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, ListStalePipelinesArgs{
+		OrgSlug:       "org",
+		NoBuildsSince: "2025-05-01",
+	})
+	assert.NoError(err)
+	assert.False(result.IsError, getTextResult(t, result).Text)
+
+	var stale StalePipelines
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &stale))
+	assert.Equal(5, stale.PipelinesChecked)
+	assert.Equal(3, stale.Total)
+
+	var slugs []string
+	for _, pipeline := range stale.Pipelines {
+		slugs = append(slugs, pipeline.Slug)
+	}
+	assert.Equal([]string{"empty", "older", "idle"}, slugs)
+	assert.True(stale.Pipelines[0].NeverBuilt)
+	assert.True(stale.Pipelines[2].Archived)
+	assert.Equal(12, stale.Pipelines[2].LastBuildNumber)
+}
+
+func TestListStalePipelinesFiltersByScopePolicy(t *testing.T) {
+	assert := require.New(t)
+
+	pipelines := &MockPipelinesClient{
+		ListFunc: func(ctx context.Context, org string, opt *buildkite.PipelineListOptions) ([]buildkite.Pipeline, *buildkite.Response, error) {
+			return []buildkite.Pipeline{{Slug: "web"}, {Slug: "secret"}}, &buildkite.Response{}, nil
+		},
+	}
+	builds := &MockBuildsClient{
+		ListByPipelineFunc: func(ctx context.Context, org string, pipeline string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error) {
+			assert.Equal("web", pipeline)
+			return nil, &buildkite.Response{}, nil
+		},
+	}
+
+	scopePolicy, err := policy.NewScopePolicy(nil, []string{"web"})
+	assert.NoError(err)
+
+	_, handler, _ := ListStalePipelines(pipelines, builds)
+	result, err := handler(policy.WithScopePolicy(context.Background(), scopePolicy), mcp.CallToolRequest{}, ListStalePipelinesArgs{
+		OrgSlug:       "org",
+		NoBuildsSince: "2025-05-01",
+	})
+	assert.NoError(err)
+	assert.False(result.IsError, getTextResult(t, result).Text)
+
+	var stale StalePipelines
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &stale))
+	assert.Equal(1, stale.PipelinesChecked)
+	assert.Len(stale.Pipelines, 1)
+	assert.Equal("web", stale.Pipelines[0].Slug)
+}
+
+func TestListStalePipelinesInvalidCutoff(t *testing.T) {
+	assert := require.New(t)
+
+	_, handler, _ := ListStalePipelines(&MockPipelinesClient{}, &MockBuildsClient{})
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, ListStalePipelinesArgs{
+		OrgSlug:       "org",
+		NoBuildsSince: "last month",
+	})
+	assert.NoError(err)
+	assert.True(result.IsError)
+	assert.Contains(getTextResult(t, result).Text, `invalid no_builds_since "last month"`)
+}
@@ -0,0 +1,218 @@
+package buildkite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+	"gopkg.in/yaml.v3"
+)
+
+// errStepNotFound is returned when no step in the pipeline configuration matches the job
+var errStepNotFound = errors.New("no matching step found in the pipeline configuration")
+
+// stepKeyFields are the step attributes which hold the step key
+var stepKeyFields = []string{"key", "id", "identifier"}
+
+// stepLabelFields are the step attributes which hold the step label
+var stepLabelFields = []string{"label", "name"}
+
+type GetJobStepConfigArgs struct {
+	OrgSlug      string `json:"org_slug"`
+	PipelineSlug string `json:"pipeline_slug"`
+	BuildNumber  string `json:"build_number"`
+	JobID        string `json:"job_id"`
+}
+
+// JobStepConfig is the step definition from the pipeline configuration which produced a job
+type JobStepConfig struct {
+	JobID     string `json:"job_id"`
+	StepKey   string `json:"step_key,omitempty"`
+	Label     string `json:"label,omitempty"`
+	MatchedBy string `json:"matched_by,omitempty"`
+	YAML      string `json:"yaml,omitempty"`
+	Note      string `json:"note,omitempty"`
+}
+
+func GetJobStepConfig(buildsClient BuildsClient, pipelinesClient PipelinesClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[GetJobStepConfigArgs], scopes []string) {
+	return mcp.NewTool("get_job_step_config",
+			mcp.WithDescription("Find the step in the pipeline's YAML configuration which produced a job, matching on step key and then label, and return that step's YAML so fixes can be proposed against the actual step definition. Steps added at runtime by 'buildkite-agent pipeline upload' are not part of the stored configuration and cannot be found."),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("build_number",
+				mcp.Required(),
+			),
+			mcp.WithString("job_id",
+				mcp.Required(),
+				mcp.Description("The UUID of the job"),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Get Job Step Config",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args GetJobStepConfigArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.GetJobStepConfig")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if args.BuildNumber == "" {
+				return mcp.NewToolResultError("build_number parameter is required"), nil
+			}
+			if args.JobID == "" {
+				return mcp.NewToolResultError("job_id parameter is required"), nil
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+				attribute.String("build_number", args.BuildNumber),
+				attribute.String("job_id", args.JobID),
+			)
+
+			build, _, err := buildsClient.Get(ctx, args.OrgSlug, args.PipelineSlug, args.BuildNumber, &buildkite.BuildGetOptions{})
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			var job *buildkite.Job
+			for i := range build.Jobs {
+				if build.Jobs[i].ID == args.JobID {
+					job = &build.Jobs[i]
+					break
+				}
+			}
+			if job == nil {
+				return mcp.NewToolResultError(fmt.Sprintf("job %s not found in build %s", args.JobID, args.BuildNumber)), nil
+			}
+
+			pipeline, _, err := pipelinesClient.Get(ctx, args.OrgSlug, args.PipelineSlug)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			result := JobStepConfig{
+				JobID:   job.ID,
+				StepKey: job.StepKey,
+				Label:   jobLabel(*job),
+			}
+
+			step, matchedBy, err := findJobStep(pipeline.Configuration, *job)
+			switch {
+			case errors.Is(err, errStepNotFound):
+				result.Note = "no step in the pipeline configuration matches this job, it was most likely added by a dynamic 'buildkite-agent pipeline upload'"
+				return mcpTextResult(span, &result)
+			case err != nil:
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			data, err := yaml.Marshal(step)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to encode step: %v", err)), nil
+			}
+
+			result.MatchedBy = matchedBy
+			result.YAML = string(data)
+
+			span.SetAttributes(
+				attribute.String("matched_by", matchedBy),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_builds", "read_pipelines"}
+}
+
+// findJobStep locates the step in a pipeline YAML configuration which produced the job, preferring
+// a step key match over a label match. It returns the step node and which attribute matched.
+func findJobStep(configuration string, job buildkite.Job) (*yaml.Node, string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(configuration), &doc); err != nil {
+		return nil, "", fmt.Errorf("failed to parse pipeline configuration: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, "", errStepNotFound
+	}
+
+	steps := collectSteps(stepsNode(doc.Content[0]))
+
+	if job.StepKey != "" {
+		for _, step := range steps {
+			if matchesAny(step, stepKeyFields, job.StepKey) {
+				return step, "step_key", nil
+			}
+		}
+	}
+
+	for _, label := range []string{job.Label, job.Name} {
+		if label == "" {
+			continue
+		}
+		for _, step := range steps {
+			if matchesAny(step, stepLabelFields, label) {
+				return step, "label", nil
+			}
+		}
+	}
+
+	return nil, "", errStepNotFound
+}
+
+// stepsNode returns the sequence of steps from the root of a pipeline document, which is either
+// a mapping with a steps key or a bare list of steps
+func stepsNode(root *yaml.Node) *yaml.Node {
+	switch root.Kind {
+	case yaml.SequenceNode:
+		return root
+	case yaml.MappingNode:
+		return mappingValue(root, "steps")
+	}
+	return nil
+}
+
+// collectSteps flattens a sequence of steps, descending into group steps
+func collectSteps(seq *yaml.Node) []*yaml.Node {
+	if seq == nil || seq.Kind != yaml.SequenceNode {
+		return nil
+	}
+
+	var steps []*yaml.Node
+	for _, step := range seq.Content {
+		if step.Kind != yaml.MappingNode {
+			continue
+		}
+		steps = append(steps, step)
+		steps = append(steps, collectSteps(mappingValue(step, "steps"))...)
+	}
+	return steps
+}
+
+func matchesAny(step *yaml.Node, fields []string, value string) bool {
+	for _, field := range fields {
+		if node := mappingValue(step, field); node != nil && node.Kind == yaml.ScalarNode && node.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
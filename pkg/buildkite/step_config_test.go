@@ -0,0 +1,98 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+const testPipelineConfiguration = `env:
+  GO_VERSION: "1.24"
+steps:
+  - label: ":go: Lint"
+    command: make lint
+  - wait
+  - group: Tests
+    steps:
+      - label: ":go: Test"
+        key: test
+        command: make test
+        retry:
+          automatic: true
+`
+
+func TestFindJobStep(t *testing.T) {
+	tests := []struct {
+		name      string
+		job       buildkite.Job
+		matchedBy string
+		command   string
+		err       error
+	}{
+		{
+			name:      "step key inside group",
+			job:       buildkite.Job{StepKey: "test", Label: "renamed"},
+			matchedBy: "step_key",
+			command:   "make test",
+		},
+		{
+			name:      "label",
+			job:       buildkite.Job{Label: ":go: Lint"},
+			matchedBy: "label",
+			command:   "make lint",
+		},
+		{
+			name: "dynamic step",
+			job:  buildkite.Job{StepKey: "deploy", Label: "Deploy"},
+			err:  errStepNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, matchedBy, err := findJobStep(testPipelineConfiguration, tt.job)
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.matchedBy, matchedBy)
+			require.Equal(t, tt.command, mappingValue(step, "command").Value)
+		})
+	}
+}
+
+func TestGetJobStepConfig(t *testing.T) {
+	assert := require.New(t)
+
+	buildsClient := &MockBuildsClient{
+		GetFunc: func(ctx context.Context, org string, pipeline string, id string, opt *buildkite.BuildGetOptions) (buildkite.Build, *buildkite.Response, error) {
+			return buildkite.Build{Jobs: []buildkite.Job{{ID: "job-1", StepKey: "test", Label: ":go: Test"}}}, &buildkite.Response{}, nil
+		},
+	}
+	pipelinesClient := &MockPipelinesClient{
+		GetFunc: func(ctx context.Context, org string, pipeline string) (buildkite.Pipeline, *buildkite.Response, error) {
+			return buildkite.Pipeline{Configuration: testPipelineConfiguration}, &buildkite.Response{}, nil
+		},
+	}
+
+	_, handler, scopes := GetJobStepConfig(buildsClient, pipelinesClient)
+	assert.Equal([]string{"read_builds", "read_pipelines"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, GetJobStepConfigArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+		BuildNumber:  "1",
+		JobID:        "job-1",
+	})
+	assert.NoError(err)
+
+	var config JobStepConfig
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &config))
+	assert.Equal("step_key", config.MatchedBy)
+	assert.Equal("label: \":go: Test\"\nkey: test\ncommand: make test\nretry:\n    automatic: true\n", config.YAML)
+}
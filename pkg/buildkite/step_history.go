@@ -0,0 +1,168 @@
+package buildkite
+
+import (
+	"context"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	defaultStepHistoryLimit = 20
+	maxStepHistoryLimit     = 200
+)
+
+type GetStepHistoryArgs struct {
+	OrgSlug      string `json:"org_slug"`
+	PipelineSlug string `json:"pipeline_slug"`
+	StepKey      string `json:"step_key"`
+	Branch       string `json:"branch,omitempty"`
+	Limit        int    `json:"limit,omitempty"`
+}
+
+// StepRun is the outcome of a step in a single build
+type StepRun struct {
+	BuildNumber     int                  `json:"build_number"`
+	Branch          string               `json:"branch"`
+	Commit          string               `json:"commit"`
+	CreatedAt       *buildkite.Timestamp `json:"created_at,omitempty"`
+	JobID           string               `json:"job_id,omitempty"`
+	State           string               `json:"state"`
+	Outcome         string               `json:"outcome"`
+	ExitStatus      *int                 `json:"exit_status,omitempty"`
+	DurationSeconds float64              `json:"duration_seconds,omitempty"`
+	Attempts        int                  `json:"attempts"`
+}
+
+// StepHistory summarises the runs of a step across recent builds
+type StepHistory struct {
+	StepKey     string    `json:"step_key"`
+	Branch      string    `json:"branch,omitempty"`
+	Passed      int       `json:"passed"`
+	Failed      int       `json:"failed"`
+	FailureRate float64   `json:"failure_rate"`
+	Runs        []StepRun `json:"runs"`
+}
+
+// stepRun describes the final attempt of a step in a build, or returns false if the step did not run
+func stepRun(build buildkite.Build, step string) (StepRun, bool) {
+	jobs := stepJobs(build, step)
+	if len(jobs) == 0 {
+		return StepRun{}, false
+	}
+
+	run := StepRun{
+		BuildNumber: build.Number,
+		Branch:      build.Branch,
+		Commit:      build.Commit,
+		CreatedAt:   build.CreatedAt,
+		Outcome:     stepOutcome(build, step),
+		Attempts:    len(jobs),
+	}
+
+	for _, job := range jobs {
+		if job.Retried {
+			continue
+		}
+		run.JobID = job.ID
+		run.State = job.State
+		run.ExitStatus = job.ExitStatus
+		if job.StartedAt != nil && job.FinishedAt != nil {
+			run.DurationSeconds = job.FinishedAt.Sub(job.StartedAt.Time).Seconds()
+		}
+	}
+
+	return run, true
+}
+
+func GetStepHistory(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[GetStepHistoryArgs], scopes []string) {
+	return mcp.NewTool("get_step_history",
+			mcp.WithDescription("Get the history of a single step across the most recent finished builds of a pipeline: its state, outcome, exit status, duration, and number of attempts in each build, plus pass/fail counts. Use this to show when a step started failing or how often it fails intermittently."),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("step_key",
+				mcp.Required(),
+				mcp.Description("Step key or label of the step"),
+			),
+			mcp.WithString("branch",
+				mcp.Description("Only include builds on this branch"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Number of recent builds to include (default 20, max 200)"),
+				mcp.Min(1),
+				mcp.Max(maxStepHistoryLimit),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Get Step History",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args GetStepHistoryArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.GetStepHistory")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if args.StepKey == "" {
+				return mcp.NewToolResultError("step_key parameter is required"), nil
+			}
+			if args.Limit <= 0 {
+				args.Limit = defaultStepHistoryLimit
+			}
+			args.Limit = min(args.Limit, maxStepHistoryLimit)
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+				attribute.String("step_key", args.StepKey),
+				attribute.String("branch", args.Branch),
+				attribute.Int("limit", args.Limit),
+			)
+
+			builds, err := listRecentBuilds(ctx, client, args.OrgSlug, args.PipelineSlug, args.Branch, args.Limit)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			history := StepHistory{
+				StepKey: args.StepKey,
+				Branch:  args.Branch,
+				Runs:    []StepRun{},
+			}
+
+			for _, build := range builds {
+				run, ok := stepRun(build, args.StepKey)
+				if !ok {
+					continue
+				}
+
+				switch run.Outcome {
+				case StepOutcomePassed:
+					history.Passed++
+				case StepOutcomeFailed:
+					history.Failed++
+				}
+				history.Runs = append(history.Runs, run)
+			}
+
+			if total := history.Passed + history.Failed; total > 0 {
+				history.FailureRate = float64(history.Failed) / float64(total)
+			}
+
+			span.SetAttributes(
+				attribute.Int("item_count", len(history.Runs)),
+			)
+
+			return mcpTextResult(span, &history)
+		}, []string{"read_builds"}
+}
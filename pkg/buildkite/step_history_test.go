@@ -0,0 +1,56 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetStepHistory(t *testing.T) {
+	assert := require.New(t)
+
+	started := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	client := &MockBuildsClient{
+		ListByPipelineFunc: func(ctx context.Context, org string, pipeline string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error) {
+			assert.Equal(3, opt.PerPage)
+			return []buildkite.Build{
+				{Number: 3, Jobs: []buildkite.Job{
+					{ID: "job-3a", Type: "script", StepKey: "test", State: "failed", Retried: true},
+					{ID: "job-3b", Type: "script", StepKey: "test", State: "failed", ExitStatus: intPtr(1),
+						StartedAt:  &buildkite.Timestamp{Time: started},
+						FinishedAt: &buildkite.Timestamp{Time: started.Add(90 * time.Second)}},
+				}},
+				{Number: 2, Jobs: []buildkite.Job{{ID: "job-2", Type: "script", StepKey: "lint", State: "passed"}}},
+				{Number: 1, Jobs: []buildkite.Job{{ID: "job-1", Type: "script", StepKey: "test", State: "passed", ExitStatus: intPtr(0)}}},
+			}, &buildkite.Response{}, nil
+		},
+	}
+
+	_, handler, scopes := GetStepHistory(client)
+	assert.Equal([]string{"read_builds"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, GetStepHistoryArgs{
+		OrgSlug:      "org",
+		PipelineSlug: "pipeline",
+		StepKey:      "test",
+		Limit:        3,
+	})
+	assert.NoError(err)
+
+	var history StepHistory
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &history))
+	assert.Equal(1, history.Passed)
+	assert.Equal(1, history.Failed)
+	assert.Equal(0.5, history.FailureRate)
+	assert.Len(history.Runs, 2)
+	assert.Equal("job-3b", history.Runs[0].JobID)
+	assert.Equal(2, history.Runs[0].Attempts)
+	assert.Equal(90.0, history.Runs[0].DurationSeconds)
+	assert.Equal(StepOutcomePassed, history.Runs[1].Outcome)
+}
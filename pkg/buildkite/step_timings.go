@@ -0,0 +1,242 @@
+package buildkite
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	defaultStepTimingsWindow = 30
+	maxStepTimingsWindow     = 200
+	defaultStepTimingsLimit  = 10
+	// minTrendSamples is how many jobs each half of the window needs for a step to have a trend
+	minTrendSamples = 2
+	// stableTrendPercent is how much the mean duration can change either way while counting as stable
+	stableTrendPercent = 10
+
+	StepTimingsSortTotal = "total"
+	StepTimingsSortMean  = "mean"
+	StepTimingsSortP95   = "p95"
+
+	TrendSlower = "slower"
+	TrendFaster = "faster"
+	TrendStable = "stable"
+)
+
+type GetStepTimingsArgs struct {
+	OrgSlug      string `json:"org_slug"`
+	PipelineSlug string `json:"pipeline_slug"`
+	Branch       string `json:"branch,omitempty"`
+	Window       int    `json:"window,omitempty"`
+	Limit        int    `json:"limit,omitempty"`
+	SortBy       string `json:"sort_by,omitempty"`
+}
+
+// StepTiming is how long the jobs of a step ran across the builds analyzed
+type StepTiming struct {
+	Step  string `json:"step"`
+	Label string `json:"label"`
+	DurationStats
+	// TotalSeconds is the time the step's jobs ran for in all, the step's share of the CI bill
+	TotalSeconds float64 `json:"total_seconds"`
+	// Trend compares the mean duration in the newer half of the builds with the older half, when
+	// both halves ran the step enough times to tell
+	Trend        string   `json:"trend,omitempty"`
+	TrendPercent *float64 `json:"trend_percent,omitempty"`
+}
+
+type StepTimings struct {
+	BuildsAnalyzed int          `json:"builds_analyzed"`
+	JobsAnalyzed   int          `json:"jobs_analyzed"`
+	TotalSteps     int          `json:"total_steps"`
+	SortBy         string       `json:"sort_by"`
+	Steps          []StepTiming `json:"steps"`
+}
+
+// stepTimings aggregates the run time of the jobs of newest-first builds by step, comparing the
+// newer half of the builds with the older half for the trend
+func stepTimings(builds []buildkite.Build) ([]StepTiming, int) {
+	type samples struct {
+		label        string
+		all          []float64
+		newer, older []float64
+	}
+	steps := map[string]*samples{}
+	jobs := 0
+
+	for i, build := range builds {
+		newer := i < len(builds)/2
+		for _, job := range build.Jobs {
+			if job.Type != "" && job.Type != "script" {
+				continue
+			}
+			if job.StartedAt == nil || job.FinishedAt == nil {
+				continue
+			}
+			id := stepIdentity(job)
+			if id == "" {
+				continue
+			}
+			jobs++
+
+			s, ok := steps[id]
+			if !ok {
+				s = &samples{label: jobLabel(job)}
+				steps[id] = s
+			}
+
+			seconds := max(job.FinishedAt.Sub(job.StartedAt.Time), 0).Seconds()
+			s.all = append(s.all, seconds)
+			if newer {
+				s.newer = append(s.newer, seconds)
+			} else {
+				s.older = append(s.older, seconds)
+			}
+		}
+	}
+
+	timings := make([]StepTiming, 0, len(steps))
+	for id, s := range steps {
+		timing := StepTiming{Step: id, Label: s.label, DurationStats: durationStats(s.all)}
+		var total float64
+		for _, seconds := range s.all {
+			total += seconds
+		}
+		timing.TotalSeconds = math.Round(total*10) / 10
+
+		if len(s.newer) >= minTrendSamples && len(s.older) >= minTrendSamples {
+			older := durationStats(s.older).MeanSeconds
+			if older > 0 {
+				change := math.Round((durationStats(s.newer).MeanSeconds-older)/older*1000) / 10
+				timing.TrendPercent = &change
+				switch {
+				case change >= stableTrendPercent:
+					timing.Trend = TrendSlower
+				case change <= -stableTrendPercent:
+					timing.Trend = TrendFaster
+				default:
+					timing.Trend = TrendStable
+				}
+			}
+		}
+
+		timings = append(timings, timing)
+	}
+
+	return timings, jobs
+}
+
+// sortStepTimings orders the steps slowest first by the statistic
+func sortStepTimings(timings []StepTiming, sortBy string) {
+	value := func(t StepTiming) float64 {
+		switch sortBy {
+		case StepTimingsSortMean:
+			return t.MeanSeconds
+		case StepTimingsSortP95:
+			return t.P95Seconds
+		default:
+			return t.TotalSeconds
+		}
+	}
+	slices.SortFunc(timings, func(a, b StepTiming) int {
+		return cmp.Or(
+			cmp.Compare(value(b), value(a)),
+			cmp.Compare(a.Step, b.Step),
+		)
+	})
+}
+
+func GetStepTimings(client BuildsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[GetStepTimingsArgs], scopes []string) {
+	return mcp.NewTool("get_step_timings",
+			mcp.WithDescription("Aggregate how long the jobs of each step of a pipeline ran across its recent finished builds: mean, median, 95th percentile and total run time, and whether the step got slower or faster between the older and newer half of the builds. Steps are ranked slowest first, by total run time unless sort_by says otherwise, answering which steps cost the most CI time. Builds are aggregated on the server so only the statistics are returned"),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("branch",
+				mcp.Description("Only analyze builds on this branch"),
+			),
+			mcp.WithNumber("window",
+				mcp.Description(fmt.Sprintf("Number of recent finished builds to analyze (default %d, max %d)", defaultStepTimingsWindow, maxStepTimingsWindow)),
+				mcp.Min(1),
+				mcp.Max(maxStepTimingsWindow),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description(fmt.Sprintf("Number of the slowest steps to return (default %d)", defaultStepTimingsLimit)),
+				mcp.Min(1),
+			),
+			mcp.WithString("sort_by",
+				mcp.Description("Rank steps by their 'total' run time (default), 'mean' run time, or 'p95' run time"),
+				mcp.Enum(StepTimingsSortTotal, StepTimingsSortMean, StepTimingsSortP95),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Get Step Timings",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args GetStepTimingsArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.GetStepTimings")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if args.Window <= 0 {
+				args.Window = defaultStepTimingsWindow
+			}
+			args.Window = min(args.Window, maxStepTimingsWindow)
+			if args.Limit <= 0 {
+				args.Limit = defaultStepTimingsLimit
+			}
+			if args.SortBy == "" {
+				args.SortBy = StepTimingsSortTotal
+			}
+			if !slices.Contains([]string{StepTimingsSortTotal, StepTimingsSortMean, StepTimingsSortP95}, args.SortBy) {
+				return mcp.NewToolResultError("sort_by must be 'total', 'mean' or 'p95'"), nil
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+				attribute.String("branch", args.Branch),
+				attribute.Int("window", args.Window),
+				attribute.String("sort_by", args.SortBy),
+			)
+
+			builds, err := listRecentBuilds(ctx, client, args.OrgSlug, args.PipelineSlug, args.Branch, args.Window)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			timings, jobs := stepTimings(builds)
+			sortStepTimings(timings, args.SortBy)
+
+			result := StepTimings{
+				BuildsAnalyzed: len(builds),
+				JobsAnalyzed:   jobs,
+				TotalSteps:     len(timings),
+				SortBy:         args.SortBy,
+				Steps:          timings[:min(len(timings), args.Limit)],
+			}
+
+			span.SetAttributes(
+				attribute.Int("jobs_analyzed", result.JobsAnalyzed),
+				attribute.Int("item_count", len(result.Steps)),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_builds"}
+}
@@ -0,0 +1,104 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetStepTimings(t *testing.T) {
+	assert := require.New(t)
+
+	base := time.Now().Add(-24 * time.Hour)
+	job := func(key string, duration time.Duration) buildkite.Job {
+		return buildkite.Job{
+			Type:       "script",
+			StepKey:    key,
+			Label:      ":go: " + key,
+			StartedAt:  &buildkite.Timestamp{Time: base},
+			FinishedAt: &buildkite.Timestamp{Time: base.Add(duration)},
+		}
+	}
+
+	// newest first: tests got twice as slow, lint stayed the same
+	var builds []buildkite.Build
+	for i := range 4 {
+		tests := 100 * time.Second
+		if i < 2 {
+			tests = 200 * time.Second
+		}
+		builds = append(builds, buildkite.Build{
+			Number: 4 - i,
+			State:  "passed",
+			Jobs: []buildkite.Job{
+				job("test", tests),
+				job("lint", 10*time.Second),
+				{Type: "waiter"},
+				{Type: "script", StepKey: "deploy", StartedAt: &buildkite.Timestamp{Time: base}},
+			},
+		})
+	}
+
+	var capturedOptions *buildkite.BuildsListOptions
+	client := &MockBuildsClient{
+		ListByPipelineFunc: func(ctx context.Context, org, pipeline string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error) {
+			capturedOptions = opt
+			return builds, &buildkite.Response{}, nil
+		},
+	}
+
+	tool, handler, scopes := GetStepTimings(client)
+	assert.Equal("get_step_timings", tool.Name)
+	assert.True(*tool.Annotations.ReadOnlyHint)
+	assert.Equal([]string{"read_builds"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, GetStepTimingsArgs{OrgSlug: "org", PipelineSlug: "pipeline", Branch: "main"})
+	assert.NoError(err)
+	assert.Equal([]string{"main"}, capturedOptions.Branch)
+	assert.Equal(finishedBuildStates, capturedOptions.State)
+
+	var timings StepTimings
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &timings))
+	assert.Equal(4, timings.BuildsAnalyzed)
+	assert.Equal(8, timings.JobsAnalyzed)
+	assert.Equal(2, timings.TotalSteps)
+	assert.Len(timings.Steps, 2)
+
+	tests := timings.Steps[0]
+	assert.Equal("test", tests.Step)
+	assert.Equal(":go: test", tests.Label)
+	assert.Equal(4, tests.Count)
+	assert.Equal(150.0, tests.MeanSeconds)
+	assert.Equal(200.0, tests.P95Seconds)
+	assert.Equal(600.0, tests.TotalSeconds)
+	assert.Equal(TrendSlower, tests.Trend)
+	assert.Equal(100.0, *tests.TrendPercent)
+
+	lint := timings.Steps[1]
+	assert.Equal("lint", lint.Step)
+	assert.Equal(TrendStable, lint.Trend)
+	assert.Equal(0.0, *lint.TrendPercent)
+
+	// limited to the slowest step
+	result, err = handler(context.Background(), mcp.CallToolRequest{}, GetStepTimingsArgs{OrgSlug: "org", PipelineSlug: "pipeline", Limit: 1, SortBy: StepTimingsSortP95})
+	assert.NoError(err)
+	timings = StepTimings{}
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &timings))
+	assert.Equal(2, timings.TotalSteps)
+	assert.Len(timings.Steps, 1)
+	assert.Equal("test", timings.Steps[0].Step)
+}
+
+func TestGetStepTimingsValidatesSortBy(t *testing.T) {
+	assert := require.New(t)
+
+	_, handler, _ := GetStepTimings(&MockBuildsClient{})
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, GetStepTimingsArgs{OrgSlug: "org", PipelineSlug: "pipeline", SortBy: "median"})
+	assert.NoError(err)
+	assert.True(result.IsError)
+}
@@ -0,0 +1,233 @@
+package buildkite
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// FlakyTestsClient describes the subset of the Buildkite client we need for flaky tests.
+type FlakyTestsClient interface {
+	List(ctx context.Context, org, slug string, opt *buildkite.FlakyTestsListOptions) ([]buildkite.FlakyTest, *buildkite.Response, error)
+}
+
+const (
+	suiteHealthPeriod = 7 * 24 * time.Hour
+	// bounds the pages read when counting runs and flaky tests for busy suites
+	maxSuiteHealthRuns       = 500
+	maxSuiteHealthFlakyTests = 500
+	maxSuiteHealthTopTests   = 5
+)
+
+type GetSuiteHealthArgs struct {
+	OrgSlug       string `json:"org_slug"`
+	TestSuiteSlug string `json:"test_suite_slug"`
+}
+
+// SuiteRunSummary is the latest finished run of a suite
+type SuiteRunSummary struct {
+	ID        string               `json:"id"`
+	State     string               `json:"state"`
+	Result    string               `json:"result"`
+	Branch    string               `json:"branch,omitempty"`
+	CommitSHA string               `json:"commit_sha,omitempty"`
+	CreatedAt *buildkite.Timestamp `json:"created_at,omitempty"`
+	WebURL    string               `json:"web_url,omitempty"`
+	Failures  int                  `json:"failures"`
+}
+
+// SuiteHealthTest is a test highlighted in a suite health summary
+type SuiteHealthTest struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	Location  string  `json:"location,omitempty"`
+	Instances int     `json:"instances,omitempty"`
+	Duration  float64 `json:"duration,omitempty"`
+}
+
+type SuiteHealth struct {
+	LatestRun          *SuiteRunSummary  `json:"latest_run,omitempty"`
+	Runs7d             int               `json:"runs_7d"`
+	FailedRuns7d       int               `json:"failed_runs_7d"`
+	PassRate7d         float64           `json:"pass_rate_7d"`
+	FlakyTestCount     int               `json:"flaky_test_count"`
+	TopFlakyTests      []SuiteHealthTest `json:"top_flaky_tests"`
+	SlowestFailedTests []SuiteHealthTest `json:"slowest_failed_tests"`
+	Notes              []string          `json:"notes,omitempty"`
+}
+
+// listTestRunsSince returns the runs of a suite created after since, newest first
+func listTestRunsSince(ctx context.Context, client TestRunsClient, org, suite string, since time.Time) ([]buildkite.TestRun, bool, error) {
+	options := &buildkite.TestRunsListOptions{
+		ListOptions: buildkite.ListOptions{PerPage: 100},
+	}
+
+	var runs []buildkite.TestRun
+	for {
+		page, resp, err := client.List(ctx, org, suite, options)
+		if err != nil {
+			return nil, false, err
+		}
+
+		for _, run := range page {
+			if run.CreatedAt != nil && run.CreatedAt.Before(since) {
+				return runs, false, nil
+			}
+			runs = append(runs, run)
+			if len(runs) == maxSuiteHealthRuns {
+				return runs, true, nil
+			}
+		}
+
+		if resp == nil || resp.NextPage == 0 || len(page) == 0 {
+			return runs, false, nil
+		}
+		options.Page = resp.NextPage
+	}
+}
+
+// listFlakyTests returns the flaky tests of a suite, up to maxSuiteHealthFlakyTests
+func listFlakyTests(ctx context.Context, client FlakyTestsClient, org, suite string) ([]buildkite.FlakyTest, error) {
+	options := &buildkite.FlakyTestsListOptions{
+		ListOptions: buildkite.ListOptions{PerPage: 100},
+	}
+
+	var tests []buildkite.FlakyTest
+	for len(tests) < maxSuiteHealthFlakyTests {
+		page, resp, err := client.List(ctx, org, suite, options)
+		if err != nil {
+			return nil, err
+		}
+
+		tests = append(tests, page...)
+
+		if resp == nil || resp.NextPage == 0 || len(page) == 0 {
+			break
+		}
+		options.Page = resp.NextPage
+	}
+
+	return tests[:min(len(tests), maxSuiteHealthFlakyTests)], nil
+}
+
+func GetSuiteHealth(testRunsClient TestRunsClient, flakyTestsClient FlakyTestsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[GetSuiteHealthArgs], scopes []string) {
+	return mcp.NewTool("get_suite_health",
+			mcp.WithDescription("Summarize the health of a Buildkite Test Engine suite in one response: the latest finished run and its failure count, the pass rate of runs over the last 7 days, the number of flaky tests with the most frequent ones, and the slowest failing tests of the latest run. Answers 'how is our test suite doing?'."),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("test_suite_slug",
+				mcp.Required(),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Get Suite Health",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args GetSuiteHealthArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.GetSuiteHealth")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.TestSuiteSlug == "" {
+				return mcp.NewToolResultError("test_suite_slug parameter is required"), nil
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("test_suite_slug", args.TestSuiteSlug),
+			)
+
+			result := SuiteHealth{
+				TopFlakyTests:      []SuiteHealthTest{},
+				SlowestFailedTests: []SuiteHealthTest{},
+			}
+
+			runs, truncated, err := listTestRunsSince(ctx, testRunsClient, args.OrgSlug, args.TestSuiteSlug, time.Now().Add(-suiteHealthPeriod))
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if truncated {
+				result.Notes = append(result.Notes, "the 7 day pass rate only covers the most recent 500 runs")
+			}
+
+			var latest *buildkite.TestRun
+			for i, run := range runs {
+				if run.State != "finished" {
+					continue
+				}
+				if latest == nil {
+					latest = &runs[i]
+				}
+				result.Runs7d++
+				if run.Result == "failed" {
+					result.FailedRuns7d++
+				}
+			}
+			if result.Runs7d > 0 {
+				result.PassRate7d = float64(result.Runs7d-result.FailedRuns7d) / float64(result.Runs7d)
+			}
+
+			if latest == nil {
+				result.Notes = append(result.Notes, "no finished runs in the last 7 days")
+			} else {
+				executions, _, err := testRunsClient.GetFailedExecutions(ctx, args.OrgSlug, args.TestSuiteSlug, latest.ID, &buildkite.FailedExecutionsOptions{})
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+
+				result.LatestRun = &SuiteRunSummary{
+					ID:        latest.ID,
+					State:     latest.State,
+					Result:    latest.Result,
+					Branch:    latest.Branch,
+					CommitSHA: latest.CommitSHA,
+					CreatedAt: latest.CreatedAt,
+					WebURL:    latest.WebURL,
+					Failures:  len(executions),
+				}
+
+				// the API only reports durations for failed executions
+				slices.SortFunc(executions, func(a, b buildkite.FailedExecution) int { return cmp.Compare(b.Duration, a.Duration) })
+				for _, execution := range executions[:min(len(executions), maxSuiteHealthTopTests)] {
+					result.SlowestFailedTests = append(result.SlowestFailedTests, SuiteHealthTest{
+						ID:       execution.TestID,
+						Name:     execution.TestName,
+						Location: execution.Location,
+						Duration: execution.Duration,
+					})
+				}
+			}
+
+			flakyTests, err := listFlakyTests(ctx, flakyTestsClient, args.OrgSlug, args.TestSuiteSlug)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			result.FlakyTestCount = len(flakyTests)
+
+			slices.SortFunc(flakyTests, func(a, b buildkite.FlakyTest) int { return cmp.Compare(b.Instances, a.Instances) })
+			for _, test := range flakyTests[:min(len(flakyTests), maxSuiteHealthTopTests)] {
+				result.TopFlakyTests = append(result.TopFlakyTests, SuiteHealthTest{
+					ID:        test.ID,
+					Name:      test.Name,
+					Location:  test.Location,
+					Instances: test.Instances,
+				})
+			}
+
+			span.SetAttributes(
+				attribute.Int("runs_7d", result.Runs7d),
+				attribute.Int("flaky_test_count", result.FlakyTestCount),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_suites"}
+}
@@ -0,0 +1,80 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+type MockFlakyTestsClient struct {
+	ListFunc func(ctx context.Context, org, slug string, opt *buildkite.FlakyTestsListOptions) ([]buildkite.FlakyTest, *buildkite.Response, error)
+}
+
+func (m *MockFlakyTestsClient) List(ctx context.Context, org, slug string, opt *buildkite.FlakyTestsListOptions) ([]buildkite.FlakyTest, *buildkite.Response, error) {
+	if m.ListFunc != nil {
+		return m.ListFunc(ctx, org, slug, opt)
+	}
+	return nil, nil, nil
+}
+
+var _ FlakyTestsClient = (*MockFlakyTestsClient)(nil)
+
+func TestGetSuiteHealth(t *testing.T) {
+	assert := require.New(t)
+
+	ago := func(d time.Duration) *buildkite.Timestamp {
+		return &buildkite.Timestamp{Time: time.Now().Add(-d)}
+	}
+
+	testRunsClient := &MockTestRunsClient{
+		ListFunc: func(ctx context.Context, org, slug string, opt *buildkite.TestRunsListOptions) ([]buildkite.TestRun, *buildkite.Response, error) {
+			return []buildkite.TestRun{
+				{ID: "run-5", State: "running", CreatedAt: ago(time.Minute)},
+				{ID: "run-4", State: "finished", Result: "failed", CreatedAt: ago(time.Hour)},
+				{ID: "run-3", State: "finished", Result: "passed", CreatedAt: ago(2 * time.Hour)},
+				{ID: "run-2", State: "finished", Result: "passed", CreatedAt: ago(48 * time.Hour)},
+				{ID: "run-1", State: "finished", Result: "failed", CreatedAt: ago(10 * 24 * time.Hour)},
+			}, &buildkite.Response{}, nil
+		},
+		GetFailedExecutionsFunc: func(ctx context.Context, org, slug, runID string, opt *buildkite.FailedExecutionsOptions) ([]buildkite.FailedExecution, *buildkite.Response, error) {
+			assert.Equal("run-4", runID)
+			return []buildkite.FailedExecution{
+				{TestID: "fast", TestName: "fast", Duration: 0.1},
+				{TestID: "slow", TestName: "slow", Duration: 12},
+			}, &buildkite.Response{}, nil
+		},
+	}
+	flakyTestsClient := &MockFlakyTestsClient{
+		ListFunc: func(ctx context.Context, org, slug string, opt *buildkite.FlakyTestsListOptions) ([]buildkite.FlakyTest, *buildkite.Response, error) {
+			return []buildkite.FlakyTest{
+				{ID: "f1", Name: "rarely", Instances: 1},
+				{ID: "f2", Name: "often", Instances: 9},
+			}, &buildkite.Response{}, nil
+		},
+	}
+
+	_, handler, scopes := GetSuiteHealth(testRunsClient, flakyTestsClient)
+	assert.Equal([]string{"read_suites"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, GetSuiteHealthArgs{
+		OrgSlug:       "org",
+		TestSuiteSlug: "suite",
+	})
+	assert.NoError(err)
+
+	var health SuiteHealth
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &health))
+	assert.Equal("run-4", health.LatestRun.ID)
+	assert.Equal(2, health.LatestRun.Failures)
+	assert.Equal(3, health.Runs7d)
+	assert.Equal(1, health.FailedRuns7d)
+	assert.InDelta(0.667, health.PassRate7d, 0.001)
+	assert.Equal(2, health.FlakyTestCount)
+	assert.Equal("often", health.TopFlakyTests[0].Name)
+	assert.Equal("slow", health.SlowestFailedTests[0].Name)
+}
@@ -0,0 +1,245 @@
+package buildkite
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// maxTerraformPlanBytes bounds the plan artifacts read, as JSON plans of large estates get big
+	maxTerraformPlanBytes = 50 * 1024 * 1024
+	// maxTerraformPlanResources bounds the resource addresses listed for each action
+	maxTerraformPlanResources = 100
+
+	TerraformPlanFormatJSON = "json"
+	TerraformPlanFormatText = "text"
+)
+
+var (
+	ansiEscapePattern = regexp.MustCompile(`\x1b\[[0-9;]*[a-zA-Z]`)
+	// matches resource headers such as "  # aws_instance.web will be created"
+	terraformResourcePattern = regexp.MustCompile(`^\s*# (\S+) (?:\(\S+\) )?(will be created|will be updated in-place|will be destroyed|must be replaced|will be read during apply)`)
+	terraformSummaryPattern  = regexp.MustCompile(`Plan: (\d+) to add, (\d+) to change, (\d+) to destroy`)
+	terraformNoChangePattern = regexp.MustCompile(`No changes\.|Your infrastructure matches the configuration`)
+)
+
+// errArtifactTooLarge stops downloading an artifact over the size being read
+var errArtifactTooLarge = errors.New("artifact is too large")
+
+// limitedBuffer is a buffer which refuses writes past its limit
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > b.limit {
+		return 0, errArtifactTooLarge
+	}
+	return b.Buffer.Write(p)
+}
+
+type SummarizeTerraformPlanArgs struct {
+	URL string `json:"url"`
+}
+
+// TerraformPlanSummary is the resources a terraform plan would add, change, and destroy
+type TerraformPlanSummary struct {
+	Format    string   `json:"format"`
+	Add       int      `json:"add"`
+	Change    int      `json:"change"`
+	Destroy   int      `json:"destroy"`
+	Replace   int      `json:"replace"`
+	NoOp      bool     `json:"no_changes,omitempty"`
+	Created   []string `json:"created"`
+	Updated   []string `json:"updated"`
+	Destroyed []string `json:"destroyed"`
+	Replaced  []string `json:"replaced"`
+	Notes     []string `json:"notes,omitempty"`
+}
+
+// terraformJSONPlan is the part of `terraform show -json` output the summary needs
+type terraformJSONPlan struct {
+	FormatVersion   string `json:"format_version"`
+	ResourceChanges []struct {
+		Address string `json:"address"`
+		Change  struct {
+			Actions []string `json:"actions"`
+		} `json:"change"`
+	} `json:"resource_changes"`
+}
+
+func newTerraformPlanSummary(format string) TerraformPlanSummary {
+	return TerraformPlanSummary{
+		Format:    format,
+		Created:   []string{},
+		Updated:   []string{},
+		Destroyed: []string{},
+		Replaced:  []string{},
+	}
+}
+
+// summarizeJSONPlan summarizes a plan in the JSON format. Replacements count as both an add and a
+// destroy, as terraform's own summary line does.
+func summarizeJSONPlan(plan terraformJSONPlan) TerraformPlanSummary {
+	summary := newTerraformPlanSummary(TerraformPlanFormatJSON)
+	for _, change := range plan.ResourceChanges {
+		actions := change.Change.Actions
+		switch {
+		case slices.Contains(actions, "create") && slices.Contains(actions, "delete"):
+			summary.Add++
+			summary.Destroy++
+			summary.Replace++
+			summary.Replaced = append(summary.Replaced, change.Address)
+		case slices.Contains(actions, "create"):
+			summary.Add++
+			summary.Created = append(summary.Created, change.Address)
+		case slices.Contains(actions, "update"):
+			summary.Change++
+			summary.Updated = append(summary.Updated, change.Address)
+		case slices.Contains(actions, "delete"):
+			summary.Destroy++
+			summary.Destroyed = append(summary.Destroyed, change.Address)
+		}
+	}
+	summary.NoOp = summary.Add+summary.Change+summary.Destroy == 0
+	return summary
+}
+
+// summarizeTextPlan summarizes the human readable output of terraform plan, preferring the counts
+// in its summary line over those of the resource headers found
+func summarizeTextPlan(data []byte) TerraformPlanSummary {
+	summary := newTerraformPlanSummary(TerraformPlanFormatText)
+	found := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := ansiEscapePattern.ReplaceAllString(scanner.Text(), "")
+
+		if match := terraformResourcePattern.FindStringSubmatch(line); match != nil {
+			switch match[2] {
+			case "will be created":
+				summary.Created = append(summary.Created, match[1])
+			case "will be updated in-place":
+				summary.Updated = append(summary.Updated, match[1])
+			case "will be destroyed":
+				summary.Destroyed = append(summary.Destroyed, match[1])
+			case "must be replaced":
+				summary.Replaced = append(summary.Replaced, match[1])
+			}
+			continue
+		}
+
+		if match := terraformSummaryPattern.FindStringSubmatch(line); match != nil {
+			found = true
+			summary.Add, _ = strconv.Atoi(match[1])
+			summary.Change, _ = strconv.Atoi(match[2])
+			summary.Destroy, _ = strconv.Atoi(match[3])
+			continue
+		}
+
+		if terraformNoChangePattern.MatchString(line) {
+			found = true
+			summary.NoOp = true
+		}
+	}
+
+	summary.Replace = len(summary.Replaced)
+	if !found {
+		summary.Add = len(summary.Created) + summary.Replace
+		summary.Change = len(summary.Updated)
+		summary.Destroy = len(summary.Destroyed) + summary.Replace
+		summary.Notes = append(summary.Notes, "no plan summary line found, counts are from the resources listed")
+	}
+	return summary
+}
+
+// summarizeTerraformPlan recognises a JSON or text terraform plan and summarizes it
+func summarizeTerraformPlan(data []byte) (TerraformPlanSummary, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var plan terraformJSONPlan
+		if err := json.Unmarshal(trimmed, &plan); err != nil {
+			return TerraformPlanSummary{}, fmt.Errorf("failed to parse JSON plan: %w", err)
+		}
+		if plan.FormatVersion == "" {
+			return TerraformPlanSummary{}, errors.New("artifact is JSON but not a terraform plan, expected the output of 'terraform show -json'")
+		}
+		return summarizeJSONPlan(plan), nil
+	}
+
+	text := ansiEscapePattern.ReplaceAll(data, nil)
+	if !terraformSummaryPattern.Match(text) && !terraformNoChangePattern.Match(text) && !terraformResourcePattern.Match(text) &&
+		!bytes.Contains(text, []byte("Terraform will perform the following actions")) {
+		return TerraformPlanSummary{}, errors.New("artifact doesn't look like terraform plan output")
+	}
+	return summarizeTextPlan(data), nil
+}
+
+// truncatePlanResources bounds the resources listed for each action, noting what was left out
+func truncatePlanResources(summary *TerraformPlanSummary) {
+	for _, resources := range []*[]string{&summary.Created, &summary.Updated, &summary.Destroyed, &summary.Replaced} {
+		if len(*resources) > maxTerraformPlanResources {
+			summary.Notes = append(summary.Notes, fmt.Sprintf("only the first %d of %d resources are listed for an action", maxTerraformPlanResources, len(*resources)))
+			*resources = (*resources)[:maxTerraformPlanResources]
+		}
+	}
+}
+
+func SummarizeTerraformPlan(client ArtifactsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[SummarizeTerraformPlanArgs], scopes []string) {
+	return mcp.NewTool("summarize_terraform_plan",
+			mcp.WithDescription("Summarize a terraform plan uploaded as an artifact, either the text output of 'terraform plan' or the JSON from 'terraform show -json'. Returns the number of resources to add, change, destroy, and replace with their addresses, for reviewing infrastructure changes from CI"),
+			mcp.WithString("url",
+				mcp.Required(),
+				mcp.Description("The artifact's download_url, as returned by list_artifacts"),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Summarize Terraform Plan",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args SummarizeTerraformPlanArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.SummarizeTerraformPlan")
+			defer span.End()
+
+			if args.URL == "" {
+				return mcp.NewToolResultError("url parameter is required"), nil
+			}
+
+			span.SetAttributes(attribute.String("url", args.URL))
+
+			buffer := &limitedBuffer{limit: maxTerraformPlanBytes}
+			if _, err := client.DownloadArtifactByURL(ctx, args.URL, buffer); err != nil {
+				if errors.Is(err, errArtifactTooLarge) {
+					return mcp.NewToolResultError(fmt.Sprintf("plan artifact is larger than %d bytes", maxTerraformPlanBytes)), nil
+				}
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			result, err := summarizeTerraformPlan(buffer.Bytes())
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			truncatePlanResources(&result)
+
+			span.SetAttributes(
+				attribute.String("format", result.Format),
+				attribute.Int("add", result.Add),
+				attribute.Int("change", result.Change),
+				attribute.Int("destroy", result.Destroy),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_artifacts"}
+}
@@ -0,0 +1,95 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+const testTextPlan = "Terraform used the selected providers to generate the following execution plan.\n" +
+	"Terraform will perform the following actions:\n\n" +
+	"\x1b[1m  # aws_instance.web\x1b[0m will be created\n" +
+	"  + resource \"aws_instance\" \"web\" {\n" +
+	"      + ami = \"ami-123\"\n" +
+	"    }\n\n" +
+	"  # aws_security_group.web will be updated in-place\n" +
+	"  ~ resource \"aws_security_group\" \"web\" {\n" +
+	"    }\n\n" +
+	"  # module.db.aws_db_instance.main must be replaced\n" +
+	"-/+ resource \"aws_db_instance\" \"main\" {\n" +
+	"    }\n\n" +
+	"  # aws_s3_bucket.old will be destroyed\n" +
+	"  - resource \"aws_s3_bucket\" \"old\" {\n" +
+	"    }\n\n" +
+	"Plan: 2 to add, 1 to change, 2 to destroy.\n"
+
+const testJSONPlan = `{
+  "format_version": "1.2",
+  "resource_changes": [
+    {"address": "aws_instance.web", "change": {"actions": ["create"]}},
+    {"address": "aws_security_group.web", "change": {"actions": ["update"]}},
+    {"address": "module.db.aws_db_instance.main", "change": {"actions": ["delete", "create"]}},
+    {"address": "aws_s3_bucket.old", "change": {"actions": ["delete"]}},
+    {"address": "aws_iam_role.unchanged", "change": {"actions": ["no-op"]}}
+  ]
+}`
+
+func TestSummarizeTerraformPlan(t *testing.T) {
+	assert := require.New(t)
+
+	for _, format := range []string{TerraformPlanFormatText, TerraformPlanFormatJSON} {
+		summary, err := summarizeTerraformPlan([]byte(map[string]string{
+			TerraformPlanFormatText: testTextPlan,
+			TerraformPlanFormatJSON: testJSONPlan,
+		}[format]))
+		assert.NoError(err)
+
+		assert.Equal(format, summary.Format)
+		assert.Equal(2, summary.Add)
+		assert.Equal(1, summary.Change)
+		assert.Equal(2, summary.Destroy)
+		assert.Equal(1, summary.Replace)
+		assert.Equal([]string{"aws_instance.web"}, summary.Created)
+		assert.Equal([]string{"aws_security_group.web"}, summary.Updated)
+		assert.Equal([]string{"aws_s3_bucket.old"}, summary.Destroyed)
+		assert.Equal([]string{"module.db.aws_db_instance.main"}, summary.Replaced)
+		assert.Empty(summary.Notes)
+	}
+
+	summary, err := summarizeTerraformPlan([]byte("No changes. Your infrastructure matches the configuration.\n"))
+	assert.NoError(err)
+	assert.True(summary.NoOp)
+
+	_, err = summarizeTerraformPlan([]byte("just a log file\n"))
+	assert.Error(err)
+	_, err = summarizeTerraformPlan([]byte(`{"some": "json"}`))
+	assert.Error(err)
+}
+
+func TestSummarizeTerraformPlanTool(t *testing.T) {
+	assert := require.New(t)
+
+	client := &MockArtifactsClient{
+		DownloadArtifactByURLFunc: func(ctx context.Context, url string, writer io.Writer) (*buildkite.Response, error) {
+			_, err := io.Copy(writer, strings.NewReader(testJSONPlan))
+			return &buildkite.Response{}, err
+		},
+	}
+
+	_, handler, scopes := SummarizeTerraformPlan(client)
+	assert.Equal([]string{"read_artifacts"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, SummarizeTerraformPlanArgs{URL: "https://example.com/plan.json"})
+	assert.NoError(err)
+
+	var summary TerraformPlanSummary
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &summary))
+	assert.Equal(TerraformPlanFormatJSON, summary.Format)
+	assert.Equal(2, summary.Add)
+}
@@ -0,0 +1,326 @@
+package buildkite
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+	"github.com/buildkite/buildkite-mcp-server/pkg/redact"
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	defaultEvidenceContextLines = 20
+	maxEvidenceContextLines     = 100
+	maxEvidenceJobsSearched     = 10
+	maxEvidenceArtifacts        = 20
+)
+
+// evidenceArtifactKinds classifies artifacts which are useful when investigating a failed test
+var evidenceArtifactKinds = []struct {
+	kind       string
+	extensions []string
+}{
+	{kind: "screenshot", extensions: []string{".png", ".jpg", ".jpeg", ".gif", ".webp"}},
+	{kind: "video", extensions: []string{".mp4", ".webm", ".mov"}},
+	{kind: "junit", extensions: []string{".xml"}},
+	{kind: "trace", extensions: []string{".zip", ".har", ".trace"}},
+}
+
+var nonAlphanumeric = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+type GetTestExecutionEvidenceArgs struct {
+	OrgSlug       string `json:"org_slug"`
+	TestSuiteSlug string `json:"test_suite_slug"`
+	RunID         string `json:"run_id"`
+	ExecutionID   string `json:"execution_id"`
+	PipelineSlug  string `json:"pipeline_slug"`
+	ContextLines  int    `json:"context_lines,omitempty"`
+}
+
+// EvidenceArtifact is an artifact uploaded by the job a failed test ran in
+type EvidenceArtifact struct {
+	ID       string `json:"id"`
+	Kind     string `json:"kind"`
+	Path     string `json:"path"`
+	MimeType string `json:"mime_type,omitempty"`
+	FileSize int64  `json:"file_size,omitempty"`
+	Related  bool   `json:"related_to_test,omitempty"`
+}
+
+// TestExecutionEvidence brings together a failed test execution and the build evidence behind it
+type TestExecutionEvidence struct {
+	TestName      string             `json:"test_name"`
+	Location      string             `json:"location,omitempty"`
+	FailureReason string             `json:"failure_reason,omitempty"`
+	BuildNumber   int                `json:"build_number,omitempty"`
+	BuildURL      string             `json:"build_url,omitempty"`
+	JobID         string             `json:"job_id,omitempty"`
+	JobLabel      string             `json:"job_label,omitempty"`
+	Log           []TerseLogEntry    `json:"log,omitempty"`
+	Artifacts     []EvidenceArtifact `json:"artifacts"`
+	Redactions    int                `json:"redactions,omitempty"`
+	Notes         []string           `json:"notes,omitempty"`
+}
+
+// findRunBuild finds the build of the pipeline which reported the test run
+func findRunBuild(ctx context.Context, client BuildsClient, org, pipeline string, run buildkite.TestRun) (*buildkite.Build, error) {
+	builds, _, err := client.ListByPipeline(ctx, org, pipeline, &buildkite.BuildsListOptions{
+		Commit:      run.CommitSHA,
+		ListOptions: buildkite.ListOptions{PerPage: 20},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, build := range builds {
+		if build.TestEngine == nil {
+			continue
+		}
+		for _, testRun := range build.TestEngine.Runs {
+			if testRun.ID == run.ID {
+				return &builds[i], nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// evidenceArtifactKind returns the kind of evidence an artifact holds, or false if it isn't useful
+func evidenceArtifactKind(artifactPath string) (string, bool) {
+	ext := strings.ToLower(path.Ext(artifactPath))
+	for _, kind := range evidenceArtifactKinds {
+		if slices.Contains(kind.extensions, ext) {
+			return kind.kind, true
+		}
+	}
+	return "", false
+}
+
+// relatedToTest returns true when an artifact path mentions the test, as screenshot and video
+// reporters usually name their files after it
+func relatedToTest(artifactPath, testName string) bool {
+	normalize := func(s string) string {
+		return strings.ToLower(nonAlphanumeric.ReplaceAllString(s, ""))
+	}
+	name := normalize(testName)
+	return name != "" && strings.Contains(normalize(artifactPath), name)
+}
+
+// testLogPattern builds a search pattern which finds the test by name in a job log
+func testLogPattern(execution buildkite.FailedExecution) string {
+	if execution.TestName != "" {
+		return regexp.QuoteMeta(execution.TestName)
+	}
+	return regexp.QuoteMeta(execution.Location)
+}
+
+func GetTestExecutionEvidence(testRunsClient TestRunsClient, buildsClient BuildsClient, logsClient BuildkiteLogsClient, artifactsClient ArtifactsClient, redactor *redact.Redactor) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[GetTestExecutionEvidenceArgs], scopes []string) {
+	return mcp.NewTool("get_test_execution_evidence",
+			mcp.WithDescription("Given a Test Engine run and one of its failed executions, find the build and job which ran the test and return the log lines around the test's output plus related artifacts such as screenshots, videos, and JUnit reports. Bridges Test Engine results and build evidence in one call."),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("test_suite_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("run_id",
+				mcp.Required(),
+			),
+			mcp.WithString("execution_id",
+				mcp.Required(),
+				mcp.Description("The execution_id of a failed execution from get_failed_executions"),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+				mcp.Description("The pipeline whose builds report to the test suite"),
+			),
+			mcp.WithNumber("context_lines",
+				mcp.Description("Number of log lines to return before and after the test output (default 20, max 100)"),
+				mcp.Min(0),
+				mcp.Max(maxEvidenceContextLines),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Get Test Execution Evidence",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args GetTestExecutionEvidenceArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.GetTestExecutionEvidence")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.TestSuiteSlug == "" {
+				return mcp.NewToolResultError("test_suite_slug parameter is required"), nil
+			}
+			if args.RunID == "" {
+				return mcp.NewToolResultError("run_id parameter is required"), nil
+			}
+			if args.ExecutionID == "" {
+				return mcp.NewToolResultError("execution_id parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if args.ContextLines <= 0 {
+				args.ContextLines = defaultEvidenceContextLines
+			}
+			args.ContextLines = min(args.ContextLines, maxEvidenceContextLines)
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("test_suite_slug", args.TestSuiteSlug),
+				attribute.String("run_id", args.RunID),
+				attribute.String("execution_id", args.ExecutionID),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+			)
+
+			run, _, err := testRunsClient.Get(ctx, args.OrgSlug, args.TestSuiteSlug, args.RunID)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			executions, _, err := testRunsClient.GetFailedExecutions(ctx, args.OrgSlug, args.TestSuiteSlug, args.RunID, &buildkite.FailedExecutionsOptions{})
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			index := slices.IndexFunc(executions, func(e buildkite.FailedExecution) bool { return e.ExecutionID == args.ExecutionID })
+			if index < 0 {
+				return mcp.NewToolResultError(fmt.Sprintf("failed execution %s not found in run %s", args.ExecutionID, args.RunID)), nil
+			}
+			execution := executions[index]
+
+			result := TestExecutionEvidence{
+				TestName:      execution.TestName,
+				Location:      execution.Location,
+				FailureReason: execution.FailureReason,
+				Artifacts:     []EvidenceArtifact{},
+			}
+
+			build, err := findRunBuild(ctx, buildsClient, args.OrgSlug, args.PipelineSlug, run)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if build == nil {
+				result.Notes = append(result.Notes, fmt.Sprintf("no build of %s for commit %s reported test run %s", args.PipelineSlug, run.CommitSHA, run.ID))
+				return mcpTextResult(span, &result)
+			}
+
+			result.BuildNumber = build.Number
+			result.BuildURL = build.WebURL
+			buildNumber := strconv.Itoa(build.Number)
+
+			// the run doesn't record which job it came from, so look for the test's output in the failed jobs
+			opts := SearchOptions{
+				Pattern: testLogPattern(execution),
+				Context: args.ContextLines,
+			}
+
+			searched := 0
+			for _, job := range build.Jobs {
+				if !isFailedJob(job) || job.Retried {
+					continue
+				}
+				if searched == maxEvidenceJobsSearched {
+					result.Notes = append(result.Notes, fmt.Sprintf("stopped after searching the logs of %d failed jobs", searched))
+					break
+				}
+				searched++
+
+				reader, err := newParquetReader(ctx, logsClient, JobLogsBaseParams{
+					OrgSlug:      args.OrgSlug,
+					PipelineSlug: args.PipelineSlug,
+					BuildNumber:  buildNumber,
+					JobID:        job.ID,
+				})
+				if err != nil {
+					result.Notes = append(result.Notes, fmt.Sprintf("log for job %s unavailable: %v", job.ID, err))
+					continue
+				}
+
+				for match, err := range reader.SearchEntriesIter(opts) {
+					if err != nil {
+						result.Notes = append(result.Notes, fmt.Sprintf("failed to search log for job %s: %v", job.ID, err))
+						break
+					}
+
+					entries := slices.Concat(match.BeforeContext, []buildkitelogs.ParquetLogEntry{match.Match}, match.AfterContext)
+					log, redactions := formatLogEntries(entries, redactor, nil)
+					result.Log, _ = log.([]TerseLogEntry)
+					result.Redactions += redactions
+					result.JobID = job.ID
+					result.JobLabel = jobLabel(job)
+					break
+				}
+
+				if result.JobID != "" {
+					break
+				}
+			}
+
+			if result.JobID == "" {
+				result.Notes = append(result.Notes, "the test name was not found in the logs of the build's failed jobs, artifacts from the whole build are listed")
+			}
+
+			artifacts, _, err := artifactsClient.ListByBuild(ctx, args.OrgSlug, args.PipelineSlug, buildNumber, &buildkite.ArtifactListOptions{
+				ListOptions: buildkite.ListOptions{PerPage: 100},
+			})
+			if err != nil {
+				result.Notes = append(result.Notes, fmt.Sprintf("failed to list artifacts: %v", err))
+				return mcpTextResult(span, &result)
+			}
+
+			for _, artifact := range artifacts {
+				if result.JobID != "" && artifact.JobID != result.JobID {
+					continue
+				}
+				kind, ok := evidenceArtifactKind(artifact.Path)
+				if !ok {
+					continue
+				}
+				result.Artifacts = append(result.Artifacts, EvidenceArtifact{
+					ID:       artifact.ID,
+					Kind:     kind,
+					Path:     artifact.Path,
+					MimeType: artifact.MimeType,
+					FileSize: artifact.FileSize,
+					Related:  relatedToTest(artifact.Path, execution.TestName),
+				})
+			}
+
+			// artifacts named after the test come first
+			slices.SortStableFunc(result.Artifacts, func(a, b EvidenceArtifact) int {
+				switch {
+				case a.Related == b.Related:
+					return 0
+				case a.Related:
+					return -1
+				}
+				return 1
+			})
+			if len(result.Artifacts) > maxEvidenceArtifacts {
+				result.Notes = append(result.Notes, fmt.Sprintf("%d more artifacts omitted", len(result.Artifacts)-maxEvidenceArtifacts))
+				result.Artifacts = result.Artifacts[:maxEvidenceArtifacts]
+			}
+
+			span.SetAttributes(
+				attribute.Int("build_number", result.BuildNumber),
+				attribute.String("job_id", result.JobID),
+				attribute.Int("artifact_count", len(result.Artifacts)),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_suites", "read_builds", "read_build_logs", "read_artifacts"}
+}
@@ -0,0 +1,89 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTestExecutionEvidence(t *testing.T) {
+	assert := require.New(t)
+
+	lintLog := writeTestLog(t, "golangci-lint run", "main.go:1: unused variable")
+	testLog := writeTestLog(t, "=== RUN   TestCheckout", "checkout_test.go:42: expected 200, got 500", "--- FAIL: TestCheckout (0.12s)")
+
+	testRunsClient := &MockTestRunsClient{
+		GetFunc: func(ctx context.Context, org, slug, runID string) (buildkite.TestRun, *buildkite.Response, error) {
+			return buildkite.TestRun{ID: runID, CommitSHA: "abc123"}, &buildkite.Response{}, nil
+		},
+		GetFailedExecutionsFunc: func(ctx context.Context, org, slug, runID string, opt *buildkite.FailedExecutionsOptions) ([]buildkite.FailedExecution, *buildkite.Response, error) {
+			return []buildkite.FailedExecution{
+				{ExecutionID: "exec-1", TestName: "TestCheckout", FailureReason: "expected 200, got 500"},
+			}, &buildkite.Response{}, nil
+		},
+	}
+	buildsClient := &MockBuildsClient{
+		ListByPipelineFunc: func(ctx context.Context, org string, pipeline string, opt *buildkite.BuildsListOptions) ([]buildkite.Build, *buildkite.Response, error) {
+			assert.Equal("abc123", opt.Commit)
+			return []buildkite.Build{
+				{Number: 6, TestEngine: &buildkite.TestEngineProperty{Runs: []buildkite.TestEngineRun{{ID: "other-run"}}}},
+				{
+					Number:     5,
+					TestEngine: &buildkite.TestEngineProperty{Runs: []buildkite.TestEngineRun{{ID: "run-1"}}},
+					Jobs: []buildkite.Job{
+						{ID: "job-lint", Type: "script", Label: "lint", State: "failed"},
+						{ID: "job-test", Type: "script", Label: "test", State: "failed"},
+					},
+				},
+			}, &buildkite.Response{}, nil
+		},
+	}
+	logsClient := &MockBuildkiteLogsClient{
+		DownloadAndCacheFunc: func(ctx context.Context, org, pipeline, build, job string, cacheTTL time.Duration, forceRefresh bool) (string, error) {
+			assert.Equal("5", build)
+			if job == "job-lint" {
+				return lintLog, nil
+			}
+			return testLog, nil
+		},
+	}
+	artifactsClient := &MockArtifactsClient{
+		ListByBuildFunc: func(ctx context.Context, org, pipelineSlug, buildNumber string, opts *buildkite.ArtifactListOptions) ([]buildkite.Artifact, *buildkite.Response, error) {
+			return []buildkite.Artifact{
+				{ID: "a1", JobID: "job-test", Path: "junit/report.xml"},
+				{ID: "a2", JobID: "job-test", Path: "coverage.out"},
+				{ID: "a3", JobID: "job-test", Path: "screenshots/test_checkout.png"},
+				{ID: "a4", JobID: "job-lint", Path: "lint.xml"},
+			}, &buildkite.Response{}, nil
+		},
+	}
+
+	_, handler, scopes := GetTestExecutionEvidence(testRunsClient, buildsClient, logsClient, artifactsClient, nil)
+	assert.Equal([]string{"read_suites", "read_builds", "read_build_logs", "read_artifacts"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, GetTestExecutionEvidenceArgs{
+		OrgSlug:       "org",
+		TestSuiteSlug: "suite",
+		RunID:         "run-1",
+		ExecutionID:   "exec-1",
+		PipelineSlug:  "pipeline",
+		ContextLines:  1,
+	})
+	assert.NoError(err)
+
+	var evidence TestExecutionEvidence
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &evidence))
+	assert.Equal(5, evidence.BuildNumber)
+	assert.Equal("job-test", evidence.JobID)
+	assert.Len(evidence.Log, 2)
+	assert.Equal("=== RUN   TestCheckout", evidence.Log[0].C)
+	assert.Equal([]EvidenceArtifact{
+		{ID: "a3", Kind: "screenshot", Path: "screenshots/test_checkout.png", Related: true},
+		{ID: "a1", Kind: "junit", Path: "junit/report.xml"},
+	}, evidence.Artifacts)
+}
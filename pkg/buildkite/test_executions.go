@@ -1,7 +1,9 @@
 package buildkite
 
 import (
+	"cmp"
 	"context"
+	"slices"
 
 	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
 	"github.com/buildkite/go-buildkite/v4"
@@ -14,6 +16,46 @@ type TestExecutionsClient interface {
 	GetFailedExecutions(ctx context.Context, org, slug, runID string, opt *buildkite.FailedExecutionsOptions) ([]buildkite.FailedExecution, *buildkite.Response, error)
 }
 
+const (
+	ExecutionSortDurationDesc = "duration_desc"
+	ExecutionSortDurationAsc  = "duration_asc"
+)
+
+// FailedExecutionWithFrame is a failed execution with the first frame of its backtrace inside the repository
+type FailedExecutionWithFrame struct {
+	buildkite.FailedExecution
+	FirstRepoFrame *StackFrame `json:"first_repo_frame,omitempty"`
+}
+
+// filterExecutionsByDuration keeps executions within the duration bounds, a bound of zero is ignored
+func filterExecutionsByDuration(executions []buildkite.FailedExecution, slowerThan, fasterThan float64) []buildkite.FailedExecution {
+	if slowerThan <= 0 && fasterThan <= 0 {
+		return executions
+	}
+
+	filtered := []buildkite.FailedExecution{}
+	for _, execution := range executions {
+		if slowerThan > 0 && execution.Duration <= slowerThan {
+			continue
+		}
+		if fasterThan > 0 && execution.Duration >= fasterThan {
+			continue
+		}
+		filtered = append(filtered, execution)
+	}
+	return filtered
+}
+
+// sortExecutionsByDuration sorts executions in place, keeping the API order for equal durations
+func sortExecutionsByDuration(executions []buildkite.FailedExecution, sort string) {
+	switch sort {
+	case ExecutionSortDurationDesc:
+		slices.SortStableFunc(executions, func(a, b buildkite.FailedExecution) int { return cmp.Compare(b.Duration, a.Duration) })
+	case ExecutionSortDurationAsc:
+		slices.SortStableFunc(executions, func(a, b buildkite.FailedExecution) int { return cmp.Compare(a.Duration, b.Duration) })
+	}
+}
+
 func GetFailedTestExecutions(client TestExecutionsClient) (tool mcp.Tool, handler server.ToolHandlerFunc, scopes []string) {
 	return mcp.NewTool("get_failed_executions",
 			mcp.WithDescription("Get failed test executions for a specific test run in Buildkite Test Engine. Optionally get the expanded failure details such as full error messages and stack traces."),
@@ -29,6 +71,25 @@ func GetFailedTestExecutions(client TestExecutionsClient) (tool mcp.Tool, handle
 			mcp.WithBoolean("include_failure_expanded",
 				mcp.Description("Include the expanded failure details such as full error messages and stack traces. This can be used to explain and diganose the cause of test failures."),
 			),
+			mcp.WithNumber("slower_than",
+				mcp.Description("Only return executions which took longer than this many seconds"),
+				mcp.Min(0),
+			),
+			mcp.WithNumber("faster_than",
+				mcp.Description("Only return executions which took less than this many seconds"),
+				mcp.Min(0),
+			),
+			mcp.WithString("sort",
+				mcp.Description("Sort executions by duration, applied before pagination"),
+				mcp.Enum(ExecutionSortDurationDesc, ExecutionSortDurationAsc),
+			),
+			mcp.WithBoolean("parse_stack_frames",
+				mcp.Description("Parse each failure's backtrace and return the first frame inside the repository (file, line, function) as first_repo_frame, to jump straight to the offending source"),
+			),
+			mcp.WithArray("repo_path_prefixes",
+				mcp.Description("Path prefixes of the repository checkout such as /workdir/ or /app/, stripped from first_repo_frame. Frames in known dependency and runtime paths are always skipped"),
+				mcp.WithStringItems(),
+			),
 			withClientSidePagination(),
 			mcp.WithToolAnnotation(mcp.ToolAnnotation{
 				Title:        "Get Failed Test Executions",
@@ -55,6 +116,11 @@ func GetFailedTestExecutions(client TestExecutionsClient) (tool mcp.Tool, handle
 			}
 
 			includeFailureExpanded := request.GetBool("include_failure_expanded", false)
+			slowerThan := request.GetFloat("slower_than", 0)
+			fasterThan := request.GetFloat("faster_than", 0)
+			sort := request.GetString("sort", "")
+			parseStackFrames := request.GetBool("parse_stack_frames", false)
+			repoPathPrefixes := request.GetStringSlice("repo_path_prefixes", nil)
 
 			// Get client-side pagination parameters (always enabled)
 			paginationParams := getClientSidePaginationParams(request)
@@ -64,12 +130,17 @@ func GetFailedTestExecutions(client TestExecutionsClient) (tool mcp.Tool, handle
 				attribute.String("test_suite_slug", testSuiteSlug),
 				attribute.String("run_id", runID),
 				attribute.Bool("include_failure_expanded", includeFailureExpanded),
+				attribute.Float64("slower_than", slowerThan),
+				attribute.Float64("faster_than", fasterThan),
+				attribute.String("sort", sort),
+				attribute.Bool("parse_stack_frames", parseStackFrames),
 				attribute.Int("page", paginationParams.Page),
 				attribute.Int("per_page", paginationParams.PerPage),
 			)
 
 			options := &buildkite.FailedExecutionsOptions{
-				IncludeFailureExpanded: includeFailureExpanded,
+				// backtraces are only returned with the expanded failure details
+				IncludeFailureExpanded: includeFailureExpanded || parseStackFrames,
 			}
 
 			failedExecutions, _, err := client.GetFailedExecutions(ctx, orgSlug, testSuiteSlug, runID, options)
@@ -77,13 +148,33 @@ func GetFailedTestExecutions(client TestExecutionsClient) (tool mcp.Tool, handle
 				return mcp.NewToolResultError(err.Error()), nil
 			}
 
-			// Always apply client-side pagination
-			result := applyClientSidePagination(failedExecutions, paginationParams)
+			// the API has no duration filters, so filter and sort before paginating
+			failedExecutions = filterExecutionsByDuration(failedExecutions, slowerThan, fasterThan)
+			sortExecutionsByDuration(failedExecutions, sort)
 
 			span.SetAttributes(
 				attribute.Int("item_count", len(failedExecutions)),
 			)
 
+			if parseStackFrames {
+				executions := make([]FailedExecutionWithFrame, len(failedExecutions))
+				for i, execution := range failedExecutions {
+					executions[i] = FailedExecutionWithFrame{
+						FailedExecution: execution,
+						FirstRepoFrame:  firstRepoFrame(execution.FailureExpanded, repoPathPrefixes),
+					}
+					if !includeFailureExpanded {
+						executions[i].FailureExpanded = nil
+					}
+				}
+
+				result := applyClientSidePagination(executions, paginationParams)
+				return mcpTextResult(span, &result)
+			}
+
+			// Always apply client-side pagination
+			result := applyClientSidePagination(failedExecutions, paginationParams)
+
 			return mcpTextResult(span, &result)
 		}, []string{"read_suites"}
 }
@@ -2,6 +2,7 @@ package buildkite
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -424,3 +425,81 @@ func TestGetFailedExecutionsLargePage(t *testing.T) {
 	assert.Contains(textContentLargePage.Text, `"has_next":false`)
 	assert.Contains(textContentLargePage.Text, `"has_prev":false`)
 }
+
+func TestGetFailedExecutionsDurationFilter(t *testing.T) {
+	assert := require.New(t)
+
+	ctx := context.Background()
+	mockClient := &MockTestExecutionsClient{
+		GetFailedExecutionsFunc: func(ctx context.Context, org, slug, runID string, opt *buildkite.FailedExecutionsOptions) ([]buildkite.FailedExecution, *buildkite.Response, error) {
+			return []buildkite.FailedExecution{
+				{ExecutionID: "exec-fast", Duration: 0.5},
+				{ExecutionID: "exec-medium", Duration: 5},
+				{ExecutionID: "exec-slow", Duration: 20},
+				{ExecutionID: "exec-slowest", Duration: 60},
+			}, &buildkite.Response{}, nil
+		},
+	}
+
+	_, handler, _ := GetFailedTestExecutions(mockClient)
+
+	request := createMCPRequest(t, map[string]any{
+		"org_slug":        "org",
+		"test_suite_slug": "suite1",
+		"run_id":          "run1",
+		"slower_than":     float64(1),
+		"faster_than":     float64(30),
+		"sort":            "duration_desc",
+	})
+
+	result, err := handler(ctx, request)
+	assert.NoError(err)
+
+	var page ClientSidePaginatedResult[buildkite.FailedExecution]
+	assert.NoError(json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &page))
+	assert.Len(page.Items, 2)
+	assert.Equal("exec-slow", page.Items[0].ExecutionID)
+	assert.Equal("exec-medium", page.Items[1].ExecutionID)
+	assert.Equal(2, page.Total)
+}
+
+func TestGetFailedExecutionsParseStackFrames(t *testing.T) {
+	assert := require.New(t)
+
+	ctx := context.Background()
+	mockClient := &MockTestExecutionsClient{
+		GetFailedExecutionsFunc: func(ctx context.Context, org, slug, runID string, opt *buildkite.FailedExecutionsOptions) ([]buildkite.FailedExecution, *buildkite.Response, error) {
+			assert.True(opt.IncludeFailureExpanded)
+			return []buildkite.FailedExecution{
+				{
+					ExecutionID: "exec-1",
+					FailureExpanded: []buildkite.FailureExpanded{{
+						Backtrace: []string{
+							"/app/node_modules/expect/build/index.js:10:3",
+							"at checkout (/app/src/checkout.js:42:7)",
+						},
+					}},
+				},
+			}, &buildkite.Response{}, nil
+		},
+	}
+
+	_, handler, _ := GetFailedTestExecutions(mockClient)
+
+	request := createMCPRequest(t, map[string]any{
+		"org_slug":           "org",
+		"test_suite_slug":    "suite1",
+		"run_id":             "run1",
+		"parse_stack_frames": true,
+		"repo_path_prefixes": []any{"/app/"},
+	})
+
+	result, err := handler(ctx, request)
+	assert.NoError(err)
+
+	var page ClientSidePaginatedResult[FailedExecutionWithFrame]
+	assert.NoError(json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &page))
+	assert.Len(page.Items, 1)
+	assert.Equal(&StackFrame{File: "src/checkout.js", Line: 42, Function: "checkout", Raw: "at checkout (/app/src/checkout.js:42:7)"}, page.Items[0].FirstRepoFrame)
+	assert.Empty(page.Items[0].FailureExpanded)
+}
@@ -0,0 +1,218 @@
+package buildkite
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/errgroup"
+)
+
+// TestLabelsClient reads the labels attached to tests in Test Engine
+type TestLabelsClient interface {
+	GetTestLabels(ctx context.Context, org, suite, testID string) ([]string, *buildkite.Response, error)
+}
+
+// testWithLabels is the part of the Test Engine test response go-buildkite doesn't decode
+type testWithLabels struct {
+	Labels []string `json:"labels"`
+}
+
+// GetTestLabels implements TestLabelsClient
+func (a *BuildkiteClientAdapter) GetTestLabels(ctx context.Context, org, suite, testID string) ([]string, *buildkite.Response, error) {
+	u := fmt.Sprintf("v2/analytics/organizations/%s/suites/%s/tests/%s", org, suite, testID)
+	req, err := a.NewRequest(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var test testWithLabels
+	resp, err := a.Do(req, &test)
+	if err != nil {
+		return nil, resp, err
+	}
+	return test.Labels, resp, nil
+}
+
+const (
+	testOwnersConcurrency = 4
+	// UnownedTests groups failures whose tests have no owner label
+	UnownedTests = "unowned"
+)
+
+var defaultOwnerLabelPrefixes = []string{"team:", "owner:"}
+
+type GroupFailuresByOwnerArgs struct {
+	OrgSlug            string   `json:"org_slug"`
+	TestSuiteSlug      string   `json:"test_suite_slug"`
+	RunID              string   `json:"run_id"`
+	OwnerLabelPrefixes []string `json:"owner_label_prefixes,omitempty"`
+}
+
+// OwnedFailure is a failed test attributed to an owner
+type OwnedFailure struct {
+	TestID        string `json:"test_id"`
+	Name          string `json:"name"`
+	Location      string `json:"location,omitempty"`
+	FailureReason string `json:"failure_reason,omitempty"`
+}
+
+// OwnerFailures are the failures attributed to one owner
+type OwnerFailures struct {
+	Owner    string         `json:"owner"`
+	Count    int            `json:"count"`
+	Failures []OwnedFailure `json:"failures"`
+}
+
+type FailuresByOwner struct {
+	RunID       string          `json:"run_id"`
+	FailedTests int             `json:"failed_tests"`
+	Owners      []OwnerFailures `json:"owners"`
+	Notes       []string        `json:"notes,omitempty"`
+}
+
+// testOwners returns the owners named by the labels matching any of the prefixes
+func testOwners(labels, prefixes []string) []string {
+	var owners []string
+	for _, label := range labels {
+		for _, prefix := range prefixes {
+			if owner, ok := strings.CutPrefix(label, prefix); ok && owner != "" && !slices.Contains(owners, owner) {
+				owners = append(owners, owner)
+			}
+		}
+	}
+	return owners
+}
+
+func GroupFailuresByOwner(testRunsClient TestRunsClient, labelsClient TestLabelsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[GroupFailuresByOwnerArgs], scopes []string) {
+	return mcp.NewTool("group_failures_by_owner",
+			mcp.WithDescription("Group the failed tests of a Buildkite Test Engine run by owning team, using owner labels on the tests such as 'team:payments'. Answers 'who do I page?'. Tests without an owner label are grouped as 'unowned', and a test with several owners is listed under each."),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("test_suite_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("run_id",
+				mcp.Required(),
+			),
+			mcp.WithArray("owner_label_prefixes",
+				mcp.Description("Prefixes of the test labels which name an owner (default: team:, owner:)"),
+				mcp.WithStringItems(),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Group Failures by Owner",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args GroupFailuresByOwnerArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.GroupFailuresByOwner")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.TestSuiteSlug == "" {
+				return mcp.NewToolResultError("test_suite_slug parameter is required"), nil
+			}
+			if args.RunID == "" {
+				return mcp.NewToolResultError("run_id parameter is required"), nil
+			}
+			if len(args.OwnerLabelPrefixes) == 0 {
+				args.OwnerLabelPrefixes = defaultOwnerLabelPrefixes
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("test_suite_slug", args.TestSuiteSlug),
+				attribute.String("run_id", args.RunID),
+			)
+
+			executions, _, err := testRunsClient.GetFailedExecutions(ctx, args.OrgSlug, args.TestSuiteSlug, args.RunID, &buildkite.FailedExecutionsOptions{})
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// retried tests report a failed execution per attempt
+			var failures []OwnedFailure
+			for _, execution := range executions {
+				if slices.ContainsFunc(failures, func(f OwnedFailure) bool { return f.TestID == execution.TestID }) {
+					continue
+				}
+				failures = append(failures, OwnedFailure{
+					TestID:        execution.TestID,
+					Name:          execution.TestName,
+					Location:      execution.Location,
+					FailureReason: execution.FailureReason,
+				})
+			}
+
+			result := FailuresByOwner{
+				RunID:       args.RunID,
+				FailedTests: len(failures),
+				Owners:      []OwnerFailures{},
+			}
+
+			var mu sync.Mutex
+			owners := map[string]*OwnerFailures{}
+
+			g, gctx := errgroup.WithContext(ctx)
+			g.SetLimit(testOwnersConcurrency)
+			for _, failure := range failures {
+				g.Go(func() error {
+					labels, _, err := labelsClient.GetTestLabels(gctx, args.OrgSlug, args.TestSuiteSlug, failure.TestID)
+
+					mu.Lock()
+					defer mu.Unlock()
+
+					if err != nil {
+						result.Notes = append(result.Notes, fmt.Sprintf("failed to get labels for test %s: %v", failure.TestID, err))
+					}
+
+					names := testOwners(labels, args.OwnerLabelPrefixes)
+					if len(names) == 0 {
+						names = []string{UnownedTests}
+					}
+					for _, owner := range names {
+						group, ok := owners[owner]
+						if !ok {
+							group = &OwnerFailures{Owner: owner}
+							owners[owner] = group
+						}
+						group.Count++
+						group.Failures = append(group.Failures, failure)
+					}
+
+					// a test we can't read labels for is reported as unowned rather than failing the grouping
+					return nil
+				})
+			}
+			_ = g.Wait()
+
+			for _, group := range owners {
+				slices.SortFunc(group.Failures, func(a, b OwnedFailure) int { return cmp.Compare(a.Name, b.Name) })
+				result.Owners = append(result.Owners, *group)
+			}
+			slices.SortFunc(result.Owners, func(a, b OwnerFailures) int {
+				return cmp.Or(
+					cmp.Compare(b.Count, a.Count),
+					cmp.Compare(a.Owner, b.Owner),
+				)
+			})
+			slices.Sort(result.Notes)
+
+			span.SetAttributes(
+				attribute.Int("failed_tests", result.FailedTests),
+				attribute.Int("owners", len(result.Owners)),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_suites"}
+}
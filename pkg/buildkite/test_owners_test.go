@@ -0,0 +1,78 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+type MockTestLabelsClient struct {
+	GetTestLabelsFunc func(ctx context.Context, org, suite, testID string) ([]string, *buildkite.Response, error)
+}
+
+func (m *MockTestLabelsClient) GetTestLabels(ctx context.Context, org, suite, testID string) ([]string, *buildkite.Response, error) {
+	if m.GetTestLabelsFunc != nil {
+		return m.GetTestLabelsFunc(ctx, org, suite, testID)
+	}
+	return nil, nil, nil
+}
+
+var _ TestLabelsClient = (*MockTestLabelsClient)(nil)
+
+func TestTestOwners(t *testing.T) {
+	assert := require.New(t)
+
+	assert.Equal([]string{"payments", "alex"}, testOwners([]string{"slow", "team:payments", "owner:alex", "team:payments"}, defaultOwnerLabelPrefixes))
+	assert.Empty(testOwners([]string{"slow", "team:"}, defaultOwnerLabelPrefixes))
+}
+
+func TestGroupFailuresByOwner(t *testing.T) {
+	assert := require.New(t)
+
+	testRunsClient := &MockTestRunsClient{
+		GetFailedExecutionsFunc: func(ctx context.Context, org, slug, runID string, opt *buildkite.FailedExecutionsOptions) ([]buildkite.FailedExecution, *buildkite.Response, error) {
+			return []buildkite.FailedExecution{
+				{TestID: "t1", TestName: "charge card"},
+				{TestID: "t1", TestName: "charge card"},
+				{TestID: "t2", TestName: "refund"},
+				{TestID: "t3", TestName: "login"},
+				{TestID: "t4", TestName: "legacy"},
+			}, &buildkite.Response{}, nil
+		},
+	}
+	labelsClient := &MockTestLabelsClient{
+		GetTestLabelsFunc: func(ctx context.Context, org, suite, testID string) ([]string, *buildkite.Response, error) {
+			switch testID {
+			case "t1", "t2":
+				return []string{"team:payments"}, &buildkite.Response{}, nil
+			case "t3":
+				return []string{"team:identity", "flaky"}, &buildkite.Response{}, nil
+			}
+			return nil, &buildkite.Response{}, nil
+		},
+	}
+
+	_, handler, scopes := GroupFailuresByOwner(testRunsClient, labelsClient)
+	assert.Equal([]string{"read_suites"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, GroupFailuresByOwnerArgs{
+		OrgSlug:       "org",
+		TestSuiteSlug: "suite",
+		RunID:         "run-1",
+	})
+	assert.NoError(err)
+
+	var grouped FailuresByOwner
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &grouped))
+	assert.Equal(4, grouped.FailedTests)
+	assert.Len(grouped.Owners, 3)
+	assert.Equal("payments", grouped.Owners[0].Owner)
+	assert.Equal(2, grouped.Owners[0].Count)
+	assert.Equal("charge card", grouped.Owners[0].Failures[0].Name)
+	assert.Equal("identity", grouped.Owners[1].Owner)
+	assert.Equal(UnownedTests, grouped.Owners[2].Owner)
+}
@@ -0,0 +1,275 @@
+package buildkite
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	defaultTestReliabilityWindow = 50
+	maxTestReliabilityWindow     = 200
+	testReliabilityConcurrency   = 4
+	maxRecentTestFailures        = 5
+
+	TestReliabilityBucketDay  = "day"
+	TestReliabilityBucketWeek = "week"
+)
+
+type GetTestReliabilityArgs struct {
+	OrgSlug       string `json:"org_slug"`
+	TestSuiteSlug string `json:"test_suite_slug"`
+	TestID        string `json:"test_id"`
+	Window        int    `json:"window,omitempty"`
+	Bucket        string `json:"bucket,omitempty"`
+}
+
+// TestReliabilityBucket is the outcome of a test across the runs in a period
+type TestReliabilityBucket struct {
+	Start    time.Time `json:"start"`
+	Runs     int       `json:"runs"`
+	Failures int       `json:"failures"`
+	PassRate float64   `json:"pass_rate"`
+}
+
+// TestFailure is a single failed execution of a test
+type TestFailure struct {
+	ExecutionID   string               `json:"execution_id"`
+	RunID         string               `json:"run_id"`
+	Branch        string               `json:"branch,omitempty"`
+	CommitSHA     string               `json:"commit_sha,omitempty"`
+	CreatedAt     *buildkite.Timestamp `json:"created_at,omitempty"`
+	FailureReason string               `json:"failure_reason,omitempty"`
+	Duration      float64              `json:"duration,omitempty"`
+}
+
+type TestReliability struct {
+	TestID                 string                  `json:"test_id"`
+	Name                   string                  `json:"name,omitempty"`
+	Location               string                  `json:"location,omitempty"`
+	RunsAnalyzed           int                     `json:"runs_analyzed"`
+	Failures               int                     `json:"failures"`
+	PassRate               float64                 `json:"pass_rate"`
+	AverageFailureDuration float64                 `json:"average_failure_duration,omitempty"`
+	Buckets                []TestReliabilityBucket `json:"buckets"`
+	RecentFailures         []TestFailure           `json:"recent_failures"`
+}
+
+// bucketStart returns the start of the day or ISO week containing t
+func bucketStart(t time.Time, bucket string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if bucket != TestReliabilityBucketWeek {
+		return day
+	}
+	// weeks start on Monday
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// listRecentTestRuns returns up to limit of the most recent runs of a suite
+func listRecentTestRuns(ctx context.Context, client TestRunsClient, org, suite string, limit int) ([]buildkite.TestRun, error) {
+	options := &buildkite.TestRunsListOptions{
+		ListOptions: buildkite.ListOptions{PerPage: min(limit, 100)},
+	}
+
+	var runs []buildkite.TestRun
+	for len(runs) < limit {
+		page, resp, err := client.List(ctx, org, suite, options)
+		if err != nil {
+			return nil, err
+		}
+
+		runs = append(runs, page...)
+
+		if resp == nil || resp.NextPage == 0 || len(page) == 0 {
+			break
+		}
+		options.Page = resp.NextPage
+	}
+
+	return runs[:min(len(runs), limit)], nil
+}
+
+// findTestFailures returns the failed execution of a test in each of the runs which reported one, keyed by run ID
+func findTestFailures(ctx context.Context, client TestRunsClient, org, suite, testID string, runs []buildkite.TestRun) (map[string]buildkite.FailedExecution, error) {
+	var mu sync.Mutex
+	failures := map[string]buildkite.FailedExecution{}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(testReliabilityConcurrency)
+	for _, run := range runs {
+		g.Go(func() error {
+			executions, _, err := client.GetFailedExecutions(gctx, org, suite, run.ID, &buildkite.FailedExecutionsOptions{})
+			if err != nil {
+				return err
+			}
+
+			for _, execution := range executions {
+				if execution.TestID == testID {
+					mu.Lock()
+					failures[run.ID] = execution
+					mu.Unlock()
+					break
+				}
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return failures, nil
+}
+
+// testReliability summarises the failures of a test across runs. A run counts as a pass for the test
+// when it didn't report a failed execution for it.
+func testReliability(testID string, runs []buildkite.TestRun, failures map[string]buildkite.FailedExecution, bucket string) TestReliability {
+	result := TestReliability{
+		TestID:         testID,
+		RunsAnalyzed:   len(runs),
+		Buckets:        []TestReliabilityBucket{},
+		RecentFailures: []TestFailure{},
+	}
+
+	buckets := map[time.Time]*TestReliabilityBucket{}
+	var totalDuration float64
+
+	for _, run := range runs {
+		var start time.Time
+		if run.CreatedAt != nil {
+			start = bucketStart(run.CreatedAt.Time, bucket)
+		}
+		b, ok := buckets[start]
+		if !ok {
+			b = &TestReliabilityBucket{Start: start}
+			buckets[start] = b
+		}
+		b.Runs++
+
+		execution, failed := failures[run.ID]
+		if !failed {
+			continue
+		}
+
+		b.Failures++
+		result.Failures++
+		totalDuration += execution.Duration
+
+		if len(result.RecentFailures) < maxRecentTestFailures {
+			result.RecentFailures = append(result.RecentFailures, TestFailure{
+				ExecutionID:   execution.ExecutionID,
+				RunID:         run.ID,
+				Branch:        run.Branch,
+				CommitSHA:     run.CommitSHA,
+				CreatedAt:     run.CreatedAt,
+				FailureReason: execution.FailureReason,
+				Duration:      execution.Duration,
+			})
+		}
+	}
+
+	for _, b := range buckets {
+		b.PassRate = float64(b.Runs-b.Failures) / float64(b.Runs)
+		result.Buckets = append(result.Buckets, *b)
+	}
+	slices.SortFunc(result.Buckets, func(a, b TestReliabilityBucket) int { return a.Start.Compare(b.Start) })
+
+	if result.RunsAnalyzed > 0 {
+		result.PassRate = float64(result.RunsAnalyzed-result.Failures) / float64(result.RunsAnalyzed)
+	}
+	if result.Failures > 0 {
+		result.AverageFailureDuration = totalDuration / float64(result.Failures)
+	}
+
+	return result
+}
+
+func GetTestReliability(testsClient TestsClient, testRunsClient TestRunsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[GetTestReliabilityArgs], scopes []string) {
+	return mcp.NewTool("get_test_reliability",
+			mcp.WithDescription("Get the reliability trend of a test in Buildkite Test Engine across the suite's most recent runs: pass rate per day or week, the most recent failures with their reasons, and the average duration of failing executions. A run counts as a pass unless it reported a failed execution for the test, so runs which skipped the test are counted as passes."),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("test_suite_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("test_id",
+				mcp.Required(),
+			),
+			mcp.WithNumber("window",
+				mcp.Description("Number of recent runs to analyze (default 50, max 200)"),
+				mcp.Min(1),
+				mcp.Max(maxTestReliabilityWindow),
+			),
+			mcp.WithString("bucket",
+				mcp.Description("Time bucket for the pass rate trend"),
+				mcp.Enum(TestReliabilityBucketDay, TestReliabilityBucketWeek),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Get Test Reliability",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args GetTestReliabilityArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.GetTestReliability")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.TestSuiteSlug == "" {
+				return mcp.NewToolResultError("test_suite_slug parameter is required"), nil
+			}
+			if args.TestID == "" {
+				return mcp.NewToolResultError("test_id parameter is required"), nil
+			}
+			if args.Window <= 0 {
+				args.Window = defaultTestReliabilityWindow
+			}
+			args.Window = min(args.Window, maxTestReliabilityWindow)
+			args.Bucket = cmp.Or(args.Bucket, TestReliabilityBucketDay)
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("test_suite_slug", args.TestSuiteSlug),
+				attribute.String("test_id", args.TestID),
+				attribute.Int("window", args.Window),
+				attribute.String("bucket", args.Bucket),
+			)
+
+			test, _, err := testsClient.Get(ctx, args.OrgSlug, args.TestSuiteSlug, args.TestID)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			runs, err := listRecentTestRuns(ctx, testRunsClient, args.OrgSlug, args.TestSuiteSlug, args.Window)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			failures, err := findTestFailures(ctx, testRunsClient, args.OrgSlug, args.TestSuiteSlug, args.TestID, runs)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			result := testReliability(args.TestID, runs, failures, args.Bucket)
+			result.Name = test.Name
+			result.Location = test.Location
+
+			span.SetAttributes(
+				attribute.Int("runs_analyzed", result.RunsAnalyzed),
+				attribute.Int("failures", result.Failures),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_suites"}
+}
@@ -0,0 +1,77 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBucketStart(t *testing.T) {
+	assert := require.New(t)
+
+	// a Wednesday
+	ts := time.Date(2025, 3, 12, 15, 4, 5, 0, time.UTC)
+
+	assert.Equal(time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC), bucketStart(ts, TestReliabilityBucketDay))
+	assert.Equal(time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), bucketStart(ts, TestReliabilityBucketWeek))
+}
+
+func TestGetTestReliability(t *testing.T) {
+	assert := require.New(t)
+
+	day1 := &buildkite.Timestamp{Time: time.Date(2025, 3, 11, 9, 0, 0, 0, time.UTC)}
+	day2 := &buildkite.Timestamp{Time: time.Date(2025, 3, 12, 9, 0, 0, 0, time.UTC)}
+
+	testsClient := &MockTestsClient{
+		GetFunc: func(ctx context.Context, org, slug, testID string) (buildkite.Test, *buildkite.Response, error) {
+			return buildkite.Test{ID: testID, Name: "checkout works"}, &buildkite.Response{}, nil
+		},
+	}
+	testRunsClient := &MockTestRunsClient{
+		ListFunc: func(ctx context.Context, org, slug string, opt *buildkite.TestRunsListOptions) ([]buildkite.TestRun, *buildkite.Response, error) {
+			return []buildkite.TestRun{
+				{ID: "run-4", CreatedAt: day2},
+				{ID: "run-3", CreatedAt: day2},
+				{ID: "run-2", CreatedAt: day1},
+				{ID: "run-1", CreatedAt: day1},
+			}, &buildkite.Response{}, nil
+		},
+		GetFailedExecutionsFunc: func(ctx context.Context, org, slug, runID string, opt *buildkite.FailedExecutionsOptions) ([]buildkite.FailedExecution, *buildkite.Response, error) {
+			switch runID {
+			case "run-4":
+				return []buildkite.FailedExecution{{ExecutionID: "e4", TestID: "test-1", Duration: 2}}, &buildkite.Response{}, nil
+			case "run-1":
+				return []buildkite.FailedExecution{{ExecutionID: "other", TestID: "test-2"}, {ExecutionID: "e1", TestID: "test-1", Duration: 4}}, &buildkite.Response{}, nil
+			}
+			return nil, &buildkite.Response{}, nil
+		},
+	}
+
+	_, handler, scopes := GetTestReliability(testsClient, testRunsClient)
+	assert.Equal([]string{"read_suites"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, GetTestReliabilityArgs{
+		OrgSlug:       "org",
+		TestSuiteSlug: "suite",
+		TestID:        "test-1",
+	})
+	assert.NoError(err)
+
+	var reliability TestReliability
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &reliability))
+	assert.Equal("checkout works", reliability.Name)
+	assert.Equal(4, reliability.RunsAnalyzed)
+	assert.Equal(2, reliability.Failures)
+	assert.Equal(0.5, reliability.PassRate)
+	assert.Equal(3.0, reliability.AverageFailureDuration)
+	assert.Len(reliability.Buckets, 2)
+	assert.Equal(day1.Time.Truncate(24*time.Hour), reliability.Buckets[0].Start)
+	assert.Equal(2, reliability.Buckets[0].Runs)
+	assert.Equal(0.5, reliability.Buckets[1].PassRate)
+	assert.Equal([]string{"e4", "e1"}, []string{reliability.RecentFailures[0].ExecutionID, reliability.RecentFailures[1].ExecutionID})
+}
@@ -0,0 +1,220 @@
+package buildkite
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// maxPreviousRunSearch bounds how far back the previous run on the branch is looked for
+const maxPreviousRunSearch = 200
+
+type DiffTestRunsArgs struct {
+	OrgSlug       string `json:"org_slug"`
+	TestSuiteSlug string `json:"test_suite_slug"`
+	RunID         string `json:"run_id"`
+	BaseRunID     string `json:"base_run_id,omitempty"`
+}
+
+// TestRunRef identifies a test run being compared
+type TestRunRef struct {
+	ID        string               `json:"id"`
+	Branch    string               `json:"branch,omitempty"`
+	CommitSHA string               `json:"commit_sha,omitempty"`
+	CreatedAt *buildkite.Timestamp `json:"created_at,omitempty"`
+	Failures  int                  `json:"failures"`
+}
+
+// DiffedTest is a test whose outcome is compared between two runs
+type DiffedTest struct {
+	TestID        string `json:"test_id"`
+	Name          string `json:"name"`
+	Location      string `json:"location,omitempty"`
+	FailureReason string `json:"failure_reason,omitempty"`
+}
+
+type TestRunDiff struct {
+	Run          TestRunRef   `json:"run"`
+	Base         TestRunRef   `json:"base"`
+	NewlyFailing []DiffedTest `json:"newly_failing"`
+	StillFailing []DiffedTest `json:"still_failing"`
+	NewlyFixed   []DiffedTest `json:"newly_fixed"`
+	Note         string       `json:"note,omitempty"`
+}
+
+func newTestRunRef(run buildkite.TestRun, failures int) TestRunRef {
+	return TestRunRef{
+		ID:        run.ID,
+		Branch:    run.Branch,
+		CommitSHA: run.CommitSHA,
+		CreatedAt: run.CreatedAt,
+		Failures:  failures,
+	}
+}
+
+func newDiffedTest(execution buildkite.FailedExecution) DiffedTest {
+	return DiffedTest{
+		TestID:        execution.TestID,
+		Name:          execution.TestName,
+		Location:      execution.Location,
+		FailureReason: execution.FailureReason,
+	}
+}
+
+// diffFailedExecutions categorises the failures of a run against those of a base run
+func diffFailedExecutions(run, base []buildkite.FailedExecution) (newlyFailing, stillFailing, newlyFixed []DiffedTest) {
+	newlyFailing, stillFailing, newlyFixed = []DiffedTest{}, []DiffedTest{}, []DiffedTest{}
+
+	failing := map[string]bool{}
+	baseFailing := map[string]bool{}
+	for _, execution := range base {
+		baseFailing[execution.TestID] = true
+	}
+
+	for _, execution := range run {
+		// a test retried within the run reports each failed attempt
+		if failing[execution.TestID] {
+			continue
+		}
+		failing[execution.TestID] = true
+
+		if baseFailing[execution.TestID] {
+			stillFailing = append(stillFailing, newDiffedTest(execution))
+		} else {
+			newlyFailing = append(newlyFailing, newDiffedTest(execution))
+		}
+	}
+
+	for _, execution := range base {
+		if failing[execution.TestID] {
+			continue
+		}
+		failing[execution.TestID] = true
+		newlyFixed = append(newlyFixed, newDiffedTest(execution))
+	}
+
+	for _, tests := range [][]DiffedTest{newlyFailing, stillFailing, newlyFixed} {
+		slices.SortFunc(tests, func(a, b DiffedTest) int { return cmp.Compare(a.Name, b.Name) })
+	}
+
+	return newlyFailing, stillFailing, newlyFixed
+}
+
+// previousBranchRun finds the run on the same branch which was created before the given run
+func previousBranchRun(ctx context.Context, client TestRunsClient, org, suite string, run buildkite.TestRun) (*buildkite.TestRun, error) {
+	runs, err := listRecentTestRuns(ctx, client, org, suite, maxPreviousRunSearch)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, candidate := range runs {
+		if candidate.ID == run.ID || candidate.Branch != run.Branch {
+			continue
+		}
+		if run.CreatedAt != nil && candidate.CreatedAt != nil && !candidate.CreatedAt.Before(run.CreatedAt.Time) {
+			continue
+		}
+		return &runs[i], nil
+	}
+
+	return nil, nil
+}
+
+func DiffTestRuns(client TestRunsClient) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[DiffTestRunsArgs], scopes []string) {
+	return mcp.NewTool("diff_test_runs",
+			mcp.WithDescription("Compare the failed tests of a Test Engine run with a base run, categorising them as newly failing, still failing, or newly fixed. When no base run is given the previous run on the same branch is used. Use this to separate what a change broke from pre-existing breakage."),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("test_suite_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("run_id",
+				mcp.Required(),
+				mcp.Description("The run to check"),
+			),
+			mcp.WithString("base_run_id",
+				mcp.Description("The run to compare against, defaults to the previous run on the same branch"),
+			),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Diff Test Runs",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args DiffTestRunsArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.DiffTestRuns")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.TestSuiteSlug == "" {
+				return mcp.NewToolResultError("test_suite_slug parameter is required"), nil
+			}
+			if args.RunID == "" {
+				return mcp.NewToolResultError("run_id parameter is required"), nil
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("test_suite_slug", args.TestSuiteSlug),
+				attribute.String("run_id", args.RunID),
+				attribute.String("base_run_id", args.BaseRunID),
+			)
+
+			run, _, err := client.Get(ctx, args.OrgSlug, args.TestSuiteSlug, args.RunID)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			var base buildkite.TestRun
+			if args.BaseRunID != "" {
+				base, _, err = client.Get(ctx, args.OrgSlug, args.TestSuiteSlug, args.BaseRunID)
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+			} else {
+				previous, err := previousBranchRun(ctx, client, args.OrgSlug, args.TestSuiteSlug, run)
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				if previous == nil {
+					return mcp.NewToolResultError(fmt.Sprintf("no previous run found on branch %q in the last %d runs, pass base_run_id", run.Branch, maxPreviousRunSearch)), nil
+				}
+				base = *previous
+			}
+
+			runFailures, _, err := client.GetFailedExecutions(ctx, args.OrgSlug, args.TestSuiteSlug, run.ID, &buildkite.FailedExecutionsOptions{})
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			baseFailures, _, err := client.GetFailedExecutions(ctx, args.OrgSlug, args.TestSuiteSlug, base.ID, &buildkite.FailedExecutionsOptions{})
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			result := TestRunDiff{
+				Run:  newTestRunRef(run, len(runFailures)),
+				Base: newTestRunRef(base, len(baseFailures)),
+			}
+			result.NewlyFailing, result.StillFailing, result.NewlyFixed = diffFailedExecutions(runFailures, baseFailures)
+			if len(result.NewlyFixed) > 0 {
+				result.Note = "newly fixed tests include tests which were skipped or not run in the checked run"
+			}
+
+			span.SetAttributes(
+				attribute.String("base_run_id", base.ID),
+				attribute.Int("newly_failing", len(result.NewlyFailing)),
+				attribute.Int("still_failing", len(result.StillFailing)),
+				attribute.Int("newly_fixed", len(result.NewlyFixed)),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_suites"}
+}
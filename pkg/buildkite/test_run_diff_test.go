@@ -0,0 +1,86 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffTestRuns(t *testing.T) {
+	assert := require.New(t)
+
+	at := func(hour int) *buildkite.Timestamp {
+		return &buildkite.Timestamp{Time: time.Date(2025, 3, 12, hour, 0, 0, 0, time.UTC)}
+	}
+	runs := []buildkite.TestRun{
+		{ID: "run-4", Branch: "feature", CreatedAt: at(12)},
+		{ID: "run-3", Branch: "main", CreatedAt: at(11)},
+		{ID: "run-2", Branch: "feature", CreatedAt: at(10)},
+		{ID: "run-1", Branch: "feature", CreatedAt: at(9)},
+	}
+
+	client := &MockTestRunsClient{
+		GetFunc: func(ctx context.Context, org, slug, runID string) (buildkite.TestRun, *buildkite.Response, error) {
+			for _, run := range runs {
+				if run.ID == runID {
+					return run, &buildkite.Response{}, nil
+				}
+			}
+			return buildkite.TestRun{}, &buildkite.Response{}, nil
+		},
+		ListFunc: func(ctx context.Context, org, slug string, opt *buildkite.TestRunsListOptions) ([]buildkite.TestRun, *buildkite.Response, error) {
+			return runs, &buildkite.Response{}, nil
+		},
+		GetFailedExecutionsFunc: func(ctx context.Context, org, slug, runID string, opt *buildkite.FailedExecutionsOptions) ([]buildkite.FailedExecution, *buildkite.Response, error) {
+			switch runID {
+			case "run-4":
+				return []buildkite.FailedExecution{
+					{TestID: "t-new", TestName: "new"},
+					{TestID: "t-still", TestName: "still"},
+					{TestID: "t-still", TestName: "still"},
+				}, &buildkite.Response{}, nil
+			case "run-2":
+				return []buildkite.FailedExecution{
+					{TestID: "t-still", TestName: "still"},
+					{TestID: "t-fixed", TestName: "fixed"},
+				}, &buildkite.Response{}, nil
+			}
+			return nil, &buildkite.Response{}, nil
+		},
+	}
+
+	_, handler, scopes := DiffTestRuns(client)
+	assert.Equal([]string{"read_suites"}, scopes)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, DiffTestRunsArgs{
+		OrgSlug:       "org",
+		TestSuiteSlug: "suite",
+		RunID:         "run-4",
+	})
+	assert.NoError(err)
+
+	var diff TestRunDiff
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &diff))
+	assert.Equal("run-2", diff.Base.ID)
+	assert.Equal([]DiffedTest{{TestID: "t-new", Name: "new"}}, diff.NewlyFailing)
+	assert.Equal([]DiffedTest{{TestID: "t-still", Name: "still"}}, diff.StillFailing)
+	assert.Equal([]DiffedTest{{TestID: "t-fixed", Name: "fixed"}}, diff.NewlyFixed)
+
+	// an explicit base run is used as given
+	result, err = handler(context.Background(), mcp.CallToolRequest{}, DiffTestRunsArgs{
+		OrgSlug:       "org",
+		TestSuiteSlug: "suite",
+		RunID:         "run-4",
+		BaseRunID:     "run-1",
+	})
+	assert.NoError(err)
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &diff))
+	assert.Equal("run-1", diff.Base.ID)
+	assert.Len(diff.NewlyFailing, 2)
+	assert.Empty(diff.StillFailing)
+}
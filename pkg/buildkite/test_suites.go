@@ -0,0 +1,90 @@
+package buildkite
+
+import (
+	"context"
+	"strings"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type TestSuitesClient interface {
+	List(ctx context.Context, org string, opt *buildkite.TestSuiteListOptions) ([]buildkite.TestSuite, *buildkite.Response, error)
+}
+
+func ListTestSuites(client TestSuitesClient) (tool mcp.Tool, handler server.ToolHandlerFunc, scopes []string) {
+	return mcp.NewTool("list_test_suites",
+			mcp.WithDescription("List the Test Engine test suites of an organization with their slug, UUID, name and default branch, optionally filtered by name or slug. Use it to find the test_suite_slug the Test Engine tools take rather than asking for the suite's UUID"),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("name",
+				mcp.Description("Only list suites whose name or slug contains this, ignoring case"),
+			),
+			withPagination(),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "List Test Suites",
+				ReadOnlyHint: mcp.ToBoolPtr(true),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.ListTestSuites")
+			defer span.End()
+
+			orgSlug, err := request.RequireString("org_slug")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			name := strings.ToLower(request.GetString("name", ""))
+
+			paginationParams, err := optionalPaginationParams(request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			// a filtered page needs more than one suite to search
+			if name != "" && request.GetInt("perPage", 0) == 0 {
+				paginationParams.PerPage = 100
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", orgSlug),
+				attribute.String("name", name),
+				attribute.Int("page", paginationParams.Page),
+				attribute.Int("per_page", paginationParams.PerPage),
+			)
+
+			suites, resp, err := client.List(ctx, orgSlug, &buildkite.TestSuiteListOptions{
+				ListOptions: paginationParams,
+			})
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// the API can't filter suites, so the page is filtered here
+			if name != "" {
+				filtered := suites[:0]
+				for _, suite := range suites {
+					if strings.Contains(strings.ToLower(suite.Name), name) || strings.Contains(strings.ToLower(suite.Slug), name) {
+						filtered = append(filtered, suite)
+					}
+				}
+				suites = filtered
+			}
+
+			result := PaginatedResult[buildkite.TestSuite]{
+				Items:   suites,
+				Headers: map[string]string{},
+			}
+			if resp != nil {
+				result.Headers["Link"] = resp.Header.Get("Link")
+			}
+
+			span.SetAttributes(attribute.Int("item_count", len(suites)))
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_suites"}
+}
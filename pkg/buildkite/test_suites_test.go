@@ -0,0 +1,67 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/stretchr/testify/require"
+)
+
+type MockTestSuitesClient struct {
+	ListFunc func(ctx context.Context, org string, opt *buildkite.TestSuiteListOptions) ([]buildkite.TestSuite, *buildkite.Response, error)
+}
+
+func (m *MockTestSuitesClient) List(ctx context.Context, org string, opt *buildkite.TestSuiteListOptions) ([]buildkite.TestSuite, *buildkite.Response, error) {
+	if m.ListFunc != nil {
+		return m.ListFunc(ctx, org, opt)
+	}
+	return nil, nil, nil
+}
+
+var _ TestSuitesClient = (*MockTestSuitesClient)(nil)
+
+func TestListTestSuites(t *testing.T) {
+	assert := require.New(t)
+
+	var perPage int
+	client := &MockTestSuitesClient{
+		ListFunc: func(ctx context.Context, org string, opt *buildkite.TestSuiteListOptions) ([]buildkite.TestSuite, *buildkite.Response, error) {
+			assert.Equal("org", org)
+			perPage = opt.PerPage
+			return []buildkite.TestSuite{
+				{ID: "1", Slug: "frontend-jest", Name: "Frontend Jest"},
+				{ID: "2", Slug: "backend-rspec", Name: "Backend RSpec"},
+			}, &buildkite.Response{Response: &http.Response{
+				Header: http.Header{"Link": []string{`<https://api.buildkite.com/v2/analytics/organizations/org/suites?page=2>; rel="next"`}},
+			}}, nil
+		},
+	}
+
+	tool, handler, scopes := ListTestSuites(client)
+	assert.Equal("list_test_suites", tool.Name)
+	assert.True(*tool.Annotations.ReadOnlyHint)
+	assert.Equal([]string{"read_suites"}, scopes)
+
+	result, err := handler(context.Background(), createMCPRequest(t, map[string]any{"org_slug": "org", "perPage": 2}))
+	assert.NoError(err)
+	var suites PaginatedResult[buildkite.TestSuite]
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &suites))
+	assert.Len(suites.Items, 2)
+	assert.Contains(suites.Headers["Link"], "page=2")
+	assert.Equal(2, perPage)
+
+	// filtering by name searches a full page
+	result, err = handler(context.Background(), createMCPRequest(t, map[string]any{"org_slug": "org", "name": "RSPEC"}))
+	assert.NoError(err)
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &suites))
+	assert.Len(suites.Items, 1)
+	assert.Equal("backend-rspec", suites.Items[0].Slug)
+	assert.Equal(100, perPage)
+
+	result, err = handler(context.Background(), createMCPRequest(t, map[string]any{}))
+	assert.NoError(err)
+	assert.True(result.IsError)
+}
@@ -0,0 +1,74 @@
+package buildkite
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// displayTimeFormat is how timestamps are displayed in a timezone, naming the zone for clarity
+const displayTimeFormat = "2006-01-02 15:04:05 MST"
+
+type displayTimezoneKey struct{}
+
+// WithDisplayTimezone sets the timezone timestamps are displayed in for tool calls which don't ask
+// for one
+func WithDisplayTimezone(ctx context.Context, loc *time.Location) context.Context {
+	return context.WithValue(ctx, displayTimezoneKey{}, loc)
+}
+
+// displayLocation returns the timezone a tool call asked for, falling back to the server's display
+// timezone, or nil when timestamps are left as the API returns them
+func displayLocation(ctx context.Context, timezone string) (*time.Location, error) {
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone %q, expected an IANA timezone such as 'Australia/Melbourne'", timezone)
+		}
+		return loc, nil
+	}
+
+	loc, _ := ctx.Value(displayTimezoneKey{}).(*time.Location)
+	return loc, nil
+}
+
+// withTimezone is the timezone parameter of tools displaying timestamps
+func withTimezone() mcp.ToolOption {
+	return mcp.WithString("timezone",
+		mcp.Description("IANA timezone to display timestamps in, e.g. 'America/New_York', noting how long ago each was. Defaults to the server's display timezone, if any"),
+	)
+}
+
+// relativeTime describes how long before now t was, such as "2h ago", or "in 5m" for future times
+func relativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	future := d < 0
+	if future {
+		d = -d
+	}
+
+	var amount string
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		amount = fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		amount = fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		amount = fmt.Sprintf("%dd", int(math.Floor(d.Hours()/24)))
+	}
+
+	if future {
+		return "in " + amount
+	}
+	return amount + " ago"
+}
+
+// displayTime formats t in the timezone, followed by how long before now it was
+func displayTime(t time.Time, loc *time.Location, now time.Time) string {
+	return fmt.Sprintf("%s (%s)", t.In(loc).Format(displayTimeFormat), relativeTime(t, now))
+}
@@ -0,0 +1,104 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelativeTime(t *testing.T) {
+	assert := require.New(t)
+
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	assert.Equal("just now", relativeTime(now.Add(-30*time.Second), now))
+	assert.Equal("5m ago", relativeTime(now.Add(-5*time.Minute), now))
+	assert.Equal("2h ago", relativeTime(now.Add(-2*time.Hour-10*time.Minute), now))
+	assert.Equal("3d ago", relativeTime(now.Add(-75*time.Hour), now))
+	assert.Equal("in 10m", relativeTime(now.Add(10*time.Minute), now))
+}
+
+func TestDisplayLocation(t *testing.T) {
+	assert := require.New(t)
+
+	loc, err := displayLocation(context.Background(), "")
+	assert.NoError(err)
+	assert.Nil(loc)
+
+	melbourne, err := time.LoadLocation("Australia/Melbourne")
+	assert.NoError(err)
+	ctx := WithDisplayTimezone(context.Background(), melbourne)
+
+	loc, err = displayLocation(ctx, "")
+	assert.NoError(err)
+	assert.Equal(melbourne, loc)
+
+	// a call's timezone overrides the server's
+	loc, err = displayLocation(ctx, "America/New_York")
+	assert.NoError(err)
+	assert.Equal("America/New_York", loc.String())
+
+	_, err = displayLocation(ctx, "Mars/Olympus_Mons")
+	assert.ErrorContains(err, "invalid timezone")
+}
+
+func TestGetBuildTimezone(t *testing.T) {
+	assert := require.New(t)
+
+	createdAt := time.Now().Add(-2 * time.Hour).UTC().Truncate(time.Second)
+	client := &MockBuildsClient{
+		GetFunc: func(ctx context.Context, org string, pipeline string, id string, opt *buildkite.BuildGetOptions) (buildkite.Build, *buildkite.Response, error) {
+			return buildkite.Build{ID: "123", Number: 1, State: "passed", CreatedAt: buildkite.NewTimestamp(createdAt)}, &buildkite.Response{}, nil
+		},
+	}
+
+	_, typedHandler, _ := GetBuild(client, nil, nil, nil)
+	handler := mcp.NewTypedToolHandler(typedHandler)
+
+	result, err := handler(context.Background(), createMCPRequest(t, map[string]any{
+		"org_slug":      "org",
+		"pipeline_slug": "pipeline",
+		"build_number":  "1",
+		"detail_level":  "summary",
+		"timezone":      "Asia/Kolkata",
+	}))
+	assert.NoError(err)
+
+	var summary BuildSummary
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, result).Text), &summary))
+	assert.Equal("2h ago", summary.CreatedAgo)
+	assert.True(createdAt.Equal(summary.CreatedAt.Time))
+	_, offset := summary.CreatedAt.Zone()
+	assert.Equal(5*60*60+30*60, offset)
+
+	// without a timezone the timestamps are left as returned by the API
+	result, err = handler(context.Background(), createMCPRequest(t, map[string]any{
+		"org_slug":      "org",
+		"pipeline_slug": "pipeline",
+		"build_number":  "1",
+		"detail_level":  "summary",
+	}))
+	assert.NoError(err)
+	assert.NotContains(getTextResult(t, result).Text, "created_ago")
+}
+
+func TestFormatLogEntriesTimezone(t *testing.T) {
+	assert := require.New(t)
+
+	loc, err := time.LoadLocation("Europe/London")
+	assert.NoError(err)
+
+	ts := time.Date(2025, 7, 1, 9, 30, 0, 0, time.UTC)
+	entries, _ := formatLogEntries([]buildkitelogs.ParquetLogEntry{
+		{Content: "hello", RowNumber: 1, Timestamp: ts.UnixMilli(), Flags: buildkitelogs.LogFlags(1 << buildkitelogs.HasTimestamp)},
+	}, nil, loc)
+
+	terse := entries.([]TerseLogEntry)
+	assert.Equal(ts.UnixMilli(), terse[0].TS)
+	assert.Contains(terse[0].T, "2025-07-01 10:30:00 BST (")
+}
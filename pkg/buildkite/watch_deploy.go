@@ -0,0 +1,338 @@
+package buildkite
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+	"github.com/buildkite/buildkite-mcp-server/pkg/cache"
+	"github.com/buildkite/buildkite-mcp-server/pkg/redact"
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type WatchDeployArgs struct {
+	OrgSlug      string `json:"org_slug"`
+	PipelineSlug string `json:"pipeline_slug"`
+	BuildNumber  string `json:"build_number"`
+	Commit       string `json:"commit"`
+	Branch       string `json:"branch"`
+	Message      string `json:"message"`
+	Job          string `json:"job"`
+	Tail         int    `json:"tail"`
+	WaitTimeout  int    `json:"wait_timeout"`
+	PollInterval int    `json:"poll_interval"`
+	Timezone     string `json:"timezone"`
+}
+
+// WatchedJob is the deploy job watched by watch_deploy
+type WatchedJob struct {
+	ID         string `json:"id"`
+	Label      string `json:"label,omitempty"`
+	StepKey    string `json:"step_key,omitempty"`
+	State      string `json:"state"`
+	ExitStatus *int   `json:"exit_status,omitempty"`
+	WebURL     string `json:"web_url,omitempty"`
+}
+
+// WatchDeployResult is the state of the build and its deploy job when the job finished, the build
+// finished without running it, or watching timed out
+type WatchDeployResult struct {
+	Build BuildSummary `json:"build"`
+	// Triggered is whether the build was created to deploy, rather than attached to
+	Triggered bool        `json:"triggered"`
+	Job       *WatchedJob `json:"job,omitempty"`
+	// Finished is false when watching timed out before the job reached a terminal state
+	Finished bool `json:"finished"`
+	// Entries are the last entries of the job's log
+	Entries    any    `json:"entries,omitempty"`
+	TotalRows  int64  `json:"total_rows"`
+	Redactions int    `json:"redactions,omitempty"`
+	Note       string `json:"note,omitempty"`
+}
+
+// findJob returns the job whose step key is the name, or failing that whose label is or contains it
+func findJob(jobs []buildkite.Job, name string) (buildkite.Job, bool) {
+	for _, job := range jobs {
+		if job.StepKey == name {
+			return job, true
+		}
+	}
+	for _, job := range jobs {
+		if strings.EqualFold(job.Label, name) {
+			return job, true
+		}
+	}
+	for _, job := range jobs {
+		if job.Label != "" && strings.Contains(strings.ToLower(job.Label), strings.ToLower(name)) {
+			return job, true
+		}
+	}
+	return buildkite.Job{}, false
+}
+
+func watchedJob(job buildkite.Job) *WatchedJob {
+	return &WatchedJob{
+		ID:         job.ID,
+		Label:      job.Label,
+		StepKey:    job.StepKey,
+		State:      job.State,
+		ExitStatus: job.ExitStatus,
+		WebURL:     job.WebURL,
+	}
+}
+
+// WatchDeploy implements the watch_deploy MCP tool
+func WatchDeploy(client BuildsClient, logsClient BuildkiteLogsClient, redactor *redact.Redactor) (tool mcp.Tool, handler mcp.TypedToolHandlerFunc[WatchDeployArgs], scopes []string) {
+	return mcp.NewTool("watch_deploy",
+			mcp.WithDescription("Trigger a build, or attach to one with build_number, then wait for the named deploy job to start and follow its log until it finishes, returning the final state of the build and job with the last entries of its log. Progress is sent as notifications when the request has a progress token, only when the job's state changes or its log grows. Use it instead of create_build, wait_for_build and tail_logs when deploying. The json format: {ts: timestamp_ms, t: timestamp in the display timezone, c: content, rn: row_number}."),
+			mcp.WithString("org_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("pipeline_slug",
+				mcp.Required(),
+			),
+			mcp.WithString("job",
+				mcp.Required(),
+				mcp.Description("The step key of the deploy job, or its label or part of it, such as 'deploy-production' or 'Deploy'"),
+			),
+			mcp.WithString("build_number",
+				mcp.Description("Attach to this build rather than triggering one"),
+			),
+			mcp.WithString("commit",
+				mcp.Description("The commit SHA to build, required when triggering a build"),
+			),
+			mcp.WithString("branch",
+				mcp.Description("The branch to build, required when triggering a build"),
+			),
+			mcp.WithString("message",
+				mcp.Description("The message of the triggered build (default: 'Deploy' and the commit)"),
+			),
+			mcp.WithNumber("tail",
+				mcp.Description("Number of entries from the end of the job's log to return (default: 20)"),
+				mcp.Min(1),
+				mcp.DefaultNumber(20),
+			),
+			mcp.WithNumber("wait_timeout",
+				mcp.Description("Timeout in seconds to watch the deploy for (default: 1800)"),
+				mcp.DefaultNumber(1800),
+			),
+			mcp.WithNumber("poll_interval",
+				mcp.Description("Seconds between checks of the build and the job's log (default: 10)"),
+				mcp.Min(1),
+				mcp.DefaultNumber(10),
+			),
+			withTimezone(),
+			mcp.WithToolAnnotation(mcp.ToolAnnotation{
+				Title:        "Watch Deploy",
+				ReadOnlyHint: mcp.ToBoolPtr(false),
+			}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest, args WatchDeployArgs) (*mcp.CallToolResult, error) {
+			ctx, span := trace.Start(ctx, "buildkite.WatchDeploy")
+			defer span.End()
+
+			if args.OrgSlug == "" {
+				return mcp.NewToolResultError("org_slug parameter is required"), nil
+			}
+			if args.PipelineSlug == "" {
+				return mcp.NewToolResultError("pipeline_slug parameter is required"), nil
+			}
+			if args.Job == "" {
+				return mcp.NewToolResultError("job parameter is required"), nil
+			}
+			if args.BuildNumber == "" && (args.Commit == "" || args.Branch == "") {
+				return mcp.NewToolResultError("either build_number, or commit and branch to trigger a build, are required"), nil
+			}
+
+			// Set defaults
+			if args.Tail <= 0 {
+				args.Tail = 20
+			}
+			if args.WaitTimeout <= 0 {
+				args.WaitTimeout = 1800
+			}
+			if args.PollInterval <= 0 {
+				args.PollInterval = 10
+			}
+
+			span.SetAttributes(
+				attribute.String("org_slug", args.OrgSlug),
+				attribute.String("pipeline_slug", args.PipelineSlug),
+				attribute.String("build_number", args.BuildNumber),
+				attribute.String("job", args.Job),
+				attribute.Int("wait_timeout", args.WaitTimeout),
+				attribute.Int("poll_interval", args.PollInterval),
+			)
+
+			loc, err := displayLocation(ctx, args.Timezone)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			var result WatchDeployResult
+			if args.BuildNumber == "" {
+				message := args.Message
+				if message == "" {
+					message = "Deploy " + args.Commit
+				}
+
+				build, _, err := client.Create(ctx, args.OrgSlug, args.PipelineSlug, buildkite.CreateBuild{
+					Commit:  args.Commit,
+					Branch:  args.Branch,
+					Message: message,
+				})
+				if err != nil {
+					var errResp *buildkite.ErrorResponse
+					if errors.As(err, &errResp) && errResp.RawBody != nil {
+						return mcp.NewToolResultError(string(errResp.RawBody)), nil
+					}
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+
+				args.BuildNumber = strconv.Itoa(build.Number)
+				result.Triggered = true
+				span.SetAttributes(attribute.String("build_number", args.BuildNumber))
+			}
+
+			var notify func(build buildkite.Build, job *WatchedJob, entries []buildkitelogs.ParquetLogEntry, totalRows int64)
+			if mcpServer := server.ServerFromContext(ctx); mcpServer != nil && request.Params.Meta != nil && request.Params.Meta.ProgressToken != nil {
+				progressToken := request.Params.Meta.ProgressToken
+				notify = func(build buildkite.Build, job *WatchedJob, entries []buildkitelogs.ParquetLogEntry, totalRows int64) {
+					// only the tail of a burst of output is sent, to keep notifications small
+					entries = entries[max(len(entries)-args.Tail, 0):]
+					formattedEntries := formatProgressEntries(ctx, entries, redactor, loc)
+
+					message := fmt.Sprintf("build %d is %s, waiting for %q to start", build.Number, build.State, args.Job)
+					if job != nil {
+						message = fmt.Sprintf("build %d is %s, %s is %s", build.Number, build.State, cmp.Or(job.Label, job.StepKey), job.State)
+					}
+
+					// progress is best effort, so a client which has gone away doesn't stop the watch
+					_ = mcpServer.SendNotificationToClient(ctx, "notifications/progress", map[string]any{
+						"progressToken": progressToken,
+						"progress":      totalRows,
+						"message":       message,
+						"build_number":  build.Number,
+						"build_state":   build.State,
+						"job":           job,
+						"entries":       formattedEntries,
+					})
+				}
+			}
+
+			logParams := JobLogsBaseParams{
+				OrgSlug:      args.OrgSlug,
+				PipelineSlug: args.PipelineSlug,
+				BuildNumber:  args.BuildNumber,
+				ForceRefresh: true,
+			}
+
+			// each poll checks the build's state with the API rather than reading a cached response
+			ctx, cancel := context.WithTimeout(cache.Revalidate(ctx), time.Duration(args.WaitTimeout)*time.Second)
+			defer cancel()
+
+			ticker := time.NewTicker(time.Duration(args.PollInterval) * time.Second)
+			defer ticker.Stop()
+
+			var build buildkite.Build
+			var job *WatchedJob
+			var started bool
+			var nextRow int64
+			lastState := ""
+
+		WATCHLOOP:
+			for {
+				// the job's state is checked before reading its log, so the read after it finishes has every entry
+				build, _, err = client.Get(ctx, args.OrgSlug, args.PipelineSlug, args.BuildNumber, nil)
+				if err != nil {
+					if ctx.Err() != nil {
+						break WATCHLOOP
+					}
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+
+				found, ok := findJob(build.Jobs, args.Job)
+				if ok {
+					job = watchedJob(found)
+					started = found.StartedAt != nil
+					logParams.JobID = found.ID
+				}
+
+				var entries []buildkitelogs.ParquetLogEntry
+				if started {
+					var totalRows int64
+					entries, totalRows, err = readLogRows(ctx, logsClient, logParams, nextRow)
+					if err != nil {
+						if ctx.Err() != nil {
+							break WATCHLOOP
+						}
+						return mcp.NewToolResultError(fmt.Sprintf("Failed to read logs: %v", err)), nil
+					}
+					nextRow = totalRows
+				}
+
+				state := build.State
+				if job != nil {
+					state += "/" + job.State
+				}
+				if notify != nil && (state != lastState || len(entries) > 0) {
+					notify(build, job, entries, nextRow)
+				}
+				lastState = state
+
+				if (job != nil && isTerminalJobState(job.State)) || isTerminalState(build.State) {
+					break WATCHLOOP
+				}
+
+				select {
+				case <-ctx.Done():
+					log.Ctx(ctx).Info().Str("build_number", args.BuildNumber).Str("job", args.Job).Msg("Timed out watching deploy")
+					break WATCHLOOP
+				case <-ticker.C:
+				}
+			}
+
+			result.Build = localizeBuild(summarizeBuild(build), loc, time.Now())
+			result.Job = job
+
+			switch {
+			case job == nil && isTerminalState(build.State):
+				result.Note = fmt.Sprintf("the build finished without a job matching %q", args.Job)
+			case job == nil:
+				result.Note = fmt.Sprintf("no job matching %q has been added to the build yet", args.Job)
+			default:
+				result.Finished = isTerminalJobState(job.State)
+			}
+
+			if started {
+				// the last entries come from the copy cached by the last poll, read without the timed out context
+				tailParams := logParams
+				tailParams.ForceRefresh = false
+
+				entries, totalRows, err := readLogRows(context.WithoutCancel(ctx), logsClient, tailParams, nextRow-int64(args.Tail))
+				if err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("Failed to read logs: %v", err)), nil
+				}
+				result.Entries, result.Redactions = formatLogEntries(entries, redactor, loc)
+				result.TotalRows = totalRows
+			}
+
+			span.SetAttributes(
+				attribute.String("build_state", build.State),
+				attribute.Bool("triggered", result.Triggered),
+				attribute.Bool("finished", result.Finished),
+			)
+
+			return mcpTextResult(span, &result)
+		}, []string{"read_builds", "write_builds", "read_build_logs"}
+}
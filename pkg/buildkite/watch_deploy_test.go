@@ -0,0 +1,134 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/redact"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindJob(t *testing.T) {
+	assert := require.New(t)
+
+	jobs := []buildkite.Job{
+		{ID: "1", Label: ":rocket: Deploy staging", StepKey: "deploy-staging"},
+		{ID: "2", Label: "Deploy", StepKey: "deploy-production"},
+	}
+
+	job, ok := findJob(jobs, "deploy-production")
+	assert.True(ok)
+	assert.Equal("2", job.ID)
+
+	// an exact label is preferred to one containing the name
+	job, ok = findJob(jobs, "deploy")
+	assert.True(ok)
+	assert.Equal("2", job.ID)
+
+	job, ok = findJob(jobs, "staging")
+	assert.True(ok)
+	assert.Equal("1", job.ID)
+
+	_, ok = findJob(jobs, "rollback")
+	assert.False(ok)
+}
+
+func TestWatchDeploy(t *testing.T) {
+	assert := require.New(t)
+
+	var created buildkite.CreateBuild
+	exitStatus := 0
+	polls := 0
+	buildsClient := &MockBuildsClient{
+		CreateFunc: func(ctx context.Context, org string, pipeline string, b buildkite.CreateBuild) (buildkite.Build, *buildkite.Response, error) {
+			created = b
+			return buildkite.Build{Number: 7, State: "scheduled"}, nil, nil
+		},
+		GetFunc: func(ctx context.Context, org string, pipeline string, id string, opt *buildkite.BuildGetOptions) (buildkite.Build, *buildkite.Response, error) {
+			polls++
+			assert.Equal("7", id)
+
+			// the deploy job is added once the tests pass, then runs and passes
+			build := buildkite.Build{Number: 7, State: "running", Jobs: []buildkite.Job{{ID: "test", StepKey: "test", State: "running"}}}
+			switch polls {
+			case 1:
+			case 2:
+				build.Jobs = append(build.Jobs, buildkite.Job{ID: "deploy", Label: ":rocket: Deploy", State: "running", StartedAt: buildkite.NewTimestamp(time.Now())})
+			default:
+				build.State = "passed"
+				build.Jobs = append(build.Jobs, buildkite.Job{ID: "deploy", Label: ":rocket: Deploy", State: "passed", ExitStatus: &exitStatus, StartedAt: buildkite.NewTimestamp(time.Now())})
+			}
+			return build, nil, nil
+		},
+	}
+
+	partial := writeTestLog(t, "deploying")
+	complete := writeTestLog(t, "deploying", "rollout complete")
+	logsClient := &MockBuildkiteLogsClient{
+		DownloadAndCacheFunc: func(ctx context.Context, org, pipeline, build, job string, cacheTTL time.Duration, forceRefresh bool) (string, error) {
+			assert.Equal("deploy", job)
+			if polls > 2 {
+				return complete, nil
+			}
+			return partial, nil
+		},
+	}
+
+	redactor, err := redact.New(nil)
+	assert.NoError(err)
+
+	tool, handler, scopes := WatchDeploy(buildsClient, logsClient, redactor)
+	assert.False(*tool.Annotations.ReadOnlyHint)
+	assert.Equal([]string{"read_builds", "write_builds", "read_build_logs"}, scopes)
+
+	// the tool is called through a server, which notifies the session of progress
+	mcpServer := server.NewMCPServer("test", "1.0")
+	mcpServer.AddTool(tool, mcp.NewTypedToolHandler(handler))
+	session := &notificationSession{notifications: make(chan mcp.JSONRPCNotification, 10)}
+	ctx := mcpServer.WithContext(context.Background(), session)
+
+	response := mcpServer.HandleMessage(ctx, json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{
+		"name":"watch_deploy",
+		"arguments":{"org_slug":"org","pipeline_slug":"pipeline","commit":"abc123","branch":"main","job":"deploy","poll_interval":1},
+		"_meta":{"progressToken":"deploy"}
+	}}`))
+	result, ok := response.(mcp.JSONRPCResponse).Result.(mcp.CallToolResult)
+	assert.True(ok)
+
+	var watched WatchDeployResult
+	assert.NoError(json.Unmarshal([]byte(getTextResult(t, &result).Text), &watched))
+	assert.Equal("Deploy abc123", created.Message)
+	assert.True(watched.Triggered)
+	assert.True(watched.Finished)
+	assert.Equal("passed", watched.Build.State)
+	assert.Equal("passed", watched.Job.State)
+	assert.Equal(0, *watched.Job.ExitStatus)
+	assert.Equal(int64(2), watched.TotalRows)
+	assert.Equal(3, polls)
+
+	// a notification for waiting, the job starting, and the job finishing with new entries
+	assert.Len(session.notifications, 3)
+	<-session.notifications
+	started := <-session.notifications
+	assert.Equal("deploy", started.Params.AdditionalFields["progressToken"])
+	assert.Contains(started.Params.AdditionalFields["message"], "running")
+	finished := <-session.notifications
+	entries := finished.Params.AdditionalFields["entries"].([]TerseLogEntry)
+	assert.Len(entries, 1)
+	assert.Equal("rollout complete", entries[0].C)
+}
+
+func TestWatchDeployRequiresBuildOrCommit(t *testing.T) {
+	assert := require.New(t)
+
+	_, handler, _ := WatchDeploy(&MockBuildsClient{}, &MockBuildkiteLogsClient{}, nil)
+	result, err := handler(context.Background(), mcp.CallToolRequest{}, WatchDeployArgs{OrgSlug: "org", PipelineSlug: "pipeline", Job: "deploy", Commit: "abc123"})
+	assert.NoError(err)
+	assert.True(result.IsError)
+	assert.Contains(getTextResult(t, result).Text, "build_number")
+}
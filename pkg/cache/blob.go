@@ -0,0 +1,321 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"gocloud.dev/blob"
+	"gocloud.dev/blob/driver"
+	"gocloud.dev/gcerrors"
+)
+
+// DefaultBlobTTL is how long blobs are kept in a store when the bucket URL doesn't set a ttl
+const DefaultBlobTTL = 24 * time.Hour
+
+var (
+	errNotImplemented = errors.New("cache: not implemented by cache buckets")
+	errBlobExists     = errors.New("cache: blob already exists")
+)
+
+func init() {
+	for _, scheme := range []string{"redis", "rediss"} {
+		blob.DefaultURLMux().RegisterBucket(scheme, &URLOpener{})
+	}
+}
+
+// URLOpener opens buckets stored in Redis from URLs such as redis://localhost:6379/0?ttl=12h,
+// where ttl is how long blobs are kept, defaulting to DefaultBlobTTL
+type URLOpener struct{}
+
+func (*URLOpener) OpenBucketURL(ctx context.Context, u *url.URL) (*blob.Bucket, error) {
+	ttl := DefaultBlobTTL
+
+	storeURL := *u
+	query := storeURL.Query()
+	if value := query.Get("ttl"); value != "" {
+		var err error
+		if ttl, err = time.ParseDuration(value); err != nil {
+			return nil, fmt.Errorf("open bucket %s: invalid ttl: %w", u.Redacted(), err)
+		}
+		query.Del("ttl")
+		storeURL.RawQuery = query.Encode()
+	}
+
+	store, err := Open(storeURL.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return NewBucket(store, ttl), nil
+}
+
+// NewBucket returns a blob bucket storing each blob in the store for ttl. Listing blobs isn't
+// supported, as stores can't list their keys.
+func NewBucket(store Store, ttl time.Duration) *blob.Bucket {
+	return blob.NewBucket(&bucket{store: store, ttl: ttl})
+}
+
+// blobAttributes are stored separately from the content of a blob, so checking whether a blob
+// exists doesn't read its content
+type blobAttributes struct {
+	ContentType string            `json:"content_type,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	CreateTime  time.Time         `json:"create_time"`
+	ModTime     time.Time         `json:"mod_time"`
+	Size        int64             `json:"size"`
+	MD5         []byte            `json:"md5,omitempty"`
+}
+
+type bucket struct {
+	store Store
+	ttl   time.Duration
+}
+
+func attributesKey(key string) string {
+	return "blob:" + key + ":attributes"
+}
+
+func contentKey(key string) string {
+	return "blob:" + key + ":content"
+}
+
+func (b *bucket) readAttributes(ctx context.Context, key string) (*blobAttributes, error) {
+	data, err := b.store.Get(ctx, attributesKey(key))
+	if err != nil {
+		return nil, err
+	}
+
+	var attrs blobAttributes
+	if err := json.Unmarshal(data, &attrs); err != nil {
+		return nil, fmt.Errorf("cache: invalid attributes for blob %q: %w", key, err)
+	}
+	return &attrs, nil
+}
+
+// write stores the content before the attributes, so a blob is never found without its content
+func (b *bucket) write(ctx context.Context, key string, attrs *blobAttributes, content []byte) error {
+	data, err := json.Marshal(attrs)
+	if err != nil {
+		return err
+	}
+
+	if err := b.store.Set(ctx, contentKey(key), content, b.ttl); err != nil {
+		return err
+	}
+	return b.store.Set(ctx, attributesKey(key), data, b.ttl)
+}
+
+func (b *bucket) ErrorCode(err error) gcerrors.ErrorCode {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return gcerrors.NotFound
+	case errors.Is(err, errNotImplemented):
+		return gcerrors.Unimplemented
+	case errors.Is(err, errBlobExists):
+		return gcerrors.FailedPrecondition
+	default:
+		return gcerrors.Unknown
+	}
+}
+
+func (b *bucket) As(i any) bool { return false }
+
+func (b *bucket) ErrorAs(err error, i any) bool { return false }
+
+func (b *bucket) Attributes(ctx context.Context, key string) (*driver.Attributes, error) {
+	attrs, err := b.readAttributes(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &driver.Attributes{
+		ContentType: attrs.ContentType,
+		Metadata:    attrs.Metadata,
+		CreateTime:  attrs.CreateTime,
+		ModTime:     attrs.ModTime,
+		Size:        attrs.Size,
+		MD5:         attrs.MD5,
+		ETag:        fmt.Sprintf("\"%x-%x\"", attrs.ModTime.UnixNano(), attrs.Size),
+	}, nil
+}
+
+func (b *bucket) ListPaged(ctx context.Context, opts *driver.ListOptions) (*driver.ListPage, error) {
+	return nil, errNotImplemented
+}
+
+func (b *bucket) NewRangeReader(ctx context.Context, key string, offset, length int64, opts *driver.ReaderOptions) (driver.Reader, error) {
+	attrs, err := b.readAttributes(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	content, err := b.store.Get(ctx, contentKey(key))
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.BeforeRead != nil {
+		if err := opts.BeforeRead(func(any) bool { return false }); err != nil {
+			return nil, err
+		}
+	}
+
+	r := bytes.NewReader(content)
+	if offset > 0 {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+	var ior io.Reader = r
+	if length >= 0 {
+		ior = io.LimitReader(r, length)
+	}
+
+	return &reader{
+		r: ior,
+		attrs: driver.ReaderAttributes{
+			ContentType: attrs.ContentType,
+			ModTime:     attrs.ModTime,
+			Size:        attrs.Size,
+		},
+	}, nil
+}
+
+type reader struct {
+	r     io.Reader
+	attrs driver.ReaderAttributes
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	return r.r.Read(p)
+}
+
+func (r *reader) Close() error {
+	return nil
+}
+
+func (r *reader) Attributes() *driver.ReaderAttributes {
+	return &r.attrs
+}
+
+func (r *reader) As(i any) bool { return false }
+
+func (b *bucket) NewTypedWriter(ctx context.Context, key, contentType string, opts *driver.WriterOptions) (driver.Writer, error) {
+	if key == "" {
+		return nil, errors.New("cache: invalid blob key (empty string)")
+	}
+
+	if opts.BeforeWrite != nil {
+		if err := opts.BeforeWrite(func(any) bool { return false }); err != nil {
+			return nil, err
+		}
+	}
+
+	metadata := map[string]string{}
+	for k, v := range opts.Metadata {
+		metadata[k] = v
+	}
+
+	return &writer{
+		ctx:         ctx,
+		b:           b,
+		key:         key,
+		contentType: contentType,
+		metadata:    metadata,
+		ifNotExist:  opts.IfNotExist,
+	}, nil
+}
+
+type writer struct {
+	ctx         context.Context
+	b           *bucket
+	key         string
+	contentType string
+	metadata    map[string]string
+	ifNotExist  bool
+	buf         bytes.Buffer
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *writer) Upload(r io.Reader) error {
+	_, err := w.buf.ReadFrom(r)
+	return err
+}
+
+func (w *writer) Close() error {
+	// Check if the write was cancelled
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	content := w.buf.Bytes()
+	sum := md5.Sum(content)
+	attrs := &blobAttributes{
+		ContentType: w.contentType,
+		Metadata:    w.metadata,
+		CreateTime:  now,
+		ModTime:     now,
+		Size:        int64(len(content)),
+		MD5:         sum[:],
+	}
+
+	prev, err := w.b.readAttributes(w.ctx, w.key)
+	switch {
+	case err == nil:
+		if w.ifNotExist {
+			return fmt.Errorf("%w for key %q", errBlobExists, w.key)
+		}
+		attrs.CreateTime = prev.CreateTime
+	case !errors.Is(err, ErrNotFound):
+		return err
+	}
+
+	return w.b.write(w.ctx, w.key, attrs, content)
+}
+
+func (b *bucket) Copy(ctx context.Context, dstKey, srcKey string, opts *driver.CopyOptions) error {
+	if opts.BeforeCopy != nil {
+		if err := opts.BeforeCopy(func(any) bool { return false }); err != nil {
+			return err
+		}
+	}
+
+	attrs, err := b.readAttributes(ctx, srcKey)
+	if err != nil {
+		return err
+	}
+	content, err := b.store.Get(ctx, contentKey(srcKey))
+	if err != nil {
+		return err
+	}
+
+	return b.write(ctx, dstKey, attrs, content)
+}
+
+func (b *bucket) Delete(ctx context.Context, key string) error {
+	if err := b.store.Delete(ctx, attributesKey(key)); err != nil {
+		return err
+	}
+	if err := b.store.Delete(ctx, contentKey(key)); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (b *bucket) SignedURL(ctx context.Context, key string, opts *driver.SignedURLOptions) (string, error) {
+	return "", errNotImplemented
+}
+
+func (b *bucket) Close() error {
+	return b.store.Close()
+}
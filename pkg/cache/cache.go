@@ -0,0 +1,44 @@
+// Package cache stores the server's cached state in a backend which can be shared by every replica
+// of the server, instead of each replica keeping its own cache on local disk.
+//
+// Importing the package registers the redis and rediss URL schemes with the gocloud.dev/blob URL
+// mux, so a Redis backend can be used anywhere a blob storage URL is accepted, such as the job logs
+// cache.
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// ErrNotFound is returned by stores for keys which aren't set or have expired
+var ErrNotFound = errors.New("cache: key not found")
+
+// Store is a key value store holding cached data
+type Store interface {
+	// Get returns the value of the key, or ErrNotFound
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set stores the value of the key, expiring it after ttl unless ttl is 0
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes the key, returning ErrNotFound if it isn't set
+	Delete(ctx context.Context, key string) error
+	Close() error
+}
+
+// Open opens the store of a cache backend URL, such as redis://localhost:6379/0
+func Open(backend string) (Store, error) {
+	u, err := url.Parse(backend)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cache backend: %w", err)
+	}
+
+	switch u.Scheme {
+	case "redis", "rediss":
+		return NewRedisStore(backend)
+	default:
+		return nil, fmt.Errorf("unsupported cache backend %q, expected a redis:// or rediss:// URL", u.Scheme)
+	}
+}
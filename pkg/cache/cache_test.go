@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/require"
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+)
+
+func TestRedisStore(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	srv := miniredis.RunT(t)
+
+	store, err := Open("redis://" + srv.Addr() + "/0")
+	assert.NoError(err)
+	defer store.Close()
+
+	_, err = store.Get(ctx, "missing")
+	assert.ErrorIs(err, ErrNotFound)
+
+	assert.NoError(store.Set(ctx, "key", []byte("value"), time.Minute))
+	value, err := store.Get(ctx, "key")
+	assert.NoError(err)
+	assert.Equal("value", string(value))
+	assert.True(srv.Exists("buildkite-mcp-server:key"))
+
+	srv.FastForward(2 * time.Minute)
+	_, err = store.Get(ctx, "key")
+	assert.ErrorIs(err, ErrNotFound)
+
+	assert.NoError(store.Set(ctx, "key", []byte("value"), 0))
+	assert.NoError(store.Delete(ctx, "key"))
+	assert.ErrorIs(store.Delete(ctx, "key"), ErrNotFound)
+}
+
+func TestMemoryStoreMaxBytes(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	// room for two entries of a one byte key and a nine byte value
+	store := NewMemoryStore(20)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	assert.NoError(store.Set(ctx, "a", []byte("123456789"), time.Minute))
+	assert.NoError(store.Set(ctx, "b", []byte("123456789"), 0))
+	assert.Equal(int64(20), store.size)
+
+	// replacing an entry only counts its new value
+	assert.NoError(store.Set(ctx, "b", []byte("12345"), 0))
+	assert.Equal(int64(16), store.size)
+
+	// expired entries are evicted first to make room
+	now = now.Add(2 * time.Minute)
+	assert.NoError(store.Set(ctx, "c", []byte("123456789"), 0))
+	_, err := store.Get(ctx, "a")
+	assert.ErrorIs(err, ErrNotFound)
+	_, err = store.Get(ctx, "b")
+	assert.NoError(err)
+	assert.Equal(int64(16), store.size)
+
+	// then others until the entry fits
+	assert.NoError(store.Set(ctx, "d", []byte("123456789"), 0))
+	assert.LessOrEqual(store.size, int64(20))
+	value, err := store.Get(ctx, "d")
+	assert.NoError(err)
+	assert.Equal("123456789", string(value))
+
+	// values larger than the store aren't cached
+	assert.NoError(store.Set(ctx, "e", make([]byte, 100), 0))
+	_, err = store.Get(ctx, "e")
+	assert.ErrorIs(err, ErrNotFound)
+	assert.LessOrEqual(store.size, int64(20))
+
+	assert.NoError(store.Delete(ctx, "d"))
+	assert.LessOrEqual(store.size, int64(10))
+}
+
+func TestOpenUnsupportedBackend(t *testing.T) {
+	assert := require.New(t)
+
+	_, err := Open("memcached://localhost:11211")
+	assert.ErrorContains(err, "unsupported cache backend")
+}
+
+func TestRedisBucket(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	srv := miniredis.RunT(t)
+
+	bucket, err := blob.OpenBucket(ctx, "redis://"+srv.Addr()+"/0?ttl=1h")
+	assert.NoError(err)
+	defer bucket.Close()
+
+	exists, err := bucket.Exists(ctx, "org/pipeline/build/job.parquet")
+	assert.NoError(err)
+	assert.False(exists)
+
+	err = bucket.WriteAll(ctx, "org/pipeline/build/job.parquet", []byte("logs"), &blob.WriterOptions{
+		ContentType: "application/octet-stream",
+		Metadata:    map[string]string{"is_terminal": "true"},
+	})
+	assert.NoError(err)
+
+	attrs, err := bucket.Attributes(ctx, "org/pipeline/build/job.parquet")
+	assert.NoError(err)
+	assert.Equal(int64(4), attrs.Size)
+	assert.Equal("true", attrs.Metadata["is_terminal"])
+
+	reader, err := bucket.NewRangeReader(ctx, "org/pipeline/build/job.parquet", 1, 2, nil)
+	assert.NoError(err)
+	data, err := io.ReadAll(reader)
+	assert.NoError(err)
+	assert.NoError(reader.Close())
+	assert.Equal("og", string(data))
+
+	// other replicas share the blobs
+	other, err := blob.OpenBucket(ctx, "redis://"+srv.Addr()+"/0")
+	assert.NoError(err)
+	defer other.Close()
+
+	data, err = other.ReadAll(ctx, "org/pipeline/build/job.parquet")
+	assert.NoError(err)
+	assert.Equal("logs", string(data))
+
+	// blobs expire after the ttl
+	srv.FastForward(2 * time.Hour)
+	_, err = bucket.Attributes(ctx, "org/pipeline/build/job.parquet")
+	assert.Equal(gcerrors.NotFound, gcerrors.Code(err))
+
+	assert.NoError(bucket.WriteAll(ctx, "key", []byte("value"), nil))
+	assert.NoError(bucket.Delete(ctx, "key"))
+	assert.Equal(gcerrors.NotFound, gcerrors.Code(bucket.Delete(ctx, "key")))
+
+	_, err = blob.OpenBucket(ctx, "redis://"+srv.Addr()+"/0?ttl=soon")
+	assert.ErrorContains(err, "invalid ttl")
+}
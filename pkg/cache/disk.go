@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DiskStore stores cached data in files of a directory, so it outlives the server. Each value is
+// written to a file named after the hash of its key, prefixed with the time it expires.
+type DiskStore struct {
+	dir string
+	now func() time.Time
+}
+
+// NewDiskStore returns a store keeping its files in dir, creating the directory if needed
+func NewDiskStore(dir string) (*DiskStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("cache: failed to create directory: %w", err)
+	}
+	return &DiskStore{dir: dir, now: time.Now}, nil
+}
+
+func (s *DiskStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:]))
+}
+
+func (s *DiskStore) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// files cut short, e.g. by a full disk, are treated as missing
+	if len(data) < 8 {
+		return nil, ErrNotFound
+	}
+	if expiresAt := int64(binary.BigEndian.Uint64(data)); expiresAt != 0 && s.now().UnixNano() >= expiresAt {
+		_ = os.Remove(s.path(key))
+		return nil, ErrNotFound
+	}
+	return data[8:], nil
+}
+
+// Set writes the value to a temporary file renamed over the key's, so readers never see part of it
+func (s *DiskStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	var expiresAt int64
+	if ttl > 0 {
+		expiresAt = s.now().Add(ttl).UnixNano()
+	}
+
+	f, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	var header [8]byte
+	binary.BigEndian.PutUint64(header[:], uint64(expiresAt))
+	if _, err := f.Write(header[:]); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if _, err := f.Write(value); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), s.path(key))
+}
+
+func (s *DiskStore) Delete(ctx context.Context, key string) error {
+	err := os.Remove(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *DiskStore) Close() error {
+	return nil
+}
@@ -0,0 +1,309 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// maxCachedResponseSize is the largest response body cached, so job logs and artifacts are
+	// streamed through instead of being held in the store
+	maxCachedResponseSize = 1 << 20
+
+	// staleResponseLifetime is how long a response is kept after it's no longer fresh, during which
+	// it's revalidated with the API rather than fetched again
+	staleResponseLifetime = time.Hour
+
+	// invalidatedKey holds the time of the last successful write request in the store, so replicas
+	// sharing the store all stop serving the responses cached before it
+	invalidatedKey = "http:invalidated-at"
+
+	meterName = "buildkite-mcp-server"
+)
+
+type revalidateKey struct{}
+
+// Revalidate returns a context whose requests always check a cached response is still current with
+// the API, for callers polling for changes which can't wait for the response to go stale. Responses
+// which haven't changed are still served from the cache.
+func Revalidate(ctx context.Context) context.Context {
+	return context.WithValue(ctx, revalidateKey{}, true)
+}
+
+func mustRevalidate(ctx context.Context) bool {
+	revalidate, _ := ctx.Value(revalidateKey{}).(bool)
+	return revalidate
+}
+
+// cachedResponse is a response stored in the cache with the time it was received
+type cachedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	StoredAt   time.Time   `json:"stored_at"`
+}
+
+func (c *cachedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        http.StatusText(c.StatusCode),
+		StatusCode:    c.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        c.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(c.Body)),
+		ContentLength: int64(len(c.Body)),
+		Request:       req,
+	}
+}
+
+// HTTPCache caches the responses of read-only API requests in a store. Responses are served from
+// the cache for ttl, after which they're revalidated with their ETag or Last-Modified header, so the
+// API only sends them again if they've changed.
+type HTTPCache struct {
+	store Store
+	ttl   time.Duration
+	now   func() time.Time
+	// invalidatedAt is the time of the last successful write request made through this cache, before
+	// which every cached response may be out of date. Writes made by other replicas sharing the store
+	// are read from invalidatedKey.
+	invalidatedAt atomic.Int64
+	// lookups counts the cacheable requests by whether they were answered from the cache
+	lookups metric.Int64Counter
+}
+
+// NewHTTPCache returns a cache keeping responses in the store, or nil if ttl is 0, which doesn't
+// cache any
+func NewHTTPCache(store Store, ttl time.Duration) *HTTPCache {
+	if store == nil || ttl <= 0 {
+		return nil
+	}
+
+	// the counter falls back to a no-op if it can't be created
+	lookups, _ := otel.GetMeterProvider().Meter(meterName).Int64Counter("buildkite.api.cache.lookups",
+		metric.WithUnit("{request}"),
+		metric.WithDescription("Cacheable Buildkite API requests, by whether the response was a fresh hit, revalidated or a miss"))
+
+	return &HTTPCache{store: store, ttl: ttl, now: time.Now, lookups: lookups}
+}
+
+// recordLookup counts a cacheable request by its result
+func (c *HTTPCache) recordLookup(ctx context.Context, result string) {
+	c.lookups.Add(ctx, 1, metric.WithAttributes(attribute.String("result", result)))
+}
+
+// Transport wraps the transport of the API client with the cache. A nil cache returns the transport
+// unchanged.
+func (c *HTTPCache) Transport(next http.RoundTripper) http.RoundTripper {
+	if c == nil {
+		return next
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &cacheTransport{cache: c, next: next}
+}
+
+// key identifies a response by the request's URL, scoped to its credentials so callers with
+// different API tokens never share responses
+func (c *HTTPCache) key(req *http.Request) string {
+	h := sha256.New()
+	for _, part := range []string{req.Header.Get("Authorization"), req.Header.Get("Accept"), req.URL.String()} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return "http:" + hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *HTTPCache) get(ctx context.Context, key string) *cachedResponse {
+	data, err := c.store.Get(ctx, key)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			log.Ctx(ctx).Warn().Err(err).Msg("Failed to read cached response")
+		}
+		return nil
+	}
+
+	var cached cachedResponse
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil
+	}
+	return &cached
+}
+
+func (c *HTTPCache) set(ctx context.Context, key string, cached *cachedResponse) {
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return
+	}
+	if err := c.store.Set(ctx, key, data, c.ttl+staleResponseLifetime); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("Failed to cache response")
+	}
+}
+
+// invalidate records a successful write request, which may change any of the cached responses. It's
+// kept in the store for as long as the responses it invalidates.
+func (c *HTTPCache) invalidate(ctx context.Context) {
+	now := c.now().UnixNano()
+	c.invalidatedAt.Store(now)
+
+	if err := c.store.Set(ctx, invalidatedKey, strconv.AppendInt(nil, now, 10), c.ttl+staleResponseLifetime); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("Failed to invalidate cached responses")
+	}
+}
+
+// lastInvalidated returns the time of the last successful write request made by any replica sharing
+// the store
+func (c *HTTPCache) lastInvalidated(ctx context.Context) time.Time {
+	invalidatedAt := c.invalidatedAt.Load()
+
+	data, err := c.store.Get(ctx, invalidatedKey)
+	if err == nil {
+		if shared, err := strconv.ParseInt(string(data), 10, 64); err == nil {
+			invalidatedAt = max(invalidatedAt, shared)
+		}
+	} else if !errors.Is(err, ErrNotFound) {
+		log.Ctx(ctx).Warn().Err(err).Msg("Failed to read when cached responses were invalidated")
+	}
+
+	return time.Unix(0, invalidatedAt)
+}
+
+// fresh is whether a cached response can be served without checking it with the API
+func (c *HTTPCache) fresh(ctx context.Context, cached *cachedResponse) bool {
+	if mustRevalidate(ctx) {
+		return false
+	}
+	if c.now().Sub(cached.StoredAt) >= c.ttl {
+		return false
+	}
+	return cached.StoredAt.After(c.lastInvalidated(ctx))
+}
+
+// cacheable is whether a request may be served from the cache
+func cacheable(req *http.Request) bool {
+	return req.Method == http.MethodGet && req.Header.Get("Range") == ""
+}
+
+func isWrite(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// storable is whether a response may be kept in the cache
+func storable(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusOK {
+		return false
+	}
+	if strings.Contains(strings.ToLower(resp.Header.Get("Cache-Control")), "no-store") {
+		return false
+	}
+	return resp.ContentLength <= maxCachedResponseSize
+}
+
+type cacheTransport struct {
+	cache *HTTPCache
+	next  http.RoundTripper
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	if !cacheable(req) {
+		resp, err := t.next.RoundTrip(req)
+		// a write may change any of the cached responses, such as the state of a build it retried
+		if err == nil && isWrite(req.Method) && resp.StatusCode < http.StatusBadRequest {
+			t.cache.invalidate(ctx)
+		}
+		return resp, err
+	}
+
+	key := t.cache.key(req)
+	cached := t.cache.get(ctx, key)
+	if cached != nil && t.cache.fresh(ctx, cached) {
+		t.cache.recordLookup(ctx, "hit")
+		return cached.response(req), nil
+	}
+
+	if cached != nil {
+		// round trippers must not modify the request they are given
+		req = req.Clone(ctx)
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if lastModified := cached.Header.Get("Last-Modified"); lastModified != "" {
+			req.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+
+	storedAt := t.cache.now()
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached != nil && resp.StatusCode == http.StatusNotModified {
+		_ = resp.Body.Close()
+
+		// the API may send newer values of headers such as the rate limit with the confirmation
+		for name, values := range resp.Header {
+			cached.Header[name] = values
+		}
+		cached.StoredAt = storedAt
+		t.cache.set(ctx, key, cached)
+
+		t.cache.recordLookup(ctx, "revalidated")
+		return cached.response(req), nil
+	}
+	t.cache.recordLookup(ctx, "miss")
+
+	if !storable(resp) {
+		return resp, nil
+	}
+
+	// read up to the limit, passing larger responses through with the part already read
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxCachedResponseSize+1))
+	if err != nil {
+		_ = resp.Body.Close()
+		return nil, err
+	}
+	if len(body) > maxCachedResponseSize {
+		resp.Body = &multiReadCloser{Reader: io.MultiReader(bytes.NewReader(body), resp.Body), Closer: resp.Body}
+		return resp, nil
+	}
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	t.cache.set(ctx, key, &cachedResponse{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+		Body:       body,
+		StoredAt:   storedAt,
+	})
+
+	return resp, nil
+}
+
+type multiReadCloser struct {
+	io.Reader
+	io.Closer
+}
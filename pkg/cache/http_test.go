@@ -0,0 +1,239 @@
+package cache
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	store := NewMemoryStore(0)
+	store.now = func() time.Time { return now }
+
+	_, err := store.Get(ctx, "missing")
+	assert.ErrorIs(err, ErrNotFound)
+
+	assert.NoError(store.Set(ctx, "key", []byte("value"), time.Minute))
+	value, err := store.Get(ctx, "key")
+	assert.NoError(err)
+	assert.Equal("value", string(value))
+
+	now = now.Add(2 * time.Minute)
+	_, err = store.Get(ctx, "key")
+	assert.ErrorIs(err, ErrNotFound)
+
+	assert.NoError(store.Set(ctx, "key", []byte("value"), 0))
+	assert.NoError(store.Delete(ctx, "key"))
+	assert.ErrorIs(store.Delete(ctx, "key"), ErrNotFound)
+}
+
+func TestDiskStore(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	now := time.Now()
+	store, err := NewDiskStore(dir)
+	assert.NoError(err)
+	store.now = func() time.Time { return now }
+
+	_, err = store.Get(ctx, "missing")
+	assert.ErrorIs(err, ErrNotFound)
+
+	assert.NoError(store.Set(ctx, "key", []byte("value"), time.Minute))
+
+	// a store opened on the same directory sees the value
+	reopened, err := NewDiskStore(dir)
+	assert.NoError(err)
+	value, err := reopened.Get(ctx, "key")
+	assert.NoError(err)
+	assert.Equal("value", string(value))
+
+	now = now.Add(2 * time.Minute)
+	_, err = store.Get(ctx, "key")
+	assert.ErrorIs(err, ErrNotFound)
+
+	assert.NoError(store.Set(ctx, "key", []byte("value"), 0))
+	assert.NoError(store.Delete(ctx, "key"))
+	assert.ErrorIs(store.Delete(ctx, "key"), ErrNotFound)
+}
+
+// testAPI serves a build whose ETag changes with its state, counting the requests it's sent
+type testAPI struct {
+	state       atomic.Value
+	requests    atomic.Int32
+	notModified atomic.Int32
+}
+
+func (a *testAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.requests.Add(1)
+
+	if r.Method != http.MethodGet {
+		a.state.Store("canceled")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	state := a.state.Load().(string)
+	etag := `"` + state + `"`
+	if r.Header.Get("If-None-Match") == etag {
+		a.notModified.Add(1)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	_, _ = io.WriteString(w, `{"state":"`+state+`"}`)
+}
+
+func TestHTTPCache(t *testing.T) {
+	assert := require.New(t)
+
+	api := &testAPI{}
+	api.state.Store("running")
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	now := time.Now()
+	responses := NewHTTPCache(NewMemoryStore(0), time.Minute)
+	responses.now = func() time.Time { return now }
+	client := &http.Client{Transport: responses.Transport(http.DefaultTransport)}
+
+	get := func(ctx context.Context, token string) string {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/builds/1", nil)
+		assert.NoError(err)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := client.Do(req)
+		assert.NoError(err)
+		defer resp.Body.Close()
+		assert.Equal(http.StatusOK, resp.StatusCode)
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(err)
+		return string(body)
+	}
+
+	assert.Equal(`{"state":"running"}`, get(context.Background(), "a"))
+	assert.Equal(`{"state":"running"}`, get(context.Background(), "a"))
+	assert.Equal(int32(1), api.requests.Load())
+
+	// callers with another token don't share the response
+	get(context.Background(), "a-different-token")
+	assert.Equal(int32(2), api.requests.Load())
+
+	// once stale, an unchanged response is revalidated rather than sent again
+	now = now.Add(2 * time.Minute)
+	assert.Equal(`{"state":"running"}`, get(context.Background(), "a"))
+	assert.Equal(int32(3), api.requests.Load())
+	assert.Equal(int32(1), api.notModified.Load())
+
+	// pollers always revalidate
+	assert.Equal(`{"state":"running"}`, get(Revalidate(context.Background()), "a"))
+	assert.Equal(int32(4), api.requests.Load())
+	assert.Equal(int32(2), api.notModified.Load())
+
+	// a write revalidates every cached response, seeing the change it made
+	resp, err := client.Post(srv.URL+"/builds/1/cancel", "application/json", strings.NewReader("{}"))
+	assert.NoError(err)
+	_ = resp.Body.Close()
+	now = now.Add(time.Second)
+	assert.Equal(`{"state":"canceled"}`, get(context.Background(), "a"))
+	assert.Equal(int32(6), api.requests.Load())
+	assert.Equal(`{"state":"canceled"}`, get(context.Background(), "a"))
+	assert.Equal(int32(6), api.requests.Load())
+}
+
+func TestHTTPCacheInvalidatesReplicasSharingStore(t *testing.T) {
+	assert := require.New(t)
+
+	api := &testAPI{}
+	api.state.Store("running")
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	store, err := NewRedisStore("redis://" + miniredis.RunT(t).Addr())
+	assert.NoError(err)
+	defer store.Close()
+
+	// two replicas share the store, with the write made through the second
+	replicas := []*http.Client{
+		{Transport: NewHTTPCache(store, time.Minute).Transport(http.DefaultTransport)},
+		{Transport: NewHTTPCache(store, time.Minute).Transport(http.DefaultTransport)},
+	}
+
+	get := func(client *http.Client) string {
+		resp, err := client.Get(srv.URL + "/builds/1")
+		assert.NoError(err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(err)
+		return string(body)
+	}
+
+	assert.Equal(`{"state":"running"}`, get(replicas[0]))
+	assert.Equal(`{"state":"running"}`, get(replicas[0]))
+	assert.Equal(int32(1), api.requests.Load())
+
+	resp, err := replicas[1].Post(srv.URL+"/builds/1/cancel", "application/json", strings.NewReader("{}"))
+	assert.NoError(err)
+	_ = resp.Body.Close()
+
+	assert.Equal(`{"state":"canceled"}`, get(replicas[0]))
+	assert.Equal(int32(3), api.requests.Load())
+}
+
+func TestHTTPCacheSkipsLargeAndUncacheableResponses(t *testing.T) {
+	assert := require.New(t)
+
+	large := strings.Repeat("x", maxCachedResponseSize+10)
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/log":
+			// streamed without a content length
+			w.(http.Flusher).Flush()
+			_, _ = io.WriteString(w, large)
+		case "/secret":
+			w.Header().Set("Cache-Control", "no-store")
+			_, _ = io.WriteString(w, "secret")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: NewHTTPCache(NewMemoryStore(0), time.Minute).Transport(nil)}
+
+	for _, path := range []string{"/log", "/secret", "/missing"} {
+		for range 2 {
+			resp, err := client.Get(srv.URL + path)
+			assert.NoError(err)
+			body, err := io.ReadAll(resp.Body)
+			assert.NoError(err)
+			_ = resp.Body.Close()
+			if path == "/log" {
+				assert.Equal(large, string(body))
+			}
+		}
+	}
+	assert.Equal(int32(6), requests.Load())
+}
+
+func TestNewHTTPCacheDisabled(t *testing.T) {
+	assert := require.New(t)
+
+	responses := NewHTTPCache(NewMemoryStore(0), 0)
+	assert.Nil(responses)
+	assert.Equal(http.DefaultTransport, responses.Transport(http.DefaultTransport))
+}
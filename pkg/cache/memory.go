@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryStoreMaxEntries bounds the entries kept by a memory store, beyond which expired entries are
+// purged and, if it's still full, others are evicted to make room
+const memoryStoreMaxEntries = 10000
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+func (e memoryEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+func (e memoryEntry) size(key string) int64 {
+	return int64(len(key) + len(e.value))
+}
+
+// MemoryStore stores cached data in the memory of the server, so it's lost when the server exits
+type MemoryStore struct {
+	now      func() time.Time
+	maxBytes int64
+	mu       sync.Mutex
+	entries  map[string]memoryEntry
+	// size is the bytes of the keys and values stored
+	size int64
+}
+
+// NewMemoryStore returns a store keeping the keys and values it stores within maxBytes, evicting
+// entries to make room once it's full. 0 only limits the number of entries.
+func NewMemoryStore(maxBytes int64) *MemoryStore {
+	return &MemoryStore{now: time.Now, maxBytes: maxBytes, entries: map[string]memoryEntry{}}
+}
+
+// remove deletes an entry, no longer counting its size
+func (s *MemoryStore) remove(key string) {
+	if entry, ok := s.entries[key]; ok {
+		s.size -= entry.size(key)
+		delete(s.entries, key)
+	}
+}
+
+// full returns whether storing bytes more would take the store over its limits
+func (s *MemoryStore) full(bytes int64) bool {
+	return len(s.entries) >= memoryStoreMaxEntries || (s.maxBytes > 0 && s.size+bytes > s.maxBytes)
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if entry.expired(s.now()) {
+		s.remove(key)
+		return nil, ErrNotFound
+	}
+	return entry.value, nil
+}
+
+func (s *MemoryStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	entry := memoryEntry{value: value}
+	if ttl > 0 {
+		entry.expiresAt = now.Add(ttl)
+	}
+
+	s.remove(key)
+
+	// values which could never fit aren't cached, rather than emptying the store for them
+	if s.maxBytes > 0 && entry.size(key) > s.maxBytes {
+		return nil
+	}
+	if s.full(entry.size(key)) {
+		s.evict(now, entry.size(key))
+	}
+
+	s.entries[key] = entry
+	s.size += entry.size(key)
+	return nil
+}
+
+// evict purges the expired entries, then evicts arbitrary entries until there's room for bytes more
+func (s *MemoryStore) evict(now time.Time, bytes int64) {
+	for key, entry := range s.entries {
+		if entry.expired(now) {
+			s.remove(key)
+		}
+	}
+	for key := range s.entries {
+		if !s.full(bytes) {
+			break
+		}
+		s.remove(key)
+	}
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || entry.expired(s.now()) {
+		s.remove(key)
+		return ErrNotFound
+	}
+	s.remove(key)
+	return nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
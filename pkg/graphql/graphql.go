@@ -0,0 +1,104 @@
+// Package graphql is a minimal client for the Buildkite GraphQL API, which exposes data such as
+// pipeline metrics and organization usage that the REST API doesn't.
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultEndpoint is the Buildkite GraphQL API
+const DefaultEndpoint = "https://graphql.buildkite.com/v1"
+
+// maximum number of bytes of an error response body included in the error
+const maxErrorBody = 512
+
+// Error is an error reported by the GraphQL API for a query
+type Error struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path,omitempty"`
+}
+
+// Response is the result of a query. The API can return partial data alongside errors.
+type Response struct {
+	Data   json.RawMessage `json:"data,omitempty"`
+	Errors []Error         `json:"errors,omitempty"`
+}
+
+// Err returns the errors of the response joined into one, or nil if there were none
+func (r *Response) Err() error {
+	if len(r.Errors) == 0 {
+		return nil
+	}
+
+	messages := make([]string, len(r.Errors))
+	for i, e := range r.Errors {
+		messages[i] = e.Message
+	}
+	return fmt.Errorf("graphql: %s", strings.Join(messages, "; "))
+}
+
+// Client sends queries to the GraphQL API with its own token, as GraphQL access is granted to API
+// tokens separately from REST access
+type Client struct {
+	endpoint   string
+	token      string
+	userAgent  string
+	httpClient *http.Client
+}
+
+// New returns a client for the GraphQL API at the endpoint, defaulting to DefaultEndpoint
+func New(endpoint, token, userAgent string, httpClient *http.Client) *Client {
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{endpoint: endpoint, token: token, userAgent: userAgent, httpClient: httpClient}
+}
+
+// Query runs the query with its variables. Errors reported by the API for the query are returned
+// in the response rather than as an error, along with any partial data.
+func (c *Client) Query(ctx context.Context, query string, variables map[string]any) (*Response, error) {
+	body, err := json.Marshal(map[string]any{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal graphql request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return nil, fmt.Errorf("graphql request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var response Response
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode graphql response: %w", err)
+	}
+
+	return &response, nil
+}
@@ -0,0 +1,46 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuery(t *testing.T) {
+	assert := require.New(t)
+
+	var request map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("Bearer secret", r.Header.Get("Authorization"))
+		assert.Equal("buildkite-mcp-server/test", r.Header.Get("User-Agent"))
+		assert.NoError(json.NewDecoder(r.Body).Decode(&request))
+
+		_, _ = w.Write([]byte(`{"data":{"viewer":{"user":{"name":"Ada"}}},"errors":[{"message":"partial"}]}`))
+	}))
+	defer srv.Close()
+
+	client := New(srv.URL, "secret", "buildkite-mcp-server/test", nil)
+	resp, err := client.Query(context.Background(), "query($id: ID!) { viewer { user { name } } }", map[string]any{"id": "1"})
+	assert.NoError(err)
+
+	assert.Equal("query($id: ID!) { viewer { user { name } } }", request["query"])
+	assert.Equal(map[string]any{"id": "1"}, request["variables"])
+	assert.JSONEq(`{"viewer":{"user":{"name":"Ada"}}}`, string(resp.Data))
+	assert.EqualError(resp.Err(), "graphql: partial")
+}
+
+func TestQueryHTTPError(t *testing.T) {
+	assert := require.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "token lacks graphql scope", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	_, err := New(srv.URL, "secret", "", nil).Query(context.Background(), "{ viewer { id } }", nil)
+	assert.EqualError(err, "graphql request failed with status 403: token lacks graphql scope")
+}
@@ -0,0 +1,268 @@
+// Package htmlmd converts the rendered HTML of Buildkite annotations into compact markdown.
+package htmlmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Options controls the conversion
+type Options struct {
+	// MaxTableRows limits the number of body rows kept for each table, keeping the
+	// first and last rows and noting how many were omitted. Zero keeps every row.
+	MaxTableRows int
+}
+
+var (
+	whitespace     = regexp.MustCompile(`\s+`)
+	excessNewlines = regexp.MustCompile(`\n{3,}`)
+)
+
+// Convert renders the HTML fragment as markdown
+func Convert(fragment string, opts Options) (string, error) {
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), &html.Node{
+		Type:     html.ElementNode,
+		Data:     "body",
+		DataAtom: atom.Body,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	c := &converter{opts: opts, sb: &strings.Builder{}}
+	for _, n := range nodes {
+		c.render(n)
+	}
+
+	out := excessNewlines.ReplaceAllString(c.sb.String(), "\n\n")
+	return strings.TrimSpace(out), nil
+}
+
+type converter struct {
+	opts  Options
+	sb    *strings.Builder
+	lists []listState
+}
+
+type listState struct {
+	ordered bool
+	index   int
+}
+
+func (c *converter) block(render func()) {
+	c.sb.WriteString("\n\n")
+	render()
+	c.sb.WriteString("\n\n")
+}
+
+func (c *converter) children(n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.render(child)
+	}
+}
+
+func (c *converter) render(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		c.sb.WriteString(whitespace.ReplaceAllString(n.Data, " "))
+		return
+	case html.ElementNode:
+	default:
+		c.children(n)
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Head:
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level := int(n.Data[1] - '0')
+		c.block(func() {
+			c.sb.WriteString(strings.Repeat("#", level) + " " + c.inline(n))
+		})
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Details:
+		c.block(func() { c.children(n) })
+	case atom.Summary:
+		c.block(func() { c.sb.WriteString("**" + c.inline(n) + "**") })
+	case atom.Br:
+		c.sb.WriteString("\n")
+	case atom.Hr:
+		c.block(func() { c.sb.WriteString("---") })
+	case atom.Strong, atom.B:
+		c.wrapInline(n, "**")
+	case atom.Em, atom.I:
+		c.wrapInline(n, "_")
+	case atom.Code:
+		c.wrapInline(n, "`")
+	case atom.Pre:
+		c.block(func() {
+			c.sb.WriteString("```\n" + strings.Trim(textContent(n), "\n") + "\n```")
+		})
+	case atom.A:
+		text := c.inline(n)
+		if href := attr(n, "href"); href != "" {
+			c.sb.WriteString("[" + text + "](" + href + ")")
+		} else {
+			c.sb.WriteString(text)
+		}
+	case atom.Img:
+		c.sb.WriteString("![" + attr(n, "alt") + "](" + attr(n, "src") + ")")
+	case atom.Blockquote:
+		c.block(func() {
+			inner := strings.TrimSpace(c.inline(n))
+			c.sb.WriteString("> " + strings.ReplaceAll(inner, "\n", "\n> "))
+		})
+	case atom.Ul, atom.Ol:
+		c.lists = append(c.lists, listState{ordered: n.DataAtom == atom.Ol})
+		c.block(func() { c.children(n) })
+		c.lists = c.lists[:len(c.lists)-1]
+	case atom.Li:
+		c.listItem(n)
+	case atom.Table:
+		c.block(func() { c.table(n) })
+	default:
+		c.children(n)
+	}
+}
+
+func (c *converter) wrapInline(n *html.Node, marker string) {
+	text := c.inline(n)
+	if strings.TrimSpace(text) == "" {
+		c.sb.WriteString(text)
+		return
+	}
+	c.sb.WriteString(marker + strings.TrimSpace(text) + marker)
+}
+
+func (c *converter) listItem(n *html.Node) {
+	indent := ""
+	marker := "- "
+	if depth := len(c.lists); depth > 0 {
+		indent = strings.Repeat("  ", depth-1)
+		list := &c.lists[depth-1]
+		if list.ordered {
+			list.index++
+			marker = fmt.Sprintf("%d. ", list.index)
+		}
+	}
+
+	c.sb.WriteString("\n" + indent + marker + strings.TrimSpace(c.inline(n)))
+}
+
+// inline renders the children of n into a separate buffer and returns the result
+func (c *converter) inline(n *html.Node) string {
+	outer := c.sb
+	c.sb = &strings.Builder{}
+	c.children(n)
+	inner := strings.TrimSpace(excessNewlines.ReplaceAllString(c.sb.String(), "\n\n"))
+	c.sb = outer
+	return inner
+}
+
+func (c *converter) table(n *html.Node) {
+	var rows [][]string
+	headerRow := false
+
+	var collect func(*html.Node)
+	collect = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			switch child.DataAtom {
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				collect(child)
+			case atom.Tr:
+				var cells []string
+				for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type != html.ElementNode || (cell.DataAtom != atom.Td && cell.DataAtom != atom.Th) {
+						continue
+					}
+					if len(rows) == 0 && cell.DataAtom == atom.Th {
+						headerRow = true
+					}
+					text := strings.ReplaceAll(c.inline(cell), "\n", " ")
+					cells = append(cells, strings.ReplaceAll(text, "|", `\|`))
+				}
+				rows = append(rows, cells)
+			}
+		}
+	}
+	collect(n)
+
+	if len(rows) == 0 {
+		return
+	}
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+
+	header := rows[0]
+	body := rows[1:]
+	if !headerRow {
+		header = make([]string, columns)
+		body = rows
+	}
+
+	body = truncateRows(body, c.opts.MaxTableRows, columns)
+
+	writeRow := func(cells []string) {
+		padded := make([]string, columns)
+		copy(padded, cells)
+		c.sb.WriteString("| " + strings.Join(padded, " | ") + " |\n")
+	}
+
+	writeRow(header)
+	c.sb.WriteString("|" + strings.Repeat(" --- |", columns) + "\n")
+	for _, row := range body {
+		writeRow(row)
+	}
+}
+
+// truncateRows keeps the first and last rows when there are more than max,
+// replacing the middle with a single marker row
+func truncateRows(rows [][]string, limit, columns int) [][]string {
+	if limit <= 0 || len(rows) <= limit {
+		return rows
+	}
+
+	head := (limit + 1) / 2
+	tail := limit - head
+	omitted := len(rows) - head - tail
+
+	marker := make([]string, max(columns, 1))
+	marker[0] = fmt.Sprintf("… %d rows omitted …", omitted)
+
+	truncated := make([][]string, 0, limit+1)
+	truncated = append(truncated, rows[:head]...)
+	truncated = append(truncated, marker)
+	truncated = append(truncated, rows[len(rows)-tail:]...)
+
+	return truncated
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+
+	var sb strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		sb.WriteString(textContent(child))
+	}
+	return sb.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
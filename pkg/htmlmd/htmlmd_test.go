@@ -0,0 +1,51 @@
+package htmlmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     Options
+		expected string
+	}{
+		{
+			name:     "inline formatting",
+			input:    `<p>Build <strong>failed</strong> in <code>make test</code>, see <a href="https://example.com">details</a></p>`,
+			expected: "Build **failed** in `make test`, see [details](https://example.com)",
+		},
+		{
+			name:     "heading and list",
+			input:    `<h3>Failures</h3><ul><li>one</li><li>two</li></ul><ol><li>first</li></ol>`,
+			expected: "### Failures\n\n- one\n- two\n\n1. first",
+		},
+		{
+			name:     "preformatted",
+			input:    "<pre><code>line 1\nline 2</code></pre>",
+			expected: "```\nline 1\nline 2\n```",
+		},
+		{
+			name:     "table",
+			input:    `<table><thead><tr><th>Test</th><th>Result</th></tr></thead><tbody><tr><td>a</td><td>pass</td></tr><tr><td>b|c</td><td>fail</td></tr></tbody></table>`,
+			expected: "| Test | Result |\n| --- | --- |\n| a | pass |\n| b\\|c | fail |",
+		},
+		{
+			name:     "truncated table",
+			input:    `<table><tr><th>N</th></tr><tr><td>1</td></tr><tr><td>2</td></tr><tr><td>3</td></tr><tr><td>4</td></tr><tr><td>5</td></tr></table>`,
+			opts:     Options{MaxTableRows: 2},
+			expected: "| N |\n| --- |\n| 1 |\n| … 3 rows omitted … |\n| 5 |",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Convert(tt.input, tt.opts)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, result)
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package logcache
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+	"github.com/rs/zerolog/log"
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+)
+
+// logSuffix ends the keys of the job logs in the cache, so other blobs in a shared bucket are left alone
+const logSuffix = ".parquet"
+
+// ErrNotListable is returned for caches in stores which can't list their blobs, such as Redis, which
+// expire the logs themselves instead
+var ErrNotListable = errors.New("the job logs cache can't be listed, as its store expires logs itself")
+
+// Stats describes the job logs in the cache
+type Stats struct {
+	StorageURL string     `json:"storage_url"`
+	Logs       int        `json:"logs"`
+	TotalBytes int64      `json:"total_bytes"`
+	Oldest     *time.Time `json:"oldest,omitempty"`
+	Newest     *time.Time `json:"newest,omitempty"`
+	// MaxBytes and MaxAgeSeconds are the limits garbage collection keeps the cache within, 0 when unlimited
+	MaxBytes      int64 `json:"max_bytes"`
+	MaxAgeSeconds int64 `json:"max_age_seconds"`
+}
+
+// PurgeResult is how many job logs were deleted from the cache, and how many remain
+type PurgeResult struct {
+	Deleted        int   `json:"deleted"`
+	DeletedBytes   int64 `json:"deleted_bytes"`
+	Remaining      int   `json:"remaining"`
+	RemainingBytes int64 `json:"remaining_bytes"`
+}
+
+// Collector keeps the job logs cache at a blob storage URL within a total size and an age, deleting
+// the logs cached longest ago first. Logs are found by listing the bucket, which stores that expire
+// their own blobs, such as Redis, don't support.
+type Collector struct {
+	storageURL string
+	maxBytes   int64
+	maxAge     time.Duration
+}
+
+// NewCollector returns a collector for the cache at the storage URL, or the default directory when
+// empty, keeping it within maxBytes and maxAge unless they're 0
+func NewCollector(storageURL string, maxBytes int64, maxAge time.Duration) (*Collector, error) {
+	storageURL, err := buildkitelogs.GetDefaultStorageURL(storageURL)
+	if err != nil {
+		return nil, err
+	}
+	return &Collector{storageURL: storageURL, maxBytes: maxBytes, maxAge: maxAge}, nil
+}
+
+// cachedLog is a job log in the cache
+type cachedLog struct {
+	key      string
+	size     int64
+	cachedAt time.Time
+}
+
+// list returns the job logs in the cache whose keys start with prefix, cached longest ago first
+func (c *Collector) list(ctx context.Context, bucket *blob.Bucket, prefix string) ([]cachedLog, error) {
+	var logs []cachedLog
+	iter := bucket.List(&blob.ListOptions{Prefix: prefix})
+	for {
+		object, err := iter.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if gcerrors.Code(err) == gcerrors.Unimplemented {
+			return nil, fmt.Errorf("%w: %s", ErrNotListable, redactURL(c.storageURL))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the job logs cache: %w", err)
+		}
+		if object.IsDir || !strings.HasSuffix(object.Key, logSuffix) {
+			continue
+		}
+		logs = append(logs, cachedLog{key: object.Key, size: object.Size, cachedAt: object.ModTime})
+	}
+
+	slices.SortFunc(logs, func(a, b cachedLog) int {
+		return cmp.Or(a.cachedAt.Compare(b.cachedAt), cmp.Compare(a.key, b.key))
+	})
+	return logs, nil
+}
+
+// Stats returns how many job logs are in the cache and their size
+func (c *Collector) Stats(ctx context.Context) (Stats, error) {
+	bucket, err := blob.OpenBucket(ctx, c.storageURL)
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to open the job logs cache: %w", err)
+	}
+	defer bucket.Close()
+
+	logs, err := c.list(ctx, bucket, "")
+	if err != nil {
+		return Stats{}, err
+	}
+
+	stats := Stats{
+		StorageURL:    redactURL(c.storageURL),
+		Logs:          len(logs),
+		MaxBytes:      c.maxBytes,
+		MaxAgeSeconds: int64(c.maxAge.Seconds()),
+	}
+	for _, l := range logs {
+		stats.TotalBytes += l.size
+	}
+	if len(logs) > 0 {
+		stats.Oldest = &logs[0].cachedAt
+		stats.Newest = &logs[len(logs)-1].cachedAt
+	}
+	return stats, nil
+}
+
+// Purge deletes the job logs whose keys start with prefix, which are named
+// <org>-<pipeline>-<build>-<job>.parquet, and which were cached before cachedBefore unless it's zero
+func (c *Collector) Purge(ctx context.Context, prefix string, cachedBefore time.Time) (PurgeResult, error) {
+	return c.collect(ctx, prefix, func(l cachedLog, remainingBytes int64) bool {
+		return cachedBefore.IsZero() || l.cachedAt.Before(cachedBefore)
+	})
+}
+
+// Collect deletes the job logs cached longer than the maximum age, then the logs cached longest ago
+// until the cache is within the maximum size
+func (c *Collector) Collect(ctx context.Context) (PurgeResult, error) {
+	expiredBefore := time.Time{}
+	if c.maxAge > 0 {
+		expiredBefore = time.Now().Add(-c.maxAge)
+	}
+
+	return c.collect(ctx, "", func(l cachedLog, remainingBytes int64) bool {
+		return l.cachedAt.Before(expiredBefore) || (c.maxBytes > 0 && remainingBytes > c.maxBytes)
+	})
+}
+
+// collect deletes the job logs, oldest first, which should be deleted given the size of the logs
+// remaining
+func (c *Collector) collect(ctx context.Context, prefix string, shouldDelete func(l cachedLog, remainingBytes int64) bool) (PurgeResult, error) {
+	bucket, err := blob.OpenBucket(ctx, c.storageURL)
+	if err != nil {
+		return PurgeResult{}, fmt.Errorf("failed to open the job logs cache: %w", err)
+	}
+	defer bucket.Close()
+
+	logs, err := c.list(ctx, bucket, prefix)
+	if err != nil {
+		return PurgeResult{}, err
+	}
+
+	var result PurgeResult
+	for _, l := range logs {
+		result.RemainingBytes += l.size
+	}
+	result.Remaining = len(logs)
+
+	for _, l := range logs {
+		if !shouldDelete(l, result.RemainingBytes) {
+			continue
+		}
+
+		// a log deleted by another replica since listing is as good as deleted
+		if err := bucket.Delete(ctx, l.key); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+			return result, fmt.Errorf("failed to delete %s from the job logs cache: %w", l.key, err)
+		}
+		result.Deleted++
+		result.DeletedBytes += l.size
+		result.Remaining--
+		result.RemainingBytes -= l.size
+	}
+
+	return result, nil
+}
+
+// Run collects the cache every interval until the context is done, stopping early if the cache
+// can't be listed
+func (c *Collector) Run(ctx context.Context, interval time.Duration) {
+	if c.maxBytes <= 0 && c.maxAge <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		result, err := c.Collect(ctx)
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msg("Failed to collect the job logs cache")
+			if errors.Is(err, ErrNotListable) {
+				return
+			}
+		} else {
+			log.Ctx(ctx).Debug().Int("deleted", result.Deleted).Int64("deleted_bytes", result.DeletedBytes).Int64("remaining_bytes", result.RemainingBytes).Msg("Collected the job logs cache")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// redactURL hides the password of a storage URL, such as a Redis backend's
+func redactURL(storageURL string) string {
+	u, err := url.Parse(storageURL)
+	if err != nil {
+		return storageURL
+	}
+	return u.Redacted()
+}
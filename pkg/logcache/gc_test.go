@@ -0,0 +1,88 @@
+package logcache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeCachedLogs writes logs of the sizes into a cache directory, each cached an hour after the one before
+func writeCachedLogs(t *testing.T, sizes map[string]int) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	cachedAt := time.Now().Add(-time.Duration(len(sizes)+1) * time.Hour)
+	for _, key := range []string{"org-web-1-a.parquet", "org-web-2-b.parquet", "org-api-3-c.parquet", "healthz-1"} {
+		size, ok := sizes[key]
+		if !ok {
+			continue
+		}
+		path := filepath.Join(dir, key)
+		require.NoError(t, os.WriteFile(path, []byte(strings.Repeat("x", size)), 0o600))
+		require.NoError(t, os.Chtimes(path, cachedAt, cachedAt))
+		cachedAt = cachedAt.Add(time.Hour)
+	}
+	return dir
+}
+
+func TestCollectorStats(t *testing.T) {
+	assert := require.New(t)
+
+	dir := writeCachedLogs(t, map[string]int{"org-web-1-a.parquet": 100, "org-web-2-b.parquet": 200, "healthz-1": 2})
+	collector, err := NewCollector("file://"+dir, 1000, time.Hour)
+	assert.NoError(err)
+
+	// blobs which aren't job logs are left out
+	stats, err := collector.Stats(context.Background())
+	assert.NoError(err)
+	assert.Equal(2, stats.Logs)
+	assert.Equal(int64(300), stats.TotalBytes)
+	assert.True(stats.Oldest.Before(*stats.Newest))
+	assert.Equal(int64(1000), stats.MaxBytes)
+	assert.Equal(int64(3600), stats.MaxAgeSeconds)
+}
+
+func TestCollectorPurge(t *testing.T) {
+	assert := require.New(t)
+
+	dir := writeCachedLogs(t, map[string]int{"org-web-1-a.parquet": 100, "org-web-2-b.parquet": 200, "org-api-3-c.parquet": 300})
+	collector, err := NewCollector("file://"+dir, 0, 0)
+	assert.NoError(err)
+
+	// logs of the pipeline cached before the newest of them
+	result, err := collector.Purge(context.Background(), "org-web-", time.Now().Add(-210*time.Minute))
+	assert.NoError(err)
+	assert.Equal(PurgeResult{Deleted: 1, DeletedBytes: 100, Remaining: 1, RemainingBytes: 200}, result)
+	assert.NoFileExists(filepath.Join(dir, "org-web-1-a.parquet"))
+
+	result, err = collector.Purge(context.Background(), "", time.Time{})
+	assert.NoError(err)
+	assert.Equal(PurgeResult{Deleted: 2, DeletedBytes: 500}, result)
+}
+
+func TestCollectorCollect(t *testing.T) {
+	assert := require.New(t)
+
+	dir := writeCachedLogs(t, map[string]int{"org-web-1-a.parquet": 100, "org-web-2-b.parquet": 200, "org-api-3-c.parquet": 300, "healthz-1": 2})
+
+	// the logs cached longest ago are deleted until the cache is within its size
+	collector, err := NewCollector("file://"+dir, 400, 0)
+	assert.NoError(err)
+	result, err := collector.Collect(context.Background())
+	assert.NoError(err)
+	assert.Equal(PurgeResult{Deleted: 2, DeletedBytes: 300, Remaining: 1, RemainingBytes: 300}, result)
+	assert.FileExists(filepath.Join(dir, "org-api-3-c.parquet"))
+	assert.FileExists(filepath.Join(dir, "healthz-1"))
+
+	// then logs cached longer than the age
+	collector, err = NewCollector("file://"+dir, 0, time.Hour)
+	assert.NoError(err)
+	result, err = collector.Collect(context.Background())
+	assert.NoError(err)
+	assert.Equal(PurgeResult{Deleted: 1, DeletedBytes: 300}, result)
+}
@@ -0,0 +1,184 @@
+// Package logcache shares one server's job log cache with other server processes on the same
+// machine over a unix socket, so a developer's many stdio servers don't each download the same logs,
+// and keeps the cache within a size and age so long-running servers don't grow it without bound.
+package logcache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/singleflight"
+)
+
+const logsPath = "/v1/logs"
+
+// LogsClient downloads job logs to a local Parquet file, returning its path
+type LogsClient interface {
+	DownloadAndCache(ctx context.Context, org, pipeline, build, job string, cacheTTL time.Duration, forceRefresh bool) (string, error)
+}
+
+type logsResponse struct {
+	Path  string `json:"path,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Handler serves the cache of a logs client, downloading each job's logs once however many
+// processes ask for them at the same time
+type Handler struct {
+	client LogsClient
+	group  singleflight.Group
+}
+
+// NewHandler returns a handler serving the cache of the client
+func NewHandler(client LogsClient) *Handler {
+	return &Handler{client: client}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != logsPath || r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+
+	query := r.URL.Query()
+	org, pipeline, build, job := query.Get("org"), query.Get("pipeline"), query.Get("build"), query.Get("job")
+
+	var ttl time.Duration
+	if value := query.Get("ttl"); value != "" {
+		var err error
+		if ttl, err = time.ParseDuration(value); err != nil {
+			writeResponse(w, http.StatusBadRequest, logsResponse{Error: fmt.Sprintf("invalid ttl: %v", err)})
+			return
+		}
+	}
+	forceRefresh, _ := strconv.ParseBool(query.Get("force_refresh"))
+
+	// concurrent requests for the same job share one download, unless one of them forces a refresh
+	key := strings.Join([]string{org, pipeline, build, job, ttl.String(), strconv.FormatBool(forceRefresh)}, "\x00")
+	path, err, _ := h.group.Do(key, func() (any, error) {
+		return h.client.DownloadAndCache(context.WithoutCancel(r.Context()), org, pipeline, build, job, ttl, forceRefresh)
+	})
+	if err != nil {
+		writeResponse(w, http.StatusBadGateway, logsResponse{Error: err.Error()})
+		return
+	}
+
+	writeResponse(w, http.StatusOK, logsResponse{Path: path.(string)})
+}
+
+func writeResponse(w http.ResponseWriter, status int, response logsResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// Listen listens on the unix socket at path, replacing a stale socket left by a process which
+// didn't shut down cleanly. It fails if another process is already serving the socket.
+func Listen(path string) (net.Listener, error) {
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("log cache socket %s is already being served", path)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to remove stale log cache socket: %w", err)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on log cache socket %s: %w", path, err)
+	}
+
+	// only the user running the server may use its cache, as it holds their logs
+	if err := os.Chmod(path, 0o600); err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("failed to restrict log cache socket permissions: %w", err)
+	}
+
+	return listener, nil
+}
+
+// JobStatusClient gets the status of a job with the caller's own API token
+type JobStatusClient interface {
+	GetJobStatus(ctx context.Context, org, pipeline, build, job string) (*buildkitelogs.JobStatus, error)
+}
+
+// Client fetches logs through the cache served on a unix socket, falling back to downloading them
+// itself when nothing is serving the socket
+type Client struct {
+	httpClient *http.Client
+	fallback   LogsClient
+	jobs       JobStatusClient
+}
+
+// NewClient returns a client using the cache served on the unix socket at path. The cache downloads
+// logs with the serving process's token, so each job is first looked up with jobs, which holds the
+// client's own token, to check the client may see its logs.
+func NewClient(path string, fallback LogsClient, jobs JobStatusClient) *Client {
+	return &Client{
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var dialer net.Dialer
+					return dialer.DialContext(ctx, "unix", path)
+				},
+			},
+		},
+		fallback: fallback,
+		jobs:     jobs,
+	}
+}
+
+// DownloadAndCache returns the path of the job's logs in the shared cache
+func (c *Client) DownloadAndCache(ctx context.Context, org, pipeline, build, job string, cacheTTL time.Duration, forceRefresh bool) (string, error) {
+	if c.jobs != nil {
+		if _, err := c.jobs.GetJobStatus(ctx, org, pipeline, build, job); err != nil {
+			return "", err
+		}
+	}
+
+	query := url.Values{
+		"org":           {org},
+		"pipeline":      {pipeline},
+		"build":         {build},
+		"job":           {job},
+		"ttl":           {cacheTTL.String()},
+		"force_refresh": {strconv.FormatBool(forceRefresh)},
+	}
+
+	// the host is ignored, as requests are always dialled to the socket
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://logcache"+logsPath+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		log.Ctx(ctx).Debug().Err(err).Msg("Shared log cache unavailable, downloading logs directly")
+		return c.fallback.DownloadAndCache(ctx, org, pipeline, build, job, cacheTTL, forceRefresh)
+	}
+	defer resp.Body.Close()
+
+	var response logsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", fmt.Errorf("invalid response from shared log cache: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.New(response.Error)
+	}
+
+	return response.Path, nil
+}
@@ -0,0 +1,164 @@
+package logcache
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+	"github.com/stretchr/testify/require"
+)
+
+type mockLogsClient struct {
+	downloads atomic.Int32
+	fn        func(org, pipeline, build, job string, ttl time.Duration, forceRefresh bool) (string, error)
+}
+
+func (m *mockLogsClient) DownloadAndCache(ctx context.Context, org, pipeline, build, job string, cacheTTL time.Duration, forceRefresh bool) (string, error) {
+	m.downloads.Add(1)
+	return m.fn(org, pipeline, build, job, cacheTTL, forceRefresh)
+}
+
+func serve(t *testing.T, client LogsClient) string {
+	t.Helper()
+
+	// unix socket paths are limited in length, so avoid the long per-test temp directory
+	dir, err := os.MkdirTemp("", "logcache")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	path := filepath.Join(dir, "cache.sock")
+
+	listener, err := Listen(path)
+	require.NoError(t, err)
+
+	srv := &http.Server{Handler: NewHandler(client)}
+	go func() { _ = srv.Serve(listener) }()
+	t.Cleanup(func() { _ = srv.Close() })
+
+	return path
+}
+
+func TestClientUsesSharedCache(t *testing.T) {
+	assert := require.New(t)
+
+	shared := &mockLogsClient{fn: func(org, pipeline, build, job string, ttl time.Duration, forceRefresh bool) (string, error) {
+		assert.Equal("org/pipeline/1/job", org+"/"+pipeline+"/"+build+"/"+job)
+		assert.Equal(time.Minute, ttl)
+		assert.True(forceRefresh)
+		return "/tmp/bklog-123", nil
+	}}
+	local := &mockLogsClient{fn: func(org, pipeline, build, job string, ttl time.Duration, forceRefresh bool) (string, error) {
+		return "", errors.New("should not download locally")
+	}}
+
+	client := NewClient(serve(t, shared), local, nil)
+
+	path, err := client.DownloadAndCache(context.Background(), "org", "pipeline", "1", "job", time.Minute, true)
+	assert.NoError(err)
+	assert.Equal("/tmp/bklog-123", path)
+	assert.Equal(int32(0), local.downloads.Load())
+}
+
+type mockJobStatusClient struct {
+	err error
+}
+
+func (m *mockJobStatusClient) GetJobStatus(ctx context.Context, org, pipeline, build, job string) (*buildkitelogs.JobStatus, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &buildkitelogs.JobStatus{ID: job}, nil
+}
+
+func TestClientChecksAccessBeforeSharedCache(t *testing.T) {
+	assert := require.New(t)
+
+	shared := &mockLogsClient{fn: func(org, pipeline, build, job string, ttl time.Duration, forceRefresh bool) (string, error) {
+		return "/tmp/bklog-123", nil
+	}}
+	path := serve(t, shared)
+
+	// a client whose token can't see the job isn't given the logs the cache downloaded with its own
+	client := NewClient(path, nil, &mockJobStatusClient{err: errors.New("failed to get build info: 404 Not Found")})
+	_, err := client.DownloadAndCache(context.Background(), "org", "pipeline", "1", "job", 0, false)
+	assert.ErrorContains(err, "404 Not Found")
+	assert.Equal(int32(0), shared.downloads.Load())
+
+	client = NewClient(path, nil, &mockJobStatusClient{})
+	logs, err := client.DownloadAndCache(context.Background(), "org", "pipeline", "1", "job", 0, false)
+	assert.NoError(err)
+	assert.Equal("/tmp/bklog-123", logs)
+}
+
+func TestClientReturnsSharedCacheErrors(t *testing.T) {
+	assert := require.New(t)
+
+	shared := &mockLogsClient{fn: func(org, pipeline, build, job string, ttl time.Duration, forceRefresh bool) (string, error) {
+		return "", errors.New("job not found")
+	}}
+
+	client := NewClient(serve(t, shared), nil, nil)
+
+	_, err := client.DownloadAndCache(context.Background(), "org", "pipeline", "1", "job", 0, false)
+	assert.EqualError(err, "job not found")
+}
+
+func TestClientFallsBackWithoutSharedCache(t *testing.T) {
+	assert := require.New(t)
+
+	local := &mockLogsClient{fn: func(org, pipeline, build, job string, ttl time.Duration, forceRefresh bool) (string, error) {
+		return "/tmp/local", nil
+	}}
+
+	client := NewClient(filepath.Join(t.TempDir(), "missing.sock"), local, nil)
+
+	path, err := client.DownloadAndCache(context.Background(), "org", "pipeline", "1", "job", 0, false)
+	assert.NoError(err)
+	assert.Equal("/tmp/local", path)
+	assert.Equal(int32(1), local.downloads.Load())
+}
+
+func TestHandlerSharesConcurrentDownloads(t *testing.T) {
+	assert := require.New(t)
+
+	release := make(chan struct{})
+	shared := &mockLogsClient{fn: func(org, pipeline, build, job string, ttl time.Duration, forceRefresh bool) (string, error) {
+		<-release
+		return "/tmp/bklog-123", nil
+	}}
+
+	client := NewClient(serve(t, shared), nil, nil)
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			path, err := client.DownloadAndCache(context.Background(), "org", "pipeline", "1", "job", 0, false)
+			assert.NoError(err)
+			assert.Equal("/tmp/bklog-123", path)
+		}()
+	}
+
+	// give the requests time to arrive before the download completes
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(int32(1), shared.downloads.Load())
+}
+
+func TestListenRefusesSocketInUse(t *testing.T) {
+	assert := require.New(t)
+
+	path := serve(t, &mockLogsClient{})
+
+	_, err := Listen(path)
+	assert.ErrorContains(err, "already being served")
+}
@@ -0,0 +1,48 @@
+package logsink
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	SinkStderr = "stderr"
+	SinkSyslog = "syslog"
+	SinkOTLP   = "otlp"
+)
+
+// Config selects where the server's log output is written
+type Config struct {
+	Sink string
+	// SyslogAddress is a URL such as "udp://localhost:514", empty uses the local syslog daemon
+	SyslogAddress string
+	// OTLPEndpoint is the OTLP/HTTP logs endpoint, e.g. "http://localhost:4318/v1/logs"
+	OTLPEndpoint   string
+	ServiceName    string
+	ServiceVersion string
+}
+
+// New returns a writer for the configured sink. Callers must close the writer
+// on shutdown so buffered records are flushed.
+func New(cfg Config) (io.WriteCloser, error) {
+	switch cfg.Sink {
+	case "", SinkStderr:
+		return nopCloser{os.Stderr}, nil
+	case SinkSyslog:
+		return newSyslogWriter(cfg.SyslogAddress, cfg.ServiceName)
+	case SinkOTLP:
+		if cfg.OTLPEndpoint == "" {
+			return nil, fmt.Errorf("an OTLP logs endpoint is required when using the %q log sink", SinkOTLP)
+		}
+		return NewOTLPWriter(cfg.OTLPEndpoint, cfg.ServiceName, cfg.ServiceVersion), nil
+	default:
+		return nil, fmt.Errorf("unknown log sink %q", cfg.Sink)
+	}
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
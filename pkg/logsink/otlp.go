@@ -0,0 +1,247 @@
+package logsink
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	otlpBatchSize     = 100
+	otlpFlushInterval = 2 * time.Second
+	// otlpMaxPending bounds the records held while the endpoint is slow or unavailable, past which
+	// new records are dropped
+	otlpMaxPending = 100 * otlpBatchSize
+)
+
+// OTLPWriter ships zerolog JSON output to an OTLP/HTTP logs endpoint using the
+// JSON protobuf encoding. Records are batched and sent from a background
+// goroutine, so logging never waits on the endpoint, and failures are dropped
+// rather than written to stderr.
+type OTLPWriter struct {
+	endpoint string
+	client   *http.Client
+	resource otlpResource
+
+	mu      sync.Mutex
+	pending []otlpLogRecord
+
+	// full wakes the background goroutine to send a batch before the flush interval
+	full chan struct{}
+
+	closeOnce sync.Once
+	closeErr  error
+	done      chan struct{}
+	stopped   chan struct{}
+}
+
+// NewOTLPWriter creates a writer which posts batches of log records to the endpoint
+func NewOTLPWriter(endpoint, serviceName, serviceVersion string) *OTLPWriter {
+	w := &OTLPWriter{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		resource: otlpResource{Attributes: []otlpKeyValue{
+			stringAttribute("service.name", serviceName),
+			stringAttribute("service.version", serviceVersion),
+		}},
+		full:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	go w.run()
+
+	return w
+}
+
+// Write converts a single zerolog JSON event into an OTLP log record, queueing it to be sent
+func (w *OTLPWriter) Write(p []byte) (int, error) {
+	var event map[string]any
+	if err := json.Unmarshal(p, &event); err != nil {
+		return 0, fmt.Errorf("failed to decode log event: %w", err)
+	}
+
+	record := newOTLPLogRecord(event)
+
+	w.mu.Lock()
+	if len(w.pending) >= otlpMaxPending {
+		w.mu.Unlock()
+		return 0, errors.New("OTLP logs endpoint is not keeping up, dropped log record")
+	}
+	w.pending = append(w.pending, record)
+	full := len(w.pending) >= otlpBatchSize
+	w.mu.Unlock()
+
+	if full {
+		select {
+		case w.full <- struct{}{}:
+		default:
+		}
+	}
+
+	return len(p), nil
+}
+
+// Close stops the background goroutine once it has sent any remaining records. It's safe to call
+// more than once.
+func (w *OTLPWriter) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.done)
+		<-w.stopped
+	})
+
+	return w.closeErr
+}
+
+func (w *OTLPWriter) run() {
+	defer close(w.stopped)
+
+	ticker := time.NewTicker(otlpFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = w.flush()
+		case <-w.full:
+			_ = w.flush()
+		case <-w.done:
+			w.closeErr = w.flush()
+			return
+		}
+	}
+}
+
+func (w *OTLPWriter) flush() error {
+	w.mu.Lock()
+	records := w.pending
+	w.pending = nil
+	w.mu.Unlock()
+
+	if len(records) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(otlpLogsRequest{
+		ResourceLogs: []otlpResourceLogs{{
+			Resource: w.resource,
+			ScopeLogs: []otlpScopeLogs{{
+				Scope:      otlpScope{Name: "buildkite-mcp-server"},
+				LogRecords: records,
+			}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.client.Post(w.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("OTLP logs endpoint returned %s", resp.Status)
+	}
+
+	return nil
+}
+
+// The types below mirror the OTLP JSON encoding of ExportLogsServiceRequest
+
+type otlpLogsRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano   string         `json:"timeUnixNano"`
+	SeverityNumber int            `json:"severityNumber"`
+	SeverityText   string         `json:"severityText"`
+	Body           otlpAnyValue   `json:"body"`
+	Attributes     []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+func stringAttribute(key, value string) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: value}}
+}
+
+// severityNumbers maps zerolog levels to the OTLP severity number ranges
+var severityNumbers = map[string]int{
+	zerolog.LevelTraceValue: 1,
+	zerolog.LevelDebugValue: 5,
+	zerolog.LevelInfoValue:  9,
+	zerolog.LevelWarnValue:  13,
+	zerolog.LevelErrorValue: 17,
+	zerolog.LevelFatalValue: 21,
+	zerolog.LevelPanicValue: 21,
+}
+
+func newOTLPLogRecord(event map[string]any) otlpLogRecord {
+	ts := time.Now()
+	if value, ok := event[zerolog.TimestampFieldName].(string); ok {
+		if parsed, err := time.Parse(zerolog.TimeFieldFormat, value); err == nil {
+			ts = parsed
+		}
+	}
+
+	level, _ := event[zerolog.LevelFieldName].(string)
+	message, _ := event[zerolog.MessageFieldName].(string)
+
+	record := otlpLogRecord{
+		TimeUnixNano:   strconv.FormatInt(ts.UnixNano(), 10),
+		SeverityNumber: severityNumbers[level],
+		SeverityText:   level,
+		Body:           otlpAnyValue{StringValue: message},
+	}
+
+	for key, value := range event {
+		switch key {
+		case zerolog.TimestampFieldName, zerolog.LevelFieldName, zerolog.MessageFieldName:
+			continue
+		}
+
+		str, ok := value.(string)
+		if !ok {
+			data, _ := json.Marshal(value)
+			str = string(data)
+		}
+		record.Attributes = append(record.Attributes, stringAttribute(key, str))
+	}
+
+	return record
+}
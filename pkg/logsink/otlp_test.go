@@ -0,0 +1,99 @@
+package logsink
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOTLPWriter(t *testing.T) {
+	assert := require.New(t)
+
+	var (
+		mu       sync.Mutex
+		requests []otlpLogsRequest
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req otlpLogsRequest
+		assert.NoError(json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal("application/json", r.Header.Get("Content-Type"))
+
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	w := NewOTLPWriter(srv.URL, "buildkite-mcp-server", "1.0.0")
+	logger := zerolog.New(w).With().Timestamp().Logger()
+	logger.Warn().Str("org", "acme").Msg("something happened")
+
+	assert.NoError(w.Close())
+	// closing again doesn't panic
+	assert.NoError(w.Close())
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assert.Len(requests, 1)
+	resourceLogs := requests[0].ResourceLogs[0]
+	assert.Contains(resourceLogs.Resource.Attributes, stringAttribute("service.version", "1.0.0"))
+
+	record := resourceLogs.ScopeLogs[0].LogRecords[0]
+	assert.Equal("something happened", record.Body.StringValue)
+	assert.Equal("warn", record.SeverityText)
+	assert.Equal(13, record.SeverityNumber)
+	assert.Equal([]otlpKeyValue{stringAttribute("org", "acme")}, record.Attributes)
+}
+
+func TestOTLPWriterDoesNotBlockOnEndpoint(t *testing.T) {
+	assert := require.New(t)
+
+	release := make(chan struct{})
+	var received atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+
+		var req otlpLogsRequest
+		assert.NoError(json.NewDecoder(r.Body).Decode(&req))
+		received.Add(int32(len(req.ResourceLogs[0].ScopeLogs[0].LogRecords)))
+	}))
+	defer srv.Close()
+
+	w := NewOTLPWriter(srv.URL, "buildkite-mcp-server", "1.0.0")
+	logger := zerolog.New(w)
+
+	// full batches are sent in the background while the endpoint is stuck
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 3 * otlpBatchSize {
+			logger.Info().Msg("working")
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("logging blocked on the OTLP endpoint")
+	}
+
+	close(release)
+	assert.NoError(w.Close())
+	assert.Equal(int32(3*otlpBatchSize), received.Load())
+}
+
+func TestNewUnknownSink(t *testing.T) {
+	_, err := New(Config{Sink: "kafka"})
+	require.Error(t, err)
+
+	_, err = New(Config{Sink: SinkOTLP})
+	require.ErrorContains(t, err, "endpoint is required")
+}
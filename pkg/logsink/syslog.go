@@ -0,0 +1,40 @@
+//go:build !windows
+
+package logsink
+
+import (
+	"fmt"
+	"io"
+	"log/syslog"
+	"net/url"
+
+	"github.com/rs/zerolog"
+)
+
+// syslogWriter maps zerolog levels onto syslog priorities
+type syslogWriter struct {
+	zerolog.LevelWriter
+	w *syslog.Writer
+}
+
+func (s *syslogWriter) Close() error {
+	return s.w.Close()
+}
+
+func newSyslogWriter(address, tag string) (io.WriteCloser, error) {
+	var network, raddr string
+	if address != "" {
+		u, err := url.Parse(address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid syslog address %q: %w", address, err)
+		}
+		network, raddr = u.Scheme, u.Host
+	}
+
+	w, err := syslog.Dial(network, raddr, syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+
+	return &syslogWriter{LevelWriter: zerolog.SyslogLevelWriter(w), w: w}, nil
+}
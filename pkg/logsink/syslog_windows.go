@@ -0,0 +1,12 @@
+//go:build windows
+
+package logsink
+
+import (
+	"errors"
+	"io"
+)
+
+func newSyslogWriter(address, tag string) (io.WriteCloser, error) {
+	return nil, errors.New("the syslog log sink is not supported on windows")
+}
@@ -0,0 +1,132 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"golang.org/x/sync/singleflight"
+)
+
+// minKeyRefresh limits how often the key set is fetched again for tokens signed with an unknown key
+const minKeyRefresh = time.Minute
+
+// signingAlgorithms are the algorithms access tokens may be signed with. Only asymmetric algorithms
+// are accepted, as the server only holds the authorization server's public keys.
+var signingAlgorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512,
+	jose.EdDSA,
+}
+
+// keySet holds the public keys of the authorization server, fetched from its jwks_uri
+type keySet struct {
+	uri    string
+	client *http.Client
+
+	// fetches shares a fetch of the key set between the tokens waiting on it
+	fetches singleflight.Group
+
+	mu        sync.Mutex
+	keys      map[string]jose.JSONWebKey
+	fetchedAt time.Time
+}
+
+// lookup returns the key with the ID. Tokens may leave out the key ID when the authorization server
+// only has one key.
+func (s *keySet) lookup(kid string) (jose.JSONWebKey, bool, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	refresh := time.Since(s.fetchedAt) >= minKeyRefresh
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true, refresh
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok, refresh
+}
+
+// key returns the key with the ID, fetching the key set again when it isn't known so keys the
+// authorization server rotates in are picked up. The key set is fetched without holding the lock,
+// so tokens signed with known keys aren't held up by a slow authorization server.
+func (s *keySet) key(ctx context.Context, kid string) (jose.JSONWebKey, error) {
+	key, ok, refresh := s.lookup(kid)
+	if ok {
+		return key, nil
+	}
+	if !refresh {
+		return jose.JSONWebKey{}, fmt.Errorf("token is signed with unknown key %q", kid)
+	}
+
+	_, err, _ := s.fetches.Do(s.uri, func() (any, error) {
+		keys, err := fetchKeys(ctx, s.client, s.uri)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.fetchedAt = time.Now()
+		if err != nil {
+			return nil, err
+		}
+		s.keys = keys
+		return nil, nil
+	})
+	if err != nil {
+		return jose.JSONWebKey{}, err
+	}
+
+	key, ok, _ = s.lookup(kid)
+	if !ok {
+		return jose.JSONWebKey{}, fmt.Errorf("token is signed with unknown key %q", kid)
+	}
+	return key, nil
+}
+
+func fetchKeys(ctx context.Context, client *http.Client, uri string) (map[string]jose.JSONWebKey, error) {
+	var set struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := getJSON(ctx, client, uri, &set); err != nil {
+		return nil, fmt.Errorf("failed to fetch the authorization server's keys: %w", err)
+	}
+
+	keys := make(map[string]jose.JSONWebKey, len(set.Keys))
+	for _, raw := range set.Keys {
+		// skip keys of unsupported types rather than rejecting the whole set
+		var key jose.JSONWebKey
+		if err := key.UnmarshalJSON(raw); err != nil || !key.Valid() || !key.IsPublic() {
+			continue
+		}
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		keys[key.KeyID] = key
+	}
+	return keys, nil
+}
+
+// verifyJWT checks the signature of a compact serialized JWT, returning its decoded payload
+func verifyJWT(ctx context.Context, token string, keys *keySet) ([]byte, error) {
+	signed, err := jose.ParseSignedCompact(token, signingAlgorithms)
+	if err != nil {
+		return nil, fmt.Errorf("token is not a JWT signed with a supported algorithm: %w", err)
+	}
+
+	key, err := keys.key(ctx, signed.Signatures[0].Header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := signed.Verify(key)
+	if err != nil {
+		return nil, fmt.Errorf("token signature is invalid: %w", err)
+	}
+	return payload, nil
+}
@@ -0,0 +1,346 @@
+// Package oauth authenticates requests to the HTTP transport with access tokens issued by an
+// OAuth 2.1 or OpenID Connect authorization server, following the MCP authorization spec. The
+// server acts as a protected resource: it advertises the authorization server in its metadata,
+// validates the JWT access tokens clients present and maps their scopes to the toolsets they may use.
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/policy"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// ScopePrefix prefixes the scopes granting access to a toolset, such as buildkite:builds, or to
+	// every toolset with buildkite:all
+	ScopePrefix = "buildkite:"
+	// WriteScope grants calls to the tools of the granted toolsets which aren't read-only
+	WriteScope = ScopePrefix + "write"
+
+	// ProtectedResourceMetadataPath serves the metadata describing the server as a protected resource
+	ProtectedResourceMetadataPath = "/.well-known/oauth-protected-resource"
+	// AuthorizationServerMetadataPath serves the authorization server's metadata, for clients which
+	// look for it on the server rather than following the protected resource metadata
+	AuthorizationServerMetadataPath = "/.well-known/oauth-authorization-server"
+
+	// clockSkew is how far the token's expiry and not before times may be off the server's clock
+	clockSkew = time.Minute
+	// httpTimeout limits how long requests to the authorization server, for its metadata and keys, may take
+	httpTimeout = 10 * time.Second
+)
+
+// Config configures which access tokens are accepted
+type Config struct {
+	// Issuer is the URL of the authorization server, whose metadata is discovered from it
+	Issuer string
+	// ResourceURL is the public URL of the MCP endpoint, which clients request tokens for
+	ResourceURL string
+	// Audience is the audience tokens must be issued for, defaulting to the resource URL
+	Audience string
+	// Scopes are the scopes advertised in the protected resource metadata
+	Scopes     []string
+	HTTPClient *http.Client
+}
+
+// Authenticator validates access tokens issued by the authorization server
+type Authenticator struct {
+	issuer              string
+	audience            string
+	resourceURL         string
+	resourceMetadataURL string
+	scopes              []string
+	// metadata is the authorization server's metadata, as it published it
+	metadata json.RawMessage
+	keys     *keySet
+}
+
+// NewAuthenticator discovers the authorization server's metadata and keys from its issuer URL
+func NewAuthenticator(ctx context.Context, cfg Config) (*Authenticator, error) {
+	if cfg.Issuer == "" {
+		return nil, errors.New("an OAuth issuer is required")
+	}
+
+	resource, err := url.Parse(cfg.ResourceURL)
+	if err != nil || resource.Scheme == "" || resource.Host == "" {
+		return nil, fmt.Errorf("invalid OAuth resource URL %q", cfg.ResourceURL)
+	}
+
+	client := cfg.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if client.Timeout == 0 {
+		// an authorization server which doesn't respond mustn't hold up the requests validated with it
+		withTimeout := *client
+		withTimeout.Timeout = httpTimeout
+		client = &withTimeout
+	}
+
+	metadata, err := discover(ctx, client, cfg.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := json.Unmarshal(metadata, &fields); err != nil {
+		return nil, fmt.Errorf("invalid authorization server metadata: %w", err)
+	}
+	if strings.TrimSuffix(fields.Issuer, "/") != strings.TrimSuffix(cfg.Issuer, "/") {
+		return nil, fmt.Errorf("authorization server metadata is for issuer %q, not %q", fields.Issuer, cfg.Issuer)
+	}
+	if fields.JWKSURI == "" {
+		return nil, errors.New("authorization server metadata has no jwks_uri to validate tokens with")
+	}
+
+	audience := cfg.Audience
+	if audience == "" {
+		audience = cfg.ResourceURL
+	}
+
+	return &Authenticator{
+		issuer:              fields.Issuer,
+		audience:            audience,
+		resourceURL:         cfg.ResourceURL,
+		resourceMetadataURL: (&url.URL{Scheme: resource.Scheme, Host: resource.Host, Path: ProtectedResourceMetadataPath}).String(),
+		scopes:              cfg.Scopes,
+		metadata:            metadata,
+		keys:                &keySet{uri: fields.JWKSURI, client: client},
+	}, nil
+}
+
+// discover fetches the authorization server's metadata, trying the OAuth 2.0 authorization server
+// metadata location before the OpenID Connect one
+func discover(ctx context.Context, client *http.Client, issuer string) (json.RawMessage, error) {
+	u, err := url.Parse(issuer)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid OAuth issuer %q", issuer)
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+
+	locations := []string{
+		(&url.URL{Scheme: u.Scheme, Host: u.Host, Path: AuthorizationServerMetadataPath + path}).String(),
+		(&url.URL{Scheme: u.Scheme, Host: u.Host, Path: path + "/.well-known/openid-configuration"}).String(),
+	}
+
+	var errs []error
+	for _, location := range locations {
+		var metadata json.RawMessage
+		if err := getJSON(ctx, client, location, &metadata); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return metadata, nil
+	}
+	return nil, fmt.Errorf("failed to discover the authorization server metadata: %w", errors.Join(errs...))
+}
+
+func getJSON(ctx context.Context, client *http.Client, location string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned status %d", location, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("GET %s returned invalid JSON: %w", location, err)
+	}
+	return nil
+}
+
+// Claims are the claims of a validated access token
+type Claims struct {
+	Issuer    string   `json:"iss"`
+	Subject   string   `json:"sub"`
+	Email     string   `json:"email,omitempty"`
+	Audience  audience `json:"aud"`
+	ExpiresAt float64  `json:"exp"`
+	NotBefore float64  `json:"nbf,omitempty"`
+	// Scope is the space separated scopes of the token, which some servers put in scp instead
+	Scope string      `json:"scope,omitempty"`
+	Scp   scopeClaims `json:"scp,omitempty"`
+}
+
+// audience is the aud claim, which is either a single string or an array of them
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(a))
+}
+
+// scopeClaims is the scp claim, which is either a space separated string or an array of scopes
+type scopeClaims []string
+
+func (s *scopeClaims) UnmarshalJSON(b []byte) error {
+	var joined string
+	if err := json.Unmarshal(b, &joined); err == nil {
+		*s = strings.Fields(joined)
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(s))
+}
+
+// ToolsetScopes returns the scopes granting access to the toolsets, along with the write scope
+func ToolsetScopes(toolsets []string) []string {
+	scopes := make([]string, 0, len(toolsets)+1)
+	for _, toolset := range toolsets {
+		scopes = append(scopes, ScopePrefix+toolset)
+	}
+	return append(scopes, WriteScope)
+}
+
+// Scopes returns the scopes granted to the token
+func (c *Claims) Scopes() []string {
+	return append(strings.Fields(c.Scope), c.Scp...)
+}
+
+// Principal identifies who the token was issued to, by email address when it has one
+func (c *Claims) Principal() string {
+	if c.Email != "" {
+		return c.Email
+	}
+	return c.Subject
+}
+
+// Authorize returns an error naming the scope the token is missing to call a tool of the toolset
+func (c *Claims) Authorize(toolset string, readOnly bool) error {
+	scopes := c.Scopes()
+
+	if !slices.Contains(scopes, ScopePrefix+"all") && !slices.Contains(scopes, ScopePrefix+toolset) {
+		return fmt.Errorf("the access token is missing the %s%s scope", ScopePrefix, toolset)
+	}
+	if !readOnly && !slices.Contains(scopes, WriteScope) {
+		return fmt.Errorf("the access token is missing the %s scope", WriteScope)
+	}
+	return nil
+}
+
+// Validate checks the token was signed by the authorization server for this server and hasn't
+// expired, returning its claims
+func (a *Authenticator) Validate(ctx context.Context, token string) (*Claims, error) {
+	payload, err := verifyJWT(ctx, token, a.keys)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("invalid token claims: %w", err)
+	}
+
+	now := time.Now()
+	switch {
+	case claims.Issuer != a.issuer:
+		return nil, fmt.Errorf("token was issued by %q, not %q", claims.Issuer, a.issuer)
+	case !slices.Contains(claims.Audience, a.audience):
+		return nil, fmt.Errorf("token was not issued for %q", a.audience)
+	case claims.ExpiresAt == 0:
+		return nil, errors.New("token has no expiry")
+	case now.Add(-clockSkew).After(time.Unix(int64(claims.ExpiresAt), 0)):
+		return nil, errors.New("token has expired")
+	case claims.NotBefore != 0 && now.Add(clockSkew).Before(time.Unix(int64(claims.NotBefore), 0)):
+		return nil, errors.New("token is not valid yet")
+	}
+
+	return &claims, nil
+}
+
+type claimsKey struct{}
+
+// WithClaims records the claims of the caller's access token on the context
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// ClaimsFromContext returns the claims of the caller's access token, or nil when the request
+// wasn't authenticated with one
+func ClaimsFromContext(ctx context.Context) *Claims {
+	claims, _ := ctx.Value(claimsKey{}).(*Claims)
+	return claims
+}
+
+// Middleware rejects requests without a valid access token, pointing the client at the protected
+// resource metadata to obtain one. The claims of accepted tokens are recorded on the request
+// context, along with the token's email or subject as the policy principal.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer resource_metadata=%q`, a.resourceMetadataURL))
+			http.Error(w, "an access token is required", http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := a.Validate(r.Context(), strings.TrimSpace(token))
+		if err != nil {
+			log.Ctx(r.Context()).Debug().Err(err).Msg("Rejected access token")
+
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="invalid_token", error_description=%q, resource_metadata=%q`, err.Error(), a.resourceMetadataURL))
+			http.Error(w, "the access token is invalid", http.StatusUnauthorized)
+			return
+		}
+
+		ctx := WithClaims(r.Context(), claims)
+		ctx = policy.WithPrincipal(ctx, claims.Principal())
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ProtectedResourceMetadataHandler serves the RFC 9728 metadata naming the authorization server
+// clients obtain tokens from
+func (a *Authenticator) ProtectedResourceMetadataHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"resource":                 a.resourceURL,
+			"authorization_servers":    []string{a.issuer},
+			"scopes_supported":         a.scopes,
+			"bearer_methods_supported": []string{"header"},
+			"resource_name":            "Buildkite MCP Server",
+		})
+	})
+}
+
+// AuthorizationServerMetadataHandler serves the authorization server's metadata as discovered
+func (a *Authenticator) AuthorizationServerMetadataHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, a.metadata)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,227 @@
+package oauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/policy"
+	"github.com/stretchr/testify/require"
+)
+
+// testIssuer is an authorization server publishing its metadata and a single RSA signing key
+func testIssuer(t *testing.T) (*httptest.Server, *rsa.PrivateKey) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                 srv.URL,
+			"jwks_uri":               srv.URL + "/jwks",
+			"authorization_endpoint": srv.URL + "/authorize",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "key-1",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+
+	return srv, key
+}
+
+func signToken(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	t.Helper()
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": kid})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestAuthenticatorValidate(t *testing.T) {
+	assert := require.New(t)
+
+	issuer, key := testIssuer(t)
+
+	authenticator, err := NewAuthenticator(context.Background(), Config{Issuer: issuer.URL, ResourceURL: "https://mcp.example.com/mcp"})
+	assert.NoError(err)
+
+	claims := func(overrides map[string]any) map[string]any {
+		c := map[string]any{
+			"iss":   issuer.URL,
+			"sub":   "user-1",
+			"email": "dev@example.com",
+			"aud":   []string{"https://mcp.example.com/mcp"},
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"scope": "buildkite:builds buildkite:write",
+		}
+		for k, v := range overrides {
+			c[k] = v
+		}
+		return c
+	}
+
+	validated, err := authenticator.Validate(context.Background(), signToken(t, key, "key-1", claims(nil)))
+	assert.NoError(err)
+	assert.Equal("dev@example.com", validated.Principal())
+	assert.Equal([]string{"buildkite:builds", "buildkite:write"}, validated.Scopes())
+
+	_, err = authenticator.Validate(context.Background(), signToken(t, key, "key-1", claims(map[string]any{"exp": time.Now().Add(-time.Hour).Unix()})))
+	assert.ErrorContains(err, "expired")
+
+	_, err = authenticator.Validate(context.Background(), signToken(t, key, "key-1", claims(map[string]any{"aud": "https://other.example.com"})))
+	assert.ErrorContains(err, "not issued for")
+
+	_, err = authenticator.Validate(context.Background(), signToken(t, key, "key-1", claims(map[string]any{"iss": "https://evil.example.com"})))
+	assert.ErrorContains(err, "issued by")
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(err)
+	_, err = authenticator.Validate(context.Background(), signToken(t, otherKey, "key-1", claims(nil)))
+	assert.ErrorContains(err, "signature is invalid")
+
+	_, err = authenticator.Validate(context.Background(), signToken(t, key, "key-2", claims(nil)))
+	assert.ErrorContains(err, "unknown key")
+
+	token := signToken(t, key, "key-1", claims(nil))
+	parts := strings.Split(token, ".")
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","kid":"key-1"}`)) + "." + parts[1] + "."
+	_, err = authenticator.Validate(context.Background(), none)
+	assert.Error(err)
+
+	// symmetric algorithms are refused, so the public key can't be used as an HMAC secret
+	hs256 := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","kid":"key-1"}`)) + "." + parts[1]
+	mac := hmac.New(sha256.New, key.N.Bytes())
+	mac.Write([]byte(hs256))
+	_, err = authenticator.Validate(context.Background(), hs256+"."+base64.RawURLEncoding.EncodeToString(mac.Sum(nil)))
+	assert.ErrorContains(err, "supported algorithm")
+}
+
+func TestKeySetFetchesWithoutBlockingKnownKeys(t *testing.T) {
+	assert := require.New(t)
+
+	issuer, key := testIssuer(t)
+
+	// the key set is fetched once, then the authorization server stops responding
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fetches.Add(1) > 1 {
+			<-release
+		}
+		resp, err := http.Get(issuer.URL + "/jwks")
+		if err == nil {
+			defer resp.Body.Close()
+			_, _ = io.Copy(w, resp.Body)
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	keys := &keySet{uri: srv.URL, client: srv.Client()}
+	_, err := keys.key(context.Background(), "key-1")
+	assert.NoError(err)
+
+	keys.mu.Lock()
+	keys.fetchedAt = time.Time{}
+	keys.mu.Unlock()
+
+	// a token signed with an unknown key waits on the fetch, without holding up those with known keys
+	go func() {
+		_, _ = keys.key(context.Background(), "key-2")
+	}()
+	assert.Eventually(func() bool { return fetches.Load() == 2 }, time.Second, time.Millisecond)
+
+	found, err := keys.key(context.Background(), "key-1")
+	assert.NoError(err)
+	assert.Equal(&key.PublicKey, found.Key)
+}
+
+func TestAuthenticatorMiddleware(t *testing.T) {
+	assert := require.New(t)
+
+	issuer, key := testIssuer(t)
+
+	authenticator, err := NewAuthenticator(context.Background(), Config{Issuer: issuer.URL, ResourceURL: "https://mcp.example.com/mcp", Scopes: ToolsetScopes([]string{"builds"})})
+	assert.NoError(err)
+
+	var claims *Claims
+	var principal string
+	handler := authenticator.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims = ClaimsFromContext(r.Context())
+		principal = policy.PrincipalFromContext(r.Context())
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", nil))
+	assert.Equal(http.StatusUnauthorized, rec.Code)
+	assert.Equal(`Bearer resource_metadata="https://mcp.example.com/.well-known/oauth-protected-resource"`, rec.Header().Get("WWW-Authenticate"))
+
+	req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	req.Header.Set("Authorization", "Bearer not-a-token")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(http.StatusUnauthorized, rec.Code)
+	assert.Contains(rec.Header().Get("WWW-Authenticate"), `error="invalid_token"`)
+
+	req = httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	req.Header.Set("Authorization", "Bearer "+signToken(t, key, "key-1", map[string]any{
+		"iss": issuer.URL,
+		"sub": "user-1",
+		"aud": "https://mcp.example.com/mcp",
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"scp": []string{"buildkite:builds"},
+	}))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(http.StatusOK, rec.Code)
+	assert.Equal("user-1", principal)
+	assert.NoError(claims.Authorize("builds", true))
+	assert.ErrorContains(claims.Authorize("builds", false), "buildkite:write")
+	assert.ErrorContains(claims.Authorize("pipelines", true), "buildkite:pipelines")
+
+	rec = httptest.NewRecorder()
+	authenticator.ProtectedResourceMetadataHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, ProtectedResourceMetadataPath, nil))
+	var metadata map[string]any
+	assert.NoError(json.Unmarshal(rec.Body.Bytes(), &metadata))
+	assert.Equal("https://mcp.example.com/mcp", metadata["resource"])
+	assert.Equal([]any{issuer.URL}, metadata["authorization_servers"])
+	assert.Equal([]any{"buildkite:builds", "buildkite:write"}, metadata["scopes_supported"])
+
+	rec = httptest.NewRecorder()
+	authenticator.AuthorizationServerMetadataHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, AuthorizationServerMetadataPath, nil))
+	assert.Contains(rec.Body.String(), issuer.URL+"/authorize")
+}
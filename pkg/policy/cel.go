@@ -0,0 +1,158 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog/log"
+)
+
+// PolicyDeniedError is the code of the structured error returned for tool calls denied by a CEL policy
+const PolicyDeniedError = "policy_denied"
+
+// CELPolicy authorizes tool calls with a CEL expression evaluated before each call, which must
+// return true for the call to proceed. The expression is given:
+//
+//   - tool: the name of the tool being called
+//   - args: the arguments of the call
+//   - principal: who is making the call, when the transport identifies them, or ""
+//   - read_only: whether the tool is annotated as read-only
+//
+// For example, `read_only || (tool == "create_build" && args.pipeline_slug.startsWith("sandbox-"))`
+type CELPolicy struct {
+	expression string
+	program    cel.Program
+}
+
+// CELInput is what a CEL policy is evaluated against
+type CELInput struct {
+	Tool      string
+	Args      map[string]any
+	Principal string
+	ReadOnly  bool
+}
+
+// PolicyDenial is the structured content of a tool result denied by a CEL policy
+type PolicyDenial struct {
+	Error  string `json:"error"`
+	Tool   string `json:"tool"`
+	Reason string `json:"reason"`
+}
+
+// NewCELPolicy compiles the expression, checking it returns a bool. An empty expression returns a
+// nil policy, which allows every call.
+func NewCELPolicy(expression string) (*CELPolicy, error) {
+	if expression == "" {
+		return nil, nil
+	}
+
+	env, err := cel.NewEnv(
+		cel.Variable("tool", cel.StringType),
+		cel.Variable("args", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("principal", cel.StringType),
+		cel.Variable("read_only", cel.BoolType),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create policy environment: %w", err)
+	}
+
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("invalid policy: %w", issues.Err())
+	}
+	if ast.OutputType() != cel.BoolType {
+		return nil, fmt.Errorf("invalid policy: expression must return a bool, not %s", ast.OutputType())
+	}
+
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+
+	return &CELPolicy{expression: expression, program: program}, nil
+}
+
+// Allow evaluates the policy, returning an error when the call is denied. Calls are denied when the
+// expression fails to evaluate, such as when it reads an argument which wasn't given.
+func (p *CELPolicy) Allow(input CELInput) error {
+	if p == nil {
+		return nil
+	}
+
+	args := input.Args
+	if args == nil {
+		args = map[string]any{}
+	}
+
+	out, _, err := p.program.Eval(map[string]any{
+		"tool":      input.Tool,
+		"args":      args,
+		"principal": input.Principal,
+		"read_only": input.ReadOnly,
+	})
+	if err != nil {
+		return fmt.Errorf("tool %q is not permitted, the server policy couldn't be evaluated: %w", input.Tool, err)
+	}
+
+	if allowed, ok := out.Value().(bool); !ok || !allowed {
+		return fmt.Errorf("tool %q is not permitted by the server policy", input.Tool)
+	}
+
+	return nil
+}
+
+// ToolHandlerMiddleware evaluates the policy before the handler is invoked, returning a structured
+// policy error for denied calls so no API call is made.
+func (p *CELPolicy) ToolHandlerMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		err := p.Allow(CELInput{
+			Tool:      request.Params.Name,
+			Args:      request.GetArguments(),
+			Principal: PrincipalFromContext(ctx),
+			ReadOnly:  isReadOnlyTool(ctx, request.Params.Name),
+		})
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Str("mcp.tool.name", request.Params.Name).Msg("Tool call denied by policy")
+
+			result := mcp.NewToolResultStructured(PolicyDenial{
+				Error:  PolicyDeniedError,
+				Tool:   request.Params.Name,
+				Reason: err.Error(),
+			}, err.Error())
+			result.IsError = true
+			return result, nil
+		}
+
+		return next(ctx, request)
+	}
+}
+
+type principalKey struct{}
+
+// WithPrincipal records who is making tool calls on the context, for policies to match against
+func WithPrincipal(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal recorded on the context, or "" if there isn't one
+func PrincipalFromContext(ctx context.Context) string {
+	principal, _ := ctx.Value(principalKey{}).(string)
+	return principal
+}
+
+func isReadOnlyTool(ctx context.Context, name string) bool {
+	s := server.ServerFromContext(ctx)
+	if s == nil {
+		return false
+	}
+
+	tool := s.GetTool(name)
+	if tool == nil || tool.Tool.Annotations.ReadOnlyHint == nil {
+		return false
+	}
+
+	return *tool.Tool.Annotations.ReadOnlyHint
+}
@@ -0,0 +1,84 @@
+package policy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCELPolicy(t *testing.T) {
+	assert := require.New(t)
+
+	policy, err := NewCELPolicy("")
+	assert.NoError(err)
+	assert.Nil(policy)
+	assert.NoError(policy.Allow(CELInput{Tool: "create_build"}))
+
+	_, err = NewCELPolicy("tool ==")
+	assert.ErrorContains(err, "invalid policy")
+
+	_, err = NewCELPolicy("tool")
+	assert.ErrorContains(err, "must return a bool")
+}
+
+func TestCELPolicyAllow(t *testing.T) {
+	policy, err := NewCELPolicy(`read_only || (tool == "create_build" && args.pipeline_slug.startsWith("sandbox-") && principal.endsWith("@example.com"))`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		input   CELInput
+		wantErr bool
+	}{
+		{name: "read-only tool", input: CELInput{Tool: "list_builds", ReadOnly: true}},
+		{name: "allowed write", input: CELInput{Tool: "create_build", Args: map[string]any{"pipeline_slug": "sandbox-web"}, Principal: "dev@example.com"}},
+		{name: "denied pipeline", input: CELInput{Tool: "create_build", Args: map[string]any{"pipeline_slug": "deploy"}, Principal: "dev@example.com"}, wantErr: true},
+		{name: "denied principal", input: CELInput{Tool: "create_build", Args: map[string]any{"pipeline_slug": "sandbox-web"}}, wantErr: true},
+		{name: "missing argument", input: CELInput{Tool: "create_build", Principal: "dev@example.com"}, wantErr: true},
+		{name: "denied tool", input: CELInput{Tool: "unblock_job"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Allow(tt.input)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCELPolicyToolHandlerMiddleware(t *testing.T) {
+	assert := require.New(t)
+
+	policy, err := NewCELPolicy(`principal == "ops"`)
+	assert.NoError(err)
+
+	called := false
+	handler := policy.ToolHandlerMiddleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		called = true
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "create_build"
+
+	result, err := handler(context.Background(), request)
+	assert.NoError(err)
+	assert.True(result.IsError)
+	assert.False(called)
+
+	denial, ok := result.StructuredContent.(PolicyDenial)
+	assert.True(ok)
+	assert.Equal(PolicyDeniedError, denial.Error)
+	assert.Equal("create_build", denial.Tool)
+
+	result, err = handler(WithPrincipal(context.Background(), "ops"), request)
+	assert.NoError(err)
+	assert.False(result.IsError)
+	assert.True(called)
+}
@@ -0,0 +1,200 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog/log"
+)
+
+// ScopePolicy restricts which organizations and pipelines the server may touch.
+// An empty list means no restriction is applied for that dimension.
+type ScopePolicy struct {
+	AllowedOrgs []string
+	// AllowedPipelines holds pipeline slug patterns, matched using path.Match
+	// syntax (e.g. "frontend-*"). Patterns may also be qualified with the org
+	// slug (e.g. "my-org/frontend-*").
+	AllowedPipelines []string
+}
+
+// NewScopePolicy creates a policy from the allowed orgs and pipeline patterns,
+// validating that each pattern is well formed.
+func NewScopePolicy(allowedOrgs, allowedPipelines []string) (ScopePolicy, error) {
+	for _, pattern := range allowedPipelines {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return ScopePolicy{}, fmt.Errorf("invalid pipeline pattern %q: %w", pattern, err)
+		}
+	}
+
+	return ScopePolicy{
+		AllowedOrgs:      allowedOrgs,
+		AllowedPipelines: allowedPipelines,
+	}, nil
+}
+
+// IsEmpty returns true if the policy does not restrict anything
+func (p ScopePolicy) IsEmpty() bool {
+	return len(p.AllowedOrgs) == 0 && len(p.AllowedPipelines) == 0
+}
+
+// CheckOrg returns an error if the organization is not allowed by the policy
+func (p ScopePolicy) CheckOrg(org string) error {
+	if len(p.AllowedOrgs) == 0 {
+		return nil
+	}
+	if !slices.Contains(p.AllowedOrgs, org) {
+		return fmt.Errorf("organization %q is not permitted by the server scope policy", org)
+	}
+	return nil
+}
+
+// CheckPipeline returns an error if the pipeline is not allowed by the policy
+func (p ScopePolicy) CheckPipeline(org, pipeline string) error {
+	if len(p.AllowedPipelines) == 0 {
+		return nil
+	}
+
+	for _, pattern := range p.AllowedPipelines {
+		if matched, _ := path.Match(pattern, pipeline); matched {
+			return nil
+		}
+		if matched, _ := path.Match(pattern, org+"/"+pipeline); matched {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("pipeline %q is not permitted by the server scope policy", pipeline)
+}
+
+// ToolScope declares which pipelines a tool's calls touch, so they can be kept within the allowed
+// pipelines. Tools which declare none are denied while pipelines are restricted.
+type ToolScope struct {
+	// PipelineArgs name the arguments holding pipeline slugs of the org_slug organization
+	PipelineArgs []string
+	// URLArgs name the arguments holding Buildkite API URLs, whose organization and pipeline are checked
+	URLArgs []string
+	// FiltersResults marks tools working across an organization which leave out the results of
+	// pipelines the policy doesn't permit, using the policy from the context
+	FiltersResults bool
+	// Unscoped marks tools which neither read nor change anything belonging to a pipeline, such as
+	// the clusters of an organization
+	Unscoped bool
+}
+
+// ScopeFromSchema returns the scope of a tool taking the pipeline_slug or url arguments
+func ScopeFromSchema(tool mcp.Tool) ToolScope {
+	var scope ToolScope
+	if _, ok := tool.InputSchema.Properties["pipeline_slug"]; ok {
+		scope.PipelineArgs = []string{"pipeline_slug"}
+	}
+	if _, ok := tool.InputSchema.Properties["url"]; ok {
+		scope.URLArgs = []string{"url"}
+	}
+	return scope
+}
+
+// pipelineFromURL returns the organization and pipeline slugs in the path of an API URL, e.g.
+// https://api.buildkite.com/v2/organizations/acme/pipelines/web/builds/1
+func pipelineFromURL(rawURL string) (org, pipeline string) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", ""
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := 0; i+1 < len(segments); i++ {
+		switch segments[i] {
+		case "organizations":
+			org = segments[i+1]
+		case "pipelines":
+			pipeline = segments[i+1]
+		}
+	}
+	return org, pipeline
+}
+
+// CheckArguments validates the org and pipeline slugs found in the arguments of a call to a tool
+// with the scope. While pipelines are restricted, calls must name a pipeline unless the tool
+// filters its results or touches no pipelines.
+func (p ScopePolicy) CheckArguments(scope ToolScope, args map[string]any) error {
+	org, _ := args["org_slug"].(string)
+	if org != "" {
+		if err := p.CheckOrg(org); err != nil {
+			return err
+		}
+	}
+
+	named := false
+	for _, arg := range scope.PipelineArgs {
+		pipeline, _ := args[arg].(string)
+		if pipeline == "" {
+			continue
+		}
+		named = true
+		if err := p.CheckPipeline(org, pipeline); err != nil {
+			return err
+		}
+	}
+
+	for _, arg := range scope.URLArgs {
+		rawURL, _ := args[arg].(string)
+		if rawURL == "" {
+			continue
+		}
+		urlOrg, pipeline := pipelineFromURL(rawURL)
+		if urlOrg == "" && len(p.AllowedOrgs) > 0 {
+			return fmt.Errorf("%s doesn't name an organization, so it can't be checked against the server scope policy", arg)
+		}
+		if err := p.CheckOrg(urlOrg); err != nil {
+			return err
+		}
+		if pipeline == "" {
+			continue
+		}
+		named = true
+		if err := p.CheckPipeline(urlOrg, pipeline); err != nil {
+			return err
+		}
+	}
+
+	if len(p.AllowedPipelines) > 0 && !named && !scope.FiltersResults && !scope.Unscoped {
+		return fmt.Errorf("the call names no pipeline, so it can't be kept within the pipelines permitted by the server scope policy")
+	}
+
+	return nil
+}
+
+type scopePolicyKey struct{}
+
+// WithScopePolicy passes the policy on to tool handlers, which filter results across an
+// organization with it
+func WithScopePolicy(ctx context.Context, p ScopePolicy) context.Context {
+	return context.WithValue(ctx, scopePolicyKey{}, p)
+}
+
+// ScopePolicyFromContext returns the policy tool calls are made under, which is empty if none was set
+func ScopePolicyFromContext(ctx context.Context) ScopePolicy {
+	p, _ := ctx.Value(scopePolicyKey{}).(ScopePolicy)
+	return p
+}
+
+// ToolHandlerMiddleware rejects calls to a tool with the scope whose arguments fall outside the
+// policy before the handler is invoked, so no API call is made, and passes the policy on to the
+// handler.
+func (p ScopePolicy) ToolHandlerMiddleware(scope ToolScope) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if err := p.CheckArguments(scope, request.GetArguments()); err != nil {
+				log.Ctx(ctx).Warn().Err(err).Str("mcp.tool.name", request.Params.Name).Msg("Tool call denied by scope policy")
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			return next(WithScopePolicy(ctx, p), request)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package policy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewScopePolicyInvalidPattern(t *testing.T) {
+	assert := require.New(t)
+
+	_, err := NewScopePolicy(nil, []string{"[invalid"})
+	assert.Error(err)
+}
+
+func TestScopePolicyCheckArguments(t *testing.T) {
+	policy, err := NewScopePolicy([]string{"acme"}, []string{"frontend-*", "acme/deploy"})
+	require.NoError(t, err)
+
+	pipelineScope := ToolScope{PipelineArgs: []string{"pipeline_slug"}}
+	diffScope := ToolScope{PipelineArgs: []string{"slug_a", "slug_b"}}
+	urlScope := ToolScope{PipelineArgs: []string{"pipeline_slug"}, URLArgs: []string{"url"}}
+
+	tests := []struct {
+		name    string
+		scope   ToolScope
+		args    map[string]any
+		wantErr bool
+	}{
+		{name: "unscoped tool", scope: ToolScope{Unscoped: true}, args: map[string]any{}},
+		{name: "allowed org", scope: ToolScope{Unscoped: true}, args: map[string]any{"org_slug": "acme"}},
+		{name: "denied org", scope: ToolScope{Unscoped: true}, args: map[string]any{"org_slug": "other"}, wantErr: true},
+		{name: "allowed pipeline pattern", scope: pipelineScope, args: map[string]any{"org_slug": "acme", "pipeline_slug": "frontend-web"}},
+		{name: "allowed qualified pipeline", scope: pipelineScope, args: map[string]any{"org_slug": "acme", "pipeline_slug": "deploy"}},
+		{name: "denied pipeline", scope: pipelineScope, args: map[string]any{"org_slug": "acme", "pipeline_slug": "backend"}, wantErr: true},
+		{name: "pipeline not named", scope: pipelineScope, args: map[string]any{"org_slug": "acme"}, wantErr: true},
+		{name: "tool filtering its results", scope: ToolScope{FiltersResults: true}, args: map[string]any{"org_slug": "acme"}},
+		{name: "undeclared scope fails closed", args: map[string]any{"org_slug": "acme", "pipeline": "backend"}, wantErr: true},
+		{name: "allowed pipelines", scope: diffScope, args: map[string]any{"org_slug": "acme", "slug_a": "frontend-web", "slug_b": "deploy"}},
+		{name: "one denied pipeline", scope: diffScope, args: map[string]any{"org_slug": "acme", "slug_a": "frontend-web", "slug_b": "backend"}, wantErr: true},
+		{name: "allowed url", scope: urlScope, args: map[string]any{"url": "https://api.buildkite.com/v2/organizations/acme/pipelines/frontend-web/builds/1/artifacts/abc/download"}},
+		{name: "denied url pipeline", scope: urlScope, args: map[string]any{"url": "https://api.buildkite.com/v2/organizations/acme/pipelines/backend/builds/1/artifacts/abc/download"}, wantErr: true},
+		{name: "denied url org", scope: urlScope, args: map[string]any{"url": "https://api.buildkite.com/v2/organizations/other/pipelines/frontend-web/builds/1"}, wantErr: true},
+		{name: "url without org", scope: urlScope, args: map[string]any{"url": "https://example.com/artifact.txt"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.CheckArguments(tt.scope, tt.args)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestScopePolicyEmptyAllowsEverything(t *testing.T) {
+	assert := require.New(t)
+
+	policy := ScopePolicy{}
+	assert.True(policy.IsEmpty())
+	assert.NoError(policy.CheckArguments(ToolScope{}, map[string]any{"org_slug": "any", "pipeline_slug": "thing"}))
+}
+
+func TestScopePolicyToolHandlerMiddleware(t *testing.T) {
+	assert := require.New(t)
+
+	policy, err := NewScopePolicy([]string{"acme"}, nil)
+	assert.NoError(err)
+
+	called := false
+	handler := policy.ToolHandlerMiddleware(ToolScope{Unscoped: true})(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		called = true
+		// the handler is given the policy to filter results with
+		assert.Equal(policy, ScopePolicyFromContext(ctx))
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"org_slug": "other"}
+
+	result, err := handler(context.Background(), request)
+	assert.NoError(err)
+	assert.True(result.IsError)
+	assert.False(called)
+
+	request.Params.Arguments = map[string]any{"org_slug": "acme"}
+	result, err = handler(context.Background(), request)
+	assert.NoError(err)
+	assert.False(result.IsError)
+	assert.True(called)
+}
+
+func TestScopeFromSchema(t *testing.T) {
+	assert := require.New(t)
+
+	scope := ScopeFromSchema(mcp.NewTool("head_artifact", mcp.WithString("pipeline_slug"), mcp.WithString("url")))
+	assert.Equal(ToolScope{PipelineArgs: []string{"pipeline_slug"}, URLArgs: []string{"url"}}, scope)
+
+	assert.Equal(ToolScope{}, ScopeFromSchema(mcp.NewTool("list_agents", mcp.WithString("org_slug"))))
+}
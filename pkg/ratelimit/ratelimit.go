@@ -0,0 +1,292 @@
+// Package ratelimit keeps calls to the Buildkite API within its rate limit, holding requests back
+// when an organization's limit is nearly used up and retrying requests the API rejected for
+// exceeding it.
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/tenant"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// maxRetryDelay caps the wait before retrying a rejected request, as the API's limits reset every minute
+	maxRetryDelay = time.Minute
+
+	// meterName is the instrumentation scope of the rate limit metrics
+	meterName = "buildkite-mcp-server"
+)
+
+// Status is the rate limit of an organization, as last reported by the API
+type Status struct {
+	Organization string    `json:"organization"`
+	Limit        int       `json:"limit"`
+	Remaining    int       `json:"remaining"`
+	ResetsAt     time.Time `json:"resets_at"`
+	// Throttled is how many requests were held back until the limit reset
+	Throttled int `json:"throttled"`
+	// Retried is how many requests were retried after the API rejected them for exceeding the limit
+	Retried    int       `json:"retried"`
+	ObservedAt time.Time `json:"observed_at"`
+}
+
+type organization struct {
+	status Status
+	// callers are the hashes of the tokens which have called the API for the organization
+	callers map[string]bool
+}
+
+// Limiter tracks the rate limit of each organization from the headers of the API's responses.
+// Requests are held back until the limit resets once fewer than reserve requests remain, and
+// requests rejected with 429 Too Many Requests are retried up to retries times.
+type Limiter struct {
+	reserve int
+	retries int
+	now     func() time.Time
+	sleep   func(ctx context.Context, d time.Duration) error
+
+	mu            sync.Mutex
+	organizations map[string]*organization
+
+	remainingGauge metric.Int64Gauge
+	delayed        metric.Int64Counter
+}
+
+// New returns a limiter keeping reserve requests of each organization's limit spare and retrying
+// rejected requests up to retries times
+func New(reserve, retries int) *Limiter {
+	meter := otel.GetMeterProvider().Meter(meterName)
+
+	// the instruments fall back to no-ops if they can't be created
+	remainingGauge, _ := meter.Int64Gauge("buildkite.api.rate_limit.remaining",
+		metric.WithUnit("{request}"),
+		metric.WithDescription("Requests remaining in the organization's Buildkite API rate limit"))
+	delayed, _ := meter.Int64Counter("buildkite.api.rate_limit.delayed",
+		metric.WithUnit("{request}"),
+		metric.WithDescription("Buildkite API requests delayed by the rate limit, by whether they were throttled or retried"))
+
+	return &Limiter{
+		reserve:        reserve,
+		retries:        retries,
+		now:            time.Now,
+		sleep:          sleep,
+		organizations:  map[string]*organization{},
+		remainingGauge: remainingGauge,
+		delayed:        delayed,
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Organization returns the slug of the organization an API path belongs to, or "" for paths outside
+// of an organization, such as the access token's
+func Organization(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		if segment == "organizations" && i+1 < len(segments) {
+			return segments[i+1]
+		}
+	}
+	return ""
+}
+
+// caller identifies the token a request is made with, without keeping the token itself
+func caller(ctx context.Context) string {
+	sum := sha256.Sum256([]byte(tenant.TokenFromContext(ctx)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Status returns the rate limits of the organizations the caller's token has called the API for
+func (l *Limiter) Status(ctx context.Context) []Status {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	c := caller(ctx)
+	statuses := make([]Status, 0, len(l.organizations))
+	for _, org := range l.organizations {
+		if org.callers[c] {
+			statuses = append(statuses, org.status)
+		}
+	}
+	slices.SortFunc(statuses, func(a, b Status) int {
+		return strings.Compare(a.Organization, b.Organization)
+	})
+	return statuses
+}
+
+// acquire returns how long a request to the organization must wait for the limit to reset, counting
+// it against the remaining requests when it can be sent straight away
+func (l *Limiter) acquire(slug string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	org, ok := l.organizations[slug]
+	if !ok || org.status.Limit == 0 {
+		return 0
+	}
+
+	now := l.now()
+	if !now.Before(org.status.ResetsAt) {
+		return 0
+	}
+	if org.status.Remaining > l.reserve {
+		org.status.Remaining--
+		return 0
+	}
+
+	org.status.Throttled++
+	return org.status.ResetsAt.Sub(now)
+}
+
+// observe records the rate limit reported by a response
+func (l *Limiter) observe(ctx context.Context, slug string, resp *http.Response) {
+	limit, err := strconv.Atoi(resp.Header.Get("RateLimit-Limit"))
+	if err != nil {
+		return
+	}
+	remaining, err := strconv.Atoi(resp.Header.Get("RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	reset, _ := strconv.Atoi(resp.Header.Get("RateLimit-Reset"))
+
+	l.mu.Lock()
+	org, ok := l.organizations[slug]
+	if !ok {
+		org = &organization{status: Status{Organization: slug}, callers: map[string]bool{}}
+		l.organizations[slug] = org
+	}
+	now := l.now()
+	org.status.Limit = limit
+	org.status.Remaining = remaining
+	org.status.ResetsAt = now.Add(time.Duration(reset) * time.Second)
+	org.status.ObservedAt = now
+	org.callers[caller(ctx)] = true
+	l.mu.Unlock()
+
+	if l.remainingGauge != nil {
+		l.remainingGauge.Record(ctx, int64(remaining), metric.WithAttributes(attribute.String("org_slug", slug)))
+	}
+}
+
+func (l *Limiter) recordDelay(ctx context.Context, slug, reason string) {
+	if l.delayed != nil {
+		l.delayed.Add(ctx, 1, metric.WithAttributes(attribute.String("org_slug", slug), attribute.String("reason", reason)))
+	}
+}
+
+func (l *Limiter) recordRetry(slug string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if org, ok := l.organizations[slug]; ok {
+		org.status.Retried++
+	}
+}
+
+// retryDelay is how long to wait before retrying a rejected request: until the limit resets when
+// the API says when that is, otherwise backing off exponentially, plus jitter so requests held back
+// together aren't all retried at once
+func retryDelay(resp *http.Response, attempt int) time.Duration {
+	delay := time.Second << attempt
+	for _, header := range []string{"RateLimit-Reset", "Retry-After"} {
+		if seconds, err := strconv.Atoi(resp.Header.Get(header)); err == nil && seconds >= 0 {
+			delay = time.Duration(seconds) * time.Second
+			break
+		}
+	}
+	delay = min(delay, maxRetryDelay)
+
+	return delay + rand.N(delay/2+100*time.Millisecond)
+}
+
+// Transport wraps the transport of the API client with the limiter. A nil limiter returns the
+// transport unchanged.
+func (l *Limiter) Transport(next http.RoundTripper) http.RoundTripper {
+	if l == nil {
+		return next
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{limiter: l, next: next}
+}
+
+type transport struct {
+	limiter *Limiter
+	next    http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	slug := Organization(req.URL.Path)
+
+	if wait := t.limiter.acquire(slug); wait > 0 {
+		log.Ctx(ctx).Info().Str("org_slug", slug).Dur("wait", wait).Msg("Buildkite API rate limit nearly exhausted, waiting for it to reset")
+		t.limiter.recordDelay(ctx, slug, "throttled")
+		if err := t.limiter.sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if err != nil {
+			return resp, err
+		}
+		t.limiter.observe(ctx, slug, resp)
+
+		// requests whose body can't be sent again aren't retried
+		if resp.StatusCode != http.StatusTooManyRequests || attempt >= t.limiter.retries || (req.Body != nil && req.GetBody == nil) {
+			return resp, nil
+		}
+
+		delay := retryDelay(resp, attempt)
+		_ = resp.Body.Close()
+
+		log.Ctx(ctx).Warn().Str("org_slug", slug).Int("attempt", attempt+1).Dur("delay", delay).Msg("Buildkite API rate limit exceeded, retrying request")
+		t.limiter.recordRetry(slug)
+		t.limiter.recordDelay(ctx, slug, "retried")
+		if err := t.limiter.sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+
+		// round trippers must not modify the request they are given
+		retry := req.Clone(ctx)
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			retry.Body = body
+		}
+		req = retry
+	}
+}
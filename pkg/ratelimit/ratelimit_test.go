@@ -0,0 +1,164 @@
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/tenant"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrganization(t *testing.T) {
+	assert := require.New(t)
+
+	assert.Equal("acme", Organization("/v2/organizations/acme/pipelines/deploy/builds"))
+	assert.Equal("acme", Organization("/proxy/v2/organizations/acme"))
+	assert.Equal("", Organization("/v2/access-token"))
+}
+
+// newTestLimiter returns a limiter whose clock only moves when it sleeps, recording each sleep
+func newTestLimiter(reserve, retries int) (*Limiter, *[]time.Duration) {
+	now := time.Now()
+	var slept []time.Duration
+
+	l := New(reserve, retries)
+	l.now = func() time.Time { return now }
+	l.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		now = now.Add(d)
+		return nil
+	}
+	return l, &slept
+}
+
+func TestLimiterThrottlesNearExhaustion(t *testing.T) {
+	assert := require.New(t)
+
+	remaining := 3
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("RateLimit-Limit", "200")
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("RateLimit-Reset", "30")
+		remaining--
+	}))
+	defer srv.Close()
+
+	limiter, slept := newTestLimiter(1, 0)
+	client := &http.Client{Transport: limiter.Transport(nil)}
+
+	for range 4 {
+		resp, err := client.Get(srv.URL + "/v2/organizations/acme/builds")
+		assert.NoError(err)
+		_ = resp.Body.Close()
+	}
+
+	// the third request left 1, which is kept in reserve, so the fourth waited for the reset
+	assert.Equal([]time.Duration{30 * time.Second}, *slept)
+
+	statuses := limiter.Status(context.Background())
+	assert.Len(statuses, 1)
+	assert.Equal("acme", statuses[0].Organization)
+	assert.Equal(200, statuses[0].Limit)
+	assert.Equal(0, statuses[0].Remaining)
+	assert.Equal(1, statuses[0].Throttled)
+
+	// callers with other tokens don't see the organization
+	assert.Empty(limiter.Status(tenant.WithToken(context.Background(), "other-token")))
+}
+
+func TestLimiterRetriesTooManyRequests(t *testing.T) {
+	assert := require.New(t)
+
+	var requests int
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+
+		w.Header().Set("RateLimit-Limit", "200")
+		if requests < 3 {
+			w.Header().Set("RateLimit-Remaining", "0")
+			w.Header().Set("RateLimit-Reset", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("RateLimit-Remaining", "200")
+		w.Header().Set("RateLimit-Reset", "60")
+	}))
+	defer srv.Close()
+
+	limiter, slept := newTestLimiter(0, 3)
+	client := &http.Client{Transport: limiter.Transport(nil)}
+
+	resp, err := client.Post(srv.URL+"/v2/organizations/acme/builds", "application/json", strings.NewReader(`{"commit":"HEAD"}`))
+	assert.NoError(err)
+	_ = resp.Body.Close()
+	assert.Equal(http.StatusOK, resp.StatusCode)
+
+	assert.Equal(3, requests)
+	assert.Equal([]string{`{"commit":"HEAD"}`, `{"commit":"HEAD"}`, `{"commit":"HEAD"}`}, bodies)
+	assert.Len(*slept, 2)
+	for _, d := range *slept {
+		// the reset plus up to half of it again as jitter
+		assert.GreaterOrEqual(d, 2*time.Second)
+		assert.Less(d, 3100*time.Millisecond)
+	}
+	assert.Equal(2, limiter.Status(context.Background())[0].Retried)
+}
+
+func TestLimiterGivesUpAfterRetries(t *testing.T) {
+	assert := require.New(t)
+
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	limiter, slept := newTestLimiter(0, 2)
+	client := &http.Client{Transport: limiter.Transport(nil)}
+
+	resp, err := client.Get(srv.URL + "/v2/organizations/acme/builds")
+	assert.NoError(err)
+	_ = resp.Body.Close()
+	assert.Equal(http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(3, requests)
+
+	// without a reset the delay backs off exponentially
+	assert.Len(*slept, 2)
+	assert.GreaterOrEqual((*slept)[0], time.Second)
+	assert.GreaterOrEqual((*slept)[1], 2*time.Second)
+}
+
+func TestLimiterWaitIsCancelled(t *testing.T) {
+	assert := require.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("RateLimit-Limit", "200")
+		w.Header().Set("RateLimit-Remaining", "0")
+		w.Header().Set("RateLimit-Reset", "60")
+	}))
+	defer srv.Close()
+
+	limiter := New(0, 0)
+	client := &http.Client{Transport: limiter.Transport(nil)}
+
+	resp, err := client.Get(srv.URL + "/v2/organizations/acme/builds")
+	assert.NoError(err)
+	_ = resp.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/v2/organizations/acme/builds", nil)
+	assert.NoError(err)
+	_, err = client.Do(req)
+	assert.ErrorIs(err, context.DeadlineExceeded)
+}
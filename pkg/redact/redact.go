@@ -0,0 +1,191 @@
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// Replacement is the text substituted for each redacted secret
+const Replacement = "[REDACTED]"
+
+// DefaultPatterns match common secret formats which should never be returned to a client
+var DefaultPatterns = []string{
+	// Buildkite API, agent and cluster tokens
+	`\bbk[a-z]{2}_[A-Za-z0-9]{20,}`,
+	// AWS access key IDs
+	`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`,
+	// AWS secret access keys assigned to a well known variable
+	`(?i)aws_secret_access_key\s*[=:]\s*["']?[A-Za-z0-9/+=]{40}["']?`,
+	// GitHub personal access, OAuth and app tokens
+	`\bgh[pousr]_[A-Za-z0-9]{36,}`,
+	`\bgithub_pat_[A-Za-z0-9_]{22,}`,
+	// Slack tokens
+	`\bxox[abposr]-[A-Za-z0-9-]{10,}`,
+	// Bearer tokens in authorization headers
+	`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`,
+	// PEM encoded private keys
+	`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`,
+}
+
+// pemBegin and pemEnd match the lines a PEM encoded private key starts and ends on, for redacting a
+// key spread over several lines of a log
+var (
+	pemBegin = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)
+	pemEnd   = regexp.MustCompile(`-----END [A-Z ]*PRIVATE KEY-----`)
+)
+
+// secretKey matches the names of environment variables which usually hold secrets, such as
+// NPM_TOKEN, AWS_SECRET_ACCESS_KEY or DB_PASSWORD
+var secretKey = regexp.MustCompile(`(?i)(^|_)(TOKEN|KEY|SECRET|CREDENTIALS?)$|PASSW(OR)?D`)
+
+// Redactor replaces secrets matching a set of patterns. A nil Redactor performs no redaction.
+type Redactor struct {
+	patterns []*regexp.Regexp
+
+	unsafeEnvValues bool
+}
+
+// Option configures a Redactor
+type Option func(*Redactor)
+
+// WithUnsafeEnvValues allows callers to ask for the values of environment variables with secret-like
+// names rather than having them redacted
+func WithUnsafeEnvValues(allow bool) Option {
+	return func(r *Redactor) {
+		r.unsafeEnvValues = allow
+	}
+}
+
+// New creates a Redactor using the default patterns plus any additional patterns provided
+func New(additionalPatterns []string, opts ...Option) (*Redactor, error) {
+	r := &Redactor{}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	for _, pattern := range slices.Concat(DefaultPatterns, additionalPatterns) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+
+	return r, nil
+}
+
+// Redact replaces all secrets in the text, returning the redacted text and the number of redactions made
+func (r *Redactor) Redact(text string) (string, int) {
+	if r == nil || text == "" {
+		return text, 0
+	}
+
+	count := 0
+	for _, re := range r.patterns {
+		text = re.ReplaceAllStringFunc(text, func(string) string {
+			count++
+			return Replacement
+		})
+	}
+
+	return text, count
+}
+
+// LineRedactor redacts text which has been split into lines, such as the entries of a job log, one
+// line at a time. Unlike redacting each line on its own, it redacts every line of a private key from
+// its BEGIN line to its END line.
+type LineRedactor struct {
+	r     *Redactor
+	inKey bool
+}
+
+// Lines returns a LineRedactor for redacting consecutive lines of the same text
+func (r *Redactor) Lines() *LineRedactor {
+	return &LineRedactor{r: r}
+}
+
+// Reset forgets any private key left open, for when the next line doesn't follow on from the last
+func (l *LineRedactor) Reset() {
+	l.inKey = false
+}
+
+// Redact replaces all secrets in the line, along with the whole line if it's within a private key,
+// returning the redacted line and the number of redactions made
+func (l *LineRedactor) Redact(line string) (string, int) {
+	if l.r == nil {
+		return line, 0
+	}
+
+	if l.inKey {
+		loc := pemEnd.FindStringIndex(line)
+		if loc == nil {
+			return Replacement, 0
+		}
+
+		l.inKey = false
+		rest, n := l.Redact(line[loc[1]:])
+		return Replacement + rest, n
+	}
+
+	// a key which starts and ends on the line is redacted by the default patterns
+	line, count := l.r.Redact(line)
+	if loc := pemBegin.FindStringIndex(line); loc != nil {
+		l.inKey = true
+		return line[:loc[0]] + Replacement, count + 1
+	}
+
+	return line, count
+}
+
+// RedactMap redacts string values in the map in place, returning the number of redactions made
+func (r *Redactor) RedactMap(values map[string]any) int {
+	if r == nil {
+		return 0
+	}
+
+	count := 0
+	for key, value := range values {
+		str, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		redacted, n := r.Redact(str)
+		if n > 0 {
+			values[key] = redacted
+			count += n
+		}
+	}
+
+	return count
+}
+
+// IsSecretKey returns true if the environment variable name looks like it holds a secret
+func IsSecretKey(key string) bool {
+	return secretKey.MatchString(key)
+}
+
+// AllowsUnsafeEnvValues returns true if callers may ask for the values of environment variables with
+// secret-like names
+func (r *Redactor) AllowsUnsafeEnvValues() bool {
+	return r != nil && r.unsafeEnvValues
+}
+
+// RedactEnv replaces the values of environment variables with secret-like names, and redacts secrets
+// in the other values, in place. It returns the number of redactions made.
+func (r *Redactor) RedactEnv(env map[string]any) int {
+	if r == nil {
+		return 0
+	}
+
+	count := 0
+	for key, value := range env {
+		if IsSecretKey(key) && value != "" {
+			env[key] = Replacement
+			count++
+		}
+	}
+
+	return count + r.RedactMap(env)
+}
@@ -0,0 +1,70 @@
+// Package reltime parses the times given to date and time filters, which can be relative to now
+// such as -24h, -7d or yesterday, so callers don't need to compute timestamps themselves.
+package reltime
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats describes the values Parse accepts, for parameter and flag help
+const Formats = "a duration ago such as -30m, -24h or -7d, 'now', 'today', 'yesterday', a date (YYYY-MM-DD) or an RFC 3339 timestamp"
+
+// Parse resolves a time relative to now. Durations are always back from now, so 24h and -24h are
+// both a day ago, and may be given in days (d) or weeks (w) as well as the units of
+// time.ParseDuration. Dates, today and yesterday are the start of those days in UTC, as the
+// Buildkite API reports times in UTC.
+func Parse(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	switch strings.ToLower(value) {
+	case "now":
+		return now, nil
+	case "today":
+		return startOfDay(now.UTC()), nil
+	case "yesterday":
+		return startOfDay(now.UTC()).AddDate(0, 0, -1), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+
+	if d, ok := parseDuration(value); ok {
+		return now.Add(-d), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q, expected %s", value, Formats)
+}
+
+// parseDuration parses a duration ignoring its sign, with days and weeks as well as the units of
+// time.ParseDuration
+func parseDuration(value string) (time.Duration, bool) {
+	value = strings.TrimPrefix(strings.TrimPrefix(value, "-"), "+")
+
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if amount, ok := strings.CutSuffix(value, suffix); ok {
+			n, err := strconv.Atoi(amount)
+			if err != nil || n < 0 {
+				return 0, false
+			}
+			return time.Duration(n) * unit, true
+		}
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, false
+	}
+	return d, true
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,43 @@
+package reltime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	assert := require.New(t)
+
+	now := time.Date(2025, 6, 4, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		value    string
+		expected time.Time
+	}{
+		{"-24h", now.Add(-24 * time.Hour)},
+		{"2h", now.Add(-2 * time.Hour)},
+		{"-90m", now.Add(-90 * time.Minute)},
+		{"-7d", now.AddDate(0, 0, -7)},
+		{"2w", now.AddDate(0, 0, -14)},
+		{"now", now},
+		{"today", time.Date(2025, 6, 4, 0, 0, 0, 0, time.UTC)},
+		{"Yesterday", time.Date(2025, 6, 3, 0, 0, 0, 0, time.UTC)},
+		{"2025-05-01", time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{"2025-05-01T08:00:00+02:00", time.Date(2025, 5, 1, 6, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			parsed, err := Parse(tt.value, now)
+			require.NoError(t, err)
+			require.True(t, tt.expected.Equal(parsed), "expected %s, got %s", tt.expected, parsed)
+		})
+	}
+
+	for _, value := range []string{"", "last week", "-d", "1.5d", "2025-13-01"} {
+		_, err := Parse(value, now)
+		assert.Error(err, value)
+	}
+}
@@ -0,0 +1,175 @@
+// Package retry retries calls to the Buildkite API which failed for reasons that are likely to pass,
+// such as a 502 from a load balancer or a connection reset, backing off exponentially between
+// attempts. Only requests which are safe to send again are retried.
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/cenkalti/backoff/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// maxRetryAfter caps how long a Retry-After header can hold a retry back for, as waiting longer
+// would outlast most tool calls
+const maxRetryAfter = 30 * time.Second
+
+type idempotentKey struct{}
+
+// WithIdempotent marks whether the requests made with the context are safe to send again whatever
+// their method, such as the GraphQL queries of read-only tools, which are sent as POSTs
+func WithIdempotent(ctx context.Context, idempotent bool) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, idempotent)
+}
+
+// IsIdempotent returns whether the requests made with the context were marked safe to send again
+func IsIdempotent(ctx context.Context) bool {
+	idempotent, _ := ctx.Value(idempotentKey{}).(bool)
+	return idempotent
+}
+
+// Policy is how many times, and how long apart, requests which failed transiently are retried
+type Policy struct {
+	retries      int
+	initialDelay time.Duration
+	maxDelay     time.Duration
+	sleep        func(ctx context.Context, d time.Duration) error
+}
+
+// New returns a policy retrying a request up to retries times, waiting initialDelay before the first
+// retry and doubling it, with jitter, up to maxDelay. A policy without retries is nil.
+func New(retries int, initialDelay, maxDelay time.Duration) *Policy {
+	if retries <= 0 {
+		return nil
+	}
+	return &Policy{retries: retries, initialDelay: initialDelay, maxDelay: max(initialDelay, maxDelay), sleep: sleep}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// backOff returns the exponential backoff between the retries of a request
+func (p *Policy) backOff() *backoff.ExponentialBackOff {
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = p.initialDelay
+	b.MaxInterval = p.maxDelay
+	b.Multiplier = 2
+	return b
+}
+
+// idempotent returns whether a request can be sent again without repeating its effect, which is
+// the case for safe methods and requests marked idempotent. The API's PUTs aren't all idempotent,
+// as rebuilding a build creates another each time.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != "" || IsIdempotent(req.Context())
+}
+
+// Transient returns whether a request failed in a way which is likely to pass if it's sent again:
+// with a 5xx response other than those saying what was asked isn't supported, or a connection which
+// was reset, refused, closed early or timed out without the caller giving up
+func Transient(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		if ctx.Err() != nil || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			return false
+		}
+		if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return true
+		}
+		var netErr net.Error
+		return errors.As(err, &netErr) && netErr.Timeout()
+	}
+	return resp.StatusCode >= http.StatusInternalServerError && resp.StatusCode != http.StatusNotImplemented && resp.StatusCode != http.StatusHTTPVersionNotSupported
+}
+
+// retryAfter returns how long a response, such as a 503, asks for requests to be held back, if it says
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return min(time.Duration(seconds)*time.Second, maxRetryAfter), true
+}
+
+// Transport wraps the transport of an API client with the policy. A nil policy returns the
+// transport unchanged.
+func (p *Policy) Transport(next http.RoundTripper) http.RoundTripper {
+	if p == nil {
+		return next
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{policy: p, next: next}
+}
+
+type transport struct {
+	policy *Policy
+	next   http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	// requests whose body can't be sent again aren't retried
+	if !idempotent(req) || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		return t.next.RoundTrip(req)
+	}
+
+	b := t.policy.backOff()
+	for attempt := 0; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if attempt >= t.policy.retries || !Transient(ctx, resp, err) {
+			return resp, err
+		}
+
+		delay, ok := retryAfter(resp)
+		if !ok {
+			delay = b.NextBackOff()
+		}
+		event := log.Ctx(ctx).Warn().Str("method", req.Method).Str("path", req.URL.Path).Int("attempt", attempt+1).Dur("delay", delay)
+		if err != nil {
+			event.Err(err).Msg("Buildkite API request failed, retrying")
+		} else {
+			event.Int("status", resp.StatusCode).Msg("Buildkite API request failed, retrying")
+			_ = resp.Body.Close()
+		}
+
+		if err := t.policy.sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+
+		// round trippers must not modify the request they are given
+		retry := req.Clone(ctx)
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			retry.Body = body
+		}
+		req = retry
+	}
+}
@@ -0,0 +1,164 @@
+package retry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestPolicy returns a policy which doesn't wait between retries, recording each wait
+func newTestPolicy(retries int) (*Policy, *[]time.Duration) {
+	var slept []time.Duration
+	p := New(retries, 100*time.Millisecond, time.Second)
+	p.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+	return p, &slept
+}
+
+// failingServer fails the first failures requests with the status, then succeeds
+func failingServer(t *testing.T, failures int32, status int) (*httptest.Server, *atomic.Int32) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if requests.Add(1) <= failures {
+			http.Error(w, "bad gateway", status)
+			return
+		}
+		_, _ = w.Write(append([]byte("ok "), body...))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestTransportRetriesTransientFailures(t *testing.T) {
+	assert := require.New(t)
+
+	srv, requests := failingServer(t, 2, http.StatusBadGateway)
+	policy, slept := newTestPolicy(3)
+	client := &http.Client{Transport: policy.Transport(nil)}
+
+	resp, err := client.Get(srv.URL + "/v2/organizations/acme/builds")
+	assert.NoError(err)
+	defer resp.Body.Close()
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.Equal(int32(3), requests.Load())
+
+	// each retry waits longer, within the jitter of the backoff
+	assert.Len(*slept, 2)
+	assert.InDelta(100*time.Millisecond, (*slept)[0], float64(50*time.Millisecond))
+	assert.InDelta(200*time.Millisecond, (*slept)[1], float64(100*time.Millisecond))
+}
+
+func TestTransportGivesUpAfterRetries(t *testing.T) {
+	assert := require.New(t)
+
+	srv, requests := failingServer(t, 5, http.StatusServiceUnavailable)
+	policy, _ := newTestPolicy(2)
+	client := &http.Client{Transport: policy.Transport(nil)}
+
+	resp, err := client.Get(srv.URL)
+	assert.NoError(err)
+	defer resp.Body.Close()
+	assert.Equal(http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(int32(3), requests.Load())
+}
+
+func TestTransportOnlyRetriesIdempotentRequests(t *testing.T) {
+	assert := require.New(t)
+
+	policy, _ := newTestPolicy(3)
+	client := &http.Client{Transport: policy.Transport(nil)}
+
+	// a POST could create a build twice
+	srv, requests := failingServer(t, 1, http.StatusBadGateway)
+	resp, err := client.Post(srv.URL, "application/json", strings.NewReader(`{"commit":"HEAD"}`))
+	assert.NoError(err)
+	_ = resp.Body.Close()
+	assert.Equal(http.StatusBadGateway, resp.StatusCode)
+	assert.Equal(int32(1), requests.Load())
+
+	// unless it's marked idempotent, such as a read-only tool's GraphQL query, whose body is sent again
+	srv, requests = failingServer(t, 1, http.StatusBadGateway)
+	req, err := http.NewRequestWithContext(WithIdempotent(context.Background(), true), http.MethodPost, srv.URL, strings.NewReader(`{"query":"{viewer{user{name}}}"}`))
+	assert.NoError(err)
+	resp, err = client.Do(req)
+	assert.NoError(err)
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(err)
+	_ = resp.Body.Close()
+	assert.Equal(`ok {"query":"{viewer{user{name}}}"}`, string(body))
+	assert.Equal(int32(2), requests.Load())
+}
+
+func TestTransportDoesNotRetryClientErrors(t *testing.T) {
+	assert := require.New(t)
+
+	srv, requests := failingServer(t, 1, http.StatusNotFound)
+	policy, _ := newTestPolicy(3)
+	client := &http.Client{Transport: policy.Transport(nil)}
+
+	resp, err := client.Get(srv.URL)
+	assert.NoError(err)
+	_ = resp.Body.Close()
+	assert.Equal(http.StatusNotFound, resp.StatusCode)
+	assert.Equal(int32(1), requests.Load())
+}
+
+func TestTransportHonoursRetryAfter(t *testing.T) {
+	assert := require.New(t)
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	policy, slept := newTestPolicy(3)
+	client := &http.Client{Transport: policy.Transport(nil)}
+
+	resp, err := client.Get(srv.URL)
+	assert.NoError(err)
+	_ = resp.Body.Close()
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.Equal([]time.Duration{2 * time.Second}, *slept)
+}
+
+func TestTransient(t *testing.T) {
+	assert := require.New(t)
+
+	ctx := context.Background()
+	assert.True(Transient(ctx, &http.Response{StatusCode: http.StatusInternalServerError}, nil))
+	assert.True(Transient(ctx, &http.Response{StatusCode: http.StatusGatewayTimeout}, nil))
+	assert.False(Transient(ctx, &http.Response{StatusCode: http.StatusNotImplemented}, nil))
+	assert.False(Transient(ctx, &http.Response{StatusCode: http.StatusTooManyRequests}, nil))
+	assert.False(Transient(ctx, &http.Response{StatusCode: http.StatusOK}, nil))
+
+	assert.True(Transient(ctx, nil, syscall.ECONNRESET))
+	assert.True(Transient(ctx, nil, io.ErrUnexpectedEOF))
+	assert.False(Transient(ctx, nil, context.DeadlineExceeded))
+
+	// requests the caller gave up on aren't retried
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.False(Transient(cancelled, nil, syscall.ECONNRESET))
+}
+
+func TestNilPolicy(t *testing.T) {
+	assert := require.New(t)
+
+	assert.Nil(New(0, time.Second, time.Second))
+	assert.Equal(http.DefaultTransport, New(0, time.Second, time.Second).Transport(http.DefaultTransport))
+}
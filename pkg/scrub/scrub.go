@@ -0,0 +1,182 @@
+package scrub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultReplacement is used when a rule does not specify a replacement
+const DefaultReplacement = "[SCRUBBED]"
+
+// Rule describes a single scrubbing regular expression and its replacement.
+//
+// On the command line a rule is written as "name=pattern" and uses the default
+// replacement. In the config file a rule is an object with "name", "pattern" and
+// optionally "replacement" keys.
+type Rule struct {
+	Name        string `json:"name"`
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// UnmarshalText parses a rule in the "name=pattern" form
+func (r *Rule) UnmarshalText(text []byte) error {
+	name, pattern, ok := strings.Cut(string(text), "=")
+	if !ok || name == "" || pattern == "" {
+		return fmt.Errorf("invalid scrub rule %q, expected format 'name=pattern'", string(text))
+	}
+
+	*r = Rule{Name: name, Pattern: pattern}
+	return nil
+}
+
+// UnmarshalJSON parses a rule from either the object or "name=pattern" string form
+func (r *Rule) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		return r.UnmarshalText([]byte(text))
+	}
+
+	type plain Rule
+	var rule plain
+	if err := json.Unmarshal(data, &rule); err != nil {
+		return err
+	}
+
+	*r = Rule(rule)
+	return nil
+}
+
+type compiledRule struct {
+	Rule
+	re *regexp.Regexp
+}
+
+// Scrubber applies operator supplied rules to all tool text output, recording
+// the matches of each rule as a metric for audit purposes.
+type Scrubber struct {
+	rules []*compiledRule
+}
+
+// New compiles the rules, returning an error if a rule is incomplete, has a
+// duplicate name or an invalid pattern.
+func New(rules []Rule) (*Scrubber, error) {
+	s := &Scrubber{}
+	seen := map[string]bool{}
+
+	for _, rule := range rules {
+		if rule.Name == "" {
+			return nil, errors.New("scrub rule is missing a name")
+		}
+		if seen[rule.Name] {
+			return nil, fmt.Errorf("duplicate scrub rule %q", rule.Name)
+		}
+		seen[rule.Name] = true
+
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern for scrub rule %q: %w", rule.Name, err)
+		}
+
+		if rule.Replacement == "" {
+			rule.Replacement = DefaultReplacement
+		}
+
+		s.rules = append(s.rules, &compiledRule{Rule: rule, re: re})
+	}
+
+	return s, nil
+}
+
+// IsEmpty returns true if there are no rules to apply
+func (s *Scrubber) IsEmpty() bool {
+	return s == nil || len(s.rules) == 0
+}
+
+// Scrub applies every rule to the text in order, returning the scrubbed text and
+// the number of matches for each rule which matched.
+func (s *Scrubber) Scrub(ctx context.Context, text string) (string, map[string]int) {
+	if s.IsEmpty() || text == "" {
+		return text, nil
+	}
+
+	var hits map[string]int
+	for _, rule := range s.rules {
+		matches := len(rule.re.FindAllStringIndex(text, -1))
+		if matches == 0 {
+			continue
+		}
+
+		text = rule.re.ReplaceAllString(text, rule.Replacement)
+
+		if hits == nil {
+			hits = map[string]int{}
+		}
+		hits[rule.Name] += matches
+	}
+
+	trace.RecordScrubMatches(ctx, hits)
+
+	return text, hits
+}
+
+type scrubberKey struct{}
+
+// WithScrubber passes the scrubber on to tool handlers, which apply it to what
+// they send outside of their result, such as progress notifications
+func WithScrubber(ctx context.Context, s *Scrubber) context.Context {
+	return context.WithValue(ctx, scrubberKey{}, s)
+}
+
+// FromContext returns the scrubber tool calls are made under, which is nil if
+// none was set
+func FromContext(ctx context.Context) *Scrubber {
+	s, _ := ctx.Value(scrubberKey{}).(*Scrubber)
+	return s
+}
+
+// ToolHandlerMiddleware scrubs the text content of every tool result before it
+// is returned to the client, and passes the scrubber on to the handler.
+func (s *Scrubber) ToolHandlerMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := next(WithScrubber(ctx, s), request)
+		if err != nil || result == nil {
+			return result, err
+		}
+
+		total := map[string]int{}
+		for i, content := range result.Content {
+			textContent, ok := content.(mcp.TextContent)
+			if !ok {
+				continue
+			}
+
+			var hits map[string]int
+			textContent.Text, hits = s.Scrub(ctx, textContent.Text)
+			result.Content[i] = textContent
+
+			for name, n := range hits {
+				total[name] += n
+			}
+		}
+
+		if len(total) > 0 {
+			event := log.Ctx(ctx).Info().Str("mcp.tool.name", request.Params.Name)
+			for name, n := range total {
+				event = event.Int("scrub."+name, n)
+			}
+			event.Msg("Scrubbed tool output")
+		}
+
+		return result, nil
+	}
+}
@@ -0,0 +1,121 @@
+package scrub
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestRuleUnmarshal(t *testing.T) {
+	assert := require.New(t)
+
+	var rule Rule
+	assert.NoError(rule.UnmarshalText([]byte(`email=[a-z]+@example\.com`)))
+	assert.Equal(Rule{Name: "email", Pattern: `[a-z]+@example\.com`}, rule)
+
+	assert.Error(rule.UnmarshalText([]byte("missing-pattern")))
+
+	var rules []Rule
+	err := json.Unmarshal([]byte(`[
+		{"name": "hosts", "pattern": "[a-z0-9-]+\\.internal", "replacement": "[HOST]"},
+		"email=[a-z]+@example\\.com"
+	]`), &rules)
+	assert.NoError(err)
+	assert.Equal([]Rule{
+		{Name: "hosts", Pattern: `[a-z0-9-]+\.internal`, Replacement: "[HOST]"},
+		{Name: "email", Pattern: `[a-z]+@example\.com`},
+	}, rules)
+}
+
+func TestNewInvalidRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []Rule
+	}{
+		{name: "missing name", rules: []Rule{{Pattern: "a"}}},
+		{name: "duplicate name", rules: []Rule{{Name: "a", Pattern: "a"}, {Name: "a", Pattern: "b"}}},
+		{name: "invalid pattern", rules: []Rule{{Name: "a", Pattern: "["}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.rules)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestScrub(t *testing.T) {
+	assert := require.New(t)
+
+	reader := sdkmetric.NewManualReader()
+	previous := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	defer otel.SetMeterProvider(previous)
+
+	scrubber, err := New([]Rule{
+		{Name: "email", Pattern: `[a-z]+@example\.com`},
+		{Name: "hosts", Pattern: `[a-z0-9-]+\.corp\.internal`, Replacement: "[HOST]"},
+	})
+	assert.NoError(err)
+
+	text, hits := scrubber.Scrub(context.Background(), "alice@example.com deployed to db-1.corp.internal and bob@example.com")
+	assert.Equal("[SCRUBBED] deployed to [HOST] and [SCRUBBED]", text)
+	assert.Equal(map[string]int{"email": 2, "hosts": 1}, hits)
+
+	_, hits = scrubber.Scrub(context.Background(), "nothing to see here")
+	assert.Empty(hits)
+
+	// the matches are counted by rule
+	var metrics metricdata.ResourceMetrics
+	assert.NoError(reader.Collect(context.Background(), &metrics))
+	assert.Len(metrics.ScopeMetrics, 1)
+	assert.Len(metrics.ScopeMetrics[0].Metrics, 1)
+	matches := metrics.ScopeMetrics[0].Metrics[0]
+	assert.Equal("mcp.scrub.matches", matches.Name)
+
+	counts := map[string]int64{}
+	for _, point := range matches.Data.(metricdata.Sum[int64]).DataPoints {
+		rule, _ := point.Attributes.Value(attribute.Key("rule"))
+		counts[rule.AsString()] = point.Value
+	}
+	assert.Equal(map[string]int64{"email": 2, "hosts": 1}, counts)
+}
+
+func TestScrubberNil(t *testing.T) {
+	assert := require.New(t)
+
+	var scrubber *Scrubber
+	assert.True(scrubber.IsEmpty())
+
+	text, hits := scrubber.Scrub(context.Background(), "alice@example.com")
+	assert.Equal("alice@example.com", text)
+	assert.Nil(hits)
+}
+
+func TestToolHandlerMiddleware(t *testing.T) {
+	assert := require.New(t)
+
+	scrubber, err := New([]Rule{{Name: "email", Pattern: `[a-z]+@example\.com`}})
+	assert.NoError(err)
+
+	handler := scrubber.ToolHandlerMiddleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// handlers are given the scrubber for what they send outside of their result
+		assert.Same(scrubber, FromContext(ctx))
+		return mcp.NewToolResultText(`{"creator":"alice@example.com"}`), nil
+	})
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	assert.NoError(err)
+
+	textContent, ok := result.Content[0].(mcp.TextContent)
+	assert.True(ok)
+	assert.Equal(`{"creator":"[SCRUBBED]"}`, textContent.Text)
+}
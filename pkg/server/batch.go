@@ -0,0 +1,333 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	executeBatchToolName = "execute_batch"
+	maxBatchSteps        = 20
+
+	BatchStepSuccess = "success"
+	BatchStepError   = "error"
+	BatchStepSkipped = "skipped"
+)
+
+// batchReference matches references to earlier results in step arguments, such as ${latest.0.number}
+var batchReference = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+type ExecuteBatchArgs struct {
+	Steps           []BatchStep `json:"steps"`
+	ContinueOnError bool        `json:"continue_on_error,omitempty"`
+}
+
+type BatchStep struct {
+	ID        string         `json:"id,omitempty"`
+	Tool      string         `json:"tool"`
+	Arguments map[string]any `json:"arguments,omitempty"`
+}
+
+type BatchStepResult struct {
+	ID     string `json:"id"`
+	Tool   string `json:"tool"`
+	Status string `json:"status"`
+	Result any    `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type BatchResult struct {
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Skipped   int               `json:"skipped"`
+	Steps     []BatchStepResult `json:"steps"`
+}
+
+// executeBatch returns the execute_batch tool, which calls the other registered tools in order
+// through the server's middleware, so each step is traced, audited and authorized on its own
+func (r *Reloader) executeBatch(readOnly bool) (mcp.Tool, server.ToolHandlerFunc) {
+	tool := mcp.NewTool(executeBatchToolName,
+		mcp.WithDescription("Call several tools in order in one request, returning the result of each step. Arguments of a step can reference the result of an earlier step as ${id.path}, where id is the earlier step's id (or its index when it has none) and path is a dot separated path into its JSON result, e.g. ${builds.0.number}. An argument which is only a reference keeps the referenced value's type. Steps after a failed step are skipped unless continue_on_error is set"),
+		mcp.WithArray("steps",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf("The tool calls to make in order (max %d)", maxBatchSteps)),
+			mcp.Items(map[string]any{
+				"type":     "object",
+				"required": []string{"tool"},
+				"properties": map[string]any{
+					"id": map[string]any{
+						"type":        "string",
+						"description": "Name for referencing the step's result from later steps",
+					},
+					"tool": map[string]any{
+						"type":        "string",
+						"description": "The name of the tool to call",
+					},
+					"arguments": map[string]any{
+						"type":        "object",
+						"description": "The arguments of the tool call",
+					},
+				},
+			}),
+		),
+		mcp.WithBoolean("continue_on_error",
+			mcp.Description("Keep running the remaining steps after a step fails"),
+		),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{
+			Title:        "Execute Batch",
+			ReadOnlyHint: mcp.ToBoolPtr(readOnly),
+		}),
+	)
+
+	handler := mcp.NewTypedToolHandler(func(ctx context.Context, request mcp.CallToolRequest, args ExecuteBatchArgs) (*mcp.CallToolResult, error) {
+		ctx, span := trace.Start(ctx, "server.ExecuteBatch")
+		defer span.End()
+
+		if len(args.Steps) == 0 {
+			return mcp.NewToolResultError("steps parameter is required"), nil
+		}
+		if len(args.Steps) > maxBatchSteps {
+			return mcp.NewToolResultError(fmt.Sprintf("at most %d steps can be run in a batch", maxBatchSteps)), nil
+		}
+
+		ids := map[string]bool{}
+		for i := range args.Steps {
+			step := &args.Steps[i]
+			if step.Tool == "" {
+				return mcp.NewToolResultError(fmt.Sprintf("step %d: tool is required", i)), nil
+			}
+			if step.Tool == executeBatchToolName {
+				return mcp.NewToolResultError(fmt.Sprintf("step %d: batches can't be nested", i)), nil
+			}
+			if _, ok := (*r.handlers.Load())[step.Tool]; !ok {
+				return mcp.NewToolResultError(fmt.Sprintf("step %d: unknown tool %q", i, step.Tool)), nil
+			}
+			if step.ID == "" {
+				step.ID = strconv.Itoa(i)
+			}
+			if strings.Contains(step.ID, ".") {
+				return mcp.NewToolResultError(fmt.Sprintf("step %d: id %q can't contain a dot", i, step.ID)), nil
+			}
+			if ids[step.ID] {
+				return mcp.NewToolResultError(fmt.Sprintf("step %d: id %q is used by an earlier step", i, step.ID)), nil
+			}
+			ids[step.ID] = true
+		}
+
+		span.SetAttributes(
+			attribute.Int("step_count", len(args.Steps)),
+			attribute.Bool("continue_on_error", args.ContinueOnError),
+		)
+
+		result := BatchResult{Steps: []BatchStepResult{}}
+		results := map[string]any{}
+		failed := false
+		for _, step := range args.Steps {
+			stepResult := BatchStepResult{ID: step.ID, Tool: step.Tool}
+
+			if failed && !args.ContinueOnError {
+				stepResult.Status = BatchStepSkipped
+				result.Skipped++
+				result.Steps = append(result.Steps, stepResult)
+				continue
+			}
+
+			value, err := r.runBatchStep(ctx, step, results)
+			if err != nil {
+				failed = true
+				stepResult.Status = BatchStepError
+				stepResult.Error = err.Error()
+				result.Failed++
+			} else {
+				results[step.ID] = value
+				stepResult.Status = BatchStepSuccess
+				stepResult.Result = value
+				result.Succeeded++
+			}
+			result.Steps = append(result.Steps, stepResult)
+		}
+
+		span.SetAttributes(
+			attribute.Int("succeeded", result.Succeeded),
+			attribute.Int("failed", result.Failed),
+		)
+
+		data, err := json.Marshal(&result)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	})
+
+	return tool, handler
+}
+
+// runBatchStep resolves the references in the step's arguments and calls its tool, returning its
+// result decoded from JSON where it can be
+func (r *Reloader) runBatchStep(ctx context.Context, step BatchStep, results map[string]any) (any, error) {
+	arguments, err := resolveBatchReferences(step.Arguments, results)
+	if err != nil {
+		return nil, err
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Method = string(mcp.MethodToolsCall)
+	request.Params.Name = step.Tool
+	request.Params.Arguments = arguments
+
+	callResult, err := r.callTool(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if callResult == nil {
+		return nil, nil
+	}
+
+	if callResult.IsError {
+		return nil, fmt.Errorf("%s", resultText(callResult))
+	}
+
+	if callResult.StructuredContent != nil {
+		data, err := json.Marshal(callResult.StructuredContent)
+		if err == nil {
+			return decodeBatchJSON(data), nil
+		}
+	}
+
+	return decodeBatchJSON([]byte(resultText(callResult))), nil
+}
+
+func resultText(result *mcp.CallToolResult) string {
+	var texts []string
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// decodeBatchJSON decodes JSON results so later steps can reference into them, keeping numbers
+// exact, and leaves other results as text
+func decodeBatchJSON(data []byte) any {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return string(data)
+	}
+	return value
+}
+
+// resolveBatchReferences replaces the references to earlier results in the arguments, returning
+// them decoded the same way as the arguments of a call from the client
+func resolveBatchReferences(value map[string]any, results map[string]any) (map[string]any, error) {
+	resolved, err := resolveBatchValue(value, results)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(resolved)
+	if err != nil {
+		return nil, err
+	}
+	var arguments map[string]any
+	if err := json.Unmarshal(data, &arguments); err != nil {
+		return nil, err
+	}
+	return arguments, nil
+}
+
+func resolveBatchValue(value any, results map[string]any) (any, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		resolved := make(map[string]any, len(v))
+		for key, item := range v {
+			r, err := resolveBatchValue(item, results)
+			if err != nil {
+				return nil, err
+			}
+			resolved[key] = r
+		}
+		return resolved, nil
+	case []any:
+		resolved := make([]any, len(v))
+		for i, item := range v {
+			r, err := resolveBatchValue(item, results)
+			if err != nil {
+				return nil, err
+			}
+			resolved[i] = r
+		}
+		return resolved, nil
+	case string:
+		return resolveBatchString(v, results)
+	default:
+		return value, nil
+	}
+}
+
+func resolveBatchString(value string, results map[string]any) (any, error) {
+	// a value which is only a reference keeps the type of what it references
+	if match := batchReference.FindStringSubmatch(value); match != nil && match[0] == value {
+		return lookupBatchReference(match[1], results)
+	}
+
+	var lookupErr error
+	resolved := batchReference.ReplaceAllStringFunc(value, func(reference string) string {
+		referenced, err := lookupBatchReference(batchReference.FindStringSubmatch(reference)[1], results)
+		if err != nil {
+			lookupErr = err
+			return reference
+		}
+		if s, ok := referenced.(string); ok {
+			return s
+		}
+		data, _ := json.Marshal(referenced)
+		return string(data)
+	})
+	if lookupErr != nil {
+		return nil, lookupErr
+	}
+	return resolved, nil
+}
+
+func lookupBatchReference(reference string, results map[string]any) (any, error) {
+	segments := strings.Split(reference, ".")
+
+	value, ok := results[segments[0]]
+	if !ok {
+		return nil, fmt.Errorf("reference ${%s}: no result for step %q", reference, segments[0])
+	}
+
+	for _, segment := range segments[1:] {
+		switch v := value.(type) {
+		case map[string]any:
+			if value, ok = v[segment]; !ok {
+				return nil, fmt.Errorf("reference ${%s}: no field %q", reference, segment)
+			}
+		case []any:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, fmt.Errorf("reference ${%s}: index %q out of range", reference, segment)
+			}
+			value = v[index]
+		default:
+			return nil, fmt.Errorf("reference ${%s}: can't look up %q in a %T", reference, segment, value)
+		}
+	}
+
+	return value, nil
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/require"
+)
+
+func runBatch(t *testing.T, handlers map[string]server.ToolHandlerFunc, args map[string]any) (BatchResult, *mcp.CallToolResult) {
+	t.Helper()
+
+	reloader := newReloader(nil, nil, &ToolsetConfig{})
+	reloader.handlers.Store(&handlers)
+	_, handler := reloader.executeBatch(false)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = executeBatchToolName
+	request.Params.Arguments = args
+
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+
+	var batch BatchResult
+	if !result.IsError {
+		require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &batch))
+	}
+	return batch, result
+}
+
+func TestExecuteBatchReferences(t *testing.T) {
+	assert := require.New(t)
+
+	var buildArgs map[string]any
+	handlers := map[string]server.ToolHandlerFunc{
+		"list_builds": func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText(`[{"number":42,"branch":"main"},{"number":41,"branch":"main"}]`), nil
+		},
+		"get_build": func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			buildArgs = request.GetArguments()
+			return mcp.NewToolResultText(`{"state":"passed"}`), nil
+		},
+	}
+
+	batch, _ := runBatch(t, handlers, map[string]any{
+		"steps": []any{
+			map[string]any{"id": "builds", "tool": "list_builds", "arguments": map[string]any{"org_slug": "acme"}},
+			map[string]any{"tool": "get_build", "arguments": map[string]any{
+				"build_number": "${builds.0.number}",
+				"message":      "build ${builds.0.number} on ${builds.1.branch}",
+			}},
+		},
+	})
+
+	assert.Equal(2, batch.Succeeded)
+	assert.Equal("builds", batch.Steps[0].ID)
+	assert.Equal("1", batch.Steps[1].ID)
+	assert.Equal(map[string]any{"state": "passed"}, batch.Steps[1].Result)
+
+	// a whole value reference keeps its type, while interpolated references become text
+	assert.Equal(float64(42), buildArgs["build_number"])
+	assert.Equal("build 42 on main", buildArgs["message"])
+}
+
+func TestExecuteBatchStopsOnError(t *testing.T) {
+	assert := require.New(t)
+
+	calls := 0
+	handlers := map[string]server.ToolHandlerFunc{
+		"failing": func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			calls++
+			return mcp.NewToolResultError("not found"), nil
+		},
+		"working": func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			calls++
+			return mcp.NewToolResultText("ok"), nil
+		},
+	}
+
+	batch, _ := runBatch(t, handlers, map[string]any{
+		"steps": []any{
+			map[string]any{"tool": "failing"},
+			map[string]any{"tool": "working"},
+		},
+	})
+	assert.Equal(1, calls)
+	assert.Equal(BatchStepError, batch.Steps[0].Status)
+	assert.Equal("not found", batch.Steps[0].Error)
+	assert.Equal(BatchStepSkipped, batch.Steps[1].Status)
+	assert.Equal(1, batch.Skipped)
+
+	batch, _ = runBatch(t, handlers, map[string]any{
+		"continue_on_error": true,
+		"steps": []any{
+			map[string]any{"tool": "failing"},
+			map[string]any{"tool": "working"},
+			map[string]any{"tool": "working", "arguments": map[string]any{"value": "${0.result}"}},
+		},
+	})
+	assert.Equal(BatchStepSuccess, batch.Steps[1].Status)
+	assert.Equal("ok", batch.Steps[1].Result)
+	assert.Equal(BatchStepError, batch.Steps[2].Status)
+	assert.Contains(batch.Steps[2].Error, `no result for step "0"`)
+}
+
+func TestExecuteBatchValidation(t *testing.T) {
+	assert := require.New(t)
+
+	handlers := map[string]server.ToolHandlerFunc{
+		"list_builds": func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText("[]"), nil
+		},
+	}
+
+	_, result := runBatch(t, handlers, map[string]any{"steps": []any{}})
+	assert.True(result.IsError)
+
+	_, result = runBatch(t, handlers, map[string]any{"steps": []any{map[string]any{"tool": "delete_everything"}}})
+	assert.True(result.IsError)
+	assert.Contains(result.Content[0].(mcp.TextContent).Text, "unknown tool")
+
+	_, result = runBatch(t, handlers, map[string]any{"steps": []any{map[string]any{"tool": executeBatchToolName}}})
+	assert.True(result.IsError)
+
+	_, result = runBatch(t, handlers, map[string]any{"steps": []any{
+		map[string]any{"id": "a", "tool": "list_builds"},
+		map[string]any{"id": "a", "tool": "list_builds"},
+	}})
+	assert.True(result.IsError)
+}
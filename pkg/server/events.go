@@ -0,0 +1,155 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/buildkite-mcp-server/pkg/webhook"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	subscribeToEventsToolName = "subscribe_to_events"
+	// eventsToolset authorizes subscriptions like the builds toolset, as events describe builds
+	eventsToolset = "builds"
+	// eventLogger names the log messages events are sent as
+	eventLogger = "buildkite-webhook"
+)
+
+type SubscribeToEventsArgs struct {
+	Events      []string `json:"events"`
+	Pipelines   []string `json:"pipelines"`
+	Branches    []string `json:"branches"`
+	Unsubscribe bool     `json:"unsubscribe"`
+}
+
+type SubscribeToEventsResult struct {
+	Subscribed bool            `json:"subscribed"`
+	Filter     *webhook.Filter `json:"filter,omitempty"`
+	Note       string          `json:"note,omitempty"`
+}
+
+// subscribeToEvents returns the subscribe_to_events tool, which sends the calling session the
+// Buildkite webhook events received by the receiver that match its filter
+func subscribeToEvents(receiver *webhook.Receiver) (mcp.Tool, server.ToolHandlerFunc) {
+	tool := mcp.NewTool(subscribeToEventsToolName,
+		mcp.WithDescription("Subscribe this session to Buildkite webhook events, such as builds finishing or jobs failing, which are then sent as notifications/message log messages from the logger '"+eventLogger+"' as they happen, so you can react to them rather than polling. Calling it again replaces the subscription. The client must keep its stream for server notifications open to receive them"),
+		mcp.WithArray("events",
+			mcp.Description("Events to receive (default: build.finished, job.failed). build.failed and job.failed are the finished events of builds and jobs which failed"),
+			mcp.WithStringEnumItems(webhook.Events),
+		),
+		mcp.WithArray("pipelines",
+			mcp.Description("Only receive events of pipelines matching these slug patterns, such as 'deploy-*' or 'my-org/frontend'"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("branches",
+			mcp.Description("Only receive events of builds on these branches"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithBoolean("unsubscribe",
+			mcp.Description("Stop receiving events instead (default: false)"),
+		),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{
+			Title:        "Subscribe to Events",
+			ReadOnlyHint: mcp.ToBoolPtr(true),
+		}),
+	)
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ctx, span := trace.Start(ctx, "server.SubscribeToEvents")
+		defer span.End()
+
+		var args SubscribeToEventsArgs
+		if err := request.BindArguments(&args); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid arguments: %v", err)), nil
+		}
+
+		session := server.ClientSessionFromContext(ctx)
+		if session == nil {
+			return mcp.NewToolResultError("events can only be sent to a client session"), nil
+		}
+
+		span.SetAttributes(
+			attribute.StringSlice("events", args.Events),
+			attribute.StringSlice("pipelines", args.Pipelines),
+			attribute.StringSlice("branches", args.Branches),
+			attribute.Bool("unsubscribe", args.Unsubscribe),
+		)
+
+		var result SubscribeToEventsResult
+		if args.Unsubscribe {
+			if !receiver.Unsubscribe(session.SessionID()) {
+				result.Note = "this session had no subscription"
+			}
+			return eventsResult(&result)
+		}
+
+		if len(args.Events) == 0 {
+			args.Events = webhook.DefaultEvents
+		}
+		for _, event := range args.Events {
+			if !slices.Contains(webhook.Events, event) {
+				return mcp.NewToolResultError(fmt.Sprintf("unknown event %q, expected one of: %s", event, strings.Join(webhook.Events, ", "))), nil
+			}
+		}
+
+		filter := webhook.Filter{Events: args.Events, Pipelines: args.Pipelines, Branches: args.Branches}
+		receiver.Subscribe(session.SessionID(), filter)
+
+		result.Subscribed = true
+		result.Filter = &filter
+		return eventsResult(&result)
+	}
+
+	return tool, handler
+}
+
+func eventsResult(result *SubscribeToEventsResult) (*mcp.CallToolResult, error) {
+	out, err := json.Marshal(result)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(out)), nil
+}
+
+// sendEvent sends an event to a session as a log message, leaving out events of organizations and
+// pipelines the scope policy doesn't permit. Events don't pass through the scrubbing of tool results,
+// so their text is redacted and scrubbed here. Subscriptions of sessions which have gone are ended.
+func (r *Reloader) sendEvent(receiver *webhook.Receiver) webhook.Sender {
+	return func(sessionID string, event webhook.Event) error {
+		cfg := r.cfg.Load()
+		if cfg.ScopePolicy.CheckOrg(event.Organization) != nil || cfg.ScopePolicy.CheckPipeline(event.Organization, event.Pipeline) != nil {
+			return nil
+		}
+
+		ctx := context.Background()
+		for _, text := range []*string{&event.Message, &event.JobLabel, &event.Branch} {
+			*text, _ = cfg.Redactor.Redact(*text)
+			*text, _ = cfg.Scrubber.Scrub(ctx, *text)
+		}
+
+		level := mcp.LoggingLevelInfo
+		if event.Failed {
+			level = mcp.LoggingLevelWarning
+		}
+
+		err := r.server.SendNotificationToSpecificClient(sessionID, "notifications/message", map[string]any{
+			"level":  level,
+			"logger": eventLogger,
+			"data":   event,
+		})
+		if errors.Is(err, server.ErrSessionNotFound) {
+			log.Debug().Str("session_id", sessionID).Msg("Ending the event subscription of a closed session")
+			receiver.Unsubscribe(sessionID)
+		}
+		return err
+	}
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/policy"
+	"github.com/buildkite/buildkite-mcp-server/pkg/redact"
+	"github.com/buildkite/buildkite-mcp-server/pkg/scrub"
+	"github.com/buildkite/buildkite-mcp-server/pkg/webhook"
+	gobuildkite "github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+type eventSession struct {
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (s *eventSession) Initialize()       {}
+func (s *eventSession) Initialized() bool { return true }
+func (s *eventSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+func (s *eventSession) SessionID() string { return "session" }
+
+func TestSubscribeToEvents(t *testing.T) {
+	assert := require.New(t)
+
+	client, err := gobuildkite.NewOpts(gobuildkite.WithTokenAuth("test-token"))
+	assert.NoError(err)
+
+	scopePolicy, err := policy.NewScopePolicy(nil, []string{"web", "deploy"})
+	assert.NoError(err)
+
+	receiver := webhook.NewReceiver("secret")
+	s := NewMCPServer("test", client, nil, WithToolsets("builds"), WithScopePolicy(scopePolicy), WithWebhookReceiver(receiver))
+	assert.NotNil(s.GetTool(subscribeToEventsToolName))
+
+	session := &eventSession{notifications: make(chan mcp.JSONRPCNotification, 10)}
+	assert.NoError(s.RegisterSession(context.Background(), session))
+	ctx := s.WithContext(context.Background(), session)
+
+	response := s.HandleMessage(ctx, json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{
+		"name":"subscribe_to_events",
+		"arguments":{"events":["build.failed"]}
+	}}`))
+	result, ok := response.(mcp.JSONRPCResponse).Result.(mcp.CallToolResult)
+	assert.True(ok)
+	assert.False(result.IsError)
+
+	var subscribed SubscribeToEventsResult
+	assert.NoError(json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &subscribed))
+	assert.True(subscribed.Subscribed)
+	assert.Equal([]string{"build.failed"}, subscribed.Filter.Events)
+
+	post := func(pipeline, state string) {
+		body := `{"build":{"number":7,"state":"` + state + `","branch":"main"},"pipeline":{"slug":"` + pipeline + `","url":"https://api.buildkite.com/v2/organizations/acme/pipelines/` + pipeline + `"}}`
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("X-Buildkite-Token", "secret")
+		req.Header.Set("X-Buildkite-Event", "build.finished")
+		receiver.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// passed builds and pipelines outside the scope policy aren't sent
+	post("web", "passed")
+	post("secret-pipeline", "failed")
+	post("web", "failed")
+
+	assert.Len(session.notifications, 1)
+	notification := <-session.notifications
+	assert.Equal("notifications/message", notification.Method)
+	assert.Equal(mcp.LoggingLevelWarning, notification.Params.AdditionalFields["level"])
+	event := notification.Params.AdditionalFields["data"].(webhook.Event)
+	assert.Equal("web", event.Pipeline)
+	assert.Equal(7, event.BuildNumber)
+
+	// the subscription ends with the session
+	s.UnregisterSession(context.Background(), session.SessionID())
+	assert.False(receiver.Unsubscribe(session.SessionID()))
+}
+
+func TestSubscribeToEventsScrubsEventText(t *testing.T) {
+	assert := require.New(t)
+
+	client, err := gobuildkite.NewOpts(gobuildkite.WithTokenAuth("test-token"))
+	assert.NoError(err)
+
+	redactor, err := redact.New(nil)
+	assert.NoError(err)
+	scrubber, err := scrub.New([]scrub.Rule{{Name: "hosts", Pattern: `[a-z]+\.internal\.acme\.dev`}})
+	assert.NoError(err)
+
+	receiver := webhook.NewReceiver("secret")
+	s := NewMCPServer("test", client, nil, WithToolsets("builds"), WithRedactor(redactor), WithScrubber(scrubber), WithWebhookReceiver(receiver))
+
+	session := &eventSession{notifications: make(chan mcp.JSONRPCNotification, 10)}
+	assert.NoError(s.RegisterSession(context.Background(), session))
+	ctx := s.WithContext(context.Background(), session)
+
+	response := s.HandleMessage(ctx, json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{
+		"name":"subscribe_to_events",
+		"arguments":{"events":["job.failed"]}
+	}}`))
+	result, ok := response.(mcp.JSONRPCResponse).Result.(mcp.CallToolResult)
+	assert.True(ok)
+	assert.False(result.IsError)
+
+	body := `{
+		"build":{"number":7,"state":"failed","branch":"main","message":"rotate bkua_abcdefghijklmnopqrstuvwxyz012345"},
+		"job":{"id":"job-1","state":"failed","exit_status":1,"label":"deploy to db.internal.acme.dev"},
+		"pipeline":{"slug":"web","url":"https://api.buildkite.com/v2/organizations/acme/pipelines/web"}
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("X-Buildkite-Token", "secret")
+	req.Header.Set("X-Buildkite-Event", "job.finished")
+	receiver.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Len(session.notifications, 1)
+	event := (<-session.notifications).Params.AdditionalFields["data"].(webhook.Event)
+	assert.Equal("rotate "+redact.Replacement, event.Message)
+	assert.Equal("deploy to "+scrub.DefaultReplacement, event.JobLabel)
+}
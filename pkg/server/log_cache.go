@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/logcache"
+	"github.com/buildkite/buildkite-mcp-server/pkg/reltime"
+	"github.com/buildkite/buildkite-mcp-server/pkg/toolsets"
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	getCacheStatsToolName = "get_cache_stats"
+	purgeLogCacheToolName = "purge_log_cache"
+
+	// logCacheToolset authorizes the cache tools like the logs toolset, whose logs they manage
+	logCacheToolset = toolsets.ToolsetLogs
+)
+
+type PurgeLogCacheArgs struct {
+	Prefix       string `json:"prefix"`
+	CachedBefore string `json:"cached_before"`
+}
+
+// getCacheStats returns the get_cache_stats tool, which reports how many job logs are cached and
+// how much space they take
+func getCacheStats(collector *logcache.Collector) (mcp.Tool, server.ToolHandlerFunc) {
+	tool := mcp.NewTool(getCacheStatsToolName,
+		mcp.WithDescription("Get how many job logs are in the server's job logs cache, the space they take, when the oldest and newest were cached, and the size and age the cache is kept within"),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{
+			Title:        "Get Cache Stats",
+			ReadOnlyHint: mcp.ToBoolPtr(true),
+		}),
+	)
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ctx, span := trace.Start(ctx, "server.GetCacheStats")
+		defer span.End()
+
+		stats, err := collector.Stats(ctx)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		span.SetAttributes(
+			attribute.Int("item_count", stats.Logs),
+			attribute.Int64("total_bytes", stats.TotalBytes),
+		)
+
+		out, err := json.Marshal(&stats)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(out)), nil
+	}
+
+	return tool, handler
+}
+
+// purgeLogCache returns the purge_log_cache tool, which deletes job logs from the cache so they're
+// downloaded again when next read
+func purgeLogCache(collector *logcache.Collector) (mcp.Tool, server.ToolHandlerFunc) {
+	tool := mcp.NewTool(purgeLogCacheToolName,
+		mcp.WithDescription("Delete job logs from the server's job logs cache, all of them unless narrowed by prefix or cached_before. Purged logs are downloaded from Buildkite again when next read, so purge logs which are stale or to free space"),
+		mcp.WithString("prefix",
+			mcp.Description("Only delete logs whose keys start with this. Keys are <org>-<pipeline>-<build>-<job>.parquet, so 'my-org-frontend-' deletes the logs of the frontend pipeline"),
+		),
+		mcp.WithString("cached_before",
+			mcp.Description("Only delete logs cached before this, either "+reltime.Formats),
+		),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{
+			Title:           "Purge Log Cache",
+			ReadOnlyHint:    mcp.ToBoolPtr(false),
+			DestructiveHint: mcp.ToBoolPtr(true),
+			IdempotentHint:  mcp.ToBoolPtr(true),
+		}),
+	)
+
+	handler := mcp.NewTypedToolHandler(func(ctx context.Context, request mcp.CallToolRequest, args PurgeLogCacheArgs) (*mcp.CallToolResult, error) {
+		ctx, span := trace.Start(ctx, "server.PurgeLogCache")
+		defer span.End()
+
+		var cachedBefore time.Time
+		if args.CachedBefore != "" {
+			var err error
+			if cachedBefore, err = reltime.Parse(args.CachedBefore, time.Now()); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("invalid cached_before: %v", err)), nil
+			}
+		}
+
+		span.SetAttributes(
+			attribute.String("prefix", args.Prefix),
+			attribute.String("cached_before", args.CachedBefore),
+		)
+
+		result, err := collector.Purge(ctx, args.Prefix, cachedBefore)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		span.SetAttributes(attribute.Int("item_count", result.Deleted))
+
+		out, err := json.Marshal(&result)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(out)), nil
+	})
+
+	return tool, handler
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/logcache"
+	gobuildkite "github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogCacheTools(t *testing.T) {
+	assert := require.New(t)
+
+	dir := t.TempDir()
+	assert.NoError(os.WriteFile(filepath.Join(dir, "org-web-1-a.parquet"), []byte("logs"), 0o600))
+	collector, err := logcache.NewCollector("file://"+dir, 0, 0)
+	assert.NoError(err)
+
+	_, handler := getCacheStats(collector)
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	assert.NoError(err)
+	var stats logcache.Stats
+	assert.NoError(json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &stats))
+	assert.Equal(1, stats.Logs)
+	assert.Equal(int64(4), stats.TotalBytes)
+
+	_, handler = purgeLogCache(collector)
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"cached_before": "yesterday-ish"}
+	result, err = handler(context.Background(), request)
+	assert.NoError(err)
+	assert.True(result.IsError)
+	assert.Contains(result.Content[0].(mcp.TextContent).Text, "invalid cached_before")
+
+	request.Params.Arguments = map[string]any{"prefix": "org-web-"}
+	result, err = handler(context.Background(), request)
+	assert.NoError(err)
+	var purged logcache.PurgeResult
+	assert.NoError(json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &purged))
+	assert.Equal(1, purged.Deleted)
+	assert.NoFileExists(filepath.Join(dir, "org-web-1-a.parquet"))
+}
+
+func TestLogCacheToolsReadOnly(t *testing.T) {
+	assert := require.New(t)
+
+	client, err := gobuildkite.NewOpts(gobuildkite.WithTokenAuth("test-token"))
+	assert.NoError(err)
+	collector, err := logcache.NewCollector("file://"+t.TempDir(), 0, 0)
+	assert.NoError(err)
+
+	// purging is a write, so read-only servers only report the cache
+	_, reloader := NewReloadableMCPServer("test", client, nil, WithToolsets("logs"), WithLogCache(collector), WithReadOnly(true))
+	definitions := *reloader.definitions.Load()
+	assert.Contains(definitions, getCacheStatsToolName)
+	assert.NotContains(definitions, purgeLogCacheToolName)
+
+	reloader.Reload(WithReadOnly(false))
+	assert.Contains(*reloader.definitions.Load(), purgeLogCacheToolName)
+}
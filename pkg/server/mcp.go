@@ -1,10 +1,22 @@
 package server
 
 import (
-	buildkitelogs "github.com/buildkite/buildkite-logs"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/audit"
+	"github.com/buildkite/buildkite-mcp-server/pkg/breaker"
 	"github.com/buildkite/buildkite-mcp-server/pkg/buildkite"
+	"github.com/buildkite/buildkite-mcp-server/pkg/graphql"
+	"github.com/buildkite/buildkite-mcp-server/pkg/logcache"
+	"github.com/buildkite/buildkite-mcp-server/pkg/policy"
+	"github.com/buildkite/buildkite-mcp-server/pkg/ratelimit"
+	"github.com/buildkite/buildkite-mcp-server/pkg/redact"
+	"github.com/buildkite/buildkite-mcp-server/pkg/scrub"
 	"github.com/buildkite/buildkite-mcp-server/pkg/toolsets"
 	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/buildkite/buildkite-mcp-server/pkg/webhook"
 	gobuildkite "github.com/buildkite/go-buildkite/v4"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -18,6 +30,32 @@ type ToolsetOption func(*ToolsetConfig)
 type ToolsetConfig struct {
 	EnabledToolsets []string
 	ReadOnly        bool
+	ScopePolicy     policy.ScopePolicy
+	CELPolicy       *policy.CELPolicy
+	Redactor        *redact.Redactor
+	Scrubber        *scrub.Scrubber
+	AuditLogger     *audit.Logger
+	Breaker         *breaker.Breaker
+	// RateLimiter reports the Buildkite API rate limit through the get_rate_limit_status tool when set
+	RateLimiter *ratelimit.Limiter
+	// ArtifactRetention is how long artifacts are kept, used to estimate when they expire
+	ArtifactRetention time.Duration
+	// DisplayTimezone is the timezone timestamps are displayed in when a call doesn't ask for one
+	DisplayTimezone *time.Location
+	// GraphQLClient queries the GraphQL API for the graphql toolset, which reports it isn't configured when nil
+	GraphQLClient buildkite.GraphQLClient
+	// ToolMiddleware is applied to every tool call once it has been authorized
+	ToolMiddleware []server.ToolHandlerMiddleware
+	// MaxResultTokens is the estimated number of tokens of the largest tool result returned, or 0 for no limit
+	MaxResultTokens int
+	// ToolTimeout is how long a tool call may run for, or 0 for no limit
+	ToolTimeout time.Duration
+	// ToolTimeouts override the ToolTimeout of the tools they name, 0 leaving the tool unlimited
+	ToolTimeouts map[string]time.Duration
+	// WebhookReceiver sends sessions the webhook events they subscribe to with the subscribe_to_events tool when set
+	WebhookReceiver *webhook.Receiver
+	// LogCache adds tools reporting the size of the job logs cache and purging it when set
+	LogCache *logcache.Collector
 }
 
 // WithToolsets enables specific toolsets
@@ -34,8 +72,128 @@ func WithReadOnly(readOnly bool) ToolsetOption {
 	}
 }
 
+// WithScopePolicy restricts the organizations and pipelines tools are permitted to access
+func WithScopePolicy(scopePolicy policy.ScopePolicy) ToolsetOption {
+	return func(cfg *ToolsetConfig) {
+		cfg.ScopePolicy = scopePolicy
+	}
+}
+
+// WithCELPolicy authorizes each tool call with a CEL expression
+func WithCELPolicy(celPolicy *policy.CELPolicy) ToolsetOption {
+	return func(cfg *ToolsetConfig) {
+		cfg.CELPolicy = celPolicy
+	}
+}
+
+// WithRedactor sets the redactor used to remove secrets from log and build environment output
+func WithRedactor(redactor *redact.Redactor) ToolsetOption {
+	return func(cfg *ToolsetConfig) {
+		cfg.Redactor = redactor
+	}
+}
+
+// WithScrubber sets the operator configured scrubbing rules applied to all tool text output
+func WithScrubber(scrubber *scrub.Scrubber) ToolsetOption {
+	return func(cfg *ToolsetConfig) {
+		cfg.Scrubber = scrubber
+	}
+}
+
+// WithAuditLogger records invocations of write tools to the audit log
+func WithAuditLogger(auditLogger *audit.Logger) ToolsetOption {
+	return func(cfg *ToolsetConfig) {
+		cfg.AuditLogger = auditLogger
+	}
+}
+
+// WithCircuitBreaker reports tool calls failed by an open circuit breaker as structured errors
+func WithCircuitBreaker(b *breaker.Breaker) ToolsetOption {
+	return func(cfg *ToolsetConfig) {
+		cfg.Breaker = b
+	}
+}
+
+// WithRateLimiter adds a tool reporting the Buildkite API rate limit tracked by the limiter
+func WithRateLimiter(limiter *ratelimit.Limiter) ToolsetOption {
+	return func(cfg *ToolsetConfig) {
+		cfg.RateLimiter = limiter
+	}
+}
+
+// WithArtifactRetention sets how long the organization retains artifacts, for estimating artifact expiry
+func WithArtifactRetention(retention time.Duration) ToolsetOption {
+	return func(cfg *ToolsetConfig) {
+		cfg.ArtifactRetention = retention
+	}
+}
+
+// WithDisplayTimezone displays timestamps in log entries and build summaries in the timezone
+func WithDisplayTimezone(loc *time.Location) ToolsetOption {
+	return func(cfg *ToolsetConfig) {
+		cfg.DisplayTimezone = loc
+	}
+}
+
+// WithGraphQLClient sets the client used by the graphql toolset
+func WithGraphQLClient(client *graphql.Client) ToolsetOption {
+	return func(cfg *ToolsetConfig) {
+		// leave the interface nil rather than holding a nil pointer
+		if client != nil {
+			cfg.GraphQLClient = client
+		}
+	}
+}
+
+// WithWebhookReceiver adds a tool subscribing sessions to the Buildkite webhook events the receiver receives
+func WithWebhookReceiver(receiver *webhook.Receiver) ToolsetOption {
+	return func(cfg *ToolsetConfig) {
+		cfg.WebhookReceiver = receiver
+	}
+}
+
+// WithLogCache adds tools reporting the size of the job logs cache managed by the collector, and
+// purging it unless read-only
+func WithLogCache(collector *logcache.Collector) ToolsetOption {
+	return func(cfg *ToolsetConfig) {
+		cfg.LogCache = collector
+	}
+}
+
+// WithToolMiddleware adds middleware applied to every tool call once it has been authorized,
+// outermost first
+func WithToolMiddleware(middleware ...server.ToolHandlerMiddleware) ToolsetOption {
+	return func(cfg *ToolsetConfig) {
+		cfg.ToolMiddleware = append(cfg.ToolMiddleware, middleware...)
+	}
+}
+
+// WithMaxResultTokens truncates the lists of tool results over maxTokens, marking them truncated
+func WithMaxResultTokens(maxTokens int) ToolsetOption {
+	return func(cfg *ToolsetConfig) {
+		cfg.MaxResultTokens = maxTokens
+	}
+}
+
+// WithToolTimeout fails tool calls running longer than timeout, or their tool's override, with an
+// error saying they timed out. Tools which wait for a build or follow a log are given their
+// wait_timeout on top unless overridden.
+func WithToolTimeout(timeout time.Duration, overrides map[string]time.Duration) ToolsetOption {
+	return func(cfg *ToolsetConfig) {
+		cfg.ToolTimeout = timeout
+		cfg.ToolTimeouts = overrides
+	}
+}
+
 // NewMCPServer creates a new MCP server with the given configuration and toolsets
-func NewMCPServer(version string, client *gobuildkite.Client, buildkiteLogsClient *buildkitelogs.Client, opts ...ToolsetOption) *server.MCPServer {
+func NewMCPServer(version string, client *gobuildkite.Client, buildkiteLogsClient buildkite.BuildkiteLogsClient, opts ...ToolsetOption) *server.MCPServer {
+	s, _ := NewReloadableMCPServer(version, client, buildkiteLogsClient, opts...)
+	return s
+}
+
+// NewReloadableMCPServer creates a new MCP server along with a reloader for replacing its
+// configuration while it runs
+func NewReloadableMCPServer(version string, client *gobuildkite.Client, buildkiteLogsClient buildkite.BuildkiteLogsClient, opts ...ToolsetOption) (*server.MCPServer, *Reloader) {
 	// Default configuration
 	cfg := &ToolsetConfig{
 		EnabledToolsets: []string{"all"},
@@ -47,21 +205,43 @@ func NewMCPServer(version string, client *gobuildkite.Client, buildkiteLogsClien
 		opt(cfg)
 	}
 
-	s := server.NewMCPServer(
-		"buildkite-mcp-server",
-		version,
+	hooks := trace.NewHooks()
+	if cfg.WebhookReceiver != nil {
+		hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+			cfg.WebhookReceiver.Unsubscribe(session.SessionID())
+		})
+	}
+
+	serverOpts := []server.ServerOption{
 		server.WithToolCapabilities(true),
 		server.WithPromptCapabilities(true),
 		server.WithResourceCapabilities(true, true),
-		server.WithToolHandlerMiddleware(trace.ToolHandlerFunc),
 		server.WithResourceHandlerMiddleware(trace.WithResourceHandlerFunc),
-		server.WithHooks(trace.NewHooks()),
-		server.WithLogging())
+		server.WithHooks(hooks),
+		server.WithLogging(),
+	}
+
+	// the tool middleware is also applied to each step of a batch
+	reloader := newReloader(client, buildkiteLogsClient, cfg)
+	reloader.middleware = toolMiddleware(cfg, reloader)
+	for _, mw := range reloader.middleware {
+		serverOpts = append(serverOpts, server.WithToolHandlerMiddleware(mw))
+	}
+
+	s := server.NewMCPServer(
+		"buildkite-mcp-server",
+		version,
+		serverOpts...)
 
 	log.Info().Str("version", version).Msg("Starting Buildkite MCP server")
 
 	// Use toolset system with configuration
-	s.AddTools(BuildkiteTools(client, buildkiteLogsClient, WithReadOnly(cfg.ReadOnly), WithToolsets(cfg.EnabledToolsets...))...)
+	reloader.server = s
+	reloader.apply(cfg)
+
+	if cfg.WebhookReceiver != nil {
+		cfg.WebhookReceiver.SetSender(reloader.sendEvent(cfg.WebhookReceiver))
+	}
 
 	s.AddPrompt(mcp.NewPrompt("user_token_organization_prompt",
 		mcp.WithPromptDescription("When asked for detail of a users pipelines start by looking up the user's token organization"),
@@ -73,11 +253,11 @@ func NewMCPServer(version string, client *gobuildkite.Client, buildkiteLogsClien
 		mcp.WithResourceDescription("Comprehensive guide for debugging Buildkite build failures using logs"),
 	), buildkite.HandleDebugLogsGuideResource)
 
-	return s
+	return s, reloader
 }
 
 // BuildkiteTools creates tools using the toolset system with functional options
-func BuildkiteTools(client *gobuildkite.Client, buildkiteLogsClient *buildkitelogs.Client, opts ...ToolsetOption) []server.ServerTool {
+func BuildkiteTools(client *gobuildkite.Client, buildkiteLogsClient buildkite.BuildkiteLogsClient, opts ...ToolsetOption) []server.ServerTool {
 	cfg := &ToolsetConfig{
 		EnabledToolsets: []string{"all"},
 		ReadOnly:        false,
@@ -87,19 +267,31 @@ func BuildkiteTools(client *gobuildkite.Client, buildkiteLogsClient *buildkitelo
 		opt(cfg)
 	}
 
+	var serverTools []server.ServerTool
+	for _, toolDef := range toolDefinitions(client, buildkiteLogsClient, cfg) {
+		serverTools = append(serverTools, server.ServerTool{
+			Tool:    toolDef.Tool,
+			Handler: toolDef.Handler,
+		})
+	}
+
+	return serverTools
+}
+
+// toolDefinitions returns the definitions of the tools enabled by the configuration
+func toolDefinitions(client *gobuildkite.Client, buildkiteLogsClient buildkite.BuildkiteLogsClient, cfg *ToolsetConfig) []toolsets.ToolDefinition {
 	registry := toolsets.NewToolsetRegistry()
 
 	registry.RegisterToolsets(
-		toolsets.CreateBuiltinToolsets(client, buildkiteLogsClient),
+		toolsets.CreateBuiltinToolsets(client, buildkiteLogsClient, cfg.GraphQLClient, cfg.Redactor, cfg.ArtifactRetention),
 	)
 
 	enabledTools := registry.GetEnabledTools(cfg.EnabledToolsets, cfg.ReadOnly)
 
-	var serverTools []server.ServerTool
-	for _, toolDef := range enabledTools {
-		serverTools = append(serverTools, server.ServerTool{
-			Tool:    toolDef.Tool,
-			Handler: toolDef.Handler,
+	// queries can reach any organization or pipeline the token can, which a scope policy can't follow
+	if !cfg.ScopePolicy.IsEmpty() {
+		enabledTools = slices.DeleteFunc(enabledTools, func(td toolsets.ToolDefinition) bool {
+			return td.Tool.Name == buildkite.RunGraphQLQueryToolName
 		})
 	}
 
@@ -108,9 +300,9 @@ func BuildkiteTools(client *gobuildkite.Client, buildkiteLogsClient *buildkitelo
 	log.Info().
 		Strs("enabled_toolsets", cfg.EnabledToolsets).
 		Bool("read_only", cfg.ReadOnly).
-		Int("tool_count", len(serverTools)).
+		Int("tool_count", len(enabledTools)).
 		Strs("required_scopes", scopes).
 		Msg("Registered tools from toolsets")
 
-	return serverTools
+	return enabledTools
 }
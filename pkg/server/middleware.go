@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/tokens"
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog/log"
+)
+
+// toolMiddleware returns the middleware every tool call passes through, outermost first:
+//
+//   - choosing the tokenizer for the client's model, which token counts are estimated with
+//   - tracing, which also logs each call and records its metrics
+//   - auditing, before authorization so denied write attempts are also recorded
+//   - authorization by the reloader, so the policies it enforces can be replaced
+//   - the middleware added with WithToolMiddleware
+//   - the tool timeout, cancelling calls which run too long
+//   - the result size guard
+//   - the circuit breaker, reporting calls failed by an open breaker as structured errors
+//
+// Handlers are left to do the work of their tool, and calls made by the execute_batch tool pass
+// through the same chain for each step.
+func toolMiddleware(cfg *ToolsetConfig, reloader *Reloader) []server.ToolHandlerMiddleware {
+	middleware := []server.ToolHandlerMiddleware{clientTokenizer, trace.ToolHandlerFunc}
+	if cfg.AuditLogger != nil {
+		middleware = append(middleware, cfg.AuditLogger.ToolHandlerMiddleware)
+	}
+	middleware = append(middleware, reloader.ToolHandlerMiddleware)
+	middleware = append(middleware, cfg.ToolMiddleware...)
+	middleware = append(middleware, reloader.timeout, reloader.guardResultSize)
+	if cfg.Breaker != nil {
+		middleware = append(middleware, cfg.Breaker.ToolHandlerMiddleware)
+	}
+	return middleware
+}
+
+// clientTokenizer counts the tokens of a call with the tokenizer for the model the client declares,
+// either as the "model" of the call's _meta or by the name of the client, e.g. "claude-code". Calls
+// from other clients use the default tokenizer.
+func clientTokenizer(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var model string
+		if request.Params.Meta != nil {
+			model, _ = request.Params.Meta.AdditionalFields["model"].(string)
+		}
+		if session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithClientInfo); ok && model == "" {
+			model = session.GetClientInfo().Name
+		}
+		if model != "" {
+			ctx = tokens.WithTokenizer(ctx, tokens.ForModel(model))
+		}
+
+		return next(ctx, request)
+	}
+}
+
+// guardResultSize cuts the longest lists of results over the current MaxResultTokens short, marking
+// them truncated with a hint to paginate, rather than filling the client's context with them.
+// Results which can't be cut down to fit are replaced with an error asking for less.
+func (r *Reloader) guardResultSize(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := next(ctx, request)
+
+		limit := r.cfg.Load().MaxResultTokens
+		if limit <= 0 || err != nil || result == nil || result.IsError {
+			return result, err
+		}
+
+		tokenizer := tokens.FromContext(ctx)
+		size := resultTokens(tokenizer, result)
+		if size <= limit {
+			return result, nil
+		}
+
+		if len(result.Content) == 1 {
+			if content, ok := result.Content[0].(mcp.TextContent); ok {
+				if text, ok := truncateResult(tokenizer, content.Text, limit); ok {
+					log.Ctx(ctx).Warn().Str("mcp.tool.name", request.Params.Name).Int("tokens", size).Int("limit", limit).Msg("Tool result truncated to the token limit")
+
+					truncated := *result
+					content.Text = text
+					truncated.Content = []mcp.Content{content}
+					return &truncated, nil
+				}
+			}
+		}
+
+		log.Ctx(ctx).Warn().Str("mcp.tool.name", request.Params.Name).Int("tokens", size).Int("limit", limit).Msg("Tool result over the token limit")
+
+		return mcp.NewToolResultError(fmt.Sprintf("the result of %s is about %d tokens, over the limit of %d tokens: ask for less, e.g. with a lower limit or page size, narrower filters or a less detailed detail_level", request.Params.Name, size, limit)), nil
+	}
+}
+
+// resultTokens returns the estimated number of tokens of the text content of a result
+func resultTokens(tokenizer tokens.Tokenizer, result *mcp.CallToolResult) int {
+	size := 0
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			size += tokenizer.CountTokens(text.Text)
+		}
+	}
+	return size
+}
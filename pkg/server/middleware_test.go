@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/tokens"
+	gobuildkite "github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolMiddleware(t *testing.T) {
+	assert := require.New(t)
+
+	client, err := gobuildkite.NewOpts(gobuildkite.WithTokenAuth("test-token"))
+	assert.NoError(err)
+
+	var called []string
+	record := func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			called = append(called, request.Params.Name)
+			return next(ctx, request)
+		}
+	}
+
+	s, reloader := NewReloadableMCPServer("test", client, nil, WithToolsets("builds"), WithToolMiddleware(record))
+
+	call := func() mcp.CallToolResult {
+		response := s.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{
+			"name":"get_tool_schema",
+			"arguments":{"tool_name":"list_builds"}
+		}}`))
+		result, ok := response.(mcp.JSONRPCResponse).Result.(mcp.CallToolResult)
+		assert.True(ok)
+		return result
+	}
+
+	assert.False(call().IsError)
+	assert.Equal([]string{"get_tool_schema"}, called)
+
+	// results which can't be truncated to the token limit are replaced, which can be changed by
+	// reloading
+	reloader.Reload(WithMaxResultTokens(5))
+	result := call()
+	assert.True(result.IsError)
+	assert.Contains(result.Content[0].(mcp.TextContent).Text, "over the limit of 5 tokens")
+
+	reloader.Reload(WithMaxResultTokens(0))
+	assert.False(call().IsError)
+	assert.Len(called, 3)
+}
+
+func TestClientTokenizer(t *testing.T) {
+	assert := require.New(t)
+
+	client, err := gobuildkite.NewOpts(gobuildkite.WithTokenAuth("test-token"))
+	assert.NoError(err)
+
+	var tokenizer tokens.Tokenizer
+	record := func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			tokenizer = tokens.FromContext(ctx)
+			return next(ctx, request)
+		}
+	}
+
+	s, _ := NewReloadableMCPServer("test", client, nil, WithToolsets("builds"), WithToolMiddleware(record))
+
+	// the model declared in the call's _meta picks the tokenizer
+	s.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{
+		"name":"get_tool_schema",
+		"arguments":{"tool_name":"list_builds"},
+		"_meta":{"model":"claude-sonnet-4"}
+	}}`))
+	claude, err := tokens.Get(tokens.Claude)
+	assert.NoError(err)
+	assert.Equal(claude, tokenizer)
+
+	// calls without one use the default
+	s.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{
+		"name":"get_tool_schema",
+		"arguments":{"tool_name":"list_builds"}
+	}}`))
+	assert.Equal(tokens.Default().CountTokens("this is a test"), tokenizer.CountTokens("this is a test"))
+}
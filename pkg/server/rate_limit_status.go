@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/ratelimit"
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const getRateLimitStatusToolName = "get_rate_limit_status"
+
+// OrganizationRateLimit is the Buildkite API rate limit of an organization, with how long until it resets
+type OrganizationRateLimit struct {
+	ratelimit.Status
+	ResetInSeconds int `json:"reset_in_seconds"`
+}
+
+type RateLimitStatus struct {
+	Organizations []OrganizationRateLimit `json:"organizations"`
+	Note          string                  `json:"note,omitempty"`
+}
+
+// getRateLimitStatus returns the get_rate_limit_status tool, which reports how much of each
+// organization's API rate limit is left so agents can pace bulk work
+func getRateLimitStatus(limiter *ratelimit.Limiter) (mcp.Tool, server.ToolHandlerFunc) {
+	tool := mcp.NewTool(getRateLimitStatusToolName,
+		mcp.WithDescription("Get how many Buildkite API requests remain in the rate limit of each organization this server has called the API for, when the limit resets, and how many requests were held back or retried because of it. Check it before bulk work such as analyzing many builds, and spread the work out when few requests remain"),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{
+			Title:        "Get Rate Limit Status",
+			ReadOnlyHint: mcp.ToBoolPtr(true),
+		}),
+	)
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ctx, span := trace.Start(ctx, "server.GetRateLimitStatus")
+		defer span.End()
+
+		now := time.Now()
+		result := RateLimitStatus{Organizations: []OrganizationRateLimit{}}
+		for _, status := range limiter.Status(ctx) {
+			result.Organizations = append(result.Organizations, OrganizationRateLimit{
+				Status:         status,
+				ResetInSeconds: max(0, int(math.Ceil(status.ResetsAt.Sub(now).Seconds()))),
+			})
+		}
+		if len(result.Organizations) == 0 {
+			result.Note = "no rate limit has been reported yet, as the API reports it with the responses of requests made for an organization"
+		}
+
+		span.SetAttributes(attribute.Int("item_count", len(result.Organizations)))
+
+		out, err := json.Marshal(&result)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(out)), nil
+	}
+
+	return tool, handler
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/ratelimit"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRateLimitStatus(t *testing.T) {
+	assert := require.New(t)
+
+	limiter := ratelimit.New(5, 0)
+	_, handler := getRateLimitStatus(limiter)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	assert.NoError(err)
+
+	var status RateLimitStatus
+	assert.NoError(json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &status))
+	assert.Empty(status.Organizations)
+	assert.NotEmpty(status.Note)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("RateLimit-Limit", "200")
+		w.Header().Set("RateLimit-Remaining", "150")
+		w.Header().Set("RateLimit-Reset", "42")
+	}))
+	defer srv.Close()
+
+	resp, err := (&http.Client{Transport: limiter.Transport(nil)}).Get(srv.URL + "/v2/organizations/acme/builds")
+	assert.NoError(err)
+	_ = resp.Body.Close()
+
+	result, err = handler(context.Background(), mcp.CallToolRequest{})
+	assert.NoError(err)
+
+	status = RateLimitStatus{}
+	assert.NoError(json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &status))
+	assert.Len(status.Organizations, 1)
+	assert.Equal("acme", status.Organizations[0].Organization)
+	assert.Equal(150, status.Organizations[0].Remaining)
+	assert.InDelta(42, status.Organizations[0].ResetInSeconds, 1)
+	assert.Empty(status.Note)
+}
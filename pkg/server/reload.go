@@ -0,0 +1,206 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/buildkite"
+	"github.com/buildkite/buildkite-mcp-server/pkg/oauth"
+	"github.com/buildkite/buildkite-mcp-server/pkg/policy"
+	"github.com/buildkite/buildkite-mcp-server/pkg/retry"
+	"github.com/buildkite/buildkite-mcp-server/pkg/toolsets"
+	gobuildkite "github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog/log"
+)
+
+// Reloader replaces the toolsets, policies, redaction and scrubbing rules of a running server.
+// Clients are sent a tools/list_changed notification when this changes the tools they can call.
+type Reloader struct {
+	server     *server.MCPServer
+	client     *gobuildkite.Client
+	logsClient buildkite.BuildkiteLogsClient
+
+	// mu serializes reloads, calls read the current configuration and handlers without it
+	mu       sync.Mutex
+	cfg      atomic.Pointer[ToolsetConfig]
+	handlers atomic.Pointer[map[string]server.ToolHandlerFunc]
+	// definitions are the registered tools with their scopes and toolsets, for describing them
+	definitions atomic.Pointer[map[string]toolsets.ToolDefinition]
+	// surface is the JSON of the registered tool definitions, to tell whether a reload changed them
+	surface string
+	// middleware is the server's tool middleware, outermost first, applied to the steps of batches
+	middleware []server.ToolHandlerMiddleware
+}
+
+func newReloader(client *gobuildkite.Client, logsClient buildkite.BuildkiteLogsClient, cfg *ToolsetConfig) *Reloader {
+	r := &Reloader{client: client, logsClient: logsClient}
+	r.cfg.Store(cfg)
+	r.handlers.Store(&map[string]server.ToolHandlerFunc{})
+	r.definitions.Store(&map[string]toolsets.ToolDefinition{})
+	return r
+}
+
+// Reload applies the options on top of the current configuration
+func (r *Reloader) Reload(opts ...ToolsetOption) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg := *r.cfg.Load()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	r.apply(&cfg)
+
+	log.Info().Strs("enabled_toolsets", cfg.EnabledToolsets).Bool("read_only", cfg.ReadOnly).Msg("Reloaded configuration")
+}
+
+// unscoped is the scope of the server's own tools, which touch no pipelines. The steps of batches
+// are checked against the scope policy as they're called.
+var unscoped = policy.ToolScope{Unscoped: true}
+
+// apply swaps in the configuration and the tools it enables, only re-registering the tools when
+// their definitions changed so clients aren't told to list them again for nothing
+func (r *Reloader) apply(cfg *ToolsetConfig) {
+	definitions := toolDefinitions(r.client, r.logsClient, cfg)
+	if len(definitions) > 0 {
+		tool, handler := r.executeBatch(cfg.ReadOnly)
+		definitions = append(definitions, toolsets.ToolDefinition{Tool: tool, Handler: handler, Toolset: serverToolset, Scope: unscoped})
+		tool, handler = r.getToolSchema()
+		definitions = append(definitions, toolsets.ToolDefinition{Tool: tool, Handler: handler, Toolset: serverToolset, Scope: unscoped})
+		if cfg.RateLimiter != nil {
+			tool, handler = getRateLimitStatus(cfg.RateLimiter)
+			definitions = append(definitions, toolsets.ToolDefinition{Tool: tool, Handler: handler, Toolset: serverToolset, Scope: unscoped})
+		}
+		if cfg.LogCache != nil {
+			tool, handler = getCacheStats(cfg.LogCache)
+			definitions = append(definitions, toolsets.ToolDefinition{Tool: tool, Handler: handler, Toolset: logCacheToolset, Scope: unscoped})
+			if !cfg.ReadOnly {
+				tool, handler = purgeLogCache(cfg.LogCache)
+				definitions = append(definitions, toolsets.ToolDefinition{Tool: tool, Handler: handler, Toolset: logCacheToolset})
+			}
+		}
+		if cfg.WebhookReceiver != nil {
+			tool, handler = subscribeToEvents(cfg.WebhookReceiver)
+			definitions = append(definitions, toolsets.ToolDefinition{Tool: tool, Handler: handler, Toolset: eventsToolset, Scope: policy.ToolScope{FiltersResults: true}})
+		}
+	}
+
+	tools := make([]server.ServerTool, 0, len(definitions))
+	handlers := make(map[string]server.ToolHandlerFunc, len(definitions))
+	byName := make(map[string]toolsets.ToolDefinition, len(definitions))
+	for _, definition := range definitions {
+		tools = append(tools, server.ServerTool{Tool: definition.Tool, Handler: definition.Handler})
+		handlers[definition.Tool.Name] = definition.Handler
+		byName[definition.Tool.Name] = definition
+	}
+
+	registered := make([]mcp.Tool, 0, len(tools))
+	for _, tool := range tools {
+		registered = append(registered, tool.Tool)
+	}
+	slices.SortFunc(registered, func(a, b mcp.Tool) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	surface, _ := json.Marshal(registered)
+
+	r.handlers.Store(&handlers)
+	r.definitions.Store(&byName)
+	r.cfg.Store(cfg)
+
+	if string(surface) == r.surface {
+		return
+	}
+	r.surface = string(surface)
+
+	delegates := make([]server.ServerTool, 0, len(tools))
+	for _, tool := range tools {
+		delegates = append(delegates, server.ServerTool{Tool: tool.Tool, Handler: r.toolHandler(tool.Tool.Name)})
+	}
+
+	if r.server != nil {
+		r.server.SetTools(delegates...)
+	}
+}
+
+// toolHandler calls the current handler of the tool, so handlers can be replaced without
+// re-registering the tools
+func (r *Reloader) toolHandler(name string) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		handler, ok := (*r.handlers.Load())[name]
+		if !ok {
+			return mcp.NewToolResultError("tool " + name + " is no longer available"), nil
+		}
+		return handler(ctx, request)
+	}
+}
+
+// callTool calls a registered tool through the server's tool middleware, as if the client had called it
+func (r *Reloader) callTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	handler := r.toolHandler(request.Params.Name)
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		handler = r.middleware[i](handler)
+	}
+	return handler(ctx, request)
+}
+
+// ToolHandlerMiddleware enforces the scopes of the caller's access token, the current scope policy,
+// CEL policy and scrubbing rules, and passes the display timezone on to the tools. The API requests
+// of read-only tools are marked safe to retry.
+func (r *Reloader) ToolHandlerMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		cfg := r.cfg.Load()
+
+		if err := r.authorize(ctx, request.Params.Name); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Str("mcp.tool.name", request.Params.Name).Msg("Tool call denied by access token scopes")
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		if cfg.DisplayTimezone != nil {
+			ctx = buildkite.WithDisplayTimezone(ctx, cfg.DisplayTimezone)
+		}
+		// set for every call, so the steps of a read-only batch are only retried when they're read-only too
+		definition := (*r.definitions.Load())[request.Params.Name]
+		ctx = retry.WithIdempotent(ctx, definition.IsReadOnly())
+
+		// wrapped innermost first, so the scope policy is enforced before the CEL policy
+		handler := next
+		if !cfg.Scrubber.IsEmpty() {
+			handler = cfg.Scrubber.ToolHandlerMiddleware(handler)
+		}
+		if cfg.CELPolicy != nil {
+			handler = cfg.CELPolicy.ToolHandlerMiddleware(handler)
+		}
+		if !cfg.ScopePolicy.IsEmpty() {
+			handler = cfg.ScopePolicy.ToolHandlerMiddleware(definition.Scope)(handler)
+		}
+
+		return handler(ctx, request)
+	}
+}
+
+// authorize checks the caller's access token grants the toolset of the tool, and writes when the tool
+// isn't read-only. The server's own tools are always permitted, as batches authorize each step.
+func (r *Reloader) authorize(ctx context.Context, name string) error {
+	claims := oauth.ClaimsFromContext(ctx)
+	if claims == nil {
+		return nil
+	}
+
+	definition, ok := (*r.definitions.Load())[name]
+	if !ok || definition.Toolset == serverToolset {
+		return nil
+	}
+
+	if err := claims.Authorize(definition.Toolset, definition.IsReadOnly()); err != nil {
+		return fmt.Errorf("tool %q is not permitted: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/oauth"
+	"github.com/buildkite/buildkite-mcp-server/pkg/policy"
+	gobuildkite "github.com/buildkite/go-buildkite/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloaderReplacesTools(t *testing.T) {
+	assert := require.New(t)
+
+	client, err := gobuildkite.NewOpts(gobuildkite.WithTokenAuth("test-token"))
+	assert.NoError(err)
+
+	s, reloader := NewReloadableMCPServer("test", client, nil, WithToolsets("builds"), WithReadOnly(true))
+	assert.NotNil(s.GetTool("list_builds"))
+	assert.Nil(s.GetTool("create_build"))
+	assert.Nil(s.GetTool("list_pipelines"))
+
+	reloader.Reload(WithReadOnly(false))
+	assert.NotNil(s.GetTool("create_build"))
+	assert.Nil(s.GetTool("list_pipelines"))
+
+	reloader.Reload(WithToolsets("pipelines"))
+	assert.NotNil(s.GetTool("list_pipelines"))
+	assert.Nil(s.GetTool("list_builds"))
+}
+
+func TestReloaderReplacesPolicies(t *testing.T) {
+	assert := require.New(t)
+
+	client, err := gobuildkite.NewOpts(gobuildkite.WithTokenAuth("test-token"))
+	assert.NoError(err)
+
+	_, reloader := NewReloadableMCPServer("test", client, nil, WithToolsets("builds"))
+
+	handler := reloader.ToolHandlerMiddleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "list_builds"
+	request.Params.Arguments = map[string]any{"org_slug": "acme", "pipeline_slug": "web"}
+
+	result, err := handler(context.Background(), request)
+	assert.NoError(err)
+	assert.False(result.IsError)
+
+	celPolicy, err := policy.NewCELPolicy(`args.pipeline_slug != "web"`)
+	assert.NoError(err)
+	reloader.Reload(WithCELPolicy(celPolicy))
+
+	result, err = handler(context.Background(), request)
+	assert.NoError(err)
+	assert.True(result.IsError)
+
+	reloader.Reload(WithCELPolicy(nil))
+
+	result, err = handler(context.Background(), request)
+	assert.NoError(err)
+	assert.False(result.IsError)
+}
+
+func TestReloaderAuthorizesAccessTokenScopes(t *testing.T) {
+	assert := require.New(t)
+
+	client, err := gobuildkite.NewOpts(gobuildkite.WithTokenAuth("test-token"))
+	assert.NoError(err)
+
+	_, reloader := NewReloadableMCPServer("test", client, nil, WithToolsets("builds", "pipelines"))
+
+	handler := reloader.ToolHandlerMiddleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	call := func(ctx context.Context, name string) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = name
+		result, err := handler(ctx, request)
+		assert.NoError(err)
+		return result
+	}
+
+	ctx := oauth.WithClaims(context.Background(), &oauth.Claims{Scope: "buildkite:builds"})
+	assert.False(call(ctx, "list_builds").IsError)
+	assert.True(call(ctx, "create_build").IsError)
+	assert.True(call(ctx, "list_pipelines").IsError)
+	assert.False(call(ctx, "get_tool_schema").IsError)
+
+	ctx = oauth.WithClaims(context.Background(), &oauth.Claims{Scp: []string{"buildkite:all", "buildkite:write"}})
+	assert.False(call(ctx, "create_build").IsError)
+	assert.False(call(ctx, "list_pipelines").IsError)
+
+	assert.False(call(context.Background(), "create_build").IsError)
+}
+
+func TestReloaderEnforcesDeclaredToolScopes(t *testing.T) {
+	assert := require.New(t)
+
+	client, err := gobuildkite.NewOpts(gobuildkite.WithTokenAuth("test-token"))
+	assert.NoError(err)
+
+	scopePolicy, err := policy.NewScopePolicy(nil, []string{"web"})
+	assert.NoError(err)
+	_, reloader := NewReloadableMCPServer("test", client, nil, WithToolsets("all"), WithScopePolicy(scopePolicy))
+
+	handler := reloader.ToolHandlerMiddleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	call := func(name string, args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = name
+		request.Params.Arguments = args
+		result, err := handler(context.Background(), request)
+		assert.NoError(err)
+		return result
+	}
+
+	assert.False(call("list_builds", map[string]any{"org_slug": "acme", "pipeline_slug": "web"}).IsError)
+	assert.True(call("list_builds", map[string]any{"org_slug": "acme", "pipeline_slug": "api"}).IsError)
+	assert.True(call("get_artifact", map[string]any{"url": "https://api.buildkite.com/v2/organizations/acme/pipelines/api/builds/1/jobs/a/artifacts/b/download"}).IsError)
+
+	// tools which filter their results, or touch no pipelines, may be called without naming one
+	assert.False(call("list_pipelines", map[string]any{"org_slug": "acme"}).IsError)
+	assert.False(call("list_clusters", map[string]any{"org_slug": "acme"}).IsError)
+	assert.False(call("get_tool_schema", map[string]any{"tool_name": "list_builds"}).IsError)
+
+	// tools across an organization which declare no scope fail closed
+	assert.True(call("list_agents", map[string]any{"org_slug": "acme"}).IsError)
+	assert.True(call("get_organization_usage", map[string]any{"org_slug": "acme"}).IsError)
+}
+
+func TestReloaderWithholdsGraphQLQueriesUnderScopePolicy(t *testing.T) {
+	assert := require.New(t)
+
+	client, err := gobuildkite.NewOpts(gobuildkite.WithTokenAuth("test-token"))
+	assert.NoError(err)
+
+	s, reloader := NewReloadableMCPServer("test", client, nil, WithToolsets("graphql"))
+	assert.NotNil(s.GetTool("run_graphql_query"))
+
+	scopePolicy, err := policy.NewScopePolicy([]string{"acme"}, nil)
+	assert.NoError(err)
+	reloader.Reload(WithScopePolicy(scopePolicy))
+	assert.Nil(s.GetTool("run_graphql_query"))
+	assert.NotNil(s.GetTool("get_organization_usage"))
+}
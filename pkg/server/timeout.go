@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog/log"
+)
+
+// waitTimeoutArg is the argument, in seconds, of the tools which wait for a build or follow a log
+const waitTimeoutArg = "wait_timeout"
+
+// toolResult is what a tool handler returned
+type toolResult struct {
+	result *mcp.CallToolResult
+	err    error
+}
+
+// timeout cancels the context of calls running longer than their tool's timeout, returning an error
+// saying so rather than leaving the session waiting on a slow API. Handlers which don't return once
+// their context is done are left to finish in the background.
+func (r *Reloader) timeout(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		timeout := r.toolTimeout(request)
+		if timeout <= 0 {
+			return next(ctx, request)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		// buffered so the handler can return after the call has timed out
+		done := make(chan toolResult, 1)
+		go func() {
+			result, err := next(ctx, request)
+			done <- toolResult{result: result, err: err}
+		}()
+
+		select {
+		case called := <-done:
+			// the handler may report its cancelled API call as an error of its own
+			if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return called.result, called.err
+			}
+		case <-ctx.Done():
+			if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, ctx.Err()
+			}
+		}
+
+		log.Ctx(ctx).Warn().Str("mcp.tool.name", request.Params.Name).Dur("timeout", timeout).Msg("Tool call timed out")
+
+		return mcp.NewToolResultError(fmt.Sprintf("%s timed out after %s waiting for the Buildkite API: try again, or ask for less, e.g. with a lower limit or page size or narrower filters", request.Params.Name, timeout)), nil
+	}
+}
+
+// toolTimeout returns how long a call may run for, which is its tool's override or the default
+// timeout. Tools which wait are given their wait_timeout, or its default, on top, and batches are
+// left to their steps' timeouts.
+func (r *Reloader) toolTimeout(request mcp.CallToolRequest) time.Duration {
+	cfg := r.cfg.Load()
+
+	timeout, ok := cfg.ToolTimeouts[request.Params.Name]
+	if ok || request.Params.Name == executeBatchToolName || cfg.ToolTimeout <= 0 {
+		return timeout
+	}
+
+	definition, ok := (*r.definitions.Load())[request.Params.Name]
+	if !ok {
+		return cfg.ToolTimeout
+	}
+	property, ok := definition.Tool.InputSchema.Properties[waitTimeoutArg].(map[string]any)
+	if !ok {
+		return cfg.ToolTimeout
+	}
+
+	wait, _ := property["default"].(float64)
+	wait = request.GetFloat(waitTimeoutArg, wait)
+	if wait <= 0 {
+		return cfg.ToolTimeout
+	}
+	return cfg.ToolTimeout + time.Duration(wait*float64(time.Second))
+}
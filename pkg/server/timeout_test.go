@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/toolsets"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeout(t *testing.T) {
+	assert := require.New(t)
+
+	reloader := newReloader(nil, nil, &ToolsetConfig{
+		ToolTimeout:  10 * time.Millisecond,
+		ToolTimeouts: map[string]time.Duration{"unlimited": 0},
+	})
+
+	// the handler ignores its context, so the call returns without waiting for it
+	block := make(chan struct{})
+	defer close(block)
+	handler := reloader.timeout(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if request.Params.Name == "slow" {
+			<-block
+		}
+		return mcp.NewToolResultText("done"), nil
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "slow"
+	result, err := handler(context.Background(), request)
+	assert.NoError(err)
+	assert.True(result.IsError)
+	assert.Equal("slow timed out after 10ms waiting for the Buildkite API: try again, or ask for less, e.g. with a lower limit or page size or narrower filters", result.Content[0].(mcp.TextContent).Text)
+
+	request.Params.Name = "fast"
+	result, err = handler(context.Background(), request)
+	assert.NoError(err)
+	assert.Equal("done", result.Content[0].(mcp.TextContent).Text)
+}
+
+func TestToolTimeout(t *testing.T) {
+	assert := require.New(t)
+
+	reloader := newReloader(nil, nil, &ToolsetConfig{
+		ToolTimeout:  time.Minute,
+		ToolTimeouts: map[string]time.Duration{"read_logs": 5 * time.Minute, "search_logs": 0},
+	})
+	waiting := mcp.NewTool("wait_for_build", mcp.WithNumber(waitTimeoutArg, mcp.DefaultNumber(300)))
+	reloader.definitions.Store(&map[string]toolsets.ToolDefinition{"wait_for_build": {Tool: waiting}})
+
+	timeout := func(name string, args map[string]any) time.Duration {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = name
+		request.Params.Arguments = args
+		return reloader.toolTimeout(request)
+	}
+
+	assert.Equal(time.Minute, timeout("list_builds", nil))
+	assert.Equal(5*time.Minute, timeout("read_logs", nil))
+	assert.Equal(time.Duration(0), timeout("search_logs", nil))
+	assert.Equal(time.Duration(0), timeout(executeBatchToolName, nil))
+
+	// tools which wait are given their wait_timeout, or its default, on top
+	assert.Equal(6*time.Minute, timeout("wait_for_build", nil))
+	assert.Equal(3*time.Minute, timeout("wait_for_build", map[string]any{"wait_timeout": 120}))
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/trace"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	getToolSchemaToolName = "get_tool_schema"
+
+	// serverToolset owns the tools provided by the server itself rather than a Buildkite toolset
+	serverToolset = "server"
+)
+
+type GetToolSchemaArgs struct {
+	ToolName string `json:"tool_name"`
+}
+
+type ToolSchema struct {
+	Name           string             `json:"name"`
+	Description    string             `json:"description"`
+	Toolset        string             `json:"toolset"`
+	InputSchema    json.RawMessage    `json:"input_schema"`
+	Annotations    mcp.ToolAnnotation `json:"annotations"`
+	RequiredScopes []string           `json:"required_scopes"`
+}
+
+// getToolSchema returns the get_tool_schema tool, which describes a single registered tool so
+// clients can check the arguments of a call without listing all the tools again
+func (r *Reloader) getToolSchema() (mcp.Tool, server.ToolHandlerFunc) {
+	tool := mcp.NewTool(getToolSchemaToolName,
+		mcp.WithDescription("Get the full input schema, annotations, required Buildkite API token scopes and owning toolset of a tool, to check the arguments of a call after it was rejected"),
+		mcp.WithString("tool_name",
+			mcp.Required(),
+			mcp.Description("The name of the tool to describe"),
+		),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{
+			Title:        "Get Tool Schema",
+			ReadOnlyHint: mcp.ToBoolPtr(true),
+		}),
+	)
+
+	handler := mcp.NewTypedToolHandler(func(ctx context.Context, request mcp.CallToolRequest, args GetToolSchemaArgs) (*mcp.CallToolResult, error) {
+		_, span := trace.Start(ctx, "server.GetToolSchema")
+		defer span.End()
+
+		if args.ToolName == "" {
+			return mcp.NewToolResultError("tool_name parameter is required"), nil
+		}
+
+		span.SetAttributes(attribute.String("tool_name", args.ToolName))
+
+		definitions := *r.definitions.Load()
+		definition, ok := definitions[args.ToolName]
+		if !ok {
+			names := make([]string, 0, len(definitions))
+			for name := range definitions {
+				names = append(names, name)
+			}
+			slices.Sort(names)
+			return mcp.NewToolResultError(fmt.Sprintf("unknown tool %q, available tools are %v", args.ToolName, names)), nil
+		}
+
+		// marshal the tool to get its input schema however it was defined
+		data, err := json.Marshal(definition.Tool)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to marshal tool: %v", err)), nil
+		}
+		var marshalled struct {
+			InputSchema json.RawMessage `json:"inputSchema"`
+		}
+		if err := json.Unmarshal(data, &marshalled); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to read input schema: %v", err)), nil
+		}
+
+		scopes := definition.RequiredScopes
+		if scopes == nil {
+			scopes = []string{}
+		}
+
+		result := ToolSchema{
+			Name:           definition.Tool.Name,
+			Description:    definition.Tool.Description,
+			Toolset:        definition.Toolset,
+			InputSchema:    marshalled.InputSchema,
+			Annotations:    definition.Tool.Annotations,
+			RequiredScopes: scopes,
+		}
+
+		out, err := json.Marshal(&result)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(out)), nil
+	})
+
+	return tool, handler
+}
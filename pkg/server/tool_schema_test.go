@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/toolsets"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetToolSchema(t *testing.T) {
+	assert := require.New(t)
+
+	reloader := newReloader(nil, nil, &ToolsetConfig{})
+	reloader.definitions.Store(&map[string]toolsets.ToolDefinition{
+		"get_build": {
+			Tool: mcp.NewTool("get_build",
+				mcp.WithDescription("Get a build"),
+				mcp.WithString("build_number", mcp.Required()),
+				mcp.WithToolAnnotation(mcp.ToolAnnotation{Title: "Get Build", ReadOnlyHint: mcp.ToBoolPtr(true)}),
+			),
+			RequiredScopes: []string{"read_builds"},
+			Toolset:        toolsets.ToolsetBuilds,
+		},
+	})
+	_, handler := reloader.getToolSchema()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"tool_name": "get_build"}
+
+	result, err := handler(context.Background(), request)
+	assert.NoError(err)
+	assert.False(result.IsError)
+
+	var schema ToolSchema
+	assert.NoError(json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &schema))
+	assert.Equal("get_build", schema.Name)
+	assert.Equal(toolsets.ToolsetBuilds, schema.Toolset)
+	assert.Equal([]string{"read_builds"}, schema.RequiredScopes)
+	assert.Equal("Get Build", schema.Annotations.Title)
+	assert.JSONEq(`{"type":"object","properties":{"build_number":{"type":"string"}},"required":["build_number"]}`, string(schema.InputSchema))
+
+	request.Params.Arguments = map[string]any{"tool_name": "get_builds"}
+	result, err = handler(context.Background(), request)
+	assert.NoError(err)
+	assert.True(result.IsError)
+	assert.Contains(result.Content[0].(mcp.TextContent).Text, "[get_build]")
+}
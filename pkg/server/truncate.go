@@ -0,0 +1,140 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/tokens"
+)
+
+// maxTruncatedLists is how many of a result's lists are cut short before giving up on fitting it
+const maxTruncatedLists = 5
+
+// truncatedList is a list within a JSON result, with how to replace it
+type truncatedList struct {
+	path     string
+	items    []any
+	size     int
+	set      func([]any)
+	returned int
+}
+
+// findLists collects the lists within a decoded JSON value, including lists nested in the items of
+// other lists
+func findLists(value any, path string, lists *[]*truncatedList) {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, child := range value {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			if items, ok := child.([]any); ok {
+				data, _ := json.Marshal(items)
+				*lists = append(*lists, &truncatedList{
+					path:     childPath,
+					items:    items,
+					size:     len(data),
+					set:      func(items []any) { value[key] = items },
+					returned: len(items),
+				})
+			}
+			findLists(child, childPath, lists)
+		}
+	case []any:
+		for _, item := range value {
+			findLists(item, path+"[]", lists)
+		}
+	}
+}
+
+// longestList returns the longest non-empty list of a result which hasn't been cut yet, looking
+// only at the items kept of the lists which have
+func longestList(root map[string]any, cut []*truncatedList) *truncatedList {
+	var lists []*truncatedList
+	findLists(root, "", &lists)
+
+	var longest *truncatedList
+	for _, list := range lists {
+		if len(list.items) == 0 || slices.ContainsFunc(cut, func(c *truncatedList) bool { return c.path == list.path }) {
+			continue
+		}
+		if longest == nil || list.size > longest.size {
+			longest = list
+		}
+	}
+	return longest
+}
+
+// truncateResult cuts the longest lists of a JSON result short until it fits in maxTokens, as counted
+// by the tokenizer, marking it truncated with a hint naming the lists and how to get the rest. A
+// result which is a list is wrapped in an object under "items" for the marker. It returns false when
+// the result isn't JSON, or doesn't fit even with its longest lists emptied.
+func truncateResult(tokenizer tokens.Tokenizer, text string, maxTokens int) (string, bool) {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return "", false
+	}
+
+	var root map[string]any
+	switch value := value.(type) {
+	case map[string]any:
+		root = value
+	case []any:
+		root = map[string]any{"items": value}
+	default:
+		return "", false
+	}
+
+	var cut []*truncatedList
+	render := func() (string, bool) {
+		root["truncated"] = true
+		root["truncation_hint"] = truncationHint(cut, maxTokens)
+
+		data, err := json.Marshal(root)
+		if err != nil {
+			return "", false
+		}
+		return string(data), tokenizer.CountTokens(string(data)) <= maxTokens
+	}
+
+	for range maxTruncatedLists {
+		// the longest lists are cut first, so as few as possible lose items
+		list := longestList(root, cut)
+		if list == nil {
+			break
+		}
+		cut = append(cut, list)
+
+		// keep the most items which fit, the first items being the ones asked for first
+		keep := sort.Search(len(list.items), func(n int) bool {
+			list.returned = n + 1
+			list.set(list.items[:n+1])
+			_, fits := render()
+			return !fits
+		})
+		list.returned = keep
+		list.set(list.items[:keep])
+
+		if text, fits := render(); fits {
+			return text, true
+		}
+	}
+
+	return "", false
+}
+
+// truncationHint explains which lists were cut short and how to get the rest of them
+func truncationHint(cut []*truncatedList, maxTokens int) string {
+	counts := make([]string, 0, len(cut))
+	for _, list := range cut {
+		counts = append(counts, fmt.Sprintf("%s (%d of %d)", list.path, list.returned, len(list.items)))
+	}
+	return fmt.Sprintf("the result was over the limit of %d tokens, so only the first items of %s were returned: paginate with page and per_page, or ask for less with a lower limit, narrower filters or a less detailed detail_level", maxTokens, strings.Join(counts, ", "))
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/tokens"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncateResult(t *testing.T) {
+	assert := require.New(t)
+
+	type build struct {
+		Number int      `json:"number"`
+		Jobs   []string `json:"jobs"`
+	}
+	builds := make([]build, 50)
+	for i := range builds {
+		builds[i] = build{Number: i + 1, Jobs: []string{"lint", "test", "deploy"}}
+	}
+	data, err := json.Marshal(map[string]any{"builds": builds, "page": 1})
+	assert.NoError(err)
+	assert.Greater(tokens.EstimateTokens(string(data)), 200)
+
+	text, ok := truncateResult(tokens.Default(), string(data), 200)
+	assert.True(ok)
+	assert.LessOrEqual(tokens.EstimateTokens(text), 200)
+
+	var result struct {
+		Builds         []build `json:"builds"`
+		Page           int     `json:"page"`
+		Truncated      bool    `json:"truncated"`
+		TruncationHint string  `json:"truncation_hint"`
+	}
+	assert.NoError(json.Unmarshal([]byte(text), &result))
+	assert.True(result.Truncated)
+	assert.Equal(1, result.Page)
+	assert.NotEmpty(result.Builds)
+	assert.Less(len(result.Builds), 50)
+
+	// the first items are kept, whole
+	assert.Equal(builds[:len(result.Builds)], result.Builds)
+	assert.Contains(result.TruncationHint, fmt.Sprintf("builds (%d of 50)", len(result.Builds)))
+	assert.Contains(result.TruncationHint, "paginate")
+}
+
+func TestTruncateResultList(t *testing.T) {
+	assert := require.New(t)
+
+	items := make([]string, 100)
+	for i := range items {
+		items[i] = fmt.Sprintf("artifact-%03d.tar.gz", i)
+	}
+	data, err := json.Marshal(items)
+	assert.NoError(err)
+
+	// a list is wrapped so it can be marked truncated
+	text, ok := truncateResult(tokens.Default(), string(data), 100)
+	assert.True(ok)
+
+	var result struct {
+		Items     []string `json:"items"`
+		Truncated bool     `json:"truncated"`
+	}
+	assert.NoError(json.Unmarshal([]byte(text), &result))
+	assert.True(result.Truncated)
+	assert.Equal(items[:len(result.Items)], result.Items)
+}
+
+func TestTruncateResultCannotFit(t *testing.T) {
+	assert := require.New(t)
+
+	_, ok := truncateResult(tokens.Default(), "not json at all", 1)
+	assert.False(ok)
+
+	// a result without lists can't be truncated
+	_, ok = truncateResult(tokens.Default(), `{"log":"a long line of output which goes on and on and on"}`, 5)
+	assert.False(ok)
+}
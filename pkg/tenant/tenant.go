@@ -0,0 +1,72 @@
+// Package tenant carries the Buildkite API token of each caller through its requests, so a server
+// hosted for several users calls the Buildkite API with every user's own credentials.
+package tenant
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+type tokenKey struct{}
+
+// WithToken records the caller's API token on the context
+func WithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, tokenKey{}, token)
+}
+
+// TokenFromContext returns the caller's API token recorded on the context, or "" if there isn't one
+func TokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(tokenKey{}).(string)
+	return token
+}
+
+// BearerToken returns the token of the request's bearer Authorization header, or "" if it has none
+func BearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// RequireToken rejects requests without a bearer token, recording the token of the others on their
+// context for the API clients to use
+func RequireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := BearerToken(r)
+		if token == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="buildkite-mcp-server"`)
+			http.Error(w, "a Buildkite API token is required as a bearer token in the Authorization header", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithToken(r.Context(), token)))
+	})
+}
+
+// Transport wraps the transport of an API client, authorizing each request with the caller's token
+// when one is recorded on its context in place of the client's own
+func Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{next: next}
+}
+
+type transport struct {
+	next http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token := TokenFromContext(req.Context())
+	if token == "" {
+		return t.next.RoundTrip(req)
+	}
+
+	// round trippers must not modify the request they are given
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,73 @@
+package tenant
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBearerToken(t *testing.T) {
+	assert := require.New(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	assert.Equal("", BearerToken(req))
+
+	req.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+	assert.Equal("", BearerToken(req))
+
+	req.Header.Set("Authorization", "bearer bkua_abc")
+	assert.Equal("bkua_abc", BearerToken(req))
+}
+
+func TestRequireToken(t *testing.T) {
+	assert := require.New(t)
+
+	var token string
+	handler := RequireToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = TokenFromContext(r.Context())
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", nil))
+	assert.Equal(http.StatusUnauthorized, rec.Code)
+	assert.Contains(rec.Header().Get("WWW-Authenticate"), "Bearer")
+
+	req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	req.Header.Set("Authorization", "Bearer bkua_abc")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(http.StatusOK, rec.Code)
+	assert.Equal("bkua_abc", token)
+}
+
+func TestTransport(t *testing.T) {
+	assert := require.New(t)
+
+	var authorization string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: Transport(nil)}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, srv.URL, nil)
+	assert.NoError(err)
+	req.Header.Set("Authorization", "Bearer server-token")
+	resp, err := client.Do(req)
+	assert.NoError(err)
+	_ = resp.Body.Close()
+	assert.Equal("Bearer server-token", authorization)
+
+	req, err = http.NewRequestWithContext(WithToken(context.Background(), "caller-token"), http.MethodGet, srv.URL, nil)
+	assert.NoError(err)
+	req.Header.Set("Authorization", "Bearer server-token")
+	resp, err = client.Do(req)
+	assert.NoError(err)
+	_ = resp.Body.Close()
+	assert.Equal("Bearer caller-token", authorization)
+	assert.Equal("Bearer server-token", req.Header.Get("Authorization"))
+}
@@ -0,0 +1,208 @@
+package tokens
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+)
+
+// Names of the built in tokenizers
+const (
+	// Heuristic counts words, weighted by their length, and is the default
+	Heuristic = "heuristic"
+	// CL100K approximates the cl100k_base encoding of GPT-4 and GPT-3.5
+	CL100K = "cl100k"
+	// O200K approximates the o200k_base encoding of GPT-4o and later OpenAI models
+	O200K = "o200k"
+	// Claude approximates the tokenizer of Anthropic's Claude models
+	Claude = "claude"
+)
+
+// Tokenizer estimates how many tokens text takes up in a model's context
+type Tokenizer interface {
+	CountTokens(text string) int
+}
+
+// TokenizerFunc adapts a function to a Tokenizer
+type TokenizerFunc func(text string) int
+
+func (f TokenizerFunc) CountTokens(text string) int {
+	return f(text)
+}
+
+var (
+	mu         sync.RWMutex
+	tokenizers = map[string]Tokenizer{
+		Heuristic: TokenizerFunc(heuristic),
+		// the OpenAI encodings split numbers into runs of up to 3 digits, the larger vocabulary of
+		// o200k covering more of each word and more scripts than cl100k
+		CL100K: approximate{charsPerToken: 4.5, digitsPerToken: 3, runesPerNonLatinToken: 1},
+		O200K:  approximate{charsPerToken: 5, digitsPerToken: 3, runesPerNonLatinToken: 1.5},
+		// Claude's tokenizer splits the same text, especially code and numbers, into more tokens
+		Claude: approximate{charsPerToken: 3.8, digitsPerToken: 2, runesPerNonLatinToken: 1},
+	}
+
+	defaultTokenizer atomic.Value
+)
+
+// Register adds a tokenizer under name, or replaces the tokenizer already registered under it,
+// e.g. to count tokens exactly with a model's vocabulary
+func Register(name string, tokenizer Tokenizer) {
+	mu.Lock()
+	defer mu.Unlock()
+	tokenizers[name] = tokenizer
+}
+
+// Names returns the names of the registered tokenizers, sorted
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(tokenizers))
+	for name := range tokenizers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Get returns the tokenizer registered under name
+func Get(name string) (Tokenizer, error) {
+	mu.RLock()
+	tokenizer, ok := tokenizers[name]
+	mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown tokenizer %q, expected one of: %s", name, strings.Join(Names(), ", "))
+	}
+	return tokenizer, nil
+}
+
+// ForModel returns the tokenizer for a model or the client using one, e.g. "gpt-4o" or
+// "claude-code", or the default tokenizer when the model isn't known. A registered tokenizer's name
+// is also accepted.
+func ForModel(model string) Tokenizer {
+	model = strings.ToLower(strings.TrimSpace(model))
+	if tokenizer, err := Get(model); err == nil {
+		return tokenizer
+	}
+
+	var name string
+	switch {
+	case strings.Contains(model, "claude"), strings.Contains(model, "anthropic"):
+		name = Claude
+	case strings.HasPrefix(model, "gpt-4o"), strings.HasPrefix(model, "gpt-4.1"), strings.HasPrefix(model, "gpt-5"),
+		strings.HasPrefix(model, "o1"), strings.HasPrefix(model, "o3"), strings.HasPrefix(model, "o4"),
+		strings.Contains(model, "codex"), strings.Contains(model, "chatgpt"):
+		name = O200K
+	case strings.HasPrefix(model, "gpt-4"), strings.HasPrefix(model, "gpt-3.5"):
+		name = CL100K
+	default:
+		return Default()
+	}
+
+	tokenizer, _ := Get(name)
+	return tokenizer
+}
+
+// SetDefault replaces the tokenizer used by EstimateTokens and when a call's model isn't known
+func SetDefault(tokenizer Tokenizer) {
+	defaultTokenizer.Store(&tokenizer)
+}
+
+// Default returns the tokenizer used by EstimateTokens, the heuristic one unless replaced with
+// SetDefault
+func Default() Tokenizer {
+	if tokenizer, ok := defaultTokenizer.Load().(*Tokenizer); ok {
+		return *tokenizer
+	}
+	return TokenizerFunc(heuristic)
+}
+
+type contextKey struct{}
+
+// WithTokenizer returns a context whose token counts are made with the tokenizer, e.g. the one for
+// the model of the client making a call
+func WithTokenizer(ctx context.Context, tokenizer Tokenizer) context.Context {
+	return context.WithValue(ctx, contextKey{}, tokenizer)
+}
+
+// FromContext returns the tokenizer set with WithTokenizer, or the default tokenizer
+func FromContext(ctx context.Context) Tokenizer {
+	if tokenizer, ok := ctx.Value(contextKey{}).(Tokenizer); ok {
+		return tokenizer
+	}
+	return Default()
+}
+
+// CountTokens returns an estimate of the number of tokens in text for the tokenizer of the context
+func CountTokens(ctx context.Context, text string) int {
+	return FromContext(ctx).CountTokens(text)
+}
+
+// approximate estimates tokens the way byte pair encodings split text, without their vocabularies:
+// text is split into runs of letters, digits, punctuation and whitespace like the encodings'
+// pre-tokenizers, and each run is counted by its length
+type approximate struct {
+	// charsPerToken is how many letters past the first of a Latin word make up a token on average
+	charsPerToken float64
+	// digitsPerToken is the length of the runs numbers are split into
+	digitsPerToken int
+	// runesPerNonLatinToken is how many letters of other scripts, e.g. CJK, make up a token
+	runesPerNonLatinToken float64
+}
+
+func (a approximate) CountTokens(text string) int {
+	count := 0
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		j := i + 1
+
+		switch {
+		case unicode.IsLetter(r) && r < unicode.MaxLatin1:
+			for j < len(runes) && unicode.IsLetter(runes[j]) && runes[j] < unicode.MaxLatin1 {
+				j++
+			}
+			// common words are a token of their own, longer ones a token per few more letters
+			count += 1 + int(float64(j-i-1)/a.charsPerToken)
+		case unicode.IsLetter(r):
+			for j < len(runes) && unicode.IsLetter(runes[j]) && runes[j] >= unicode.MaxLatin1 {
+				j++
+			}
+			count += int(math.Ceil(float64(j-i) / a.runesPerNonLatinToken))
+		case unicode.IsDigit(r):
+			for j < len(runes) && unicode.IsDigit(runes[j]) {
+				j++
+			}
+			count += (j - i + a.digitsPerToken - 1) / a.digitsPerToken
+		case r == ' ':
+			// a single space is part of the token of the word after it
+			for j < len(runes) && runes[j] == ' ' {
+				j++
+			}
+			if j-i > 1 || j == len(runes) || !unicode.IsLetter(runes[j]) {
+				count++
+			}
+		case unicode.IsSpace(r):
+			for j < len(runes) && unicode.IsSpace(runes[j]) {
+				j++
+			}
+			count++
+		default:
+			// runs of punctuation and symbols, such as JSON's '":"', merge in pairs
+			for j < len(runes) && !unicode.IsLetter(runes[j]) && !unicode.IsDigit(runes[j]) && !unicode.IsSpace(runes[j]) {
+				j++
+			}
+			count += (j - i + 1) / 2
+		}
+
+		i = j
+	}
+	return count
+}
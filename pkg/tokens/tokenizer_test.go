@@ -0,0 +1,82 @@
+package tokens
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApproximateTokenizers(t *testing.T) {
+	assert := require.New(t)
+
+	cl100k, err := Get(CL100K)
+	assert.NoError(err)
+	o200k, err := Get(O200K)
+	assert.NoError(err)
+	claude, err := Get(Claude)
+	assert.NoError(err)
+
+	assert.Equal(0, cl100k.CountTokens(""))
+	// a space is part of the word after it, and numbers are split into runs of 3 digits
+	assert.Equal(2, cl100k.CountTokens("hello world"))
+	assert.Equal(2, cl100k.CountTokens("123456"))
+	assert.Equal(3, claude.CountTokens("123456"))
+
+	text := strings.Repeat(`{"state":"failed","label":":rspec: Integration tests","exit_status":1} `, 20)
+	assert.Greater(claude.CountTokens(text), cl100k.CountTokens(text))
+	assert.GreaterOrEqual(cl100k.CountTokens(text), o200k.CountTokens(text))
+}
+
+func TestGetUnknownTokenizer(t *testing.T) {
+	assert := require.New(t)
+
+	_, err := Get("bpe")
+	assert.ErrorContains(err, `unknown tokenizer "bpe", expected one of: cl100k, claude, heuristic, o200k`)
+}
+
+func TestForModel(t *testing.T) {
+	assert := require.New(t)
+
+	for model, name := range map[string]string{
+		"claude-sonnet-4": Claude,
+		"claude-code":     Claude,
+		"gpt-4o-mini":     O200K,
+		"o3":              O200K,
+		"gpt-4-turbo":     CL100K,
+		"cl100k":          CL100K,
+	} {
+		expected, err := Get(name)
+		assert.NoError(err)
+		assert.Equal(expected, ForModel(model), model)
+	}
+
+	// unknown models use the default
+	assert.Equal(EstimateTokens("this is a test"), ForModel("Visual Studio Code").CountTokens("this is a test"))
+}
+
+func TestRegisterAndDefault(t *testing.T) {
+	assert := require.New(t)
+
+	chars := TokenizerFunc(func(text string) int { return len(text) })
+	Register("chars", chars)
+	t.Cleanup(func() {
+		mu.Lock()
+		delete(tokenizers, "chars")
+		mu.Unlock()
+		SetDefault(TokenizerFunc(heuristic))
+	})
+
+	tokenizer, err := Get("chars")
+	assert.NoError(err)
+	assert.Equal(4, tokenizer.CountTokens("test"))
+
+	SetDefault(tokenizer)
+	assert.Equal(14, EstimateTokens("this is a test"))
+
+	// a context's tokenizer is used in place of the default
+	ctx := WithTokenizer(context.Background(), TokenizerFunc(heuristic))
+	assert.Equal(4, CountTokens(ctx, "this is a test"))
+	assert.Equal(14, CountTokens(context.Background(), "this is a test"))
+}
@@ -4,8 +4,14 @@ import (
 	"strings"
 )
 
-// EstimateTokens returns an estimate of the number of tokens in the given text.
+// EstimateTokens returns an estimate of the number of tokens in the given text, made with the
+// default tokenizer.
 func EstimateTokens(text string) int {
+	return Default().CountTokens(text)
+}
+
+// heuristic estimates tokens by counting words, with longer words counting as more tokens.
+func heuristic(text string) int {
 	words := strings.Fields(text)
 	tokenCount := 0
 
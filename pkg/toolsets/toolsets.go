@@ -3,9 +3,11 @@ package toolsets
 import (
 	"fmt"
 	"slices"
+	"time"
 
-	buildkitelogs "github.com/buildkite/buildkite-logs"
 	"github.com/buildkite/buildkite-mcp-server/pkg/buildkite"
+	"github.com/buildkite/buildkite-mcp-server/pkg/policy"
+	"github.com/buildkite/buildkite-mcp-server/pkg/redact"
 	gobuildkite "github.com/buildkite/go-buildkite/v4"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -16,6 +18,15 @@ type ToolDefinition struct {
 	Tool           mcp.Tool
 	Handler        server.ToolHandlerFunc
 	RequiredScopes []string // Buildkite API token scopes required for this tool
+	Toolset        string   // Name of the toolset the tool was enabled from, set by the registry
+	// Scope declares the pipelines the tool's calls touch, for the scope policy
+	Scope policy.ToolScope
+}
+
+// WithScope returns the tool with the scope declared, replacing the one found from its arguments
+func (td ToolDefinition) WithScope(scope policy.ToolScope) ToolDefinition {
+	td.Scope = scope
+	return td
 }
 
 // IsReadOnly returns true if the tool is read-only
@@ -133,10 +144,13 @@ func (tr *ToolsetRegistry) GetEnabledTools(enabledToolsets []string, readOnlyMod
 
 	for _, toolsetName := range enabledToolsets {
 		if toolset, exists := tr.toolsets[toolsetName]; exists {
+			toolsetTools := toolset.GetAllTools()
 			if readOnlyMode {
-				tools = append(tools, toolset.GetReadOnlyTools()...)
-			} else {
-				tools = append(tools, toolset.GetAllTools()...)
+				toolsetTools = toolset.GetReadOnlyTools()
+			}
+			for _, tool := range toolsetTools {
+				tool.Toolset = toolsetName
+				tools = append(tools, tool)
 			}
 		}
 	}
@@ -213,12 +227,14 @@ func (tr *ToolsetRegistry) GetRequiredScopes(enabledToolsets []string, readOnlyM
 	return scopes
 }
 
-// NewTool creates a new tool definition with annotations based on access level
+// NewTool creates a new tool definition with annotations based on access level, scoped to the
+// pipelines named by its pipeline_slug or url arguments
 func NewTool(tool mcp.Tool, handler server.ToolHandlerFunc, scopes []string) ToolDefinition {
 	return ToolDefinition{
 		Tool:           tool,
 		Handler:        handler,
 		RequiredScopes: scopes,
+		Scope:          policy.ScopeFromSchema(tool),
 	}
 }
 
@@ -231,6 +247,8 @@ const (
 	ToolsetLogs        = "logs"
 	ToolsetTests       = "tests"
 	ToolsetAnnotations = "annotations"
+	ToolsetAgents      = "agents"
+	ToolsetGraphQL     = "graphql"
 	ToolsetUser        = "user"
 )
 
@@ -243,6 +261,8 @@ var ValidToolsets = []string{
 	ToolsetLogs,
 	ToolsetTests,
 	ToolsetAnnotations,
+	ToolsetAgents,
+	ToolsetGraphQL,
 	ToolsetUser,
 }
 
@@ -267,8 +287,16 @@ func ValidateToolsets(names []string) error {
 	return nil
 }
 
+var (
+	// unscoped is the scope of tools which neither read nor change anything belonging to a pipeline
+	unscoped = policy.ToolScope{Unscoped: true}
+	// filtersResults is the scope of tools across an organization which leave out the results of
+	// pipelines the scope policy doesn't permit
+	filtersResults = policy.ToolScope{FiltersResults: true}
+)
+
 // CreateBuiltinToolsets creates the default toolsets with all available tools
-func CreateBuiltinToolsets(client *gobuildkite.Client, buildkiteLogsClient *buildkitelogs.Client) map[string]Toolset {
+func CreateBuiltinToolsets(client *gobuildkite.Client, buildkiteLogsClient buildkite.BuildkiteLogsClient, graphQLClient buildkite.GraphQLClient, redactor *redact.Redactor, artifactRetention time.Duration) map[string]Toolset {
 	// Create a client adapter for artifact tools
 	clientAdapter := &buildkite.BuildkiteClientAdapter{Client: client}
 
@@ -277,14 +305,34 @@ func CreateBuiltinToolsets(client *gobuildkite.Client, buildkiteLogsClient *buil
 			Name:        "Cluster Management",
 			Description: "Tools for managing Buildkite clusters and cluster queues",
 			Tools: []ToolDefinition{
-				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) { return buildkite.GetCluster(client.Clusters) }),
-				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) { return buildkite.ListClusters(client.Clusters) }),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) { return buildkite.GetCluster(client.Clusters) }).WithScope(unscoped),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) { return buildkite.ListClusters(client.Clusters) }).WithScope(unscoped),
 				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
 					return buildkite.GetClusterQueue(client.ClusterQueues)
-				}),
+				}).WithScope(unscoped),
 				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
 					return buildkite.ListClusterQueues(client.ClusterQueues)
+				}).WithScope(unscoped),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.GetQueueWaitTimes(client.Builds, client.ClusterQueues)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.CreateClusterQueue(client.ClusterQueues)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.PauseClusterQueue(client.ClusterQueues)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.ResumeClusterQueue(client.ClusterQueues)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
 				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.ListClusterAgentTokens(client.ClusterTokens)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}).WithScope(unscoped),
 			},
 		},
 		ToolsetPipelines: {
@@ -296,9 +344,21 @@ func CreateBuiltinToolsets(client *gobuildkite.Client, buildkiteLogsClient *buil
 					return tool, mcp.NewTypedToolHandler(handler), scopes
 				}),
 				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
-					tool, handler, scopes := buildkite.ListPipelines(client.Pipelines)
+					tool, handler, scopes := buildkite.LintPipelineAgainstCluster(client.Pipelines, client.Clusters, client.ClusterQueues)
 					return tool, mcp.NewTypedToolHandler(handler), scopes
 				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.DiffPipelines(client.Pipelines)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}).WithScope(policy.ToolScope{PipelineArgs: []string{"slug_a", "slug_b"}}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.ListStalePipelines(client.Pipelines, client.Builds)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}).WithScope(filtersResults),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.ListPipelines(client.Pipelines)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}).WithScope(filtersResults),
 				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
 					tool, handler, scopes := buildkite.CreatePipeline(client.Pipelines)
 					return tool, mcp.NewTypedToolHandler(handler), scopes
@@ -314,11 +374,31 @@ func CreateBuiltinToolsets(client *gobuildkite.Client, buildkiteLogsClient *buil
 			Description: "Tools for managing builds and jobs",
 			Tools: []ToolDefinition{
 				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
-					tool, handler, scopes := buildkite.ListBuilds(client.Builds)
+					tool, handler, scopes := buildkite.ListBuilds(client.Builds, client.User, clientAdapter, client.Annotations, redactor)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.SearchBuilds(client.Builds, client.User, clientAdapter)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}).WithScope(filtersResults),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.GetBranchStatusMatrix(client.Builds)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.GetBuild(client.Builds, client.TestRuns, client.Annotations, redactor)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.GetBuildsBatch(client.Builds)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.GetBuildMetadata(client.Builds, redactor)
 					return tool, mcp.NewTypedToolHandler(handler), scopes
 				}),
 				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
-					tool, handler, scopes := buildkite.GetBuild(client.Builds)
+					tool, handler, scopes := buildkite.GetBuildFullReport(client.Builds, client.Annotations, client.TestRuns)
 					return tool, mcp.NewTypedToolHandler(handler), scopes
 				}),
 				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
@@ -329,10 +409,22 @@ func CreateBuiltinToolsets(client *gobuildkite.Client, buildkiteLogsClient *buil
 					tool, handler, scopes := buildkite.CreateBuild(client.Builds)
 					return tool, mcp.NewTypedToolHandler(handler), scopes
 				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.CancelBuilds(client.Builds)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.RebuildFailedBuilds(client.Builds)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
 				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
 					tool, handler, scopes := buildkite.WaitForBuild(client.Builds)
 					return tool, mcp.NewTypedToolHandler(handler), scopes
 				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.WatchDeploy(client.Builds, buildkiteLogsClient, redactor)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
 				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
 					tool, handler, scopes := buildkite.GetJobs(client.Builds)
 					return tool, mcp.NewTypedToolHandler(handler), scopes
@@ -341,14 +433,92 @@ func CreateBuiltinToolsets(client *gobuildkite.Client, buildkiteLogsClient *buil
 					tool, handler, scopes := buildkite.UnblockJob(client.Jobs)
 					return tool, mcp.NewTypedToolHandler(handler), scopes
 				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.ClassifyBuildFailure(client.Builds, buildkiteLogsClient, redactor)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.ShouldRetry(client.Builds, buildkiteLogsClient, redactor)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.GetJobStepConfig(client.Builds, client.Pipelines)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.GetBuildChangeContext(client.Builds)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.FindFirstFailure(client.Builds)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.GetStepHistory(client.Builds)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.DetectFlakySteps(client.Builds)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.AnalyzeQueueContention(client.Builds, client.Pipelines)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.GetPipelineMetrics(client.Builds)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.GetStepTimings(client.Builds)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.GetJobAgentInfo(client.Builds, client.Agents)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
 			},
 		},
 		ToolsetArtifacts: {
 			Name:        "Artifact Management",
 			Description: "Tools for managing build artifacts",
 			Tools: []ToolDefinition{
-				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) { return buildkite.ListArtifacts(clientAdapter) }),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					return buildkite.ListArtifacts(clientAdapter, client.Builds, artifactRetention)
+				}),
 				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) { return buildkite.GetArtifact(clientAdapter) }),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.ListRecentArtifacts(client.Builds, clientAdapter, artifactRetention)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.DiffArtifacts(clientAdapter)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.HeadArtifact(clientAdapter, clientAdapter)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.DownloadArtifactToFile(clientAdapter, "")
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.DownloadArtifacts(clientAdapter, "")
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.SummarizeTerraformPlan(clientAdapter)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.SearchArtifact(clientAdapter, redactor)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.QueryArtifactLogs(clientAdapter, redactor, "")
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
 			},
 		},
 		ToolsetTests: {
@@ -361,6 +531,42 @@ func CreateBuiltinToolsets(client *gobuildkite.Client, buildkiteLogsClient *buil
 					return buildkite.GetFailedTestExecutions(client.TestRuns)
 				}),
 				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) { return buildkite.GetTest(client.Tests) }),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.GetTestExecutionEvidence(client.TestRuns, client.Builds, buildkiteLogsClient, clientAdapter, redactor)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.GetTestReliability(client.Tests, client.TestRuns)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.LinkBuildFailuresToTests(client.Builds, client.TestRuns, buildkiteLogsClient)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.DiffTestRuns(client.TestRuns)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.QuarantineTest(client.Tests, clientAdapter, client.Annotations)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.GetSuiteHealth(client.TestRuns, client.FlakyTests)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.ListFlakyTests(client.FlakyTests)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.GetTestStability(client.Tests, client.TestRuns, client.FlakyTests)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.GroupFailuresByOwner(client.TestRuns, clientAdapter)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
 			},
 		},
 		ToolsetLogs: {
@@ -368,11 +574,15 @@ func CreateBuiltinToolsets(client *gobuildkite.Client, buildkiteLogsClient *buil
 			Description: "Tools for searching, reading, and analyzing job logs",
 			Tools: []ToolDefinition{
 				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
-					tool, handler, scopes := buildkite.SearchLogs(buildkiteLogsClient)
+					tool, handler, scopes := buildkite.SearchLogs(buildkiteLogsClient, redactor)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.TailLogs(buildkiteLogsClient, redactor)
 					return tool, mcp.NewTypedToolHandler(handler), scopes
 				}),
 				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
-					tool, handler, scopes := buildkite.TailLogs(buildkiteLogsClient)
+					tool, handler, scopes := buildkite.FollowLogs(buildkiteLogsClient, client.Builds, redactor)
 					return tool, mcp.NewTypedToolHandler(handler), scopes
 				}),
 				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
@@ -380,7 +590,19 @@ func CreateBuiltinToolsets(client *gobuildkite.Client, buildkiteLogsClient *buil
 					return tool, mcp.NewTypedToolHandler(handler), scopes
 				}),
 				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
-					tool, handler, scopes := buildkite.ReadLogs(buildkiteLogsClient)
+					tool, handler, scopes := buildkite.ReadLogs(buildkiteLogsClient, redactor)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.SummarizeLog(buildkiteLogsClient, redactor)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.ReadLogGroup(buildkiteLogsClient, redactor)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.SearchLogsAcrossBuilds(client.Builds, buildkiteLogsClient, redactor)
 					return tool, mcp.NewTypedToolHandler(handler), scopes
 				}),
 			},
@@ -392,17 +614,75 @@ func CreateBuiltinToolsets(client *gobuildkite.Client, buildkiteLogsClient *buil
 				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
 					return buildkite.ListAnnotations(client.Annotations)
 				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.GetAnnotation(client.Annotations)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.CreateAnnotation(client.Annotations)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.UpdateAnnotation(client.Annotations)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.DeleteAnnotation(client.Annotations, clientAdapter)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+			},
+		},
+		ToolsetAgents: {
+			Name:        "Agent Management",
+			Description: "Tools for inspecting and stopping agents",
+			Tools: []ToolDefinition{
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.ListAgents(client.Agents)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.GetAgent(client.Agents)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.StopAgent(client.Agents)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+			},
+		},
+		ToolsetGraphQL: {
+			Name:        "GraphQL",
+			Description: "Tools for querying data only exposed by the Buildkite GraphQL API",
+			Tools: []ToolDefinition{
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.RunGraphQLQuery(graphQLClient)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.GetOrganizationUsage(graphQLClient)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					tool, handler, scopes := buildkite.GetPipelineDashboardMetrics(graphQLClient)
+					return tool, mcp.NewTypedToolHandler(handler), scopes
+				}),
 			},
 		},
 		ToolsetUser: {
 			Name:        "User & Organization",
 			Description: "Tools for user and organization information",
 			Tools: []ToolDefinition{
-				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) { return buildkite.CurrentUser(client.User) }),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) { return buildkite.CurrentUser(client.User) }).WithScope(unscoped),
 				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
 					return buildkite.UserTokenOrganization(client.Organizations)
+				}).WithScope(unscoped),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) { return buildkite.AccessToken(client.AccessTokens) }).WithScope(unscoped),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					return buildkite.ListOrganizationMembers(clientAdapter)
+				}).WithScope(unscoped),
+				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) {
+					return buildkite.ListTestSuites(client.TestSuites)
 				}),
-				newToolFromFunc(func() (mcp.Tool, server.ToolHandlerFunc, []string) { return buildkite.AccessToken(client.AccessTokens) }),
 			},
 		},
 	}
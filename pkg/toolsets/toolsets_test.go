@@ -391,6 +391,7 @@ func TestToolsetRegistry_GetEnabledTools(t *testing.T) {
 		tools := registry.GetEnabledTools([]string{"toolset1", "toolset2"}, true)
 		assert.Len(tools, 1)
 		assert.Equal("read-only-tool", tools[0].Tool.Name)
+		assert.Equal("toolset1", tools[0].Toolset)
 	})
 
 	t.Run("all toolsets enabled", func(t *testing.T) {
@@ -635,11 +636,11 @@ func TestCreateBuiltinToolsets(t *testing.T) {
 	client := &gobuildkite.Client{}
 
 	registry := NewToolsetRegistry()
-	builtin := CreateBuiltinToolsets(client, nil)
+	builtin := CreateBuiltinToolsets(client, nil, nil, nil, 0)
 	registry.RegisterToolsets(builtin)
 
 	// Check that expected toolsets are registered
-	expectedToolsets := []string{"clusters", "pipelines", "builds", "artifacts", "logs", "tests", "annotations", "user"}
+	expectedToolsets := []string{"clusters", "pipelines", "builds", "artifacts", "logs", "tests", "annotations", "agents", "graphql", "user"}
 	for _, name := range expectedToolsets {
 		_, exists := registry.Get(name)
 		assert.True(exists, "expected toolset %s to be registered", name)
@@ -0,0 +1,224 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/buildkite/buildkite-mcp-server/pkg/tokens"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// defaultDetailLevel tags responses of tools without a detail_level argument
+const defaultDetailLevel = "default"
+
+// NewMeterProvider returns a meter provider exporting with the same protocol as the traces, which
+// for noop records nothing, and to any other readers such as a Prometheus reader
+func NewMeterProvider(ctx context.Context, exporter, name, version string, readers ...sdkmetric.Reader) (*sdkmetric.MeterProvider, error) {
+	res, err := newResource(ctx, name, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource: %w", err)
+	}
+
+	options := []sdkmetric.Option{sdkmetric.WithResource(res)}
+	for _, reader := range readers {
+		options = append(options, sdkmetric.WithReader(reader))
+	}
+
+	var exp sdkmetric.Exporter
+	switch exporter {
+	case "http/protobuf":
+		exp, err = otlpmetrichttp.New(ctx)
+	case "grpc":
+		exp, err = otlpmetricgrpc.New(ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create metric exporter: %w", err)
+	}
+	if exp != nil {
+		options = append(options, sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exp)))
+	}
+
+	mp := sdkmetric.NewMeterProvider(options...)
+	otel.SetMeterProvider(mp)
+
+	return mp, nil
+}
+
+// NewPrometheusReader returns a metric reader for the meter provider along with a handler serving
+// what it reads in the Prometheus text format, together with the Go runtime and process metrics
+func NewPrometheusReader() (sdkmetric.Reader, http.Handler, error) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	reader, err := otelprometheus.New(otelprometheus.WithRegisterer(registry))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create prometheus exporter: %w", err)
+	}
+
+	return reader, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), nil
+}
+
+// recordToolCall records a tool call and how long it took, tagged by tool and whether it succeeded,
+// returned a tool error or failed
+func recordToolCall(ctx context.Context, request mcp.CallToolRequest, res *mcp.CallToolResult, err error, duration time.Duration) {
+	outcome := "ok"
+	switch {
+	case err != nil:
+		outcome = "error"
+	case res != nil && res.IsError:
+		outcome = "tool_error"
+	}
+
+	meter := otel.GetMeterProvider().Meter(tracerName)
+	attributes := metric.WithAttributes(
+		attribute.String("mcp.tool.name", request.Params.Name),
+		attribute.String("outcome", outcome),
+	)
+
+	if calls, err := meter.Int64Counter("mcp.tool.calls",
+		metric.WithUnit("{call}"),
+		metric.WithDescription("Tool calls, by tool and outcome")); err == nil {
+		calls.Add(ctx, 1, attributes)
+	}
+	if durations, err := meter.Float64Histogram("mcp.tool.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of tool calls")); err == nil {
+		durations.Record(ctx, duration.Seconds(), attributes)
+	}
+}
+
+// errorCountingTransport counts the Buildkite API requests which failed or were answered with an
+// error status
+type errorCountingTransport struct {
+	wrapped http.RoundTripper
+}
+
+func (t *errorCountingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.wrapped.RoundTrip(req)
+
+	errorType := ""
+	switch {
+	case err != nil:
+		errorType = "transport"
+	case resp.StatusCode >= http.StatusBadRequest:
+		errorType = strconv.Itoa(resp.StatusCode)
+	}
+	if errorType != "" {
+		meter := otel.GetMeterProvider().Meter(tracerName)
+		if failures, err := meter.Int64Counter("buildkite.api.errors",
+			metric.WithUnit("{request}"),
+			metric.WithDescription("Buildkite API requests which failed, by status code or transport for those without a response")); err == nil {
+			failures.Add(req.Context(), 1, metric.WithAttributes(attribute.String("error.type", errorType)))
+		}
+	}
+
+	return resp, err
+}
+
+// RecordLogCacheCheck records whether the logs of a job were found in the job logs cache
+func RecordLogCacheCheck(ctx context.Context, hit bool) {
+	meter := otel.GetMeterProvider().Meter(tracerName)
+	if checks, err := meter.Int64Counter("buildkite.logs.cache.checks",
+		metric.WithUnit("{check}"),
+		metric.WithDescription("Checks of the job logs cache, by whether the logs were cached")); err == nil {
+		checks.Add(ctx, 1, metric.WithAttributes(attribute.Bool("hit", hit)))
+	}
+}
+
+// RecordScrubMatches records the matches of each scrubbing rule in tool output, tagged by rule
+func RecordScrubMatches(ctx context.Context, hits map[string]int) {
+	if len(hits) == 0 {
+		return
+	}
+
+	meter := otel.GetMeterProvider().Meter(tracerName)
+	if matches, err := meter.Int64Counter("mcp.scrub.matches",
+		metric.WithUnit("{match}"),
+		metric.WithDescription("Matches of the scrubbing rules in tool output, by rule")); err == nil {
+		for rule, n := range hits {
+			matches.Add(ctx, int64(n), metric.WithAttributes(attribute.String("rule", rule)))
+		}
+	}
+}
+
+// RecordLogDownload records how long downloading and caching the logs of a job took, and their size
+func RecordLogDownload(ctx context.Context, duration time.Duration, size int64) {
+	meter := otel.GetMeterProvider().Meter(tracerName)
+	if durations, err := meter.Float64Histogram("buildkite.logs.download.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of downloading and caching job logs")); err == nil {
+		durations.Record(ctx, duration.Seconds())
+	}
+	if sizes, err := meter.Int64Histogram("buildkite.logs.download.size",
+		metric.WithUnit("By"),
+		metric.WithDescription("Size of downloaded job logs")); err == nil {
+		sizes.Record(ctx, size)
+	}
+}
+
+// recordResponseSize records how much of the client's context a tool response takes, as the size
+// and estimated tokens of its content, tagged by tool and detail level
+func recordResponseSize(ctx context.Context, span trace.Span, request mcp.CallToolRequest, res *mcp.CallToolResult) {
+	text := responseText(res)
+	size := int64(len(text))
+	estimatedTokens := int64(tokens.CountTokens(ctx, text))
+
+	span.SetAttributes(
+		attribute.Int64("mcp.response.bytes", size),
+		attribute.Int64("mcp.response.tokens", estimatedTokens),
+	)
+
+	meter := otel.GetMeterProvider().Meter(tracerName)
+	attributes := metric.WithAttributes(
+		attribute.String("mcp.tool.name", request.Params.Name),
+		attribute.String("detail_level", request.GetString("detail_level", defaultDetailLevel)),
+	)
+
+	if sizes, err := meter.Int64Histogram("mcp.tool.response.size",
+		metric.WithUnit("By"),
+		metric.WithDescription("Size of tool responses")); err == nil {
+		sizes.Record(ctx, size, attributes)
+	}
+	if counts, err := meter.Int64Histogram("mcp.tool.response.tokens",
+		metric.WithUnit("{token}"),
+		metric.WithDescription("Estimated tokens of tool responses")); err == nil {
+		counts.Record(ctx, estimatedTokens, attributes)
+	}
+}
+
+// responseText returns the text content of a response, or its structured content when it has none
+func responseText(res *mcp.CallToolResult) string {
+	var text string
+	for _, content := range res.Content {
+		if textContent, ok := content.(mcp.TextContent); ok {
+			text += textContent.Text
+		}
+	}
+
+	if text == "" && res.StructuredContent != nil {
+		data, err := json.Marshal(res.StructuredContent)
+		if err == nil {
+			text = string(data)
+		}
+	}
+
+	return text
+}
@@ -0,0 +1,40 @@
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+type requestIDKey struct{}
+
+// WithRequestID records the correlation ID of a tool call on the context
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the correlation ID of the tool call being handled, or "" outside of one
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// setResultRequestID adds the correlation ID to the _meta of a tool result, so users can quote it
+// when reporting a problem
+func setResultRequestID(result *mcp.CallToolResult, requestID string) {
+	if result.Meta == nil {
+		result.Meta = &mcp.Meta{}
+	}
+	if result.Meta.AdditionalFields == nil {
+		result.Meta.AdditionalFields = map[string]any{}
+	}
+	result.Meta.AdditionalFields["request_id"] = requestID
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
@@ -80,7 +82,7 @@ func NewHTTPClientWithHeaders(headers map[string]string) *http.Client {
 	return &http.Client{
 		Transport: &headerInjector{
 			headers: headers,
-			wrapped: otelhttp.NewTransport(http.DefaultTransport),
+			wrapped: &errorCountingTransport{wrapped: otelhttp.NewTransport(http.DefaultTransport)},
 		},
 	}
 }
@@ -144,21 +146,38 @@ func ToolHandlerFunc(thf server.ToolHandlerFunc) server.ToolHandlerFunc {
 		ctx, span := Start(ctx, "mcp.ToolHandler")
 		defer span.End()
 
+		requestID := newRequestID()
+		ctx = WithRequestID(ctx, requestID)
+
 		span.SetAttributes(
 			attribute.String("mcp.method.name", request.Method),
 			attribute.String("mcp.tool.name", request.Params.Name),
+			attribute.String("mcp.request.id", requestID),
 		)
 
-		log.Debug().Str("mcp.tool.name", request.Params.Name).Msg("Handling MCP tool call")
+		logger := zerolog.Ctx(ctx)
+		if logger.GetLevel() == zerolog.Disabled {
+			logger = &log.Logger
+		}
+		ctx = logger.With().Str("request_id", requestID).Logger().WithContext(ctx)
+
+		log.Ctx(ctx).Debug().Str("mcp.tool.name", request.Params.Name).Msg("Handling MCP tool call")
 
+		started := time.Now()
 		res, err := thf(ctx, request)
+		recordToolCall(ctx, request, res, err, time.Since(started))
 		if err != nil {
 			span.RecordError(err)
 			span.SetStatus(codes.Error, err.Error())
-			log.Error().Err(err).Str("mcp.tool.name", request.Params.Name).Msg("Error in MCP tool call")
+			log.Ctx(ctx).Error().Err(err).Str("mcp.tool.name", request.Params.Name).Msg("Error in MCP tool call")
 		} else {
 			span.SetStatus(codes.Ok, "OK")
-			log.Debug().Str("mcp.tool.name", request.Params.Name).Msg("Completed MCP tool call successfully")
+			log.Ctx(ctx).Debug().Str("mcp.tool.name", request.Params.Name).Msg("Completed MCP tool call successfully")
+		}
+
+		if res != nil {
+			recordResponseSize(ctx, span, request, res)
+			setResultRequestID(res, requestID)
 		}
 
 		return res, err
@@ -2,9 +2,15 @@ package trace
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 
+	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 )
 
 func TestNewProvider(t *testing.T) {
@@ -24,3 +30,94 @@ func TestNewProvider(t *testing.T) {
 	assert.NoError(err)
 
 }
+
+func TestToolHandlerFuncRequestID(t *testing.T) {
+	assert := require.New(t)
+
+	var handlerRequestID string
+	handler := ToolHandlerFunc(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		handlerRequestID = RequestIDFromContext(ctx)
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "list_builds"
+
+	result, err := handler(context.Background(), request)
+	assert.NoError(err)
+	assert.Len(handlerRequestID, 32)
+	assert.Equal(handlerRequestID, result.Meta.AdditionalFields["request_id"])
+
+	data, err := json.Marshal(result)
+	assert.NoError(err)
+	assert.Contains(string(data), `"_meta":{"request_id":"`+handlerRequestID+`"}`)
+
+	other, err := handler(context.Background(), request)
+	assert.NoError(err)
+	assert.NotEqual(result.Meta.AdditionalFields["request_id"], other.Meta.AdditionalFields["request_id"])
+}
+
+func TestToolHandlerFuncResponseSize(t *testing.T) {
+	assert := require.New(t)
+
+	reader := sdkmetric.NewManualReader()
+	previous := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	defer otel.SetMeterProvider(previous)
+
+	handler := ToolHandlerFunc(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("three short words"), nil
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "list_builds"
+	request.Params.Arguments = map[string]any{"detail_level": "full"}
+
+	_, err := handler(context.Background(), request)
+	assert.NoError(err)
+
+	var metrics metricdata.ResourceMetrics
+	assert.NoError(reader.Collect(context.Background(), &metrics))
+
+	recorded := map[string]metricdata.HistogramDataPoint[int64]{}
+	var calls []metricdata.DataPoint[int64]
+	var durations []metricdata.HistogramDataPoint[float64]
+	for _, scope := range metrics.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Histogram[int64]:
+				assert.Len(data.DataPoints, 1)
+				recorded[m.Name] = data.DataPoints[0]
+			case metricdata.Sum[int64]:
+				assert.Equal("mcp.tool.calls", m.Name)
+				calls = data.DataPoints
+			case metricdata.Histogram[float64]:
+				assert.Equal("mcp.tool.duration", m.Name)
+				durations = data.DataPoints
+			default:
+				assert.Failf("unexpected metric", "%s", m.Name)
+			}
+		}
+	}
+
+	// the call is counted along with its duration
+	assert.Len(calls, 1)
+	assert.Equal(int64(1), calls[0].Value)
+	outcome, ok := calls[0].Attributes.Value(attribute.Key("outcome"))
+	assert.True(ok)
+	assert.Equal("ok", outcome.AsString())
+	assert.Len(durations, 1)
+	assert.Equal(uint64(1), durations[0].Count)
+
+	assert.Equal(int64(len("three short words")), recorded["mcp.tool.response.size"].Sum)
+	assert.Equal(int64(6), recorded["mcp.tool.response.tokens"].Sum)
+
+	size := recorded["mcp.tool.response.size"]
+	detailLevel, ok := size.Attributes.Value(attribute.Key("detail_level"))
+	assert.True(ok)
+	assert.Equal("full", detailLevel.AsString())
+	tokens := recorded["mcp.tool.response.tokens"]
+	tool, ok := tokens.Attributes.Value(attribute.Key("mcp.tool.name"))
+	assert.True(ok)
+	assert.Equal("list_builds", tool.AsString())
+}
@@ -0,0 +1,336 @@
+// Package webhook receives Buildkite webhook events and passes them on to the MCP sessions
+// which subscribed to them, so agents can react to builds finishing or jobs failing rather
+// than polling for them.
+package webhook
+
+import (
+	"cmp"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	EventBuildScheduled = "build.scheduled"
+	EventBuildRunning   = "build.running"
+	EventBuildFailing   = "build.failing"
+	EventBuildFinished  = "build.finished"
+	EventJobStarted     = "job.started"
+	EventJobFinished    = "job.finished"
+	// EventBuildFailed and EventJobFailed aren't sent by Buildkite, they match the finished
+	// events of builds and jobs which failed
+	EventBuildFailed = "build.failed"
+	EventJobFailed   = "job.failed"
+
+	// maxBodyBytes is the largest webhook payload read, well above the size of build and job events
+	maxBodyBytes = 1 << 20
+	// maxSignatureAge is how old a signed event may be, so a captured request can't be replayed later
+	maxSignatureAge = 5 * time.Minute
+)
+
+// Events are the events sessions may subscribe to
+var Events = []string{EventBuildScheduled, EventBuildRunning, EventBuildFailing, EventBuildFinished, EventBuildFailed, EventJobStarted, EventJobFinished, EventJobFailed}
+
+// DefaultEvents are the events a subscription receives when it doesn't name any
+var DefaultEvents = []string{EventBuildFinished, EventJobFailed}
+
+// Event is a build or job event received from Buildkite
+type Event struct {
+	Type         string `json:"type"`
+	Organization string `json:"org_slug"`
+	Pipeline     string `json:"pipeline_slug"`
+	BuildNumber  int    `json:"build_number"`
+	BuildState   string `json:"build_state"`
+	Branch       string `json:"branch"`
+	Commit       string `json:"commit"`
+	Message      string `json:"message"`
+	BuildURL     string `json:"build_url"`
+	JobID        string `json:"job_id,omitempty"`
+	JobLabel     string `json:"job_label,omitempty"`
+	JobState     string `json:"job_state,omitempty"`
+	ExitStatus   *int   `json:"exit_status,omitempty"`
+	// Failed is whether the finished build or job failed, soft failed jobs aren't failed
+	Failed     bool      `json:"failed"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// Is returns whether the event is of the type, including the failed events derived from finished ones
+func (e Event) Is(eventType string) bool {
+	switch eventType {
+	case EventBuildFailed:
+		return e.Type == EventBuildFinished && e.Failed
+	case EventJobFailed:
+		return e.Type == EventJobFinished && e.Failed
+	default:
+		return e.Type == eventType
+	}
+}
+
+// Filter selects the events a session receives
+type Filter struct {
+	Events []string `json:"events"`
+	// Pipelines are pipeline slug patterns, matched using path.Match syntax, which may be qualified
+	// with the organization slug (e.g. "my-org/deploy-*")
+	Pipelines []string `json:"pipelines,omitempty"`
+	Branches  []string `json:"branches,omitempty"`
+}
+
+// Matches returns whether the filter selects the event
+func (f Filter) Matches(event Event) bool {
+	if !slices.ContainsFunc(f.Events, event.Is) {
+		return false
+	}
+	if len(f.Branches) > 0 && !slices.Contains(f.Branches, event.Branch) {
+		return false
+	}
+	if len(f.Pipelines) == 0 {
+		return true
+	}
+	for _, pattern := range f.Pipelines {
+		if matched, _ := path.Match(pattern, event.Pipeline); matched {
+			return true
+		}
+		if matched, _ := path.Match(pattern, event.Organization+"/"+event.Pipeline); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Sender delivers an event to a session
+type Sender func(sessionID string, event Event) error
+
+// Receiver is an http.Handler receiving Buildkite webhooks, authenticated by the webhook's token or
+// signature, which sends each event to the sessions whose filter matches it
+type Receiver struct {
+	token string
+	now   func() time.Time
+
+	mu            sync.Mutex
+	send          Sender
+	subscriptions map[string]Filter
+}
+
+// NewReceiver returns a receiver accepting webhooks carrying the token, or signed with it
+func NewReceiver(token string) *Receiver {
+	return &Receiver{
+		token:         token,
+		now:           time.Now,
+		subscriptions: map[string]Filter{},
+	}
+}
+
+// SetSender sets how events are delivered to sessions, they are dropped until it is set
+func (r *Receiver) SetSender(send Sender) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.send = send
+}
+
+// Subscribe replaces the filter of the session's subscription
+func (r *Receiver) Subscribe(sessionID string, filter Filter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscriptions[sessionID] = filter
+}
+
+// Unsubscribe ends the session's subscription, returning whether it had one
+func (r *Receiver) Unsubscribe(sessionID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.subscriptions[sessionID]
+	delete(r.subscriptions, sessionID)
+	return ok
+}
+
+func (r *Receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxBodyBytes))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	if err := r.authenticate(req.Header, body); err != nil {
+		log.Ctx(req.Context()).Warn().Err(err).Msg("Rejected webhook")
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	eventType := req.Header.Get("X-Buildkite-Event")
+	event, ok, err := parseEvent(eventType, body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// pings and events no session can subscribe to are acknowledged and ignored
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	event.ReceivedAt = r.now()
+
+	delivered := r.dispatch(event)
+
+	log.Ctx(req.Context()).Debug().Str("event", event.Type).Str("pipeline", event.Pipeline).Int("build", event.BuildNumber).Int("sessions", delivered).Msg("Received webhook")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// dispatch sends the event to each subscribed session whose filter matches it, returning how many
+// it was delivered to
+func (r *Receiver) dispatch(event Event) int {
+	r.mu.Lock()
+	send := r.send
+	var sessions []string
+	for sessionID, filter := range r.subscriptions {
+		if filter.Matches(event) {
+			sessions = append(sessions, sessionID)
+		}
+	}
+	r.mu.Unlock()
+
+	if send == nil {
+		return 0
+	}
+
+	delivered := 0
+	for _, sessionID := range sessions {
+		if err := send(sessionID, event); err != nil {
+			log.Warn().Err(err).Str("session_id", sessionID).Msg("Failed to send webhook event to session")
+			continue
+		}
+		delivered++
+	}
+	return delivered
+}
+
+// authenticate checks the request carries the webhook's token in X-Buildkite-Token, or is signed
+// with it in X-Buildkite-Signature
+func (r *Receiver) authenticate(header http.Header, body []byte) error {
+	if signature := header.Get("X-Buildkite-Signature"); signature != "" {
+		return r.verifySignature(signature, body)
+	}
+
+	token := header.Get("X-Buildkite-Token")
+	if token == "" {
+		return errors.New("missing X-Buildkite-Token or X-Buildkite-Signature header")
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(r.token)) != 1 {
+		return errors.New("invalid webhook token")
+	}
+	return nil
+}
+
+// verifySignature checks a signature of the form timestamp=<unix seconds>,signature=<hex HMAC-SHA256
+// of timestamp.body>
+func (r *Receiver) verifySignature(header string, body []byte) error {
+	var timestamp, signature string
+	for part := range strings.SplitSeq(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "timestamp":
+			timestamp = value
+		case "signature":
+			signature = value
+		}
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("invalid webhook signature timestamp")
+	}
+	if age := r.now().Sub(time.Unix(seconds, 0)); age > maxSignatureAge || age < -maxSignatureAge {
+		return errors.New("webhook signature has expired")
+	}
+
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return errors.New("invalid webhook signature")
+	}
+
+	mac := hmac.New(sha256.New, []byte(r.token))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return errors.New("invalid webhook signature")
+	}
+	return nil
+}
+
+type payload struct {
+	Event    string              `json:"event"`
+	Build    *buildkite.Build    `json:"build"`
+	Job      *buildkite.Job      `json:"job"`
+	Pipeline *buildkite.Pipeline `json:"pipeline"`
+}
+
+// parseEvent parses a build or job event, returning false for other events such as pings
+func parseEvent(eventType string, body []byte) (Event, bool, error) {
+	var p payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return Event{}, false, errors.New("invalid webhook payload")
+	}
+	if eventType == "" {
+		eventType = p.Event
+	}
+	// the failed events are derived from the finished events rather than received
+	if !slices.Contains(Events, eventType) || eventType == EventBuildFailed || eventType == EventJobFailed || p.Build == nil {
+		return Event{}, false, nil
+	}
+
+	event := Event{
+		Type:        eventType,
+		BuildNumber: p.Build.Number,
+		BuildState:  p.Build.State,
+		Branch:      p.Build.Branch,
+		Commit:      p.Build.Commit,
+		Message:     p.Build.Message,
+		BuildURL:    p.Build.WebURL,
+	}
+	if p.Pipeline != nil {
+		event.Organization = organization(p.Pipeline.URL)
+		event.Pipeline = p.Pipeline.Slug
+	}
+
+	if p.Job != nil {
+		event.JobID = p.Job.ID
+		event.JobLabel = cmp.Or(p.Job.Label, p.Job.Name)
+		event.JobState = p.Job.State
+		event.ExitStatus = p.Job.ExitStatus
+		if eventType == EventJobFinished {
+			event.Failed = !p.Job.SoftFailed && (p.Job.State == "failed" || (p.Job.ExitStatus != nil && *p.Job.ExitStatus != 0))
+		}
+	} else if eventType == EventBuildFinished {
+		event.Failed = p.Build.State == "failed"
+	}
+
+	return event, true, nil
+}
+
+// organization returns the organization slug from the API URL of a pipeline
+func organization(pipelineURL string) string {
+	_, rest, ok := strings.Cut(pipelineURL, "/organizations/")
+	if !ok {
+		return ""
+	}
+	org, _, _ := strings.Cut(rest, "/")
+	return org
+}
@@ -0,0 +1,132 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const jobFinished = `{
+	"event": "job.finished",
+	"job": {"id": "job-1", "label": ":rspec: Test", "state": "failed", "exit_status": 1},
+	"build": {"number": 42, "state": "failing", "branch": "main", "commit": "abc123", "message": "Fix tests", "web_url": "https://buildkite.com/acme/web/builds/42"},
+	"pipeline": {"slug": "web", "url": "https://api.buildkite.com/v2/organizations/acme/pipelines/web"}
+}`
+
+// newTestReceiver returns a receiver recording the events it sends to each session
+func newTestReceiver() (*Receiver, map[string][]Event) {
+	sent := map[string][]Event{}
+	receiver := NewReceiver("secret")
+	receiver.SetSender(func(sessionID string, event Event) error {
+		sent[sessionID] = append(sent[sessionID], event)
+		return nil
+	})
+	return receiver, sent
+}
+
+func postWebhook(receiver *Receiver, eventType, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header = header
+	req.Header.Set("X-Buildkite-Event", eventType)
+	rec := httptest.NewRecorder()
+	receiver.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestReceiverSendsMatchingEvents(t *testing.T) {
+	assert := require.New(t)
+
+	receiver, sent := newTestReceiver()
+	receiver.Subscribe("failures", Filter{Events: DefaultEvents})
+	receiver.Subscribe("deploys", Filter{Events: []string{EventJobFinished}, Pipelines: []string{"acme/deploy-*"}})
+	receiver.Subscribe("main", Filter{Events: []string{EventJobFinished}, Pipelines: []string{"web"}, Branches: []string{"main"}})
+
+	rec := postWebhook(receiver, EventJobFinished, jobFinished, http.Header{"X-Buildkite-Token": {"secret"}})
+	assert.Equal(http.StatusNoContent, rec.Code)
+
+	assert.Len(sent["failures"], 1)
+	event := sent["failures"][0]
+	assert.Equal(EventJobFinished, event.Type)
+	assert.True(event.Failed)
+	assert.Equal("acme", event.Organization)
+	assert.Equal("web", event.Pipeline)
+	assert.Equal(42, event.BuildNumber)
+	assert.Equal(":rspec: Test", event.JobLabel)
+	assert.Equal(1, *event.ExitStatus)
+
+	assert.Empty(sent["deploys"])
+	assert.Len(sent["main"], 1)
+
+	// ended subscriptions receive nothing more
+	assert.True(receiver.Unsubscribe("failures"))
+	assert.False(receiver.Unsubscribe("failures"))
+	postWebhook(receiver, EventJobFinished, jobFinished, http.Header{"X-Buildkite-Token": {"secret"}})
+	assert.Len(sent["failures"], 1)
+	assert.Len(sent["main"], 2)
+}
+
+func TestReceiverAuthenticates(t *testing.T) {
+	assert := require.New(t)
+
+	receiver, sent := newTestReceiver()
+	receiver.Subscribe("session", Filter{Events: DefaultEvents})
+
+	rec := postWebhook(receiver, EventJobFinished, jobFinished, http.Header{})
+	assert.Equal(http.StatusUnauthorized, rec.Code)
+	rec = postWebhook(receiver, EventJobFinished, jobFinished, http.Header{"X-Buildkite-Token": {"wrong"}})
+	assert.Equal(http.StatusUnauthorized, rec.Code)
+
+	sign := func(timestamp int64, body string) string {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		fmt.Fprintf(mac, "%d.%s", timestamp, body)
+		return fmt.Sprintf("timestamp=%d,signature=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	now := time.Now().Unix()
+	rec = postWebhook(receiver, EventJobFinished, jobFinished, http.Header{"X-Buildkite-Signature": {sign(now, jobFinished)}})
+	assert.Equal(http.StatusNoContent, rec.Code)
+
+	// signatures of other bodies, or from long ago, are rejected
+	rec = postWebhook(receiver, EventJobFinished, jobFinished, http.Header{"X-Buildkite-Signature": {sign(now, "{}")}})
+	assert.Equal(http.StatusUnauthorized, rec.Code)
+	rec = postWebhook(receiver, EventJobFinished, jobFinished, http.Header{"X-Buildkite-Signature": {sign(now-3600, jobFinished)}})
+	assert.Equal(http.StatusUnauthorized, rec.Code)
+
+	assert.Len(sent["session"], 1)
+}
+
+func TestReceiverIgnoresOtherEvents(t *testing.T) {
+	assert := require.New(t)
+
+	receiver, sent := newTestReceiver()
+	receiver.Subscribe("session", Filter{Events: Events})
+
+	rec := postWebhook(receiver, "ping", `{"event":"ping"}`, http.Header{"X-Buildkite-Token": {"secret"}})
+	assert.Equal(http.StatusNoContent, rec.Code)
+	// failed events are only derived from finished events
+	rec = postWebhook(receiver, EventJobFailed, jobFinished, http.Header{"X-Buildkite-Token": {"secret"}})
+	assert.Equal(http.StatusNoContent, rec.Code)
+	assert.Empty(sent)
+
+	rec = postWebhook(receiver, EventJobFinished, "not json", http.Header{"X-Buildkite-Token": {"secret"}})
+	assert.Equal(http.StatusBadRequest, rec.Code)
+}
+
+func TestEventIs(t *testing.T) {
+	assert := require.New(t)
+
+	passed := Event{Type: EventBuildFinished}
+	failed := Event{Type: EventBuildFinished, Failed: true}
+	assert.True(passed.Is(EventBuildFinished))
+	assert.False(passed.Is(EventBuildFailed))
+	assert.True(failed.Is(EventBuildFailed))
+	assert.False(failed.Is(EventJobFailed))
+}